@@ -0,0 +1,82 @@
+package app
+
+import (
+	"claude-squad/cmd"
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/session/tmux"
+	"claude-squad/ui/overlay"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// showAdoptPicker lists the tmux sessions claude-squad didn't start, so the user can bring one, e.g. an agent
+// they started by hand, under management.
+func (m *home) showAdoptPicker() (tea.Model, tea.Cmd) {
+	sessions, err := tmux.ListExternalSessions(cmd.MakeExecutor())
+	if err != nil {
+		return m, m.handleError(err)
+	}
+	if len(sessions) == 0 {
+		return m, m.handleError(fmt.Errorf("no tmux sessions to adopt, sessions claude-squad started aren't listed"))
+	}
+
+	items := make([]overlay.SelectionItem, 0, len(sessions))
+	for _, s := range sessions {
+		items = append(items, overlay.SelectionItem{Label: s.Name, Description: s.Command + " in " + s.Dir})
+	}
+	m.pickerSessions = sessions
+	m.selectionOverlay = overlay.NewSelectionOverlay("Adopt a tmux session", items)
+	m.state = stateAdoptPicker
+	return m, nil
+}
+
+// handleAdoptPickerState handles key presses while the adopt picker is shown. The picked session becomes an
+// instance titled after it.
+func (m *home) handleAdoptPickerState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.selectionOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+	selected := m.selectionOverlay.Selected()
+	sessions := m.pickerSessions
+	m.selectionOverlay = nil
+	m.pickerSessions = nil
+	m.state = stateDefault
+	if selected < 0 {
+		return m, tea.WindowSize()
+	}
+	return m, tea.Batch(tea.WindowSize(), m.adoptSession(sessions[selected]))
+}
+
+// adoptSession adopts the tmux session s as a new instance and selects it.
+func (m *home) adoptSession(s tmux.ExternalSession) tea.Cmd {
+	if m.list.NumInstances() >= GlobalInstanceLimit {
+		return m.handleError(fmt.Errorf("you can't create more than %d instances", GlobalInstanceLimit))
+	}
+	title := s.Name
+	if len(title) > maxTitleLength {
+		title = title[:maxTitleLength]
+	}
+	for _, instance := range m.list.GetInstances() {
+		if instance.Title == title {
+			return m.handleError(fmt.Errorf("there's already a session named %s", title))
+		}
+	}
+
+	instance, err := session.AdoptTmuxSession(session.AdoptOptions{Title: title, Session: s})
+	if err != nil {
+		return m.handleError(err)
+	}
+	m.list.AddInstance(instance)()
+	m.list.SetSelectedInstance(m.list.NumInstances() - 1)
+	if err := m.trackRepository(instance); err != nil {
+		log.WarningLog.Printf("failed to track repository: %v", err)
+	}
+	m.showWorkspaceOf(instance)
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		return m.handleError(err)
+	}
+	return tea.Batch(m.instanceChanged(), m.handleInfo(fmt.Sprintf("adopted %s, it was renamed to %s in tmux",
+		s.Name, instance.TmuxSessionName())))
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"fmt"
+	"os"
+	"os/exec"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// alert plays the configured alert for an instance's state change, unless the instance is muted, snoozed
+// or attached to, or the same event was already alerted since the instance last produced output.
+func (m *home) alert(instance *session.Instance, event string) tea.Cmd {
+	if !m.appConfig.Alerts.Enabled(event) || m.alerted[instance] == event {
+		return nil
+	}
+	if m.alerted == nil {
+		m.alerted = make(map[*session.Instance]string)
+	}
+	// Mark the event as alerted anyway, so it isn't played late once the snooze ends.
+	m.alerted[instance] = event
+	if instance.Muted || instance.Snoozed() || instance.Attached() {
+		return nil
+	}
+
+	command := m.appConfig.Alerts.Command
+	if command == "" {
+		fmt.Fprint(os.Stdout, "\a")
+		return nil
+	}
+	run := func() error {
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Env = append(os.Environ(), "CS_INSTANCE="+instance.Title, "CS_EVENT="+event)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("alert command failed: %w: %s", err, output)
+		}
+		return nil
+	}
+	return func() tea.Msg {
+		err := run()
+		if err == nil {
+			return nil
+		}
+		// A command that notifies elsewhere, e.g. through a webhook, is run again once the network is back.
+		if git.IsNetworkError(err) {
+			return m.queueIfOffline(fmt.Sprintf("%s alert of '%s'", event, instance.Title), err, run)
+		}
+		log.WarningLog.Printf("%v", err)
+		return nil
+	}
+}
+
+// toggleMuted mutes or unmutes the selected instance's alerts.
+func (m *home) toggleMuted() tea.Cmd {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return nil
+	}
+	selected.Muted = !selected.Muted
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		return m.handleError(err)
+	}
+	if selected.Muted {
+		return m.handleInfo(fmt.Sprintf("alerts muted for '%s'", selected.Title))
+	}
+	if len(m.appConfig.Alerts.Events) == 0 {
+		return m.handleInfo(fmt.Sprintf("alerts unmuted for '%s', but no alert events are configured", selected.Title))
+	}
+	return m.handleInfo(fmt.Sprintf("alerts unmuted for '%s'", selected.Title))
+}
@@ -2,15 +2,29 @@ package app
 
 import (
 	"claude-squad/config"
+	"claude-squad/digest"
+	"claude-squad/health"
+	"claude-squad/hooks"
 	"claude-squad/keys"
 	"claude-squad/log"
+	"claude-squad/network"
+	"claude-squad/plugin"
+	"claude-squad/power"
+	"claude-squad/prompt"
+	"claude-squad/retry"
 	"claude-squad/session"
+	"claude-squad/stats"
+	"claude-squad/telemetry"
+	"claude-squad/session/git"
+	"claude-squad/session/tmux"
 	"claude-squad/ui"
 	"claude-squad/ui/overlay"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -21,13 +35,26 @@ import (
 const GlobalInstanceLimit = 10
 
 // Run is the main entrypoint into the application.
-func Run(ctx context.Context, program string, autoYes bool, targetDir string) error {
+// Run runs the TUI. programGiven is whether program was given with --program, overriding the repositories'
+// default programs. identity, if set, overrides the commit identity of the instances created in it.
+func Run(ctx context.Context, program string, programGiven bool, autoYes bool, targetDir string, identity config.CommitIdentity) error {
+	h := newHome(ctx, program, autoYes, targetDir)
+	h.programGiven = programGiven
+	h.identity = identity
+	if h.appConfig.TerminalTitle {
+		saveTerminalTitle()
+		defer restoreTerminalTitle()
+	}
 	p := tea.NewProgram(
-		newHome(ctx, program, autoYes, targetDir),
+		h,
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(), // Mouse scroll
 	)
 	_, err := p.Run()
+	// Mutations batched after the last flush, e.g. when the program was interrupted, are saved on the way out.
+	if flushErr := h.flushState(); flushErr != nil {
+		log.ErrorLog.Printf("failed to save state: %v", flushErr)
+	}
 	return err
 }
 
@@ -45,6 +72,38 @@ const (
 	stateConfirm
 	// stateDirectoryPicker is the state when the directory picker is displayed.
 	stateDirectoryPicker
+	// stateCompare is the state when the comparison of two instances is displayed.
+	stateCompare
+	// stateStalled is the state when the actions for a stalled instance are displayed.
+	stateStalled
+	// stateTaskPicker is the state when the task library is displayed.
+	stateTaskPicker
+	// statePlanReview is the state when the user is reviewing an instance's plan.
+	statePlanReview
+	// stateDatabase is the state when the state of an instance's database and its actions are displayed.
+	stateDatabase
+	// stateSnooze is the state when the user is picking how long to snooze an instance for.
+	stateSnooze
+	// statePalette is the state when the plugins' commands are displayed.
+	statePalette
+	// stateTicket is the state when the user is entering the ticket to create an instance from.
+	stateTicket
+	// stateStats is the state when the repository stats are displayed.
+	stateStats
+	// stateRepoPicker is the state when the user is picking the repository of the instance being named.
+	stateRepoPicker
+	// stateBranchPicker is the state when the user is picking the existing branch to create an instance from.
+	stateBranchPicker
+	// stateAdoptPicker is the state when the user is picking the tmux session to adopt.
+	stateAdoptPicker
+	// stateBudget is the state when the user is entering an instance's budget.
+	stateBudget
+	// stateWorkspace is the state when the user is picking the workspace to show.
+	stateWorkspace
+	// stateReview is the state when the weekly review is displayed.
+	stateReview
+	// stateProgram is the state when the user is editing the program of the instance being named.
+	stateProgram
 )
 
 type home struct {
@@ -53,15 +112,37 @@ type home struct {
 	// -- Storage and Configuration --
 
 	program string
+	// programGiven is whether program was given with --program
+	programGiven bool
 	autoYes bool
 	targetDir string
+	// identity overrides the commit identity of the instances created, if set
+	identity config.CommitIdentity
 
 	// storage is the interface for saving/loading data to/from the app's state
 	storage *session.Storage
 	// appConfig stores persistent application configuration
 	appConfig *config.Config
 	// appState stores persistent application state like seen help screens
-	appState config.AppState
+	appState config.StateManager
+	// terminalTitle is the last summary the terminal title was set to
+	terminalTitle string
+	// alerted is the last alert played for each instance since it last produced output
+	alerted map[*session.Instance]string
+	// narrow is set when the terminal is too narrow to show the list and the preview side by side, in
+	// which case only one of them is shown
+	narrow bool
+	// showPreview shows the preview instead of the list in a narrow terminal
+	showPreview bool
+	// mergesCheckedAt is when the instances' branches were last checked for merges
+	mergesCheckedAt time.Time
+	// restoreErr describes the stored instances that failed to restore on launch
+	restoreErr error
+	// resizeSeq identifies the latest window resize, so only the last of a burst of resizes is applied to
+	// the tmux sessions
+	resizeSeq int
+	// lowPower is set while refreshes are slowed down to save battery
+	lowPower bool
 
 	// -- State --
 
@@ -73,10 +154,61 @@ type home struct {
 
 	// promptAfterName tracks if we should enter prompt mode after naming
 	promptAfterName bool
+	// taskPrompt is the prompt of the task picked from the task library. It pre-fills the prompt overlay.
+	taskPrompt string
+	// taskTicket is the key of the ticket the instance being created is for, if any.
+	taskTicket string
+	// taskName is the name of the task the instance being created was started from, if any.
+	taskName string
+	// tasks are the tasks listed in the task picker
+	tasks []prompt.Task
+	// statsReport is the repository stats being displayed
+	statsReport stats.Report
+	// review is the weekly review being displayed
+	review stats.Review
+	// hint is the ID of the hint shown below the menu, if any
+	hint string
+	// pickerRepos are the repositories listed in the repository picker
+	pickerRepos []string
+	// naming is set when the repository picker was opened while naming a new instance
+	naming bool
+	// pickerBranches are the branches listed in the branch picker, and pickerBranchRepo their repository
+	pickerBranches   []git.Branch
+	pickerBranchRepo string
+	// pickerSessions are the tmux sessions listed in the adopt picker
+	pickerSessions []tmux.ExternalSession
+	// planMode is set when the instance being created should write a plan for approval before making changes.
+	planMode bool
 
 	// keySent is used to manage underlining menu items
 	keySent bool
 
+	// confirmResult holds the message returned by a confirmed action until it is handed back to the update loop.
+	confirmResult tea.Msg
+
+	// retryQueue holds network operations waiting to be retried while offline.
+	retryQueue *retry.Queue
+
+	// pendingPrompt is a prompt waiting for the user to fill in its template variables.
+	pendingPrompt *pendingPrompt
+
+	// compareBase is the instance marked as the left-hand side of a comparison.
+	compareBase *session.Instance
+
+	// plugins are the plugins discovered on PATH.
+	plugins []*plugin.Plugin
+	// paletteActions are the plugin commands and creation flows listed in the palette
+	paletteActions []paletteAction
+	// pluginColumnsAt is when the plugins' columns were last refreshed
+	pluginColumnsAt time.Time
+
+	// healthChecker runs the health checks on the instances
+	healthChecker *health.Checker
+	// healthCheckedAt is when the instances were last checked
+	healthCheckedAt time.Time
+	// unhealthy are the titles of the instances whose checks failed last time, which were already reported
+	unhealthy map[string]bool
+
 	// -- UI Components --
 
 	// list displays the list of instances
@@ -99,14 +231,25 @@ type home struct {
 	directoryPicker *ui.DirectoryPicker
 	// repoTabs manages repository tab navigation
 	repoTabs *ui.RepoTabs
+	// comparePane displays the comparison of two instances
+	comparePane *ui.ComparePane
+	// selectionOverlay lets the user pick from a list, e.g. a task from the task library
+	selectionOverlay *overlay.SelectionOverlay
 }
 
 func newHome(ctx context.Context, program string, autoYes bool, targetDir string) *home {
 	// Load application config
 	appConfig := config.LoadConfig()
+	lowPower := power.LowPower(appConfig.Refresh)
 
-	// Load application state
+	// Load application state. Without it, the TUI would start with no instances and save that over it.
 	appState := config.LoadState()
+	if err := appState.ReadOnly(); err != nil {
+		fmt.Printf("Failed to load state: %v\n", err)
+		os.Exit(1)
+	}
+	// The TUI's mutations are batched and saved by flushStateCmd, rather than each rewriting the state file.
+	appState.SetWriteBehind(true)
 
 	// Initialize storage
 	storage, err := session.NewStorage(appState)
@@ -117,21 +260,41 @@ func newHome(ctx context.Context, program string, autoYes bool, targetDir string
 
 	h := &home{
 		ctx:             ctx,
-		spinner:         spinner.New(spinner.WithSpinner(spinner.MiniDot)),
+		spinner:         newSpinner(appConfig.Refresh, lowPower),
 		menu:            ui.NewMenu(),
 		tabbedWindow:    ui.NewTabbedWindow(ui.NewPreviewPane(), ui.NewDiffPane()),
 		errBox:          ui.NewErrBox(),
 		storage:         storage,
 		appConfig:       appConfig,
+		lowPower:        lowPower,
 		program:         program,
 		autoYes:         autoYes,
 		targetDir:       targetDir,
 		state:           stateDefault,
 		appState:        appState,
 		directoryPicker: ui.NewDirectoryPicker(),
-		repoTabs:        ui.NewRepoTabs(),
+		comparePane:     ui.NewComparePane(),
+		retryQueue:      retry.NewQueue(),
+		healthChecker:   health.NewChecker(appConfig.Health),
 	}
 	h.list = ui.NewList(&h.spinner, autoYes)
+	// The tabs filter the list, so it's the list's tabs that are shown.
+	h.repoTabs = h.list.GetRepoTabs()
+	h.list.SetAbsoluteTimes(appConfig.AbsoluteTimes)
+	h.list.SetColumns(appConfig.ListColumns)
+	h.list.SetSortMode(ui.ParseSortMode(appState.GetPreferences().SortOrder))
+	h.list.SetAirGapped(network.AirGapped())
+	ui.SetRepoColors(appConfig.GetRepoColors())
+	ui.SetRepoAliases(appState.GetRepositoryAliases())
+	if detachKey, err := tmux.ParseDetachKey(appConfig.DetachKey); err != nil {
+		log.ErrorLog.Printf("keeping the default detach key: %v", err)
+	} else {
+		tmux.SetDetachKey(detachKey)
+	}
+	tmux.SetAttachHelp(!appConfig.HideAttachHelp)
+	if err := keys.ApplyKeymap(appConfig.Keymap); err != nil {
+		log.ErrorLog.Printf("keeping the default keys: %v", err)
+	}
 
 	// Initialize repository state management
 	if err := h.initializeRepositoryState(); err != nil {
@@ -139,11 +302,19 @@ func newHome(ctx context.Context, program string, autoYes bool, targetDir string
 		os.Exit(1)
 	}
 	
-	// Load saved instances
-	instances, err := storage.LoadInstances()
+	// Restore saved instances. The ones that fail to restore are reported once the UI is up.
+	storage.SetLazyRestore(appConfig.LazyRestore)
+	showedProgress := false
+	instances, err := storage.RestoreInstances(func(done, total int) {
+		fmt.Printf("\rRestoring sessions %d/%d", done, total)
+		showedProgress = true
+	})
+	if showedProgress {
+		fmt.Print("\r\033[K")
+	}
 	if err != nil {
-		fmt.Printf("Failed to load instances: %v\n", err)
-		os.Exit(1)
+		log.ErrorLog.Printf("failed to restore instances: %v", err)
+		h.restoreErr = err
 	}
 
 	// Initialize repository tabs with repositories from state
@@ -173,11 +344,13 @@ func newHome(ctx context.Context, program string, autoYes bool, targetDir string
 		}
 	}
 
-	if len(repoPaths) > 0 {
-		h.repoTabs.SetRepos(repoPaths)
-		// Select targetDir if provided, otherwise keep default selection
-		if targetDir != "" {
-			h.repoTabs.SelectRepo(targetDir)
+	// Registered repositories keep their tabs even without instances, so new ones can be created there.
+	for _, path := range repoPaths {
+		h.repoTabs.RegisterRepo(path)
+	}
+	for _, repo := range repos {
+		if repo.Pinned {
+			h.repoTabs.SetPinned(repo.Path, true)
 		}
 	}
 
@@ -190,6 +363,17 @@ func newHome(ctx context.Context, program string, autoYes bool, targetDir string
 		}
 	}
 
+	// Return to the workspace, repository and instance selected when the app was last used, unless a
+	// directory was given.
+	h.restoreWorkspace()
+	selectedRepo := appState.GetSelectedRepository()
+	if targetDir != "" {
+		selectedRepo = targetDir
+	}
+	if selectedRepo != "" {
+		h.selectRepo(selectedRepo)
+	}
+
 	// If no instances exist and no targetDir was provided, show directory picker on startup
 	if len(instances) == 0 && targetDir == "" {
 		h.state = stateDirectoryPicker
@@ -200,11 +384,20 @@ func newHome(ctx context.Context, program string, autoYes bool, targetDir string
 }
 
 // updateHandleWindowSizeEvent sets the sizes of the components.
-// The components will try to render inside their bounds.
-func (m *home) updateHandleWindowSizeEvent(msg tea.WindowSizeMsg) {
-	// List takes 30% of width, preview takes 70%
+// The components will try to render inside their bounds. Resizing the tmux sessions is debounced, since
+// dragging a terminal window sends many resizes in quick succession.
+func (m *home) updateHandleWindowSizeEvent(msg tea.WindowSizeMsg) tea.Cmd {
+	// List takes 30% of width, preview takes 70%. Narrow terminals show one of them at a time instead.
 	listWidth := int(float32(msg.Width) * 0.3)
 	tabsWidth := msg.Width - listWidth
+	layout := m.appState.GetPreferences().GetLayout()
+	m.narrow = layout == config.LayoutSingle || (layout == config.LayoutAuto && msg.Width < narrowWidth)
+	if m.narrow {
+		listWidth = msg.Width
+		tabsWidth = msg.Width
+	}
+	m.list.SetCompact(m.narrow)
+	m.menu.SetNarrow(m.narrow)
 
 	// Menu takes 10% of height, list and window take 90%
 	contentHeight := int(float32(msg.Height) * 0.9)
@@ -232,12 +425,27 @@ func (m *home) updateHandleWindowSizeEvent(msg tea.WindowSizeMsg) {
 	if m.repoTabs != nil {
 		m.repoTabs.SetWidth(msg.Width)
 	}
+	m.comparePane.SetSize(int(float32(msg.Width)*0.8), int(float32(msg.Height)*0.8))
 
-	previewWidth, previewHeight := m.tabbedWindow.GetPreviewSize()
-	if err := m.list.SetSessionPreviewSize(previewWidth, previewHeight); err != nil {
-		log.ErrorLog.Print(err)
-	}
 	m.menu.SetSize(msg.Width, menuHeight)
+
+	m.resizeSeq++
+	seq := m.resizeSeq
+	return tea.Tick(resizeDebounce, func(time.Time) tea.Msg {
+		return resizeMsg{seq: seq}
+	})
+}
+
+// narrowWidth is the terminal width below which the list and the preview are shown one at a time.
+const narrowWidth = 100
+
+// resizeDebounce is how long the window size has to be stable before the tmux sessions are resized.
+const resizeDebounce = 150 * time.Millisecond
+
+// resizeMsg implements tea.Msg and resizes the tmux sessions to the preview size, unless a later resize
+// superseded it.
+type resizeMsg struct {
+	seq int
 }
 
 func (m *home) Init() tea.Cmd {
@@ -245,17 +453,26 @@ func (m *home) Init() tea.Cmd {
 	// update the spinner, which sends a new spinner.TickMsg. I think this lasts forever lol.
 	cmds := []tea.Cmd{
 		m.spinner.Tick,
-		func() tea.Msg {
-			time.Sleep(100 * time.Millisecond)
-			return previewTickMsg{}
-		},
-		tickUpdateMetadataCmd,
+		m.previewTickCmd(),
+		m.tickUpdateMetadataCmd(),
+		m.flushStateCmd(),
+		m.checkPowerCmd(),
+		loadPlugins,
+	}
+	if cmd := m.collectReview(); cmd != nil {
+		cmds = append(cmds, cmd)
+	}
+	if cmd := m.discoverRepos(); cmd != nil {
+		cmds = append(cmds, cmd)
 	}
 
 	// If we're starting in directory picker state, initialize it
 	if m.state == stateDirectoryPicker {
 		cmds = append(cmds, m.directoryPicker.Init())
 	}
+	if m.restoreErr != nil {
+		cmds = append(cmds, m.handleError(m.restoreErr))
+	}
 
 	return tea.Batch(cmds...)
 }
@@ -274,22 +491,8 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.targetDir = selectedPath
 		
 		// Create new instance in the selected directory
-		instance, err := session.NewInstance(session.InstanceOptions{
-			Title:   "",
-			Path:    selectedPath,
-			Program: m.program,
-		})
-		if err != nil {
-			m.state = stateDefault
-			return m, m.handleError(err)
-		}
-		
-		m.newInstanceFinalizer = m.list.AddInstance(instance)
-		m.list.SetSelectedInstance(m.list.NumInstances() - 1)
-		m.state = stateNew
-		m.menu.SetState(ui.StateNewInstance)
-		
-		return m, tea.WindowSize()
+		m.state = stateDefault
+		return m, tea.Batch(tea.WindowSize(), m.addNewInstance(selectedPath, "", ""))
 	case ui.DirectoryPickerCancelledMsg:
 		// Handle bubble tea directory picker cancellation
 		m.state = stateDefault
@@ -306,22 +509,8 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.targetDir = selectedPath
 		
 		// Create new instance in the selected directory
-		instance, err := session.NewInstance(session.InstanceOptions{
-			Title:   "",
-			Path:    selectedPath,
-			Program: m.program,
-		})
-		if err != nil {
-			m.state = stateDefault
-			return m, m.handleError(err)
-		}
-		
-		m.newInstanceFinalizer = m.list.AddInstance(instance)
-		m.list.SetSelectedInstance(m.list.NumInstances() - 1)
-		m.state = stateNew
-		m.menu.SetState(ui.StateNewInstance)
-		
-		return m, tea.WindowSize()
+		m.state = stateDefault
+		return m, tea.Batch(tea.WindowSize(), m.addNewInstance(selectedPath, "", ""))
 	case ui.NvimDirectoryPickerCancelledMsg:
 		// Handle nvim directory picker cancellation
 		m.state = stateDefault
@@ -330,40 +519,101 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Handle nvim directory picker error
 		m.state = stateDefault
 		return m, m.handleError(msg.Error)
+	case newInstanceMsg:
+		return m, m.createNewInstance(msg.path, msg.title, msg.branch)
 	case hideErrMsg:
 		m.errBox.Clear()
+	case hideHintMsg:
+		m.hideHint(msg.id)
+	case scanFindingsMsg:
+		return m, m.showScanFindings(msg)
+	case dependencyWarningMsg:
+		return m, m.handleInfo(msg.String())
+	case instanceFrozenMsg:
+		return m, tea.Batch(m.instanceChanged(), m.handleInfo("session archived to "+msg.entry.Path))
+	case operationQueuedMsg:
+		m.list.SetQueued(m.retryQueue.Len())
+		return m, m.handleInfo(fmt.Sprintf("offline: %s queued, it will be retried when the network is back", msg.name))
+	case retryResultsMsg:
+		return m, m.handleRetryResults(msg.results)
+	case pluginsLoadedMsg:
+		m.plugins = msg.plugins
+		return m, m.refreshPluginColumns()
+	case pluginColumnsMsg:
+		m.list.SetPluginColumns(msg.columns)
+		return m, nil
+	case pluginCommandMsg:
+		return m, tea.Batch(m.instanceChanged(), m.handleInfo(msg.message))
+	case pluginInstanceMsg:
+		return m.startPluginInstance(msg)
+	case ticketLoadedMsg:
+		return m.startTicketInstance(msg)
+	case ticketsSyncedMsg:
+		return m, m.handleTicketsSynced(msg)
 	case previewTickMsg:
 		cmd := m.instanceChanged()
-		return m, tea.Batch(
-			cmd,
-			func() tea.Msg {
-				time.Sleep(100 * time.Millisecond)
-				return previewTickMsg{}
-			},
-		)
+		return m, tea.Batch(cmd, m.previewTickCmd())
+	case powerMsg:
+		return m, m.handlePower(msg)
+	case statsMsg:
+		return m, m.showStats(msg)
+	case reviewMsg:
+		return m, m.showReview(msg)
+	case reposDiscoveredMsg:
+		return m, m.handleReposDiscovered(msg)
 	case keyupMsg:
 		m.menu.ClearKeydown()
 		return m, nil
+	case stateFlushMsg:
+		if err := m.flushState(); err != nil {
+			return m, tea.Batch(m.flushStateCmd(), m.handleError(err))
+		}
+		return m, tea.Batch(m.flushStateCmd(), m.reloadMergedInstances())
 	case tickUpdateMetadataMessage:
+		cmds := []tea.Cmd{m.tickUpdateMetadataCmd(), m.retryQueued()}
+		// The selection is saved here rather than on every move, so scrolling through the list doesn't
+		// write the state on every key press.
+		m.saveSelection()
+		poll := telemetry.Start("status.poll")
 		for _, instance := range m.list.GetInstances() {
-			if !instance.Started() || instance.Paused() {
+			if !instance.Started() || instance.Inactive() || instance.Dormant() || instance.Status == session.Error {
 				continue
 			}
-			updated, prompt := instance.HasUpdated()
-			if updated {
-				instance.SetStatus(session.Running)
-			} else {
-				if prompt {
-					instance.TapEnter()
-				} else {
-					instance.SetStatus(session.Ready)
-				}
+			span := poll.Child("instance.poll", "instance", instance.Title)
+			wasRunning := instance.Status == session.Running
+			cmds = append(cmds, m.updateStatus(instance)...)
+			if cmd := m.checkBudget(instance); cmd != nil {
+				cmds = append(cmds, cmd)
 			}
 			if err := instance.UpdateDiffStats(); err != nil {
 				log.WarningLog.Printf("could not update diff stats: %v", err)
+				span.SetError(err)
+			}
+			span.SetAttr("status", instance.Status.String())
+			span.End()
+			if wasRunning && instance.Status == session.Ready {
+				hooks.Run(hooks.StatusReady, instance)
+				cmds = append(cmds, m.alert(instance, config.AlertReady))
+				if cmd := m.instanceReady(instance); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
 			}
 		}
-		return m, tickUpdateMetadataCmd
+		poll.End()
+		cmds = append(cmds, m.updateTerminalTitle(), m.checkMerges(), m.refreshPluginColumns(), m.checkHealth())
+		return m, tea.Batch(cmds...)
+	case mergesCheckedMsg:
+		return m, m.handleMerges(msg)
+	case credentialsRequiredMsg:
+		return m, m.handleCredentialsRequired(msg)
+	case enterCredentialsMsg:
+		return m, m.enterCredentials(msg)
+	case credentialsEnteredMsg:
+		return m, m.handleCredentialsEntered(msg)
+	case operationResumedMsg:
+		return m, m.handleOperationResumed(msg)
+	case healthCheckedMsg:
+		return m, m.handleHealthChecked(msg)
 	case tea.MouseMsg:
 		// Handle mouse wheel scrolling in the diff view
 		if m.tabbedWindow.IsInDiffTab() {
@@ -390,7 +640,15 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m.handleKeyPress(msg)
 	case tea.WindowSizeMsg:
-		m.updateHandleWindowSizeEvent(msg)
+		return m, m.updateHandleWindowSizeEvent(msg)
+	case resizeMsg:
+		if msg.seq != m.resizeSeq {
+			return m, nil
+		}
+		previewWidth, previewHeight := m.tabbedWindow.GetPreviewSize()
+		if err := m.list.SetSessionPreviewSize(previewWidth, previewHeight); err != nil {
+			log.ErrorLog.Print(err)
+		}
 		return m, nil
 	case error:
 		// Handle errors from confirmation actions
@@ -406,13 +664,32 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// handleQuit saves the state and quits. The operations queued while offline only live in memory, so quitting
+// with any queued asks first.
 func (m *home) handleQuit() (tea.Model, tea.Cmd) {
-	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+	if queued := m.retryQueue.Names(); len(queued) > 0 {
+		message := fmt.Sprintf("[!] Quit? The operations queued while offline won't be retried: %s.",
+			strings.Join(queued, ", "))
+		return m, m.confirmAction(message, m.quit)
+	}
+	if err, ok := m.quit().(error); ok {
 		return m, m.handleError(err)
 	}
 	return m, tea.Quit
 }
 
+// quit saves the state, and returns tea.QuitMsg, or the error saving it.
+func (m *home) quit() tea.Msg {
+	m.saveSelection()
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		return err
+	}
+	if err := m.flushState(); err != nil {
+		return err
+	}
+	return tea.QuitMsg{}
+}
+
 func (m *home) handleMenuHighlighting(msg tea.KeyMsg) (cmd tea.Cmd, returnEarly bool) {
 	// Handle menu highlighting when you press a button. We intercept it here and immediately return to
 	// update the ui while re-sending the keypress. Then, on the next call to this, we actually handle the keypress.
@@ -420,7 +697,11 @@ func (m *home) handleMenuHighlighting(msg tea.KeyMsg) (cmd tea.Cmd, returnEarly
 		m.keySent = false
 		return nil, false
 	}
-	if m.state == statePrompt || m.state == stateHelp || m.state == stateConfirm {
+	if m.state == statePrompt || m.state == stateHelp || m.state == stateConfirm || m.state == stateCompare ||
+		m.state == stateStalled || m.state == stateTaskPicker || m.state == statePlanReview || m.state == stateDatabase ||
+		m.state == stateSnooze || m.state == statePalette || m.state == stateTicket || m.state == stateStats ||
+		m.state == stateRepoPicker || m.state == stateBranchPicker || m.state == stateAdoptPicker ||
+		m.state == stateBudget || m.state == stateWorkspace || m.state == stateReview || m.state == stateProgram {
 		return nil, false
 	}
 	// If it's in the global keymap, we should try to highlight it.
@@ -429,7 +710,7 @@ func (m *home) handleMenuHighlighting(msg tea.KeyMsg) (cmd tea.Cmd, returnEarly
 		return nil, false
 	}
 
-	if m.list.GetSelectedInstance() != nil && m.list.GetSelectedInstance().Paused() && name == keys.KeyEnter {
+	if m.list.GetSelectedInstance() != nil && m.list.GetSelectedInstance().Inactive() && name == keys.KeyEnter {
 		return nil, false
 	}
 	if name == keys.KeyShiftDown || name == keys.KeyShiftUp {
@@ -447,6 +728,165 @@ func (m *home) handleMenuHighlighting(msg tea.KeyMsg) (cmd tea.Cmd, returnEarly
 		m.keydownCallback(name)), true
 }
 
+// startNewInstance adds a new, unnamed instance and switches to naming it. If there's no target
+// directory, the directory picker is shown first. If withPrompt is set, the prompt overlay opens once
+// the instance is named.
+func (m *home) startNewInstance(withPrompt bool) (tea.Model, tea.Cmd) {
+	if err := m.resetNewInstance(withPrompt); err != nil {
+		return m, m.handleError(err)
+	}
+
+	// New instances go in the selected repository tab's repository, which may have none yet, or else in
+	// targetDir. If neither is available, show directory picker
+	path := m.targetDir
+	if repo := m.list.GetCurrentRepoPath(); repo != "" {
+		path = repo
+	}
+	if path == "" {
+		return m, m.showDirectoryPicker()
+	}
+	return m, m.addNewInstance(path, "", "")
+}
+
+// resetNewInstance checks that another instance can be created, and clears what's left of the last one.
+func (m *home) resetNewInstance(withPrompt bool) error {
+	if m.list.NumInstances() >= GlobalInstanceLimit {
+		return fmt.Errorf("you can't create more than %d instances", GlobalInstanceLimit)
+	}
+	m.promptAfterName = withPrompt
+	m.taskPrompt = ""
+	m.taskTicket = ""
+	m.taskName = ""
+	m.planMode = false
+	return nil
+}
+
+// showDirectoryPicker shows the built-in directory picker. A new instance is added in the picked directory.
+func (m *home) showDirectoryPicker() tea.Cmd {
+	m.state = stateDirectoryPicker
+	m.directoryPicker.Reset()
+	return tea.Batch(tea.WindowSize(), m.directoryPicker.Init())
+}
+
+// newInstanceMsg adds a new instance once the user answered whether it may run its repository's program.
+type newInstanceMsg struct {
+	path, title, branch string
+}
+
+// addNewInstance adds a new instance titled title in path, and switches to naming it. If branch is set, the
+// instance checks out that existing branch instead of creating one. If the repository's settings file sets a
+// program the user wasn't asked about yet, they're asked first.
+func (m *home) addNewInstance(path, title, branch string) tea.Cmd {
+	if m.askRepoProgram(path, newInstanceMsg{path: path, title: title, branch: branch}) {
+		return nil
+	}
+	return m.createNewInstance(path, title, branch)
+}
+
+// askRepoProgram asks whether new instances in path's repository may run the program its settings file sets,
+// if it sets one the user wasn't asked about. Anyone who can commit to the repository can set it, so it's only
+// run once allowed. The repository is added to the state to keep the answer, and next is sent either way. It
+// returns false if there's nothing to ask.
+func (m *home) askRepoProgram(path string, next tea.Msg) bool {
+	if m.programGiven {
+		return false
+	}
+	repoPath, err := config.FindRepositoryForPath(path)
+	if err != nil {
+		return false
+	}
+	settings := config.GetRepoSettings(m.appState, repoPath)
+	if settings.DefaultProgram == "" || settings.ProgramAllowed != nil {
+		return false
+	}
+	if err := m.ensureRepositoryTracked(repoPath); err != nil {
+		log.WarningLog.Printf("failed to track repository: %v", err)
+		return false
+	}
+
+	answer := func(allowed bool) {
+		m.state = stateDefault
+		if err := m.appState.SetRepositoryProgramAllowed(repoPath, allowed); err != nil {
+			log.ErrorLog.Printf("failed to save the answer about the program of %s: %v", repoPath, err)
+		}
+		m.confirmResult = next
+	}
+	m.state = stateConfirm
+	m.confirmationOverlay = overlay.NewConfirmationOverlay(fmt.Sprintf(
+		"%s in %s sets new sessions to run '%s'. Allow it?", config.RepoFileName, filepath.Base(repoPath),
+		settings.DefaultProgram))
+	m.confirmationOverlay.SetWidth(50)
+	m.confirmationOverlay.OnConfirm = func() { answer(true) }
+	m.confirmationOverlay.OnCancel = func() { answer(false) }
+	return true
+}
+
+// createNewInstance adds a new instance as addNewInstance does, without asking about the repository's program.
+func (m *home) createNewInstance(path, title, branch string) tea.Cmd {
+	instance, err := session.NewInstance(session.InstanceOptions{
+		Title:          title,
+		Path:           path,
+		Program:        m.programFor(path),
+		Branch:         branch,
+		CommitIdentity: m.identity,
+		RepoSettings:   m.repoSettingsFor(path),
+		Budget:         session.BudgetFromConfig(m.appConfig.Budget),
+	})
+	if err != nil {
+		return m.handleError(err)
+	}
+
+	m.newInstanceFinalizer = m.list.AddInstance(instance)
+	m.list.SetSelectedInstance(m.list.NumInstances() - 1)
+	m.state = stateNew
+	m.menu.SetState(ui.StateNewInstance)
+	return m.newInstanceRepoInfo()
+}
+
+// programFor returns the program a new instance in path runs: the program given with --program, or else the
+// program and arguments the user chose for its repository, or else its repository's default program if the user
+// allowed it, or else the default program.
+func (m *home) programFor(path string) string {
+	if m.programGiven {
+		return m.program
+	}
+	repoPath, err := config.FindRepositoryForPath(path)
+	if err != nil {
+		return m.program
+	}
+	program := m.program
+	if repoProgram := config.GetRepoSettings(m.appState, repoPath).AllowedProgram(); repoProgram != "" {
+		program = repoProgram
+	}
+	if repo, err := m.appState.GetRepository(repoPath); err == nil {
+		return repo.ProgramFor(program)
+	}
+	return program
+}
+
+// repoSettingsFor returns the settings of path's repository, or nil if path isn't in one.
+func (m *home) repoSettingsFor(path string) *config.RepoSettings {
+	repoPath, err := config.FindRepositoryForPath(path)
+	if err != nil {
+		return nil
+	}
+	return config.GetRepoSettings(m.appState, repoPath)
+}
+
+// autoYesFor returns true if the new instance should accept prompts automatically. Its repository's setting can
+// turn the global one off, but not on: it's committed by whoever can commit to the repository.
+func (m *home) autoYesFor(instance *session.Instance) bool {
+	if !m.autoYes {
+		return false
+	}
+	worktree, err := instance.GetGitWorktree()
+	if err != nil {
+		return true
+	}
+	autoYes := config.GetRepoSettings(m.appState, worktree.GetRepoPath()).AutoYes
+	return autoYes == nil || *autoYes
+}
+
 func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 	cmd, returnEarly := m.handleMenuHighlighting(msg)
 	if returnEarly {
@@ -457,6 +897,70 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		return m.handleHelpState(msg)
 	}
 
+	if m.state == stateCompare {
+		return m.handleCompareState(msg)
+	}
+
+	if m.state == stateStalled {
+		return m.handleStalledState(msg)
+	}
+
+	if m.state == stateDatabase {
+		return m.handleDatabaseState(msg)
+	}
+
+	if m.state == stateStats {
+		return m.handleStatsState(msg)
+	}
+
+	if m.state == stateReview {
+		return m.handleReviewState(msg)
+	}
+
+	if m.state == stateProgram {
+		return m.handleProgramState(msg)
+	}
+
+	if m.state == stateRepoPicker {
+		return m.handleRepoPickerState(msg)
+	}
+
+	if m.state == stateTaskPicker {
+		return m.handleTaskPickerState(msg)
+	}
+
+	if m.state == stateBranchPicker {
+		return m.handleBranchPickerState(msg)
+	}
+
+	if m.state == stateAdoptPicker {
+		return m.handleAdoptPickerState(msg)
+	}
+
+	if m.state == stateSnooze {
+		return m.handleSnoozeState(msg)
+	}
+
+	if m.state == statePalette {
+		return m.handlePaletteState(msg)
+	}
+
+	if m.state == stateTicket {
+		return m.handleTicketState(msg)
+	}
+
+	if m.state == stateBudget {
+		return m.handleBudgetState(msg)
+	}
+
+	if m.state == stateWorkspace {
+		return m.handleWorkspaceState(msg)
+	}
+
+	if m.state == statePlanReview {
+		return m.handlePlanReviewState(msg)
+	}
+
 	if m.state == stateNew {
 		// Handle quit commands first. Don't handle q because the user might want to type that.
 		if msg.String() == "ctrl+c" {
@@ -480,6 +984,10 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 				return m, m.handleError(fmt.Errorf("title cannot be empty"))
 			}
 
+			instance.Ticket = m.taskTicket
+			m.taskTicket = ""
+			instance.Task = m.taskName
+			m.taskName = ""
 			if err := instance.Start(true); err != nil {
 				m.list.Kill()
 				m.state = stateDefault
@@ -491,34 +999,39 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 				// Log error but don't fail instance creation
 				log.WarningLog.Printf("failed to track repository: %v", err)
 			}
+			m.showWorkspaceOf(instance)
 			
 			// Save after adding new instance
 			if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
 				return m, m.handleError(err)
 			}
+			hooks.Run(hooks.InstanceCreated, instance)
+			digest.RecordCreated(instance)
 			// Instance added successfully, call the finalizer.
 			m.newInstanceFinalizer()
-			if m.autoYes {
+			if m.autoYesFor(instance) {
 				instance.AutoYes = true
 			}
 
 			m.newInstanceFinalizer()
 			m.state = stateDefault
+			var hintCmd tea.Cmd
 			if m.promptAfterName {
 				m.state = statePrompt
 				m.menu.SetState(ui.StatePrompt)
 				// Initialize the text input overlay
-				m.textInputOverlay = overlay.NewTextInputOverlay("Enter prompt", "")
+				m.textInputOverlay = overlay.NewTextInputOverlay("Enter prompt", m.taskPrompt)
 				m.promptAfterName = false
+				m.taskPrompt = ""
 			} else {
 				m.menu.SetState(ui.StateDefault)
-				m.showHelpScreen(helpTypeInstanceStart, nil)
+				hintCmd = m.showHint(hintInstanceCreated)
 			}
 
-			return m, tea.Batch(tea.WindowSize(), m.instanceChanged())
+			return m, tea.Batch(tea.WindowSize(), m.instanceChanged(), hintCmd)
 		case tea.KeyRunes:
-			if len(instance.Title) >= 32 {
-				return m, m.handleError(fmt.Errorf("title cannot be longer than 32 characters"))
+			if len(instance.Title) >= maxTitleLength {
+				return m, m.handleError(fmt.Errorf("title cannot be longer than %d characters", maxTitleLength))
 			}
 			if err := instance.SetTitle(instance.Title + string(msg.Runes)); err != nil {
 				return m, m.handleError(err)
@@ -534,6 +1047,10 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 			if err := instance.SetTitle(instance.Title + " "); err != nil {
 				return m, m.handleError(err)
 			}
+		case tea.KeyTab:
+			return m.showRepoPicker()
+		case tea.KeyCtrlP:
+			return m.showProgramInput(instance)
 		case tea.KeyEsc:
 			m.list.Kill()
 			m.state = stateDefault
@@ -561,22 +1078,29 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 				if selected == nil {
 					return m, nil
 				}
-				if err := selected.SendPrompt(m.textInputOverlay.GetValue()); err != nil {
+				awaiting, err := m.handlePromptInput(selected, m.textInputOverlay.GetValue())
+				if err != nil {
 					return m, m.handleError(err)
 				}
+				if awaiting {
+					// handlePromptInput opened an overlay asking for the next template variable.
+					return m, tea.WindowSize()
+				}
+			} else {
+				m.pendingPrompt = nil
+				m.planMode = false
 			}
 
 			// Close the overlay and reset state
 			m.textInputOverlay = nil
 			m.state = stateDefault
-			return m, tea.Sequence(
+			return m, tea.Batch(tea.Sequence(
 				tea.WindowSize(),
 				func() tea.Msg {
 					m.menu.SetState(ui.StateDefault)
-					m.showHelpScreen(helpTypeInstanceStart, nil)
 					return nil
 				},
-			)
+			), m.showHint(hintInstanceCreated))
 		}
 
 		return m, nil
@@ -586,15 +1110,22 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 	if m.state == stateConfirm {
 		shouldClose := m.confirmationOverlay.HandleKeyPress(msg)
 		if shouldClose {
-			m.state = stateDefault
 			m.confirmationOverlay = nil
+			if result := m.confirmResult; result != nil {
+				m.confirmResult = nil
+				return m, func() tea.Msg { return result }
+			}
+			// The confirmed action may have opened another overlay, e.g. to show commit hook output.
+			if m.state == stateConfirm {
+				m.state = stateDefault
+			}
 			return m, nil
 		}
 		return m, nil
 	}
 
-	// Handle quit commands first
-	if msg.String() == "ctrl+c" || msg.String() == "q" {
+	// ctrl+c always quits, whatever the keymap.
+	if msg.String() == "ctrl+c" {
 		return m.handleQuit()
 	}
 
@@ -604,75 +1135,24 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 	}
 
 	switch name {
+	case keys.KeyQuit:
+		return m.handleQuit()
 	case keys.KeyHelp:
-		return m.showHelpScreen(helpTypeGeneral, nil)
+		return m.showHelpScreen()
 	case keys.KeyPrompt:
-		if m.list.NumInstances() >= GlobalInstanceLimit {
-			return m, m.handleError(
-				fmt.Errorf("you can't create more than %d instances", GlobalInstanceLimit))
-		}
-		
-		// If targetDir is available, use it; otherwise show directory picker
-		if m.targetDir != "" {
-			instance, err := session.NewInstance(session.InstanceOptions{
-				Title:   "",
-				Path:    m.targetDir,
-				Program: m.program,
-			})
-			if err != nil {
-				return m, m.handleError(err)
-			}
-
-			m.newInstanceFinalizer = m.list.AddInstance(instance)
-			m.list.SetSelectedInstance(m.list.NumInstances() - 1)
-			m.state = stateNew
-			m.menu.SetState(ui.StateNewInstance)
-			m.promptAfterName = true
-		} else {
-			// No targetDir, show directory picker
-			m.state = stateDirectoryPicker
-			m.directoryPicker.Reset()
-			m.promptAfterName = true
-			return m, tea.Batch(tea.WindowSize(), m.directoryPicker.Init())
-		}
-
-		return m, nil
+		return m.startNewInstance(true)
 	case keys.KeyNew:
-		if m.list.NumInstances() >= GlobalInstanceLimit {
-			return m, m.handleError(
-				fmt.Errorf("you can't create more than %d instances", GlobalInstanceLimit))
-		}
-		
-		// If targetDir is available, use it; otherwise show directory picker
-		if m.targetDir != "" {
-			instance, err := session.NewInstance(session.InstanceOptions{
-				Title:   "",
-				Path:    m.targetDir,
-				Program: m.program,
-			})
-			if err != nil {
-				return m, m.handleError(err)
-			}
-
-			m.newInstanceFinalizer = m.list.AddInstance(instance)
-			m.list.SetSelectedInstance(m.list.NumInstances() - 1)
-			m.state = stateNew
-			m.menu.SetState(ui.StateNewInstance)
-		} else {
-			// No targetDir, show directory picker
-			m.state = stateDirectoryPicker
-			m.directoryPicker.Reset()
-			m.promptAfterName = false
-			return m, tea.Batch(tea.WindowSize(), m.directoryPicker.Init())
-		}
-
-		return m, nil
+		return m.startNewInstance(false)
+	case keys.KeyTask:
+		return m.showTaskPicker()
+	case keys.KeyPlan:
+		return m.handlePlan()
 	case keys.KeyUp:
 		m.list.Up()
-		return m, m.instanceChanged()
+		return m, tea.Batch(m.instanceChanged(), m.showHint(hintInstanceSelected))
 	case keys.KeyDown:
 		m.list.Down()
-		return m, m.instanceChanged()
+		return m, tea.Batch(m.instanceChanged(), m.showHint(hintInstanceSelected))
 	case keys.KeyShiftUp:
 		if m.tabbedWindow.IsInDiffTab() {
 			m.tabbedWindow.ScrollUp()
@@ -686,6 +1166,9 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 	case keys.KeyTab:
 		m.tabbedWindow.Toggle()
 		m.menu.SetInDiffTab(m.tabbedWindow.IsInDiffTab())
+		if m.tabbedWindow.IsInDiffTab() {
+			return m, tea.Batch(m.instanceChanged(), m.showHint(hintDiffShown))
+		}
 		return m, m.instanceChanged()
 	case keys.KeyKill:
 		selected := m.list.GetSelectedInstance()
@@ -693,6 +1176,49 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 			return m, nil
 		}
 
+		// An instance that couldn't be killed is force-killed instead. Its branch may be checked out by then, so
+		// that's not checked.
+		if selected.Status == session.Error {
+			forceKill := func() tea.Msg {
+				if err := m.storage.DeleteInstance(selected.Title); err != nil {
+					return err
+				}
+				// The instance is removed even if some of it couldn't be killed.
+				killErr := m.list.ForceKill()
+				hooks.Run(hooks.InstanceKilled, selected)
+				digest.RecordKilled(selected)
+				if killErr != nil {
+					return killErr
+				}
+				return instanceChangedMsg{}
+			}
+			message := fmt.Sprintf("[!] Force-kill session '%s'? Its programs are killed with SIGKILL.", selected.Title)
+			return m, m.confirmKill(message, forceKill)
+		}
+		if selected.Inactive() {
+			return m, m.handleError(fmt.Errorf("session '%s' is already %s, press %s to purge it",
+				selected.Title, selected.Status, keys.Help(keys.KeyPurge)))
+		}
+
+		// Stopping only kills the agent. Its branch and worktree are kept until the instance is purged.
+		stopAction := func() tea.Msg {
+			if err := selected.Stop(); err != nil {
+				return err
+			}
+			if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+				return err
+			}
+			return instanceChangedMsg{}
+		}
+
+		message := fmt.Sprintf("[!] Stop session '%s'? Its branch and worktree are kept.", selected.Title)
+		return m, m.confirmKill(message, stopAction)
+	case keys.KeyPurge:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil {
+			return m, nil
+		}
+
 		// Create the kill action as a tea.Cmd
 		killAction := func() tea.Msg {
 			// Get worktree and check if branch is checked out
@@ -710,19 +1236,23 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 				return fmt.Errorf("instance %s is currently checked out", selected.Title)
 			}
 
-			// Delete from storage first
-			if err := m.storage.DeleteInstance(selected.Title); err != nil {
+			// Kill the instance first, so it's kept, in the Error status, if that fails
+			if err := m.list.Kill(); err != nil {
 				return err
 			}
 
-			// Then kill the instance
-			m.list.Kill()
+			// Then delete it from storage
+			if err := m.storage.DeleteInstance(selected.Title); err != nil {
+				return err
+			}
+			hooks.Run(hooks.InstanceKilled, selected)
+			digest.RecordKilled(selected)
 			return instanceChangedMsg{}
 		}
 
 		// Show confirmation modal
-		message := fmt.Sprintf("[!] Kill session '%s'?", selected.Title)
-		return m, m.confirmAction(message, killAction)
+		message := fmt.Sprintf("[!] Purge session '%s'? Its branch %s and worktree are deleted.", selected.Title, selected.Branch)
+		return m, m.confirmKill(message, killAction)
 	case keys.KeySubmit:
 		selected := m.list.GetSelectedInstance()
 		if selected == nil {
@@ -730,7 +1260,7 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		}
 
 		// Create the push action as a tea.Cmd
-		pushAction := func() tea.Msg {
+		push := func() tea.Msg {
 			// Default commit message with timestamp
 			commitMsg := fmt.Sprintf("[claudesquad] update from '%s' on %s", selected.Title, time.Now().Format(time.RFC822))
 			worktree, err := selected.GetGitWorktree()
@@ -738,28 +1268,58 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 				return err
 			}
 			if err = worktree.PushChanges(commitMsg, true); err != nil {
-				return err
+				if msg := credentialsRequired(fmt.Sprintf("push of '%s'", selected.Title), err); msg != nil {
+					return msg
+				}
+				// Only the pull request is left to create if the push itself went through.
+				var openErr *git.OpenError
+				if errors.As(err, &openErr) {
+					return m.queueIfOffline(fmt.Sprintf("pull request of '%s'", selected.Title), err, worktree.OpenOnGitHub)
+				}
+				// The queued push creates the pull request too, like the push it replaces would have.
+				return m.queueIfOffline(fmt.Sprintf("push of '%s'", selected.Title), err, func() error {
+					return worktree.PushChanges(commitMsg, true)
+				})
 			}
 			return nil
 		}
+		// Scan the changes first; findings must be overridden explicitly before pushing.
+		pushAction := func() tea.Msg {
+			findings, err := selected.ScanChanges()
+			if err != nil {
+				return err
+			}
+			depFindings, block, err := selected.CheckDependencies()
+			if err != nil {
+				return err
+			}
+			if block {
+				findings = append(findings, depFindings...)
+			}
+			if len(findings) > 0 {
+				return scanFindingsMsg{instance: selected, findings: findings, push: push}
+			}
+			if result := push(); result != nil || block || len(depFindings) == 0 {
+				return result
+			}
+			return dependencyWarningMsg{instance: selected, findings: depFindings}
+		}
 
+		if err := network.Check("pushing"); err != nil {
+			return m, m.handleError(err)
+		}
 		// Show confirmation modal
-		message := fmt.Sprintf("[!] Push changes from session '%s'?", selected.Title)
-		return m, m.confirmAction(message, pushAction)
+		return m, m.confirmAction(pushConfirmation(selected), pushAction)
 	case keys.KeyCheckout:
 		selected := m.list.GetSelectedInstance()
 		if selected == nil {
 			return m, nil
 		}
 
-		// Show help screen before pausing
-		m.showHelpScreen(helpTypeInstanceCheckout, func() {
-			if err := selected.Pause(); err != nil {
-				m.handleError(err)
-			}
-			m.instanceChanged()
-		})
-		return m, nil
+		if err := selected.Pause(); err != nil {
+			return m, m.handleError(err)
+		}
+		return m, tea.Batch(m.instanceChanged(), m.showHint(hintInstanceCheckedOut))
 	case keys.KeyResume:
 		selected := m.list.GetSelectedInstance()
 		if selected == nil {
@@ -774,48 +1334,104 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 			return m, nil
 		}
 		selected := m.list.GetSelectedInstance()
-		if selected == nil || selected.Paused() || !selected.TmuxAlive() {
+		if selected == nil || selected.Inactive() || !selected.TmuxAlive() {
 			return m, nil
 		}
-		// Show help screen before attaching
-		m.showHelpScreen(helpTypeInstanceAttach, func() {
-			var ch chan struct{}
-			var err error
-			
-			// Check if we're on the terminal tab and attach to the appropriate window
-			if m.tabbedWindow.IsInTerminalTab() {
-				ch, err = m.list.AttachToTerminal()
-			} else {
-				ch, err = m.list.Attach()
-			}
-			
-			if err != nil {
-				m.handleError(err)
-				return
+		// Inside tmux, jump to the instance's session instead of nesting tmux. The detach key jumps back.
+		if tmux.InsideTmux() {
+			if err := selected.SwitchTo(m.tabbedWindow.IsInTerminalTab()); err != nil {
+				return m, m.handleError(err)
 			}
-			<-ch
-			m.state = stateDefault
-		})
+			return m, nil
+		}
+
+		var ch chan struct{}
+		var err error
+
+		// Check if we're on the terminal tab and attach to the appropriate window
+		if m.tabbedWindow.IsInTerminalTab() {
+			ch, err = m.list.AttachToTerminal()
+		} else {
+			ch, err = m.list.Attach()
+		}
+
+		if err != nil {
+			return m, m.handleError(err)
+		}
+		<-ch
+		m.state = stateDefault
 		return m, nil
 	case keys.KeyDirectoryPicker:
-		// Show directory picker - prefer nvim if available, fallback to bubble tea picker
-		log.ErrorLog.Printf("DEBUG: KeyDirectoryPicker pressed, nvim available: %v", ui.IsNvimAvailable())
-		if ui.IsNvimAvailable() {
-			log.ErrorLog.Printf("DEBUG: Launching nvim directory picker")
-			nvimPicker := ui.NewNvimDirectoryPicker()
-			return m, nvimPicker.LaunchDirectoryPicker()
-		} else {
-			log.ErrorLog.Printf("DEBUG: Nvim not available, showing instructions")
-			// Show setup instructions for nvim + Oil.nvim
-			setupMsg := ui.GetNvimSetupInstructions()
-			return m, m.handleError(fmt.Errorf("neovim with Oil.nvim required for directory picker:\n\n%s", setupMsg))
+		return m.pickNewInstanceRepo()
+	case keys.KeyCompare:
+		return m, m.handleCompare()
+	case keys.KeyToggleTimes:
+		m.list.ToggleAbsoluteTimes()
+		return m, nil
+	case keys.KeySort:
+		preferences := m.appState.GetPreferences()
+		preferences.SortOrder = m.list.CycleSort().String()
+		if err := m.appState.SetPreferences(preferences); err != nil {
+			return m, tea.Batch(m.instanceChanged(), m.handleError(err))
+		}
+		return m, m.instanceChanged()
+	case keys.KeyStalled:
+		m.showStalledActions()
+		return m, nil
+	case keys.KeyDatabase:
+		return m, m.showDatabaseActions()
+	case keys.KeyFreeze:
+		return m, m.freezeInstance()
+	case keys.KeyHistory:
+		return m, m.showHistory()
+	case keys.KeyMute:
+		return m, m.toggleMuted()
+	case keys.KeySnooze:
+		return m, m.showSnoozePicker()
+	case keys.KeyBudget:
+		return m, m.showBudgetInput()
+	case keys.KeyWorkspace:
+		return m.showWorkspacePicker()
+	case keys.KeyPane:
+		selected := m.list.GetSelectedInstance()
+		if selected == nil || !selected.Started() || selected.Inactive() {
+			return m, nil
 		}
+		if err := selected.CyclePane(); err != nil {
+			return m, m.handleError(err)
+		}
+		if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+			return m, m.handleError(err)
+		}
+		info := "previewing the agent's pane, prompts go to the agent"
+		if selected.Pane > 0 {
+			info = fmt.Sprintf("previewing pane %d, prompts go to it", selected.Pane)
+		}
+		return m, tea.Batch(m.instanceChanged(), m.handleInfo(info))
+	case keys.KeyPalette:
+		return m.showPalette()
+	case keys.KeyTicket:
+		return m.showTicketInput()
+	case keys.KeyStats:
+		return m, m.collectStats()
+	case keys.KeyBranch:
+		return m.showBranchPicker()
+	case keys.KeyAdopt:
+		return m.showAdoptPicker()
+	case keys.KeyPreview:
+		if !m.narrow {
+			return m, nil
+		}
+		m.showPreview = !m.showPreview
+		return m, m.instanceChanged()
 	case keys.KeyRepoTabNext:
 		// Navigate to next repository tab
 		if m.repoTabs.HasRepos() {
 			m.repoTabs.NextRepo()
 			// Filter instances based on selected repository
-			return m, m.instanceChanged()
+			m.selectRepo(m.repoTabs.GetSelectedRepo())
+			m.saveSelectedRepo()
+			return m, tea.Batch(m.instanceChanged(), m.showHint(hintRepoTabSwitched))
 		}
 		return m, nil
 	case keys.KeyRepoTabPrev:
@@ -823,9 +1439,16 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		if m.repoTabs.HasRepos() {
 			m.repoTabs.PrevRepo()
 			// Filter instances based on selected repository
-			return m, m.instanceChanged()
+			m.selectRepo(m.repoTabs.GetSelectedRepo())
+			m.saveSelectedRepo()
+			return m, tea.Batch(m.instanceChanged(), m.showHint(hintRepoTabSwitched))
 		}
 		return m, nil
+	case keys.KeyPinRepo:
+		return m, m.togglePinnedRepo()
+	case keys.KeyDismissHint:
+		m.dismissHint()
+		return m, nil
 	default:
 		return m, nil
 	}
@@ -837,7 +1460,25 @@ func (m *home) instanceChanged() tea.Cmd {
 	// selected may be nil
 	selected := m.list.GetSelectedInstance()
 
+	// Lazily restored instances get their tmux session once they're first selected.
+	if selected != nil && selected.Dormant() {
+		if err := selected.Wake(); err != nil {
+			return m.handleError(err)
+		}
+	}
+
+	// Instances other than the selected one are only resized to the preview size once they're shown.
+	if err := m.list.SizeSelected(); err != nil {
+		log.ErrorLog.Print(err)
+	}
+
 	m.tabbedWindow.UpdateDiff(selected)
+	// Draw the preview in the accent color of the selected instance's repository when several are shown.
+	if selected != nil && m.list.HasMultipleRepos() {
+		m.tabbedWindow.SetRepo(selected.RepoPath())
+	} else {
+		m.tabbedWindow.SetRepo("")
+	}
 	// Update menu with current instance
 	m.menu.SetInstance(selected)
 
@@ -878,18 +1519,18 @@ type tickUpdateMetadataMessage struct{}
 
 type instanceChangedMsg struct{}
 
-// tickUpdateMetadataCmd is the callback to update the metadata of the instances every 500ms. Note that we iterate
-// overall the instances and capture their output. It's a pretty expensive operation. Let's do it 2x a second only.
-var tickUpdateMetadataCmd = func() tea.Msg {
-	time.Sleep(500 * time.Millisecond)
-	return tickUpdateMetadataMessage{}
-}
-
 // handleError handles all errors which get bubbled up to the app. sets the error message. We return a callback tea.Cmd that returns a hideErrMsg message
 // which clears the error message after 3 seconds.
 func (m *home) handleError(err error) tea.Cmd {
 	log.ErrorLog.Printf("%v", err)
 	m.errBox.SetError(err)
+
+	// Commit failures are usually hook rejections, whose output doesn't fit in the error box.
+	var commitErr *git.CommitError
+	if errors.As(err, &commitErr) && commitErr.Output != "" {
+		m.showCommitOutput(commitErr)
+	}
+
 	return func() tea.Msg {
 		select {
 		case <-m.ctx.Done():
@@ -900,6 +1541,46 @@ func (m *home) handleError(err error) tea.Cmd {
 	}
 }
 
+// instanceReady handles an instance that just finished working on a prompt.
+func (m *home) instanceReady(instance *session.Instance) tea.Cmd {
+	if m.appConfig.AutoTitle {
+		m.refineTitle(instance)
+	}
+	switch {
+	case instance.PlanState == session.PlanDrafting:
+		return m.capturePlan(instance)
+	case instance.SummaryPending():
+		return m.captureSummary(instance)
+	case m.appConfig.AutoSummary && instance.PlanState != session.PlanAwaitingApproval:
+		if err := instance.RequestSummary(m.appConfig.GetSummaryPrompt()); err != nil {
+			return m.handleError(err)
+		}
+	}
+	return nil
+}
+
+// refineTitle replaces the instance's placeholder title now that it has finished working on a prompt.
+func (m *home) refineTitle(instance *session.Instance) {
+	isTaken := func(title string) bool {
+		for _, other := range m.list.GetInstances() {
+			if other != instance && other.Title == title {
+				return true
+			}
+		}
+		return false
+	}
+	renamed, err := instance.RefineTitle(isTaken)
+	if err != nil {
+		log.WarningLog.Printf("could not refine title of %s: %v", instance.Title, err)
+		return
+	}
+	if renamed {
+		if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+			log.WarningLog.Printf("could not save renamed instance: %v", err)
+		}
+	}
+}
+
 // trackRepository ensures the repository is tracked in state when an instance is created
 func (m *home) trackRepository(instance *session.Instance) error {
 	// Get repository path from instance
@@ -925,6 +1606,11 @@ func (m *home) trackRepository(instance *session.Instance) error {
 		return fmt.Errorf("state is not a concrete State type")
 	}
 	
+	// The repository keeps its tab from now on, even once its instances are gone
+	if m.repoTabs != nil {
+		m.repoTabs.RegisterRepo(repoPath)
+	}
+	
 	// Check if repository already exists in state
 	_, err := state.GetRepository(repoPath)
 	if err == nil {
@@ -953,22 +1639,19 @@ func (m *home) trackRepository(instance *session.Instance) error {
 
 // initializeRepositoryState performs cleanup and initialization of repository state
 func (m *home) initializeRepositoryState() error {
-	// Clean up invalid repositories
-	var removedCount int
-	var err error
+	// Clean up invalid repositories and their orphaned instances
 	if state, ok := m.appState.(*config.State); ok {
-		removedCount, err = state.CleanupInvalidRepositories()
+		report, err := config.NewRepositoryManager(state, m.storage).Cleanup()
 		if err != nil {
-			return fmt.Errorf("failed to cleanup invalid repositories: %w", err)
+			return err
 		}
-		if removedCount > 0 && log.InfoLog != nil {
-			log.InfoLog.Printf("Cleaned up %d invalid repositories", removedCount)
+		if len(report.Repositories) > 0 || len(report.Instances) > 0 {
+			log.InfoLog.Printf("Cleaned up %d invalid repositories and %d orphaned instances",
+				len(report.Repositories), len(report.Instances))
+		}
+		for _, kept := range report.Kept {
+			log.WarningLog.Printf("cleanup kept %s", kept)
 		}
-	}
-	
-	// Clean up orphaned instances
-	if err := m.storage.CleanupOrphanedInstances(); err != nil {
-		return fmt.Errorf("failed to cleanup orphaned instances: %w", err)
 	}
 	
 	// Migrate instances to ensure they have repository paths set
@@ -1020,6 +1703,16 @@ func (m *home) ensureRepositoryTracked(path string) error {
 	return state.AddRepository(repoData)
 }
 
+// confirmKill confirms stopping, force-killing or purging an instance, unless the user chose not to be asked.
+func (m *home) confirmKill(message string, action tea.Cmd) tea.Cmd {
+	if m.appState.GetPreferences().GetConfirmOnKill() {
+		return m.confirmAction(message, action)
+	}
+	// Like a confirmed action, it runs before the next update.
+	result := action()
+	return func() tea.Msg { return result }
+}
+
 // confirmAction shows a confirmation modal and stores the action to execute on confirm
 func (m *home) confirmAction(message string, action tea.Cmd) tea.Cmd {
 	m.state = stateConfirm
@@ -1032,9 +1725,9 @@ func (m *home) confirmAction(message string, action tea.Cmd) tea.Cmd {
 	// Set callbacks for confirmation and cancellation
 	m.confirmationOverlay.OnConfirm = func() {
 		m.state = stateDefault
-		// Execute the action if it exists
+		// Execute the action if it exists and hand its result back to the update loop
 		if action != nil {
-			_ = action()
+			m.confirmResult = action()
 		}
 	}
 
@@ -1046,9 +1739,17 @@ func (m *home) confirmAction(message string, action tea.Cmd) tea.Cmd {
 }
 
 func (m *home) View() string {
-	listWithPadding := lipgloss.NewStyle().PaddingTop(1).Render(m.list.String())
-	previewWithPadding := lipgloss.NewStyle().PaddingTop(1).Render(m.tabbedWindow.String())
-	listAndPreview := lipgloss.JoinHorizontal(lipgloss.Top, listWithPadding, previewWithPadding)
+	var listAndPreview string
+	switch {
+	case m.narrow && m.showPreview:
+		listAndPreview = lipgloss.NewStyle().PaddingTop(1).Render(m.tabbedWindow.String())
+	case m.narrow:
+		listAndPreview = lipgloss.NewStyle().PaddingTop(1).Render(m.list.String())
+	default:
+		listWithPadding := lipgloss.NewStyle().PaddingTop(1).Render(m.list.String())
+		previewWithPadding := lipgloss.NewStyle().PaddingTop(1).Render(m.tabbedWindow.String())
+		listAndPreview = lipgloss.JoinHorizontal(lipgloss.Top, listWithPadding, previewWithPadding)
+	}
 
 	// Build main content components
 	components := []string{}
@@ -1069,12 +1770,14 @@ func (m *home) View() string {
 		components...,
 	)
 
-	if m.state == statePrompt {
+	if m.state == statePrompt || m.state == statePlanReview || m.state == stateTicket || m.state == stateBudget ||
+		m.state == stateProgram {
 		if m.textInputOverlay == nil {
 			log.ErrorLog.Printf("text input overlay is nil")
 		}
 		return overlay.PlaceOverlay(0, 0, m.textInputOverlay.Render(), mainView, true, true)
-	} else if m.state == stateHelp {
+	} else if m.state == stateHelp || m.state == stateStalled || m.state == stateDatabase || m.state == stateStats ||
+		m.state == stateReview {
 		if m.textOverlay == nil {
 			log.ErrorLog.Printf("text overlay is nil")
 		}
@@ -1089,6 +1792,12 @@ func (m *home) View() string {
 			log.ErrorLog.Printf("directory picker is nil")
 		}
 		return overlay.PlaceOverlay(0, 0, m.directoryPicker.View(), mainView, true, false)
+	} else if m.state == stateCompare {
+		return overlay.PlaceOverlay(0, 0, m.comparePane.String(), mainView, true, true)
+	} else if m.state == stateTaskPicker || m.state == stateSnooze || m.state == statePalette ||
+		m.state == stateRepoPicker || m.state == stateBranchPicker || m.state == stateAdoptPicker ||
+		m.state == stateWorkspace {
+		return overlay.PlaceOverlay(0, 0, m.selectionOverlay.Render(), mainView, true, true)
 	}
 
 	return mainView
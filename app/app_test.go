@@ -1,15 +1,22 @@
 package app
 
 import (
+	"claude-squad/cmd/cmd_test"
 	"claude-squad/config"
 	"claude-squad/log"
+	"claude-squad/retry"
 	"claude-squad/session"
+	"claude-squad/session/tmux"
 	"claude-squad/ui"
 	"claude-squad/ui/overlay"
 	"context"
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
@@ -105,6 +112,25 @@ func TestConfirmationModalStateTransitions(t *testing.T) {
 	})
 }
 
+func TestQuitWithQueuedOperations(t *testing.T) {
+	h := &home{
+		ctx:        context.Background(),
+		state:      stateDefault,
+		appConfig:  config.DefaultConfig(),
+		retryQueue: retry.NewQueue(),
+	}
+	h.retryQueue.Add("push of 'docs'", func() error { return nil })
+
+	// The queued operations would be lost, so quitting asks first.
+	_, cmd := h.handleQuit()
+	require.Nil(t, cmd)
+	require.Equal(t, stateConfirm, h.state)
+	require.Contains(t, h.confirmationOverlay.Render(), "'docs'")
+
+	h.confirmationOverlay.HandleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	require.Equal(t, stateDefault, h.state)
+}
+
 // TestConfirmationModalKeyHandling tests the actual key handling in confirmation state
 func TestConfirmationModalKeyHandling(t *testing.T) {
 	// Import needed packages
@@ -464,3 +490,235 @@ func TestConfirmationModalVisualAppearance(t *testing.T) {
 	// Test that the danger indicator is preserved
 	assert.Contains(t, rendered, "[!")
 }
+
+// TestRepoPickerMovesPendingInstance tests picking another repository for the instance being named
+func TestRepoPickerMovesPendingInstance(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	s := spinner.New(spinner.WithSpinner(spinner.MiniDot))
+	list := ui.NewList(&s, false)
+	h := &home{
+		ctx:       context.Background(),
+		state:     stateNew,
+		appConfig: config.DefaultConfig(),
+		appState:  config.LoadState(),
+		list:      list,
+		menu:      ui.NewMenu(),
+		errBox:    ui.NewErrBox(),
+		repoTabs:  list.GetRepoTabs(),
+	}
+	first, second := t.TempDir(), t.TempDir()
+	h.repoTabs.RegisterRepo(first)
+	h.repoTabs.RegisterRepo(second)
+
+	pending, err := session.NewInstance(session.InstanceOptions{Title: "fix", Path: first, Program: "claude"})
+	require.NoError(t, err)
+	h.newInstanceFinalizer = list.AddInstance(pending)
+	list.SetSelectedInstance(0)
+
+	h.showRepoPicker()
+	require.Equal(t, stateRepoPicker, h.state)
+	h.handleRepoPickerState(tea.KeyMsg{Type: tea.KeyDown})
+	h.handleRepoPickerState(tea.KeyMsg{Type: tea.KeyEnter})
+
+	require.Equal(t, stateNew, h.state)
+	require.Equal(t, 1, list.NumInstances())
+	moved := list.GetInstances()[0]
+	require.Equal(t, "fix", moved.Title)
+	require.Equal(t, second, moved.Path)
+	require.Equal(t, second, list.GetCurrentRepoPath())
+}
+
+// TestPickNewInstanceRepo tests creating an instance in a registered repository other than the launch directory
+func TestPickNewInstanceRepo(t *testing.T) {
+	s := spinner.New(spinner.WithSpinner(spinner.MiniDot))
+	list := ui.NewList(&s, false)
+	old, recent := t.TempDir(), t.TempDir()
+	state := config.DefaultState()
+	state.Repositories = []config.RepositoryData{
+		{Path: old, LastAccessed: time.Now().Add(-time.Hour)},
+		{Path: recent, LastAccessed: time.Now()},
+	}
+	h := &home{
+		ctx:       context.Background(),
+		state:     stateDefault,
+		appConfig: config.DefaultConfig(),
+		appState:  state,
+		list:      list,
+		menu:      ui.NewMenu(),
+		errBox:    ui.NewErrBox(),
+		repoTabs:  list.GetRepoTabs(),
+		targetDir: t.TempDir(),
+	}
+
+	h.pickNewInstanceRepo()
+	require.Equal(t, stateRepoPicker, h.state)
+	require.Equal(t, []string{recent, old}, h.pickerRepos)
+	h.handleRepoPickerState(tea.KeyMsg{Type: tea.KeyDown})
+	h.handleRepoPickerState(tea.KeyMsg{Type: tea.KeyEnter})
+
+	require.Equal(t, stateNew, h.state)
+	require.Equal(t, 1, list.NumInstances())
+	require.Equal(t, old, list.GetInstances()[0].Path)
+}
+
+// TestSpinnerOnlyChangesStall tests that an instance whose only changing output is claude's working indicator
+// is marked as stalled
+func TestSpinnerOnlyChangesStall(t *testing.T) {
+	frame := 0
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error { return nil },
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			if !strings.Contains(cmd.String(), "capture-pane") {
+				return nil, fmt.Errorf("no clients")
+			}
+			frame++
+			spinner := []string{"✶", "✳", "✢", "·"}[frame%4]
+			return []byte(fmt.Sprintf("⏺ Reading app/app.go\n\n%s Thinking… (%ds · esc to interrupt)\n> ", spinner, frame)), nil
+		},
+	}
+	instance, err := session.NewInstance(session.InstanceOptions{Title: "hung", Path: t.TempDir(), Program: "claude"})
+	require.NoError(t, err)
+	instance.SetTmuxSession(tmux.NewTmuxSessionWithDeps("hung", "claude", tmux.MakePtyFactory(), cmdExec))
+	instance.SetStatus(session.Running)
+
+	cfg := config.DefaultConfig()
+	cfg.StallTimeout = 1
+	h := &home{
+		ctx:       context.Background(),
+		appConfig: cfg,
+		errBox:    ui.NewErrBox(),
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for instance.Status != session.Stalled && time.Now().Before(deadline) {
+		h.updateStatus(instance)
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.Equal(t, session.Stalled, instance.Status)
+	require.GreaterOrEqual(t, frame, 10)
+
+	// The spinner keeps ticking, which doesn't end the stall.
+	h.updateStatus(instance)
+	require.Equal(t, session.Stalled, instance.Status)
+}
+
+func TestProgramFor(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repo := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(repo, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, config.RepoFileName), []byte("default_program: aider\n"), 0644))
+
+	appConfig := config.DefaultConfig()
+	h := &home{
+		ctx:       context.Background(),
+		appConfig: appConfig,
+		appState:  config.LoadState(),
+		program:   appConfig.DefaultProgram,
+	}
+	require.Equal(t, appConfig.DefaultProgram, h.programFor(repo), "the repository's default isn't run until allowed")
+
+	repoData, err := config.CreateRepositoryData(repo)
+	require.NoError(t, err)
+	require.NoError(t, h.appState.AddRepository(repoData))
+	require.NoError(t, h.appState.SetRepositoryProgramAllowed(repo, false))
+	require.Equal(t, appConfig.DefaultProgram, h.programFor(repo))
+	require.NoError(t, h.appState.SetRepositoryProgramAllowed(repo, true))
+	require.Equal(t, "aider", h.programFor(repo), "the repository's default overrides the config once allowed")
+
+	// Adding the repository again keeps the answer while its program is the same.
+	require.NoError(t, h.appState.AddRepository(repoData))
+	require.Equal(t, "aider", h.programFor(repo))
+
+	// A saved preference is the default too, which the repository's default overrides.
+	h.program = "codex"
+	require.Equal(t, "aider", h.programFor(repo))
+
+	// The program given with --program overrides the repository's, even if it's the config's default.
+	h.program, h.programGiven = appConfig.DefaultProgram, true
+	require.Equal(t, appConfig.DefaultProgram, h.programFor(repo))
+
+	// The program the user chose for the repository overrides its default.
+	h.programGiven = false
+	require.NoError(t, h.appState.SetRepositoryProgram(repo, "gemini", "--yolo"))
+	require.Equal(t, "gemini --yolo", h.programFor(repo))
+}
+
+func TestAskRepoProgram(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repo := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(repo, ".git"), 0755))
+
+	appConfig := config.DefaultConfig()
+	h := &home{
+		ctx:       context.Background(),
+		appConfig: appConfig,
+		appState:  config.LoadState(),
+		program:   appConfig.DefaultProgram,
+	}
+	next := newInstanceMsg{path: repo}
+	require.False(t, h.askRepoProgram(repo, next), "there's nothing to ask without a program")
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, config.RepoFileName), []byte("default_program: aider\n"), 0644))
+	require.True(t, h.askRepoProgram(repo, next))
+	require.Equal(t, stateConfirm, h.state)
+	_, err := h.appState.GetRepository(repo)
+	require.NoError(t, err, "the repository is added to keep the answer")
+
+	// Declining still creates the instance, with the default program.
+	h.confirmationOverlay.OnCancel()
+	require.Equal(t, next, h.confirmResult)
+	require.Equal(t, appConfig.DefaultProgram, h.programFor(repo))
+	require.False(t, h.askRepoProgram(repo, next), "the user is only asked once")
+}
+
+func TestAutoYesFor(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repo := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(repo, ".git"), 0755))
+
+	tests := []struct {
+		name     string
+		global   bool
+		file     string
+		expected bool
+	}{
+		{name: "global off", global: false, expected: false},
+		{name: "global on", global: true, expected: true},
+		{name: "repository turns it off", global: true, file: "auto_yes: false\n", expected: false},
+		{name: "repository can't turn it on", global: false, file: "auto_yes: true\n", expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, os.WriteFile(filepath.Join(repo, config.RepoFileName), []byte(tt.file), 0644))
+			h := &home{appState: config.LoadState(), autoYes: tt.global}
+			instance, err := session.FromInstanceData(session.InstanceData{
+				Title:    "paused",
+				Status:   session.Paused,
+				Worktree: session.GitWorktreeData{RepoPath: repo},
+			})
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, h.autoYesFor(instance))
+		})
+	}
+}
+
+func TestAlertQueuedWhenOffline(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Alerts = config.AlertConfig{
+		Events:  []string{config.AlertReady},
+		Command: `echo "curl: (6) Could not resolve host: hooks.example.com" >&2; exit 6`,
+	}
+	h := &home{
+		ctx:        context.Background(),
+		appConfig:  cfg,
+		retryQueue: retry.NewQueue(),
+	}
+	instance, err := session.NewInstance(session.InstanceOptions{Title: "docs", Path: t.TempDir(), Program: "claude"})
+	require.NoError(t, err)
+
+	// The webhook the command posts to couldn't be reached, so the alert is sent again later.
+	cmd := h.alert(instance, config.AlertReady)
+	require.NotNil(t, cmd)
+	require.Equal(t, operationQueuedMsg{name: "ready alert of 'docs'"}, cmd())
+	require.Equal(t, []string{"ready alert of 'docs'"}, h.retryQueue.Names())
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"claude-squad/session/git"
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxTitleLength is the length of the longest title a new instance can be given.
+const maxTitleLength = 32
+
+// showBranchPicker lists the existing branches of the selected repository, so the user can create an instance
+// that checks one out, e.g. to hand a branch someone is working on to an agent.
+func (m *home) showBranchPicker() (tea.Model, tea.Cmd) {
+	repo := m.list.GetCurrentRepoPath()
+	if repo == "" {
+		repo = m.targetDir
+	}
+	if repo == "" {
+		return m, m.handleError(fmt.Errorf("select a repository to create the session in first"))
+	}
+	branches, err := git.Branches(repo)
+	if err != nil {
+		return m, m.handleError(err)
+	}
+	if len(branches) == 0 {
+		return m, m.handleError(fmt.Errorf("no branches to check out in %s, branches checked out elsewhere aren't listed", ui.RepoDisplayName(repo)))
+	}
+
+	items := make([]overlay.SelectionItem, 0, len(branches))
+	for _, branch := range branches {
+		description := branch.Subject
+		if branch.Remote {
+			description = "remote · " + description
+		}
+		items = append(items, overlay.SelectionItem{Label: branch.Name, Description: description})
+	}
+	m.pickerBranches = branches
+	m.pickerBranchRepo = repo
+	m.selectionOverlay = overlay.NewSelectionOverlay("Create a session from a branch", items)
+	m.state = stateBranchPicker
+	return m, nil
+}
+
+// handleBranchPickerState handles key presses while the branch picker is shown. Picking a branch starts naming
+// a new instance that checks it out, titled after the branch.
+func (m *home) handleBranchPickerState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.selectionOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+	selected := m.selectionOverlay.Selected()
+	branches, repo := m.pickerBranches, m.pickerBranchRepo
+	m.selectionOverlay = nil
+	m.pickerBranches = nil
+	m.pickerBranchRepo = ""
+	m.state = stateDefault
+	if selected < 0 {
+		return m, tea.WindowSize()
+	}
+
+	if err := m.resetNewInstance(false); err != nil {
+		return m, tea.Batch(tea.WindowSize(), m.handleError(err))
+	}
+	branch := branches[selected].Name
+	return m, tea.Batch(tea.WindowSize(), m.addNewInstance(repo, branchTitle(branch), branch))
+}
+
+// branchTitle returns the title of an instance created from branch: its last part, e.g. login for
+// origin/fix/login.
+func branchTitle(branch string) string {
+	title := branch[strings.LastIndex(branch, "/")+1:]
+	if len(title) > maxTitleLength {
+		title = title[:maxTitleLength]
+	}
+	return title
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"claude-squad/audit"
+	"claude-squad/config"
+	"claude-squad/keys"
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// showBudgetInput asks for the selected instance's budget.
+func (m *home) showBudgetInput() tea.Cmd {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return nil
+	}
+	m.textInputOverlay = overlay.NewTextInputOverlay(
+		fmt.Sprintf("Budget of '%s', e.g. 2h, $5 or 90m $2.50. Empty for none", selected.Title), selected.Budget.String())
+	m.state = stateBudget
+	m.menu.SetState(ui.StatePrompt)
+	return nil
+}
+
+// handleBudgetState handles key presses while the budget is being entered, and sets it once it's submitted.
+func (m *home) handleBudgetState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.textInputOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+	submitted := m.textInputOverlay.IsSubmitted()
+	value := m.textInputOverlay.GetValue()
+	m.textInputOverlay = nil
+	m.state = stateDefault
+	m.menu.SetState(ui.StateDefault)
+
+	selected := m.list.GetSelectedInstance()
+	if !submitted || selected == nil {
+		return m, tea.WindowSize()
+	}
+	budget, err := session.ParseBudget(value)
+	if err != nil {
+		return m, tea.Batch(tea.WindowSize(), m.handleError(err))
+	}
+	selected.SetBudget(budget)
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		return m, tea.Batch(tea.WindowSize(), m.handleError(err))
+	}
+	if budget.IsZero() {
+		return m, tea.Batch(tea.WindowSize(), m.handleInfo(fmt.Sprintf("'%s' has no budget", selected.Title)))
+	}
+	return m, tea.Batch(tea.WindowSize(), m.handleInfo(fmt.Sprintf("budget of '%s' set to %s", selected.Title, budget)))
+}
+
+// checkBudget interrupts the instance if it's over its budget, and notifies the user.
+func (m *home) checkBudget(instance *session.Instance) tea.Cmd {
+	reason, err := instance.CheckBudget()
+	if reason == "" {
+		return nil
+	}
+	if err != nil {
+		log.ErrorLog.Printf("failed to interrupt %s, which is over budget: %v", instance.Title, err)
+	}
+	audit.Record(audit.Entry{Action: "over_budget", Instance: instance.Title, Repository: instance.RepositoryPath, Detail: reason})
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		log.ErrorLog.Printf("failed to save instances: %v", err)
+	}
+	return tea.Batch(m.alert(instance, config.AlertOverBudget), m.handleError(fmt.Errorf(
+		"'%s' %s and was interrupted, press %s to change its budget", instance.Title, reason, keys.Help(keys.KeyBudget))))
+}
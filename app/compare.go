@@ -0,0 +1,86 @@
+package app
+
+import (
+	"claude-squad/keys"
+	"claude-squad/session/git"
+	"fmt"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// handleCompare marks the selected instance as the base of a comparison, or, if another instance is
+// already marked, opens the comparison between the two.
+func (m *home) handleCompare() tea.Cmd {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil || !selected.Started() {
+		return nil
+	}
+
+	// The marked instance may have been killed since.
+	if m.compareBase != nil && !slices.Contains(m.list.GetInstances(), m.compareBase) {
+		m.compareBase = nil
+	}
+
+	if m.compareBase == nil || m.compareBase == selected {
+		m.compareBase = selected
+		return m.handleInfo(fmt.Sprintf("marked '%s' for comparison, select another instance and press = again", selected.Title))
+	}
+
+	base := m.compareBase
+	m.compareBase = nil
+
+	baseWorktree, err := base.GetGitWorktree()
+	if err != nil {
+		return m.handleError(err)
+	}
+	selectedWorktree, err := selected.GetGitWorktree()
+	if err != nil {
+		return m.handleError(err)
+	}
+	comparison, err := git.Compare(baseWorktree, selectedWorktree)
+	if err != nil {
+		return m.handleError(err)
+	}
+
+	m.errBox.Clear()
+	m.comparePane.SetComparison(base.Title, selected.Title, comparison)
+	m.state = stateCompare
+	return nil
+}
+
+// handleCompareState handles key presses while the comparison is displayed.
+func (m *home) handleCompareState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "esc" {
+		m.state = stateDefault
+		return m, nil
+	}
+
+	name, ok := keys.GlobalKeyStringsMap[msg.String()]
+	if !ok {
+		return m, nil
+	}
+	switch name {
+	case keys.KeyQuit, keys.KeyCompare:
+		m.state = stateDefault
+	case keys.KeyUp, keys.KeyShiftUp:
+		m.comparePane.ScrollUp()
+	case keys.KeyDown, keys.KeyShiftDown:
+		m.comparePane.ScrollDown()
+	}
+	return m, nil
+}
+
+// handleInfo shows a status message in the error box and clears it after 3 seconds.
+func (m *home) handleInfo(info string) tea.Cmd {
+	m.errBox.SetInfo(info)
+	return func() tea.Msg {
+		select {
+		case <-m.ctx.Done():
+		case <-time.After(3 * time.Second):
+		}
+
+		return hideErrMsg{}
+	}
+}
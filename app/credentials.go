@@ -0,0 +1,83 @@
+package app
+
+import (
+	"claude-squad/session/git"
+	"errors"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// credentialsRequiredMsg is returned by an operation whose git command needs credentials entered.
+type credentialsRequiredMsg struct {
+	// operation names the operation, e.g. "push of 'fix-login'".
+	operation string
+	err       *git.CredentialsRequiredError
+}
+
+// enterCredentialsMsg hands the terminal to the git command of an operation that needs credentials.
+type enterCredentialsMsg credentialsRequiredMsg
+
+// credentialsEnteredMsg is returned when the git command given the terminal exits.
+type credentialsEnteredMsg struct {
+	operation string
+	err       error
+	// credErr is the error the command was run for, which resumes the rest of the operation.
+	credErr *git.CredentialsRequiredError
+}
+
+// operationResumedMsg is returned when the rest of an operation, resumed after credentials were entered,
+// finishes.
+type operationResumedMsg struct {
+	operation string
+	err       error
+}
+
+// credentialsRequired returns a credentialsRequiredMsg for the operation if err says its git command needs
+// credentials, and nil otherwise.
+func credentialsRequired(operation string, err error) tea.Msg {
+	var credErr *git.CredentialsRequiredError
+	if !errors.As(err, &credErr) {
+		return nil
+	}
+	return credentialsRequiredMsg{operation: operation, err: credErr}
+}
+
+// handleCredentialsRequired asks whether to run the git command that needs credentials in the terminal, where
+// git and ssh can prompt for them.
+func (m *home) handleCredentialsRequired(msg credentialsRequiredMsg) tea.Cmd {
+	message := fmt.Sprintf("[!] The %s needs credentials:\n%s\n\nEnter them in the terminal?", msg.operation,
+		msg.err.Prompt())
+	return m.confirmAction(message, func() tea.Msg { return enterCredentialsMsg(msg) })
+}
+
+// enterCredentials suspends the TUI and runs the git command with the terminal's input and output, so the
+// user answers its prompts directly. They're never seen by claude-squad.
+func (m *home) enterCredentials(msg enterCredentialsMsg) tea.Cmd {
+	return tea.ExecProcess(msg.err.Command(), func(err error) tea.Msg {
+		return credentialsEnteredMsg{operation: msg.operation, err: err, credErr: msg.err}
+	})
+}
+
+// handleCredentialsEntered resumes the rest of the operation once the git command given the terminal
+// succeeded, e.g. opening the pull request after a push.
+func (m *home) handleCredentialsEntered(msg credentialsEnteredMsg) tea.Cmd {
+	if msg.err != nil {
+		return m.handleError(fmt.Errorf("%s failed: %w", msg.operation, msg.err))
+	}
+	return func() tea.Msg {
+		err := msg.credErr.Resume()
+		if credMsg := credentialsRequired(msg.operation, err); credMsg != nil {
+			return credMsg
+		}
+		return operationResumedMsg{operation: msg.operation, err: err}
+	}
+}
+
+// handleOperationResumed reports whether the operation finished.
+func (m *home) handleOperationResumed(msg operationResumedMsg) tea.Cmd {
+	if msg.err != nil {
+		return m.handleError(fmt.Errorf("%s failed: %w", msg.operation, msg.err))
+	}
+	return m.handleInfo(msg.operation + " succeeded")
+}
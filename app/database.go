@@ -0,0 +1,75 @@
+package app
+
+import (
+	"claude-squad/keys"
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/ui/overlay"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// databaseStatus describes the state of an instance's database snapshot.
+func databaseStatus(db session.DatabaseState) string {
+	switch {
+	case db.Error != "":
+		return "failed: " + db.Error
+	case db.SnapshotAt.IsZero():
+		return "no snapshot yet"
+	default:
+		return fmt.Sprintf("snapshot taken %s ago", time.Since(db.SnapshotAt).Round(time.Second))
+	}
+}
+
+// showDatabaseActions displays the state of the selected instance's database and the actions on it.
+func (m *home) showDatabaseActions() tea.Cmd {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return nil
+	}
+	if !selected.HasDatabase() {
+		return m.handleInfo("no database hooks are configured for this repository")
+	}
+
+	name := selected.Database.Name
+	if name == "" {
+		name = "(not created)"
+	}
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		titleStyle.Render("Instance Database"),
+		"",
+		descStyle.Render(fmt.Sprintf("Database: %s", name)),
+		descStyle.Render(fmt.Sprintf("Status:   %s", databaseStatus(selected.Database))),
+		"",
+		headerStyle.Render("Actions:"),
+		keyStyle.Render(keys.Help(keys.KeyResetDatabase))+descStyle.Render(" - Reset the database to a fresh snapshot"),
+		"",
+		descStyle.Render("Press any other key to dismiss."),
+	)
+	m.textOverlay = overlay.NewTextOverlay(content)
+	m.state = stateDatabase
+	return nil
+}
+
+// handleDatabaseState runs the chosen action on the selected instance's database.
+func (m *home) handleDatabaseState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.state = stateDefault
+	m.textOverlay = nil
+
+	selected := m.list.GetSelectedInstance()
+	if name, _ := keys.OverlayKey(keys.OverlayDatabase, msg.String()); selected == nil || name != keys.KeyResetDatabase {
+		return m, nil
+	}
+
+	err := selected.ResetDatabase()
+	if saveErr := m.storage.SaveInstances(m.list.GetInstances()); saveErr != nil {
+		log.WarningLog.Printf("could not save database state: %v", saveErr)
+	}
+	if err != nil {
+		return m, m.handleError(err)
+	}
+	return m, m.handleInfo(fmt.Sprintf("reset the database of '%s'", selected.Title))
+}
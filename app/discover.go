@@ -0,0 +1,46 @@
+package app
+
+import (
+	"claude-squad/log"
+	"claude-squad/session/git"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// reposDiscoveredMsg carries the repositories found by scanning the discovery roots. repos is nil if the roots
+// couldn't be scanned at all.
+type reposDiscoveredMsg struct {
+	repos []string
+	err   error
+}
+
+// discoverRepos scans the discovery roots for repositories in the background, if there are any.
+func (m *home) discoverRepos() tea.Cmd {
+	discovery := m.appConfig.Discovery
+	if len(discovery.Roots) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		roots, err := discovery.GetRoots()
+		if err != nil {
+			return reposDiscoveredMsg{err: err}
+		}
+		repos, err := git.FindRepositories(roots, discovery.GetDepth())
+		return reposDiscoveredMsg{repos: repos, err: err}
+	}
+}
+
+// handleReposDiscovered saves the discovered repositories, which the repository picker offers.
+func (m *home) handleReposDiscovered(msg reposDiscoveredMsg) tea.Cmd {
+	if msg.err != nil {
+		// A root that's missing, e.g. on another machine, doesn't keep the others' repositories from being offered.
+		log.WarningLog.Printf("failed to discover repositories: %v", msg.err)
+	}
+	if msg.repos == nil {
+		return nil
+	}
+	if err := m.appState.SetDiscoveredRepositories(msg.repos); err != nil {
+		return m.handleError(err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"claude-squad/archive"
+	"claude-squad/audit"
+	"claude-squad/digest"
+	"claude-squad/hooks"
+	"claude-squad/ui/overlay"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// instanceFrozenMsg is sent once an instance has been archived and killed.
+type instanceFrozenMsg struct {
+	entry *archive.Entry
+}
+
+// freezeInstance asks for confirmation, then archives the selected instance's worktree, branch and
+// metadata to a tarball and kills it.
+func (m *home) freezeInstance() tea.Cmd {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return nil
+	}
+
+	freeze := func() tea.Msg {
+		worktree, err := selected.GetGitWorktree()
+		if err != nil {
+			return err
+		}
+		checkedOut, err := worktree.IsBranchCheckedOut()
+		if err != nil {
+			return err
+		}
+		if checkedOut {
+			return fmt.Errorf("instance %s is currently checked out", selected.Title)
+		}
+
+		dir, err := m.appConfig.GetArchiveDir()
+		if err != nil {
+			return err
+		}
+		entry, err := archive.Freeze(selected.ToInstanceData(), dir)
+		if err != nil {
+			return fmt.Errorf("failed to freeze instance %s: %w", selected.Title, err)
+		}
+		audit.Record(audit.Entry{
+			Action:     "instance_frozen",
+			Instance:   selected.Title,
+			Repository: worktree.GetRepoPath(),
+			Detail:     entry.Path,
+		})
+
+		if err := m.list.Kill(); err != nil {
+			return fmt.Errorf("archived %s to %s, but %w", selected.Title, entry.Path, err)
+		}
+		if err := m.storage.DeleteInstance(selected.Title); err != nil {
+			return err
+		}
+		hooks.Run(hooks.InstanceKilled, selected)
+		digest.RecordKilled(selected)
+		return instanceFrozenMsg{entry: entry}
+	}
+
+	message := fmt.Sprintf("[!] Archive session '%s' to a tarball and kill it?", selected.Title)
+	return m.confirmAction(message, freeze)
+}
+
+// showHistory displays the frozen instances and where their archives are.
+func (m *home) showHistory() tea.Cmd {
+	entries, err := archive.History()
+	if err != nil {
+		return m.handleError(err)
+	}
+	if len(entries) == 0 {
+		return m.handleInfo("no sessions have been frozen yet")
+	}
+
+	lines := []string{titleStyle.Render("Frozen Sessions"), ""}
+	// Newest first.
+	for i := len(entries) - 1; i >= 0; i-- {
+		lines = append(lines, descStyle.Render(entries[i].Describe()))
+	}
+	lines = append(lines, "", descStyle.Render("Press any key to dismiss."))
+	m.textOverlay = overlay.NewTextOverlay(lipgloss.JoinVertical(lipgloss.Left, lines...))
+	m.state = stateHelp
+	return nil
+}
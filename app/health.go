@@ -0,0 +1,79 @@
+package app
+
+import (
+	"claude-squad/health"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// healthCheckedMsg carries the reports of the health checks, by instance title.
+type healthCheckedMsg struct {
+	reports map[string]health.Report
+}
+
+// checkHealth runs the health checks on the running instances in the background, at most every configured
+// interval.
+func (m *home) checkHealth() tea.Cmd {
+	interval := m.appConfig.Health.GetInterval()
+	if m.healthChecker == nil || interval <= 0 || time.Since(m.healthCheckedAt) < interval {
+		return nil
+	}
+	m.healthCheckedAt = time.Now()
+
+	var targets []health.Target
+	for _, instance := range m.list.GetInstances() {
+		if instance.Started() && !instance.Inactive() && !instance.Dormant() {
+			targets = append(targets, health.TargetFor(instance))
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+	checker := m.healthChecker
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(m.ctx, interval)
+		defer cancel()
+		reports := make(map[string]health.Report, len(targets))
+		for _, target := range targets {
+			reports[target.Title] = checker.Run(ctx, target)
+		}
+		return healthCheckedMsg{reports: reports}
+	}
+}
+
+// handleHealthChecked shows the problems the health checks found in the list, and reports those of instances
+// that just became unhealthy and those that were fixed.
+func (m *home) handleHealthChecked(msg healthCheckedMsg) tea.Cmd {
+	problems := make(map[string]string, len(msg.reports))
+	var failing, fixed []string
+	unhealthy := make(map[string]bool)
+	for title, report := range msg.reports {
+		for _, result := range report.Remediated {
+			fixed = append(fixed, fmt.Sprintf("%s of '%s'", result.Message, title))
+		}
+		if summary := report.Summary(); summary != "" {
+			problems[title] = summary
+		}
+		if report.Status() == health.Failing {
+			unhealthy[title] = true
+			if !m.unhealthy[title] {
+				failing = append(failing, fmt.Sprintf("'%s': %s", title, report.Summary()))
+			}
+		}
+	}
+	m.unhealthy = unhealthy
+	m.list.SetHealth(problems)
+
+	var cmds []tea.Cmd
+	if len(failing) > 0 {
+		cmds = append(cmds, m.handleError(fmt.Errorf("unhealthy %s", strings.Join(failing, "; "))))
+	}
+	if len(fixed) > 0 {
+		cmds = append(cmds, m.handleInfo("fixed "+strings.Join(fixed, ", ")))
+	}
+	return tea.Batch(cmds...)
+}
@@ -1,34 +1,18 @@
 package app
 
 import (
-	"claude-squad/log"
-	"claude-squad/session"
+	"claude-squad/keys"
+	"claude-squad/session/git"
+	"claude-squad/session/tmux"
 	"claude-squad/ui"
 	"claude-squad/ui/overlay"
 	"fmt"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
-type helpType int
-
-// Make a help state type enum
-const (
-	helpTypeGeneral helpType = iota
-	helpTypeInstanceStart
-	helpTypeInstanceAttach
-	helpTypeInstanceCheckout
-)
-
-// Help screen bit flags for tracking in config
-const (
-	HelpFlagGeneral          uint32 = 1 << helpTypeGeneral
-	HelpFlagInstanceStart    uint32 = 1 << helpTypeInstanceStart
-	HelpFlagInstanceAttach   uint32 = 1 << helpTypeInstanceAttach
-	HelpFlagInstanceCheckout uint32 = 1 << helpTypeInstanceCheckout
-)
-
 var (
 	titleStyle  = lipgloss.NewStyle().Bold(true).Underline(true).Foreground(lipgloss.Color("#7D56F4"))
 	headerStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#36CFC9"))
@@ -36,121 +20,102 @@ var (
 	descStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFFFFF"))
 )
 
-func (h helpType) ToContent(instance *session.Instance) string {
-	switch h {
-	case helpTypeGeneral:
-		content := lipgloss.JoinVertical(lipgloss.Left,
-			titleStyle.Render("Claude Squad"),
-			"",
-			"A terminal UI that manages multiple Claude Code (and other local agents) in separate workspaces.",
-			"",
-			headerStyle.Render("Managing:"),
-			keyStyle.Render("n")+descStyle.Render("         - Create a new session"),
-			keyStyle.Render("N")+descStyle.Render("         - Create a new session with a prompt"),
-			keyStyle.Render("D")+descStyle.Render("         - Kill (delete) the selected session"),
-			keyStyle.Render("↑/j, ↓/k")+descStyle.Render("  - Navigate between sessions"),
-			keyStyle.Render("↵/o")+descStyle.Render("       - Attach to the selected session"),
-			keyStyle.Render("ctrl-q")+descStyle.Render("    - Detach from session"),
-			"",
-			headerStyle.Render("Handoff:"),
-			keyStyle.Render("p")+descStyle.Render("         - Commit and push branch to github"),
-			keyStyle.Render("c")+descStyle.Render("         - Checkout: commit changes and pause session"),
-			keyStyle.Render("r")+descStyle.Render("         - Resume a paused session"),
-			"",
-			headerStyle.Render("Other:"),
-			keyStyle.Render("tab")+descStyle.Render("       - Switch between preview and diff tabs"),
-			keyStyle.Render("shift-↓/↑")+descStyle.Render(" - Scroll in diff view"),
-			keyStyle.Render("q")+descStyle.Render("         - Quit the application"),
-		)
-		return content
-
-	case helpTypeInstanceStart:
-		content := lipgloss.JoinVertical(lipgloss.Left,
-			titleStyle.Render("Instance Created"),
-			"",
-			descStyle.Render("New session created:"),
-			descStyle.Render(fmt.Sprintf("• Git branch: %s (isolated worktree)", lipgloss.NewStyle().Bold(true).Render(instance.Branch))),
-			descStyle.Render(fmt.Sprintf("• %s running in background tmux session", lipgloss.NewStyle().Bold(true).Render(instance.Program))),
-			"",
-			headerStyle.Render("Managing:"),
-			keyStyle.Render("↵/o")+descStyle.Render("   - Attach to the session to interact with it directly"),
-			keyStyle.Render("tab")+descStyle.Render("   - Switch preview panes to view session diff"),
-			keyStyle.Render("D")+descStyle.Render("     - Kill (delete) the selected session"),
-			"",
-			headerStyle.Render("Handoff:"),
-			keyStyle.Render("c")+descStyle.Render("     - Checkout this instance's branch"),
-			keyStyle.Render("p")+descStyle.Render("     - Push branch to GitHub to create a PR"),
-		)
-		return content
-
-	case helpTypeInstanceAttach:
-		content := lipgloss.JoinVertical(lipgloss.Left,
-			titleStyle.Render("Attaching to Instance"),
-			"",
-			descStyle.Render("To detach from a session, press ")+keyStyle.Render("ctrl-q"),
-		)
-		return content
-
-	case helpTypeInstanceCheckout:
-		content := lipgloss.JoinVertical(lipgloss.Left,
-			titleStyle.Render("Checkout Instance"),
-			"",
-			"Changes will be committed locally. The branch name has been copied to your clipboard for you to checkout.",
-			"",
-			"Feel free to make changes to the branch and commit them. When resuming, the session will continue from where you left off.",
-			"",
-			headerStyle.Render("Commands:"),
-			keyStyle.Render("c")+descStyle.Render(" - Checkout: commit changes locally and pause session"),
-			keyStyle.Render("r")+descStyle.Render(" - Resume a paused session"),
-		)
-		return content
-	}
-	return ""
+// helpContent renders the help screen listing the keys.
+func helpContent() string {
+	return lipgloss.JoinVertical(lipgloss.Left,
+		titleStyle.Render("Claude Squad"),
+		"",
+		"A terminal UI that manages multiple Claude Code (and other local agents) in separate workspaces.",
+		"",
+		headerStyle.Render("Managing:"),
+		helpLine(10, "Create a new session", keys.KeyNew),
+		helpLine(10, "Create a new session in any repository", keys.KeyDirectoryPicker),
+		helpLine(10, "Create a new session with a prompt", keys.KeyPrompt),
+		helpLine(10, "Create a new session from the task library", keys.KeyTask),
+		helpLine(10, "Create a new session that plans first, or review its plan", keys.KeyPlan),
+		helpLine(10, "Run a plugin command or start a session from a plugin", keys.KeyPalette),
+		helpLine(10, "Create a new session from a Jira or Linear ticket", keys.KeyTicket),
+		helpLine(10, "Create a new session that checks out an existing branch", keys.KeyBranch),
+		helpLine(10, "Adopt a tmux session you started yourself", keys.KeyAdopt),
+		helpLine(10, "Stop the selected session's agent, keeping its branch and worktree", keys.KeyKill),
+		helpLine(10, "Purge the selected session: kill it and delete its branch and worktree", keys.KeyPurge),
+		helpLine(10, "Freeze: archive the session to a tarball, then kill it", keys.KeyFreeze),
+		helpLine(10, "Show the history of frozen sessions", keys.KeyHistory),
+		helpLine(10, "Navigate between sessions", keys.KeyUp, keys.KeyDown),
+		helpLine(10, "Switch between repository tabs", keys.KeyRepoTabPrev, keys.KeyRepoTabNext),
+		helpLine(10, "Pin or unpin the repository tab, keeping it first", keys.KeyPinRepo),
+		helpLine(10, "Switch between workspaces, named sets of repositories", keys.KeyWorkspace),
+		helpLine(10, "Attach to the selected session", keys.KeyEnter),
+		keyStyle.Render(fmt.Sprintf("%-10s", tmux.GetDetachKey().Name))+descStyle.Render("- Detach from session"),
+		"",
+		headerStyle.Render("Handoff:"),
+		helpLine(10, "Commit and push branch to github", keys.KeySubmit),
+		helpLine(10, "Checkout: commit changes and pause session", keys.KeyCheckout),
+		helpLine(10, "Resume a paused or stopped session", keys.KeyResume),
+		"",
+		headerStyle.Render("Other:"),
+		helpLine(10, "Switch between preview and diff tabs", keys.KeyTab),
+		helpLine(10, "Cycle the pane that's previewed and sent prompts", keys.KeyPane),
+		helpLine(10, "Switch between the list and the preview in narrow terminals", keys.KeyPreview),
+		helpLine(10, "Scroll in diff view", keys.KeyShiftDown, keys.KeyShiftUp),
+		helpLine(10, "Mark a session, then press again on another to compare them", keys.KeyCompare),
+		helpLine(10, "Interrupt, nudge or restart a stalled session", keys.KeyStalled),
+		helpLine(10, "Show or reset the session's database snapshot", keys.KeyDatabase),
+		helpLine(10, "Mute or unmute the session's alerts", keys.KeyMute),
+		helpLine(10, "Snooze the session's alerts and notifications for a while", keys.KeySnooze),
+		helpLine(10, "Limit how long the session runs and how much it spends", keys.KeyBudget),
+		helpLine(10, "Switch between relative and absolute times", keys.KeyToggleTimes),
+		helpLine(10, "Sort sessions by uptime or last activity", keys.KeySort),
+		helpLine(10, "Show usage stats for each repository", keys.KeyStats),
+		helpLine(10, "Show this help", keys.KeyHelp),
+		helpLine(10, "Dismiss the hint shown below the menu for good", keys.KeyDismissHint),
+		helpLine(10, "Quit the application", keys.KeyQuit),
+	)
 }
 
-// showHelpScreen displays the help screen overlay if it hasn't been shown before
-func (m *home) showHelpScreen(helpType helpType, onDismiss func()) (tea.Model, tea.Cmd) {
-	// Get the flag for this help type
-	var helpFlag uint32
-	switch helpType {
-	case helpTypeGeneral:
-		helpFlag = HelpFlagGeneral
-	case helpTypeInstanceStart:
-		helpFlag = HelpFlagInstanceStart
-	case helpTypeInstanceAttach:
-		helpFlag = HelpFlagInstanceAttach
-	case helpTypeInstanceCheckout:
-		helpFlag = HelpFlagInstanceCheckout
-	}
-
-	// Check if this help screen has been seen before
-	// Only show if we're showing the general help screen or the corresponding flag is not set in the seen bitmask.
-	if helpType == helpTypeGeneral || (m.appState.GetHelpScreensSeen()&helpFlag) == 0 {
-		// Mark this help screen as seen and save state
-		if err := m.appState.SetHelpScreensSeen(m.appState.GetHelpScreensSeen() | helpFlag); err != nil {
-			log.WarningLog.Printf("Failed to save help screen state: %v", err)
-		}
-
-		content := helpType.ToContent(m.list.GetSelectedInstance())
-
-		m.textOverlay = overlay.NewTextOverlay(content)
-		m.textOverlay.OnDismiss = onDismiss
-		m.state = stateHelp
-		return m, nil
+// helpLine renders a line of the help describing the keys bound to names, padded to width columns.
+func helpLine(width int, desc string, names ...keys.KeyName) string {
+	bound := make([]string, len(names))
+	for i, name := range names {
+		bound[i] = keys.Help(name)
 	}
+	shown := strings.Join(bound, ", ")
+	shown += strings.Repeat(" ", max(width-lipgloss.Width(shown), 1))
+	return keyStyle.Render(shown) + descStyle.Render("- "+desc)
+}
 
-	// Skip displaying the help screen
-	if onDismiss != nil {
-		onDismiss()
-	}
+// showHelpScreen displays the help screen overlay.
+func (m *home) showHelpScreen() (tea.Model, tea.Cmd) {
+	m.textOverlay = overlay.NewTextOverlay(helpContent())
+	m.state = stateHelp
 	return m, nil
 }
 
+// showCommitOutput displays the output of a failed commit, such as a rejecting pre-commit hook.
+func (m *home) showCommitOutput(err *git.CommitError) {
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		titleStyle.Render("Commit Failed"),
+		"",
+		descStyle.Render("The commit was rejected. Output from git and the repository's hooks:"),
+		"",
+		strings.TrimSpace(err.Output),
+		"",
+		descStyle.Render("Fix the issue in the session and try again, or set skip_hooks for this repository in the config."),
+	)
+	m.textOverlay = overlay.NewTextOverlay(content)
+	m.state = stateHelp
+}
+
 // handleHelpState handles key events when in help state
 func (m *home) handleHelpState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Any key press will close the help overlay
-	shouldClose := m.textOverlay.HandleKeyPress(msg)
+	current := m.textOverlay
+	shouldClose := current.HandleKeyPress(msg)
 	if shouldClose {
+		// The dismiss callback may have opened another overlay, e.g. to show commit hook output.
+		if m.textOverlay != current {
+			return m, nil
+		}
 		m.state = stateDefault
 		return m, tea.Sequence(
 			tea.WindowSize(),
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"claude-squad/keys"
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/session/tmux"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// hintDuration is how long a hint stays below the menu, unless it's dismissed.
+const hintDuration = 20 * time.Second
+
+// hintContext is the screen or action a hint is relevant to.
+type hintContext int
+
+const (
+	// hintInstanceCreated is right after an instance is created.
+	hintInstanceCreated hintContext = iota
+	// hintInstanceSelected is when the user moves to an instance in the list.
+	hintInstanceSelected
+	// hintInstanceCheckedOut is right after an instance is checked out.
+	hintInstanceCheckedOut
+	// hintDiffShown is when the user switches to the diff tab.
+	hintDiffShown
+	// hintRepoTabSwitched is when the user switches between repository tabs.
+	hintRepoTabSwitched
+)
+
+// hint is a one-line tip shown below the menu when its context comes up. Hints are rate-limited, retired
+// after a few showings and never shown again once dismissed; the state keeps track of each by its ID.
+type hint struct {
+	id      string
+	context hintContext
+	// text returns the hint given the selected instance, or "" if it doesn't apply.
+	text func(selected *session.Instance) string
+}
+
+// hints are the contextual hints, in the order they're considered for their context. New features add
+// theirs here.
+var hints = []hint{
+	{id: "instance_created", context: hintInstanceCreated, text: func(selected *session.Instance) string {
+		if selected == nil {
+			return ""
+		}
+		return fmt.Sprintf("'%s' runs %s on branch %s in its own worktree: %s attaches, %s shows its diff, %s checks it out",
+			selected.Title, selected.Program, selected.Branch, keys.Help(keys.KeyEnter), keys.Help(keys.KeyTab),
+			keys.Help(keys.KeyCheckout))
+	}},
+	{id: "attach", context: hintInstanceSelected, text: func(selected *session.Instance) string {
+		if selected == nil || selected.Inactive() {
+			return ""
+		}
+		return fmt.Sprintf("Press %s to attach to '%s', and %s to detach", keys.Help(keys.KeyEnter), selected.Title,
+			tmux.GetDetachKey().Name)
+	}},
+	{id: "checkout", context: hintInstanceCheckedOut, text: func(selected *session.Instance) string {
+		if selected == nil {
+			return ""
+		}
+		return fmt.Sprintf("'%s' is paused with its changes committed, and %s is copied to the clipboard. %s resumes it where it left off",
+			selected.Title, selected.Branch, keys.Help(keys.KeyResume))
+	}},
+	{id: "diff", context: hintDiffShown, text: func(selected *session.Instance) string {
+		return fmt.Sprintf("Scroll the diff with %s and %s", keys.Help(keys.KeyShiftUp), keys.Help(keys.KeyShiftDown))
+	}},
+	{id: "pin_repo", context: hintRepoTabSwitched, text: func(selected *session.Instance) string {
+		return fmt.Sprintf("Press %s to pin this repository's tab first, and %s to show only a workspace's repositories",
+			keys.Help(keys.KeyPinRepo), keys.Help(keys.KeyWorkspace))
+	}},
+}
+
+// hideHintMsg hides the hint id once it has been shown for hintDuration.
+type hideHintMsg struct {
+	id string
+}
+
+// showHint shows the first hint for context that applies and that the state allows, and records that it was
+// shown. Hints are skipped while another one is shown.
+func (m *home) showHint(context hintContext) tea.Cmd {
+	if m.hint != "" {
+		return nil
+	}
+	now := time.Now()
+	shown := m.appState.GetHints()
+	selected := m.list.GetSelectedInstance()
+	for _, h := range hints {
+		if h.context != context || !shown.CanShow(h.id, now) {
+			continue
+		}
+		text := h.text(selected)
+		if text == "" {
+			continue
+		}
+		if err := m.appState.RecordHintShown(h.id, now); err != nil {
+			log.WarningLog.Printf("failed to save hint state: %v", err)
+		}
+		m.hint = h.id
+		m.errBox.SetHint(fmt.Sprintf("%s (%s to dismiss)", text, keys.Help(keys.KeyDismissHint)))
+		id := h.id
+		return tea.Tick(hintDuration, func(time.Time) tea.Msg {
+			return hideHintMsg{id: id}
+		})
+	}
+	return nil
+}
+
+// hideHint hides the hint shown, if it's still id.
+func (m *home) hideHint(id string) {
+	if m.hint == id {
+		m.hint = ""
+		m.errBox.SetHint("")
+	}
+}
+
+// dismissHint hides the hint shown, and records that it shouldn't be shown again.
+func (m *home) dismissHint() {
+	if m.hint == "" {
+		return
+	}
+	if err := m.appState.DismissHint(m.hint); err != nil {
+		log.WarningLog.Printf("failed to save hint state: %v", err)
+	}
+	m.hideHint(m.hint)
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"claude-squad/hooks"
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// mergeCheckInterval is how often the instances' branches are checked for merges.
+const mergeCheckInterval = 5 * time.Minute
+
+// mergesCheckedMsg carries the instances whose branches were found merged, and when they were merged.
+type mergesCheckedMsg struct {
+	merged map[*session.Instance]time.Time
+}
+
+// checkMerges checks in the background whether the instances' branches have been merged into their
+// repositories' default branches. It only checks if a merge_completed hook is configured, or for instances
+// created from tickets, and at most every mergeCheckInterval, since it runs a few git commands per instance.
+func (m *home) checkMerges() tea.Cmd {
+	if time.Since(m.mergesCheckedAt) < mergeCheckInterval {
+		return nil
+	}
+	m.mergesCheckedAt = time.Now()
+
+	hooked := len(m.appConfig.Hooks[hooks.MergeCompleted]) > 0
+	worktrees := make(map[*session.Instance]*git.GitWorktree)
+	for _, instance := range m.list.GetInstances() {
+		if !instance.MergedAt.IsZero() || (!hooked && instance.Ticket == "") {
+			continue
+		}
+		if worktree, err := instance.GetGitWorktree(); err == nil {
+			worktrees[instance] = worktree
+		}
+	}
+	if len(worktrees) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		merged := make(map[*session.Instance]time.Time)
+		for instance, worktree := range worktrees {
+			mergedAt, ok, err := worktree.MergedAt()
+			if err != nil {
+				log.WarningLog.Printf("could not check whether %s was merged: %v", instance.Title, err)
+				continue
+			}
+			if ok {
+				merged[instance] = mergedAt
+			}
+		}
+		return mergesCheckedMsg{merged: merged}
+	}
+}
+
+// handleMerges records the merges found by checkMerges, runs the merge_completed hooks for them and moves
+// the instances' tickets to done.
+func (m *home) handleMerges(msg mergesCheckedMsg) tea.Cmd {
+	instances := m.list.GetInstances()
+	var merged []*session.Instance
+	for instance, mergedAt := range msg.merged {
+		// The instance may have been killed while its branch was checked.
+		if !slices.Contains(instances, instance) {
+			continue
+		}
+		instance.MergedAt = mergedAt
+		hooks.Run(hooks.MergeCompleted, instance)
+		merged = append(merged, instance)
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	if err := m.storage.SaveInstances(instances); err != nil {
+		return m.handleError(err)
+	}
+	return m.syncTickets(merged)
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"claude-squad/retry"
+	"claude-squad/session/git"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// operationQueuedMsg is sent when a network operation failed and was queued for retry.
+type operationQueuedMsg struct {
+	name string
+}
+
+// retryResultsMsg carries the operations that left the retry queue.
+type retryResultsMsg struct {
+	results []retry.Result
+}
+
+// queueIfOffline queues run for retry if err was caused by the network being unavailable. It returns
+// the message to hand back to the update loop: a notice that the operation was queued, or err itself.
+func (m *home) queueIfOffline(name string, err error, run func() error) tea.Msg {
+	if !git.IsNetworkError(err) {
+		return err
+	}
+	m.retryQueue.Add(name, run)
+	return operationQueuedMsg{name: name}
+}
+
+// retryQueued retries the queued operations that are due, off the update loop.
+func (m *home) retryQueued() tea.Cmd {
+	if !m.retryQueue.HasDue() {
+		return nil
+	}
+	return func() tea.Msg {
+		return retryResultsMsg{results: m.retryQueue.RunDue(git.IsNetworkError)}
+	}
+}
+
+// handleRetryResults reports the operations that succeeded or gave up.
+func (m *home) handleRetryResults(results []retry.Result) tea.Cmd {
+	m.list.SetQueued(m.retryQueue.Len())
+	for _, result := range results {
+		if result.Err != nil {
+			return m.handleError(fmt.Errorf("queued %s failed: %w", result.Name, result.Err))
+		}
+	}
+	if len(results) > 0 {
+		return m.handleInfo(fmt.Sprintf("queued %s succeeded", results[len(results)-1].Name))
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"claude-squad/keys"
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// handlePlan opens the review of the selected instance's plan if it's waiting for approval. Otherwise it
+// starts a new instance in plan mode: the agent writes a plan for the prompt and waits for approval.
+func (m *home) handlePlan() (tea.Model, tea.Cmd) {
+	selected := m.list.GetSelectedInstance()
+	if selected != nil && selected.PlanState == session.PlanAwaitingApproval {
+		m.showPlanReview(selected)
+		return m, tea.WindowSize()
+	}
+
+	model, cmd := m.startNewInstance(true)
+	m.planMode = true
+	return model, cmd
+}
+
+// capturePlan captures the plan of an instance that finished drafting it and asks the user to review it.
+func (m *home) capturePlan(instance *session.Instance) tea.Cmd {
+	if err := instance.CapturePlan(); err != nil {
+		return m.handleError(err)
+	}
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		log.WarningLog.Printf("could not save captured plan: %v", err)
+	}
+	return m.handleInfo(fmt.Sprintf("%s: plan ready, select it and press %s to review", instance.Title, keys.Help(keys.KeyPlan)))
+}
+
+// showPlanReview shows the instance's plan in an editable overlay.
+func (m *home) showPlanReview(instance *session.Instance) {
+	m.textInputOverlay = overlay.NewTextInputOverlay(
+		fmt.Sprintf("Plan for %s (submit to approve, esc to decide later)", instance.Title), instance.Plan)
+	m.state = statePlanReview
+	m.menu.SetState(ui.StatePrompt)
+}
+
+// handlePlanReviewState handles key presses while a plan is being reviewed. Submitting approves the plan,
+// with the user's edits, and tells the agent to execute it.
+func (m *home) handlePlanReviewState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.textInputOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+
+	submitted := m.textInputOverlay.IsSubmitted()
+	plan := m.textInputOverlay.GetValue()
+	m.textInputOverlay = nil
+	m.state = stateDefault
+	m.menu.SetState(ui.StateDefault)
+
+	selected := m.list.GetSelectedInstance()
+	if !submitted || selected == nil {
+		return m, tea.WindowSize()
+	}
+	if err := selected.ApprovePlan(plan); err != nil {
+		return m, tea.Batch(tea.WindowSize(), m.handleError(err))
+	}
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		return m, tea.Batch(tea.WindowSize(), m.handleError(err))
+	}
+	return m, tea.Batch(tea.WindowSize(), m.instanceChanged())
+}
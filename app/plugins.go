@@ -0,0 +1,192 @@
+package app
+
+import (
+	"claude-squad/log"
+	"claude-squad/plugin"
+	"claude-squad/session"
+	"claude-squad/ui/overlay"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pluginColumnsInterval is how often the plugins' columns are refreshed.
+const pluginColumnsInterval = 30 * time.Second
+
+// paletteAction is a plugin command or creation flow listed in the palette.
+type paletteAction struct {
+	plugin *plugin.Plugin
+	name   string
+	// create is set for creation flows.
+	create bool
+}
+
+// pluginsLoadedMsg is sent once the plugins on PATH have been discovered.
+type pluginsLoadedMsg struct {
+	plugins []*plugin.Plugin
+}
+
+// pluginColumnsMsg carries the plugins' columns for each instance, by instance title.
+type pluginColumnsMsg struct {
+	columns map[string][]string
+}
+
+// pluginCommandMsg carries the message of a plugin command that succeeded.
+type pluginCommandMsg struct {
+	message string
+}
+
+// pluginInstanceMsg carries a new instance from a plugin's creation flow.
+type pluginInstanceMsg struct {
+	instance plugin.NewInstance
+}
+
+// loadPlugins discovers the plugins in the background.
+func loadPlugins() tea.Msg {
+	plugins, errs := plugin.Discover()
+	for _, err := range errs {
+		log.WarningLog.Print(err)
+	}
+	return pluginsLoadedMsg{plugins: plugins}
+}
+
+// pluginInstance returns instance as it's sent to plugins.
+func pluginInstance(instance *session.Instance) plugin.Instance {
+	p := plugin.Instance{
+		Title:   instance.Title,
+		Status:  instance.Status.String(),
+		Branch:  instance.Branch,
+		Program: instance.Program,
+	}
+	if worktree, err := instance.GetGitWorktree(); err == nil {
+		p.Repository = worktree.GetRepoPath()
+		p.Worktree = worktree.GetWorktreePath()
+	}
+	return p
+}
+
+// refreshPluginColumns asks the plugins for their columns in the background, at most every
+// pluginColumnsInterval.
+func (m *home) refreshPluginColumns() tea.Cmd {
+	if time.Since(m.pluginColumnsAt) < pluginColumnsInterval {
+		return nil
+	}
+	var plugins []*plugin.Plugin
+	for _, p := range m.plugins {
+		if len(p.Manifest.Columns) > 0 {
+			plugins = append(plugins, p)
+		}
+	}
+	if len(plugins) == 0 {
+		return nil
+	}
+	m.pluginColumnsAt = time.Now()
+
+	var instances []plugin.Instance
+	for _, instance := range m.list.GetInstances() {
+		if instance.Started() {
+			instances = append(instances, pluginInstance(instance))
+		}
+	}
+	return func() tea.Msg {
+		columns := make(map[string][]string)
+		for _, p := range plugins {
+			values, err := p.Columns(instances)
+			if err != nil {
+				log.WarningLog.Print(err)
+				continue
+			}
+			for _, instance := range instances {
+				for _, column := range p.Manifest.Columns {
+					if value := values[instance.Title][column]; value != "" {
+						columns[instance.Title] = append(columns[instance.Title], column+" "+value)
+					}
+				}
+			}
+		}
+		return pluginColumnsMsg{columns: columns}
+	}
+}
+
+// showPalette lists the plugins' commands and creation flows.
+func (m *home) showPalette() (tea.Model, tea.Cmd) {
+	var actions []paletteAction
+	var items []overlay.SelectionItem
+	for _, p := range m.plugins {
+		for _, command := range p.Manifest.Commands {
+			actions = append(actions, paletteAction{plugin: p, name: command.Name})
+			items = append(items, overlay.SelectionItem{Label: p.Name + ": " + command.Name, Description: command.Description})
+		}
+		for _, flow := range p.Manifest.Flows {
+			actions = append(actions, paletteAction{plugin: p, name: flow.Name, create: true})
+			items = append(items, overlay.SelectionItem{Label: p.Name + ": new " + flow.Name, Description: flow.Description})
+		}
+	}
+	if len(actions) == 0 {
+		return m, m.handleInfo("no plugin commands found, plugins are executables named " + plugin.Prefix + "* on PATH")
+	}
+	m.paletteActions = actions
+	m.selectionOverlay = overlay.NewSelectionOverlay("Run a plugin command", items)
+	m.state = statePalette
+	return m, nil
+}
+
+// handlePaletteState runs the picked plugin command or creation flow in the background.
+func (m *home) handlePaletteState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.selectionOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+	choice := m.selectionOverlay.Selected()
+	actions := m.paletteActions
+	m.selectionOverlay = nil
+	m.paletteActions = nil
+	m.state = stateDefault
+	if choice < 0 {
+		return m, tea.WindowSize()
+	}
+
+	action := actions[choice]
+	if action.create {
+		return m, tea.Batch(tea.WindowSize(), func() tea.Msg {
+			instance, err := action.plugin.Create(action.name)
+			if err != nil {
+				return err
+			}
+			return pluginInstanceMsg{instance: instance}
+		})
+	}
+
+	var instance *plugin.Instance
+	if selected := m.list.GetSelectedInstance(); selected != nil && selected.Started() {
+		p := pluginInstance(selected)
+		instance = &p
+	}
+	return m, tea.Batch(tea.WindowSize(), func() tea.Msg {
+		result, err := action.plugin.RunCommand(action.name, instance)
+		if err != nil {
+			return err
+		}
+		if result.Message == "" {
+			result.Message = fmt.Sprintf("%s: %s done", action.plugin.Name, action.name)
+		}
+		return pluginCommandMsg{message: result.Message}
+	})
+}
+
+// startPluginInstance starts naming a new instance from a plugin's creation flow, with its prompt
+// pre-filled like a task's.
+func (m *home) startPluginInstance(msg pluginInstanceMsg) (tea.Model, tea.Cmd) {
+	if m.state != stateDefault {
+		return m, m.handleError(fmt.Errorf("could not start the new session, finish what you're doing first"))
+	}
+	model, cmd := m.startNewInstance(true)
+	m.taskPrompt = msg.instance.Prompt
+	if m.state == stateNew && msg.instance.Title != "" {
+		instance := m.list.GetInstances()[m.list.NumInstances()-1]
+		if err := instance.SetTitle(msg.instance.Title); err != nil {
+			return model, tea.Batch(cmd, m.handleError(err))
+		}
+	}
+	return model, cmd
+}
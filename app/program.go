@@ -0,0 +1,73 @@
+package app
+
+import (
+	"claude-squad/config"
+	"claude-squad/session"
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// showProgramInput asks for the program the instance being named runs, pre-filled with its repository's.
+func (m *home) showProgramInput(instance *session.Instance) (tea.Model, tea.Cmd) {
+	m.textInputOverlay = overlay.NewTextInputOverlay(
+		fmt.Sprintf("Program of '%s' with its arguments, kept for new sessions in %s", instance.Title,
+			ui.RepoDisplayName(m.newInstanceRepo(instance))), instance.Program)
+	m.state = stateProgram
+	m.menu.SetState(ui.StatePrompt)
+	return m, nil
+}
+
+// handleProgramState handles key presses while the program is being edited. Once it's submitted, the
+// instance being named runs it, and so do the next ones created in its repository.
+func (m *home) handleProgramState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.textInputOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+	submitted := m.textInputOverlay.IsSubmitted()
+	value := m.textInputOverlay.GetValue()
+	m.textInputOverlay = nil
+	m.state = stateNew
+	m.menu.SetState(ui.StateNewInstance)
+	if !submitted {
+		return m, tea.WindowSize()
+	}
+
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return m, tea.Batch(tea.WindowSize(), m.handleError(fmt.Errorf("program cannot be empty")))
+	}
+	instance := m.list.GetInstances()[m.list.NumInstances()-1]
+	instance.Program = strings.Join(fields, " ")
+	if err := m.saveRepoProgram(m.newInstanceRepo(instance), fields[0], strings.Join(fields[1:], " ")); err != nil {
+		return m, tea.Batch(tea.WindowSize(), m.handleError(err))
+	}
+	return m, tea.Batch(tea.WindowSize(), m.handleInfo(fmt.Sprintf("'%s' runs %s", instance.Title, instance.Program)))
+}
+
+// newInstanceRepo returns the repository the instance being named is created in.
+func (m *home) newInstanceRepo(instance *session.Instance) string {
+	if repoPath, err := config.FindRepositoryForPath(instance.Path); err == nil {
+		return repoPath
+	}
+	return instance.Path
+}
+
+// saveRepoProgram saves the program and arguments new instances in repoPath run, adding the repository to the
+// state if it's new.
+func (m *home) saveRepoProgram(repoPath, program, args string) error {
+	state := m.appState
+	if _, err := state.GetRepository(repoPath); err != nil {
+		repoData, err := config.CreateRepositoryData(repoPath)
+		if err != nil {
+			return fmt.Errorf("failed to create repository data: %w", err)
+		}
+		if err := state.AddRepository(repoData); err != nil {
+			return fmt.Errorf("failed to add repository to state: %w", err)
+		}
+	}
+	return state.SetRepositoryProgram(repoPath, program, args)
+}
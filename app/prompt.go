@@ -0,0 +1,68 @@
+package app
+
+import (
+	"claude-squad/log"
+	"claude-squad/prompt"
+	"claude-squad/session"
+	"claude-squad/ui/overlay"
+	"fmt"
+	"strings"
+)
+
+// pendingPrompt is a prompt whose template variables are being filled in by the user.
+type pendingPrompt struct {
+	text   string
+	values map[string]string
+	// remaining are the variables still to ask for. The first one is the one currently asked for.
+	remaining []string
+	// plan is set if the agent should write a plan for approval instead of acting on the prompt.
+	plan bool
+}
+
+// handlePromptInput handles a value submitted in the prompt overlay: either the prompt itself or the
+// value of one of its template variables. Once every variable has a value, the expanded prompt is sent.
+// It returns true if it opened an overlay asking for another variable.
+func (m *home) handlePromptInput(instance *session.Instance, value string) (bool, error) {
+	if m.pendingPrompt == nil {
+		m.pendingPrompt = &pendingPrompt{text: value, values: builtinVariables(instance), plan: m.planMode}
+		m.planMode = false
+		m.pendingPrompt.remaining = prompt.Missing(value, m.pendingPrompt.values)
+	} else {
+		m.pendingPrompt.values[m.pendingPrompt.remaining[0]] = value
+		m.pendingPrompt.remaining = m.pendingPrompt.remaining[1:]
+	}
+
+	if len(m.pendingPrompt.remaining) > 0 {
+		m.textInputOverlay = overlay.NewTextInputOverlay(
+			fmt.Sprintf("Value for {{%s}}", m.pendingPrompt.remaining[0]), "")
+		return true, nil
+	}
+
+	pending := m.pendingPrompt
+	m.pendingPrompt = nil
+	if pending.plan {
+		return false, instance.StartPlan(prompt.Expand(pending.text, pending.values))
+	}
+	return false, instance.SendInput(prompt.Expand(pending.text, pending.values))
+}
+
+// builtinVariables returns the template variables that are filled in from the instance itself.
+func builtinVariables(instance *session.Instance) map[string]string {
+	values := map[string]string{
+		"title":   instance.Title,
+		"branch":  instance.Branch,
+		"program": instance.Program,
+	}
+	if repo, err := instance.RepoName(); err == nil {
+		values["repo"] = repo
+	}
+	if worktree, err := instance.GetGitWorktree(); err == nil {
+		files, err := worktree.ChangedFiles()
+		if err != nil {
+			log.WarningLog.Printf("could not list changed files for prompt: %v", err)
+		} else {
+			values["files"] = strings.Join(files, ", ")
+		}
+	}
+	return values
+}
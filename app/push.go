@@ -0,0 +1,44 @@
+package app
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"fmt"
+	"strings"
+)
+
+// maxOwnersShown is the number of code owners listed in the push confirmation.
+const maxOwnersShown = 5
+
+// pushConfirmation returns the message asking to push the instance's changes. If the repository has a
+// CODEOWNERS file, it lists who owns the changed files; they're requested as reviewers of the pull request.
+func pushConfirmation(instance *session.Instance) string {
+	message := fmt.Sprintf("[!] Push changes from session '%s'?", instance.Title)
+	worktree, err := instance.GetGitWorktree()
+	if err != nil {
+		return message
+	}
+	ownership, err := worktree.Ownership()
+	if err != nil {
+		log.WarningLog.Printf("failed to read code owners: %v", err)
+	}
+	if len(ownership) == 0 {
+		return message
+	}
+
+	var b strings.Builder
+	b.WriteString(message)
+	b.WriteString("\n\nReviewers from CODEOWNERS:\n")
+	for i, o := range ownership {
+		if i == maxOwnersShown {
+			fmt.Fprintf(&b, "… and %d more\n", len(ownership)-maxOwnersShown)
+			break
+		}
+		noun := "files"
+		if len(o.Files) == 1 {
+			noun = "file"
+		}
+		fmt.Fprintf(&b, "• %s: %d %s\n", o.Owner, len(o.Files), noun)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,136 @@
+package app
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/power"
+	"claude-squad/session"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// stateFlushInterval is how often the state's batched mutations are saved.
+const stateFlushInterval = 2 * time.Second
+
+// stateFlushMsg triggers a save of the state's batched mutations.
+type stateFlushMsg struct{}
+
+// powerMsg carries whether the machine is on battery, checked periodically in auto low-power mode.
+type powerMsg struct {
+	onBattery bool
+}
+
+// newSpinner returns the running instances' spinner, ticking at the configured rate.
+func newSpinner(cfg config.RefreshConfig, lowPower bool) spinner.Model {
+	return spinner.New(spinner.WithSpinner(spinner.Spinner{
+		Frames: spinner.MiniDot.Frames,
+		FPS:    power.Scale(cfg, cfg.GetSpinner(), lowPower),
+	}))
+}
+
+// previewTickCmd schedules the next preview refresh.
+func (m *home) previewTickCmd() tea.Cmd {
+	interval := power.Scale(m.appConfig.Refresh, m.appConfig.Refresh.GetPreview(), m.lowPower)
+	return func() tea.Msg {
+		time.Sleep(interval)
+		return previewTickMsg{}
+	}
+}
+
+// tickUpdateMetadataCmd schedules the next update of the instances' metadata. Note that we iterate overall the
+// instances and capture their output. It's a pretty expensive operation, so by default it's only done 2x a
+// second.
+func (m *home) tickUpdateMetadataCmd() tea.Cmd {
+	interval := power.Scale(m.appConfig.Refresh, m.appConfig.Refresh.GetPoll(), m.lowPower)
+	return func() tea.Msg {
+		time.Sleep(interval)
+		return tickUpdateMetadataMessage{}
+	}
+}
+
+// flushStateCmd schedules the next save of the state's batched mutations.
+func (m *home) flushStateCmd() tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(stateFlushInterval)
+		return stateFlushMsg{}
+	}
+}
+
+// flushState saves the state's mutations that were batched since the last flush.
+func (m *home) flushState() error {
+	return m.appState.Flush()
+}
+
+// reloadMergedInstances brings the list up to date with the instances another process added, changed or
+// removed, e.g. with cs new or kill, once saving the state took them in. Otherwise the next save would undo
+// those changes.
+func (m *home) reloadMergedInstances() tea.Cmd {
+	restored, removed, err := m.storage.TakeMergedInstances()
+	if len(restored) == 0 && len(removed) == 0 {
+		if err != nil {
+			return m.handleError(err)
+		}
+		return nil
+	}
+
+	byTitle := make(map[string]*session.Instance)
+	for _, instance := range m.list.GetInstances() {
+		if instance.Started() {
+			byTitle[instance.Title] = instance
+		}
+	}
+	for _, title := range removed {
+		if instance, ok := byTitle[title]; ok {
+			if err := instance.Disown(); err != nil {
+				log.WarningLog.Printf("failed to let go of instance %s: %v", title, err)
+			}
+			m.list.RemoveInstance(instance)
+		}
+	}
+	for _, instance := range restored {
+		old, ok := byTitle[instance.Title]
+		if !ok {
+			m.list.AddInstance(instance)()
+			continue
+		}
+		if err := old.Disown(); err != nil {
+			log.WarningLog.Printf("failed to let go of instance %s: %v", old.Title, err)
+		}
+		m.list.ReplaceInstance(old, instance)
+	}
+	cmd := m.instanceChanged()
+	if err != nil {
+		return tea.Batch(cmd, m.handleError(err))
+	}
+	return cmd
+}
+
+// checkPowerCmd checks whether the machine is on battery after a while, in auto low-power mode.
+func (m *home) checkPowerCmd() tea.Cmd {
+	if m.appConfig.Refresh.GetLowPower() != config.LowPowerAuto {
+		return nil
+	}
+	return func() tea.Msg {
+		time.Sleep(power.CheckInterval)
+		return powerMsg{onBattery: power.OnBattery()}
+	}
+}
+
+// handlePower switches low-power mode on or off as the machine goes on or off battery.
+func (m *home) handlePower(msg powerMsg) tea.Cmd {
+	cmds := []tea.Cmd{m.checkPowerCmd()}
+	if msg.onBattery != m.lowPower {
+		m.lowPower = msg.onBattery
+		// The ticking spinner is replaced, so its pending tick is dropped and a new one started.
+		m.spinner = newSpinner(m.appConfig.Refresh, m.lowPower)
+		cmds = append(cmds, m.spinner.Tick)
+		if m.lowPower {
+			cmds = append(cmds, m.handleInfo("On battery: refreshing less often"))
+		} else {
+			cmds = append(cmds, m.handleInfo("Plugged in: refreshing at the usual rate"))
+		}
+	}
+	return tea.Batch(cmds...)
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"claude-squad/keys"
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"fmt"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pickNewInstanceRepo starts a new instance in a repository the user picks, whichever directory
+// claude-squad was launched from.
+func (m *home) pickNewInstanceRepo() (tea.Model, tea.Cmd) {
+	if err := m.resetNewInstance(false); err != nil {
+		return m, m.handleError(err)
+	}
+	return m.showRepoPicker()
+}
+
+// pickableRepos returns the repositories offered by the repository picker: the registered ones, most
+// recently used first, then those of the other repository tabs, then those found in the discovery roots.
+// known is how many of them aren't only discovered.
+func (m *home) pickableRepos() (repos []string, known int) {
+	for _, repo := range m.appState.GetRepositoriesSortedByLastAccessed() {
+		repos = append(repos, repo.Path)
+	}
+	for _, repo := range m.repoTabs.GetAllRepos() {
+		if !slices.Contains(repos, repo) {
+			repos = append(repos, repo)
+		}
+	}
+	known = len(repos)
+	for _, repo := range m.appState.GetDiscoveredRepositories() {
+		if !slices.Contains(repos, repo) {
+			repos = append(repos, repo)
+		}
+	}
+	return repos, known
+}
+
+// showRepoPicker lets the user pick the repository to create an instance in, among pickableRepos, or any
+// directory with the directory pickers. If an instance is being named, it's moved to the picked repository.
+func (m *home) showRepoPicker() (tea.Model, tea.Cmd) {
+	repos, known := m.pickableRepos()
+	m.pickerRepos = repos
+	items := make([]overlay.SelectionItem, 0, len(repos)+2)
+	for i, repo := range repos {
+		description := repo
+		if i >= known {
+			description += " (discovered)"
+		}
+		items = append(items, overlay.SelectionItem{Label: ui.RepoDisplayName(repo), Description: description})
+	}
+	items = append(items, overlay.SelectionItem{Label: "Other directory...", Description: "browse for any repository"})
+	if ui.IsNvimAvailable() {
+		items = append(items, overlay.SelectionItem{Label: "Other directory in Neovim...", Description: "browse with Oil.nvim"})
+	}
+	m.selectionOverlay = overlay.NewSelectionOverlay("Create the new session in", items)
+	m.naming = m.state == stateNew
+	m.state = stateRepoPicker
+	return m, nil
+}
+
+// handleRepoPickerState adds the new instance in the picked repository. An instance being named is moved
+// there, keeping its title.
+func (m *home) handleRepoPickerState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.selectionOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+	choice := m.selectionOverlay.Selected()
+	repos := m.pickerRepos
+	m.selectionOverlay = nil
+	m.pickerRepos = nil
+	m.state = stateDefault
+	if m.naming {
+		m.state = stateNew
+	}
+	if choice < 0 {
+		return m, tea.WindowSize()
+	}
+
+	title := ""
+	if m.naming {
+		title = m.list.GetInstances()[m.list.NumInstances()-1].Title
+		m.list.Kill()
+		m.menu.SetState(ui.StateDefault)
+	}
+	m.naming = false
+	switch {
+	case choice == len(repos):
+		return m, m.showDirectoryPicker()
+	case choice > len(repos):
+		m.state = stateDefault
+		return m, ui.NewNvimDirectoryPicker().LaunchDirectoryPicker()
+	}
+	m.list.SelectRepo(repos[choice])
+	cmd := m.addNewInstance(repos[choice], title, "")
+	return m, tea.Batch(tea.WindowSize(), cmd)
+}
+
+// newInstanceRepoInfo tells the user which repository the instance being named is created in, when there are
+// several to choose from, and the program it runs, which is its repository's.
+func (m *home) newInstanceRepoInfo() tea.Cmd {
+	repo := m.list.GetCurrentRepoPath()
+	if repo == "" {
+		return nil
+	}
+	instance := m.list.GetInstances()[m.list.NumInstances()-1]
+	return m.handleInfo(fmt.Sprintf("new session in %s running %s, press tab to change the repository, %s the program",
+		ui.RepoDisplayName(repo), instance.Program, keys.Help(keys.KeyChangeProgram)))
+}
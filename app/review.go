@@ -0,0 +1,164 @@
+package app
+
+import (
+	"claude-squad/archive"
+	"claude-squad/audit"
+	"claude-squad/config"
+	"claude-squad/keys"
+	"claude-squad/log"
+	"claude-squad/session/git"
+	"claude-squad/stats"
+	"claude-squad/ui/overlay"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// reviewMsg carries the weekly review once it's collected.
+type reviewMsg struct {
+	review stats.Review
+}
+
+// collectReview collects the weekly review in the background if it's the first launch of the week, since
+// measuring the worktrees and listing the branches may take a while.
+func (m *home) collectReview() tea.Cmd {
+	state := m.appState
+	if m.appConfig.HideWeeklyReview {
+		return nil
+	}
+	last := state.GetWeeklyReview()
+	now := time.Now()
+	if !last.Due(now) {
+		return nil
+	}
+	data, err := m.storage.LoadInstanceData()
+	if err != nil {
+		log.WarningLog.Printf("failed to load instances for the weekly review: %v", err)
+		return nil
+	}
+	if len(data) == 0 {
+		// There's nothing to review yet; the next one compares with an empty squad.
+		if err := state.SetWeeklyReview(config.WeeklyReview{ShownAt: now}); err != nil {
+			log.WarningLog.Printf("failed to save the weekly review: %v", err)
+		}
+		return nil
+	}
+	// The repositories are copied, since the state may change while the branches are listed.
+	repos := append([]config.RepositoryData(nil), state.GetRepositories()...)
+	cfg := m.appConfig
+	return func() tea.Msg {
+		var branches []git.SquadBranch
+		orphans, err := archive.FindOrphans(cfg, repos, data)
+		if err != nil {
+			log.WarningLog.Printf("failed to find orphaned branches for the weekly review: %v", err)
+		} else {
+			branches = orphans.Branches
+		}
+		return reviewMsg{review: stats.CollectReview(now, data, last.WorktreeBytes, branches)}
+	}
+}
+
+// showReview records that the weekly review was shown and displays it, with the cleanups it suggests.
+func (m *home) showReview(msg reviewMsg) tea.Cmd {
+	// Don't cover whatever the user moved on to while the review was collected; it's shown next launch instead.
+	if m.state != stateDefault {
+		return nil
+	}
+	shown := config.WeeklyReview{ShownAt: msg.review.GeneratedAt, WorktreeBytes: msg.review.WorktreeBytes}
+	if err := m.appState.SetWeeklyReview(shown); err != nil {
+		log.WarningLog.Printf("failed to save the weekly review: %v", err)
+	}
+	if msg.review.Empty() {
+		return nil
+	}
+
+	review := msg.review.Lines()
+	lines := []string{titleStyle.Render(review[0]), ""}
+	for _, line := range review[1:] {
+		lines = append(lines, descStyle.Render(line))
+	}
+	var actions []string
+	if n := len(msg.review.Stale); n > 0 {
+		actions = append(actions, keyStyle.Render(keys.Help(keys.KeyPauseStale))+descStyle.Render(fmt.Sprintf(
+			" - Pause the %d stale instances, freeing %s", n, stats.FormatBytes(msg.review.StaleBytes()))))
+	}
+	if n := len(msg.review.MergedBranches); n > 0 {
+		actions = append(actions, keyStyle.Render(keys.Help(keys.KeyDeleteMerged))+descStyle.Render(fmt.Sprintf(
+			" - Delete the %d branches with nothing to merge", n)))
+	}
+	if len(actions) > 0 {
+		lines = append(lines, "", headerStyle.Render("Actions:"))
+		lines = append(lines, actions...)
+	}
+	lines = append(lines, "", descStyle.Render("Press any other key to dismiss."))
+	m.review = msg.review
+	m.textOverlay = overlay.NewTextOverlay(lipgloss.JoinVertical(lipgloss.Left, lines...))
+	m.state = stateReview
+	return nil
+}
+
+// handleReviewState runs the displayed review's cleanup the user picked, if any, and dismisses it.
+func (m *home) handleReviewState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.state = stateDefault
+	m.textOverlay = nil
+	review := m.review
+	m.review = stats.Review{}
+	name, _ := keys.OverlayKey(keys.OverlayReview, msg.String())
+	switch name {
+	case keys.KeyPauseStale:
+		return m, m.pauseStale(review.Stale)
+	case keys.KeyDeleteMerged:
+		return m, m.deleteMergedBranches(review.MergedBranches)
+	}
+	return m, nil
+}
+
+// pauseStale pauses the instances the review found stale.
+func (m *home) pauseStale(stale []stats.ReviewInstance) tea.Cmd {
+	isStale := make(map[string]bool, len(stale))
+	for _, instance := range stale {
+		isStale[instance.Repository+"\x00"+instance.Title] = true
+	}
+	var paused, failed []string
+	for _, instance := range m.list.GetInstances() {
+		if !isStale[instance.RepositoryPath+"\x00"+instance.Title] || !instance.Started() || instance.Paused() {
+			continue
+		}
+		if err := instance.Pause(); err != nil {
+			log.ErrorLog.Printf("failed to pause stale instance %s: %v", instance.Title, err)
+			failed = append(failed, instance.Title)
+			continue
+		}
+		paused = append(paused, instance.Title)
+		audit.Record(audit.Entry{Action: "stale_paused", Instance: instance.Title, Repository: instance.RepositoryPath})
+	}
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		return m.handleError(err)
+	}
+	m.instanceChanged()
+	if len(failed) > 0 {
+		return m.handleError(fmt.Errorf("failed to pause %s", strings.Join(failed, ", ")))
+	}
+	if len(paused) == 0 {
+		return nil
+	}
+	return m.handleInfo("paused " + strings.Join(paused, ", "))
+}
+
+// deleteMergedBranches deletes the branches the review found with nothing to merge.
+func (m *home) deleteMergedBranches(branches []git.SquadBranch) tea.Cmd {
+	var failed []string
+	for _, branch := range branches {
+		if err := git.DeleteBranch(branch.Repository, branch.Name); err != nil {
+			log.ErrorLog.Printf("%v", err)
+			failed = append(failed, branch.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return m.handleError(fmt.Errorf("failed to delete %s", strings.Join(failed, ", ")))
+	}
+	return m.handleInfo(fmt.Sprintf("deleted %d branches", len(branches)))
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"claude-squad/audit"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxFindingsShown is the number of scan findings listed in the override prompt.
+const maxFindingsShown = 10
+
+// scanFindingsMsg is returned instead of pushing when the safety scan found problems in the changes.
+type scanFindingsMsg struct {
+	instance *session.Instance
+	findings []git.Finding
+	// push pushes the changes anyway.
+	push tea.Cmd
+}
+
+// dependencyWarningMsg reports problems with added dependencies after a push that they didn't block.
+type dependencyWarningMsg struct {
+	instance *session.Instance
+	findings []git.Finding
+}
+
+func (msg dependencyWarningMsg) String() string {
+	details := make([]string, 0, len(msg.findings))
+	for _, finding := range msg.findings {
+		details = append(details, finding.String())
+	}
+	return fmt.Sprintf("pushed '%s', but check its dependencies: %s", msg.instance.Title, strings.Join(details, "; "))
+}
+
+// showScanFindings reports what the safety scan found and lets the user push anyway. Overrides are
+// recorded in the audit log.
+func (m *home) showScanFindings(msg scanFindingsMsg) tea.Cmd {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[!] The changes in '%s' may not be safe to push:\n\n", msg.instance.Title)
+	details := make([]string, 0, len(msg.findings))
+	for i, finding := range msg.findings {
+		details = append(details, finding.String())
+		if i < maxFindingsShown {
+			fmt.Fprintf(&b, "• %s\n", finding)
+		}
+	}
+	if len(msg.findings) > maxFindingsShown {
+		fmt.Fprintf(&b, "… and %d more\n", len(msg.findings)-maxFindingsShown)
+	}
+	b.WriteString("\nPush anyway? The override is recorded in the audit log.")
+
+	override := func() tea.Msg {
+		entry := audit.Entry{
+			Action:   "safety_scan_overridden",
+			Instance: msg.instance.Title,
+			Detail:   strings.Join(details, "; "),
+		}
+		if worktree, err := msg.instance.GetGitWorktree(); err == nil {
+			entry.Repository = worktree.GetRepoPath()
+		}
+		audit.Record(entry)
+		return msg.push()
+	}
+	cmd := m.confirmAction(b.String(), override)
+	m.confirmationOverlay.SetWidth(80)
+	return cmd
+}
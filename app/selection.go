@@ -0,0 +1,81 @@
+package app
+
+import (
+	"claude-squad/log"
+	"claude-squad/ui"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// selectRepo switches the list to the repository tab of repoPath and selects the instance last selected in
+// that repository, if it's still around.
+func (m *home) selectRepo(repoPath string) {
+	m.list.SelectRepo(repoPath)
+	if repoPath == "" {
+		return
+	}
+	repo, err := m.appState.GetRepository(repoPath)
+	if err != nil || repo.LastSelectedInstance == "" {
+		return
+	}
+	for idx, instance := range m.list.GetInstances() {
+		if instance.Title == repo.LastSelectedInstance && instance.RepoPath() == repoPath {
+			m.list.SetSelectedInstance(idx)
+			return
+		}
+	}
+}
+
+// saveSelectedRepo records the selected repository tab in the state, so it's selected again on startup.
+func (m *home) saveSelectedRepo() {
+	state := m.appState
+	repoPath := m.repoTabs.GetSelectedRepo()
+	if repoPath == "" || state.GetSelectedRepository() == repoPath {
+		return
+	}
+	// Repositories that aren't registered can't be selected in the state.
+	if _, err := state.GetRepository(repoPath); err != nil {
+		return
+	}
+	if err := state.SetSelectedRepository(repoPath); err != nil {
+		log.WarningLog.Printf("could not save the selected repository: %v", err)
+	}
+}
+
+// saveSelection records the selected instance and its repository in the state, so users return to them on
+// startup.
+func (m *home) saveSelection() {
+	state := m.appState
+	selected := m.list.GetSelectedInstance()
+	// A new instance has no title until it's named.
+	if selected == nil || selected.Title == "" {
+		return
+	}
+	repoPath := selected.RepoPath()
+	// Repositories that aren't tracked have nowhere to keep the selection.
+	if _, err := state.GetRepository(repoPath); err != nil {
+		return
+	}
+	if err := state.SetSelection(repoPath, selected.Title); err != nil {
+		log.WarningLog.Printf("could not save the selection: %v", err)
+	}
+}
+
+// togglePinnedRepo pins or unpins the selected repository tab. Pinned tabs come first, and pinned repositories
+// are offered first when creating an instance.
+func (m *home) togglePinnedRepo() tea.Cmd {
+	repoPath := m.list.GetCurrentRepoPath()
+	if repoPath == "" {
+		return m.handleInfo("there are no repository tabs to pin, they're shown once there are several repositories")
+	}
+	pinned := !m.repoTabs.IsPinned(repoPath)
+	if err := m.appState.SetRepositoryPinned(repoPath, pinned); err != nil {
+		return m.handleError(err)
+	}
+	m.repoTabs.SetPinned(repoPath, pinned)
+	if pinned {
+		return m.handleInfo(fmt.Sprintf("pinned %s", ui.RepoDisplayName(repoPath)))
+	}
+	return m.handleInfo(fmt.Sprintf("unpinned %s", ui.RepoDisplayName(repoPath)))
+}
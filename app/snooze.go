@@ -0,0 +1,72 @@
+package app
+
+import (
+	"claude-squad/ui/overlay"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// snoozeDurations are the choices offered when snoozing an instance.
+var snoozeDurations = []time.Duration{15 * time.Minute, time.Hour, 4 * time.Hour, 8 * time.Hour, 24 * time.Hour}
+
+// showSnoozePicker lets the user pick how long to snooze the selected instance's alerts and notifications
+// for, or end its snooze early.
+func (m *home) showSnoozePicker() tea.Cmd {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return nil
+	}
+
+	items := make([]overlay.SelectionItem, 0, len(snoozeDurations)+1)
+	for _, d := range snoozeDurations {
+		items = append(items, overlay.SelectionItem{
+			Label:       "Snooze for " + formatSnoozeDuration(d),
+			Description: "until " + time.Now().Add(d).Format("Mon 15:04"),
+		})
+	}
+	if selected.Snoozed() {
+		items = append(items, overlay.SelectionItem{Label: "Unsnooze", Description: "alert and notify again"})
+	}
+	m.selectionOverlay = overlay.NewSelectionOverlay(fmt.Sprintf("Snooze '%s'", selected.Title), items)
+	m.state = stateSnooze
+	return nil
+}
+
+// handleSnoozeState snoozes or unsnoozes the selected instance once a choice is made.
+func (m *home) handleSnoozeState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.selectionOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+	choice := m.selectionOverlay.Selected()
+	m.selectionOverlay = nil
+	m.state = stateDefault
+
+	selected := m.list.GetSelectedInstance()
+	if choice < 0 || selected == nil {
+		return m, tea.WindowSize()
+	}
+	var info string
+	if choice < len(snoozeDurations) {
+		selected.SnoozedUntil = time.Now().Add(snoozeDurations[choice])
+		info = fmt.Sprintf("'%s' snoozed until %s", selected.Title, selected.SnoozedUntil.Format("Mon 15:04"))
+	} else {
+		selected.SnoozedUntil = time.Time{}
+		info = fmt.Sprintf("'%s' unsnoozed", selected.Title)
+	}
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		return m, tea.Batch(tea.WindowSize(), m.handleError(err))
+	}
+	return m, tea.Batch(tea.WindowSize(), m.handleInfo(info))
+}
+
+func formatSnoozeDuration(d time.Duration) string {
+	if d < time.Hour {
+		return fmt.Sprintf("%d minutes", int(d.Minutes()))
+	}
+	if d == time.Hour {
+		return "1 hour"
+	}
+	return fmt.Sprintf("%d hours", int(d.Hours()))
+}
@@ -0,0 +1,97 @@
+package app
+
+import (
+	"claude-squad/config"
+	"claude-squad/keys"
+	"claude-squad/stats"
+	"claude-squad/ui/overlay"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// statsMsg carries the repository stats once they're collected.
+type statsMsg struct {
+	report stats.Report
+	err    error
+}
+
+// collectStats collects the repository stats in the background, since measuring the worktrees' disk usage
+// may take a while.
+func (m *home) collectStats() tea.Cmd {
+	data, err := m.storage.LoadInstanceData()
+	if err != nil {
+		return m.handleError(err)
+	}
+	return tea.Batch(m.handleInfo("collecting stats..."), func() tea.Msg {
+		report, err := stats.Collect(time.Now(), data)
+		return statsMsg{report: report, err: err}
+	})
+}
+
+// showStats displays the repository stats and the action to export them.
+func (m *home) showStats(msg statsMsg) tea.Cmd {
+	if msg.err != nil {
+		return m.handleError(msg.err)
+	}
+	// Don't cover whatever the user moved on to while the stats were collected.
+	if m.state != stateDefault {
+		return nil
+	}
+
+	report := msg.report.Lines()
+	lines := []string{titleStyle.Render("Repository Stats"), ""}
+	for _, line := range report[1:] {
+		lines = append(lines, descStyle.Render(line))
+	}
+	lines = append(lines,
+		"",
+		headerStyle.Render("Actions:"),
+		keyStyle.Render(keys.Help(keys.KeyExportStats))+descStyle.Render(" - Export the stats as JSON"),
+		"",
+		descStyle.Render("Press any other key to dismiss."),
+	)
+	m.statsReport = msg.report
+	m.textOverlay = overlay.NewTextOverlay(lipgloss.JoinVertical(lipgloss.Left, lines...))
+	m.state = stateStats
+	return nil
+}
+
+// handleStatsState exports the displayed stats if asked to, and dismisses them.
+func (m *home) handleStatsState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.state = stateDefault
+	m.textOverlay = nil
+	report := m.statsReport
+	m.statsReport = stats.Report{}
+	if name, _ := keys.OverlayKey(keys.OverlayStats, msg.String()); name != keys.KeyExportStats {
+		return m, nil
+	}
+
+	path, err := exportStats(report)
+	if err != nil {
+		return m, m.handleError(err)
+	}
+	return m, m.handleInfo("exported the stats to " + path)
+}
+
+// exportStats writes report as JSON to the config directory and returns where.
+func exportStats(report stats.Report) (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal stats: %w", err)
+	}
+	path := filepath.Join(configDir, fmt.Sprintf("stats-%s.json", report.GeneratedAt.Format("20060102-150405")))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to export stats: %w", err)
+	}
+	return path, nil
+}
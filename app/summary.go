@@ -0,0 +1,19 @@
+package app
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// captureSummary stores the summary an instance wrote after being asked for one.
+func (m *home) captureSummary(instance *session.Instance) tea.Cmd {
+	if err := instance.CaptureSummary(); err != nil {
+		return m.handleError(err)
+	}
+	if err := m.storage.SaveInstances(m.list.GetInstances()); err != nil {
+		log.WarningLog.Printf("could not save summary: %v", err)
+	}
+	return m.instanceChanged()
+}
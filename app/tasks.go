@@ -0,0 +1,56 @@
+package app
+
+import (
+	"claude-squad/config"
+	"claude-squad/prompt"
+	"claude-squad/ui/overlay"
+	"fmt"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// showTaskPicker lists the task library so the user can start a new instance from one of its tasks.
+func (m *home) showTaskPicker() (tea.Model, tea.Cmd) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return m, m.handleError(err)
+	}
+	tasks, err := prompt.LoadTasks(filepath.Join(configDir, prompt.TasksDirName))
+	if err != nil {
+		return m, m.handleError(err)
+	}
+	if len(tasks) == 0 {
+		return m, m.handleError(fmt.Errorf("no tasks found in %s", filepath.Join(configDir, prompt.TasksDirName)))
+	}
+
+	items := make([]overlay.SelectionItem, 0, len(tasks))
+	for _, task := range tasks {
+		items = append(items, overlay.SelectionItem{Label: task.Name, Description: task.Description})
+	}
+	m.tasks = tasks
+	m.selectionOverlay = overlay.NewSelectionOverlay("Start a new session from a task", items)
+	m.state = stateTaskPicker
+	return m, nil
+}
+
+// handleTaskPickerState handles key presses while the task picker is shown. Picking a task starts a new
+// instance whose prompt overlay is pre-filled with the task's prompt.
+func (m *home) handleTaskPickerState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.selectionOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+	selected := m.selectionOverlay.Selected()
+	tasks := m.tasks
+	m.selectionOverlay = nil
+	m.tasks = nil
+	m.state = stateDefault
+	if selected < 0 {
+		return m, tea.WindowSize()
+	}
+
+	model, cmd := m.startNewInstance(true)
+	m.taskPrompt = tasks[selected].Prompt
+	m.taskName = tasks[selected].Name
+	return model, tea.Batch(tea.WindowSize(), cmd)
+}
@@ -0,0 +1,196 @@
+package app
+
+import (
+	"claude-squad/audit"
+	"claude-squad/config"
+	"claude-squad/network"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"claude-squad/tickets"
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ticketLoadedMsg carries the ticket to create a new instance from.
+type ticketLoadedMsg struct {
+	ticket tickets.Ticket
+}
+
+// ticketsSyncedMsg carries the keys of the tickets moved to done after their instances' branches merged, and
+// of those queued for retry because the tracker couldn't be reached.
+type ticketsSyncedMsg struct {
+	keys   []string
+	queued []string
+}
+
+// ticketSync is the update of the ticket of an instance whose branch merged.
+type ticketSync struct {
+	cfg      config.TicketConfig
+	instance string
+	key      string
+	repo     string
+	comment  string
+}
+
+// run moves the ticket to done and comments on it.
+func (sync ticketSync) run() error {
+	tracker, err := tickets.New(sync.cfg)
+	if err != nil {
+		return err
+	}
+	if err := tracker.Resolve(sync.key); err != nil {
+		return err
+	}
+	if err := tracker.Comment(sync.key, sync.comment); err != nil {
+		return err
+	}
+	audit.Record(audit.Entry{
+		Action:     "ticket_resolved",
+		Instance:   sync.instance,
+		Repository: sync.repo,
+		Detail:     fmt.Sprintf("moved %s to %s", sync.key, sync.cfg.GetDoneState()),
+	})
+	return nil
+}
+
+// ticketConfig returns the issue tracker configuration of the selected repository.
+func (m *home) ticketConfig() (config.TicketConfig, error) {
+	if err := network.Check("creating sessions from tickets"); err != nil {
+		return config.TicketConfig{}, err
+	}
+	repo := m.repoTabs.GetSelectedRepo()
+	if repo == "" {
+		return config.TicketConfig{}, fmt.Errorf("select a repository to create the session in first")
+	}
+	cfg := m.appConfig.GetRepoConfig(repo).Tickets
+	if cfg.Tracker == "" {
+		return cfg, fmt.Errorf("no issue tracker is configured for %s, set tickets in its entry of repos in the config", repo)
+	}
+	return cfg, nil
+}
+
+// showTicketInput asks for the ticket to create a new instance from.
+func (m *home) showTicketInput() (tea.Model, tea.Cmd) {
+	if _, err := m.ticketConfig(); err != nil {
+		return m, m.handleError(err)
+	}
+	m.textInputOverlay = overlay.NewTextInputOverlay("Create a session from a ticket, e.g. ENG-123", "")
+	m.state = stateTicket
+	m.menu.SetState(ui.StatePrompt)
+	return m, nil
+}
+
+// handleTicketState handles key presses while the ticket is being entered, and fetches the ticket in the
+// background once it's submitted.
+func (m *home) handleTicketState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.textInputOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+	submitted := m.textInputOverlay.IsSubmitted()
+	key := strings.TrimSpace(m.textInputOverlay.GetValue())
+	m.textInputOverlay = nil
+	m.state = stateDefault
+	m.menu.SetState(ui.StateDefault)
+	if !submitted || key == "" {
+		return m, tea.WindowSize()
+	}
+
+	cfg, err := m.ticketConfig()
+	if err != nil {
+		return m, tea.Batch(tea.WindowSize(), m.handleError(err))
+	}
+	return m, tea.Batch(tea.WindowSize(), func() tea.Msg {
+		tracker, err := tickets.New(cfg)
+		if err != nil {
+			return err
+		}
+		ticket, err := tracker.Get(key)
+		if err != nil {
+			return err
+		}
+		return ticketLoadedMsg{ticket: ticket}
+	})
+}
+
+// startTicketInstance starts naming a new instance for a ticket, titled after the ticket's key and with its
+// prompt pre-filled with the ticket's title and description.
+func (m *home) startTicketInstance(msg ticketLoadedMsg) (tea.Model, tea.Cmd) {
+	if m.state != stateDefault {
+		return m, m.handleError(fmt.Errorf("could not start the session for %s, finish what you're doing first", msg.ticket.Key))
+	}
+	model, cmd := m.startNewInstance(true)
+	m.taskPrompt = msg.ticket.Prompt()
+	m.taskTicket = msg.ticket.Key
+	if m.state == stateNew {
+		instance := m.list.GetInstances()[m.list.NumInstances()-1]
+		if err := instance.SetTitle(msg.ticket.Key); err != nil {
+			return model, tea.Batch(cmd, m.handleError(err))
+		}
+	}
+	return model, cmd
+}
+
+// syncTickets moves the tickets of instances whose branches merged to done in the background, and comments
+// on them with the instances' summaries. Nothing is synced in air-gapped mode.
+func (m *home) syncTickets(merged []*session.Instance) tea.Cmd {
+	var syncs []ticketSync
+	for _, instance := range merged {
+		worktree, err := instance.GetGitWorktree()
+		if instance.Ticket == "" || err != nil {
+			continue
+		}
+		repo := worktree.GetRepoPath()
+		cfg := m.appConfig.GetRepoConfig(repo).Tickets
+		if cfg.Tracker == "" {
+			continue
+		}
+		syncs = append(syncs, ticketSync{cfg: cfg, instance: instance.Title, key: instance.Ticket, repo: repo, comment: ticketComment(instance)})
+	}
+	if len(syncs) == 0 || network.AirGapped() {
+		return nil
+	}
+	return func() tea.Msg {
+		var keys, queued []string
+		var errs []string
+		for _, sync := range syncs {
+			if err := sync.run(); err != nil {
+				// The tracker is updated once the network is back.
+				if git.IsNetworkError(err) {
+					m.retryQueue.Add(fmt.Sprintf("update of ticket %s", sync.key), sync.run)
+					queued = append(queued, sync.key)
+					continue
+				}
+				errs = append(errs, err.Error())
+				continue
+			}
+			keys = append(keys, sync.key)
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("failed to update tickets: %s", strings.Join(errs, "; "))
+		}
+		return ticketsSyncedMsg{keys: keys, queued: queued}
+	}
+}
+
+// ticketComment returns the comment left on an instance's ticket when its branch merges.
+func ticketComment(instance *session.Instance) string {
+	comment := fmt.Sprintf("Branch %s was merged.", instance.Branch)
+	if summary := strings.TrimSpace(instance.Summary); summary != "" {
+		comment += "\n\n" + summary
+	}
+	return comment
+}
+
+// handleTicketsSynced reports the tickets moved to done, or queued to be.
+func (m *home) handleTicketsSynced(msg ticketsSyncedMsg) tea.Cmd {
+	if len(msg.queued) > 0 {
+		m.list.SetQueued(m.retryQueue.Len())
+		return m.handleInfo(fmt.Sprintf("offline: update of %s queued, it will be retried when the network is back",
+			strings.Join(msg.queued, ", ")))
+	}
+	return m.handleInfo(fmt.Sprintf("moved %s to done", strings.Join(msg.keys, ", ")))
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"claude-squad/session"
+	"fmt"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// terminalTitle summarizes the instances for the terminal window title, so it's visible from the tab bar
+// whether any of them needs attention. Snoozed instances aren't counted.
+func terminalTitle(instances []*session.Instance) string {
+	ready, running := 0, 0
+	for _, instance := range instances {
+		if !instance.Started() || instance.Inactive() || instance.Snoozed() {
+			continue
+		}
+		switch instance.Status {
+		case session.Ready:
+			ready++
+		case session.Running:
+			running++
+		}
+	}
+	return fmt.Sprintf("cs: %d ready / %d running", ready, running)
+}
+
+// updateTerminalTitle sets the terminal title if the summary changed since it was last set.
+func (m *home) updateTerminalTitle() tea.Cmd {
+	if !m.appConfig.TerminalTitle {
+		return nil
+	}
+	title := terminalTitle(m.list.GetInstances())
+	if title == m.terminalTitle {
+		return nil
+	}
+	m.terminalTitle = title
+	return tea.SetWindowTitle(title)
+}
+
+// saveTerminalTitle pushes the terminal's title onto its title stack, so restoreTerminalTitle can put it
+// back on exit. Terminals without a title stack ignore both.
+func saveTerminalTitle() {
+	fmt.Fprint(os.Stdout, "\x1b[22;0t")
+}
+
+// restoreTerminalTitle pops the title saved by saveTerminalTitle.
+func restoreTerminalTitle() {
+	fmt.Fprint(os.Stdout, "\x1b[23;0t")
+}
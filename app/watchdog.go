@@ -0,0 +1,117 @@
+package app
+
+import (
+	"claude-squad/config"
+	"claude-squad/keys"
+	"claude-squad/session"
+	"claude-squad/ui/overlay"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// updateStatus updates the status of a started instance from its pane content, answering or alerting on
+// prompts and marking it as stalled once it has been working without output for too long.
+func (m *home) updateStatus(instance *session.Instance) []tea.Cmd {
+	var cmds []tea.Cmd
+	updated, prompt := instance.HasUpdated()
+	if updated {
+		// Claude's spinner and timer redraw every second while it works, so changes to them alone neither
+		// count as activity nor end a stall.
+		if instance.Progressed() {
+			instance.SetStatus(session.Running)
+			instance.RecordActivity()
+			delete(m.alerted, instance)
+		} else if instance.Status != session.Stalled {
+			instance.SetStatus(session.Running)
+		}
+	} else if prompt {
+		if instance.AnswersPrompts() {
+			// Does nothing while the user is attached.
+			instance.TapEnter()
+		} else {
+			cmds = append(cmds, m.alert(instance, config.AlertNeedsInput))
+		}
+	} else if !instance.Busy() && instance.Status != session.OverBudget {
+		instance.SetStatus(session.Ready)
+	}
+	if instance.Busy() {
+		if cmd := m.checkStalled(instance); cmd != nil {
+			cmds = append(cmds, cmd, m.alert(instance, config.AlertError))
+		}
+	}
+	return cmds
+}
+
+// checkStalled marks a working instance as stalled once it has gone without output for longer than the
+// configured stall timeout. The user is notified when the instance first becomes stalled.
+func (m *home) checkStalled(instance *session.Instance) tea.Cmd {
+	timeout := time.Duration(m.appConfig.StallTimeout) * time.Second
+	if timeout <= 0 || instance.IdleFor() < timeout || instance.Status == session.Stalled {
+		return nil
+	}
+
+	instance.SetStatus(session.Stalled)
+	if instance.Snoozed() || instance.Attached() {
+		return nil
+	}
+	return m.handleError(fmt.Errorf("'%s' has produced no output for %s and may be stalled, press %s for actions",
+		instance.Title, instance.IdleFor().Round(time.Second), keys.Help(keys.KeyStalled)))
+}
+
+// showStalledActions displays the actions that can be taken on the selected instance if it's stalled.
+func (m *home) showStalledActions() {
+	selected := m.list.GetSelectedInstance()
+	if selected == nil || selected.Status != session.Stalled {
+		return
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		titleStyle.Render("Stalled Instance"),
+		"",
+		descStyle.Render(fmt.Sprintf("'%s' has produced no output for %s.", selected.Title,
+			selected.IdleFor().Round(time.Second))),
+		"",
+		headerStyle.Render("Actions:"),
+		keyStyle.Render(keys.Help(keys.KeyInterrupt))+descStyle.Render(" - Interrupt the current operation"),
+		keyStyle.Render(keys.Help(keys.KeyNudge))+descStyle.Render(" - Send a nudge prompt"),
+		keyStyle.Render(keys.Help(keys.KeyRestart))+descStyle.Render(" - Restart the program"),
+		"",
+		descStyle.Render("Press any other key to dismiss."),
+	)
+	m.textOverlay = overlay.NewTextOverlay(content)
+	m.state = stateStalled
+}
+
+// handleStalledState runs the chosen action on the stalled instance.
+func (m *home) handleStalledState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.state = stateDefault
+	m.textOverlay = nil
+
+	selected := m.list.GetSelectedInstance()
+	if selected == nil {
+		return m, nil
+	}
+
+	name, _ := keys.OverlayKey(keys.OverlayStalled, msg.String())
+	var err error
+	switch name {
+	case keys.KeyInterrupt:
+		err = selected.Interrupt()
+	case keys.KeyNudge:
+		err = selected.SendPrompt(m.appConfig.GetNudgePrompt())
+	case keys.KeyRestart:
+		err = selected.RestartProgram()
+	default:
+		return m, nil
+	}
+	if err != nil {
+		return m, m.handleError(err)
+	}
+	if selected.Status == session.Stalled {
+		selected.SetStatus(session.Running)
+	}
+	return m, nil
+}
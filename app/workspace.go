@@ -0,0 +1,95 @@
+package app
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/ui"
+	"claude-squad/ui/overlay"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// restoreWorkspace shows the workspace selected when the app was last used. All repositories are shown
+// instead if a directory outside of it was given.
+func (m *home) restoreWorkspace() {
+	selected := m.appState.GetSelectedWorkspace()
+	if selected == "" {
+		return
+	}
+	workspace, err := m.appState.GetWorkspace(selected)
+	if err != nil {
+		log.WarningLog.Printf("could not restore the selected workspace: %v", err)
+		return
+	}
+	if m.targetDir != "" && !workspace.Contains(m.targetDir) {
+		return
+	}
+	m.list.SetWorkspace(workspace)
+}
+
+// showWorkspacePicker lets the user pick the workspace to show, or all repositories.
+func (m *home) showWorkspacePicker() (tea.Model, tea.Cmd) {
+	state := m.appState
+	if len(state.GetWorkspaces()) == 0 {
+		return m, m.handleInfo("no workspaces yet, create one with `claude-squad workspace set <name> <repository>...`")
+	}
+	items := []overlay.SelectionItem{{Label: "All repositories", Description: fmt.Sprintf("%d repositories",
+		len(state.GetRepositories()))}}
+	for _, workspace := range state.GetWorkspaces() {
+		names := make([]string, 0, len(workspace.Repositories))
+		for _, repo := range workspace.Repositories {
+			names = append(names, ui.RepoDisplayName(repo))
+		}
+		items = append(items, overlay.SelectionItem{Label: workspace.Name, Description: strings.Join(names, ", ")})
+	}
+	m.selectionOverlay = overlay.NewSelectionOverlay("Show workspace", items)
+	m.state = stateWorkspace
+	return m, nil
+}
+
+// handleWorkspaceState switches the view to the picked workspace, and records it in the state so it's shown
+// again on startup.
+func (m *home) handleWorkspaceState(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if !m.selectionOverlay.HandleKeyPress(msg) {
+		return m, nil
+	}
+	choice := m.selectionOverlay.Selected()
+	m.selectionOverlay = nil
+	m.state = stateDefault
+	if choice < 0 {
+		return m, tea.WindowSize()
+	}
+
+	var workspace *config.Workspace
+	name := ""
+	if choice > 0 {
+		picked := m.appState.GetWorkspaces()[choice-1]
+		workspace, name = &picked, picked.Name
+	}
+	if err := m.appState.SetSelectedWorkspace(name); err != nil {
+		return m, tea.Batch(tea.WindowSize(), m.handleError(err))
+	}
+	m.list.SetWorkspace(workspace)
+	m.saveSelectedRepo()
+	// The tabs shown change, and with them the height of the list.
+	if workspace == nil {
+		return m, tea.Batch(tea.WindowSize(), m.handleInfo("showing all repositories"))
+	}
+	return m, tea.Batch(tea.WindowSize(), m.handleInfo(fmt.Sprintf("showing workspace %s", name)))
+}
+
+// showWorkspaceOf switches the view to all repositories if instance's repository isn't in the selected
+// workspace, so a new instance doesn't disappear from the list as soon as it's started.
+func (m *home) showWorkspaceOf(instance *session.Instance) {
+	workspace := m.list.GetWorkspace()
+	if workspace == nil || workspace.Contains(instance.RepoPath()) {
+		return
+	}
+	m.list.SetWorkspace(nil)
+	if err := m.appState.SetSelectedWorkspace(""); err != nil {
+		log.WarningLog.Printf("could not save the selected workspace: %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+// Package archive freezes instances into tarballs for long-term retention and keeps a history of them.
+package archive
+
+import (
+	"archive/tar"
+	"claude-squad/config"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+const (
+	// HistoryFileName is the file in the state directory that lists frozen instances.
+	HistoryFileName = "history.json"
+
+	metadataName = "metadata.json"
+	bundleName   = "branch.bundle"
+	worktreeDir  = "worktree/"
+)
+
+// Entry is a frozen instance in the history.
+type Entry struct {
+	Instance   string    `json:"instance"`
+	Repository string    `json:"repository"`
+	Branch     string    `json:"branch"`
+	CreatedAt  time.Time `json:"created_at"`
+	FrozenAt   time.Time `json:"frozen_at"`
+	// Path is the archive's location.
+	Path string `json:"path"`
+}
+
+// Metadata is written into each archive alongside the worktree.
+type Metadata struct {
+	FrozenAt time.Time            `json:"frozen_at"`
+	Instance session.InstanceData `json:"instance"`
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Freeze writes the instance's worktree, including untracked and ignored files, its branch as a git
+// bundle and its metadata to a gzipped tarball in dir, and records it in the history. Paused instances
+// have no worktree, so only their branch and metadata are archived.
+func Freeze(data session.InstanceData, dir string) (*Entry, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	now := time.Now()
+	name := fmt.Sprintf("%s-%s.tar.gz", unsafeNameChars.ReplaceAllString(data.Title, "_"), now.Format("20060102-150405"))
+	path := filepath.Join(dir, name)
+
+	if err := writeArchive(path, data, now); err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	entry := Entry{
+		Instance:   data.Title,
+		Repository: data.Worktree.RepoPath,
+		Branch:     data.Branch,
+		CreatedAt:  data.CreatedAt,
+		FrozenAt:   now,
+		Path:       path,
+	}
+	history, err := History()
+	if err != nil {
+		return nil, err
+	}
+	if err := saveHistory(append(history, entry)); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func writeArchive(path string, data session.InstanceData, now time.Time) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	metadata, err := json.MarshalIndent(Metadata{FrozenAt: now, Instance: data}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, metadataName, metadata); err != nil {
+		return err
+	}
+
+	bundleDir, err := os.MkdirTemp("", "claudesquad-freeze-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(bundleDir)
+	bundlePath := filepath.Join(bundleDir, bundleName)
+	if err := git.CreateBundle(data.Worktree.RepoPath, data.Branch, bundlePath); err != nil {
+		return err
+	}
+	bundle, err := os.ReadFile(bundlePath)
+	if err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, bundleName, bundle); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(data.Worktree.WorktreePath); err == nil {
+		if err := addWorktree(tw, data.Worktree.WorktreePath); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// addWorktree adds every file under dir to the archive. The .git file only points at the repository's
+// worktree metadata, which the bundle replaces.
+func addWorktree(tw *tar.Writer, dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if rel == ".git" {
+			return nil
+		}
+
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = worktreeDir + filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tw, file)
+		return err
+	})
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte) error {
+	header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func getHistoryPath() (string, error) {
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get state directory: %w", err)
+	}
+	return filepath.Join(stateDir, HistoryFileName), nil
+}
+
+// History returns the frozen instances, oldest first.
+func History() ([]Entry, error) {
+	path, err := getHistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse history: %w", err)
+	}
+	return entries, nil
+}
+
+func saveHistory(entries []Entry) error {
+	path, err := getHistoryPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal history: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Describe formats an entry for display, e.g. in the history screen.
+func (e Entry) Describe() string {
+	return fmt.Sprintf("%s  %s (%s) → %s", e.FrozenAt.Local().Format("2006-01-02 15:04"), e.Instance,
+		filepath.Base(e.Repository), e.Path)
+}
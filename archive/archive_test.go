@@ -0,0 +1,75 @@
+package archive
+
+import (
+	"archive/tar"
+	"claude-squad/session"
+	"claude-squad/session/git/gittest"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreeze(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	r := gittest.New(t)
+	repo := r.Dir
+	r.Commit("main.go", "initial")
+	r.Run("branch", "alice/feature")
+
+	worktree := filepath.Join(t.TempDir(), "feature")
+	require.NoError(t, os.MkdirAll(filepath.Join(worktree, "src"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: elsewhere\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(worktree, "src", "untracked.txt"), []byte("notes"), 0644))
+	require.NoError(t, os.Symlink("src/untracked.txt", filepath.Join(worktree, "link")))
+
+	data := session.InstanceData{Title: "my feature", Branch: "alice/feature"}
+	data.Worktree.RepoPath = repo
+	data.Worktree.WorktreePath = worktree
+
+	dir := t.TempDir()
+	entry, err := Freeze(data, dir)
+	require.NoError(t, err)
+	require.Equal(t, dir, filepath.Dir(entry.Path))
+	require.Regexp(t, `^my_feature-\d{8}-\d{6}\.tar\.gz$`, filepath.Base(entry.Path))
+
+	f, err := os.Open(entry.Path)
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	contents := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		contents[header.Name] = string(body)
+		if header.Name == "worktree/link" {
+			require.Equal(t, "src/untracked.txt", header.Linkname)
+		}
+	}
+	require.Contains(t, contents, metadataName)
+	require.Contains(t, contents[metadataName], `"title": "my feature"`)
+	require.Contains(t, contents, bundleName)
+	require.Equal(t, "notes", contents["worktree/src/untracked.txt"])
+	require.Contains(t, contents, "worktree/src/")
+	require.Contains(t, contents, "worktree/link")
+	require.NotContains(t, contents, "worktree/.git")
+
+	history, err := History()
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, "my feature", history[0].Instance)
+	require.Equal(t, "alice/feature", history[0].Branch)
+	require.Equal(t, entry.Path, history[0].Path)
+	require.True(t, entry.FrozenAt.Equal(history[0].FrozenAt))
+}
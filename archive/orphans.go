@@ -0,0 +1,83 @@
+package archive
+
+import (
+	"claude-squad/config"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"fmt"
+	"strings"
+)
+
+// Orphans are the branches claude-squad created that no stored or archived instance uses anymore, e.g. because
+// they were left behind by instances killed with older versions or in repositories that were reset.
+type Orphans struct {
+	Branches []git.SquadBranch
+	// Warnings describe repositories whose branches couldn't be listed. They're left alone.
+	Warnings []string
+}
+
+// FindOrphans finds the orphaned branches in repos and in the repositories of the instances and archives.
+// Branches are recognized by the global and the repository's branch prefix, and by the commit trailers that name
+// the instance's session. The prefix of a repository that isn't in repos is read from its settings file.
+func FindOrphans(cfg *config.Config, repos []config.RepositoryData, instances []session.InstanceData) (*Orphans, error) {
+	history, err := History()
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool)
+	seen := make(map[string]bool)
+	var paths []string
+	addRepo := func(path string) {
+		if path != "" && !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	settings := make(map[string]*config.RepoSettings)
+	for _, repo := range repos {
+		addRepo(repo.Path)
+		if repo.Settings != nil {
+			settings[repo.Path] = repo.Settings
+		}
+	}
+	for _, instance := range instances {
+		used[instance.Worktree.BranchName] = true
+		addRepo(instance.Worktree.RepoPath)
+	}
+	for _, entry := range history {
+		used[entry.Branch] = true
+		addRepo(entry.Repository)
+	}
+
+	var trailerKeys []string
+	for _, trailer := range cfg.CommitTrailers {
+		if strings.Contains(trailer.Value, "{session}") {
+			trailerKeys = append(trailerKeys, trailer.Key)
+		}
+	}
+
+	orphans := &Orphans{}
+	for _, path := range paths {
+		if !git.IsGitRepo(path) {
+			orphans.Warnings = append(orphans.Warnings, fmt.Sprintf("%s is not a git repository anymore", path))
+			continue
+		}
+		repoSettings, ok := settings[path]
+		if !ok {
+			repoSettings = config.GetRepoSettings(nil, path)
+		}
+		prefixes := []string{cfg.BranchPrefix, repoSettings.BranchPrefix}
+		branches, err := git.SquadBranches(path, prefixes, trailerKeys)
+		if err != nil {
+			orphans.Warnings = append(orphans.Warnings, err.Error())
+			continue
+		}
+		for _, branch := range branches {
+			if !used[branch.Name] {
+				orphans.Branches = append(orphans.Branches, branch)
+			}
+		}
+	}
+	return orphans, nil
+}
@@ -0,0 +1,126 @@
+package archive
+
+import (
+	"claude-squad/audit"
+	"claude-squad/config"
+	"claude-squad/digest"
+	"claude-squad/hooks"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Candidate is an instance the retention policy archives.
+type Candidate struct {
+	Title    string
+	Branch   string
+	MergedAt time.Time
+}
+
+// Plan is what enforcing the retention policy affects.
+type Plan struct {
+	// Instances are frozen to an archive and killed.
+	Instances []Candidate
+	// Archives are deleted.
+	Archives []Entry
+	// Warnings describe instances whose merge state couldn't be determined. They're left alone.
+	Warnings []string
+}
+
+// Empty returns true if the plan affects nothing.
+func (p *Plan) Empty() bool {
+	return len(p.Instances) == 0 && len(p.Archives) == 0
+}
+
+// NewPlan determines which instances and archives the retention policy affects at now: instances whose
+// branch was merged long enough ago, and archives old enough to delete. It only inspects the instances'
+// repositories, so it's safe to use for a preview.
+func NewPlan(cfg config.RetentionConfig, instances []session.InstanceData, now time.Time) (*Plan, error) {
+	plan := &Plan{}
+	for _, instance := range instances {
+		worktree := git.NewGitWorktreeFromStorage(instance.Worktree.RepoPath, instance.Worktree.WorktreePath,
+			instance.Worktree.SessionName, instance.Worktree.BranchName, instance.Worktree.BaseCommitSHA)
+		mergedAt, merged, err := worktree.MergedAt()
+		if err != nil {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s: %v", instance.Title, err))
+			continue
+		}
+		if !merged || now.Sub(mergedAt) < cfg.GetArchiveMergedAfter() {
+			continue
+		}
+		// Like killing, archiving requires that the branch isn't checked out.
+		if checkedOut, err := worktree.IsBranchCheckedOut(); err != nil || checkedOut {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s: merged but its branch is checked out", instance.Title))
+			continue
+		}
+		plan.Instances = append(plan.Instances, Candidate{Title: instance.Title, Branch: instance.Branch, MergedAt: mergedAt})
+	}
+
+	history, err := History()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range history {
+		if now.Sub(entry.FrozenAt) >= cfg.GetDeleteArchivesAfter() {
+			plan.Archives = append(plan.Archives, entry)
+		}
+	}
+	return plan, nil
+}
+
+// Apply archives the plan's instances to dir and kills them, then deletes its archives. It returns the
+// instances that were archived, so the caller can remove them from storage, even if it fails part way.
+func (p *Plan) Apply(dir string, instances []*session.Instance) ([]*session.Instance, error) {
+	byTitle := make(map[string]*session.Instance, len(instances))
+	for _, instance := range instances {
+		byTitle[instance.Title] = instance
+	}
+
+	var archived []*session.Instance
+	for _, candidate := range p.Instances {
+		instance, ok := byTitle[candidate.Title]
+		if !ok {
+			continue
+		}
+		entry, err := Freeze(instance.ToInstanceData(), dir)
+		if err != nil {
+			return archived, fmt.Errorf("failed to archive instance %s: %w", instance.Title, err)
+		}
+		audit.Record(audit.Entry{
+			Action:     "instance_archived",
+			Instance:   instance.Title,
+			Repository: entry.Repository,
+			Detail:     fmt.Sprintf("merged %s, archived to %s", candidate.MergedAt.Format(time.RFC3339), entry.Path),
+		})
+		hooks.Run(hooks.InstanceKilled, instance)
+		digest.RecordKilled(instance)
+		if err := instance.Kill(); err != nil {
+			return archived, fmt.Errorf("failed to kill archived instance %s: %w", instance.Title, err)
+		}
+		archived = append(archived, instance)
+	}
+
+	if len(p.Archives) == 0 {
+		return archived, nil
+	}
+	deleted := make(map[string]bool, len(p.Archives))
+	for _, entry := range p.Archives {
+		if err := os.Remove(entry.Path); err != nil && !os.IsNotExist(err) {
+			return archived, fmt.Errorf("failed to delete archive: %w", err)
+		}
+		deleted[entry.Path] = true
+	}
+	history, err := History()
+	if err != nil {
+		return archived, err
+	}
+	kept := history[:0]
+	for _, entry := range history {
+		if !deleted[entry.Path] {
+			kept = append(kept, entry)
+		}
+	}
+	return archived, saveHistory(kept)
+}
@@ -0,0 +1,47 @@
+package archive
+
+import (
+	"claude-squad/config"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetentionDeletesOldArchives(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	var entries []Entry
+	for _, age := range []int{100, 90, 30} {
+		path := filepath.Join(dir, fmt.Sprintf("%d.tar.gz", age))
+		require.NoError(t, os.WriteFile(path, nil, 0644))
+		entries = append(entries, Entry{Instance: fmt.Sprint(age), Path: path, FrozenAt: now.AddDate(0, 0, -age)})
+	}
+	require.NoError(t, saveHistory(entries))
+
+	plan, err := NewPlan(config.RetentionConfig{Enabled: true}, nil, now)
+	require.NoError(t, err)
+	require.Len(t, plan.Archives, 2)
+	require.Empty(t, plan.Instances)
+
+	archived, err := plan.Apply(dir, nil)
+	require.NoError(t, err)
+	require.Empty(t, archived)
+
+	history, err := History()
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, "30", history[0].Instance)
+	require.NoFileExists(t, entries[0].Path)
+	require.NoFileExists(t, entries[1].Path)
+	require.FileExists(t, entries[2].Path)
+
+	plan, err = NewPlan(config.RetentionConfig{Enabled: true, DeleteArchivesAfterDays: 10}, nil, now)
+	require.NoError(t, err)
+	require.Len(t, plan.Archives, 1)
+}
@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"bufio"
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/redact"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const FileName = "audit.log"
+
+// Entry is a single record in the audit log.
+type Entry struct {
+	// Time is when the action happened. It is filled in by Record if unset.
+	Time time.Time `json:"time"`
+	// Action is a short machine-readable name for what happened (e.g. "commit_hooks_skipped").
+	Action string `json:"action"`
+	// Instance is the title of the instance the action applied to, if any.
+	Instance string `json:"instance,omitempty"`
+	// Repository is the path of the repository the action applied to, if any.
+	Repository string `json:"repository,omitempty"`
+	// Detail is free-form context for the action.
+	Detail string `json:"detail,omitempty"`
+}
+
+func getAuditLogPath() (string, error) {
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get state directory: %w", err)
+	}
+	return filepath.Join(stateDir, FileName), nil
+}
+
+// Record appends an entry to the audit log. The audit log is append-only JSON lines in the state directory.
+// Secrets in the entry's detail are redacted.
+// Errors are logged rather than returned since a failed audit write should not block the audited action.
+func Record(entry Entry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	entry.Detail = redact.Load(config.LoadConfig()).Redact(entry.Detail)
+	if err := record(entry); err != nil {
+		log.ErrorLog.Printf("failed to write audit log entry %q: %v", entry.Action, err)
+	}
+}
+
+func record(entry Entry) error {
+	path, err := getAuditLogPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Read returns all entries in the audit log, oldest first. Malformed lines are skipped.
+func Read() ([]Entry, error) {
+	path, err := getAuditLogPath()
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,428 @@
+// Package backup writes the whole squad (configuration, state and the branches of every instance) to a
+// single archive and restores it.
+package backup
+
+import (
+	"archive/tar"
+	"claude-squad/config"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	manifestName = "manifest.json"
+	configPrefix = "config/"
+	// statePrefix holds the state directory, when it isn't the config directory.
+	statePrefix  = "state/"
+	bundlePrefix = "bundles/"
+
+	manifestVersion = 1
+)
+
+// skippedDirs are the directories in the config and state directories that aren't backed up. Worktrees are
+// recreated from the bundled branches when instances are resumed, archives of frozen instances are
+// already backups themselves, and the other profiles are backed up with --profile.
+var skippedDirs = map[string]bool{"worktrees": true, "archives": true, "profiles": true}
+
+// Manifest describes the contents of a backup.
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Branches  []Branch  `json:"branches"`
+}
+
+// Branch is an instance branch saved as a git bundle.
+type Branch struct {
+	Instance   string `json:"instance"`
+	Repository string `json:"repository"`
+	Branch     string `json:"branch"`
+	Bundle     string `json:"bundle"`
+}
+
+// Result reports what a backup or restore did.
+type Result struct {
+	Manifest *Manifest
+	// Warnings lists problems that didn't stop the operation, such as branches that couldn't be restored.
+	Warnings []string
+}
+
+// Create writes a backup to outPath. The archive is compressed with gzip, or with zstd if outPath ends
+// in .zst, which requires the zstd command.
+func Create(outPath string) (*Result, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config directory: %w", err)
+	}
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state directory: %w", err)
+	}
+
+	storage, err := session.NewStorage(config.LoadState())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	instances, err := storage.LoadInstanceData()
+	if err != nil {
+		return nil, err
+	}
+
+	bundleDir, err := os.MkdirTemp("", "claudesquad-backup-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(bundleDir)
+
+	result := &Result{Manifest: &Manifest{Version: manifestVersion, CreatedAt: time.Now()}}
+	for i, instance := range instances {
+		repoPath := instance.Worktree.RepoPath
+		bundle := fmt.Sprintf("%d.bundle", i)
+		if err := git.CreateBundle(repoPath, instance.Branch, filepath.Join(bundleDir, bundle)); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %v", instance.Title, err))
+			continue
+		}
+		if instance.Status != session.Paused {
+			worktree := git.NewGitWorktreeFromStorage(repoPath, instance.Worktree.WorktreePath,
+				instance.Worktree.SessionName, instance.Branch, instance.Worktree.BaseCommitSHA)
+			if dirty, err := worktree.IsDirty(); err == nil && dirty {
+				result.Warnings = append(result.Warnings,
+					fmt.Sprintf("%s: uncommitted changes are not included, pause the instance first to keep them", instance.Title))
+			}
+		}
+		result.Manifest.Branches = append(result.Manifest.Branches, Branch{
+			Instance:   instance.Title,
+			Repository: repoPath,
+			Branch:     instance.Branch,
+			Bundle:     bundle,
+		})
+	}
+
+	out, err := createCompressed(outPath)
+	if err != nil {
+		return nil, err
+	}
+	tw := tar.NewWriter(out)
+
+	manifest, err := json.MarshalIndent(result.Manifest, "", "  ")
+	if err != nil {
+		out.Close()
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	err = writeTarFile(tw, manifestName, manifest, 0644)
+	if err == nil {
+		err = addDir(tw, configDir, configPrefix)
+	}
+	if err == nil && stateDir != configDir {
+		err = addDir(tw, stateDir, statePrefix)
+	}
+	if err == nil {
+		err = addDir(tw, bundleDir, bundlePrefix)
+	}
+	if err == nil {
+		err = tw.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(outPath)
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+	return result, nil
+}
+
+// Restore extracts a backup created by Create into the config directory and recreates the instance
+// branches in their repositories. Restored instances are paused; resuming them recreates their worktrees
+// and sessions. Existing state is only overwritten if force is set.
+func Restore(archivePath string, force bool) (*Result, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config directory: %w", err)
+	}
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state directory: %w", err)
+	}
+	if _, err := os.Stat(filepath.Join(stateDir, config.StateFileName)); err == nil && !force {
+		return nil, fmt.Errorf("%s already contains state, use --force to overwrite it", stateDir)
+	}
+
+	bundleDir, err := os.MkdirTemp("", "claudesquad-restore-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(bundleDir)
+
+	manifest, err := extract(archivePath, configDir, stateDir, bundleDir)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Manifest: manifest}
+
+	for _, branch := range manifest.Branches {
+		if _, err := os.Stat(branch.Repository); err != nil || !git.IsGitRepo(branch.Repository) {
+			result.Warnings = append(result.Warnings,
+				fmt.Sprintf("%s: repository %s not found, branch %s was not restored", branch.Instance, branch.Repository, branch.Branch))
+			continue
+		}
+		if err := git.FetchBundle(branch.Repository, filepath.Join(bundleDir, filepath.Base(branch.Bundle)), branch.Branch); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: %v", branch.Instance, err))
+		}
+	}
+
+	if err := pauseInstances(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// pauseInstances marks every restored instance as paused, since their worktrees and tmux sessions
+// don't exist yet.
+func pauseInstances() error {
+	state := config.LoadState()
+	storage, err := session.NewStorage(state)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	instances, err := storage.LoadInstanceData()
+	if err != nil {
+		return err
+	}
+	for i := range instances {
+		instances[i].Status = session.Paused
+	}
+	data, err := json.Marshal(instances)
+	if err != nil {
+		return fmt.Errorf("failed to marshal instances: %w", err)
+	}
+	return state.SaveInstances(data)
+}
+
+// extract unpacks the archive, writing config files to configDir, state files to stateDir and bundles to
+// bundleDir. The state file of backups of a config directory that held the state too goes to stateDir.
+func extract(archivePath, configDir, stateDir, bundleDir string) (*Manifest, error) {
+	in, err := openCompressed(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	var manifest *Manifest
+	tr := tar.NewReader(in)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup: %w", err)
+		}
+
+		var dest string
+		switch {
+		case header.Name == manifestName:
+			manifest = &Manifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("failed to parse manifest: %w", err)
+			}
+			if manifest.Version > manifestVersion {
+				return nil, fmt.Errorf("backup version %d is newer than this claude-squad supports", manifest.Version)
+			}
+			continue
+		case header.Name == configPrefix+config.StateFileName:
+			dest, err = safeJoin(stateDir, config.StateFileName)
+		case strings.HasPrefix(header.Name, configPrefix):
+			dest, err = safeJoin(configDir, strings.TrimPrefix(header.Name, configPrefix))
+		case strings.HasPrefix(header.Name, statePrefix):
+			dest, err = safeJoin(stateDir, strings.TrimPrefix(header.Name, statePrefix))
+		case strings.HasPrefix(header.Name, bundlePrefix):
+			dest, err = safeJoin(bundleDir, strings.TrimPrefix(header.Name, bundlePrefix))
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := writeFile(dest, tr, os.FileMode(header.Mode)); err != nil {
+			return nil, err
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("%s is not a claude-squad backup: no manifest", archivePath)
+	}
+	return manifest, nil
+}
+
+// addDir adds the regular files under dir to the archive, with names prefixed by prefix.
+func addDir(tw *tar.Writer, dir, prefix string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if skippedDirs[rel] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		// The mode is kept, so e.g. the secrets and diffs are only readable by their owner once restored.
+		return writeTarFile(tw, prefix+filepath.ToSlash(rel), data, info.Mode().Perm())
+	})
+}
+
+// safeJoin joins name to dir, rejecting names that would escape it such as "../../.bashrc".
+func safeJoin(dir, name string) (string, error) {
+	path := filepath.Join(dir, name)
+	if rel, err := filepath.Rel(dir, path); err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("invalid path in backup: %s", name)
+	}
+	return path, nil
+}
+
+func writeTarFile(tw *tar.Writer, name string, data []byte, mode os.FileMode) error {
+	header := &tar.Header{Name: name, Mode: int64(mode), Size: int64(len(data)), ModTime: time.Now()}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func writeFile(path string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	// An existing file keeps its mode when it's opened, so it's set like a new file's.
+	if err := f.Chmod(mode.Perm()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to set the mode of %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// isZstd returns true if path should be compressed with zstd rather than gzip.
+func isZstd(path string) bool {
+	return strings.HasSuffix(path, ".zst")
+}
+
+// createCompressed creates path, only readable by its owner since it holds the state, and returns a writer
+// that compresses into it.
+func createCompressed(path string) (io.WriteCloser, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	if !isZstd(path) {
+		return &closeBoth{WriteCloser: gzip.NewWriter(f), file: f}, nil
+	}
+
+	cmd := exec.Command("zstd", "-q", "-c")
+	cmd.Stdout = f
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to run zstd, install it or use a .tar.gz output: %w", err)
+	}
+	return &closeBoth{WriteCloser: stdin, file: f, cmd: cmd}, nil
+}
+
+// openCompressed opens path and returns a reader that decompresses it.
+func openCompressed(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	if !isZstd(path) {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		return &readCloseBoth{ReadCloser: gz, file: f}, nil
+	}
+
+	cmd := exec.Command("zstd", "-q", "-d", "-c")
+	cmd.Stdin = f
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to run zstd, install it to restore .zst backups: %w", err)
+	}
+	return &readCloseBoth{ReadCloser: stdout, file: f, cmd: cmd}, nil
+}
+
+// closeBoth closes the compressing writer, waits for the compressor if it's a command, then closes the file.
+type closeBoth struct {
+	io.WriteCloser
+	file *os.File
+	cmd  *exec.Cmd
+}
+
+func (c *closeBoth) Close() error {
+	err := c.WriteCloser.Close()
+	if c.cmd != nil {
+		if waitErr := c.cmd.Wait(); err == nil {
+			err = waitErr
+		}
+	}
+	if closeErr := c.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// readCloseBoth closes the decompressing reader, waits for the decompressor if it's a command, then
+// closes the file.
+type readCloseBoth struct {
+	io.ReadCloser
+	file *os.File
+	cmd  *exec.Cmd
+}
+
+func (r *readCloseBoth) Close() error {
+	err := r.ReadCloser.Close()
+	if r.cmd != nil {
+		_ = r.cmd.Wait()
+	}
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
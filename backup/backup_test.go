@@ -0,0 +1,324 @@
+package backup
+
+import (
+	"bytes"
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSafeJoin(t *testing.T) {
+	path, err := safeJoin("/home/user/.claude-squad", "state.json")
+	require.NoError(t, err)
+	require.Equal(t, "/home/user/.claude-squad/state.json", path)
+
+	path, err = safeJoin("/home/user/.claude-squad", "logs/a..b.log")
+	require.NoError(t, err)
+	require.Equal(t, "/home/user/.claude-squad/logs/a..b.log", path)
+
+	_, err = safeJoin("/home/user/.claude-squad", "../.bashrc")
+	require.Error(t, err)
+}
+
+func TestExportImport(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	newRepo := func(home string) string {
+		repo := filepath.Join(home, "src", "app")
+		require.NoError(t, os.MkdirAll(repo, 0755))
+		require.NoError(t, exec.Command("git", "init", "-q", repo).Run())
+		return repo
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	repo := newRepo(home)
+	state := config.LoadState()
+	require.NoError(t, state.AddRepository(config.RepositoryData{Path: repo, Name: "app"}))
+	require.NoError(t, state.SetSelectedRepository(repo))
+	instances, err := json.Marshal([]session.InstanceData{{
+		Title: "fix-login", Path: repo, Branch: "fix-login", Status: session.Ready, RepositoryPath: repo,
+		Worktree: session.GitWorktreeData{RepoPath: repo, WorktreePath: filepath.Join(home, ".claude-squad", "worktrees", "fix-login")},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, state.SaveInstances(instances))
+
+	archive := filepath.Join(t.TempDir(), "squad.tar.gz")
+	export, err := Export(archive)
+	require.NoError(t, err)
+	require.Equal(t, "~/src/app", export.Repositories[0].Path, "paths are relative to the home directory")
+
+	// On another machine, the paths resolve under its home directory.
+	home = t.TempDir()
+	t.Setenv("HOME", home)
+	repo = newRepo(home)
+	result, err := Import(archive)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Repositories)
+	require.Equal(t, 1, result.Instances)
+	require.Len(t, result.Warnings, 1, "the branch hasn't been fetched yet")
+
+	state = config.LoadState()
+	require.Equal(t, repo, state.GetSelectedRepository())
+	var imported []session.InstanceData
+	require.NoError(t, json.Unmarshal(state.GetInstances(), &imported))
+	require.Len(t, imported, 1)
+	require.Equal(t, session.Paused, imported[0].Status)
+	require.Equal(t, repo, imported[0].RepositoryPath)
+	require.Equal(t, filepath.Join(home, ".claude-squad", "worktrees", "fix-login"), imported[0].Worktree.WorktreePath)
+
+	// Importing again doesn't duplicate anything.
+	result, err = Import(archive)
+	require.NoError(t, err)
+	require.Zero(t, result.Repositories)
+	require.Zero(t, result.Instances)
+}
+
+func TestSnapshots(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	worktree := filepath.Join(home, "worktrees", "fix-login")
+	require.NoError(t, os.MkdirAll(worktree, 0755))
+	state := config.LoadState()
+	instances, err := json.Marshal([]session.InstanceData{
+		{Title: "fix-login", Status: session.Running, Worktree: session.GitWorktreeData{WorktreePath: worktree}},
+		{Title: "add-tests", Status: session.Ready, Worktree: session.GitWorktreeData{
+			WorktreePath: filepath.Join(home, "worktrees", "add-tests"), RepoPath: filepath.Join(home, "gone"),
+		}},
+	})
+	require.NoError(t, err)
+	require.NoError(t, state.SaveInstances(instances))
+
+	snapshot, err := CreateSnapshot("before cleaning up")
+	require.NoError(t, err)
+	require.Len(t, snapshot.Sessions, 2)
+	require.Equal(t, worktree, snapshot.Sessions[0].Worktree)
+
+	// The instances are killed by accident.
+	require.NoError(t, config.LoadState().SaveInstances(json.RawMessage(`[]`)))
+
+	result, err := RestoreSnapshot(snapshot.ID)
+	require.NoError(t, err)
+	require.Len(t, result.Warnings, 1, "the repository of add-tests is gone")
+	var restored []session.InstanceData
+	require.NoError(t, json.Unmarshal(config.LoadState().GetInstances(), &restored))
+	require.Len(t, restored, 2)
+	require.Equal(t, session.Stopped, restored[0].Status, "the worktree is still there")
+	require.Equal(t, session.Paused, restored[1].Status, "the worktree is gone")
+
+	// Restoring snapshots the state it replaces, so it can be undone.
+	snapshots, err := ListSnapshots()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	require.Equal(t, "before restoring "+snapshot.ID, snapshots[0].Note)
+	require.Empty(t, snapshots[0].Sessions)
+
+	_, err = RestoreSnapshot("missing")
+	require.Error(t, err)
+}
+
+func TestSync(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	remoteRepo := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", "-q", "--bare", remoteRepo).Run())
+	remote := &gitRemote{url: remoteRepo, branch: "main"}
+	// machine switches to a machine with its own home directory, state and clone of the remote, which has the
+	// app repository.
+	machine := func() string {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+		remote.dir = filepath.Join(home, "sync")
+		repo := filepath.Join(home, "src", "app")
+		require.NoError(t, os.MkdirAll(repo, 0755))
+		require.NoError(t, exec.Command("git", "init", "-q", repo).Run())
+		return repo
+	}
+	instance := func(title, repo string, status session.Status) session.InstanceData {
+		return session.InstanceData{Title: title, Branch: title, Status: status, RepositoryPath: repo,
+			Worktree: session.GitWorktreeData{RepoPath: repo}}
+	}
+	saveInstances := func(instances ...session.InstanceData) {
+		data, err := json.Marshal(instances)
+		require.NoError(t, err)
+		require.NoError(t, config.LoadState().SaveInstances(data))
+	}
+	loadInstances := func() map[string]session.Status {
+		var instances []session.InstanceData
+		require.NoError(t, json.Unmarshal(config.LoadState().GetInstances(), &instances))
+		statuses := make(map[string]session.Status)
+		for _, instance := range instances {
+			statuses[instance.Title] = instance.Status
+		}
+		return statuses
+	}
+
+	laptopRepo := machine()
+	laptopHome := os.Getenv("HOME")
+	require.NoError(t, config.LoadState().AddRepository(config.RepositoryData{Path: laptopRepo, Name: "app"}))
+	saveInstances(instance("fix-login", laptopRepo, session.Running), instance("add-tests", laptopRepo, session.Paused))
+	result, err := Pull(remote)
+	require.NoError(t, err)
+	require.Nil(t, result.Remote, "nothing was pushed yet")
+	result, err = Push(remote)
+	require.NoError(t, err)
+	require.True(t, result.Changed)
+	result, err = Push(remote)
+	require.NoError(t, err)
+	require.False(t, result.Changed, "nothing changed since the last push")
+
+	// The desktop's first pull adds to what it has.
+	desktopRepo := machine()
+	saveInstances(instance("refactor", desktopRepo, session.Paused))
+	result, err = Pull(remote)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Repositories)
+	require.Equal(t, 2, result.Instances)
+	require.Equal(t, desktopRepo, config.LoadState().GetRepositories()[0].Path)
+	require.Equal(t, map[string]session.Status{
+		"refactor": session.Paused, "fix-login": session.Paused, "add-tests": session.Paused,
+	}, loadInstances())
+	result, err = Push(remote)
+	require.NoError(t, err)
+	require.Empty(t, result.Warnings)
+
+	// Back on the laptop, the paused instances are replaced and the running one is kept.
+	t.Setenv("HOME", laptopHome)
+	remote.dir = filepath.Join(laptopHome, "sync")
+	saveInstances(instance("fix-login", laptopRepo, session.Running), instance("docs", laptopRepo, session.Paused))
+	result, err = Pull(remote)
+	require.NoError(t, err)
+	require.Contains(t, result.Warnings[0], "changes made here since the last sync were replaced",
+		"docs was added since the last sync")
+	require.Equal(t, map[string]session.Status{
+		"fix-login": session.Running, "add-tests": session.Paused, "refactor": session.Paused,
+	}, loadInstances())
+
+	// Pulling again finds nothing new.
+	result, err = Pull(remote)
+	require.NoError(t, err)
+	require.False(t, result.Changed)
+
+	// A push from a machine whose clock is behind is still pulled, since it's a new revision.
+	synced, _, err := readRemote(remote)
+	require.NoError(t, err)
+	synced.ExportedAt = synced.ExportedAt.Add(-time.Hour)
+	synced.Instances = synced.Instances[:1]
+	data, err := json.Marshal(synced)
+	require.NoError(t, err)
+	_, err = remote.Write(data)
+	require.NoError(t, err)
+	result, err = Pull(remote)
+	require.NoError(t, err)
+	require.True(t, result.Changed)
+}
+
+func TestSyncEncrypted(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32))
+
+	remoteRepo := t.TempDir()
+	require.NoError(t, exec.Command("git", "init", "-q", "--bare", remoteRepo).Run())
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(config.StateKeyEnv, key)
+	configDir, err := config.GetConfigDir()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(configDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, config.ConfigFileName), []byte(`{"encrypt_state": true}`), 0644))
+	data, err := json.Marshal([]session.InstanceData{{Title: "secret-plan", Status: session.Paused}})
+	require.NoError(t, err)
+	require.NoError(t, config.LoadState().SaveInstances(data))
+
+	remote := &gitRemote{url: remoteRepo, branch: "main", dir: filepath.Join(home, "sync")}
+	_, err = Push(remote)
+	require.NoError(t, err)
+	pushed, _, err := remote.Read()
+	require.NoError(t, err)
+	require.NotContains(t, string(pushed), "secret-plan", "the synced state is encrypted like the state")
+
+	synced, _, err := readRemote(remote)
+	require.NoError(t, err)
+	require.Equal(t, "secret-plan", synced.Instances[0].Title)
+
+	// Another machine needs the same key to pull it.
+	t.Setenv(config.StateKeyEnv, base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{8}, 32)))
+	_, _, err = readRemote(remote)
+	require.ErrorContains(t, err, config.StateKeyEnv)
+}
+
+func TestSyncCredentialsRequired(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	// The remote asks for credentials, which there's no helper for.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	dir := filepath.Join(home, "sync")
+	remote := &gitRemote{url: server.URL + "/state.git", branch: "main", dir: dir}
+	_, _, err := remote.Read()
+	var credErr *git.CredentialsRequiredError
+	require.ErrorAs(t, err, &credErr)
+	require.Contains(t, credErr.Prompt(), "could not read Username")
+
+	cmd := credErr.Command()
+	require.Equal(t, []string{"git", "fetch", "-q", "origin"}, cmd.Args)
+	require.Equal(t, dir, cmd.Dir)
+}
+
+func TestCreateRestoreKeepsModes(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	t.Setenv("HOME", t.TempDir())
+	configDir, err := config.GetConfigDir()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(configDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "secrets.json"), []byte(`{"jira": "token"}`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, config.ConfigFileName), []byte(`{}`), 0644))
+	require.NoError(t, config.LoadState().SaveInstances(json.RawMessage(`[]`)))
+
+	archive := filepath.Join(t.TempDir(), "backup.tar.gz")
+	_, err = Create(archive)
+	require.NoError(t, err)
+	info, err := os.Stat(archive)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm(), "the archive holds the secrets")
+
+	t.Setenv("HOME", t.TempDir())
+	configDir, err = config.GetConfigDir()
+	require.NoError(t, err)
+	_, err = Restore(archive, false)
+	require.NoError(t, err)
+	info, err = os.Stat(filepath.Join(configDir, "secrets.json"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	info, err = os.Stat(filepath.Join(configDir, config.ConfigFileName))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0644), info.Mode().Perm())
+}
@@ -0,0 +1,266 @@
+package backup
+
+import (
+	"archive/tar"
+	"claude-squad/config"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	exportName    = "export.json"
+	exportVersion = 1
+)
+
+// StateExport is the squad's session setup: its repositories, instances and selected repository. Unlike a
+// backup, it's meant to be imported on another machine, so paths under the home directory are written
+// relative to it, as in "~/src/app", and it holds no config or branches.
+type StateExport struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	// Machine is the hostname of the machine the state was exported from.
+	Machine            string                  `json:"machine,omitempty"`
+	Repositories       []config.RepositoryData `json:"repositories"`
+	SelectedRepository string                  `json:"selected_repository,omitempty"`
+	Instances          []session.InstanceData  `json:"instances"`
+}
+
+// ImportResult reports what an import added to the state.
+type ImportResult struct {
+	Export       *StateExport
+	Repositories int
+	Instances    int
+	// Warnings lists what wasn't imported and why, and the instances that can't be resumed yet.
+	Warnings []string
+}
+
+// Export writes the state's repositories and instances to an archive at outPath, compressed like a backup.
+func Export(outPath string) (*StateExport, error) {
+	export, err := exportState()
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal export: %w", err)
+	}
+
+	out, err := createCompressed(outPath)
+	if err != nil {
+		return nil, err
+	}
+	tw := tar.NewWriter(out)
+	err = writeTarFile(tw, exportName, data, 0600)
+	if err == nil {
+		err = tw.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(outPath)
+		return nil, fmt.Errorf("failed to write export: %w", err)
+	}
+	return export, nil
+}
+
+// Import adds the repositories and instances of an archive written by Export to the state. Repositories that
+// don't exist on this machine are skipped along with their instances, as are instances whose titles are
+// taken. Imported instances are paused; resuming them recreates their worktrees and sessions from their
+// branches, which have to be fetched or pushed to the repositories first.
+func Import(archivePath string) (*ImportResult, error) {
+	export, err := readExport(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := export.expandHome(); err != nil {
+		return nil, err
+	}
+
+	state := config.LoadState()
+	// The repositories and instances are saved at once by the Flush below.
+	state.SetWriteBehind(true)
+	storage, err := session.NewStorage(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	instances, err := storage.LoadInstanceData()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{Export: export}
+	repos := make(map[string]bool)
+	for _, repo := range state.GetRepositories() {
+		repos[repo.Path] = true
+	}
+	for _, repo := range export.Repositories {
+		if repos[repo.Path] {
+			continue
+		}
+		if _, err := os.Stat(repo.Path); err != nil || !git.IsGitRepo(repo.Path) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("repository %s not found, it wasn't imported", repo.Path))
+			continue
+		}
+		repo.InstanceCount = 0
+		repo.LastSelectedInstance = ""
+		if err := state.AddRepository(repo); err != nil {
+			return nil, err
+		}
+		repos[repo.Path] = true
+		result.Repositories++
+	}
+
+	titles := make(map[string]bool)
+	for _, instance := range instances {
+		titles[instance.Title] = true
+	}
+	for _, instance := range export.Instances {
+		switch {
+		case titles[instance.Title]:
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: an instance with this title already exists", instance.Title))
+			continue
+		case !repos[instance.RepositoryPath]:
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: its repository wasn't imported", instance.Title))
+			continue
+		case instance.Worktree.External:
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: adopted tmux sessions can't be imported", instance.Title))
+			continue
+		}
+		if !git.HasBranch(instance.RepositoryPath, instance.Branch) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf(
+				"%s: branch %s isn't in %s yet, fetch it before resuming the instance", instance.Title, instance.Branch, instance.RepositoryPath))
+		}
+		instance.Status = session.Paused
+		instances = append(instances, instance)
+		titles[instance.Title] = true
+		result.Instances++
+	}
+	data, err := json.Marshal(instances)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal instances: %w", err)
+	}
+	if err := state.SaveInstances(data); err != nil {
+		return nil, err
+	}
+	if state.GetSelectedRepository() == "" && repos[export.SelectedRepository] {
+		if err := state.SetSelectedRepository(export.SelectedRepository); err != nil {
+			return nil, err
+		}
+	}
+	if err := state.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to save state: %w", err)
+	}
+	return result, nil
+}
+
+// readExport reads the export from an archive written by Export.
+func readExport(archivePath string) (*StateExport, error) {
+	in, err := openCompressed(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	tr := tar.NewReader(in)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s is not a claude-squad export: no %s", archivePath, exportName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read export: %w", err)
+		}
+		if header.Name != exportName {
+			continue
+		}
+		return decodeExport(tr)
+	}
+}
+
+// exportState returns the state's repositories and instances, with the paths under the home directory made
+// relative to it.
+func exportState() (*StateExport, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	state := config.LoadState()
+	storage, err := session.NewStorage(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	instances, err := storage.LoadInstanceData()
+	if err != nil {
+		return nil, err
+	}
+	machine, _ := os.Hostname()
+
+	export := &StateExport{
+		Version:            exportVersion,
+		ExportedAt:         time.Now(),
+		Machine:            machine,
+		Repositories:       append([]config.RepositoryData(nil), state.GetRepositories()...),
+		SelectedRepository: state.GetSelectedRepository(),
+		Instances:          instances,
+	}
+	export.mapPaths(func(path string) string {
+		if rel, err := filepath.Rel(home, path); err == nil && rel != ".." && !strings.HasPrefix(rel, "../") {
+			return filepath.ToSlash(filepath.Join("~", rel))
+		}
+		return path
+	})
+	return export, nil
+}
+
+// decodeExport decodes an export written by exportState.
+func decodeExport(r io.Reader) (*StateExport, error) {
+	export := &StateExport{}
+	if err := json.NewDecoder(r).Decode(export); err != nil {
+		return nil, fmt.Errorf("failed to parse export: %w", err)
+	}
+	if export.Version > exportVersion {
+		return nil, fmt.Errorf("export version %d is newer than this claude-squad supports", export.Version)
+	}
+	return export, nil
+}
+
+// expandHome resolves the paths relative to the home directory, as written by exportState, under this
+// machine's home directory.
+func (e *StateExport) expandHome() error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("failed to get home directory: %w", err)
+	}
+	e.mapPaths(func(path string) string {
+		if rel, ok := strings.CutPrefix(path, "~/"); ok {
+			return filepath.Join(home, filepath.FromSlash(rel))
+		}
+		return path
+	})
+	return nil
+}
+
+// mapPaths replaces every path in the export with the result of fn.
+func (e *StateExport) mapPaths(fn func(string) string) {
+	for i := range e.Repositories {
+		e.Repositories[i].Path = fn(e.Repositories[i].Path)
+	}
+	if e.SelectedRepository != "" {
+		e.SelectedRepository = fn(e.SelectedRepository)
+	}
+	for i := range e.Instances {
+		instance := &e.Instances[i]
+		instance.Path = fn(instance.Path)
+		instance.RepositoryPath = fn(instance.RepositoryPath)
+		instance.Worktree.RepoPath = fn(instance.Worktree.RepoPath)
+		instance.Worktree.WorktreePath = fn(instance.Worktree.WorktreePath)
+	}
+}
@@ -0,0 +1,233 @@
+package backup
+
+import (
+	"bytes"
+	"claude-squad/config"
+	"claude-squad/network"
+	"claude-squad/session/git"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// syncFileName is the name of the synced state in a git remote.
+const syncFileName = "squad.json"
+
+// Remote is where the state synced between machines is kept. Each version of the synced state has a revision,
+// which changes whenever it's written: the git commit or the S3 object's ETag. Revisions tell whether another
+// machine pushed since the last sync, without relying on the machines' clocks agreeing.
+type Remote interface {
+	// Read returns the synced state and its revision, or nil if none was pushed yet.
+	Read() (data []byte, revision string, err error)
+	// Write replaces the synced state with data and returns its revision.
+	Write(data []byte) (revision string, err error)
+}
+
+// NewRemote returns the remote configured by cfg.
+func NewRemote(cfg config.SyncConfig) (Remote, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("no sync remote configured, set sync.url in the config")
+	}
+	if err := network.Check("sync"); err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(cfg.URL, "s3://") {
+		return &s3Remote{url: cfg.URL}, nil
+	}
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state directory: %w", err)
+	}
+	return &gitRemote{url: cfg.URL, branch: cfg.GetBranch(), dir: filepath.Join(stateDir, "sync")}, nil
+}
+
+// s3Remote keeps the synced state in an S3 object, copied with the aws command and its credentials.
+type s3Remote struct {
+	url string
+}
+
+// object returns the bucket and key of the S3 object.
+func (r *s3Remote) object() (bucket, key string, err error) {
+	bucket, key, _ = strings.Cut(strings.TrimPrefix(r.url, "s3://"), "/")
+	if bucket == "" || key == "" {
+		return "", "", fmt.Errorf("invalid S3 URL %s, expected s3://bucket/key", r.url)
+	}
+	return bucket, key, nil
+}
+
+// s3api runs an aws s3api command and returns the ETag it reports, which the object's content is sent to or
+// read from file.
+func (r *s3Remote) s3api(args ...string) (string, error) {
+	if _, err := exec.LookPath("aws"); err != nil {
+		return "", errors.New("the aws command is needed to sync with S3")
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("aws", append([]string{"s3api"}, append(args, "--output", "json")...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s (%w)", strings.TrimSpace(stderr.String()), err)
+	}
+	var response struct {
+		ETag string `json:"ETag"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return "", fmt.Errorf("failed to parse the aws output: %w", err)
+	}
+	return response.ETag, nil
+}
+
+func (r *s3Remote) Read() ([]byte, string, error) {
+	bucket, key, err := r.object()
+	if err != nil {
+		return nil, "", err
+	}
+	tmp, err := os.CreateTemp("", "claude-squad-sync-*")
+	if err != nil {
+		return nil, "", err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	// The object and its ETag are read at once, so they match even if another machine pushes meanwhile.
+	etag, err := r.s3api("get-object", "--bucket", bucket, "--key", key, tmp.Name())
+	if err != nil {
+		if strings.Contains(err.Error(), "NoSuchKey") {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("failed to download %s: %w", r.url, err)
+	}
+	data, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return nil, "", err
+	}
+	return data, etag, nil
+}
+
+func (r *s3Remote) Write(data []byte) (string, error) {
+	bucket, key, err := r.object()
+	if err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp("", "claude-squad-sync-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	etag, err := r.s3api("put-object", "--bucket", bucket, "--key", key, "--body", tmp.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to upload %s: %w", r.url, err)
+	}
+	return etag, nil
+}
+
+// gitRemote keeps the synced state in a file committed to a branch of a git repository, through a clone of it
+// in dir. The clone is reset to the remote branch before each read and write; the branch's history is the
+// history of the synced state.
+type gitRemote struct {
+	url    string
+	branch string
+	dir    string
+}
+
+func (r *gitRemote) Read() ([]byte, string, error) {
+	revision, err := r.update()
+	if err != nil || revision == "" {
+		return nil, "", err
+	}
+	data, err := os.ReadFile(filepath.Join(r.dir, syncFileName))
+	if os.IsNotExist(err) {
+		return nil, "", nil
+	}
+	return data, revision, err
+}
+
+func (r *gitRemote) Write(data []byte) (string, error) {
+	// Someone may push between the update and the push, in which case it's tried again on top of theirs.
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		var revision string
+		if revision, err = r.update(); err != nil {
+			return "", err
+		}
+		if err = os.WriteFile(filepath.Join(r.dir, syncFileName), data, 0644); err != nil {
+			return "", fmt.Errorf("failed to write %s: %w", syncFileName, err)
+		}
+		if _, err = r.git("add", syncFileName); err != nil {
+			return "", err
+		}
+		if _, err = r.git("diff", "--cached", "--quiet"); err == nil {
+			if revision != "" {
+				return revision, nil
+			}
+			// The branch isn't on the remote yet, so the commit that failed to push before is pushed now.
+		} else {
+			machine, _ := os.Hostname()
+			if _, err = r.git("-c", "user.name=claude-squad", "-c", "user.email=claude-squad@"+machine,
+				"commit", "-q", "-m", "Sync from "+machine); err != nil {
+				return "", err
+			}
+		}
+		if _, err = r.git("push", "-q", "origin", "HEAD:refs/heads/"+r.branch); err == nil {
+			return r.revision("HEAD")
+		}
+	}
+	return "", err
+}
+
+// update clones the repository the first time, and resets the clone to the remote branch, if it exists yet.
+// It returns the commit of the remote branch, or "" if it doesn't exist yet.
+func (r *gitRemote) update() (string, error) {
+	if _, err := os.Stat(filepath.Join(r.dir, ".git")); err != nil {
+		if err := os.MkdirAll(r.dir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create %s: %w", r.dir, err)
+		}
+		if _, err := r.git("init", "-q"); err != nil {
+			return "", err
+		}
+		if _, err := r.git("remote", "add", "origin", r.url); err != nil {
+			return "", err
+		}
+	}
+	if _, err := r.git("remote", "set-url", "origin", r.url); err != nil {
+		return "", err
+	}
+	if _, err := r.git("fetch", "-q", "origin"); err != nil {
+		return "", err
+	}
+	remoteBranch := "refs/remotes/origin/" + r.branch
+	revision, err := r.revision(remoteBranch)
+	if err != nil {
+		return "", nil
+	}
+	if _, err := r.git("checkout", "-q", "-f", "-B", r.branch, remoteBranch); err != nil {
+		return "", err
+	}
+	return revision, nil
+}
+
+// revision returns the commit ref points to.
+func (r *gitRemote) revision(ref string) (string, error) {
+	output, err := r.git("rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// git runs a git command in the clone. Fetches and pushes can't prompt for credentials; they return a
+// *git.CredentialsRequiredError instead.
+func (r *gitRemote) git(args ...string) (string, error) {
+	return git.RunWithoutPrompt(r.dir, args...)
+}
@@ -0,0 +1,215 @@
+package backup
+
+import (
+	"claude-squad/config"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"claude-squad/session/tmux"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	// snapshotManifestName is the name of a snapshot's manifest in its directory.
+	snapshotManifestName = "manifest.json"
+	// maxSnapshots is the number of snapshots that are kept. Older ones are deleted as new ones are taken.
+	maxSnapshots = 20
+)
+
+// Snapshot is a copy of the state, with the tmux sessions and worktrees of its instances, to roll back to.
+// Unlike a backup, it doesn't include the config or the instances' branches.
+type Snapshot struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	// Note says why the snapshot was taken.
+	Note string `json:"note,omitempty"`
+	// Sessions are the instances' tmux sessions and worktrees when the snapshot was taken.
+	Sessions []SnapshotSession `json:"sessions"`
+}
+
+// SnapshotSession is an instance's tmux session and worktree when a snapshot was taken.
+type SnapshotSession struct {
+	Instance    string `json:"instance"`
+	Status      string `json:"status"`
+	TmuxSession string `json:"tmux_session"`
+	Repository  string `json:"repository,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+	Worktree    string `json:"worktree,omitempty"`
+}
+
+func getSnapshotsDir() (string, error) {
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get state directory: %w", err)
+	}
+	return filepath.Join(stateDir, config.SnapshotsDirName), nil
+}
+
+// CreateSnapshot snapshots the state, noting why. The oldest snapshots are deleted to keep maxSnapshots.
+func CreateSnapshot(note string) (*Snapshot, error) {
+	dir, err := getSnapshotsDir()
+	if err != nil {
+		return nil, err
+	}
+	storage, err := session.NewStorage(config.LoadState())
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	instances, err := storage.LoadInstanceData()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	snapshot := &Snapshot{CreatedAt: now, Note: note}
+	for _, instance := range instances {
+		snapshot.Sessions = append(snapshot.Sessions, SnapshotSession{
+			Instance:    instance.Title,
+			Status:      instance.Status.String(),
+			TmuxSession: tmux.NewTmuxSession(instance.Title, instance.Program).Name(),
+			Repository:  instance.Worktree.RepoPath,
+			Branch:      instance.Worktree.BranchName,
+			Worktree:    instance.Worktree.WorktreePath,
+		})
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshots directory: %w", err)
+	}
+	// A snapshot taken in the same millisecond as another, like the one a restore takes, gets the next free ID.
+	var snapshotDir string
+	for at := now; ; at = at.Add(time.Millisecond) {
+		snapshot.ID = at.Format("20060102-150405.000")
+		snapshotDir = filepath.Join(dir, snapshot.ID)
+		err := os.Mkdir(snapshotDir, 0755)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+		}
+	}
+	if err := config.CopyStateFile(filepath.Join(snapshotDir, config.StateFileName)); err != nil {
+		os.RemoveAll(snapshotDir)
+		return nil, err
+	}
+	manifest, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		os.RemoveAll(snapshotDir)
+		return nil, fmt.Errorf("failed to marshal snapshot manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(snapshotDir, snapshotManifestName), manifest, 0644); err != nil {
+		os.RemoveAll(snapshotDir)
+		return nil, fmt.Errorf("failed to write snapshot manifest: %w", err)
+	}
+
+	snapshots, err := ListSnapshots()
+	if err != nil {
+		return nil, err
+	}
+	for _, old := range snapshots[min(len(snapshots), maxSnapshots):] {
+		if err := os.RemoveAll(filepath.Join(dir, old.ID)); err != nil {
+			return nil, fmt.Errorf("failed to delete snapshot %s: %w", old.ID, err)
+		}
+	}
+	return snapshot, nil
+}
+
+// ListSnapshots returns the snapshots, the most recent first.
+func ListSnapshots() ([]Snapshot, error) {
+	dir, err := getSnapshotsDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	var snapshots []Snapshot
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name(), snapshotManifestName))
+		if err != nil {
+			continue
+		}
+		var snapshot Snapshot
+		if err := json.Unmarshal(data, &snapshot); err != nil || snapshot.ID != entry.Name() {
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].ID > snapshots[j].ID })
+	return snapshots, nil
+}
+
+// RestoreSnapshot rolls the state back to the snapshot with id, after snapshotting the current state so the
+// rollback can be undone. Instances whose tmux sessions are gone are marked stopped if their worktrees are
+// still there, and paused otherwise, so they're resumed rather than restored. Instances whose branches are gone
+// too can't be resumed, which the warnings report.
+func RestoreSnapshot(id string) (*Result, error) {
+	dir, err := getSnapshotsDir()
+	if err != nil {
+		return nil, err
+	}
+	snapshotDir := filepath.Join(dir, filepath.Base(id))
+	data, err := os.ReadFile(filepath.Join(snapshotDir, snapshotManifestName))
+	if err != nil {
+		return nil, fmt.Errorf("snapshot %s not found, list them with `claude-squad backup list`", id)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", id, err)
+	}
+
+	if _, err := CreateSnapshot("before restoring " + snapshot.ID); err != nil {
+		return nil, fmt.Errorf("failed to snapshot the current state: %w", err)
+	}
+	if _, err := config.ReplaceStateFile(filepath.Join(snapshotDir, config.StateFileName)); err != nil {
+		return nil, err
+	}
+
+	result := &Result{Manifest: &Manifest{Version: manifestVersion, CreatedAt: snapshot.CreatedAt}}
+	state := config.LoadState()
+	storage, err := session.NewStorage(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	instances, err := storage.LoadInstanceData()
+	if err != nil {
+		return nil, err
+	}
+	for i, instance := range instances {
+		if instance.Status == session.Paused {
+			continue
+		}
+		if tmux.NewTmuxSession(instance.Title, instance.Program).DoesSessionExist() {
+			continue
+		}
+		if _, err := os.Stat(instance.Worktree.WorktreePath); err == nil && instance.Worktree.WorktreePath != "" {
+			instances[i].Status = session.Stopped
+			continue
+		}
+		instances[i].Status = session.Paused
+		if _, err := os.Stat(instance.Worktree.RepoPath); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: repository %s not found, so it can't be resumed",
+				instance.Title, instance.Worktree.RepoPath))
+		} else if !git.HasBranch(instance.Worktree.RepoPath, instance.Worktree.BranchName) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: branch %s is gone, so it can't be resumed",
+				instance.Title, instance.Worktree.BranchName))
+		}
+	}
+	data, err = json.Marshal(instances)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal instances: %w", err)
+	}
+	if err := state.SaveInstances(data); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,338 @@
+package backup
+
+import (
+	"bytes"
+	"claude-squad/config"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// syncRecordName is the name of the record of the last sync, in the state directory.
+const syncRecordName = "sync.json"
+
+// syncRecord is what was synced last, to tell which side changed since.
+type syncRecord struct {
+	// Revision is the remote's revision of the synced state last pulled or pushed.
+	Revision string `json:"revision,omitempty"`
+	// RemoteAt is when the synced state last pulled or pushed was exported.
+	RemoteAt time.Time `json:"remote_at"`
+	// Fingerprint identifies the repositories and instances as they were here after the last sync.
+	Fingerprint string `json:"fingerprint"`
+}
+
+// SyncResult reports what a pull or push did.
+type SyncResult struct {
+	// Remote is the synced state that was pulled or pushed, or nil if there was none to pull.
+	Remote *StateExport
+	// Changed is false if there was nothing new to pull or push.
+	Changed bool
+	// Repositories and Instances are the number of repositories and instances a pull added or removed.
+	Repositories int
+	Instances    int
+	// Warnings lists the changes that were overwritten, and what wasn't pulled and why.
+	Warnings []string
+}
+
+// pushedSince returns true if the synced state at revision was pushed by another machine since the last sync.
+func (r syncRecord) pushedSince(revision string, synced *StateExport) bool {
+	if r.Revision == "" && !r.RemoteAt.IsZero() {
+		// Records from before revisions were kept only have the time the synced state was exported.
+		return synced.ExportedAt.After(r.RemoteAt)
+	}
+	return revision != r.Revision
+}
+
+// Push replaces the synced state with this machine's repositories and instances, unless they're the same. The
+// last writer wins: if another machine pushed since the last sync, its changes are
+// overwritten, which the warnings report. The synced state is encrypted with the state key if the state is.
+func Push(remote Remote) (*SyncResult, error) {
+	record, err := loadSyncRecord()
+	if err != nil {
+		return nil, err
+	}
+	current, revision, err := readRemote(remote)
+	if err != nil {
+		return nil, err
+	}
+	export, err := exportState()
+	if err != nil {
+		return nil, err
+	}
+	result := &SyncResult{Remote: current}
+	if current != nil && record.pushedSince(revision, current) {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"overwrote the state pushed from %s at %s, which wasn't pulled here", current.Machine,
+			current.ExportedAt.Format(time.RFC822)))
+	} else if current != nil && export.fingerprint() == current.fingerprint() {
+		return result, nil
+	}
+	result.Changed = true
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal state: %w", err)
+	}
+	// The export holds the instances' prompts, paths and branches, which mustn't leave the machine in plaintext
+	// if the state is encrypted.
+	if data, err = config.SealStateData(data); err != nil {
+		return nil, fmt.Errorf("failed to encrypt the synced state: %w", err)
+	}
+	if revision, err = remote.Write(data); err != nil {
+		return nil, err
+	}
+	result.Remote = export
+	return result, saveSyncRecord(syncRecord{
+		Revision:    revision,
+		RemoteAt:    export.ExportedAt,
+		Fingerprint: export.fingerprint(),
+	})
+}
+
+// Pull replaces this machine's repositories and paused instances with the synced ones, if they were pushed
+// since the last sync. The last writer wins: changes made here since the last sync are overwritten, which the
+// warnings report. The first pull adds to the repositories and instances instead of replacing them.
+//
+// Instances that aren't paused here keep running and aren't replaced. Synced instances running on another
+// machine are pulled paused; resuming them recreates their worktrees from their branches. Repositories that
+// don't exist here are skipped along with their instances, and repositories of instances that aren't paused
+// here are kept.
+func Pull(remote Remote) (*SyncResult, error) {
+	record, err := loadSyncRecord()
+	if err != nil {
+		return nil, err
+	}
+	synced, revision, err := readRemote(remote)
+	if err != nil || synced == nil {
+		return &SyncResult{}, err
+	}
+	result := &SyncResult{Remote: synced}
+	if !record.pushedSince(revision, synced) {
+		return result, nil
+	}
+	result.Changed = true
+
+	local, err := exportState()
+	if err != nil {
+		return nil, err
+	}
+	firstSync := record.Fingerprint == ""
+	if !firstSync && local.fingerprint() != record.Fingerprint {
+		result.Warnings = append(result.Warnings, fmt.Sprintf(
+			"the changes made here since the last sync were replaced by the state pushed from %s at %s",
+			synced.Machine, synced.ExportedAt.Format(time.RFC822)))
+	}
+	if err := synced.expandHome(); err != nil {
+		return nil, err
+	}
+	if err := applySynced(synced, firstSync, result); err != nil {
+		return nil, err
+	}
+
+	if local, err = exportState(); err != nil {
+		return nil, err
+	}
+	return result, saveSyncRecord(syncRecord{
+		Revision:    revision,
+		RemoteAt:    synced.ExportedAt,
+		Fingerprint: local.fingerprint(),
+	})
+}
+
+// applySynced replaces the state's repositories and paused instances with the synced ones, as described by
+// Pull, or adds them if keep is set.
+func applySynced(synced *StateExport, keep bool, result *SyncResult) error {
+	state := config.LoadState()
+	// The repositories and instances are saved at once by the Flush below.
+	state.SetWriteBehind(true)
+	storage, err := session.NewStorage(state)
+	if err != nil {
+		return fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	local, err := storage.LoadInstanceData()
+	if err != nil {
+		return err
+	}
+
+	// Instances that aren't paused have worktrees and sessions here, so they're this machine's.
+	var instances []session.InstanceData
+	live := make(map[string]bool)
+	liveRepos := make(map[string]bool)
+	for _, instance := range local {
+		if instance.Status != session.Paused || keep {
+			instances = append(instances, instance)
+			live[instance.Title] = true
+			liveRepos[instance.RepositoryPath] = true
+		}
+	}
+
+	repos := make(map[string]bool)
+	for _, repo := range state.GetRepositories() {
+		repos[repo.Path] = true
+	}
+	syncedRepos := make(map[string]bool)
+	for _, repo := range synced.Repositories {
+		if _, err := os.Stat(repo.Path); err != nil || !git.IsGitRepo(repo.Path) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("repository %s not found, it wasn't pulled", repo.Path))
+			continue
+		}
+		syncedRepos[repo.Path] = true
+		if repos[repo.Path] {
+			continue
+		}
+		repo.InstanceCount = 0
+		repo.LastSelectedInstance = ""
+		if err := state.AddRepository(repo); err != nil {
+			return err
+		}
+		repos[repo.Path] = true
+		result.Repositories++
+	}
+	if !keep {
+		for path := range repos {
+			if syncedRepos[path] || liveRepos[path] {
+				continue
+			}
+			if err := state.RemoveRepository(path); err != nil {
+				return err
+			}
+			delete(repos, path)
+			result.Repositories++
+		}
+	}
+
+	previous := make(map[string]bool)
+	for _, instance := range local {
+		previous[instance.Title] = true
+	}
+	pulled := make(map[string]bool)
+	for _, instance := range synced.Instances {
+		switch {
+		case live[instance.Title]:
+			continue
+		case !repos[instance.RepositoryPath]:
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s: its repository wasn't pulled", instance.Title))
+			continue
+		case instance.Worktree.External:
+			continue
+		}
+		if !git.HasBranch(instance.RepositoryPath, instance.Branch) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf(
+				"%s: branch %s isn't in %s yet, fetch it before resuming the instance", instance.Title, instance.Branch,
+				instance.RepositoryPath))
+		}
+		instance.Status = session.Paused
+		instances = append(instances, instance)
+		pulled[instance.Title] = true
+		if !previous[instance.Title] {
+			result.Instances++
+		}
+	}
+	for title := range previous {
+		if !live[title] && !pulled[title] {
+			result.Instances++
+		}
+	}
+
+	data, err := json.Marshal(instances)
+	if err != nil {
+		return fmt.Errorf("failed to marshal instances: %w", err)
+	}
+	if err := state.SaveInstances(data); err != nil {
+		return err
+	}
+	if repos[synced.SelectedRepository] && (!keep || state.GetSelectedRepository() == "") {
+		if err := state.SetSelectedRepository(synced.SelectedRepository); err != nil {
+			return err
+		}
+	}
+	if err := state.Flush(); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
+
+// readRemote returns the synced state and its revision, or nil if none was pushed yet. It's decrypted if it was
+// pushed encrypted.
+func readRemote(remote Remote) (*StateExport, string, error) {
+	data, revision, err := remote.Read()
+	if err != nil || data == nil {
+		return nil, "", err
+	}
+	if data, err = config.OpenStateData(data); err != nil {
+		return nil, "", fmt.Errorf("the synced state is encrypted, set %s to the state key of the machine that "+
+			"pushed it: %w", config.StateKeyEnv, err)
+	}
+	export, err := decodeExport(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	return export, revision, nil
+}
+
+// fingerprint identifies the repositories and the instances' titles, statuses and branches, which are what
+// a sync changes.
+func (e *StateExport) fingerprint() string {
+	var lines []string
+	for _, repo := range e.Repositories {
+		lines = append(lines, "repository "+repo.Path)
+	}
+	for _, instance := range e.Instances {
+		lines = append(lines, fmt.Sprintf("instance %s %s %s", instance.Title, instance.Status, instance.Branch))
+	}
+	sort.Strings(lines)
+	hash := sha256.New()
+	for _, line := range lines {
+		fmt.Fprintln(hash, line)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func getSyncRecordPath() (string, error) {
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get state directory: %w", err)
+	}
+	return filepath.Join(stateDir, syncRecordName), nil
+}
+
+// loadSyncRecord returns the record of the last sync, which is empty if this machine never synced.
+func loadSyncRecord() (syncRecord, error) {
+	var record syncRecord
+	path, err := getSyncRecordPath()
+	if err != nil {
+		return record, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return record, nil
+	}
+	if err != nil {
+		return record, fmt.Errorf("failed to read sync record: %w", err)
+	}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return record, fmt.Errorf("failed to parse sync record: %w", err)
+	}
+	return record, nil
+}
+
+func saveSyncRecord(record syncRecord) error {
+	path, err := getSyncRecordPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal sync record: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
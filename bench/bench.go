@@ -0,0 +1,293 @@
+// Package bench measures how long claude-squad's core operations take, creating and removing worktrees,
+// computing diff stats, starting and killing instances, saving state and rendering the instance list, against a throwaway
+// repository and config directory. It's run by `claude-squad bench` as a yardstick for performance work.
+package bench
+
+import (
+	"claude-squad/config"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"claude-squad/ui"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+)
+
+const (
+	// saveRuns, frameRuns and diffRuns are how many times state is saved, the list rendered and diff stats
+	// computed.
+	saveRuns  = 20
+	frameRuns = 100
+	diffRuns  = 20
+	// repoFiles is the number of files in the throwaway repository.
+	repoFiles = 500
+	// listWidth and listHeight are the size the list is rendered at.
+	listWidth  = 60
+	listHeight = 50
+)
+
+// Options configures a benchmark run.
+type Options struct {
+	// Instances is the number of synthetic instances to create.
+	Instances int
+	// Program is run in the instances. It should be cheap to start, like "sh".
+	Program string
+}
+
+// Result is the timings of one operation.
+type Result struct {
+	Name    string
+	Samples []time.Duration
+}
+
+// Percentile returns the p-th percentile of the samples, for p between 0 and 100.
+func (r Result) Percentile(p int) time.Duration {
+	if len(r.Samples) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(r.Samples)
+	slices.Sort(sorted)
+	return sorted[(len(sorted)-1)*p/100]
+}
+
+// Mean returns the mean of the samples.
+func (r Result) Mean() time.Duration {
+	if len(r.Samples) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, sample := range r.Samples {
+		total += sample
+	}
+	return total / time.Duration(len(r.Samples))
+}
+
+// Report writes the results as a table.
+func Report(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "operation\truns\tmean\tp50\tp95\tmax")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\t%s\n", r.Name, len(r.Samples),
+			round(r.Mean()), round(r.Percentile(50)), round(r.Percentile(95)), round(r.Percentile(100)))
+	}
+	return tw.Flush()
+}
+
+func round(d time.Duration) time.Duration {
+	if d > time.Millisecond {
+		return d.Round(10 * time.Microsecond)
+	}
+	return d.Round(time.Microsecond)
+}
+
+// timed returns how long fn took, and its error.
+func timed(fn func() error) (time.Duration, error) {
+	start := time.Now()
+	err := fn()
+	return time.Since(start), err
+}
+
+// Run benchmarks the operations with a throwaway repository and config directory, and returns the
+// timings. The config directory is swapped in through HOME and CS_CONFIG_DIR, so the user's instances and state aren't
+// touched. logf reports progress.
+func Run(opts Options, logf func(format string, args ...any)) ([]Result, error) {
+	dir, err := os.MkdirTemp("", "claude-squad-bench-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	home := os.Getenv("HOME")
+	if err := os.Setenv("HOME", filepath.Join(dir, "home")); err != nil {
+		return nil, err
+	}
+	defer os.Setenv("HOME", home)
+	configDir := os.Getenv(config.ConfigDirEnv)
+	if err := os.Setenv(config.ConfigDirEnv, filepath.Join(dir, "home", ".claude-squad")); err != nil {
+		return nil, err
+	}
+	defer os.Setenv(config.ConfigDirEnv, configDir)
+
+	repo := filepath.Join(dir, "repo")
+	if err := newRepo(repo); err != nil {
+		return nil, err
+	}
+
+	// Titles are unique to the run, so their tmux sessions can't collide with real instances'.
+	prefix := fmt.Sprintf("bench%x", time.Now().UnixNano()&0xffffff)
+	worktreeCreate := Result{Name: "worktree create"}
+	worktreeRemove := Result{Name: "worktree remove"}
+	logf("creating and removing %d worktrees", opts.Instances)
+	for i := 0; i < opts.Instances; i++ {
+		var worktree *git.GitWorktree
+		elapsed, err := timed(func() error {
+			var err error
+			if worktree, _, err = git.NewGitWorktree(repo, fmt.Sprintf("%s-wt-%d", prefix, i), nil); err != nil {
+				return err
+			}
+			return worktree.Setup()
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create worktree: %w", err)
+		}
+		worktreeCreate.Samples = append(worktreeCreate.Samples, elapsed)
+		elapsed, err = timed(worktree.Cleanup)
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove worktree: %w", err)
+		}
+		worktreeRemove.Samples = append(worktreeRemove.Samples, elapsed)
+	}
+	results := []Result{worktreeCreate, worktreeRemove}
+
+	logf("computing diff stats")
+	diffs, err := benchDiff(repo, prefix)
+	if err != nil {
+		return nil, err
+	}
+	results = append(results, diffs...)
+
+	if _, err := exec.LookPath("tmux"); err != nil {
+		logf("tmux not found, skipping the instance benchmarks")
+		return results, nil
+	}
+
+	instanceStart := Result{Name: "instance start"}
+	logf("starting %d instances", opts.Instances)
+	var instances []*session.Instance
+	defer func() {
+		for _, instance := range instances {
+			_ = instance.Kill()
+		}
+	}()
+	for i := 0; i < opts.Instances; i++ {
+		instance, err := session.NewInstance(session.InstanceOptions{
+			Title:   fmt.Sprintf("%s-%d", prefix, i),
+			Path:    repo,
+			Program: opts.Program,
+		})
+		if err != nil {
+			return nil, err
+		}
+		elapsed, err := timed(func() error { return instance.Start(true) })
+		if err != nil {
+			return nil, fmt.Errorf("failed to start instance: %w", err)
+		}
+		instances = append(instances, instance)
+		instanceStart.Samples = append(instanceStart.Samples, elapsed)
+	}
+
+	logf("saving state and rendering the list")
+	storage, err := session.NewStorage(config.LoadState())
+	if err != nil {
+		return nil, err
+	}
+	stateSave := Result{Name: fmt.Sprintf("state save (%d instances)", len(instances))}
+	for i := 0; i < saveRuns; i++ {
+		elapsed, err := timed(func() error { return storage.SaveInstances(instances) })
+		if err != nil {
+			return nil, fmt.Errorf("failed to save state: %w", err)
+		}
+		stateSave.Samples = append(stateSave.Samples, elapsed)
+	}
+
+	s := spinner.New()
+	list := ui.NewList(&s, false)
+	list.SetSize(listWidth, listHeight)
+	for _, instance := range instances {
+		list.AddInstance(instance)()
+	}
+	frame := Result{Name: fmt.Sprintf("list frame (%d instances)", len(instances))}
+	for i := 0; i < frameRuns; i++ {
+		elapsed, _ := timed(func() error {
+			_ = list.String()
+			return nil
+		})
+		frame.Samples = append(frame.Samples, elapsed)
+	}
+
+	instanceKill := Result{Name: "instance kill"}
+	logf("killing %d instances", len(instances))
+	for len(instances) > 0 {
+		instance := instances[len(instances)-1]
+		instances = instances[:len(instances)-1]
+		elapsed, err := timed(instance.Kill)
+		if err != nil {
+			return nil, fmt.Errorf("failed to kill instance: %w", err)
+		}
+		instanceKill.Samples = append(instanceKill.Samples, elapsed)
+	}
+
+	return append(results, instanceStart, instanceKill, stateSave, frame), nil
+}
+
+// benchDiff times computing the diff stats of a worktree after a file changes, when git has to be run, and
+// again while nothing has changed, when the last diff is reused.
+func benchDiff(repo, prefix string) ([]Result, error) {
+	worktree, _, err := git.NewGitWorktree(repo, prefix+"-diff", nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := worktree.Setup(); err != nil {
+		return nil, fmt.Errorf("failed to create worktree: %w", err)
+	}
+	defer worktree.Cleanup()
+
+	changed := Result{Name: "diff stats (changed)"}
+	unchanged := Result{Name: "diff stats (unchanged)"}
+	file := filepath.Join(worktree.GetWorktreePath(), "README.md")
+	for i := 0; i < diffRuns; i++ {
+		if err := os.WriteFile(file, []byte(fmt.Sprintf("# bench %d\n", i)), 0644); err != nil {
+			return nil, err
+		}
+		elapsed, err := timed(func() error { return worktree.Diff().Error })
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute diff stats: %w", err)
+		}
+		changed.Samples = append(changed.Samples, elapsed)
+		elapsed, err = timed(func() error { return worktree.Diff().Error })
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute diff stats: %w", err)
+		}
+		unchanged.Samples = append(unchanged.Samples, elapsed)
+	}
+	return []Result{changed, unchanged}, nil
+}
+
+// newRepo creates a repository with a commit at path.
+func newRepo(path string) error {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(path, "README.md"), []byte("# bench\n"), 0644); err != nil {
+		return err
+	}
+	for i := 0; i < repoFiles; i++ {
+		dir := filepath.Join(path, "src", fmt.Sprintf("pkg%d", i%20))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		content := fmt.Sprintf("package pkg%d\n\nconst File%d = %d\n", i%20, i, i)
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%d.go", i)), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"add", "."},
+		{"-c", "user.name=bench", "-c", "user.email=bench@example.com", "commit", "-q", "-m", "Initial commit"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = path
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("git %s failed: %w: %s", args[0], err, output)
+		}
+	}
+	return nil
+}
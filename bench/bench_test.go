@@ -0,0 +1,32 @@
+package bench
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResult(t *testing.T) {
+	r := Result{Name: "state save", Samples: []time.Duration{
+		4 * time.Millisecond, time.Millisecond, 3 * time.Millisecond, 2 * time.Millisecond, 10 * time.Millisecond,
+	}}
+	require.Equal(t, 4*time.Millisecond, r.Mean())
+	require.Equal(t, time.Millisecond, r.Percentile(0))
+	require.Equal(t, 3*time.Millisecond, r.Percentile(50))
+	require.Equal(t, 10*time.Millisecond, r.Percentile(100))
+	require.Equal(t, time.Duration(0), Result{}.Mean())
+	require.Equal(t, time.Duration(0), Result{}.Percentile(50))
+}
+
+func TestReport(t *testing.T) {
+	var b strings.Builder
+	require.NoError(t, Report(&b, []Result{
+		{Name: "worktree create", Samples: []time.Duration{15*time.Millisecond + 123*time.Microsecond}},
+		{Name: "list frame", Samples: []time.Duration{191234 * time.Nanosecond}},
+	}))
+	require.Equal(t, "operation        runs  mean     p50      p95      max\n"+
+		"worktree create  1     15.12ms  15.12ms  15.12ms  15.12ms\n"+
+		"list frame       1     191µs    191µs    191µs    191µs\n", b.String())
+}
@@ -0,0 +1,117 @@
+// Package codeowners reads a repository's CODEOWNERS file to find who owns its paths.
+package codeowners
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// locations are where CODEOWNERS is looked for, relative to the repository root, in the order GitHub
+// uses.
+var locations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Rule assigns owners to the paths matching a pattern.
+type Rule struct {
+	Pattern string
+	Owners  []string
+}
+
+// Owners is a parsed CODEOWNERS file.
+type Owners struct {
+	Rules []Rule
+}
+
+// Load reads the CODEOWNERS file of the repository at repoPath. It returns nil if the repository has none.
+func Load(repoPath string) (*Owners, error) {
+	for _, location := range locations {
+		data, err := os.ReadFile(filepath.Join(repoPath, location))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return Parse(string(data)), nil
+	}
+	return nil, nil
+}
+
+// Parse parses the contents of a CODEOWNERS file.
+func Parse(content string) *Owners {
+	owners := &Owners{}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		owners.Rules = append(owners.Rules, Rule{Pattern: fields[0], Owners: fields[1:]})
+	}
+	return owners
+}
+
+// Of returns the owners of file, a slash-separated path relative to the repository root. As in GitHub,
+// the last matching rule wins, and a matching rule without owners means the file has none.
+func (o *Owners) Of(file string) []string {
+	if o == nil {
+		return nil
+	}
+	for i := len(o.Rules) - 1; i >= 0; i-- {
+		if match(o.Rules[i].Pattern, file) {
+			return o.Rules[i].Owners
+		}
+	}
+	return nil
+}
+
+// match reports whether a CODEOWNERS pattern, which uses gitignore syntax, matches file.
+func match(pattern, file string) bool {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	// Patterns containing a slash other than at the end are relative to the root; others match at
+	// any depth.
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	fileParts := strings.Split(file, "/")
+	patternParts := strings.Split(pattern, "/")
+	if !anchored {
+		patternParts = append([]string{"**"}, patternParts...)
+	}
+	// A pattern matching a directory matches everything in it.
+	for end := len(fileParts); end >= 1; end-- {
+		if dirOnly && end == len(fileParts) {
+			continue
+		}
+		if matchParts(patternParts, fileParts[:end]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchParts matches path segments against pattern segments, where "**" matches any number of segments.
+func matchParts(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchParts(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchParts(pattern[1:], parts[1:])
+}
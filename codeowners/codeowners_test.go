@@ -0,0 +1,34 @@
+package codeowners
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwners(t *testing.T) {
+	owners := Parse(`# Default owners
+*           @org/everyone
+*.go        @org/go-team
+/docs/      @org/docs
+apps/       @org/apps
+/web/**/test @org/qa
+/vendor/     # no owners
+`)
+
+	for file, want := range map[string][]string{
+		"README.md":             {"@org/everyone"},
+		"cmd/main.go":           {"@org/go-team"},
+		"docs/guide/intro.md":   {"@org/docs"},
+		"src/docs/notes.md":     {"@org/everyone"},
+		"apps/api/server.py":    {"@org/apps"},
+		"lib/apps/helper.py":    {"@org/apps"},
+		"web/a/b/test/x.js":     {"@org/qa"},
+		"vendor/lib/library.go": {},
+	} {
+		require.Equal(t, want, owners.Of(file), file)
+	}
+
+	var none *Owners
+	require.Nil(t, none.Of("main.go"))
+}
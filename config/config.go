@@ -4,27 +4,26 @@ import (
 	"claude-squad/log"
 	"encoding/json"
 	"fmt"
+	"net/mail"
 	"os"
 	"os/exec"
 	"os/user"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 )
 
 const (
 	ConfigFileName = "config.json"
 	defaultProgram = "claude"
-)
+	defaultRemote  = "origin"
 
-// GetConfigDir returns the path to the application's configuration directory
-func GetConfigDir() (string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get config home directory: %w", err)
-	}
-	return filepath.Join(homeDir, ".claude-squad"), nil
-}
+	defaultNudgePrompt = "You seem to have stopped making progress. Please continue with the task."
+	// defaultSummaryPrompt is sent to instances that reach Ready when AutoSummary is enabled.
+	defaultSummaryPrompt = "Summarize what you changed and anything left TODO."
+)
 
 // Config represents the application configuration
 type Config struct {
@@ -36,6 +35,844 @@ type Config struct {
 	DaemonPollInterval int `json:"daemon_poll_interval"`
 	// BranchPrefix is the prefix used for git branches created by the application.
 	BranchPrefix string `json:"branch_prefix"`
+	// CommitTrailers are appended to every commit claude-squad creates on behalf of an agent.
+	CommitTrailers []CommitTrailer `json:"commit_trailers,omitempty"`
+	// Repos holds per-repository settings keyed by the absolute repository path.
+	Repos map[string]RepoConfig `json:"repos,omitempty"`
+	// StallTimeout is the number of seconds a working instance may go without producing output before
+	// it's marked as stalled. 0 disables the watchdog.
+	StallTimeout int `json:"stall_timeout"`
+	// NudgePrompt is sent to a stalled instance when the user chooses to nudge it.
+	NudgePrompt string `json:"nudge_prompt,omitempty"`
+	// AbsoluteTimes shows timestamps as local times instead of relative to now (e.g. "12m ago").
+	AbsoluteTimes bool `json:"absolute_times,omitempty"`
+	// ListColumns are the columns shown under each instance: "created", "updated", "uptime", "activity" and
+	// "health". Defaults to created, updated and health.
+	ListColumns []string `json:"list_columns,omitempty"`
+	// TerminalTitle sets the terminal's window title to a summary of the instances, like
+	// "cs: 2 ready / 5 running". The previous title is restored on exit.
+	TerminalTitle bool `json:"terminal_title"`
+	// LazyRestore defers reattaching to the tmux sessions of stored instances until each is first selected,
+	// which speeds up launching with many dormant instances.
+	LazyRestore bool `json:"lazy_restore,omitempty"`
+	// Accessible renders the UI without colors, box-drawing characters or spinners, with statuses spelled
+	// out, for screen readers and logging. It's also enabled by the --accessible flag and by NO_COLOR.
+	Accessible bool `json:"accessible,omitempty"`
+	// TmuxOptions are tmux options applied to the sessions claude-squad creates, e.g. {"history-limit":
+	// "50000", "status": "off"}, for when the global tmux.conf doesn't suit agent sessions. Server options,
+	// like default-terminal, apply to the whole tmux server.
+	TmuxOptions map[string]string `json:"tmux_options,omitempty"`
+	// DetachKey is the control key that returns from an attached session to claude-squad, e.g. "ctrl-]".
+	// Defaults to ctrl-q.
+	DetachKey string `json:"detach_key,omitempty"`
+	// HideAttachHelp hides the status line that attached sessions show with a reminder of the detach key.
+	HideAttachHelp bool `json:"hide_attach_help,omitempty"`
+	// HideWeeklyReview doesn't show the weekly review of open instances, stale worktrees and disk usage on
+	// the first launch of each week.
+	HideWeeklyReview bool `json:"hide_weekly_review,omitempty"`
+	// Keymap remaps the keys of the TUI, keyed by their names, e.g. {"up": ["up", "e"], "down": ["down", "n"]}
+	// for Colemak users. Keys that aren't listed keep their defaults; the help screen shows the effective ones.
+	Keymap map[string][]string `json:"keymap,omitempty"`
+	// WindowSizes override the size of the tmux windows of instances running a program, keyed by the
+	// program's command name, e.g. {"aider": {"width": 200}}, for agents that need wider terminals than
+	// the preview. Zero fields fall back to the preview's size.
+	WindowSizes map[string]WindowSize `json:"window_sizes,omitempty"`
+	// Hooks map lifecycle events to shell commands, e.g. {"status_ready": ["tmux display-popup ..."]}.
+	// The events are "instance_created", "status_ready", "instance_killed" and "merge_completed". Each
+	// command is run with sh and receives the event as a JSON object on stdin.
+	Hooks map[string][]string `json:"hooks,omitempty"`
+	// Alerts configures the audible alerts when instances change state.
+	Alerts AlertConfig `json:"alerts,omitempty"`
+	// Budget is the budget new instances start with. Each instance's budget can be changed from the list.
+	Budget BudgetConfig `json:"budget,omitempty"`
+	// IdleShutdown pauses the whole squad once it's been idle for long, while the daemon runs it.
+	IdleShutdown IdleShutdownConfig `json:"idle_shutdown,omitempty"`
+	// Discovery scans directories for git repositories, which the repository picker offers.
+	Discovery DiscoveryConfig `json:"discovery,omitempty"`
+	// AutoTitle renames instances with placeholder titles like "test2", and their branches, once their first
+	// prompt completes. It's off by default.
+	AutoTitle bool `json:"auto_title"`
+	// AutoSummary asks instances to summarize their changes each time they finish working on a prompt.
+	AutoSummary bool `json:"auto_summary,omitempty"`
+	// SummaryPrompt is the prompt sent to ask for the summary when AutoSummary is enabled.
+	SummaryPrompt string `json:"summary_prompt,omitempty"`
+	// Instructions are written into the agent instructions file (e.g. CLAUDE.md) of every new worktree,
+	// so each instance starts with the team's conventions.
+	Instructions string `json:"instructions,omitempty"`
+	// InstructionsFile overrides the file the instructions are written to. By default it's CLAUDE.md for
+	// claude, GEMINI.md for gemini and AGENTS.md for other programs.
+	InstructionsFile string `json:"instructions_file,omitempty"`
+	// Sandbox runs new instances against a copy-on-write view of their worktree, so everything they write,
+	// including ignored files like caches and local databases, is discarded wholesale when they're
+	// killed. Requires fuse-overlayfs (or root) on Linux; on macOS the worktree is cloned on APFS.
+	Sandbox bool `json:"sandbox,omitempty"`
+	// MCPServers are the MCP servers made available to every instance, keyed by server name.
+	MCPServers map[string]MCPServer `json:"mcp_servers,omitempty"`
+	// Redaction configures the masking of secrets in previews and persisted agent output.
+	Redaction RedactionConfig `json:"redaction,omitempty"`
+	// EncryptState encrypts the state file, which holds the instances' prompts, plans and paths, with AES-256-GCM.
+	// The key is read from CLAUDE_SQUAD_STATE_KEY or else kept in the keychain, where one is generated on the
+	// first save. The state's backups and snapshots are encrypted too, including those kept before it's enabled.
+	EncryptState bool `json:"encrypt_state,omitempty"`
+	// SensitivePaths are glob patterns of files whose changes are flagged in the diff view and instance
+	// list, e.g. "migrations/" or ".github/workflows/". A pattern ending in "/" matches a directory
+	// anywhere in the repository.
+	SensitivePaths []string `json:"sensitive_paths,omitempty"`
+	// Blame shows who last changed the lines an instance removes or modifies in the diff view.
+	Blame BlameConfig `json:"blame,omitempty"`
+	// DiffStorage configures how the instances' diffs are stored between runs.
+	DiffStorage DiffStorageConfig `json:"diff_storage,omitempty"`
+	// SafetyScan configures the scan of an instance's changes before they're pushed.
+	SafetyScan SafetyScanConfig `json:"safety_scan,omitempty"`
+	// Dependencies configures the checks on dependencies an instance adds before its changes are pushed.
+	Dependencies DependencyGuardConfig `json:"dependencies,omitempty"`
+	// ArchiveDir is where frozen instances are archived. Defaults to "archives" in the state directory.
+	ArchiveDir string `json:"archive_dir,omitempty"`
+	// Retention configures the automatic archival of merged instances and deletion of old archives.
+	Retention RetentionConfig `json:"retention,omitempty"`
+	// Tracing exports OpenTelemetry spans of slow operations, like creating instances and saving state.
+	Tracing TracingConfig `json:"tracing,omitempty"`
+	// Digest configures how `claude-squad digest --send` delivers the digest of the squad's activity.
+	Digest DigestConfig `json:"digest,omitempty"`
+	// Refresh configures how often the UI redraws and polls the instances, and the low-power mode that slows
+	// both down on battery.
+	Refresh RefreshConfig `json:"refresh,omitempty"`
+	// Health configures the periodic health checks of the instances, shown in the list's "health" column.
+	Health HealthConfig `json:"health,omitempty"`
+	// Sync configures where `claude-squad sync` keeps the repositories and instances shared between machines.
+	Sync SyncConfig `json:"sync,omitempty"`
+	// Network configures the proxies and certificate authorities used to reach issue trackers, deps.dev, the
+	// tracing endpoint and the digest's SMTP server.
+	Network NetworkConfig `json:"network,omitempty"`
+	// AirGapped disables every feature that reaches a network service: pushing and pull requests, issue
+	// trackers, license lookups, tracing, sync and the digest's email. The list shows an indicator while it's on.
+	AirGapped bool `json:"air_gapped,omitempty"`
+}
+
+// Low-power modes.
+const (
+	// LowPowerAuto slows refreshes down while the machine runs on battery.
+	LowPowerAuto = "auto"
+	// LowPowerAlways always slows refreshes down.
+	LowPowerAlways = "always"
+	// LowPowerNever never slows refreshes down.
+	LowPowerNever = "never"
+)
+
+// RefreshConfig configures refresh intervals, in milliseconds. Zero fields use the defaults.
+type RefreshConfig struct {
+	// Spinner is the interval between frames of the running instances' spinner. Defaults to 83, 12 frames a
+	// second.
+	Spinner int `json:"spinner,omitempty"`
+	// Preview is how often the preview is refreshed. Defaults to 100.
+	Preview int `json:"preview,omitempty"`
+	// Poll is how often the instances are polled for their status and diff stats. Defaults to 500. The
+	// daemon polls every daemon_poll_interval instead.
+	Poll int `json:"poll,omitempty"`
+	// LowPower is when refreshes, and the daemon's polls, are slowed down to save battery: "auto", the
+	// default, while on battery, "always" or "never".
+	LowPower string `json:"low_power,omitempty"`
+	// LowPowerFactor is how many times longer the intervals are in low-power mode. Defaults to 4.
+	LowPowerFactor int `json:"low_power_factor,omitempty"`
+}
+
+const (
+	defaultSpinnerInterval = 83
+	defaultPreviewInterval = 100
+	defaultPollInterval    = 500
+	defaultLowPowerFactor  = 4
+)
+
+func orDefault(ms, fallback int) time.Duration {
+	if ms <= 0 {
+		ms = fallback
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// GetSpinner returns the interval between spinner frames.
+func (c RefreshConfig) GetSpinner() time.Duration {
+	return orDefault(c.Spinner, defaultSpinnerInterval)
+}
+
+// GetPreview returns how often the preview is refreshed.
+func (c RefreshConfig) GetPreview() time.Duration {
+	return orDefault(c.Preview, defaultPreviewInterval)
+}
+
+// GetPoll returns how often the instances are polled.
+func (c RefreshConfig) GetPoll() time.Duration {
+	return orDefault(c.Poll, defaultPollInterval)
+}
+
+// GetLowPower returns the low-power mode.
+func (c RefreshConfig) GetLowPower() string {
+	if c.LowPower == "" {
+		return LowPowerAuto
+	}
+	return c.LowPower
+}
+
+// GetLowPowerFactor returns how many times longer the intervals are in low-power mode.
+func (c RefreshConfig) GetLowPowerFactor() int {
+	if c.LowPowerFactor <= 0 {
+		return defaultLowPowerFactor
+	}
+	return c.LowPowerFactor
+}
+
+// TracingConfig configures the export of OpenTelemetry traces.
+type TracingConfig struct {
+	// Endpoint is the base URL of an OTLP/HTTP endpoint, e.g. "http://localhost:4318". Spans are posted to
+	// its /v1/traces. Defaults to OTEL_EXPORTER_OTLP_ENDPOINT; tracing is off if neither is set.
+	Endpoint string `json:"endpoint,omitempty"`
+	// Headers are added to export requests, e.g. for authentication.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// DigestConfig configures the delivery of activity digests. If Command is set it's used, otherwise the
+// digest is emailed with SMTP.
+type DigestConfig struct {
+	// Command is run with sh and receives the digest on stdin, with CS_SUBJECT set to its subject, e.g.
+	// `mail -s "$CS_SUBJECT" lead@example.com`.
+	Command string `json:"command,omitempty"`
+	// SMTP configures the email the digest is sent as.
+	SMTP SMTPConfig `json:"smtp,omitempty"`
+}
+
+// SMTPConfig configures an SMTP server to send email with.
+type SMTPConfig struct {
+	// Host is the server's host name.
+	Host string `json:"host,omitempty"`
+	// Port is the server's port. Defaults to 587.
+	Port int `json:"port,omitempty"`
+	// User is the name to authenticate as. No authentication is attempted if it's empty.
+	User string `json:"user,omitempty"`
+	// PasswordSecret is the name of the secret holding the password. Defaults to "smtp".
+	PasswordSecret string `json:"password_secret,omitempty"`
+	// From is the sender's address.
+	From string `json:"from,omitempty"`
+	// To are the recipients' addresses.
+	To []string `json:"to,omitempty"`
+}
+
+// GetPort returns the SMTP server's port.
+func (s SMTPConfig) GetPort() int {
+	if s.Port == 0 {
+		return 587
+	}
+	return s.Port
+}
+
+// GetPasswordSecret returns the name of the secret holding the SMTP password.
+func (s SMTPConfig) GetPasswordSecret() string {
+	if s.PasswordSecret == "" {
+		return "smtp"
+	}
+	return s.PasswordSecret
+}
+
+// Alert events.
+const (
+	// AlertReady is when an instance finishes working and is ready for input.
+	AlertReady = "ready"
+	// AlertNeedsInput is when an instance asks for permission and isn't in auto-yes mode.
+	AlertNeedsInput = "needs_input"
+	// AlertError is when an instance stops producing output and is marked as stalled.
+	AlertError = "error"
+	// AlertOverBudget is when an instance runs out of its budget and is interrupted.
+	AlertOverBudget = "over_budget"
+	// AlertIdleShutdown is when the daemon pauses every instance because none was active for long.
+	AlertIdleShutdown = "idle_shutdown"
+)
+
+// AlertConfig configures the audible alerts when instances change state, for when claude-squad isn't in
+// view. Alerts can be muted per instance.
+type AlertConfig struct {
+	// Events are the state changes that trigger an alert: "ready", "needs_input", "error", "over_budget" and
+	// "idle_shutdown". No alerts are played by default.
+	Events []string `json:"events,omitempty"`
+	// Command is run with sh instead of ringing the terminal bell, e.g. "afplay /System/Library/Sounds/Glass.aiff".
+	// CS_INSTANCE and CS_EVENT are set to the instance's title and the event. The daemon's alerts also set
+	// CS_MESSAGE to what happened.
+	Command string `json:"command,omitempty"`
+}
+
+// WindowSize is the size of an instance's tmux window.
+type WindowSize struct {
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+}
+
+// GetWindowSize returns the window size override for program, which is zero if there is none.
+func (c *Config) GetWindowSize(program string) WindowSize {
+	fields := strings.Fields(program)
+	if len(fields) == 0 {
+		return WindowSize{}
+	}
+	return c.WindowSizes[filepath.Base(fields[0])]
+}
+
+// Enabled returns true if alerts are played for event.
+func (c AlertConfig) Enabled(event string) bool {
+	return slices.Contains(c.Events, event)
+}
+
+// BudgetConfig limits how long instances run and how much their agents spend. Once an instance is over its
+// budget, it's interrupted, its prompts aren't accepted automatically anymore, and the user is alerted.
+type BudgetConfig struct {
+	// MaxRuntimeMinutes is how long, in minutes, an instance may run after it's started or resumed. 0 is no
+	// limit.
+	MaxRuntimeMinutes int `json:"max_runtime_minutes,omitempty"`
+	// MaxCost is how many dollars an instance's agent may spend, going by the costs it prints, like aider's
+	// and Claude Code's. 0 is no limit.
+	MaxCost float64 `json:"max_cost,omitempty"`
+}
+
+// IdleShutdownConfig configures pausing every instance once none produced output and the user didn't use any
+// for a while, so agents don't hold resources overnight. It's enforced by the daemon, which runs the
+// instances while claude-squad isn't open.
+type IdleShutdownConfig struct {
+	// AfterHours is how long, in hours, the squad must be idle before it's paused. 0 disables idle shutdown.
+	AfterHours float64 `json:"after_hours,omitempty"`
+	// ExitDaemon stops the daemon too once the squad is paused.
+	ExitDaemon bool `json:"exit_daemon,omitempty"`
+}
+
+// GetTimeout returns how long the squad must be idle before it's paused, or 0 if idle shutdown is disabled.
+func (c IdleShutdownConfig) GetTimeout() time.Duration {
+	return time.Duration(c.AfterHours * float64(time.Hour))
+}
+
+// DiscoveryConfig configures the directories scanned for git repositories, e.g. ~/src, so they needn't be
+// added one at a time with the directory picker. They're scanned when claude-squad starts, and by
+// "claude-squad scan".
+type DiscoveryConfig struct {
+	// Roots are the directories scanned. A leading ~/ is the home directory.
+	Roots []string `json:"roots,omitempty"`
+	// Depth is how many levels of directories below each root are scanned. Defaults to 3.
+	Depth int `json:"depth,omitempty"`
+}
+
+// GetDepth returns how many levels of directories below each root are scanned.
+func (c DiscoveryConfig) GetDepth() int {
+	if c.Depth <= 0 {
+		return 3
+	}
+	return c.Depth
+}
+
+// GetRoots returns the directories scanned, with ~/ expanded to the home directory.
+func (c DiscoveryConfig) GetRoots() ([]string, error) {
+	roots := make([]string, 0, len(c.Roots))
+	for _, root := range c.Roots {
+		if rel, ok := strings.CutPrefix(root, "~/"); ok || root == "~" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get home directory: %w", err)
+			}
+			root = filepath.Join(home, rel)
+		}
+		roots = append(roots, root)
+	}
+	return roots, nil
+}
+
+// RetentionConfig configures the retention policy the daemon enforces. Run "claude-squad retention" to
+// preview what it affects.
+type RetentionConfig struct {
+	// Enabled turns the policy on.
+	Enabled bool `json:"enabled,omitempty"`
+	// ArchiveMergedAfterDays is how long, in days, after its branch was merged an instance is frozen to an
+	// archive and killed. Defaults to 7.
+	ArchiveMergedAfterDays int `json:"archive_merged_after_days,omitempty"`
+	// DeleteArchivesAfterDays is how old, in days, archives are deleted at. Defaults to 90.
+	DeleteArchivesAfterDays int `json:"delete_archives_after_days,omitempty"`
+}
+
+const (
+	defaultArchiveMergedAfterDays  = 7
+	defaultDeleteArchivesAfterDays = 90
+)
+
+// GetArchiveMergedAfter returns how long after being merged instances are archived.
+func (c RetentionConfig) GetArchiveMergedAfter() time.Duration {
+	days := c.ArchiveMergedAfterDays
+	if days == 0 {
+		days = defaultArchiveMergedAfterDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// GetDeleteArchivesAfter returns how old archives are deleted at.
+func (c RetentionConfig) GetDeleteArchivesAfter() time.Duration {
+	days := c.DeleteArchivesAfterDays
+	if days == 0 {
+		days = defaultDeleteArchivesAfterDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// HealthConfig configures the health checks run on each running instance: "pane_responsive",
+// "process_alive", "git_index" and "disk_space", plus the configured probes.
+type HealthConfig struct {
+	// IntervalSeconds is how often the instances are checked. Defaults to 60; a negative value disables the checks.
+	IntervalSeconds int `json:"interval_seconds,omitempty"`
+	// Disabled are the names of the built-in checks not to run.
+	Disabled []string `json:"disabled,omitempty"`
+	// Remediate are the names of the checks whose problems are fixed automatically, e.g. "git_index" to remove
+	// a stale index.lock.
+	Remediate []string `json:"remediate,omitempty"`
+	// MinFreeDiskMB is the free space, in MB, under which the disk of a worktree is reported as full.
+	// Defaults to 1024.
+	MinFreeDiskMB int `json:"min_free_disk_mb,omitempty"`
+	// Probes are program-specific checks: commands run in the worktrees of the instances running one of their
+	// programs, failing when they exit with a non-zero status.
+	Probes []HealthProbe `json:"probes,omitempty"`
+}
+
+// HealthProbe is a command that checks an instance's health.
+type HealthProbe struct {
+	// Name identifies the probe in the health column, e.g. "mcp".
+	Name string `json:"name"`
+	// Command is run with sh in the instance's worktree, with the instance's title in CS_INSTANCE.
+	Command string `json:"command"`
+	// Programs are the programs the probe applies to, like "claude" or "aider". All programs if empty.
+	Programs []string `json:"programs,omitempty"`
+}
+
+const (
+	defaultHealthInterval = time.Minute
+	defaultMinFreeDiskMB  = 1024
+)
+
+// GetInterval returns how often the instances are checked, or 0 if the checks are disabled.
+func (c HealthConfig) GetInterval() time.Duration {
+	switch {
+	case c.IntervalSeconds < 0:
+		return 0
+	case c.IntervalSeconds == 0:
+		return defaultHealthInterval
+	}
+	return time.Duration(c.IntervalSeconds) * time.Second
+}
+
+// GetMinFreeDisk returns the free space, in bytes, under which a disk is reported as full.
+func (c HealthConfig) GetMinFreeDisk() uint64 {
+	mb := c.MinFreeDiskMB
+	if mb <= 0 {
+		mb = defaultMinFreeDiskMB
+	}
+	return uint64(mb) << 20
+}
+
+// SyncConfig configures the remote the repositories and instances are synced through, so they're the same on
+// each machine that syncs with it.
+type SyncConfig struct {
+	// URL is where the synced state is kept: an S3 object, as in "s3://bucket/squad.json", written with the
+	// aws command, or a git repository, as in "git@github.com:me/squad-state.git". Syncing is off if empty.
+	URL string `json:"url,omitempty"`
+	// Branch is the branch of the git repository the state is committed to. Defaults to "main".
+	Branch string `json:"branch,omitempty"`
+	// Auto pulls the synced state when claude-squad starts and pushes it when it exits.
+	Auto bool `json:"auto,omitempty"`
+}
+
+// GetBranch returns the branch of the git repository the state is committed to.
+func (c SyncConfig) GetBranch() string {
+	if c.Branch == "" {
+		return "main"
+	}
+	return c.Branch
+}
+
+// NetworkConfig configures how claude-squad reaches network services, e.g. from behind a corporate proxy.
+type NetworkConfig struct {
+	// Proxy is the URL of the proxy HTTP requests go through, e.g. "http://proxy.example.com:8080". Defaults to
+	// HTTPS_PROXY, HTTP_PROXY and NO_PROXY from the environment.
+	Proxy string `json:"proxy,omitempty"`
+	// Proxies override the proxy for requests to some hosts, e.g. to reach an internal Jira directly.
+	Proxies []ProxyOverride `json:"proxies,omitempty"`
+	// CABundle is a PEM file of certificate authorities trusted in addition to the system's, e.g. those of a
+	// proxy that inspects TLS or of internal services.
+	CABundle string `json:"ca_bundle,omitempty"`
+}
+
+// ProxyOverride sets the proxy for the requests to a host.
+type ProxyOverride struct {
+	// Host is the host the override applies to, e.g. "jira.example.com", or with a leading dot a domain and its
+	// subdomains, e.g. ".example.com".
+	Host string `json:"host"`
+	// Proxy is the URL of the proxy for the host, or "direct" to connect without one.
+	Proxy string `json:"proxy"`
+}
+
+// GetArchiveDir returns the directory frozen instances are archived to.
+func (c *Config) GetArchiveDir() (string, error) {
+	if c.ArchiveDir != "" {
+		return c.ArchiveDir, nil
+	}
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "archives"), nil
+}
+
+// DependencyGuardConfig configures the checks on dependencies added to go.mod, package.json and
+// requirements.txt. Licenses are looked up on deps.dev.
+type DependencyGuardConfig struct {
+	// Disabled turns the checks off.
+	Disabled bool `json:"disabled,omitempty"`
+	// Block requires the user to explicitly override problems before pushing. By default they're only
+	// reported as a warning.
+	Block bool `json:"block,omitempty"`
+	// DisallowedLicenses are SPDX license identifiers that added dependencies must not use. Defaults to
+	// the strong copyleft licenses (GPL, AGPL and SSPL).
+	DisallowedLicenses []string `json:"disallowed_licenses,omitempty"`
+	// MaxNewDependencies is the number of dependencies a single manifest may gain before it's reported.
+	// Defaults to 10.
+	MaxNewDependencies int `json:"max_new_dependencies,omitempty"`
+}
+
+var defaultDisallowedLicenses = []string{"GPL-2.0", "GPL-3.0", "AGPL-3.0", "SSPL-1.0"}
+
+const defaultMaxNewDependencies = 10
+
+// GetDisallowedLicenses returns the configured disallowed licenses, or the defaults if none are configured.
+func (c DependencyGuardConfig) GetDisallowedLicenses() []string {
+	if c.DisallowedLicenses == nil {
+		return defaultDisallowedLicenses
+	}
+	return c.DisallowedLicenses
+}
+
+// GetMaxNewDependencies returns the configured dependency limit, or the default if none is configured.
+func (c DependencyGuardConfig) GetMaxNewDependencies() int {
+	if c.MaxNewDependencies == 0 {
+		return defaultMaxNewDependencies
+	}
+	return c.MaxNewDependencies
+}
+
+// SafetyScanConfig configures the scan for secrets, large binaries and forbidden paths that runs before
+// an instance's changes are pushed.
+type SafetyScanConfig struct {
+	// Disabled turns the scan off.
+	Disabled bool `json:"disabled,omitempty"`
+	// ForbiddenPaths are glob patterns of files that must not be pushed. Defaults to common credential
+	// files such as .env and private keys.
+	ForbiddenPaths []string `json:"forbidden_paths,omitempty"`
+	// MaxBinarySize is the size in bytes above which binary files are reported. Defaults to 1 MiB.
+	MaxBinarySize int64 `json:"max_binary_size,omitempty"`
+}
+
+var defaultSensitivePaths = []string{"migrations/", "migrate/", ".github/workflows/", ".gitlab-ci.yml", "Dockerfile", "*.tf"}
+
+// GetSensitivePaths returns the configured sensitive paths, or the defaults if none are configured.
+func (c *Config) GetSensitivePaths() []string {
+	if c.SensitivePaths == nil {
+		return defaultSensitivePaths
+	}
+	return c.SensitivePaths
+}
+
+// BlameConfig configures the blame annotations and regression hints in the diff view.
+type BlameConfig struct {
+	// Enabled annotates removed and modified lines with their author and age.
+	Enabled bool `json:"enabled,omitempty"`
+	// RecentDays is how young, in days, rewritten code must be to warn about it. Defaults to 14.
+	RecentDays int `json:"recent_days,omitempty"`
+}
+
+const defaultRecentDays = 14
+
+// GetRecent returns how young rewritten code must be to warn about it.
+func (c BlameConfig) GetRecent() time.Duration {
+	days := c.RecentDays
+	if days == 0 {
+		days = defaultRecentDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// DiffStorageConfig configures how the instances' diffs are stored. Each is kept in its own file in the
+// state directory, rather than in the state file, and only loaded when it's first shown.
+type DiffStorageConfig struct {
+	// Compress stores the diffs zstd-compressed.
+	Compress bool `json:"compress,omitempty"`
+	// MaxSize is the size, in bytes, of the largest diff that's stored. A larger diff is recomputed once its
+	// instance runs again, so a paused instance's diff is missing until it's resumed. Defaults to 1 MiB.
+	MaxSize int `json:"max_size,omitempty"`
+}
+
+const defaultMaxDiffSize = 1 << 20
+
+// GetMaxSize returns the size of the largest diff that's stored.
+func (c DiffStorageConfig) GetMaxSize() int {
+	if c.MaxSize == 0 {
+		return defaultMaxDiffSize
+	}
+	return c.MaxSize
+}
+
+var defaultForbiddenPaths = []string{".env", ".env.*", "*.pem", "*.key", "*.p12", "id_rsa", "id_ed25519", ".npmrc", ".pypirc"}
+
+const defaultMaxBinarySize = 1 << 20
+
+// GetForbiddenPaths returns the configured forbidden paths, or the defaults if none are configured.
+func (c SafetyScanConfig) GetForbiddenPaths() []string {
+	if c.ForbiddenPaths == nil {
+		return defaultForbiddenPaths
+	}
+	return c.ForbiddenPaths
+}
+
+// GetMaxBinarySize returns the configured binary size limit, or the default if none is configured.
+func (c SafetyScanConfig) GetMaxBinarySize() int64 {
+	if c.MaxBinarySize == 0 {
+		return defaultMaxBinarySize
+	}
+	return c.MaxBinarySize
+}
+
+// RedactionConfig configures the masking of secrets in agent output. API keys, tokens and emails are
+// masked by default.
+type RedactionConfig struct {
+	// Disabled turns redaction off.
+	Disabled bool `json:"disabled,omitempty"`
+	// Patterns are extra regular expressions to mask.
+	Patterns []string `json:"patterns,omitempty"`
+	// KeepEmails leaves email addresses unmasked.
+	KeepEmails bool `json:"keep_emails,omitempty"`
+}
+
+// CommitTrailer is a git trailer such as "Co-Authored-By: ...". Value may reference {program}, {title},
+// {branch}, {session} and {coauthor}, the co-author identity of the instance's agent, which are expanded per
+// instance. A trailer that expands to nothing, such as {coauthor} for an agent without a known identity, is
+// left out.
+type CommitTrailer struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// RepoConfig holds settings that only apply to a single repository.
+type RepoConfig struct {
+	// Push configures how instance branches are pushed for this repository.
+	Push PushConfig `json:"push,omitempty"`
+	// Identity is the author identity and signing key used for commits made in this repository.
+	Identity CommitIdentity `json:"identity,omitempty"`
+	// SkipHooks bypasses the repository's commit hooks (--no-verify) for commits made by claude-squad.
+	// Every bypassed commit is recorded in the audit log.
+	SkipHooks bool `json:"skip_hooks,omitempty"`
+	// Instructions are added after the global instructions for worktrees of this repository.
+	Instructions string `json:"instructions,omitempty"`
+	// MCPServers are added to the global MCP servers for instances of this repository. A server with the
+	// same name as a global one replaces it.
+	MCPServers map[string]MCPServer `json:"mcp_servers,omitempty"`
+	// Database configures the hooks that give each instance of this repository its own local database.
+	Database DatabaseConfig `json:"database,omitempty"`
+	// Panes are side panes created below the agent in each instance's tmux session, e.g. a test watcher,
+	// a log tail or a dev server. They're shown in the preview and killed with the instance.
+	Panes []PaneConfig `json:"panes,omitempty"`
+	// Tickets connects the repository to its issue tracker, so instances can be created from tickets and
+	// the tickets are updated when the instances' branches merge.
+	Tickets TicketConfig `json:"tickets,omitempty"`
+	// Color is the repository's accent color in the UI: an ANSI color number such as "33" or a hex color
+	// such as "#ff8800". By default one is picked from a palette based on the repository's path.
+	Color string `json:"color,omitempty"`
+}
+
+// Issue trackers.
+const (
+	TrackerJira   = "jira"
+	TrackerLinear = "linear"
+)
+
+// TicketConfig configures the issue tracker of a repository.
+type TicketConfig struct {
+	// Tracker is TrackerJira or TrackerLinear.
+	Tracker string `json:"tracker,omitempty"`
+	// URL is the Jira site, e.g. "https://example.atlassian.net". Unused for Linear.
+	URL string `json:"url,omitempty"`
+	// User is the email of the Jira account the token belongs to. Unused for Linear.
+	User string `json:"user,omitempty"`
+	// TokenSecret is the name of the secret holding the API token. Defaults to the tracker's name.
+	TokenSecret string `json:"token_secret,omitempty"`
+	// DoneState is the Jira transition or Linear workflow state a ticket is moved to when its instance's
+	// branch merges. Defaults to "Done".
+	DoneState string `json:"done_state,omitempty"`
+}
+
+// GetTokenSecret returns the name of the secret holding the tracker's API token.
+func (t TicketConfig) GetTokenSecret() string {
+	if t.TokenSecret == "" {
+		return t.Tracker
+	}
+	return t.TokenSecret
+}
+
+// GetDoneState returns the transition or state tickets are moved to when their instances' branches merge.
+func (t TicketConfig) GetDoneState() string {
+	if t.DoneState == "" {
+		return "Done"
+	}
+	return t.DoneState
+}
+
+// PaneConfig configures a side pane in instances' tmux sessions.
+type PaneConfig struct {
+	// Command is the shell command the pane runs in the instance's worktree.
+	Command string `json:"command"`
+	// Size is the pane's height as a percentage of the window. By default, the pane above it is halved.
+	Size int `json:"size,omitempty"`
+}
+
+// DatabaseConfig holds shell commands that snapshot and restore a per-instance copy of a local development
+// database, e.g. `createdb -T app_dev "$CS_DATABASE"` for Postgres or `cp dev.sqlite3 "$CS_DATABASE"` for
+// SQLite. They run in the instance's worktree with CS_DATABASE set to a name unique to the instance, and
+// CS_INSTANCE, CS_WORKTREE and CS_REPOSITORY describing it.
+type DatabaseConfig struct {
+	// Snapshot creates the instance's database. It runs when the instance is created.
+	Snapshot string `json:"snapshot,omitempty"`
+	// Restore resets the instance's database to a fresh snapshot. Defaults to Drop followed by Snapshot.
+	Restore string `json:"restore,omitempty"`
+	// Drop deletes the instance's database. It runs when the instance is killed.
+	Drop string `json:"drop,omitempty"`
+}
+
+// MCPServer configures an MCP server. It uses the format of the "mcpServers" entries in Claude Code's
+// .mcp.json: either a command to run over stdio, or the URL of an sse or http server.
+type MCPServer struct {
+	Type    string            `json:"type,omitempty"`
+	Command string            `json:"command,omitempty"`
+	Args    []string          `json:"args,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// CommitIdentity is the author/committer identity and signing key used for commits made by claude-squad.
+// Empty fields fall back to the user's git configuration.
+type CommitIdentity struct {
+	// Name is the author and committer name.
+	Name string `json:"name,omitempty"`
+	// Email is the author and committer email.
+	Email string `json:"email,omitempty"`
+	// SigningKey is a GPG key ID, or the path to a public key when SigningFormat is "ssh".
+	// Commits are signed only when this is set.
+	SigningKey string `json:"signing_key,omitempty"`
+	// SigningFormat is the git signing format: "openpgp" (default), "ssh" or "x509".
+	SigningFormat string `json:"signing_format,omitempty"`
+}
+
+// IsZero returns true if no identity fields are set.
+func (c CommitIdentity) IsZero() bool {
+	return c == CommitIdentity{}
+}
+
+// Merge returns c with the fields set in override replaced by override's.
+func (c CommitIdentity) Merge(override CommitIdentity) CommitIdentity {
+	if override.Name != "" {
+		c.Name = override.Name
+	}
+	if override.Email != "" {
+		c.Email = override.Email
+	}
+	if override.SigningKey != "" {
+		c.SigningKey = override.SigningKey
+	}
+	if override.SigningFormat != "" {
+		c.SigningFormat = override.SigningFormat
+	}
+	return c
+}
+
+// ParseCommitIdentity parses an identity written like a git author, e.g. "Review Bot <bot@example.com>".
+func ParseCommitIdentity(s string) (CommitIdentity, error) {
+	address, err := mail.ParseAddress(s)
+	if err != nil {
+		return CommitIdentity{}, fmt.Errorf("invalid identity %q, expected \"Name <email>\": %w", s, err)
+	}
+	return CommitIdentity{Name: address.Name, Email: address.Address}, nil
+}
+
+// PushConfig describes where and with which credentials branches are pushed.
+type PushConfig struct {
+	// Remote is the name of the git remote to push to. Defaults to "origin".
+	Remote string `json:"remote,omitempty"`
+	// PushURL, if set, is pushed to directly instead of the remote's configured URL.
+	PushURL string `json:"push_url,omitempty"`
+	// SSHKey is the path to a private key used for SSH remotes.
+	SSHKey string `json:"ssh_key,omitempty"`
+	// CredentialHelper is the git credential helper used for HTTPS remotes.
+	CredentialHelper string `json:"credential_helper,omitempty"`
+}
+
+// IsExplicit returns true if the push configuration was set by the user rather than defaulted.
+func (p PushConfig) IsExplicit() bool {
+	return p != PushConfig{} && p != PushConfig{Remote: defaultRemote}
+}
+
+// GetRepoConfig returns the settings for the repository at repoPath. Unset values are filled with defaults.
+func (c *Config) GetRepoConfig(repoPath string) RepoConfig {
+	repoCfg := c.Repos[repoPath]
+	if repoCfg.Push.Remote == "" {
+		repoCfg.Push.Remote = defaultRemote
+	}
+	return repoCfg
+}
+
+// GetRepoColors returns the accent colors chosen for repositories, keyed by repository path.
+func (c *Config) GetRepoColors() map[string]string {
+	colors := make(map[string]string)
+	for path, repoCfg := range c.Repos {
+		if repoCfg.Color != "" {
+			colors[path] = repoCfg.Color
+		}
+	}
+	return colors
+}
+
+// GetInstructions returns the instructions to write into new worktrees of the repository at repoPath:
+// the global instructions followed by the repository's own.
+func (c *Config) GetInstructions(repoPath string) string {
+	var parts []string
+	for _, part := range []string{c.Instructions, c.Repos[repoPath].Instructions} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// GetMCPServers returns the MCP servers for instances of the repository at repoPath: the global servers
+// overridden by the repository's own.
+func (c *Config) GetMCPServers(repoPath string) map[string]MCPServer {
+	servers := make(map[string]MCPServer)
+	for name, server := range c.MCPServers {
+		servers[name] = server
+	}
+	for name, server := range c.Repos[repoPath].MCPServers {
+		servers[name] = server
+	}
+	return servers
+}
+
+// GetNudgePrompt returns the prompt sent to nudge a stalled instance.
+func (c *Config) GetNudgePrompt() string {
+	if c.NudgePrompt == "" {
+		return defaultNudgePrompt
+	}
+	return c.NudgePrompt
+}
+
+// GetSummaryPrompt returns the prompt sent to ask an instance for a summary of its changes.
+func (c *Config) GetSummaryPrompt() string {
+	if c.SummaryPrompt == "" {
+		return defaultSummaryPrompt
+	}
+	return c.SummaryPrompt
 }
 
 // DefaultConfig returns the default configuration
@@ -58,6 +895,13 @@ func DefaultConfig() *Config {
 			}
 			return fmt.Sprintf("%s/", strings.ToLower(user.Username))
 		}(),
+		CommitTrailers: []CommitTrailer{
+			{Key: "Co-Authored-By", Value: "{coauthor}"},
+			{Key: "Agent", Value: "{program}"},
+			{Key: "Session-ID", Value: "{session}"},
+		},
+		StallTimeout:  300,
+		TerminalTitle: true,
 	}
 }
 
@@ -109,6 +953,44 @@ func GetClaudeCommand() (string, error) {
 	return "", fmt.Errorf("claude command not found in aliases or PATH")
 }
 
+// ReadConfig reads the config file without creating it or falling back to the default config like LoadConfig
+// does, and returns why it can't be loaded, if it can't. The default config is returned if there's no file.
+func ReadConfig() (*Config, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config directory: %w", err)
+	}
+	data, err := os.ReadFile(filepath.Join(configDir, ConfigFileName))
+	if os.IsNotExist(err) {
+		return DefaultConfig(), nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	return &config, nil
+}
+
+// AirGappedEnabled returns true if the config file turns on air-gapped mode. Only that field is read, so it can
+// be called before logging is initialized: building the default config logs when it can't find claude.
+func AirGappedEnabled() bool {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(filepath.Join(configDir, ConfigFileName))
+	if err != nil {
+		return false
+	}
+	var cfg struct {
+		AirGapped bool `json:"air_gapped"`
+	}
+	_ = json.Unmarshal(data, &cfg)
+	return cfg.AirGapped
+}
+
 func LoadConfig() *Config {
 	configDir, err := GetConfigDir()
 	if err != nil {
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetWindowSize(t *testing.T) {
+	cfg := &Config{WindowSizes: map[string]WindowSize{"aider": {Width: 200}}}
+
+	require.Equal(t, WindowSize{Width: 200}, cfg.GetWindowSize("/usr/local/bin/aider --model sonnet"))
+	require.Equal(t, WindowSize{}, cfg.GetWindowSize("claude"))
+	require.Equal(t, WindowSize{}, cfg.GetWindowSize(""))
+}
+
+func TestRefreshDefaults(t *testing.T) {
+	var cfg RefreshConfig
+	require.Equal(t, 83*time.Millisecond, cfg.GetSpinner())
+	require.Equal(t, 100*time.Millisecond, cfg.GetPreview())
+	require.Equal(t, 500*time.Millisecond, cfg.GetPoll())
+	require.Equal(t, LowPowerAuto, cfg.GetLowPower())
+	require.Equal(t, 4, cfg.GetLowPowerFactor())
+
+	cfg = RefreshConfig{Preview: 250, LowPower: LowPowerNever, LowPowerFactor: 2}
+	require.Equal(t, 250*time.Millisecond, cfg.GetPreview())
+	require.Equal(t, LowPowerNever, cfg.GetLowPower())
+	require.Equal(t, 2, cfg.GetLowPowerFactor())
+}
+
+func TestCommitIdentityMerge(t *testing.T) {
+	repo := CommitIdentity{Name: "Release Team", Email: "release@example.com", SigningKey: "ABC123"}
+
+	// Only a signing key given for the instance keeps the repository's name and email.
+	require.Equal(t, CommitIdentity{Name: "Release Team", Email: "release@example.com",
+		SigningKey: "~/.ssh/id_ed25519.pub", SigningFormat: "ssh"},
+		repo.Merge(CommitIdentity{SigningKey: "~/.ssh/id_ed25519.pub", SigningFormat: "ssh"}))
+	require.Equal(t, CommitIdentity{Name: "Review Bot", Email: "bot@example.com", SigningKey: "ABC123"},
+		repo.Merge(CommitIdentity{Name: "Review Bot", Email: "bot@example.com"}))
+	require.Equal(t, repo, repo.Merge(CommitIdentity{}))
+}
@@ -0,0 +1,54 @@
+package config
+
+import "time"
+
+const (
+	// HintInterval is the shortest time between two hints, so they don't get in the way.
+	HintInterval = 2 * time.Minute
+	// HintMaxShows is how many times a hint is shown before it's retired, even if it isn't dismissed.
+	HintMaxShows = 3
+)
+
+// HintRecord is what's known of a hint the user was shown.
+type HintRecord struct {
+	// Shown is how many times the hint was shown, and ShownAt when it last was.
+	Shown   int       `json:"shown,omitempty"`
+	ShownAt time.Time `json:"shown_at,omitempty"`
+	// Dismissed is set once the user dismissed the hint, which isn't shown again.
+	Dismissed bool `json:"dismissed,omitempty"`
+}
+
+// Hints are the contextual hints the user was shown, by ID.
+type Hints struct {
+	// LastShownAt is when any hint was last shown.
+	LastShownAt time.Time             `json:"last_shown_at,omitempty"`
+	Records     map[string]HintRecord `json:"records,omitempty"`
+}
+
+// CanShow returns true if the hint id may be shown at now: it wasn't dismissed or shown HintMaxShows times
+// already, and no hint was shown in the last HintInterval.
+func (h Hints) CanShow(id string, now time.Time) bool {
+	record := h.Records[id]
+	return !record.Dismissed && record.Shown < HintMaxShows && now.Sub(h.LastShownAt) >= HintInterval
+}
+
+// with returns a copy of the hints with the record of id updated by update, leaving h's records unchanged.
+func (h Hints) with(id string, update func(record *HintRecord)) Hints {
+	records := make(map[string]HintRecord, len(h.Records)+1)
+	for k, v := range h.Records {
+		records[k] = v
+	}
+	record := records[id]
+	update(&record)
+	records[id] = record
+	h.Records = records
+	return h
+}
+
+// legacyHelpScreenHints are the hints that replaced the help screens shown once, by their bit in the
+// HelpScreensSeen bitmask of older states.
+var legacyHelpScreenHints = map[uint32]string{
+	1 << 1: "instance_created",
+	1 << 2: "attach",
+	1 << 3: "checkout",
+}
@@ -0,0 +1,165 @@
+package config
+
+import (
+	"claude-squad/log"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Migration upgrades the state by one version. Migrations must leave the state unchanged when they fail.
+type Migration struct {
+	// Description says what the migration changes, for the log.
+	Description string
+	// Up upgrades the state to the next version.
+	Up func(state *State) error
+	// Down reverts Up. It's nil if the migration can't be reverted.
+	Down func(state *State) error
+}
+
+// migrations upgrade the state from each version to the next: migrations[0] from version 0 to 1, and so on.
+// Schema changes to State or InstanceData add a migration here and bump CurrentStateVersion.
+var migrations = []Migration{
+	{
+		Description: "track repositories",
+		Up: func(state *State) error {
+			if state.Repositories == nil {
+				state.Repositories = make([]RepositoryData, 0)
+			}
+			return nil
+		},
+	},
+	{
+		Description: "associate instances with their worktree's repository",
+		Up: func(state *State) error {
+			return updateInstances(state, func(instance map[string]json.RawMessage) error {
+				if path := instance["repository_path"]; len(path) > 0 && string(path) != `""` {
+					return nil
+				}
+				var worktree struct {
+					RepoPath string `json:"repo_path"`
+				}
+				if data, ok := instance["worktree"]; ok {
+					if err := json.Unmarshal(data, &worktree); err != nil {
+						return err
+					}
+				}
+				if worktree.RepoPath == "" {
+					return nil
+				}
+				path, err := json.Marshal(worktree.RepoPath)
+				if err != nil {
+					return err
+				}
+				instance["repository_path"] = path
+				return nil
+			})
+		},
+		// Version 1 already reads the repository path of instances.
+		Down: func(state *State) error { return nil },
+	},
+	{
+		Description: "replace the help screens shown once with hints",
+		Up: func(state *State) error {
+			for bit, id := range legacyHelpScreenHints {
+				if state.HelpScreensSeen&bit != 0 {
+					state.Hints = state.Hints.with(id, func(record *HintRecord) { record.Dismissed = true })
+				}
+			}
+			state.HelpScreensSeen = 0
+			return nil
+		},
+		Down: func(state *State) error {
+			for bit, id := range legacyHelpScreenHints {
+				if record := state.Hints.Records[id]; record.Dismissed || record.Shown > 0 {
+					state.HelpScreensSeen |= bit
+				}
+			}
+			return nil
+		},
+	},
+}
+
+// CurrentStateVersion is the version of the state this version of claude-squad reads and writes.
+const CurrentStateVersion = 3
+
+// errIrreversible is returned when the state can't be downgraded to the version asked for.
+var errIrreversible = errors.New("the migration can't be reverted")
+
+// migrateState upgrades or downgrades the state, one version at a time, to version to. The state is left at
+// the last version it reached if a migration fails. States from a later version of claude-squad are left as
+// they are, since their migrations aren't known.
+func migrateState(state *State, to int) error {
+	if state.StateVersion > len(migrations) {
+		return fmt.Errorf("state version %d is newer than this claude-squad supports (%d)", state.StateVersion,
+			len(migrations))
+	}
+	for state.StateVersion < to {
+		migration := migrations[state.StateVersion]
+		if err := migration.Up(state); err != nil {
+			return fmt.Errorf("failed to migrate state from version %d to %d (%s): %w", state.StateVersion,
+				state.StateVersion+1, migration.Description, err)
+		}
+		state.StateVersion++
+		if log.InfoLog != nil {
+			log.InfoLog.Printf("migrated state from version %d to %d: %s", state.StateVersion-1, state.StateVersion,
+				migration.Description)
+		}
+	}
+	for state.StateVersion > to {
+		migration := migrations[state.StateVersion-1]
+		if migration.Down == nil {
+			return fmt.Errorf("failed to migrate state from version %d to %d (%s): %w", state.StateVersion,
+				state.StateVersion-1, migration.Description, errIrreversible)
+		}
+		if err := migration.Down(state); err != nil {
+			return fmt.Errorf("failed to migrate state from version %d to %d (%s): %w", state.StateVersion,
+				state.StateVersion-1, migration.Description, err)
+		}
+		state.StateVersion--
+		if log.InfoLog != nil {
+			log.InfoLog.Printf("reverted state from version %d to %d: %s", state.StateVersion+1, state.StateVersion,
+				migration.Description)
+		}
+	}
+	return nil
+}
+
+// updateInstances applies update to each of the state's instances, as JSON objects by field name. The
+// instances are only replaced if all of them are updated.
+func updateInstances(state *State, update func(instance map[string]json.RawMessage) error) error {
+	if len(state.InstancesData) == 0 {
+		return nil
+	}
+	var instances []map[string]json.RawMessage
+	if err := json.Unmarshal(state.InstancesData, &instances); err != nil {
+		return fmt.Errorf("failed to parse instances: %w", err)
+	}
+	for _, instance := range instances {
+		if err := update(instance); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(instances)
+	if err != nil {
+		return fmt.Errorf("failed to marshal instances: %w", err)
+	}
+	state.InstancesData = data
+	return nil
+}
+
+// preMigrationBackupPath returns where the state file at statePath is kept before it's migrated from
+// version from.
+func preMigrationBackupPath(statePath string, from int) string {
+	return fmt.Sprintf("%s.pre-migration-v%d", statePath, from)
+}
+
+// backUpBeforeMigration copies the state file at statePath, as it was before it's migrated from version from.
+func backUpBeforeMigration(statePath string, from int) error {
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(preMigrationBackupPath(statePath, from), data, 0644)
+}
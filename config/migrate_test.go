@@ -0,0 +1,70 @@
+package config
+
+import (
+	"claude-squad/log"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateState(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	require.Len(t, migrations, CurrentStateVersion, "each version needs a migration")
+
+	state := &State{InstancesData: json.RawMessage(`[
+		{"title": "legacy", "worktree": {"repo_path": "/src/app"}},
+		{"title": "associated", "repository_path": "/src/site", "worktree": {"repo_path": "/src/site"}}
+	]`)}
+	require.NoError(t, migrateState(state, CurrentStateVersion))
+	require.Equal(t, CurrentStateVersion, state.StateVersion)
+	require.NotNil(t, state.Repositories)
+	var instances []struct {
+		Title          string `json:"title"`
+		RepositoryPath string `json:"repository_path"`
+	}
+	require.NoError(t, json.Unmarshal(state.InstancesData, &instances))
+	require.Equal(t, "/src/app", instances[0].RepositoryPath)
+	require.Equal(t, "/src/site", instances[1].RepositoryPath)
+
+	state.StateVersion = 2
+	state.HelpScreensSeen = 1<<0 | 1<<2
+	require.NoError(t, migrateState(state, CurrentStateVersion))
+	require.Zero(t, state.HelpScreensSeen)
+	require.Equal(t, map[string]HintRecord{"attach": {Dismissed: true}}, state.Hints.Records)
+	require.NoError(t, migrateState(state, 2))
+	// The general help was shown on demand rather than once, so it has no hint.
+	require.EqualValues(t, 1<<2, state.HelpScreensSeen)
+
+	require.NoError(t, migrateState(state, 1))
+	require.Equal(t, 1, state.StateVersion)
+	require.ErrorIs(t, migrateState(state, 0), errIrreversible)
+	require.Equal(t, 1, state.StateVersion)
+
+	state.StateVersion = CurrentStateVersion + 1
+	require.Error(t, migrateState(state, CurrentStateVersion))
+	require.Equal(t, CurrentStateVersion+1, state.StateVersion)
+}
+
+func TestLoadStateBacksUpBeforeMigrating(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	stateDir, err := GetStateDir()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(stateDir, 0755))
+	statePath := filepath.Join(stateDir, StateFileName)
+	original := []byte(`{"state_version": 1, "instances": [{"title": "a", "worktree": {"repo_path": "/src/app"}}]}`)
+	require.NoError(t, os.WriteFile(statePath, original, 0644))
+
+	state := LoadState()
+	require.Equal(t, CurrentStateVersion, state.StateVersion)
+	backup, err := os.ReadFile(filepath.Join(stateDir, StateFileName+".pre-migration-v1"))
+	require.NoError(t, err)
+	require.Equal(t, original, backup)
+	require.Equal(t, CurrentStateVersion, LoadState().StateVersion)
+}
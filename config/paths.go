@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// ConfigDirEnv overrides the directory the config and the state are kept in, e.g. to keep the state on
+	// another disk, or in a writable volume of a container whose home directory is read-only. It's how
+	// --config-dir is passed on to the processes claude-squad starts.
+	ConfigDirEnv = "CS_CONFIG_DIR"
+	// legacyDirName is the directory, in the home directory, claude-squad kept everything in before it
+	// followed the XDG base directories.
+	legacyDirName = ".claude-squad"
+	// xdgDirName is claude-squad's directory in the XDG base directories.
+	xdgDirName = "claude-squad"
+)
+
+// SetConfigDir keeps the config and the state in dir from now on, in this process and the ones it starts.
+func SetConfigDir(dir string) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("invalid config directory: %w", err)
+	}
+	return os.Setenv(ConfigDirEnv, dir)
+}
+
+// GetConfigDir returns the path to the current profile's configuration directory, where its config file,
+// task templates and rules script are kept.
+func GetConfigDir() (string, error) {
+	pm, err := NewProfileManager()
+	if err != nil {
+		return "", err
+	}
+	return pm.Dir(GetProfile())
+}
+
+// GetStateDir returns the path to the directory of the current profile's state: the state file, worktrees,
+// diffs and archives. It's the configuration directory unless the XDG base directories are followed.
+func GetStateDir() (string, error) {
+	pm, err := NewProfileManager()
+	if err != nil {
+		return "", err
+	}
+	return pm.StateDir(GetProfile())
+}
+
+// getBaseDirs returns the default profile's configuration and state directories:
+//   - $CS_CONFIG_DIR for both, if it's set;
+//   - ~/.claude-squad for both, if it exists;
+//   - $XDG_CONFIG_HOME/claude-squad and $XDG_STATE_HOME/claude-squad if either variable is set, the unset one
+//     defaulting to ~/.config or ~/.local/state;
+//   - ~/.claude-squad for both otherwise.
+func getBaseDirs() (configDir, stateDir string, err error) {
+	if dir := os.Getenv(ConfigDirEnv); dir != "" {
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid %s: %w", ConfigDirEnv, err)
+		}
+		return dir, dir, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get config home directory, set %s: %w", ConfigDirEnv, err)
+	}
+	legacyDir := filepath.Join(homeDir, legacyDirName)
+	if _, err := os.Stat(legacyDir); err == nil {
+		return legacyDir, legacyDir, nil
+	}
+	configHome, stateHome := os.Getenv("XDG_CONFIG_HOME"), os.Getenv("XDG_STATE_HOME")
+	if configHome == "" && stateHome == "" {
+		return legacyDir, legacyDir, nil
+	}
+	if configHome == "" {
+		configHome = filepath.Join(homeDir, ".config")
+	}
+	if stateHome == "" {
+		stateHome = filepath.Join(homeDir, ".local", "state")
+	}
+	return filepath.Join(configHome, xdgDirName), filepath.Join(stateHome, xdgDirName), nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigAndStateDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(ProfileEnv, "")
+	t.Setenv(ConfigDirEnv, "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_STATE_HOME", "")
+
+	dirs := func() (string, string) {
+		configDir, err := GetConfigDir()
+		require.NoError(t, err)
+		stateDir, err := GetStateDir()
+		require.NoError(t, err)
+		return configDir, stateDir
+	}
+
+	legacyDir := filepath.Join(home, ".claude-squad")
+	configDir, stateDir := dirs()
+	require.Equal(t, legacyDir, configDir)
+	require.Equal(t, legacyDir, stateDir)
+
+	// The XDG base directories are followed when they're set.
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+	configDir, stateDir = dirs()
+	require.Equal(t, filepath.Join(home, ".config", "claude-squad"), configDir)
+	require.Equal(t, filepath.Join(home, "state", "claude-squad"), stateDir)
+
+	pm, err := NewProfileManager()
+	require.NoError(t, err)
+	require.NoError(t, pm.Create("work"))
+	require.DirExists(t, filepath.Join(home, ".config", "claude-squad", "profiles", "work"))
+	require.DirExists(t, filepath.Join(home, "state", "claude-squad", "profiles", "work"))
+	require.NoError(t, pm.Delete("work"))
+	require.NoDirExists(t, filepath.Join(home, "state", "claude-squad", "profiles", "work"))
+
+	// Unless ~/.claude-squad already exists.
+	require.NoError(t, os.MkdirAll(legacyDir, 0755))
+	configDir, stateDir = dirs()
+	require.Equal(t, legacyDir, configDir)
+	require.Equal(t, legacyDir, stateDir)
+
+	// The override takes precedence over both.
+	require.NoError(t, SetConfigDir(filepath.Join(home, "elsewhere")))
+	configDir, stateDir = dirs()
+	require.Equal(t, filepath.Join(home, "elsewhere"), configDir)
+	require.Equal(t, filepath.Join(home, "elsewhere"), stateDir)
+}
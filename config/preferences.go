@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Layouts of the list and the preview.
+const (
+	// LayoutAuto shows the list and the preview side by side, or one at a time in narrow terminals.
+	LayoutAuto = "auto"
+	// LayoutSplit always shows the list and the preview side by side.
+	LayoutSplit = "split"
+	// LayoutSingle always shows the list and the preview one at a time.
+	LayoutSingle = "single"
+)
+
+// layouts are the valid layouts.
+var layouts = []string{LayoutAuto, LayoutSplit, LayoutSingle}
+
+// sortOrders are the orders instances can be listed in.
+var sortOrders = []string{"default", "uptime", "activity"}
+
+// PreferenceKeys are the names of the preferences, as in the state file.
+var PreferenceKeys = []string{"layout", "default_program", "sort_order", "confirm_on_kill"}
+
+// Preferences are the choices the user made in the UI, kept across sessions.
+type Preferences struct {
+	// Layout is how the list and the preview are laid out: "auto", "split" or "single". Defaults to "auto".
+	Layout string `json:"layout,omitempty"`
+	// DefaultProgram is the program new instances run, overriding the config's default_program. The
+	// --program flag overrides it.
+	DefaultProgram string `json:"default_program,omitempty"`
+	// SortOrder is the order instances are listed in: "default", "uptime" or "activity".
+	SortOrder string `json:"sort_order,omitempty"`
+	// ConfirmOnKill asks before stopping, force-killing or purging an instance. Defaults to true.
+	ConfirmOnKill *bool `json:"confirm_on_kill,omitempty"`
+}
+
+// GetLayout returns how the list and the preview are laid out.
+func (p Preferences) GetLayout() string {
+	if p.Layout == "" {
+		return LayoutAuto
+	}
+	return p.Layout
+}
+
+// GetConfirmOnKill returns whether to ask before stopping, force-killing or purging an instance.
+func (p Preferences) GetConfirmOnKill() bool {
+	return p.ConfirmOnKill == nil || *p.ConfirmOnKill
+}
+
+// Get returns the value of the preference named key, with its default if it's not set.
+func (p Preferences) Get(key string) (string, error) {
+	switch key {
+	case "layout":
+		return p.GetLayout(), nil
+	case "default_program":
+		return p.DefaultProgram, nil
+	case "sort_order":
+		if p.SortOrder == "" {
+			return sortOrders[0], nil
+		}
+		return p.SortOrder, nil
+	case "confirm_on_kill":
+		return strconv.FormatBool(p.GetConfirmOnKill()), nil
+	}
+	return "", unknownPreference(key)
+}
+
+// Set sets the preference named key to value, or back to its default if value is empty.
+func (p *Preferences) Set(key, value string) error {
+	switch key {
+	case "layout":
+		if value != "" && !slices.Contains(layouts, value) {
+			return fmt.Errorf("unknown layout %q, expected one of %s", value, strings.Join(layouts, ", "))
+		}
+		p.Layout = value
+	case "default_program":
+		p.DefaultProgram = value
+	case "sort_order":
+		if value != "" && !slices.Contains(sortOrders, value) {
+			return fmt.Errorf("unknown sort order %q, expected one of %s", value, strings.Join(sortOrders, ", "))
+		}
+		p.SortOrder = value
+	case "confirm_on_kill":
+		if value == "" {
+			p.ConfirmOnKill = nil
+			return nil
+		}
+		confirm, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("confirm_on_kill must be true or false, not %q", value)
+		}
+		p.ConfirmOnKill = &confirm
+	default:
+		return unknownPreference(key)
+	}
+	return nil
+}
+
+func unknownPreference(key string) error {
+	return fmt.Errorf("unknown preference %q, expected one of %s", key, strings.Join(PreferenceKeys, ", "))
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"claude-squad/log"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreferences(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	var preferences Preferences
+	for key, value := range map[string]string{
+		"layout": "auto", "default_program": "", "sort_order": "default", "confirm_on_kill": "true",
+	} {
+		got, err := preferences.Get(key)
+		require.NoError(t, err)
+		require.Equal(t, value, got, key)
+	}
+	_, err := preferences.Get("theme")
+	require.ErrorContains(t, err, "unknown preference")
+
+	require.ErrorContains(t, preferences.Set("layout", "stacked"), "unknown layout")
+	require.ErrorContains(t, preferences.Set("sort_order", "name"), "unknown sort order")
+	require.ErrorContains(t, preferences.Set("confirm_on_kill", "maybe"), "true or false")
+	require.NoError(t, preferences.Set("layout", LayoutSingle))
+	require.NoError(t, preferences.Set("default_program", "aider"))
+	require.NoError(t, preferences.Set("sort_order", "activity"))
+	require.NoError(t, preferences.Set("confirm_on_kill", "false"))
+
+	require.NoError(t, DefaultState().SetPreferences(preferences))
+	loaded := LoadState().GetPreferences()
+	require.Equal(t, preferences, loaded)
+	require.Equal(t, LayoutSingle, loaded.GetLayout())
+	require.False(t, loaded.GetConfirmOnKill())
+
+	require.NoError(t, loaded.Set("confirm_on_kill", ""))
+	require.True(t, loaded.GetConfirmOnKill())
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+const (
+	// ProfileEnv selects the profile when --profile isn't given. It's also how the profile is passed on to the
+	// processes claude-squad starts, like the daemon.
+	ProfileEnv = "CLAUDE_SQUAD_PROFILE"
+	// DefaultProfile is the profile whose config and state are in the config and state directories themselves.
+	DefaultProfile = "default"
+	// profilesDirName is the directory, in the default profile's config and state directories, of the other
+	// profiles'.
+	profilesDirName = "profiles"
+)
+
+var profileNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// GetProfile returns the current profile, DefaultProfile unless another was selected with SetProfile or
+// ProfileEnv.
+func GetProfile() string {
+	if profile := os.Getenv(ProfileEnv); profile != "" {
+		return profile
+	}
+	return DefaultProfile
+}
+
+// SetProfile selects the profile whose config and state are used from now on, by this process and the ones it
+// starts. The profile must exist.
+func SetProfile(name string) error {
+	pm, err := NewProfileManager()
+	if err != nil {
+		return err
+	}
+	if !pm.Exists(name) {
+		return fmt.Errorf("profile %q doesn't exist, create it with `claude-squad profile create %s`", name, name)
+	}
+	return os.Setenv(ProfileEnv, name)
+}
+
+// ProfileManager manages the profiles: separate config and state directories, e.g. for personal and work
+// repositories with different defaults.
+type ProfileManager struct {
+	// baseDir is the default profile's config directory.
+	baseDir string
+	// stateBaseDir is the default profile's state directory, usually baseDir.
+	stateBaseDir string
+}
+
+// NewProfileManager returns a ProfileManager for the user's profiles.
+func NewProfileManager() (*ProfileManager, error) {
+	baseDir, stateBaseDir, err := getBaseDirs()
+	if err != nil {
+		return nil, err
+	}
+	return &ProfileManager{baseDir: baseDir, stateBaseDir: stateBaseDir}, nil
+}
+
+// Dir returns the config directory of the profile.
+func (pm *ProfileManager) Dir(name string) (string, error) {
+	return profileDir(pm.baseDir, name)
+}
+
+// StateDir returns the state directory of the profile.
+func (pm *ProfileManager) StateDir(name string) (string, error) {
+	return profileDir(pm.stateBaseDir, name)
+}
+
+// profileDir returns the directory of the profile, given the default profile's.
+func profileDir(baseDir, name string) (string, error) {
+	if name == DefaultProfile {
+		return baseDir, nil
+	}
+	if !profileNameRegex.MatchString(name) {
+		return "", fmt.Errorf("invalid profile name %q: use letters, digits, - and _", name)
+	}
+	return filepath.Join(baseDir, profilesDirName, name), nil
+}
+
+// Exists returns true if the profile exists. The default profile always does.
+func (pm *ProfileManager) Exists(name string) bool {
+	dir, err := pm.Dir(name)
+	if err != nil {
+		return false
+	}
+	if name == DefaultProfile {
+		return true
+	}
+	info, err := os.Stat(dir)
+	return err == nil && info.IsDir()
+}
+
+// List returns the names of the profiles, the default one first.
+func (pm *ProfileManager) List() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(pm.baseDir, profilesDirName))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to list profiles: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && profileNameRegex.MatchString(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return append([]string{DefaultProfile}, names...), nil
+}
+
+// Create creates the profile. Its config starts as a copy of the default profile's, if there's one, and its state
+// starts empty.
+func (pm *ProfileManager) Create(name string) error {
+	dir, err := pm.Dir(name)
+	if err != nil {
+		return err
+	}
+	if pm.Exists(name) {
+		return fmt.Errorf("profile %q already exists", name)
+	}
+	stateDir, err := pm.StateDir(name)
+	if err != nil {
+		return err
+	}
+	for _, d := range []string{dir, stateDir} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			return fmt.Errorf("failed to create profile %s: %w", name, err)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(pm.baseDir, ConfigFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read the default config: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ConfigFileName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write the config of profile %s: %w", name, err)
+	}
+	return nil
+}
+
+// Delete deletes the profile with its config and state. The default profile, the current one and profiles that
+// still have instances can't be deleted.
+func (pm *ProfileManager) Delete(name string) error {
+	dir, err := pm.Dir(name)
+	if err != nil {
+		return err
+	}
+	stateDir, err := pm.StateDir(name)
+	if err != nil {
+		return err
+	}
+	switch {
+	case name == DefaultProfile:
+		return errors.New("the default profile can't be deleted")
+	case name == GetProfile():
+		return fmt.Errorf("profile %q is in use", name)
+	case !pm.Exists(name):
+		return fmt.Errorf("profile %q doesn't exist", name)
+	}
+
+	data, err := readStateData(filepath.Join(stateDir, StateFileName))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read the state of profile %s: %w", name, err)
+	}
+	if err == nil {
+		var state struct {
+			Instances []struct{} `json:"instances"`
+		}
+		if err := json.Unmarshal(data, &state); err != nil {
+			return fmt.Errorf("failed to read the state of profile %s: %w", name, err)
+		}
+		if n := len(state.Instances); n > 0 {
+			return fmt.Errorf("profile %q still has %d instances, kill them first with `claude-squad --profile %s`",
+				name, n, name)
+		}
+	}
+	for _, d := range []string{dir, stateDir} {
+		if err := os.RemoveAll(d); err != nil {
+			return fmt.Errorf("failed to delete profile %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"claude-squad/log"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiles(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(ProfileEnv, "")
+
+	baseDir := filepath.Join(home, ".claude-squad")
+	require.NoError(t, os.MkdirAll(baseDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(baseDir, ConfigFileName), []byte(`{"default_program":"aider"}`), 0644))
+
+	pm, err := NewProfileManager()
+	require.NoError(t, err)
+	require.Error(t, SetProfile("work"), "the profile doesn't exist yet")
+	require.NoError(t, pm.Create("work"))
+	require.Error(t, pm.Create("work"))
+	require.Error(t, pm.Create("../work"))
+	profiles, err := pm.List()
+	require.NoError(t, err)
+	require.Equal(t, []string{DefaultProfile, "work"}, profiles)
+
+	// The profile's config starts as a copy of the default one, and its state is separate.
+	require.NoError(t, SetProfile("work"))
+	dir, err := GetConfigDir()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(baseDir, "profiles", "work"), dir)
+	require.Equal(t, "aider", LoadConfig().DefaultProgram)
+	require.NoError(t, LoadState().SaveInstances(json.RawMessage(`[{"title":"a"}]`)))
+	require.Error(t, pm.Delete("work"), "the profile is in use")
+
+	t.Setenv(ProfileEnv, "")
+	require.Equal(t, "[]", string(LoadState().GetInstances()))
+	require.Error(t, pm.Delete("work"), "the profile still has instances")
+	require.Error(t, pm.Delete(DefaultProfile))
+
+	require.NoError(t, os.Remove(filepath.Join(dir, StateFileName)))
+	require.NoError(t, pm.Delete("work"))
+	require.NoDirExists(t, dir)
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"claude-squad/log"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RepoFileName is the file a repository can ship to set claude-squad's defaults for the instances created in
+// it, e.g. the agent its instructions are written for.
+const RepoFileName = ".claude-squad.yaml"
+
+// RepoSettings are the settings of a repository's RepoFileName. Set values override the global config for
+// the instances created in the repository. Since anyone who can commit to the repository can set them, the
+// ones that would run something on the user's behalf have to be allowed by the user.
+type RepoSettings struct {
+	// DefaultProgram is the program new instances run, unless another is given with --program. It's only run
+	// once the user allowed it, see ProgramAllowed.
+	DefaultProgram string `yaml:"default_program,omitempty" json:"default_program,omitempty"`
+	// ProgramAllowed records the user's answer when asked whether new instances may run DefaultProgram. It's
+	// nil until they're asked, and is never read from the RepoFileName.
+	ProgramAllowed *bool `yaml:"-" json:"program_allowed,omitempty"`
+	// BranchPrefix is the prefix of the instances' branches, e.g. "agent/".
+	BranchPrefix string `yaml:"branch_prefix,omitempty" json:"branch_prefix,omitempty"`
+	// AutoYes set to false keeps new instances from automatically accepting prompts even if the user turned
+	// that on. It can't turn it on.
+	AutoYes *bool `yaml:"auto_yes,omitempty" json:"auto_yes,omitempty"`
+	// WorktreeDir is the directory the instances' worktrees are created in, relative to the repository unless
+	// it's absolute, e.g. "../app-worktrees" to keep them next to it.
+	WorktreeDir string `yaml:"worktree_dir,omitempty" json:"worktree_dir,omitempty"`
+}
+
+// LoadRepoSettings reads the RepoFileName of the repository at repoPath. It returns nil if there's none.
+func LoadRepoSettings(repoPath string) (*RepoSettings, error) {
+	data, err := os.ReadFile(filepath.Join(repoPath, RepoFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", RepoFileName, err)
+	}
+	settings := &RepoSettings{}
+	if err := yaml.Unmarshal(data, settings); err != nil {
+		return nil, fmt.Errorf("failed to parse %s of %s: %w", RepoFileName, repoPath, err)
+	}
+	return settings, nil
+}
+
+// GetRepoSettings returns the settings of the repository at repoPath: those loaded when it was added to repos,
+// or, if it hasn't been or repos is nil, those in its RepoFileName. It never returns nil.
+func GetRepoSettings(repos RepositoryStorage, repoPath string) *RepoSettings {
+	if repos != nil {
+		if repo, err := repos.GetRepository(repoPath); err == nil && repo.Settings != nil {
+			return repo.Settings
+		}
+	}
+	settings, err := LoadRepoSettings(repoPath)
+	if err != nil {
+		log.WarningLog.Printf("ignoring the repository settings: %v", err)
+	}
+	if settings == nil {
+		return &RepoSettings{}
+	}
+	return settings
+}
+
+// AllowedProgram returns DefaultProgram if the user allowed it, or else "".
+func (s *RepoSettings) AllowedProgram() string {
+	if s.ProgramAllowed == nil || !*s.ProgramAllowed {
+		return ""
+	}
+	return s.DefaultProgram
+}
+
+// GetWorktreeDir returns the absolute directory the worktrees of the repository at repoPath are created in,
+// or "" to use the default.
+func (s *RepoSettings) GetWorktreeDir(repoPath string) string {
+	if s.WorktreeDir == "" || filepath.IsAbs(s.WorktreeDir) {
+		return s.WorktreeDir
+	}
+	return filepath.Join(repoPath, s.WorktreeDir)
+}
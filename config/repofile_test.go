@@ -0,0 +1,48 @@
+package config
+
+import (
+	"claude-squad/log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepoSettings(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	repo := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(repo, ".git"), 0755))
+	settings, err := LoadRepoSettings(repo)
+	require.NoError(t, err)
+	require.Nil(t, settings)
+	state := LoadState()
+	require.Equal(t, &RepoSettings{}, GetRepoSettings(state, repo))
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, RepoFileName), []byte(
+		"default_program: aider\nbranch_prefix: agent/\nauto_yes: false\nworktree_dir: ../worktrees\n"), 0644))
+	// Before the repository is added, its file is read directly.
+	require.Equal(t, "aider", GetRepoSettings(state, repo).DefaultProgram)
+
+	repoData, err := CreateRepositoryData(repo)
+	require.NoError(t, err)
+	require.NotNil(t, repoData.Settings)
+	require.Equal(t, "agent/", repoData.Settings.BranchPrefix)
+	require.NotNil(t, repoData.Settings.AutoYes)
+	require.False(t, *repoData.Settings.AutoYes)
+	require.Equal(t, filepath.Join(filepath.Dir(repo), "worktrees"), repoData.Settings.GetWorktreeDir(repo))
+	require.NoError(t, state.AddRepository(repoData))
+
+	// Once it's added, the settings loaded then are used.
+	require.NoError(t, os.WriteFile(filepath.Join(repo, RepoFileName), []byte("default_program: codex\n"), 0644))
+	require.Equal(t, "aider", GetRepoSettings(state, repo).DefaultProgram)
+	// Without the state, the file is read.
+	require.Equal(t, "codex", GetRepoSettings(nil, repo).DefaultProgram)
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, RepoFileName), []byte("default_program: [\n"), 0644))
+	_, err = LoadRepoSettings(repo)
+	require.Error(t, err)
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SharedConfig is the portion of the config that can be distributed to a team, with the task templates. It
+// leaves out secrets and machine-specific settings such as program paths, branch prefixes and per-repository
+// settings.
+// Fields are pointers so that importing only overrides the settings present in the shared file.
+type SharedConfig struct {
+	AutoYes            *bool           `json:"auto_yes,omitempty"`
+	DaemonPollInterval *int            `json:"daemon_poll_interval,omitempty"`
+	CommitTrailers     []CommitTrailer `json:"commit_trailers,omitempty"`
+	StallTimeout       *int            `json:"stall_timeout,omitempty"`
+	NudgePrompt        *string         `json:"nudge_prompt,omitempty"`
+	// Keymap, Hooks and WindowSizes replace the config's as a whole when present.
+	Keymap      map[string][]string   `json:"keymap,omitempty"`
+	Hooks       map[string][]string   `json:"hooks,omitempty"`
+	WindowSizes map[string]WindowSize `json:"window_sizes,omitempty"`
+	// Tasks are the task templates in the tasks directory, keyed by file name. They're read and written with
+	// ReadTasks and WriteTasks, since they're files of their own rather than part of the config.
+	Tasks map[string]string `json:"tasks,omitempty"`
+}
+
+// Export returns the shareable portion of the config.
+func (c *Config) Export() *SharedConfig {
+	shared := &SharedConfig{
+		AutoYes:            &c.AutoYes,
+		DaemonPollInterval: &c.DaemonPollInterval,
+		CommitTrailers:     c.CommitTrailers,
+		StallTimeout:       &c.StallTimeout,
+		Keymap:             c.Keymap,
+		Hooks:              c.Hooks,
+		WindowSizes:        c.WindowSizes,
+	}
+	if c.NudgePrompt != "" {
+		shared.NudgePrompt = &c.NudgePrompt
+	}
+	return shared
+}
+
+// Import overrides the config with the settings present in shared.
+func (c *Config) Import(shared *SharedConfig) {
+	if shared.AutoYes != nil {
+		c.AutoYes = *shared.AutoYes
+	}
+	if shared.DaemonPollInterval != nil {
+		c.DaemonPollInterval = *shared.DaemonPollInterval
+	}
+	if shared.CommitTrailers != nil {
+		c.CommitTrailers = shared.CommitTrailers
+	}
+	if shared.StallTimeout != nil {
+		c.StallTimeout = *shared.StallTimeout
+	}
+	if shared.NudgePrompt != nil {
+		c.NudgePrompt = *shared.NudgePrompt
+	}
+	if shared.Keymap != nil {
+		c.Keymap = shared.Keymap
+	}
+	if shared.Hooks != nil {
+		c.Hooks = shared.Hooks
+	}
+	if shared.WindowSizes != nil {
+		c.WindowSizes = shared.WindowSizes
+	}
+}
+
+// ReadTasks adds the task templates in dir, markdown files, to the shared config. A missing dir has none.
+func (s *SharedConfig) ReadTasks(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read tasks: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".md" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read task %s: %w", entry.Name(), err)
+		}
+		if s.Tasks == nil {
+			s.Tasks = make(map[string]string)
+		}
+		s.Tasks[entry.Name()] = string(data)
+	}
+	return nil
+}
+
+// WriteTasks writes the shared config's task templates to dir, replacing those of the same name. The other
+// tasks in dir are kept.
+func (s *SharedConfig) WriteTasks(dir string) error {
+	if len(s.Tasks) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create tasks directory: %w", err)
+	}
+	for name, task := range s.Tasks {
+		// Names come from a file that may not have been exported by claude-squad.
+		if name != filepath.Base(name) || filepath.Ext(name) != ".md" {
+			return fmt.Errorf("invalid task name %q", name)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(task), 0644); err != nil {
+			return fmt.Errorf("failed to write task %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// ParseSharedConfig parses a file written by exporting a config.
+func ParseSharedConfig(data []byte) (*SharedConfig, error) {
+	var shared SharedConfig
+	if err := json.Unmarshal(data, &shared); err != nil {
+		return nil, fmt.Errorf("failed to parse shared config: %w", err)
+	}
+	return &shared, nil
+}
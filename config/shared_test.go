@@ -0,0 +1,76 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportLeavesOutMachineSpecificSettings(t *testing.T) {
+	cfg := &Config{
+		DefaultProgram: "/usr/local/bin/claude",
+		BranchPrefix:   "alice/",
+		StallTimeout:   120,
+		Repos: map[string]RepoConfig{
+			"/home/alice/repo": {Push: PushConfig{SSHKey: "/home/alice/.ssh/id_ed25519"}},
+		},
+	}
+
+	data, err := json.Marshal(cfg.Export())
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "claude")
+	require.NotContains(t, string(data), "alice")
+	require.Contains(t, string(data), `"stall_timeout":120`)
+}
+
+func TestImportOnlyOverridesPresentSettings(t *testing.T) {
+	cfg := &Config{AutoYes: true, StallTimeout: 300, BranchPrefix: "bob/"}
+
+	shared, err := ParseSharedConfig([]byte(`{"stall_timeout": 60, "commit_trailers": [{"key": "Agent", "value": "{program}"}]}`))
+	require.NoError(t, err)
+	cfg.Import(shared)
+
+	require.True(t, cfg.AutoYes)
+	require.Equal(t, 60, cfg.StallTimeout)
+	require.Equal(t, "bob/", cfg.BranchPrefix)
+	require.Equal(t, []CommitTrailer{{Key: "Agent", Value: "{program}"}}, cfg.CommitTrailers)
+}
+
+func TestSharedConfigRoundTrip(t *testing.T) {
+	cfg := &Config{
+		StallTimeout: 120,
+		Keymap:       map[string][]string{"up": {"up", "e"}, "down": {"down", "n"}},
+		Hooks:        map[string][]string{"status_ready": {"notify-send ready"}},
+		WindowSizes:  map[string]WindowSize{"aider": {Width: 200}},
+	}
+	tasksDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(tasksDir, "review.md"), []byte("# Review\nReview the diff."), 0644))
+
+	shared := cfg.Export()
+	require.NoError(t, shared.ReadTasks(tasksDir))
+	data, err := json.Marshal(shared)
+	require.NoError(t, err)
+
+	imported, err := ParseSharedConfig(data)
+	require.NoError(t, err)
+	other := &Config{}
+	other.Import(imported)
+	otherTasksDir := t.TempDir()
+	require.NoError(t, imported.WriteTasks(otherTasksDir))
+
+	require.Equal(t, cfg.StallTimeout, other.StallTimeout)
+	require.Equal(t, cfg.Keymap, other.Keymap)
+	require.Equal(t, cfg.Hooks, other.Hooks)
+	require.Equal(t, cfg.WindowSizes, other.WindowSizes)
+	task, err := os.ReadFile(filepath.Join(otherTasksDir, "review.md"))
+	require.NoError(t, err)
+	require.Equal(t, "# Review\nReview the diff.", string(task))
+}
+
+func TestWriteTasksRejectsPaths(t *testing.T) {
+	shared := &SharedConfig{Tasks: map[string]string{"../evil.md": "x"}}
+	require.Error(t, shared.WriteTasks(t.TempDir()))
+}
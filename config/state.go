@@ -2,10 +2,14 @@ package config
 
 import (
 	"claude-squad/log"
+	"claude-squad/telemetry"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -13,6 +17,12 @@ import (
 const (
 	StateFileName     = "state.json"
 	InstancesFileName = "instances.json"
+	// SnapshotsDirName is the directory, in the state directory, the state snapshots are kept in, one
+	// directory each.
+	SnapshotsDirName = "snapshots"
+	// DiffsDirName is the directory, in the state directory, the instances' diffs are kept in, one file each,
+	// which keeps the state file small and fast to write.
+	DiffsDirName = "diffs"
 )
 
 // RepositoryData represents a known repository with metadata
@@ -21,12 +31,46 @@ type RepositoryData struct {
 	Path string `json:"path"`
 	// Name is the display name of the repository (typically the directory name)
 	Name string `json:"name"`
+	// Alias is the name the repository is shown by instead of Name, e.g. to tell apart two repositories named
+	// "api"
+	Alias string `json:"alias,omitempty"`
+	// Pinned repositories are listed first, and their tabs come first
+	Pinned bool `json:"pinned,omitempty"`
+	// DefaultProgram is the program new instances in the repository run, and DefaultArgs the arguments they
+	// run it with, e.g. "claude" and "--model sonnet". They override the repository's own settings and the
+	// config's default_program, but not --program.
+	DefaultProgram string `json:"default_program,omitempty"`
+	DefaultArgs    string `json:"default_args,omitempty"`
 	// LastAccessed is the last time this repository was accessed
 	LastAccessed time.Time `json:"last_accessed"`
 	// CreatedAt is when this repository was first added to the system
 	CreatedAt time.Time `json:"created_at"`
 	// InstanceCount is the number of instances currently associated with this repository
 	InstanceCount int `json:"instance_count"`
+	// LastSelectedInstance is the title of the instance last selected in this repository
+	LastSelectedInstance string `json:"last_selected_instance,omitempty"`
+	// Settings are the repository's own settings from its RepoFileName, loaded when it was added
+	Settings *RepoSettings `json:"settings,omitempty"`
+}
+
+// DisplayName returns the name the repository is shown by: its alias, or else its name
+func (r RepositoryData) DisplayName() string {
+	if r.Alias != "" {
+		return r.Alias
+	}
+	return r.Name
+}
+
+// ProgramFor returns the command new instances in the repository run: its default program, or else program,
+// followed by its default arguments
+func (r RepositoryData) ProgramFor(program string) string {
+	if r.DefaultProgram != "" {
+		program = r.DefaultProgram
+	}
+	if r.DefaultArgs != "" {
+		program += " " + r.DefaultArgs
+	}
+	return program
 }
 
 // InstanceStorage handles instance-related operations
@@ -37,6 +81,9 @@ type InstanceStorage interface {
 	GetInstances() json.RawMessage
 	// DeleteAllInstances removes all stored instances
 	DeleteAllInstances() error
+	// TakeMergedInstances returns the titles of the instances another process added, changed or removed that
+	// saves took in since it was last called
+	TakeMergedInstances() []string
 }
 
 // RepositoryStorage handles repository-related operations
@@ -47,10 +94,14 @@ type RepositoryStorage interface {
 	AddRepository(repo RepositoryData) error
 	// RemoveRepository removes a repository from the state
 	RemoveRepository(path string) error
+	// CleanupInvalidRepositories removes the repositories that don't exist anymore and returns how many
+	CleanupInvalidRepositories() (int, error)
 	// UpdateRepository updates an existing repository's metadata
 	UpdateRepository(repo RepositoryData) error
 	// GetRepository returns a specific repository by path
 	GetRepository(path string) (*RepositoryData, error)
+	// GetRepositoriesSortedByLastAccessed returns repositories sorted by last accessed time (most recent first)
+	GetRepositoriesSortedByLastAccessed() []RepositoryData
 	// GetSelectedRepository returns the currently selected repository path
 	GetSelectedRepository() string
 	// SetSelectedRepository sets the currently selected repository
@@ -59,14 +110,67 @@ type RepositoryStorage interface {
 	UpdateRepositoryInstanceCount(path string, count int) error
 	// UpdateRepositoryLastAccessed updates the last accessed time for a repository
 	UpdateRepositoryLastAccessed(path string) error
+	// SetSelection records the selected repository and the instance last selected in it
+	SetSelection(path, instanceTitle string) error
+	// SetRepositoryAlias sets the name a repository is shown by, or clears it if alias is empty
+	SetRepositoryAlias(path, alias string) error
+	// GetRepositoryAliases returns the repositories' aliases, keyed by path
+	GetRepositoryAliases() map[string]string
+	// SetRepositoryPinned pins or unpins a repository
+	SetRepositoryPinned(path string, pinned bool) error
+	// SetRepositoryProgram sets the program and arguments new instances in a repository run, or clears them
+	SetRepositoryProgram(path, program, args string) error
+	// SetRepositoryProgramAllowed records whether new instances in a repository may run the program its
+	// RepoFileName sets
+	SetRepositoryProgramAllowed(path string, allowed bool) error
+	// GetWorkspaces returns all workspaces
+	GetWorkspaces() []Workspace
+	// GetWorkspace returns a specific workspace by name
+	GetWorkspace(name string) (*Workspace, error)
+	// SaveWorkspace adds a workspace, or replaces the one of the same name
+	SaveWorkspace(workspace Workspace) error
+	// RemoveWorkspace removes a workspace
+	RemoveWorkspace(name string) error
+	// GetSelectedWorkspace returns the name of the currently selected workspace, empty for all repositories
+	GetSelectedWorkspace() string
+	// SetSelectedWorkspace sets the currently selected workspace, empty for all repositories
+	SetSelectedWorkspace(name string) error
+	// GetDiscoveredRepositories returns the repositories found by the last scan of the discovery roots
+	GetDiscoveredRepositories() []string
+	// SetDiscoveredRepositories replaces the repositories found by scanning the discovery roots
+	SetDiscoveredRepositories(paths []string) error
 }
 
 // AppState handles application-level state
 type AppState interface {
-	// GetHelpScreensSeen returns the bitmask of seen help screens
-	GetHelpScreensSeen() uint32
-	// SetHelpScreensSeen updates the bitmask of seen help screens
-	SetHelpScreensSeen(seen uint32) error
+	// GetHints returns the contextual hints the user was shown
+	GetHints() Hints
+	// RecordHintShown records that the hint id was shown at now
+	RecordHintShown(id string, now time.Time) error
+	// DismissHint records that the user dismissed the hint id, which isn't shown again
+	DismissHint(id string) error
+	// GetPreferences returns the user's preferences
+	GetPreferences() Preferences
+	// SetPreferences saves the user's preferences
+	SetPreferences(preferences Preferences) error
+	// GetWeeklyReview returns when the weekly review was last shown
+	GetWeeklyReview() WeeklyReview
+	// SetWeeklyReview records that the weekly review was shown
+	SetWeeklyReview(review WeeklyReview) error
+}
+
+// WeeklyReview is when the weekly review was last shown, and what it measured then, to compare with.
+type WeeklyReview struct {
+	ShownAt time.Time `json:"shown_at,omitempty"`
+	// WorktreeBytes is the disk the instances' worktrees used.
+	WorktreeBytes int64 `json:"worktree_bytes,omitempty"`
+}
+
+// Due returns true if the weekly review wasn't shown yet in the week of now. Weeks start on Monday.
+func (r WeeklyReview) Due(now time.Time) bool {
+	year, week := now.ISOWeek()
+	shownYear, shownWeek := r.ShownAt.ISOWeek()
+	return r.ShownAt.IsZero() || year != shownYear || week != shownWeek
 }
 
 // StateManager combines instance storage, repository storage, and app state management
@@ -74,12 +178,15 @@ type StateManager interface {
 	InstanceStorage
 	RepositoryStorage
 	AppState
+	// Flush saves the mutations batched by write-behind that weren't saved yet
+	Flush() error
 }
 
 // State represents the application state that persists between sessions
 type State struct {
-	// HelpScreensSeen is a bitmask tracking which help screens have been shown
-	HelpScreensSeen uint32 `json:"help_screens_seen"`
+	// HelpScreensSeen is the bitmask of the help screens shown once, before hints replaced them. It's only
+	// read to migrate older states.
+	HelpScreensSeen uint32 `json:"help_screens_seen,omitempty"`
 	// Instances stores the serialized instance data as raw JSON
 	InstancesData json.RawMessage `json:"instances"`
 	// Repositories stores the list of known repositories with metadata
@@ -88,14 +195,34 @@ type State struct {
 	SelectedRepository string `json:"selected_repository"`
 	// StateVersion tracks the schema version for migration purposes
 	StateVersion int `json:"state_version"`
-}
+	// Preferences are the choices the user made in the UI
+	Preferences Preferences `json:"preferences"`
+	// WeeklyReview is when the weekly review was last shown
+	WeeklyReview WeeklyReview `json:"weekly_review"`
+	// Hints are the contextual hints the user was shown
+	Hints Hints `json:"hints"`
+	// Workspaces are the named sets of repositories the view can be switched between
+	Workspaces []Workspace `json:"workspaces"`
+	// SelectedWorkspace is the name of the currently selected workspace, empty for all repositories
+	SelectedWorkspace string `json:"selected_workspace"`
+	// DiscoveredRepositories are the repositories found by the last scan of the discovery roots, registered or not
+	DiscoveredRepositories []string `json:"discovered_repositories"`
 
-const CurrentStateVersion = 1
+	// file is the version of the state file this state was last loaded from or saved to, if any
+	file *stateFile
+	// mergedInstances are the titles of the instances another process changed that saves took in
+	mergedInstances []string
+	// readOnly is why the state can't be saved, if it can't: the state file couldn't be decrypted, and saving
+	// would replace it
+	readOnly error
+	// writeBehind defers saves until Flush; dirty is set when a mutation hasn't been saved yet.
+	writeBehind bool
+	dirty       bool
+}
 
 // DefaultState returns the default state
 func DefaultState() *State {
 	return &State{
-		HelpScreensSeen:    0,
 		InstancesData:      json.RawMessage("[]"),
 		Repositories:       make([]RepositoryData, 0),
 		SelectedRepository: "",
@@ -103,21 +230,31 @@ func DefaultState() *State {
 	}
 }
 
-// LoadState loads the state from disk. If it cannot be done, we return the default state.
+// LoadState loads the state from disk. If it cannot be done, we return the default state. The state file is
+// locked while it's read, so a state being saved by another process isn't read half-written.
 func LoadState() *State {
-	configDir, err := GetConfigDir()
+	stateDir, err := GetStateDir()
 	if err != nil {
-		log.ErrorLog.Printf("failed to get config directory: %v", err)
+		log.ErrorLog.Printf("failed to get state directory: %v", err)
 		return DefaultState()
 	}
 
-	statePath := filepath.Join(configDir, StateFileName)
-	data, err := os.ReadFile(statePath)
+	lock, err := lockState(stateDir)
+	if err != nil {
+		log.WarningLog.Printf("loading the state without locking it: %v", err)
+	}
+	defer lock.unlock()
+	return loadState(filepath.Join(stateDir, StateFileName), lock)
+}
+
+// loadState loads the state from statePath while holding lock, which may be nil.
+func loadState(statePath string, lock *stateLock) *State {
+	f, err := os.Open(statePath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			// Create and save default state if file doesn't exist
 			defaultState := DefaultState()
-			if saveErr := SaveState(defaultState); saveErr != nil {
+			if saveErr := saveState(defaultState, statePath, lock); saveErr != nil {
 				log.WarningLog.Printf("failed to save default state: %v", saveErr)
 			}
 			return defaultState
@@ -126,44 +263,140 @@ func LoadState() *State {
 		log.WarningLog.Printf("failed to get state file: %v", err)
 		return DefaultState()
 	}
+	defer f.Close()
 
-	var state State
-	if err := json.Unmarshal(data, &state); err != nil {
-		log.ErrorLog.Printf("failed to parse state file: %v", err)
-		return DefaultState()
+	if info, err := f.Stat(); err == nil && info.Size() > maxStateSize {
+		f.Close()
+		aside, err := setAsideState(statePath, "oversized")
+		if err != nil {
+			log.ErrorLog.Printf("state file %s is %d MB, over the %d MB limit, and it can't be set aside: %v",
+				statePath, info.Size()>>20, maxStateSize>>20, err)
+			return DefaultState()
+		}
+		log.ErrorLog.Printf("state file %s is %d MB, over the %d MB limit; it was moved to %s and the state reset",
+			statePath, info.Size()>>20, maxStateSize>>20, aside)
+		return repairState(DefaultState(), statePath, lock)
 	}
 
-	// Perform state migration if needed
-	migratedState := migrateState(&state)
-	
-	// Save migrated state if changes were made
-	if migratedState.StateVersion != state.StateVersion {
-		if saveErr := SaveState(migratedState); saveErr != nil {
+	r, err := openState(f)
+	if errors.Is(err, errNoStateKey) {
+		// The key may only be unavailable for now, e.g. while the keychain is locked, so the state file is left
+		// as is, and the empty state loaded instead can't be saved over it.
+		log.ErrorLog.Printf("failed to read state file: %v", err)
+		state := DefaultState()
+		state.readOnly = fmt.Errorf("the state file %s is encrypted and can't be read: %w", statePath, err)
+		return state
+	}
+	// An encrypted state file that's damaged is set aside like a plain one, with nothing to salvage.
+	state := DefaultState()
+	if err == nil {
+		state, err = decodeState(r)
+	}
+	if err != nil {
+		f.Close()
+		aside, asideErr := setAsideState(statePath, "damaged")
+		if asideErr != nil {
+			// Don't overwrite a state file that can't be kept.
+			log.ErrorLog.Printf("failed to parse state file: %v (%v)", err, asideErr)
+			return DefaultState()
+		}
+		var instances []json.RawMessage
+		_ = json.Unmarshal(state.InstancesData, &instances)
+		log.ErrorLog.Printf("failed to parse state file: %v; salvaged %d instances, the original was moved to %s",
+			err, len(instances), aside)
+		return repairState(state, statePath, lock)
+	}
+	state.rememberLoaded(lock)
+
+	// Perform state migration if needed. The state file is backed up first, since it's rewritten in the new
+	// version's schema.
+	version := state.StateVersion
+	if err := migrateState(state, CurrentStateVersion); err != nil {
+		log.ErrorLog.Printf("%v", err)
+	}
+	if state.StateVersion != version {
+		if err := backUpBeforeMigration(statePath, version); err != nil {
+			log.WarningLog.Printf("failed to back up the state before migrating it: %v", err)
+		}
+		if saveErr := saveState(state, statePath, lock); saveErr != nil {
 			log.WarningLog.Printf("failed to save migrated state: %v", saveErr)
 		}
 	}
 
-	return migratedState
+	return state
+}
+
+// repairState migrates and saves the state salvaged from a state file that couldn't be loaded as is.
+func repairState(state *State, statePath string, lock *stateLock) *State {
+	if err := migrateState(state, CurrentStateVersion); err != nil {
+		log.ErrorLog.Printf("%v", err)
+	}
+	if err := saveState(state, statePath, lock); err != nil {
+		log.WarningLog.Printf("failed to save repaired state: %v", err)
+	}
+	return state
 }
 
-// SaveState saves the state to disk
+// SaveState saves the state to disk. The state file is locked while it's written. If another process saved
+// it since this state was loaded or last saved, its changes are taken in first: the fields only it changed,
+// and the instances and repositories it added, removed or changed that this state didn't change too. Where
+// both changed the same field or entry, this state's change wins.
 func SaveState(state *State) error {
-	configDir, err := GetConfigDir()
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return fmt.Errorf("failed to get state directory: %w", err)
+	}
+
+	lock, err := lockState(stateDir)
 	if err != nil {
-		return fmt.Errorf("failed to get config directory: %w", err)
+		return err
 	}
+	defer lock.unlock()
+	return saveState(state, filepath.Join(stateDir, StateFileName), lock)
+}
 
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
+// saveState saves the state to statePath while holding lock, which may be nil.
+func saveState(state *State, statePath string, lock *stateLock) (err error) {
+	span := telemetry.Start("state.save")
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
+	if state.readOnly != nil {
+		return state.readOnly
+	}
+	if err := state.mergeConcurrentChanges(statePath, lock); err != nil {
+		log.WarningLog.Printf("overwriting changes saved to the state by another process: %v", err)
+	}
+	data, fields, err := encodeState(state)
+	if err != nil {
+		return err
 	}
 
-	statePath := filepath.Join(configDir, StateFileName)
-	data, err := json.MarshalIndent(state, "", "  ")
+	span.SetAttr("bytes", strconv.Itoa(len(data)))
+	configDir, err := GetConfigDir()
 	if err != nil {
-		return fmt.Errorf("failed to marshal state: %w", err)
+		configDir = filepath.Dir(statePath)
+	}
+	if data, err = sealState(configDir, data); err != nil {
+		return err
 	}
+	if err := writeStateFile(statePath, data, true); err != nil {
+		return err
+	}
+	state.file = &stateFile{fields: fields, revision: lock.bump()}
+	if err := sealStateCopies(configDir, statePath); err != nil {
+		log.WarningLog.Printf("failed to encrypt the copies of the state: %v", err)
+	}
+	return nil
+}
 
-	return os.WriteFile(statePath, data, 0644)
+// ReadOnly returns why the state can't be saved, or nil if it can. A state file that's encrypted with a key
+// that isn't available, e.g. while the keychain is locked, is loaded as an empty state that can't be saved,
+// rather than replaced by it. Long-running processes refuse to start with it.
+func (s *State) ReadOnly() error {
+	return s.readOnly
 }
 
 // Helper functions for repository management
@@ -173,7 +406,7 @@ func (s *State) UpdateRepositoryLastAccessed(path string) error {
 	for i, repo := range s.Repositories {
 		if repo.Path == path {
 			s.Repositories[i].LastAccessed = time.Now()
-			return SaveState(s)
+			return s.save()
 		}
 	}
 	return fmt.Errorf("repository not found: %s", path)
@@ -184,20 +417,141 @@ func (s *State) UpdateRepositoryInstanceCount(path string, count int) error {
 	for i, repo := range s.Repositories {
 		if repo.Path == path {
 			s.Repositories[i].InstanceCount = count
-			return SaveState(s)
+			return s.save()
 		}
 	}
 	return fmt.Errorf("repository not found: %s", path)
 }
 
+// SetSelection records that the repository at path is selected, and that the instance titled instanceTitle
+// was last selected in it, so both are selected again on startup. The state is only saved if either changed.
+func (s *State) SetSelection(path, instanceTitle string) error {
+	for i, repo := range s.Repositories {
+		if repo.Path == path {
+			if s.SelectedRepository == path && repo.LastSelectedInstance == instanceTitle {
+				return nil
+			}
+			s.SelectedRepository = path
+			s.Repositories[i].LastSelectedInstance = instanceTitle
+			return s.save()
+		}
+	}
+	return fmt.Errorf("repository not found: %s", path)
+}
+
+// SetRepositoryAlias sets the name the repository at path is shown by, or clears it if alias is empty.
+// Aliases must be unique, so repositories can be told apart by them.
+func (s *State) SetRepositoryAlias(path, alias string) error {
+	alias = strings.TrimSpace(alias)
+	index := -1
+	for i, repo := range s.Repositories {
+		if repo.Path == path {
+			index = i
+		} else if alias != "" && repo.Alias == alias {
+			return fmt.Errorf("alias %q is already used by %s", alias, repo.Path)
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("repository not found: %s", path)
+	}
+	if s.Repositories[index].Alias == alias {
+		return nil
+	}
+	s.Repositories[index].Alias = alias
+	return s.save()
+}
+
+// GetRepositoryAliases returns the repositories' aliases, keyed by path
+func (s *State) GetRepositoryAliases() map[string]string {
+	aliases := make(map[string]string)
+	for _, repo := range s.Repositories {
+		if repo.Alias != "" {
+			aliases[repo.Path] = repo.Alias
+		}
+	}
+	return aliases
+}
+
+// SetRepositoryPinned pins or unpins the repository at path
+func (s *State) SetRepositoryPinned(path string, pinned bool) error {
+	for i, repo := range s.Repositories {
+		if repo.Path == path {
+			if repo.Pinned == pinned {
+				return nil
+			}
+			s.Repositories[i].Pinned = pinned
+			return s.save()
+		}
+	}
+	return fmt.Errorf("repository not found: %s", path)
+}
+
+// SetRepositoryProgramAllowed records whether new instances in the repository at path may run the program its
+// RepoFileName sets.
+func (s *State) SetRepositoryProgramAllowed(path string, allowed bool) error {
+	for i, repo := range s.Repositories {
+		if repo.Path == path {
+			// The settings are copied, since they may be shared with a copy of the repository.
+			settings := RepoSettings{}
+			if repo.Settings != nil {
+				settings = *repo.Settings
+			}
+			settings.ProgramAllowed = &allowed
+			s.Repositories[i].Settings = &settings
+			return s.save()
+		}
+	}
+	return fmt.Errorf("repository not found: %s", path)
+}
+
+// SetRepositoryProgram sets the program and arguments new instances in the repository at path run. Empty
+// ones fall back to the repository's settings and the config.
+func (s *State) SetRepositoryProgram(path, program, args string) error {
+	program, args = strings.TrimSpace(program), strings.TrimSpace(args)
+	if program != "" && len(strings.Fields(program)) > 1 {
+		return fmt.Errorf("program %q cannot contain spaces, give its arguments separately", program)
+	}
+	for i, repo := range s.Repositories {
+		if repo.Path == path {
+			if repo.DefaultProgram == program && repo.DefaultArgs == args {
+				return nil
+			}
+			s.Repositories[i].DefaultProgram = program
+			s.Repositories[i].DefaultArgs = args
+			return s.save()
+		}
+	}
+	return fmt.Errorf("repository not found: %s", path)
+}
+
+// RepositoryInstances is implemented by the instance storage, which knows which repository each instance
+// belongs to
+type RepositoryInstances interface {
+	// CleanupOrphanedInstances removes the instances whose repositories aren't known anymore, along with
+	// their sessions and worktrees
+	CleanupOrphanedInstances() (*CleanupReport, error)
+	// GetInstanceCountByRepository returns the number of instances of each repository, keyed by its path
+	GetInstanceCountByRepository() (map[string]int, error)
+}
+
+// CleanupReport describes what a cleanup removed and what it left in place
+type CleanupReport struct {
+	// Repositories are the paths of the repositories that were removed
+	Repositories []string
+	// Instances are the titles of the instances that were removed with their repositories
+	Instances []string
+	// Kept describes what was left in place and why, e.g. the worktrees of repositories that are gone
+	Kept []string
+}
+
 // RepositoryManager provides high-level repository management operations
 type RepositoryManager struct {
 	state   StateManager
-	storage InstanceStorage
+	storage RepositoryInstances
 }
 
 // NewRepositoryManager creates a new repository manager
-func NewRepositoryManager(state StateManager, storage InstanceStorage) *RepositoryManager {
+func NewRepositoryManager(state StateManager, storage RepositoryInstances) *RepositoryManager {
 	return &RepositoryManager{
 		state:   state,
 		storage: storage,
@@ -236,32 +590,70 @@ func (rm *RepositoryManager) AddRepositoryFromPath(path string) (*RepositoryData
 	return &repoData, nil
 }
 
-// RemoveRepositoryAndCleanup removes a repository and cleans up orphaned instances
-func (rm *RepositoryManager) RemoveRepositoryAndCleanup(path string) error {
+// RemoveRepositoryAndCleanup removes a repository and cleans up its instances with their sessions and worktrees
+func (rm *RepositoryManager) RemoveRepositoryAndCleanup(path string) (*CleanupReport, error) {
 	// Remove from state
 	if err := rm.state.RemoveRepository(path); err != nil {
-		return fmt.Errorf("failed to remove repository: %w", err)
+		return nil, fmt.Errorf("failed to remove repository: %w", err)
 	}
-	
-	// Clean up orphaned instances if storage is available
-	if rm.storage != nil {
-		// This would require the storage to implement cleanup
-		// For now, we'll leave this as a manual operation
+
+	report, err := rm.cleanupInstances()
+	if err != nil {
+		return nil, err
 	}
-	
-	return nil
+	report.Repositories = append([]string{path}, report.Repositories...)
+	return report, nil
+}
+
+// Cleanup removes the repositories that don't exist anymore, then the instances of repositories that aren't
+// known anymore, with their sessions and worktrees
+func (rm *RepositoryManager) Cleanup() (*CleanupReport, error) {
+	var removed []string
+	before := rm.state.GetRepositories()
+	if _, err := rm.state.CleanupInvalidRepositories(); err != nil {
+		return nil, fmt.Errorf("failed to cleanup invalid repositories: %w", err)
+	}
+	for _, repo := range before {
+		if _, err := rm.state.GetRepository(repo.Path); err != nil {
+			removed = append(removed, repo.Path)
+		}
+	}
+
+	report, err := rm.cleanupInstances()
+	if err != nil {
+		return nil, err
+	}
+	report.Repositories = append(removed, report.Repositories...)
+	return report, nil
+}
+
+// cleanupInstances cleans up the instances of repositories that aren't known anymore, if storage is available
+func (rm *RepositoryManager) cleanupInstances() (*CleanupReport, error) {
+	if rm.storage == nil {
+		return &CleanupReport{}, nil
+	}
+	report, err := rm.storage.CleanupOrphanedInstances()
+	if err != nil {
+		return nil, fmt.Errorf("failed to cleanup orphaned instances: %w", err)
+	}
+	return report, nil
 }
 
 // GetRepositoriesWithCounts returns repositories with current instance counts
 func (rm *RepositoryManager) GetRepositoriesWithCounts() ([]RepositoryData, error) {
-	repos := rm.state.GetRepositories()
-	
+	repos := append([]RepositoryData(nil), rm.state.GetRepositories()...)
+
 	// Update instance counts if storage is available
 	if rm.storage != nil {
-		// This would require implementing instance counting in storage
-		// For now, return repos as-is
+		counts, err := rm.storage.GetInstanceCountByRepository()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get instance counts: %w", err)
+		}
+		for i := range repos {
+			repos[i].InstanceCount = counts[repos[i].Path]
+		}
 	}
-	
+
 	return repos, nil
 }
 
@@ -270,10 +662,11 @@ func (s *State) GetRepositoriesSortedByLastAccessed() []RepositoryData {
 	repos := make([]RepositoryData, len(s.Repositories))
 	copy(repos, s.Repositories)
 	
-	// Simple bubble sort by LastAccessed (descending)
+	// Simple bubble sort by LastAccessed (descending), pinned repositories first
 	for i := 0; i < len(repos)-1; i++ {
 		for j := 0; j < len(repos)-i-1; j++ {
-			if repos[j].LastAccessed.Before(repos[j+1].LastAccessed) {
+			if (!repos[j].Pinned && repos[j+1].Pinned) ||
+				(repos[j].Pinned == repos[j+1].Pinned && repos[j].LastAccessed.Before(repos[j+1].LastAccessed)) {
 				repos[j], repos[j+1] = repos[j+1], repos[j]
 			}
 		}
@@ -339,7 +732,7 @@ func (s *State) CleanupInvalidRepositories() (int, error) {
 	
 	if removedCount > 0 {
 		s.Repositories = validRepos
-		if err := SaveState(s); err != nil {
+		if err := s.save(); err != nil {
 			return removedCount, fmt.Errorf("failed to save state after cleanup: %w", err)
 		}
 	}
@@ -376,6 +769,12 @@ func CreateRepositoryData(path string) (RepositoryData, error) {
 		return RepositoryData{}, fmt.Errorf("failed to get absolute path: %w", err)
 	}
 	
+	// A broken settings file shouldn't keep the repository from being added.
+	settings, err := LoadRepoSettings(absPath)
+	if err != nil {
+		log.WarningLog.Printf("ignoring the repository settings: %v", err)
+	}
+
 	now := time.Now()
 	return RepositoryData{
 		Path:          absPath,
@@ -383,6 +782,7 @@ func CreateRepositoryData(path string) (RepositoryData, error) {
 		LastAccessed:  now,
 		CreatedAt:     now,
 		InstanceCount: 0,
+		Settings:      settings,
 	}, nil
 }
 
@@ -417,7 +817,7 @@ func FindRepositoryForPath(path string) (string, error) {
 // SaveInstances saves the raw instance data
 func (s *State) SaveInstances(instancesJSON json.RawMessage) error {
 	s.InstancesData = instancesJSON
-	return SaveState(s)
+	return s.save()
 }
 
 // GetInstances returns the raw instance data
@@ -428,20 +828,63 @@ func (s *State) GetInstances() json.RawMessage {
 // DeleteAllInstances removes all stored instances
 func (s *State) DeleteAllInstances() error {
 	s.InstancesData = json.RawMessage("[]")
-	return SaveState(s)
+	return s.save()
+}
+
+// TakeMergedInstances returns the titles of the instances another process added, changed or removed that
+// saves took in since it was last called. Whoever holds the instances reloads them, or its next save would
+// undo the other process's changes.
+func (s *State) TakeMergedInstances() []string {
+	titles := s.mergedInstances
+	s.mergedInstances = nil
+	return titles
 }
 
 // AppState interface implementation
 
-// GetHelpScreensSeen returns the bitmask of seen help screens
-func (s *State) GetHelpScreensSeen() uint32 {
-	return s.HelpScreensSeen
+// GetHints returns the contextual hints the user was shown
+func (s *State) GetHints() Hints {
+	return s.Hints
+}
+
+// RecordHintShown records that the hint id was shown at now
+func (s *State) RecordHintShown(id string, now time.Time) error {
+	s.Hints = s.Hints.with(id, func(record *HintRecord) {
+		record.Shown++
+		record.ShownAt = now
+	})
+	s.Hints.LastShownAt = now
+	return s.save()
+}
+
+// DismissHint records that the user dismissed the hint id, which isn't shown again
+func (s *State) DismissHint(id string) error {
+	s.Hints = s.Hints.with(id, func(record *HintRecord) {
+		record.Dismissed = true
+	})
+	return s.save()
+}
+
+// GetPreferences returns the user's preferences
+func (s *State) GetPreferences() Preferences {
+	return s.Preferences
+}
+
+// SetPreferences saves the user's preferences
+func (s *State) SetPreferences(preferences Preferences) error {
+	s.Preferences = preferences
+	return s.save()
 }
 
-// SetHelpScreensSeen updates the bitmask of seen help screens
-func (s *State) SetHelpScreensSeen(seen uint32) error {
-	s.HelpScreensSeen = seen
-	return SaveState(s)
+// GetWeeklyReview returns when the weekly review was last shown
+func (s *State) GetWeeklyReview() WeeklyReview {
+	return s.WeeklyReview
+}
+
+// SetWeeklyReview records that the weekly review was shown
+func (s *State) SetWeeklyReview(review WeeklyReview) error {
+	s.WeeklyReview = review
+	return s.save()
 }
 
 // RepositoryStorage interface implementation
@@ -456,15 +899,29 @@ func (s *State) AddRepository(repo RepositoryData) error {
 	// Check if repository already exists
 	for i, existing := range s.Repositories {
 		if existing.Path == repo.Path {
-			// Update existing repository
+			// Update existing repository, keeping the alias, pin and program the user chose, and their answer
+			// about the repository's own program if it's still the same
+			if repo.Alias == "" {
+				repo.Alias = existing.Alias
+			}
+			repo.Pinned = repo.Pinned || existing.Pinned
+			if repo.DefaultProgram == "" && repo.DefaultArgs == "" {
+				repo.DefaultProgram, repo.DefaultArgs = existing.DefaultProgram, existing.DefaultArgs
+			}
+			if repo.Settings != nil && repo.Settings.ProgramAllowed == nil && existing.Settings != nil &&
+				existing.Settings.DefaultProgram == repo.Settings.DefaultProgram {
+				settings := *repo.Settings
+				settings.ProgramAllowed = existing.Settings.ProgramAllowed
+				repo.Settings = &settings
+			}
 			s.Repositories[i] = repo
-			return SaveState(s)
+			return s.save()
 		}
 	}
 	
 	// Add new repository
 	s.Repositories = append(s.Repositories, repo)
-	return SaveState(s)
+	return s.save()
 }
 
 // RemoveRepository removes a repository from the state
@@ -480,8 +937,14 @@ func (s *State) RemoveRepository(path string) error {
 			if s.SelectedRepository == path {
 				s.SelectedRepository = ""
 			}
+
+			// Remove the repository from the workspaces it's in
+			for j := range s.Workspaces {
+				s.Workspaces[j].Repositories = slices.DeleteFunc(s.Workspaces[j].Repositories,
+					func(p string) bool { return p == path })
+			}
 			
-			return SaveState(s)
+			return s.save()
 		}
 	}
 	return fmt.Errorf("repository not found: %s", path)
@@ -492,7 +955,7 @@ func (s *State) UpdateRepository(repo RepositoryData) error {
 	for i, existing := range s.Repositories {
 		if existing.Path == repo.Path {
 			s.Repositories[i] = repo
-			return SaveState(s)
+			return s.save()
 		}
 	}
 	return fmt.Errorf("repository not found: %s", repo.Path)
@@ -530,7 +993,92 @@ func (s *State) SetSelectedRepository(path string) error {
 	}
 	
 	s.SelectedRepository = path
-	return SaveState(s)
+	return s.save()
+}
+
+// GetWorkspaces returns all workspaces
+func (s *State) GetWorkspaces() []Workspace {
+	return s.Workspaces
+}
+
+// GetWorkspace returns a specific workspace by name
+func (s *State) GetWorkspace(name string) (*Workspace, error) {
+	for _, workspace := range s.Workspaces {
+		if workspace.Name == name {
+			return &workspace, nil
+		}
+	}
+	return nil, fmt.Errorf("workspace not found: %s", name)
+}
+
+// SaveWorkspace adds a workspace, or replaces the one of the same name. Its repositories must be registered.
+func (s *State) SaveWorkspace(workspace Workspace) error {
+	if err := validateWorkspaceName(workspace.Name); err != nil {
+		return err
+	}
+	if len(workspace.Repositories) == 0 {
+		return fmt.Errorf("workspace %s has no repositories", workspace.Name)
+	}
+	for _, path := range workspace.Repositories {
+		if _, err := s.GetRepository(path); err != nil {
+			return err
+		}
+	}
+	for i, existing := range s.Workspaces {
+		if existing.Name == workspace.Name {
+			s.Workspaces[i] = workspace
+			return s.save()
+		}
+	}
+	s.Workspaces = append(s.Workspaces, workspace)
+	return s.save()
+}
+
+// RemoveWorkspace removes a workspace. Its repositories and their instances are kept.
+func (s *State) RemoveWorkspace(name string) error {
+	for i, workspace := range s.Workspaces {
+		if workspace.Name == name {
+			s.Workspaces = append(s.Workspaces[:i], s.Workspaces[i+1:]...)
+			if s.SelectedWorkspace == name {
+				s.SelectedWorkspace = ""
+			}
+			return s.save()
+		}
+	}
+	return fmt.Errorf("workspace not found: %s", name)
+}
+
+// GetSelectedWorkspace returns the name of the currently selected workspace, empty for all repositories
+func (s *State) GetSelectedWorkspace() string {
+	return s.SelectedWorkspace
+}
+
+// SetSelectedWorkspace sets the currently selected workspace, empty for all repositories
+func (s *State) SetSelectedWorkspace(name string) error {
+	if name != "" {
+		if _, err := s.GetWorkspace(name); err != nil {
+			return err
+		}
+	}
+	if s.SelectedWorkspace == name {
+		return nil
+	}
+	s.SelectedWorkspace = name
+	return s.save()
+}
+
+// GetDiscoveredRepositories returns the repositories found by the last scan of the discovery roots
+func (s *State) GetDiscoveredRepositories() []string {
+	return s.DiscoveredRepositories
+}
+
+// SetDiscoveredRepositories replaces the repositories found by scanning the discovery roots
+func (s *State) SetDiscoveredRepositories(paths []string) error {
+	if slices.Equal(s.DiscoveredRepositories, paths) {
+		return nil
+	}
+	s.DiscoveredRepositories = paths
+	return s.save()
 }
 
 // BatchUpdateRepositories performs multiple repository operations atomically
@@ -543,29 +1091,7 @@ func (s *State) BatchUpdateRepositories(operations []func(*State) error) error {
 	}
 	
 	// Save state once at the end
-	return SaveState(s)
-}
-
-// GetRepositoryStats returns statistics about repository usage
-func (s *State) GetRepositoryStats() map[string]interface{} {
-	stats := make(map[string]interface{})
-	
-	totalRepos := len(s.Repositories)
-	totalInstances := 0
-	
-	for _, repo := range s.Repositories {
-		totalInstances += repo.InstanceCount
-	}
-	
-	stats["total_repositories"] = totalRepos
-	stats["total_instances"] = totalInstances
-	stats["selected_repository"] = s.SelectedRepository
-	
-	if totalRepos > 0 {
-		stats["average_instances_per_repo"] = float64(totalInstances) / float64(totalRepos)
-	}
-	
-	return stats
+	return s.save()
 }
 
 // CompactRepositories removes repositories with zero instances and validates remaining ones
@@ -598,30 +1124,10 @@ func (s *State) CompactRepositories() (int, error) {
 			s.SelectedRepository = ""
 		}
 		
-		if err := SaveState(s); err != nil {
+		if err := s.save(); err != nil {
 			return removedCount, fmt.Errorf("failed to save state after compacting: %w", err)
 		}
 	}
 	
 	return removedCount, nil
 }
-
-// migrateState handles migration from older state versions
-func migrateState(state *State) *State {
-	// If no version is set, this is a v0 state - migrate to v1
-	if state.StateVersion == 0 {
-		// Initialize new fields for v1
-		if state.Repositories == nil {
-			state.Repositories = make([]RepositoryData, 0)
-		}
-		if state.SelectedRepository == "" {
-			state.SelectedRepository = ""
-		}
-		state.StateVersion = 1
-		if log.InfoLog != nil {
-			log.InfoLog.Printf("Migrated state from version 0 to version 1")
-		}
-	}
-	
-	return state
-}
@@ -0,0 +1,407 @@
+package config
+
+import (
+	"bytes"
+	"claude-squad/log"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetSelection(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	state := DefaultState()
+	state.Repositories = []RepositoryData{{Path: "/src/app", Name: "app"}, {Path: "/src/site", Name: "site"}}
+	require.NoError(t, state.SetSelection("/src/app", "login"))
+	require.NoError(t, state.SetSelection("/src/site", "docs"))
+	require.Error(t, state.SetSelection("/src/other", "api"))
+
+	loaded := LoadState()
+	require.Equal(t, "/src/site", loaded.GetSelectedRepository())
+	app, err := loaded.GetRepository("/src/app")
+	require.NoError(t, err)
+	require.Equal(t, "login", app.LastSelectedInstance)
+	site, err := loaded.GetRepository("/src/site")
+	require.NoError(t, err)
+	require.Equal(t, "docs", site.LastSelectedInstance)
+}
+
+func TestSetRepositoryAlias(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	state := DefaultState()
+	state.Repositories = []RepositoryData{{Path: "/src/shop/api", Name: "api"}, {Path: "/src/blog/api", Name: "api"}}
+	require.NoError(t, state.SetRepositoryAlias("/src/shop/api", "shop-api"))
+	require.Error(t, state.SetRepositoryAlias("/src/blog/api", "shop-api"))
+	require.Error(t, state.SetRepositoryAlias("/src/other", "other"))
+
+	loaded := LoadState()
+	require.Equal(t, map[string]string{"/src/shop/api": "shop-api"}, loaded.GetRepositoryAliases())
+	repo, err := loaded.GetRepository("/src/shop/api")
+	require.NoError(t, err)
+	require.Equal(t, "shop-api", repo.DisplayName())
+
+	// Re-adding the repository keeps its alias, and an empty alias clears it.
+	require.NoError(t, loaded.AddRepository(RepositoryData{Path: "/src/shop/api", Name: "api"}))
+	require.Equal(t, "shop-api", loaded.GetRepositoryAliases()["/src/shop/api"])
+	require.NoError(t, loaded.SetRepositoryAlias("/src/shop/api", ""))
+	require.Empty(t, loaded.GetRepositoryAliases())
+}
+
+func TestPinnedRepositories(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	now := time.Now()
+	state := DefaultState()
+	state.Repositories = []RepositoryData{
+		{Path: "/src/api", LastAccessed: now.Add(-time.Hour)},
+		{Path: "/src/web", LastAccessed: now},
+		{Path: "/src/docs", LastAccessed: now.Add(-2 * time.Hour)},
+	}
+	require.NoError(t, state.SetRepositoryPinned("/src/docs", true))
+	require.Error(t, state.SetRepositoryPinned("/src/other", true))
+
+	var paths []string
+	for _, repo := range LoadState().GetRepositoriesSortedByLastAccessed() {
+		paths = append(paths, repo.Path)
+	}
+	require.Equal(t, []string{"/src/docs", "/src/web", "/src/api"}, paths)
+}
+
+func TestRepositoryProgram(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	state := DefaultState()
+	state.Repositories = []RepositoryData{{Path: "/src/ml", Name: "ml"}, {Path: "/src/engine", Name: "engine"}}
+	require.NoError(t, state.SetRepositoryProgram("/src/ml", "claude", " --model sonnet "))
+	require.NoError(t, state.SetRepositoryProgram("/src/engine", "aider", ""))
+	require.Error(t, state.SetRepositoryProgram("/src/engine", "aider --yes", ""))
+	require.Error(t, state.SetRepositoryProgram("/src/other", "aider", ""))
+
+	// The program the user chose is kept when the repository is added again.
+	require.NoError(t, state.AddRepository(RepositoryData{Path: "/src/ml", Name: "ml"}))
+	loaded := LoadState()
+	ml, err := loaded.GetRepository("/src/ml")
+	require.NoError(t, err)
+	require.Equal(t, "claude --model sonnet", ml.ProgramFor("codex"))
+	engine, err := loaded.GetRepository("/src/engine")
+	require.NoError(t, err)
+	require.Equal(t, "aider", engine.ProgramFor("claude"))
+
+	// Arguments alone are given to the default program.
+	require.NoError(t, loaded.SetRepositoryProgram("/src/engine", "", "--verbose"))
+	engine, err = loaded.GetRepository("/src/engine")
+	require.NoError(t, err)
+	require.Equal(t, "claude --verbose", engine.ProgramFor("claude"))
+}
+
+func TestWorkspaces(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	state := DefaultState()
+	state.Repositories = []RepositoryData{{Path: "/src/api", Name: "api"}, {Path: "/src/web", Name: "web"}}
+	require.Error(t, state.SaveWorkspace(Workspace{Name: " ", Repositories: []string{"/src/api"}}))
+	require.Error(t, state.SaveWorkspace(Workspace{Name: "shop"}))
+	require.Error(t, state.SaveWorkspace(Workspace{Name: "shop", Repositories: []string{"/src/other"}}))
+	require.NoError(t, state.SaveWorkspace(Workspace{Name: "shop", Repositories: []string{"/src/api"}}))
+	require.NoError(t, state.SaveWorkspace(Workspace{Name: "shop", Repositories: []string{"/src/api", "/src/web"}}))
+	require.NoError(t, state.SaveWorkspace(Workspace{Name: "docs", Repositories: []string{"/src/web"}}))
+	require.Error(t, state.SetSelectedWorkspace("other"))
+	require.NoError(t, state.SetSelectedWorkspace("shop"))
+
+	loaded := LoadState()
+	require.Equal(t, "shop", loaded.GetSelectedWorkspace())
+	require.Len(t, loaded.GetWorkspaces(), 2)
+	shop, err := loaded.GetWorkspace("shop")
+	require.NoError(t, err)
+	require.Equal(t, []string{"/src/api", "/src/web"}, shop.Repositories)
+
+	// Removing a repository removes it from its workspaces, and removing a workspace unselects it.
+	require.NoError(t, loaded.RemoveRepository("/src/web"))
+	shop, err = loaded.GetWorkspace("shop")
+	require.NoError(t, err)
+	require.Equal(t, []string{"/src/api"}, shop.Repositories)
+	require.NoError(t, loaded.RemoveWorkspace("shop"))
+	require.Empty(t, loaded.GetSelectedWorkspace())
+	require.Error(t, loaded.RemoveWorkspace("shop"))
+}
+
+func TestWeeklyReview(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	// Wednesday.
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	state := DefaultState()
+	require.True(t, state.GetWeeklyReview().Due(now))
+	require.NoError(t, state.SetWeeklyReview(WeeklyReview{ShownAt: now.AddDate(0, 0, -2), WorktreeBytes: 2048}))
+
+	loaded := LoadState()
+	review := loaded.GetWeeklyReview()
+	require.Equal(t, int64(2048), review.WorktreeBytes)
+	require.False(t, review.Due(now))
+	// Sunday is still the same week, the next Monday isn't.
+	require.False(t, review.Due(now.AddDate(0, 0, 4)))
+	require.True(t, review.Due(now.AddDate(0, 0, 5)))
+	require.True(t, review.Due(now.AddDate(1, 0, 0)))
+}
+
+func TestHints(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	now := time.Date(2024, 5, 15, 10, 0, 0, 0, time.UTC)
+	state := DefaultState()
+	require.True(t, state.GetHints().CanShow("attach", now))
+	require.NoError(t, state.RecordHintShown("attach", now))
+	// Hints are rate-limited, whichever was shown last.
+	require.False(t, state.GetHints().CanShow("checkout", now.Add(time.Minute)))
+	require.True(t, state.GetHints().CanShow("checkout", now.Add(HintInterval)))
+
+	for i := 1; i < HintMaxShows; i++ {
+		now = now.Add(HintInterval)
+		require.True(t, state.GetHints().CanShow("attach", now))
+		require.NoError(t, state.RecordHintShown("attach", now))
+	}
+	require.False(t, state.GetHints().CanShow("attach", now.Add(HintInterval)), "retired after HintMaxShows")
+
+	require.NoError(t, state.DismissHint("checkout"))
+	loaded := LoadState()
+	require.False(t, loaded.GetHints().CanShow("checkout", now.Add(HintInterval)))
+	require.Equal(t, HintMaxShows, loaded.GetHints().Records["attach"].Shown)
+	require.True(t, loaded.GetHints().CanShow("diff", now.Add(HintInterval)))
+}
+
+func TestSaveStateMergesConcurrentChanges(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	// Two processes load the same state.
+	tui := LoadState()
+	cli := LoadState()
+
+	require.NoError(t, cli.DismissHint("attach"))
+	require.NoError(t, tui.SaveInstances(json.RawMessage(`[{"title":"a"}]`)))
+	// The change the other process saved is taken in rather than overwritten.
+	require.True(t, tui.GetHints().Records["attach"].Dismissed)
+
+	loaded := LoadState()
+	require.True(t, loaded.GetHints().Records["attach"].Dismissed)
+	require.JSONEq(t, `[{"title":"a"}]`, string(loaded.GetInstances()))
+
+	// When both change the same field, the last save wins.
+	require.NoError(t, cli.SetPreferences(Preferences{SortOrder: "uptime"}))
+	require.NoError(t, tui.SetPreferences(Preferences{SortOrder: "activity"}))
+	require.Equal(t, "activity", LoadState().GetPreferences().SortOrder)
+}
+
+func TestSaveStateMergesConcurrentInstances(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	base := LoadState()
+	require.NoError(t, base.SaveInstances(json.RawMessage(`[{"title":"a","status":0},{"title":"b","status":0},{"title":"c","status":0}]`)))
+	require.NoError(t, base.AddRepository(RepositoryData{Path: "/repo/one", Name: "one"}))
+	tui := LoadState()
+	cli := LoadState()
+
+	// The other process adds d, kills b and pauses c, and adds a repository.
+	require.NoError(t, cli.SaveInstances(json.RawMessage(`[{"title":"a","status":0},{"title":"c","status":3},{"title":"d","status":0}]`)))
+	require.NoError(t, cli.AddRepository(RepositoryData{Path: "/repo/two", Name: "two"}))
+	// Meanwhile this one adds e and changes a, and adds another repository.
+	require.NoError(t, tui.SaveInstances(json.RawMessage(`[{"title":"a","status":1},{"title":"b","status":0},{"title":"c","status":0},{"title":"e","status":0}]`)))
+	require.NoError(t, tui.AddRepository(RepositoryData{Path: "/repo/three", Name: "three"}))
+
+	want := `[{"title":"a","status":1},{"title":"c","status":3},{"title":"e","status":0},{"title":"d","status":0}]`
+	require.JSONEq(t, want, string(tui.GetInstances()))
+	require.JSONEq(t, want, string(LoadState().GetInstances()))
+	var paths []string
+	for _, repo := range LoadState().GetRepositories() {
+		paths = append(paths, repo.Path)
+	}
+	require.ElementsMatch(t, []string{"/repo/one", "/repo/two", "/repo/three"}, paths)
+	require.ElementsMatch(t, []string{"b", "c", "d"}, tui.TakeMergedInstances())
+	require.Empty(t, tui.TakeMergedInstances())
+
+	// An instance both changed keeps this process's change.
+	require.NoError(t, cli.SaveInstances(json.RawMessage(`[{"title":"a","status":2}]`)))
+	require.NoError(t, tui.SaveInstances(json.RawMessage(`[{"title":"a","status":0},{"title":"c","status":3},{"title":"e","status":0},{"title":"d","status":0}]`)))
+	require.Contains(t, string(LoadState().GetInstances()), `{"title":"a","status":0}`)
+}
+
+func TestSaveStateWaitsForLock(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	configDir, err := GetConfigDir()
+	require.NoError(t, err)
+	lock, err := lockState(configDir)
+	require.NoError(t, err)
+
+	saved := make(chan error)
+	go func() { saved <- SaveState(DefaultState()) }()
+	select {
+	case <-saved:
+		t.Fatal("state saved while locked")
+	case <-time.After(100 * time.Millisecond):
+	}
+	lock.unlock()
+	require.NoError(t, <-saved)
+}
+
+func TestWriteBehind(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	state := LoadState()
+	state.SetWriteBehind(true)
+	require.NoError(t, state.DismissHint("attach"))
+	require.NoError(t, state.SaveInstances(json.RawMessage(`[{"title":"a"}]`)))
+	// Mutations are batched until the state is flushed.
+	require.Empty(t, LoadState().GetHints().Records)
+
+	require.NoError(t, state.Flush())
+	loaded := LoadState()
+	require.True(t, loaded.GetHints().Records["attach"].Dismissed)
+	require.JSONEq(t, `[{"title":"a"}]`, string(loaded.GetInstances()))
+	require.NoError(t, state.Flush(), "flushing a clean state is a no-op")
+}
+
+func TestEncryptedState(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(StateKeyEnv, base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+
+	configDir, err := GetConfigDir()
+	require.NoError(t, err)
+	statePath := filepath.Join(configDir, StateFileName)
+	state := LoadState()
+	require.NoError(t, state.SaveInstances(json.RawMessage(`[{"title":"secret-plan"}]`)))
+	data, err := os.ReadFile(statePath)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "secret-plan", "the state is only encrypted once it's enabled")
+
+	// A snapshot taken before encryption is enabled is a plaintext copy of the state too.
+	snapshot := filepath.Join(configDir, SnapshotsDirName, "20250101-000000.000", StateFileName)
+	require.NoError(t, os.MkdirAll(filepath.Dir(snapshot), 0755))
+	require.NoError(t, os.WriteFile(snapshot, data, 0644))
+	// So are the diffs stored next to it.
+	diff := filepath.Join(configDir, DiffsDirName, "instance.diff")
+	require.NoError(t, os.MkdirAll(filepath.Dir(diff), 0755))
+	require.NoError(t, os.WriteFile(diff, []byte("+secret-line\n"), 0644))
+
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, ConfigFileName), []byte(`{"encrypt_state": true}`), 0644))
+	require.NoError(t, state.DismissHint("attach"))
+	data, err = os.ReadFile(statePath)
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(data, encryptedStateMagic))
+	require.NotContains(t, string(data), "secret-plan")
+
+	// The copies kept before are encrypted once it's enabled.
+	for _, path := range []string{stateBackupPath(statePath, 1), snapshot} {
+		copied, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.True(t, bytes.HasPrefix(copied, encryptedStateMagic), path)
+		copiedState, err := readStateData(path)
+		require.NoError(t, err)
+		require.Contains(t, string(copiedState), `"state_version"`)
+	}
+	sealedDiff, err := os.ReadFile(diff)
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(sealedDiff, encryptedStateMagic))
+	openedDiff, err := OpenStateData(sealedDiff)
+	require.NoError(t, err)
+	require.Equal(t, "+secret-line\n", string(openedDiff))
+	info, err := os.Stat(diff)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	loaded := LoadState()
+	require.NoError(t, loaded.ReadOnly())
+	require.True(t, loaded.GetHints().Records["attach"].Dismissed)
+	require.JSONEq(t, `[{"title":"secret-plan"}]`, string(loaded.GetInstances()))
+
+	// Without the right key, e.g. while the keychain is locked, the state is read-only rather than reset.
+	t.Setenv(StateKeyEnv, base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{8}, 32)))
+	locked := LoadState()
+	require.Error(t, locked.ReadOnly())
+	require.Empty(t, locked.GetHints().Records)
+	require.Error(t, locked.SaveInstances(json.RawMessage(`[]`)))
+	unchanged, err := os.ReadFile(statePath)
+	require.NoError(t, err)
+	require.Equal(t, data, unchanged)
+}
+
+func TestStateKeyNeverReplaced(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the keychain is faked with a secret-tool script")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(StateKeyEnv, "")
+	defer func() { keychainKey = nil }()
+
+	bin := t.TempDir()
+	calls := filepath.Join(bin, "calls")
+	script := `#!/bin/sh
+echo "$1" >> "` + calls + `"
+if [ "$1" = lookup ]; then
+	[ -n "$LOOKUP_ERROR" ] && echo "$LOOKUP_ERROR" >&2
+	exit 1
+fi
+cat > /dev/null
+`
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "secret-tool"), []byte(script), 0755))
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	stored := func() bool {
+		data, _ := os.ReadFile(calls)
+		return bytes.Contains(data, []byte("store"))
+	}
+
+	// The Secret Service isn't running, which doesn't mean there's no key.
+	t.Setenv("LOOKUP_ERROR", "Cannot autolaunch D-Bus without X11 $DISPLAY")
+	_, err := stateKey(true)
+	require.ErrorContains(t, err, "Cannot autolaunch D-Bus")
+	require.False(t, stored())
+
+	// The key is missing, but the state is encrypted with it.
+	t.Setenv("LOOKUP_ERROR", "")
+	stateDir, err := GetStateDir()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(stateDir, 0755))
+	statePath := filepath.Join(stateDir, StateFileName)
+	require.NoError(t, os.WriteFile(statePath, append(append([]byte{}, encryptedStateMagic...), "sealed"...), 0600))
+	_, err = stateKey(true)
+	require.ErrorContains(t, err, StateKeyEnv)
+	require.False(t, stored())
+
+	// Only with nothing encrypted yet is a key generated.
+	require.NoError(t, os.Remove(statePath))
+	key, err := stateKey(true)
+	require.NoError(t, err)
+	require.Len(t, key, 32)
+	require.True(t, stored())
+}
@@ -0,0 +1,374 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// StateKeyEnv is the environment variable holding the key the state file is encrypted with, a base64-encoded
+	// 32-byte key such as the output of `openssl rand -base64 32`. Without it, the key is kept in the keychain.
+	StateKeyEnv = "CLAUDE_SQUAD_STATE_KEY"
+	// stateKeyService is the keychain service the state key is stored under.
+	stateKeyService = "claude-squad-state"
+)
+
+// encryptedStateMagic starts encrypted state files. It's followed by the nonce and the AES-256-GCM sealed state.
+var encryptedStateMagic = []byte("claude-squad-encrypted-state/v1\n")
+
+// errNoStateKey is returned when an encrypted state file is read without its key.
+var errNoStateKey = errors.New("no key to decrypt the state")
+
+var (
+	keychainKeyMu sync.Mutex
+	// keychainKey caches the key read from the keychain, which takes a command to read.
+	keychainKey []byte
+	// sealedStateCopies are the state files whose plaintext copies were already encrypted by this process.
+	sealedStateCopies sync.Map
+)
+
+// encryptStateEnabled returns true if the config in configDir asks for the state to be encrypted. Only that
+// setting is read, since the state is loaded and saved far more often than the config.
+func encryptStateEnabled(configDir string) bool {
+	data, err := os.ReadFile(filepath.Join(configDir, ConfigFileName))
+	if err != nil {
+		return false
+	}
+	var cfg struct {
+		EncryptState bool `json:"encrypt_state"`
+	}
+	_ = json.Unmarshal(data, &cfg)
+	return cfg.EncryptState
+}
+
+// sealState encrypts the state file contents data if the config asks for it.
+func sealState(configDir string, data []byte) ([]byte, error) {
+	if !encryptStateEnabled(configDir) {
+		return data, nil
+	}
+	key, err := stateKey(true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the state encryption key: %w", err)
+	}
+	aead, err := newStateCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to encrypt state: %w", err)
+	}
+	sealed := append(append([]byte{}, encryptedStateMagic...), nonce...)
+	return aead.Seal(sealed, nonce, data, encryptedStateMagic), nil
+}
+
+// SealStateData encrypts data, a copy of state that's kept outside the state directory, e.g. synced between
+// machines, with the state key if the config asks for the state to be encrypted. It's returned as is otherwise.
+func SealStateData(data []byte) ([]byte, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return sealState(configDir, data)
+}
+
+// OpenStateData decrypts data sealed by SealStateData. Data that isn't encrypted is returned as is.
+func OpenStateData(data []byte) ([]byte, error) {
+	r, err := openState(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// sealStateCopies encrypts the copies of the state file at statePath that were kept before the config asked for
+// encryption: its backups, the states set aside and the snapshots, and the instances' diffs stored next to it.
+// Otherwise turning encryption on would leave plaintext copies of the state behind. It's done once per process.
+func sealStateCopies(configDir, statePath string) error {
+	if _, done := sealedStateCopies.Load(statePath); done || !encryptStateEnabled(configDir) {
+		return nil
+	}
+	copies, diffs, err := stateCopyPaths(statePath)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, path := range append(copies, diffs...) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if bytes.HasPrefix(data, encryptedStateMagic) {
+			continue
+		}
+		sealed, err := sealState(configDir, data)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := writeStateFile(path, sealed, false); err != nil {
+			errs = append(errs, fmt.Errorf("failed to encrypt %s: %w", path, err))
+			continue
+		}
+		// The diffs are only readable by the user, like the diff store writes them.
+		if slices.Contains(diffs, path) {
+			if err := os.Chmod(path, 0600); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	sealedStateCopies.Store(statePath, true)
+	return nil
+}
+
+// stateCopyPaths returns the paths of the copies of the state file at statePath: its backups, the states set
+// aside and the snapshots, and separately the instances' diffs stored next to it.
+func stateCopyPaths(statePath string) (copies, diffs []string, err error) {
+	backups, err := filepath.Glob(statePath + ".*")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, path := range backups {
+		if path == filepath.Join(filepath.Dir(statePath), stateLockFileName) ||
+			strings.HasPrefix(filepath.Base(path), StateFileName+".tmp-") {
+			continue
+		}
+		copies = append(copies, path)
+	}
+	snapshots, err := filepath.Glob(filepath.Join(filepath.Dir(statePath), SnapshotsDirName, "*", StateFileName))
+	if err != nil {
+		return nil, nil, err
+	}
+	diffs, err = filepath.Glob(filepath.Join(filepath.Dir(statePath), DiffsDirName, "*"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(copies, snapshots...), diffs, nil
+}
+
+// encryptedStateExists returns true if the state file, or any copy of it, is encrypted. It's also true if that
+// can't be told, so a key is never generated in place of the one the state may be encrypted with.
+func encryptedStateExists() bool {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return true
+	}
+	statePath := filepath.Join(stateDir, StateFileName)
+	copies, diffs, err := stateCopyPaths(statePath)
+	if err != nil {
+		return true
+	}
+	magic := make([]byte, len(encryptedStateMagic))
+	for _, path := range append(append([]string{statePath}, copies...), diffs...) {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return true
+		}
+		n, _ := io.ReadFull(f, magic)
+		f.Close()
+		if bytes.Equal(magic[:n], encryptedStateMagic) {
+			return true
+		}
+	}
+	return false
+}
+
+// openState returns a reader of the state file contents read from r, decrypting them if they're encrypted.
+// Plain state files are read as they are, whatever the config says, so turning encryption on or off takes
+// effect at the next save.
+func openState(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(len(encryptedStateMagic)); !bytes.Equal(magic, encryptedStateMagic) {
+		return br, nil
+	}
+	data, err := io.ReadAll(br)
+	if err != nil {
+		return nil, err
+	}
+	key, err := stateKey(false)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errNoStateKey, err)
+	}
+	aead, err := newStateCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	data = data[len(encryptedStateMagic):]
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("encrypted state file is truncated")
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], encryptedStateMagic)
+	if err != nil {
+		return nil, fmt.Errorf("%w: the key doesn't match (%v)", errNoStateKey, err)
+	}
+	return bytes.NewReader(plain), nil
+}
+
+// readStateData reads the state file at path, decrypting it if it's encrypted.
+func readStateData(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r, err := openState(f)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func newStateCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// stateKey returns the key the state is encrypted with: the one in StateKeyEnv, or else the one in the keychain.
+// If create is true, the keychain reports it has no key and nothing is encrypted yet, a key is generated and
+// stored there.
+func stateKey(create bool) ([]byte, error) {
+	if encoded := os.Getenv(StateKeyEnv); encoded != "" {
+		return decodeStateKey(encoded)
+	}
+
+	keychainKeyMu.Lock()
+	defer keychainKeyMu.Unlock()
+	if keychainKey != nil {
+		return keychainKey, nil
+	}
+	encoded, err := keychainGet()
+	if err != nil {
+		// A locked keychain, a denied prompt or a missing Secret Service doesn't mean there's no key: replacing
+		// it would make everything encrypted with it unreadable.
+		if !create || !errors.Is(err, errNoKeychainKey) {
+			return nil, fmt.Errorf("set %s or store the key in the keychain: %w", StateKeyEnv, err)
+		}
+		// Nor does a key that was deleted, if the state is still encrypted with it.
+		if encryptedStateExists() {
+			return nil, fmt.Errorf("the state is encrypted, but the keychain has no key for it: set %s to the key",
+				StateKeyEnv)
+		}
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		encoded = base64.StdEncoding.EncodeToString(key)
+		if err := keychainSet(encoded); err != nil {
+			return nil, fmt.Errorf("set %s, or make the keychain available to store a generated key: %w", StateKeyEnv, err)
+		}
+	}
+	key, err := decodeStateKey(encoded)
+	if err != nil {
+		return nil, err
+	}
+	keychainKey = key
+	return key, nil
+}
+
+func decodeStateKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("the state key must be 32 bytes, base64-encoded, e.g. from `openssl rand -base64 32`")
+	}
+	return key, nil
+}
+
+// keychainAccount is the account the state key is stored under in the keychain.
+func keychainAccount() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return "claude-squad"
+}
+
+// errNoKeychainKey is returned by keychainGet when the keychain was read, but has no state key.
+var errNoKeychainKey = errors.New("the keychain has no state key")
+
+// keychainGet reads the state key from the macOS keychain or, elsewhere, the Secret Service through secret-tool.
+// It returns errNoKeychainKey only if the keychain could be searched and has no key.
+func keychainGet() (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-a", keychainAccount(), "-s", stateKeyService, "-w")
+	case "windows":
+		return "", errors.New("no keychain support on Windows")
+	default:
+		cmd = exec.Command("secret-tool", "lookup", "service", stateKeyService, "account", keychainAccount())
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// security exits with errSecItemNotFound; secret-tool fails silently when nothing matched, while
+			// its other failures, e.g. no Secret Service running, are reported.
+			if (runtime.GOOS == "darwin" && exitErr.ExitCode() == 44) ||
+				(runtime.GOOS != "darwin" && exitErr.ExitCode() == 1 && len(bytes.TrimSpace(exitErr.Stderr)) == 0) {
+				return "", errNoKeychainKey
+			}
+			return "", fmt.Errorf("failed to read the state key from the keychain: %s (%w)",
+				bytes.TrimSpace(exitErr.Stderr), err)
+		}
+		return "", fmt.Errorf("failed to read the state key from the keychain: %w", err)
+	}
+	if len(bytes.TrimSpace(output)) == 0 {
+		return "", errNoKeychainKey
+	}
+	return string(output), nil
+}
+
+// keychainSet stores a new state key in the keychain.
+func keychainSet(encoded string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// The command is read from stdin by security's interactive mode, so the key isn't on the command line,
+		// where other users could see it in ps.
+		cmd = exec.Command("security", "-i")
+		// Without -U, an existing key is never replaced: the command fails instead.
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -a %s -s %s -w %s\n",
+			strconv.Quote(keychainAccount()), stateKeyService, encoded))
+	case "windows":
+		return errors.New("no keychain support on Windows")
+	default:
+		// secret-tool replaces a matching key, so it's only stored after a lookup found none.
+		cmd = exec.Command("secret-tool", "store", "--label=claude-squad state key", "service", stateKeyService,
+			"account", keychainAccount())
+		cmd.Stdin = strings.NewReader(encoded)
+	}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to store the state key in the keychain: %s (%w)", bytes.TrimSpace(output), err)
+	}
+	// security's interactive mode may exit successfully although the command it read failed.
+	if bytes.Contains(output, []byte("already exists")) {
+		return fmt.Errorf("failed to store the state key in the keychain: %s", bytes.TrimSpace(output))
+	}
+	return nil
+}
@@ -0,0 +1,336 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"claude-squad/log"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// maxStateSize is the size of the largest state file that's loaded. A bigger one is pathological, e.g.
+	// from huge diffs stored inline by older versions, and is set aside rather than read into memory.
+	maxStateSize = 256 << 20
+	// maxStoredInstanceSize is the size of the largest stored instance that's kept when loading the state.
+	maxStoredInstanceSize = 64 << 20
+	// stateBackups is the number of backups of the state file that are kept: state.json.1 is the most recent,
+	// state.json.2 the one before it, and so on.
+	stateBackups = 5
+	// stateBackupInterval is the shortest time between backups. The state is saved every few seconds while
+	// instances run, so without it the backups would only go back a few saves.
+	stateBackupInterval = time.Minute
+)
+
+// decodeState reads the state from r one field, and one instance, at a time. If the state can't be read in
+// full, what was read before the error is returned along with it, so a damaged state file can be salvaged.
+func decodeState(r io.Reader) (*State, error) {
+	state := &State{}
+	err := decodeStateFields(json.NewDecoder(bufio.NewReader(r)), state)
+	if state.InstancesData == nil {
+		state.InstancesData = json.RawMessage("[]")
+	}
+	return state, err
+}
+
+func decodeStateFields(dec *json.Decoder, state *State) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return fmt.Errorf("unexpected %v in state", token)
+		}
+		if key == "instances" {
+			instances, err := decodeInstances(dec)
+			state.InstancesData = instances
+			if err != nil {
+				return fmt.Errorf("failed to read instances: %w", err)
+			}
+			continue
+		}
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		field, err := json.Marshal(map[string]json.RawMessage{key: value})
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(field, state); err != nil {
+			return fmt.Errorf("failed to read %s: %w", key, err)
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+// decodeInstances reads the stored instances one at a time, leaving out any over maxStoredInstanceSize. On
+// error, it returns the instances read before it.
+func decodeInstances(dec *json.Decoder) (json.RawMessage, error) {
+	var instances []json.RawMessage
+	salvaged := func() json.RawMessage {
+		data, err := json.Marshal(instances)
+		if err != nil || instances == nil {
+			return json.RawMessage("[]")
+		}
+		return data
+	}
+
+	token, err := dec.Token()
+	if err != nil {
+		return salvaged(), err
+	}
+	if token == nil {
+		return salvaged(), nil
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return salvaged(), fmt.Errorf("expected a list of instances, got %v", token)
+	}
+	for dec.More() {
+		start := dec.InputOffset()
+		var instance json.RawMessage
+		if err := dec.Decode(&instance); err != nil {
+			return salvaged(), err
+		}
+		if size := dec.InputOffset() - start; size > maxStoredInstanceSize {
+			log.ErrorLog.Printf("left out stored instance %d of %d MB, over the %d MB limit", len(instances),
+				size>>20, maxStoredInstanceSize>>20)
+			continue
+		}
+		instances = append(instances, instance)
+	}
+	if err := expectDelim(dec, ']'); err != nil {
+		return salvaged(), err
+	}
+	return salvaged(), nil
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("expected %q, got %v", want, token)
+	}
+	return nil
+}
+
+// setAsideState moves a state file that can't be loaded as is out of the way, so it isn't overwritten, and
+// returns where it was moved to.
+func setAsideState(statePath, reason string) (string, error) {
+	aside := fmt.Sprintf("%s.%s-%s", statePath, reason, time.Now().Format("20060102-150405"))
+	if err := os.Rename(statePath, aside); err != nil {
+		return "", fmt.Errorf("failed to set aside state file: %w", err)
+	}
+	return aside, nil
+}
+
+// writeStateFile replaces the state file at statePath with data. data is written to a temporary file that's
+// renamed over the state file, so a crash mid-write leaves the previous state file in place. If backup is
+// true, the previous state file is kept as the most recent backup.
+func writeStateFile(statePath string, data []byte, backup bool) error {
+	tmp, err := os.CreateTemp(filepath.Dir(statePath), filepath.Base(statePath)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file: %w", err)
+	}
+	// Only the failed writes leave the temporary file behind.
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+
+	if backup {
+		if err := backUpState(statePath); err != nil {
+			log.WarningLog.Printf("failed to back up the state: %v", err)
+		}
+	}
+	if err := os.Rename(tmp.Name(), statePath); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	return nil
+}
+
+// stateBackupPath returns the path of the nth most recent backup of the state file at statePath.
+func stateBackupPath(statePath string, n int) string {
+	return fmt.Sprintf("%s.%d", statePath, n)
+}
+
+// backUpState makes the state file at statePath the most recent backup, shifting the older ones and dropping
+// the oldest, unless the most recent backup is under stateBackupInterval old.
+func backUpState(statePath string) error {
+	if _, err := os.Stat(statePath); os.IsNotExist(err) {
+		return nil
+	}
+	latest := stateBackupPath(statePath, 1)
+	if info, err := os.Stat(latest); err == nil && time.Since(info.ModTime()) < stateBackupInterval {
+		return nil
+	}
+
+	for n := stateBackups - 1; n >= 1; n-- {
+		err := os.Rename(stateBackupPath(statePath, n), stateBackupPath(statePath, n+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	// The state file is copied rather than moved, so it's there even if the process dies before it's replaced.
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(latest, data, 0644)
+}
+
+// RestoreStateBackup replaces the state file with its most recent backup that can be loaded, and returns the
+// path of that backup. The replaced state file is set aside rather than deleted.
+func RestoreStateBackup() (string, error) {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get state directory: %w", err)
+	}
+	lock, err := lockState(stateDir)
+	if err != nil {
+		return "", err
+	}
+	defer lock.unlock()
+
+	statePath := filepath.Join(stateDir, StateFileName)
+	for n := 1; n <= stateBackups; n++ {
+		backup := stateBackupPath(statePath, n)
+		data, err := os.ReadFile(backup)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		r, err := openState(bytes.NewReader(data))
+		if err == nil {
+			_, err = decodeState(r)
+		}
+		if err != nil {
+			log.WarningLog.Printf("skipping damaged state backup %s: %v", backup, err)
+			continue
+		}
+
+		if _, err := setAsideState(statePath, "replaced"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		if err := writeStateFile(statePath, data, false); err != nil {
+			return "", err
+		}
+		lock.bump()
+		return backup, nil
+	}
+	return "", fmt.Errorf("no state backup that can be loaded in %s", stateDir)
+}
+
+// CopyStateFile copies the state file, as it's stored, to dest. The state file is locked while it's read.
+func CopyStateFile(dest string) error {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return fmt.Errorf("failed to get state directory: %w", err)
+	}
+	lock, err := lockState(stateDir)
+	if err != nil {
+		return err
+	}
+	defer lock.unlock()
+
+	data, err := os.ReadFile(filepath.Join(stateDir, StateFileName))
+	if err != nil {
+		return fmt.Errorf("failed to read state: %w", err)
+	}
+	return os.WriteFile(dest, data, 0644)
+}
+
+// ReadStateFile reads the state file without repairing, replacing or creating it like LoadState does, and
+// returns why it can't be loaded, if it can't. The state file is locked while it's read.
+func ReadStateFile() (*State, error) {
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state directory: %w", err)
+	}
+	lock, err := lockState(stateDir)
+	if err != nil {
+		return nil, err
+	}
+	defer lock.unlock()
+
+	f, err := os.Open(filepath.Join(stateDir, StateFileName))
+	if os.IsNotExist(err) {
+		return DefaultState(), nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+	defer f.Close()
+	r, err := openState(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+	state, err := decodeState(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse state: %w", err)
+	}
+	return state, nil
+}
+
+// ReplaceStateFile replaces the state file with src, a state file copied by CopyStateFile, and returns where
+// the replaced state file was set aside. src must be a state file that can be loaded.
+func ReplaceStateFile(src string) (string, error) {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", src, err)
+	}
+	r, err := openState(bytes.NewReader(data))
+	if err == nil {
+		_, err = decodeState(r)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s can't be loaded: %w", src, err)
+	}
+
+	stateDir, err := GetStateDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get state directory: %w", err)
+	}
+	lock, err := lockState(stateDir)
+	if err != nil {
+		return "", err
+	}
+	defer lock.unlock()
+
+	statePath := filepath.Join(stateDir, StateFileName)
+	aside, err := setAsideState(statePath, "replaced")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	if err := writeStateFile(statePath, data, false); err != nil {
+		return "", err
+	}
+	lock.bump()
+	return aside, nil
+}
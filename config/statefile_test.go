@@ -0,0 +1,101 @@
+package config
+
+import (
+	"claude-squad/log"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeState(t *testing.T) {
+	state, err := decodeState(strings.NewReader(`{"help_screens_seen": 3, "instances": [{"title": "a"}, {"title": "b"}],
+		"selected_repository": "/repo", "state_version": 1}`))
+	require.NoError(t, err)
+	require.EqualValues(t, 3, state.HelpScreensSeen)
+	require.Equal(t, "/repo", state.SelectedRepository)
+	require.JSONEq(t, `[{"title": "a"}, {"title": "b"}]`, string(state.InstancesData))
+
+	state, err = decodeState(strings.NewReader(`{"state_version": 1}`))
+	require.NoError(t, err)
+	require.Equal(t, "[]", string(state.InstancesData))
+}
+
+func TestDecodeStateSalvagesTruncatedInstances(t *testing.T) {
+	state, err := decodeState(strings.NewReader(`{"help_screens_seen": 3, "instances": [{"title": "a"}, {"title": "b"}, {"title": "c`))
+	require.Error(t, err)
+	require.EqualValues(t, 3, state.HelpScreensSeen)
+	require.JSONEq(t, `[{"title": "a"}, {"title": "b"}]`, string(state.InstancesData))
+}
+
+func TestLoadStateRepairsDamagedFile(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	configDir, err := GetConfigDir()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(configDir, 0755))
+	damaged := `{"state_version": 1, "instances": [{"title": "a"}, {"title": `
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, StateFileName), []byte(damaged), 0644))
+
+	state := LoadState()
+	require.JSONEq(t, `[{"title": "a"}]`, string(state.GetInstances()))
+
+	// The damaged file is kept, and the state file replaced with what was salvaged.
+	matches, err := filepath.Glob(filepath.Join(configDir, StateFileName+".damaged-*"))
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	kept, err := os.ReadFile(matches[0])
+	require.NoError(t, err)
+	require.Equal(t, damaged, string(kept))
+
+	data, err := os.ReadFile(filepath.Join(configDir, StateFileName))
+	require.NoError(t, err)
+	var repaired State
+	require.NoError(t, json.Unmarshal(data, &repaired))
+	require.JSONEq(t, `[{"title": "a"}]`, string(repaired.InstancesData))
+}
+
+func TestSaveStateKeepsBackups(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	configDir, err := GetConfigDir()
+	require.NoError(t, err)
+	statePath := filepath.Join(configDir, StateFileName)
+	state := LoadState()
+
+	// Each save backs up the previous state file, unless the last backup is too recent.
+	for seen := 1; seen <= stateBackups+2; seen++ {
+		if info, err := os.Stat(stateBackupPath(statePath, 1)); err == nil {
+			old := info.ModTime().Add(-stateBackupInterval)
+			require.NoError(t, os.Chtimes(stateBackupPath(statePath, 1), old, old))
+		}
+		require.NoError(t, state.RecordHintShown("attach", time.Now()))
+	}
+	require.NoError(t, state.RecordHintShown("attach", time.Now()))
+
+	backups, err := filepath.Glob(statePath + ".*[0-9]")
+	require.NoError(t, err)
+	require.Len(t, backups, stateBackups)
+	leftovers, err := filepath.Glob(statePath + ".tmp-*")
+	require.NoError(t, err)
+	require.Empty(t, leftovers)
+
+	// The most recent backup that can be loaded is restored.
+	require.NoError(t, os.WriteFile(stateBackupPath(statePath, 1), []byte(`{"help_screens_seen": `), 0644))
+	restored, err := RestoreStateBackup()
+	require.NoError(t, err)
+	require.Equal(t, stateBackupPath(statePath, 2), restored)
+	require.Equal(t, stateBackups, LoadState().GetHints().Records["attach"].Shown)
+
+	replaced, err := filepath.Glob(statePath + ".replaced-*")
+	require.NoError(t, err)
+	require.Len(t, replaced, 1)
+}
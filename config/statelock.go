@@ -0,0 +1,267 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// stateLockFileName is the file claude-squad processes lock while they read or write the state file, so one
+// process doesn't read a half-written state or clobber another's changes.
+const stateLockFileName = StateFileName + ".lock"
+
+// stateFile is what a State knows of the state file it was last loaded from or saved to.
+type stateFile struct {
+	// fields are the state's top-level fields as they were in the file, compactly encoded.
+	fields map[string]json.RawMessage
+	// revision is the revision of the file fields were read from or written to.
+	revision uint64
+}
+
+// stateLock is the lock on the state file. The lock file also holds the state file's revision, the number of
+// times it was saved, which tells whether another process saved it since it was last read.
+type stateLock struct {
+	f *os.File
+}
+
+// lockState takes the lock on the state file in stateDir, waiting for other processes to release it.
+func lockState(stateDir string) (*stateLock, error) {
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(stateDir, stateLockFileName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state lock: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock state: %w", err)
+	}
+	return &stateLock{f: f}, nil
+}
+
+// unlock releases the lock. It's a no-op on a nil lock, e.g. when the state is loaded without one.
+func (l *stateLock) unlock() {
+	if l == nil {
+		return
+	}
+	_ = unlockFile(l.f)
+	l.f.Close()
+}
+
+// revision returns the state file's revision, 0 if unknown.
+func (l *stateLock) revision() uint64 {
+	if l == nil {
+		return 0
+	}
+	buf := make([]byte, 32)
+	n, _ := l.f.ReadAt(buf, 0)
+	revision, _ := strconv.ParseUint(strings.TrimSpace(string(buf[:n])), 10, 64)
+	return revision
+}
+
+// bump records that the state file was saved and returns its new revision.
+func (l *stateLock) bump() uint64 {
+	if l == nil {
+		return 0
+	}
+	revision := l.revision() + 1
+	if err := l.f.Truncate(0); err == nil {
+		_, _ = l.f.WriteAt([]byte(strconv.FormatUint(revision, 10)), 0)
+	}
+	return revision
+}
+
+// encodeState returns the state file contents for state, and its top-level fields compactly encoded.
+func encodeState(state *State) ([]byte, map[string]json.RawMessage, error) {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal state: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal state: %w", err)
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "  "); err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal state: %w", err)
+	}
+	return indented.Bytes(), fields, nil
+}
+
+// rememberLoaded records the revision of the state file the state was just loaded from.
+func (s *State) rememberLoaded(lock *stateLock) {
+	_, fields, err := encodeState(s)
+	if err != nil {
+		return
+	}
+	s.file = &stateFile{fields: fields, revision: lock.revision()}
+}
+
+// entryIDs are the top-level fields that are lists merged entry by entry, by the key that identifies their
+// entries, so two processes can each add, change and remove entries without undoing the other's.
+var entryIDs = map[string]string{
+	"instances":    "title",
+	"repositories": "path",
+}
+
+// mergeConcurrentChanges takes in the fields another process changed in the state file at statePath since
+// this state was loaded from or saved to it, going by the revision in lock, unless this state changed them
+// too, in which case its own changes win. The instances and repositories are merged entry by entry instead;
+// the titles of the instances taken in are recorded for TakeMergedInstances.
+func (s *State) mergeConcurrentChanges(statePath string, lock *stateLock) error {
+	if s.file == nil || lock == nil || lock.revision() == s.file.revision {
+		return nil
+	}
+	info, err := os.Stat(statePath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if info.Size() > maxStateSize {
+		return fmt.Errorf("state file is %d MB, over the %d MB limit", info.Size()>>20, maxStateSize>>20)
+	}
+
+	data, err := readStateData(statePath)
+	if err != nil {
+		return err
+	}
+	var theirs map[string]json.RawMessage
+	if err := json.Unmarshal(data, &theirs); err != nil {
+		return fmt.Errorf("failed to parse state file: %w", err)
+	}
+	_, ours, err := encodeState(s)
+	if err != nil {
+		return err
+	}
+	for key, value := range theirs {
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, value); err != nil {
+			return err
+		}
+		base := s.file.fields[key]
+		if bytes.Equal(compacted.Bytes(), base) {
+			continue
+		}
+		merged := compacted.Bytes()
+		if id, ok := entryIDs[key]; ok {
+			var taken []string
+			if merged, taken, err = mergeEntries(base, ours[key], merged, id); err != nil {
+				return fmt.Errorf("failed to merge %s: %w", key, err)
+			}
+			if key == "instances" {
+				s.mergedInstances = append(s.mergedInstances, taken...)
+			}
+		} else if !bytes.Equal(ours[key], base) {
+			continue
+		}
+		if err := s.setField(key, merged); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeEntries merges the changes to the list theirs made since base into ours, matching entries by their id
+// key. Entries they added are appended and entries they removed are removed, even if ours changed them, since
+// they're gone for good, e.g. a killed instance. Entries they changed are taken unless ours changed them too.
+// It returns the merged list and the ids of the entries taken from theirs.
+func mergeEntries(base, ours, theirs json.RawMessage, id string) (json.RawMessage, []string, error) {
+	baseEntries, _, err := entriesByID(base, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	theirEntries, theirOrder, err := entriesByID(theirs, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	ourEntries, ourOrder, err := entriesByID(ours, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	merged := make([]json.RawMessage, 0, len(ourOrder)+len(theirOrder))
+	var taken []string
+	for _, key := range ourOrder {
+		baseEntry, inBase := baseEntries[key]
+		theirEntry, inTheirs := theirEntries[key]
+		switch {
+		case inBase && !inTheirs:
+			taken = append(taken, key)
+		case inTheirs && !bytes.Equal(theirEntry, baseEntry) && bytes.Equal(ourEntries[key], baseEntry):
+			merged = append(merged, theirEntry)
+			taken = append(taken, key)
+		default:
+			merged = append(merged, ourEntries[key])
+		}
+	}
+	for _, key := range theirOrder {
+		_, inBase := baseEntries[key]
+		_, inOurs := ourEntries[key]
+		if !inBase && !inOurs {
+			merged = append(merged, theirEntries[key])
+			taken = append(taken, key)
+		}
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, taken, nil
+}
+
+// entriesByID returns the compactly encoded entries of the JSON list data keyed by the string value of their
+// id key, and the ids in the order of the list. A null or missing list has no entries.
+func entriesByID(data json.RawMessage, id string) (map[string][]byte, []string, error) {
+	var list []json.RawMessage
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, nil, err
+		}
+	}
+	entries := make(map[string][]byte, len(list))
+	order := make([]string, 0, len(list))
+	for _, entry := range list {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(entry, &fields); err != nil {
+			return nil, nil, err
+		}
+		var key string
+		_ = json.Unmarshal(fields[id], &key)
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, entry); err != nil {
+			return nil, nil, err
+		}
+		if _, ok := entries[key]; !ok {
+			order = append(order, key)
+		}
+		entries[key] = compacted.Bytes()
+	}
+	return entries, order, nil
+}
+
+// setField sets the field of the state whose JSON key is key to value, replacing it entirely. Keys of no field,
+// e.g. written by a newer version, are left out.
+func (s *State) setField(key string, value json.RawMessage) error {
+	v := reflect.ValueOf(s).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		if name != key {
+			continue
+		}
+		field := reflect.New(v.Type().Field(i).Type)
+		if err := json.Unmarshal(value, field.Interface()); err != nil {
+			return fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		v.Field(i).Set(field.Elem())
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Workspace is a named set of repositories that are shown together, e.g. the services of one project. While
+// a workspace is selected, only its repositories' tabs and instances are shown.
+type Workspace struct {
+	Name string `json:"name"`
+	// Repositories are the paths of the workspace's repositories, which are registered in the state.
+	Repositories []string `json:"repositories"`
+}
+
+// Contains returns true if the repository at path is in the workspace.
+func (w Workspace) Contains(path string) bool {
+	return slices.Contains(w.Repositories, path)
+}
+
+// validateWorkspaceName returns an error if name can't name a workspace.
+func validateWorkspaceName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("workspace name cannot be empty")
+	}
+	if strings.TrimSpace(name) != name {
+		return fmt.Errorf("workspace name %q cannot start or end with spaces", name)
+	}
+	return nil
+}
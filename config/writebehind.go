@@ -0,0 +1,31 @@
+package config
+
+// SetWriteBehind switches the state between saving on every mutation and write-behind, where mutations only
+// mark it dirty and a burst of them is saved at once by the next Flush. The TUI mutates the state many times
+// a second, e.g. when instances are created or repositories switched, and each save rewrites the whole file
+// under the lock. Whoever enables write-behind must call Flush on a timer and before exiting.
+func (s *State) SetWriteBehind(enabled bool) {
+	s.writeBehind = enabled
+}
+
+// Flush saves the state if it has mutations that weren't saved yet. Callers that need a mutation to be
+// durable, e.g. before handing off to another process, call it right after the mutation.
+func (s *State) Flush() error {
+	if !s.dirty {
+		return nil
+	}
+	if err := SaveState(s); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+// save persists a mutation of the state, or defers it to the next Flush under write-behind.
+func (s *State) save() error {
+	if s.writeBehind {
+		s.dirty = true
+		return nil
+	}
+	return SaveState(s)
+}
@@ -0,0 +1,20 @@
+package daemon
+
+import (
+	"claude-squad/log"
+	"os"
+	"os/exec"
+)
+
+// runAlert runs the alert command, if any, with CS_INSTANCE, CS_EVENT and CS_MESSAGE set to instance, event and
+// message.
+func runAlert(command, instance, event, message string) {
+	if command == "" {
+		return
+	}
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Env = append(os.Environ(), "CS_INSTANCE="+instance, "CS_EVENT="+event, "CS_MESSAGE="+message)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		log.WarningLog.Printf("alert command failed: %v: %s", err, output)
+	}
+}
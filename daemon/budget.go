@@ -0,0 +1,27 @@
+package daemon
+
+import (
+	"claude-squad/audit"
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session"
+)
+
+// enforceBudget interrupts instance if it's over its budget, and notifies the user with the alert command.
+// It returns whether the instance went over budget.
+func enforceBudget(cfg *config.Config, instance *session.Instance) bool {
+	reason, err := instance.CheckBudget()
+	if reason == "" {
+		return false
+	}
+	if err != nil {
+		log.ErrorLog.Printf("failed to interrupt %s, which is over budget: %v", instance.Title, err)
+	}
+	log.InfoLog.Printf("%s %s", instance.Title, reason)
+	audit.Record(audit.Entry{Action: "over_budget", Instance: instance.Title, Repository: instance.RepositoryPath, Detail: reason})
+
+	if cfg.Alerts.Enabled(config.AlertOverBudget) && !instance.Muted && !instance.Snoozed() {
+		runAlert(cfg.Alerts.Command, instance.Title, config.AlertOverBudget, instance.Title+" "+reason)
+	}
+	return true
+}
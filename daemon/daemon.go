@@ -1,54 +1,96 @@
 package daemon
 
 import (
+	"claude-squad/archive"
 	"claude-squad/config"
 	"claude-squad/log"
+	"claude-squad/power"
 	"claude-squad/session"
+	"claude-squad/telemetry"
 	"fmt"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"sync"
 	"syscall"
 	"time"
 )
 
-// RunDaemon runs the daemon process which iterates over all sessions and runs AutoYes mode on them.
-// It's expected that the main process kills the daemon when the main process starts.
-func RunDaemon(cfg *config.Config) error {
+// Wanted returns true if the daemon has work to do while claude-squad isn't open: answering prompts in
+// auto-yes mode, running the automation rules, enforcing the retention policy or pausing the squad once it's
+// idle.
+func Wanted(cfg *config.Config, autoYes bool) bool {
+	return autoYes || rulesConfigured() || cfg.Retention.Enabled || cfg.IdleShutdown.GetTimeout() > 0
+}
+
+// RunDaemon runs the daemon process which iterates over all sessions, runs AutoYes mode on them if autoYes
+// is set and enforces the retention policy. It's expected that the main process kills the daemon when the
+// main process starts.
+func RunDaemon(cfg *config.Config, autoYes bool) error {
 	log.InfoLog.Printf("starting daemon")
 	state := config.LoadState()
+	if err := state.ReadOnly(); err != nil {
+		return err
+	}
 	storage, err := session.NewStorage(state)
 	if err != nil {
 		return fmt.Errorf("failed to initialize storage: %w", err)
 	}
 
-	instances, err := storage.LoadInstances()
+	// Instances that fail to restore are left alone, and kept in storage.
+	instances, err := storage.RestoreInstances(nil)
 	if err != nil {
-		return fmt.Errorf("failed to load instacnes: %w", err)
+		log.ErrorLog.Printf("failed to restore instances: %v", err)
 	}
-	for _, instance := range instances {
-		// Assume AutoYes is true if the daemon is running.
-		instance.AutoYes = true
+	if autoYes {
+		for _, instance := range instances {
+			instance.AutoYes = true
+		}
 	}
 
 	pollInterval := time.Duration(cfg.DaemonPollInterval) * time.Millisecond
+	// Polls are slowed down in low-power mode, which is re-checked now and then as the machine goes on and off
+	// battery.
+	lowPower := power.LowPower(cfg.Refresh)
+	lastPowerCheck := time.Now()
 
 	// If we get an error for a session, it's likely that we'll keep getting the error. Log every 30 seconds.
 	everyN := log.NewEvery(60 * time.Second)
 
+	// The user's automation rules, if any.
+	ruleRunner := loadRules(cfg)
+
+	// The retention policy changes slowly, so it's enforced far less often than instances are polled.
+	var lastRetention time.Time
+
+	// Idle shutdown, if it's enabled. exitCh is closed when the daemon exits once the squad is paused.
+	idle := newIdleTracker(cfg.IdleShutdown)
+	exitCh := make(chan struct{})
+
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
 	stopCh := make(chan struct{})
 	go func() {
 		defer wg.Done()
-		ticker := time.NewTimer(pollInterval)
+		ticker := time.NewTimer(power.Scale(cfg.Refresh, pollInterval, lowPower))
 		for {
+			poll := telemetry.Start("daemon.poll", "instances", strconv.Itoa(len(instances)))
+			overBudget := false
 			for _, instance := range instances {
 				// We only store started instances, but check anyway.
-				if instance.Started() && !instance.Paused() {
-					if _, hasPrompt := instance.HasUpdated(); hasPrompt {
+				if instance.Started() && !instance.Inactive() {
+					if enforceBudget(cfg, instance) {
+						overBudget = true
+						continue
+					}
+					updated, hasPrompt := instance.HasUpdated()
+					if idle != nil {
+						idle.observe(instance, updated)
+					}
+					if hasPrompt && instance.AnswersPrompts() && (ruleRunner == nil || ruleRunner.approve(instance)) {
 						instance.TapEnter()
 						if err := instance.UpdateDiffStats(); err != nil {
 							if everyN.ShouldLog() {
@@ -56,9 +98,41 @@ func RunDaemon(cfg *config.Config) error {
 							}
 						}
 					}
+					if ruleRunner != nil && !instance.Paused() {
+						ruleRunner.poll(instance, updated)
+					}
+				}
+			}
+			poll.End()
+			// The daemon may be killed rather than stopped, so save instances over budget right away.
+			if overBudget {
+				if err := storage.SaveInstances(instances); err != nil {
+					log.ErrorLog.Printf("failed to save instances after enforcing budgets: %v", err)
+				}
+			}
+			// The daemon may be killed rather than stopped, so save paused instances right away.
+			if ruleRunner != nil && ruleRunner.takePaused() {
+				if err := storage.SaveInstances(instances); err != nil {
+					log.ErrorLog.Printf("failed to save instances after running rules: %v", err)
 				}
 			}
 
+			if cfg.Retention.Enabled && time.Since(lastRetention) >= retentionInterval {
+				lastRetention = time.Now()
+				instances = enforceRetention(cfg, storage, instances)
+			}
+
+			if idle != nil && idle.idle() {
+				shutdownIdle(cfg, storage, instances)
+				if cfg.IdleShutdown.ExitDaemon {
+					log.InfoLog.Printf("exiting daemon after idle shutdown")
+					close(exitCh)
+					return
+				}
+				// Everything is paused, so idle time is counted again from now.
+				idle.lastActive = time.Now()
+			}
+
 			// Handle stop before ticker.
 			select {
 			case <-stopCh:
@@ -66,16 +140,28 @@ func RunDaemon(cfg *config.Config) error {
 			default:
 			}
 
+			if time.Since(lastPowerCheck) >= power.CheckInterval {
+				lastPowerCheck = time.Now()
+				if low := power.LowPower(cfg.Refresh); low != lowPower {
+					lowPower = low
+					log.InfoLog.Printf("low-power mode %v", lowPower)
+				}
+			}
+
 			<-ticker.C
-			ticker.Reset(pollInterval)
+			ticker.Reset(power.Scale(cfg.Refresh, pollInterval, lowPower))
 		}
 	}()
 
 	// Notify on SIGINT (Ctrl+C) and SIGTERM. Save instances before
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	sig := <-sigChan
-	log.InfoLog.Printf("received signal %s", sig.String())
+	select {
+	case sig := <-sigChan:
+		log.InfoLog.Printf("received signal %s", sig.String())
+	case <-exitCh:
+		removePIDFile()
+	}
 
 	// Stop the goroutine so we don't race.
 	close(stopCh)
@@ -87,15 +173,70 @@ func RunDaemon(cfg *config.Config) error {
 	return nil
 }
 
-// LaunchDaemon launches the daemon process.
-func LaunchDaemon() error {
+// retentionInterval is how often the daemon enforces the retention policy.
+const retentionInterval = time.Hour
+
+// enforceRetention archives merged instances and deletes old archives according to the retention policy.
+// It returns the remaining instances.
+func enforceRetention(cfg *config.Config, storage *session.Storage, instances []*session.Instance) []*session.Instance {
+	data := make([]session.InstanceData, 0, len(instances))
+	for _, instance := range instances {
+		if instance.Started() {
+			data = append(data, instance.ToInstanceData())
+		}
+	}
+	plan, err := archive.NewPlan(cfg.Retention, data, time.Now())
+	if err != nil {
+		log.ErrorLog.Printf("failed to plan retention: %v", err)
+		return instances
+	}
+	for _, warning := range plan.Warnings {
+		log.WarningLog.Printf("retention: %s", warning)
+	}
+	if plan.Empty() {
+		return instances
+	}
+
+	dir, err := cfg.GetArchiveDir()
+	if err != nil {
+		log.ErrorLog.Printf("failed to get archive directory: %v", err)
+		return instances
+	}
+	archived, err := plan.Apply(dir, instances)
+	if err != nil {
+		log.ErrorLog.Printf("failed to enforce retention: %v", err)
+	}
+	log.InfoLog.Printf("retention: archived %d instances, deleted %d archives", len(archived), len(plan.Archives))
+	if len(archived) == 0 {
+		return instances
+	}
+
+	remaining := make([]*session.Instance, 0, len(instances))
+	for _, instance := range instances {
+		if !slices.Contains(archived, instance) {
+			remaining = append(remaining, instance)
+		}
+	}
+	// The daemon may be killed rather than stopped, so save right away.
+	if err := storage.SaveInstances(remaining); err != nil {
+		log.ErrorLog.Printf("failed to save instances after enforcing retention: %v", err)
+	}
+	return remaining
+}
+
+// LaunchDaemon launches the daemon process. If autoYes is set, it answers the instances' prompts.
+func LaunchDaemon(autoYes bool) error {
 	// Find the claude squad binary.
 	execPath, err := os.Executable()
 	if err != nil {
 		return fmt.Errorf("failed to get executable path: %w", err)
 	}
 
-	cmd := exec.Command(execPath, "--daemon")
+	args := []string{"--daemon"}
+	if autoYes {
+		args = append(args, "--autoyes")
+	}
+	cmd := exec.Command(execPath, args...)
 
 	// Detach the process from the parent
 	cmd.Stdin = nil
@@ -112,9 +253,9 @@ func LaunchDaemon() error {
 	log.InfoLog.Printf("started daemon child process with PID: %d", cmd.Process.Pid)
 
 	// Save PID to a file for later management
-	pidDir, err := config.GetConfigDir()
+	pidDir, err := config.GetStateDir()
 	if err != nil {
-		return fmt.Errorf("failed to get config directory: %w", err)
+		return fmt.Errorf("failed to get state directory: %w", err)
 	}
 
 	pidFile := filepath.Join(pidDir, "daemon.pid")
@@ -129,9 +270,9 @@ func LaunchDaemon() error {
 // StopDaemon attempts to stop a running daemon process if it exists. Returns no error if the daemon is not found
 // (assumes the daemon does not exist).
 func StopDaemon() error {
-	pidDir, err := config.GetConfigDir()
+	pidDir, err := config.GetStateDir()
 	if err != nil {
-		return fmt.Errorf("failed to get config directory: %w", err)
+		return fmt.Errorf("failed to get state directory: %w", err)
 	}
 
 	pidFile := filepath.Join(pidDir, "daemon.pid")
@@ -0,0 +1,26 @@
+package daemon
+
+import (
+	"claude-squad/config"
+	"claude-squad/rules"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWanted(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	require.False(t, Wanted(&config.Config{}, false))
+	require.True(t, Wanted(&config.Config{}, true))
+	require.True(t, Wanted(&config.Config{Retention: config.RetentionConfig{Enabled: true}}, false))
+	require.True(t, Wanted(&config.Config{IdleShutdown: config.IdleShutdownConfig{AfterHours: 4}}, false))
+
+	dir, err := config.GetConfigDir()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, rules.FileName), []byte(`on("ready", function(instance) end)`), 0644))
+	require.True(t, Wanted(&config.Config{}, false))
+}
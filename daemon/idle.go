@@ -0,0 +1,111 @@
+package daemon
+
+import (
+	"claude-squad/audit"
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// idleTracker tracks when the squad was last active, so it's paused once it's been idle for long.
+type idleTracker struct {
+	timeout time.Duration
+	// lastActive is when an instance last produced output or was attached to. The daemon starts when the
+	// user quits claude-squad, which counts as activity.
+	lastActive time.Time
+}
+
+// newIdleTracker returns a tracker for cfg's idle timeout, or nil if idle shutdown is disabled.
+func newIdleTracker(cfg config.IdleShutdownConfig) *idleTracker {
+	if cfg.GetTimeout() <= 0 {
+		return nil
+	}
+	return &idleTracker{timeout: cfg.GetTimeout(), lastActive: time.Now()}
+}
+
+// observe records instance as active if it produced output since it was last polled, going by updated, or
+// the user is attached to it.
+func (t *idleTracker) observe(instance *session.Instance, updated bool) {
+	if updated || instance.Attached() {
+		t.lastActive = time.Now()
+	}
+}
+
+// idle returns true if the squad has been idle for longer than the timeout.
+func (t *idleTracker) idle() bool {
+	return time.Since(t.lastActive) >= t.timeout
+}
+
+// shutdownIdle pauses the instances that are running, and notifies the user of what was paused. It returns
+// true if any instance was paused.
+func shutdownIdle(cfg *config.Config, storage *session.Storage, instances []*session.Instance) bool {
+	var paused, failed []string
+	for _, instance := range instances {
+		if !instance.Started() || instance.Inactive() {
+			continue
+		}
+		if err := instance.Pause(); err != nil {
+			log.ErrorLog.Printf("failed to pause idle instance %s: %v", instance.Title, err)
+			failed = append(failed, instance.Title)
+			continue
+		}
+		paused = append(paused, instance.Title)
+		audit.Record(audit.Entry{Action: "idle_paused", Instance: instance.Title, Repository: instance.RepositoryPath})
+	}
+	if len(paused) == 0 && len(failed) == 0 {
+		return false
+	}
+
+	message := idleSummary(cfg.IdleShutdown.AfterHours, paused, failed)
+	log.InfoLog.Printf("%s", message)
+	// The daemon may be killed rather than stopped, so save right away.
+	if err := storage.SaveInstances(instances); err != nil {
+		log.ErrorLog.Printf("failed to save instances after pausing idle instances: %v", err)
+	}
+	if cfg.Alerts.Enabled(config.AlertIdleShutdown) {
+		runAlert(cfg.Alerts.Command, "", config.AlertIdleShutdown, message)
+	}
+	return len(paused) > 0
+}
+
+// idleSummary describes what idle shutdown paused, and failed to pause.
+func idleSummary(afterHours float64, paused, failed []string) string {
+	idle := strconv.FormatFloat(afterHours, 'f', -1, 64) + " hours"
+	if afterHours == 1 {
+		idle = "1 hour"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "idle for %s", idle)
+	if len(paused) > 0 {
+		fmt.Fprintf(&b, ", paused %s", strings.Join(paused, ", "))
+	}
+	if len(failed) > 0 {
+		fmt.Fprintf(&b, ", failed to pause %s", strings.Join(failed, ", "))
+	}
+	return b.String()
+}
+
+// removePIDFile removes the daemon's PID file when the daemon exits by itself, so it isn't mistaken for a
+// running daemon.
+func removePIDFile() {
+	pidDir, err := config.GetStateDir()
+	if err != nil {
+		log.ErrorLog.Printf("failed to get state directory: %v", err)
+		return
+	}
+	pidFile := filepath.Join(pidDir, "daemon.pid")
+	data, err := os.ReadFile(pidFile)
+	// Another daemon may have been launched since.
+	if err != nil || strings.TrimSpace(string(data)) != strconv.Itoa(os.Getpid()) {
+		return
+	}
+	if err := os.Remove(pidFile); err != nil {
+		log.ErrorLog.Printf("failed to remove PID file: %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+package daemon
+
+import (
+	"claude-squad/config"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdleTracker(t *testing.T) {
+	require.Nil(t, newIdleTracker(config.IdleShutdownConfig{}))
+
+	idle := newIdleTracker(config.IdleShutdownConfig{AfterHours: 0.5})
+	require.False(t, idle.idle())
+	idle.lastActive = time.Now().Add(-31 * time.Minute)
+	require.True(t, idle.idle())
+
+	require.Equal(t, "idle for 1 hour, paused api, web",
+		idleSummary(1, []string{"api", "web"}, nil))
+	require.Equal(t, "idle for 2.5 hours, paused api, failed to pause docs",
+		idleSummary(2.5, []string{"api"}, []string{"docs"}))
+}
@@ -0,0 +1,150 @@
+package daemon
+
+import (
+	"claude-squad/audit"
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/rules"
+	"claude-squad/session"
+	"os"
+	"path/filepath"
+)
+
+// ruleRunner fires the user's automation rules as the daemon polls instances.
+type ruleRunner struct {
+	cfg    *config.Config
+	engine *rules.Engine
+	// working is whether each instance was working when last polled.
+	working map[*session.Instance]bool
+	// diffs are the diff sizes rules last saw for each instance.
+	diffs map[*session.Instance][2]int
+	// declined are the instances whose prompt rules declined to approve, so the rules aren't run again on
+	// every poll until the prompt is answered.
+	declined map[*session.Instance]bool
+	// paused is set when a rule paused an instance since the last save.
+	paused bool
+}
+
+// rulesConfigured returns true if there's a rules script in the config directory.
+func rulesConfigured() bool {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(dir, rules.FileName))
+	return err == nil
+}
+
+// loadRules loads the rules script from the config directory. It returns nil if there's no script or it
+// failed to load.
+func loadRules(cfg *config.Config) *ruleRunner {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		log.ErrorLog.Printf("failed to get config directory: %v", err)
+		return nil
+	}
+	engine, err := rules.Load(filepath.Join(dir, rules.FileName))
+	if err != nil {
+		log.ErrorLog.Printf("%v", err)
+		return nil
+	}
+	if engine == nil {
+		return nil
+	}
+	return &ruleRunner{
+		cfg:      cfg,
+		engine:   engine,
+		working:  make(map[*session.Instance]bool),
+		diffs:    make(map[*session.Instance][2]int),
+		declined: make(map[*session.Instance]bool),
+	}
+}
+
+// approve runs the prompt rules for instance and returns whether to approve its prompt.
+func (r *ruleRunner) approve(instance *session.Instance) bool {
+	if !r.engine.Handles(rules.EventPrompt) {
+		return true
+	}
+	if r.declined[instance] {
+		return false
+	}
+	if err := instance.UpdateDiffStats(); err != nil {
+		log.WarningLog.Printf("could not update diff stats for %s: %v", instance.Title, err)
+	}
+	// A rule may have paused the instance, leaving nothing to approve.
+	approve := r.fire(rules.EventPrompt, instance) && !instance.Paused()
+	r.declined[instance] = !approve
+	return approve
+}
+
+// poll runs the diff and ready rules for instance when it finishes working. updated is whether its pane
+// changed since it was last polled.
+func (r *ruleRunner) poll(instance *session.Instance, updated bool) {
+	wasWorking := r.working[instance]
+	r.working[instance] = updated
+	if updated {
+		// The pane changed, so any declined prompt has been answered.
+		delete(r.declined, instance)
+	}
+	if !wasWorking || updated {
+		return
+	}
+
+	if err := instance.UpdateDiffStats(); err != nil {
+		log.WarningLog.Printf("could not update diff stats for %s: %v", instance.Title, err)
+	}
+	if stats := instance.GetDiffStats(); stats != nil && stats.Error == nil {
+		diff := [2]int{stats.Added, stats.Removed}
+		if last, ok := r.diffs[instance]; !ok || last != diff {
+			r.diffs[instance] = diff
+			r.fire(rules.EventDiff, instance)
+		}
+	}
+	if !instance.Paused() {
+		r.fire(rules.EventReady, instance)
+	}
+}
+
+// fire runs the rules for event on instance and returns their decision.
+func (r *ruleRunner) fire(event string, instance *session.Instance) bool {
+	if !r.engine.Handles(event) {
+		return true
+	}
+	approve, err := r.engine.Fire(event, rules.NewSubject(instance), rules.Actions{
+		Pause: func() error {
+			if instance.Paused() {
+				return nil
+			}
+			if err := instance.Pause(); err != nil {
+				return err
+			}
+			r.paused = true
+			audit.Record(audit.Entry{Action: "rule_paused", Instance: instance.Title, Repository: instance.RepositoryPath, Detail: event})
+			return nil
+		},
+		Notify: func(message string) {
+			r.notify(instance, message)
+		},
+	})
+	if err != nil {
+		log.ErrorLog.Printf("%v", err)
+	}
+	return approve
+}
+
+// notify records a rule's message and runs the alert command, if any, with CS_MESSAGE set to it.
+func (r *ruleRunner) notify(instance *session.Instance, message string) {
+	log.InfoLog.Printf("rule notification for %s: %s", instance.Title, message)
+	audit.Record(audit.Entry{Action: "rule_notified", Instance: instance.Title, Repository: instance.RepositoryPath, Detail: message})
+	if instance.Muted || instance.Snoozed() {
+		return
+	}
+	runAlert(r.cfg.Alerts.Command, instance.Title, "rule", message)
+}
+
+// takePaused returns whether a rule paused an instance since it was last called.
+func (r *ruleRunner) takePaused() bool {
+	paused := r.paused
+	r.paused = false
+	return paused
+}
@@ -0,0 +1,150 @@
+// Package deps finds the dependencies added to a project's manifests and resolves their licenses.
+package deps
+
+import (
+	"bufio"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Ecosystem is the package ecosystem a dependency belongs to.
+type Ecosystem string
+
+const (
+	Go   Ecosystem = "go"
+	NPM  Ecosystem = "npm"
+	PyPI Ecosystem = "pypi"
+)
+
+// manifests maps the manifest file names that are understood to their ecosystem.
+var manifests = map[string]Ecosystem{
+	"go.mod":           Go,
+	"package.json":     NPM,
+	"requirements.txt": PyPI,
+}
+
+// Dependency is a package required by a manifest.
+type Dependency struct {
+	Ecosystem Ecosystem
+	Name      string
+	// Version is the version or version constraint as written in the manifest. It may be empty.
+	Version string
+}
+
+func (d Dependency) String() string {
+	if d.Version == "" {
+		return d.Name
+	}
+	return d.Name + " " + d.Version
+}
+
+// IsManifest reports whether file is a manifest that Parse understands.
+func IsManifest(file string) bool {
+	_, ok := manifests[path.Base(file)]
+	return ok
+}
+
+// Parse returns the dependencies declared in the manifest file with the given content. Unknown
+// manifests and malformed content yield no dependencies.
+func Parse(file, content string) []Dependency {
+	switch manifests[path.Base(file)] {
+	case Go:
+		return parseGoMod(content)
+	case NPM:
+		return parsePackageJSON(content)
+	case PyPI:
+		return parseRequirements(content)
+	}
+	return nil
+}
+
+// Added returns the dependencies of the manifest file that are in newContent but weren't in
+// oldContent. A dependency whose version changed isn't considered added.
+func Added(file, oldContent, newContent string) []Dependency {
+	existing := make(map[string]bool)
+	for _, dep := range Parse(file, oldContent) {
+		existing[dep.Name] = true
+	}
+	var added []Dependency
+	for _, dep := range Parse(file, newContent) {
+		if !existing[dep.Name] {
+			added = append(added, dep)
+		}
+	}
+	return added
+}
+
+func parseGoMod(content string) []Dependency {
+	var deps []Dependency
+	inRequire := false
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case inRequire && fields[0] == ")":
+			inRequire = false
+			continue
+		case fields[0] == "require" && len(fields) == 2 && fields[1] == "(":
+			inRequire = true
+			continue
+		case fields[0] == "require":
+			fields = fields[1:]
+		case !inRequire:
+			continue
+		}
+		if len(fields) == 2 {
+			deps = append(deps, Dependency{Ecosystem: Go, Name: fields[0], Version: fields[1]})
+		}
+	}
+	return deps
+}
+
+func parsePackageJSON(content string) []Dependency {
+	var pkg struct {
+		Dependencies         map[string]string `json:"dependencies"`
+		DevDependencies      map[string]string `json:"devDependencies"`
+		OptionalDependencies map[string]string `json:"optionalDependencies"`
+	}
+	if err := json.Unmarshal([]byte(content), &pkg); err != nil {
+		return nil
+	}
+	var deps []Dependency
+	for _, group := range []map[string]string{pkg.Dependencies, pkg.DevDependencies, pkg.OptionalDependencies} {
+		for name, version := range group {
+			deps = append(deps, Dependency{Ecosystem: NPM, Name: name, Version: version})
+		}
+	}
+	// Map iteration order is random; keep reports stable.
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Name < deps[j].Name })
+	return deps
+}
+
+func parseRequirements(content string) []Dependency {
+	var deps []Dependency
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		// Skip blank lines and options such as -r other.txt or -e ./local.
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+		end := strings.IndexAny(line, "=<>!~[;@ ")
+		if end == -1 {
+			deps = append(deps, Dependency{Ecosystem: PyPI, Name: line})
+			continue
+		}
+		dep := Dependency{Ecosystem: PyPI, Name: line[:end]}
+		if version, ok := strings.CutPrefix(strings.TrimSpace(line[end:]), "=="); ok {
+			dep.Version, _, _ = strings.Cut(strings.TrimSpace(version), ";")
+			dep.Version = strings.TrimSpace(dep.Version)
+		}
+		deps = append(deps, dep)
+	}
+	return deps
+}
@@ -0,0 +1,109 @@
+package deps
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdded(t *testing.T) {
+	tests := []struct {
+		file     string
+		old, new string
+		want     []Dependency
+	}{
+		{
+			file: "go.mod",
+			old:  "module example\n\nrequire github.com/a/a v1.0.0\n",
+			new: `module example
+
+require github.com/a/a v1.1.0
+
+require (
+	github.com/b/b v0.2.0 // indirect
+	golang.org/x/c v0.0.0-20240101000000-abcdef123456
+)
+`,
+			want: []Dependency{
+				{Ecosystem: Go, Name: "github.com/b/b", Version: "v0.2.0"},
+				{Ecosystem: Go, Name: "golang.org/x/c", Version: "v0.0.0-20240101000000-abcdef123456"},
+			},
+		},
+		{
+			file: "web/package.json",
+			old:  `{"dependencies": {"react": "^18.0.0"}}`,
+			new:  `{"dependencies": {"react": "^18.2.0", "left-pad": "1.3.0"}, "devDependencies": {"jest": "~29.0.0"}}`,
+			want: []Dependency{
+				{Ecosystem: NPM, Name: "jest", Version: "~29.0.0"},
+				{Ecosystem: NPM, Name: "left-pad", Version: "1.3.0"},
+			},
+		},
+		{
+			file: "requirements.txt",
+			old:  "",
+			new:  "# deps\n-r base.txt\nrequests==2.31.0\nflask >= 3.0\nnumpy ; python_version > '3.8'\n",
+			want: []Dependency{
+				{Ecosystem: PyPI, Name: "requests", Version: "2.31.0"},
+				{Ecosystem: PyPI, Name: "flask"},
+				{Ecosystem: PyPI, Name: "numpy"},
+			},
+		},
+		{
+			file: "Cargo.toml",
+			new:  "[dependencies]\nserde = \"1\"\n",
+		},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, Added(tt.file, tt.old, tt.new), tt.file)
+	}
+}
+
+func TestDisallowed(t *testing.T) {
+	disallowed := []string{"GPL-3.0", "AGPL-3.0"}
+
+	license, ok := Disallowed([]string{"MIT", "GPL-3.0-or-later"}, disallowed)
+	require.True(t, ok)
+	require.Equal(t, "GPL-3.0", license)
+
+	license, ok = Disallowed([]string{"(MIT OR agpl-3.0-only)"}, disallowed)
+	require.True(t, ok)
+	require.Equal(t, "AGPL-3.0", license)
+
+	_, ok = Disallowed([]string{"LGPL-3.0", "Apache-2.0"}, disallowed)
+	require.False(t, ok)
+}
+
+func TestResolverLicenses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/systems/NPM/packages/left-pad/versions/1.3.0":
+			fmt.Fprint(w, `{"licenses": ["WTFPL"]}`)
+		case "/systems/PYPI/packages/flask":
+			fmt.Fprint(w, `{"versions": [{"versionKey": {"version": "2.0.0"}}, {"versionKey": {"version": "3.0.0"}, "isDefault": true}]}`)
+		case "/systems/PYPI/packages/flask/versions/3.0.0":
+			fmt.Fprint(w, `{"licenses": ["BSD-3-Clause"]}`)
+		case "/systems/GO/packages/github.com%2Fa%2Fa/versions/v1.0.0":
+			fmt.Fprint(w, `{"licenses": ["Apache-2.0"]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	resolver := &Resolver{Endpoint: server.URL, Client: server.Client()}
+
+	for dep, want := range map[Dependency][]string{
+		{Ecosystem: NPM, Name: "left-pad", Version: "^1.3.0"}:      {"WTFPL"},
+		{Ecosystem: PyPI, Name: "flask"}:                           {"BSD-3-Clause"},
+		{Ecosystem: Go, Name: "github.com/a/a", Version: "v1.0.0"}: {"Apache-2.0"},
+	} {
+		licenses, err := resolver.Licenses(dep)
+		require.NoError(t, err, dep.String())
+		require.Equal(t, want, licenses, dep.String())
+	}
+
+	_, err := resolver.Licenses(Dependency{Ecosystem: NPM, Name: "missing", Version: "1.0.0"})
+	require.Error(t, err)
+}
@@ -0,0 +1,121 @@
+package deps
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultEndpoint is the deps.dev API used to look up licenses.
+const DefaultEndpoint = "https://api.deps.dev/v3"
+
+// RequestTimeout bounds each request to deps.dev.
+const RequestTimeout = 10 * time.Second
+
+// systems maps ecosystems to their deps.dev system names.
+var systems = map[Ecosystem]string{
+	Go:   "GO",
+	NPM:  "NPM",
+	PyPI: "PYPI",
+}
+
+// Resolver looks up the licenses of dependencies on deps.dev.
+type Resolver struct {
+	Endpoint string
+	Client   *http.Client
+}
+
+// NewResolver returns a Resolver for the public deps.dev API that sends its requests with client.
+func NewResolver(client *http.Client) *Resolver {
+	return &Resolver{Endpoint: DefaultEndpoint, Client: client}
+}
+
+// Licenses returns the SPDX license expressions of dep. If the manifest doesn't pin an exact version,
+// the package's default version is used.
+func (r *Resolver) Licenses(dep Dependency) ([]string, error) {
+	system, ok := systems[dep.Ecosystem]
+	if !ok {
+		return nil, fmt.Errorf("unsupported ecosystem %q", dep.Ecosystem)
+	}
+	packageURL := fmt.Sprintf("%s/systems/%s/packages/%s", r.Endpoint, system, url.PathEscape(dep.Name))
+
+	version := exactVersion(dep)
+	if version == "" {
+		var pkg struct {
+			Versions []struct {
+				VersionKey struct {
+					Version string `json:"version"`
+				} `json:"versionKey"`
+				IsDefault bool `json:"isDefault"`
+			} `json:"versions"`
+		}
+		if err := r.get(packageURL, &pkg); err != nil {
+			return nil, err
+		}
+		for _, v := range pkg.Versions {
+			if v.IsDefault {
+				version = v.VersionKey.Version
+			}
+		}
+		if version == "" {
+			return nil, fmt.Errorf("no default version of %s", dep.Name)
+		}
+	}
+
+	var v struct {
+		Licenses []string `json:"licenses"`
+	}
+	if err := r.get(packageURL+"/versions/"+url.PathEscape(version), &v); err != nil {
+		return nil, err
+	}
+	return v.Licenses, nil
+}
+
+func (r *Resolver) get(url string, out any) error {
+	resp, err := r.Client.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to look up license: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to look up license: %s returned %s", url, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse license lookup response: %w", err)
+	}
+	return nil
+}
+
+// exactVersion returns the version dep pins, or "" if it's a range.
+func exactVersion(dep Dependency) string {
+	version := dep.Version
+	if dep.Ecosystem == NPM {
+		// Caret and tilde ranges are resolved to the version they start from.
+		version = strings.TrimLeft(version, "^~=v")
+	}
+	if version == "" || strings.ContainsAny(version, " <>|*xX") {
+		return ""
+	}
+	return version
+}
+
+// Disallowed returns the first of disallowed that a license expression names. Identifiers are matched
+// case-insensitively and "-only"/"-or-later" variants match their base identifier, so "GPL-3.0" matches
+// "GPL-3.0-or-later" but not "LGPL-3.0".
+func Disallowed(licenses, disallowed []string) (string, bool) {
+	for _, expression := range licenses {
+		for _, token := range strings.FieldsFunc(expression, func(r rune) bool {
+			return r == ' ' || r == '(' || r == ')'
+		}) {
+			for _, id := range disallowed {
+				if strings.EqualFold(token, id) || strings.HasPrefix(strings.ToLower(token), strings.ToLower(id)+"-") {
+					return id, true
+				}
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,240 @@
+// Package digest summarizes the squad's activity over a day or a week, the instances created, merged and
+// abandoned and the ones waiting on the user, for leads tracking agent-assisted throughput. Digests are
+// printed or sent by `claude-squad digest`, which is meant to be run from cron.
+package digest
+
+import (
+	"claude-squad/audit"
+	"claude-squad/config"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Periods a digest can cover.
+const (
+	Daily  = "daily"
+	Weekly = "weekly"
+)
+
+// Audit log actions the digest, and the repository stats, are built from.
+const (
+	ActionCreated = "instance_created"
+	ActionKilled  = "instance_killed"
+)
+
+// abandoned is the detail of instance_killed entries for instances whose branches weren't merged. The others
+// have "merged " followed by when.
+const abandoned = "abandoned"
+
+// taskPrefix starts the detail of instance_created entries for instances started from a task, followed by the
+// task's name.
+const taskPrefix = "task "
+
+// RecordCreated records the creation of instance in the audit log.
+func RecordCreated(instance *session.Instance) {
+	entry := audit.Entry{Action: ActionCreated, Instance: instance.Title, Repository: repository(instance)}
+	if instance.Task != "" {
+		entry.Detail = taskPrefix + instance.Task
+	}
+	audit.Record(entry)
+}
+
+// RecordKilled records that instance was killed in the audit log, and whether its branch was merged, which
+// may take a few git commands to check.
+func RecordKilled(instance *session.Instance) {
+	detail := abandoned
+	mergedAt := instance.MergedAt
+	if mergedAt.IsZero() {
+		if worktree, err := instance.GetGitWorktree(); err == nil {
+			if at, merged, err := worktree.MergedAt(); err == nil && merged {
+				mergedAt = at
+			}
+		}
+	}
+	if !mergedAt.IsZero() {
+		detail = "merged " + mergedAt.Format(time.RFC3339)
+	}
+	audit.Record(audit.Entry{Action: ActionKilled, Instance: instance.Title, Repository: repository(instance), Detail: detail})
+}
+
+// MergedAt returns when the branch of the instance an instance_killed entry records was merged, if it was.
+func MergedAt(entry audit.Entry) (time.Time, bool) {
+	at, ok := strings.CutPrefix(entry.Detail, "merged ")
+	if entry.Action != ActionKilled || !ok {
+		return time.Time{}, false
+	}
+	mergedAt, err := time.Parse(time.RFC3339, at)
+	return mergedAt, err == nil
+}
+
+// Task returns the task the instance an instance_created entry records was started from, if any.
+func Task(entry audit.Entry) string {
+	if entry.Action != ActionCreated {
+		return ""
+	}
+	if task, ok := strings.CutPrefix(entry.Detail, taskPrefix); ok {
+		return task
+	}
+	return ""
+}
+
+func repository(instance *session.Instance) string {
+	if worktree, err := instance.GetGitWorktree(); err == nil {
+		return worktree.GetRepoPath()
+	}
+	return instance.RepositoryPath
+}
+
+// Instance is an instance that's still around, as the digest sees it.
+type Instance struct {
+	Title      string
+	Repository string
+	CreatedAt  time.Time
+	// MergedAt is when the instance's branch was merged, if it has been.
+	MergedAt time.Time
+	// ReadySince is when the instance became ready for the user, if it's waiting on them.
+	ReadySince time.Time
+}
+
+// Item is an instance listed in a digest.
+type Item struct {
+	Title      string
+	Repository string
+	Time       time.Time
+}
+
+// Digest is the activity between From and To.
+type Digest struct {
+	Period    string
+	From, To  time.Time
+	Created   []Item
+	Merged    []Item
+	Abandoned []Item
+	// Ready are the instances waiting on the user, longest waiting first.
+	Ready []Item
+}
+
+// Build returns the digest for the period ending at now, from the audit log entries and the instances
+// still around.
+func Build(period string, now time.Time, entries []audit.Entry, instances []Instance) Digest {
+	from := now.AddDate(0, 0, -1)
+	if period == Weekly {
+		from = now.AddDate(0, 0, -7)
+	}
+	d := Digest{Period: period, From: from, To: now}
+	inPeriod := func(t time.Time) bool {
+		return !t.Before(from) && t.Before(now)
+	}
+	add := func(items []Item, item Item) []Item {
+		if slices.ContainsFunc(items, func(i Item) bool { return i.Title == item.Title && i.Repository == item.Repository }) {
+			return items
+		}
+		return append(items, item)
+	}
+
+	for _, entry := range entries {
+		item := Item{Title: entry.Instance, Repository: entry.Repository, Time: entry.Time}
+		switch {
+		case entry.Action == ActionCreated && inPeriod(entry.Time):
+			d.Created = add(d.Created, item)
+		case entry.Action == ActionKilled && entry.Detail == abandoned && inPeriod(entry.Time):
+			d.Abandoned = add(d.Abandoned, item)
+		case entry.Action == ActionKilled:
+			if mergedAt, merged := MergedAt(entry); merged && inPeriod(mergedAt) {
+				item.Time = mergedAt
+				d.Merged = add(d.Merged, item)
+			}
+		}
+	}
+	for _, instance := range instances {
+		if inPeriod(instance.CreatedAt) {
+			d.Created = add(d.Created, Item{Title: instance.Title, Repository: instance.Repository, Time: instance.CreatedAt})
+		}
+		if inPeriod(instance.MergedAt) {
+			d.Merged = add(d.Merged, Item{Title: instance.Title, Repository: instance.Repository, Time: instance.MergedAt})
+		}
+		if !instance.ReadySince.IsZero() {
+			d.Ready = append(d.Ready, Item{Title: instance.Title, Repository: instance.Repository, Time: instance.ReadySince})
+		}
+	}
+
+	for _, items := range [][]Item{d.Created, d.Merged, d.Abandoned, d.Ready} {
+		slices.SortStableFunc(items, func(a, b Item) int { return a.Time.Compare(b.Time) })
+	}
+	return d
+}
+
+// Collect returns the digest for the period ending now, checking which of the instances' branches have
+// merged.
+func Collect(period string, now time.Time) (Digest, error) {
+	entries, err := audit.Read()
+	if err != nil {
+		return Digest{}, err
+	}
+	storage, err := session.NewStorage(config.LoadState())
+	if err != nil {
+		return Digest{}, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	data, err := storage.LoadInstanceData()
+	if err != nil {
+		return Digest{}, err
+	}
+
+	instances := make([]Instance, 0, len(data))
+	for _, d := range data {
+		instance := Instance{Title: d.Title, Repository: d.Worktree.RepoPath, CreatedAt: d.CreatedAt, MergedAt: d.MergedAt}
+		if instance.MergedAt.IsZero() {
+			worktree := git.NewGitWorktreeFromStorage(d.Worktree.RepoPath, d.Worktree.WorktreePath,
+				d.Worktree.SessionName, d.Worktree.BranchName, d.Worktree.BaseCommitSHA)
+			if mergedAt, merged, err := worktree.MergedAt(); err == nil && merged {
+				instance.MergedAt = mergedAt
+			}
+		}
+		if d.Status == session.Ready && instance.MergedAt.IsZero() {
+			instance.ReadySince = d.UpdatedAt
+		}
+		instances = append(instances, instance)
+	}
+	return Build(period, now, entries, instances), nil
+}
+
+// Subject returns the digest's one-line title, e.g. for an email subject.
+func (d Digest) Subject() string {
+	return fmt.Sprintf("Claude Squad %s digest: %d created, %d merged, %d abandoned, %d ready",
+		d.Period, len(d.Created), len(d.Merged), len(d.Abandoned), len(d.Ready))
+}
+
+// String renders the digest as plain text.
+func (d Digest) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n%s to %s\n", d.Subject(), d.From.Format("Mon Jan 2 15:04"), d.To.Format("Mon Jan 2 15:04"))
+	sections := []struct {
+		title string
+		items []Item
+		verb  string
+	}{
+		{"Waiting on you", d.Ready, "ready since"},
+		{"Merged", d.Merged, "merged"},
+		{"Abandoned", d.Abandoned, "killed"},
+		{"Created", d.Created, "created"},
+	}
+	for _, section := range sections {
+		if len(section.items) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s (%d):\n", section.title, len(section.items))
+		for _, item := range section.items {
+			fmt.Fprintf(&b, "  - %s", item.Title)
+			if item.Repository != "" {
+				fmt.Fprintf(&b, " (%s)", filepath.Base(item.Repository))
+			}
+			fmt.Fprintf(&b, ", %s %s\n", section.verb, item.Time.Format("Mon Jan 2 15:04"))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,64 @@
+package digest
+
+import (
+	"claude-squad/audit"
+	"claude-squad/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuild(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	hoursAgo := func(h int) time.Time { return now.Add(-time.Duration(h) * time.Hour) }
+
+	entries := []audit.Entry{
+		{Time: hoursAgo(30), Action: ActionCreated, Instance: "old", Repository: "/src/app"},
+		{Time: hoursAgo(20), Action: ActionCreated, Instance: "login", Repository: "/src/app"},
+		{Time: hoursAgo(10), Action: ActionKilled, Instance: "login", Repository: "/src/app",
+			Detail: "merged " + hoursAgo(12).Format(time.RFC3339)},
+		{Time: hoursAgo(5), Action: ActionKilled, Instance: "spike", Repository: "/src/app", Detail: abandoned},
+		{Time: hoursAgo(5), Action: "instance_frozen", Instance: "spike"},
+	}
+	instances := []Instance{
+		{Title: "docs", Repository: "/src/site", CreatedAt: hoursAgo(3), ReadySince: hoursAgo(1)},
+		{Title: "cache", Repository: "/src/app", CreatedAt: hoursAgo(50), MergedAt: hoursAgo(2)},
+		{Title: "api", Repository: "/src/app", CreatedAt: hoursAgo(60), ReadySince: hoursAgo(40)},
+	}
+
+	d := Build(Daily, now, entries, instances)
+	require.Equal(t, []Item{
+		{Title: "login", Repository: "/src/app", Time: hoursAgo(20)},
+		{Title: "docs", Repository: "/src/site", Time: hoursAgo(3)},
+	}, d.Created)
+	require.Equal(t, []Item{
+		{Title: "login", Repository: "/src/app", Time: hoursAgo(12)},
+		{Title: "cache", Repository: "/src/app", Time: hoursAgo(2)},
+	}, d.Merged)
+	require.Equal(t, []Item{{Title: "spike", Repository: "/src/app", Time: hoursAgo(5)}}, d.Abandoned)
+	require.Equal(t, []Item{
+		{Title: "api", Repository: "/src/app", Time: hoursAgo(40)},
+		{Title: "docs", Repository: "/src/site", Time: hoursAgo(1)},
+	}, d.Ready)
+	require.Equal(t, "Claude Squad daily digest: 2 created, 2 merged, 1 abandoned, 2 ready", d.Subject())
+	require.Contains(t, d.String(), "Waiting on you (2):\n  - api (app), ready since Wed Oct 14 17:00\n")
+
+	d = Build(Weekly, now, entries, instances)
+	require.Len(t, d.Created, 5)
+	require.Equal(t, now.AddDate(0, 0, -7), d.From)
+}
+
+func TestSendCommand(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "digest")
+	d := Digest{Period: Daily, From: time.Now().AddDate(0, 0, -1), To: time.Now()}
+	require.NoError(t, Send(config.DigestConfig{Command: `{ echo "$CS_SUBJECT"; cat; } > ` + out}, config.NetworkConfig{}, d))
+
+	sent, err := os.ReadFile(out)
+	require.NoError(t, err)
+	require.Equal(t, d.Subject()+"\n"+d.String(), string(sent))
+
+	require.ErrorContains(t, Send(config.DigestConfig{}, config.NetworkConfig{}, d), "no digest delivery")
+}
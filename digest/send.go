@@ -0,0 +1,108 @@
+package digest
+
+import (
+	"claude-squad/config"
+	"claude-squad/network"
+	"claude-squad/secrets"
+	"crypto/tls"
+	"fmt"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Send delivers the digest with the configured command, or by email. Email is sent over TLS, trusting the
+// certificate authorities of the network config, if the server supports it. Email is disabled in air-gapped
+// mode; the command, which is the user's own, still runs.
+func Send(cfg config.DigestConfig, networkCfg config.NetworkConfig, d Digest) error {
+	if cfg.Command != "" {
+		cmd := exec.Command("sh", "-c", cfg.Command)
+		cmd.Env = append(os.Environ(), "CS_SUBJECT="+d.Subject())
+		cmd.Stdin = strings.NewReader(d.String())
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("digest command failed: %w: %s", err, output)
+		}
+		return nil
+	}
+
+	if err := network.Check("emailing the digest"); err != nil {
+		return err
+	}
+	server := cfg.SMTP
+	if server.Host == "" || server.From == "" || len(server.To) == 0 {
+		return fmt.Errorf("no digest delivery is configured, set digest.command or digest.smtp's host, from and to in the config")
+	}
+	var auth smtp.Auth
+	if server.User != "" {
+		password, err := secrets.Get(server.GetPasswordSecret())
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", server.User, password, server.Host)
+	}
+	tlsConfig, err := network.TLSConfig(networkCfg)
+	if err != nil {
+		return err
+	}
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	tlsConfig.ServerName = server.Host
+	addr := fmt.Sprintf("%s:%d", server.Host, server.GetPort())
+	if err := sendMail(addr, tlsConfig, auth, server.From, server.To, message(server, d)); err != nil {
+		return fmt.Errorf("failed to email digest: %w", err)
+	}
+	return nil
+}
+
+// sendMail is smtp.SendMail with the TLS configuration used for STARTTLS, which smtp.SendMail doesn't take.
+func sendMail(addr string, tlsConfig *tls.Config, auth smtp.Auth, from string, to []string, msg []byte) error {
+	c, err := smtp.Dial(addr)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := c.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message returns the digest as an email.
+func message(server config.SMTPConfig, d Digest) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", server.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(server.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", d.Subject())
+	fmt.Fprintf(&b, "Date: %s\r\n", d.To.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(d.String(), "\n", "\r\n"))
+	return []byte(b.String())
+}
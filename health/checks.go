@@ -0,0 +1,172 @@
+package health
+
+import (
+	"claude-squad/config"
+	"claude-squad/session/git"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Names of the built-in checks.
+const (
+	PaneResponsive = "pane_responsive"
+	ProcessAlive   = "process_alive"
+	GitIndex       = "git_index"
+	DiskSpace      = "disk_space"
+)
+
+var (
+	// paneTimeout is how long the pane may take to be captured before it's reported as unresponsive.
+	paneTimeout = 5 * time.Second
+	// staleLockAge is how old an index.lock is reported as stale at. Git holds it for the duration of a
+	// command, which rarely takes more than a few seconds.
+	staleLockAge = 2 * time.Minute
+	// probeTimeout bounds how long a probe may run.
+	probeTimeout = 30 * time.Second
+)
+
+// builtinChecks returns the built-in checks.
+func builtinChecks(cfg config.HealthConfig) []Check {
+	return []Check{
+		{Name: PaneResponsive, Run: checkPane},
+		{Name: ProcessAlive, Run: checkProcess},
+		{Name: GitIndex, Run: checkIndexLock, Remediate: removeIndexLock},
+		{Name: DiskSpace, Run: func(ctx context.Context, target Target) Result {
+			return checkDiskSpace(target, cfg.GetMinFreeDisk())
+		}},
+	}
+}
+
+// checkPane fails if the pane can't be captured, or takes longer than paneTimeout to be, which happens when
+// tmux itself is stuck.
+func checkPane(ctx context.Context, target Target) Result {
+	if target.Pane == nil {
+		return Result{}
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := target.Pane()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return Result{Status: Failing, Message: "pane not responding"}
+		}
+		return Result{}
+	case <-time.After(paneTimeout):
+		return Result{Status: Failing, Message: fmt.Sprintf("pane not responding for %s", paneTimeout)}
+	case <-ctx.Done():
+		return Result{}
+	}
+}
+
+// checkProcess fails if the program has exited.
+func checkProcess(ctx context.Context, target Target) Result {
+	if target.ProgramAlive == nil {
+		return Result{}
+	}
+	alive, err := target.ProgramAlive()
+	if err != nil || alive {
+		// A session that can't be inspected is reported by the pane check.
+		return Result{}
+	}
+	return Result{Status: Failing, Message: "program exited"}
+}
+
+// indexLockPath returns the path of the index.lock of the worktree.
+func indexLockPath(worktree string) (string, error) {
+	output, err := exec.Command("git", "-C", worktree, "rev-parse", "--git-path", "index.lock").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the git directory of %s: %w", worktree, err)
+	}
+	path := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(worktree, path)
+	}
+	return path, nil
+}
+
+// staleIndexLock returns the path of the worktree's index.lock and how old it is, if it has been held for
+// longer than staleLockAge.
+func staleIndexLock(worktree string) (string, time.Duration, bool) {
+	if worktree == "" {
+		return "", 0, false
+	}
+	path, err := indexLockPath(worktree)
+	if err != nil {
+		return "", 0, false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", 0, false
+	}
+	age := time.Since(info.ModTime())
+	return path, age, age >= staleLockAge
+}
+
+// checkIndexLock warns about an index.lock that was left behind, e.g. by a git command that crashed, which
+// makes every later git command in the worktree fail.
+func checkIndexLock(ctx context.Context, target Target) Result {
+	if _, age, stale := staleIndexLock(target.Worktree); stale {
+		return Result{Status: Warning, Message: fmt.Sprintf("index.lock held for %s", age.Round(time.Minute))}
+	}
+	return Result{}
+}
+
+// removeIndexLock removes the worktree's index.lock if it's still stale.
+func removeIndexLock(target Target) error {
+	path, _, stale := staleIndexLock(target.Worktree)
+	if !stale {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return nil
+}
+
+// checkDiskSpace fails if the disk of the worktree has less than minFree bytes free.
+func checkDiskSpace(target Target, minFree uint64) Result {
+	if target.Worktree == "" {
+		return Result{}
+	}
+	free, err := git.FreeSpace(target.Worktree)
+	if err != nil || free >= minFree {
+		return Result{}
+	}
+	return Result{Status: Failing, Message: fmt.Sprintf("disk full, %d MB free", free>>20)}
+}
+
+// probeCheck returns the check running probe in the worktrees of the instances running its programs.
+func probeCheck(probe config.HealthProbe) Check {
+	return Check{
+		Name:     probe.Name,
+		Programs: probe.Programs,
+		Run: func(ctx context.Context, target Target) Result {
+			if target.Worktree == "" {
+				return Result{}
+			}
+			ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+			defer cancel()
+
+			cmd := exec.CommandContext(ctx, "sh", "-c", probe.Command)
+			cmd.Dir = target.Worktree
+			cmd.Env = append(os.Environ(), "CS_INSTANCE="+target.Title)
+			output, err := cmd.CombinedOutput()
+			if err == nil {
+				return Result{}
+			}
+			message := probe.Name + " failed"
+			if line, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n"); line != "" {
+				message += ": " + line
+			}
+			return Result{Status: Failing, Message: message}
+		},
+	}
+}
@@ -0,0 +1,159 @@
+// Package health checks that instances are healthy: that their panes respond, their programs are alive, their
+// worktrees' git index isn't locked and their disks aren't full, plus the probes configured for their programs.
+// Some of the problems the checks find can be fixed automatically.
+package health
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session"
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Status is the outcome of a check.
+type Status int
+
+const (
+	// OK means the check found no problem, or couldn't run on the instance.
+	OK Status = iota
+	// Warning means the check found a problem the instance may recover from.
+	Warning
+	// Failing means the check found a problem the instance won't recover from by itself.
+	Failing
+)
+
+// Target is the instance a check inspects.
+type Target struct {
+	Title   string
+	Program string
+	// Worktree is the instance's worktree directory. It's empty if the instance has none.
+	Worktree string
+	// Pane captures the pane running the instance's program.
+	Pane func() (string, error)
+	// ProgramAlive returns true if the instance's program is still running.
+	ProgramAlive func() (bool, error)
+}
+
+// TargetFor returns the target of the checks of instance.
+func TargetFor(instance *session.Instance) Target {
+	target := Target{
+		Title:        instance.Title,
+		Program:      instance.Program,
+		Pane:         instance.Preview,
+		ProgramAlive: instance.ProgramAlive,
+	}
+	if worktree, err := instance.GetGitWorktree(); err == nil {
+		target.Worktree = worktree.GetWorktreePath()
+	}
+	return target
+}
+
+// Result is the outcome of a check on an instance.
+type Result struct {
+	Check  string
+	Status Status
+	// Message describes the problem the check found.
+	Message string
+}
+
+// Check is a health check.
+type Check struct {
+	Name string
+	// Programs are the programs the check applies to, like "claude" or "aider". All programs if empty.
+	Programs []string
+	// Run checks the target. The result's Check is filled in by the Checker.
+	Run func(ctx context.Context, target Target) Result
+	// Remediate fixes the problem Run found, if the check can.
+	Remediate func(target Target) error
+}
+
+// appliesTo returns true if the check applies to instances running program.
+func (c Check) appliesTo(program string) bool {
+	if len(c.Programs) == 0 {
+		return true
+	}
+	fields := strings.Fields(program)
+	return len(fields) > 0 && slices.Contains(c.Programs, filepath.Base(fields[0]))
+}
+
+// Report is the outcome of the checks on an instance.
+type Report struct {
+	// Results are the outcomes of the checks that found a problem.
+	Results []Result
+	// Remediated are the problems that were found and fixed.
+	Remediated []Result
+}
+
+// Status returns the worst status of the checks.
+func (r Report) Status() Status {
+	status := OK
+	for _, result := range r.Results {
+		status = max(status, result.Status)
+	}
+	return status
+}
+
+// Summary describes the problems the checks found, or returns "" if there are none.
+func (r Report) Summary() string {
+	messages := make([]string, len(r.Results))
+	for i, result := range r.Results {
+		messages[i] = result.Message
+	}
+	return strings.Join(messages, ", ")
+}
+
+// Checker runs the health checks on instances.
+type Checker struct {
+	checks []Check
+	// remediate are the names of the checks whose problems are fixed automatically.
+	remediate []string
+}
+
+// NewChecker returns a Checker running the built-in checks that cfg doesn't disable and its probes.
+func NewChecker(cfg config.HealthConfig) *Checker {
+	c := &Checker{remediate: cfg.Remediate}
+	for _, check := range builtinChecks(cfg) {
+		if !slices.Contains(cfg.Disabled, check.Name) {
+			c.checks = append(c.checks, check)
+		}
+	}
+	for _, probe := range cfg.Probes {
+		c.checks = append(c.checks, probeCheck(probe))
+	}
+	return c
+}
+
+// Add adds check to the checks run on each instance.
+func (c *Checker) Add(check Check) {
+	c.checks = append(c.checks, check)
+}
+
+// Run runs the checks that apply to target and fixes the problems of the checks configured to be remediated.
+func (c *Checker) Run(ctx context.Context, target Target) Report {
+	var report Report
+	for _, check := range c.checks {
+		if !check.appliesTo(target.Program) {
+			continue
+		}
+		result := check.Run(ctx, target)
+		result.Check = check.Name
+		if result.Status == OK {
+			continue
+		}
+		if check.Remediate != nil && slices.Contains(c.remediate, check.Name) {
+			err := check.Remediate(target)
+			if err == nil {
+				log.InfoLog.Printf("health: fixed %s of %s: %s", check.Name, target.Title, result.Message)
+				report.Remediated = append(report.Remediated, result)
+				continue
+			}
+			result.Message = fmt.Sprintf("%s (not fixed: %v)", result.Message, err)
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
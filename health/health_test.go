@@ -0,0 +1,60 @@
+package health
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	repo := t.TempDir()
+	output, err := exec.Command("git", "-C", repo, "init").CombinedOutput()
+	require.NoError(t, err, string(output))
+	lock := filepath.Join(repo, ".git", "index.lock")
+	require.NoError(t, os.WriteFile(lock, nil, 0644))
+
+	target := Target{
+		Title:        "test",
+		Program:      "aider --model sonnet",
+		Worktree:     repo,
+		Pane:         func() (string, error) { return "", errors.New("no server running") },
+		ProgramAlive: func() (bool, error) { return true, nil },
+	}
+	checker := NewChecker(config.HealthConfig{
+		Disabled: []string{DiskSpace},
+		Probes: []config.HealthProbe{
+			{Name: "aider", Command: "echo 'no model'; exit 1", Programs: []string{"aider"}},
+			{Name: "claude", Command: "exit 1", Programs: []string{"claude"}},
+		},
+	})
+
+	// A fresh index.lock is held by a running git command.
+	report := checker.Run(context.Background(), target)
+	require.Equal(t, Failing, report.Status())
+	require.Equal(t, "pane not responding, aider failed: no model", report.Summary())
+
+	require.NoError(t, os.Chtimes(lock, time.Now(), time.Now().Add(-time.Hour)))
+	report = checker.Run(context.Background(), target)
+	require.Len(t, report.Results, 3)
+	require.Equal(t, Result{Check: GitIndex, Status: Warning, Message: "index.lock held for 1h0m0s"}, report.Results[1])
+	require.FileExists(t, lock)
+
+	// Checks configured to be remediated fix the problems they find.
+	checker = NewChecker(config.HealthConfig{Disabled: []string{DiskSpace}, Remediate: []string{GitIndex}})
+	target.Pane = func() (string, error) { return "", nil }
+	report = checker.Run(context.Background(), target)
+	require.Equal(t, OK, report.Status())
+	require.Len(t, report.Remediated, 1)
+	require.NoFileExists(t, lock)
+}
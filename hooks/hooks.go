@@ -0,0 +1,92 @@
+package hooks
+
+import (
+	"bytes"
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Lifecycle events that hooks can be configured for.
+const (
+	InstanceCreated = "instance_created"
+	StatusReady     = "status_ready"
+	InstanceKilled  = "instance_killed"
+	MergeCompleted  = "merge_completed"
+)
+
+// timeout bounds how long a hook may run, so a hung hook doesn't leave processes behind.
+const timeout = time.Minute
+
+// Payload is the JSON object a hook receives on stdin.
+type Payload struct {
+	Event      string    `json:"event"`
+	Time       time.Time `json:"time"`
+	Instance   string    `json:"instance"`
+	Repository string    `json:"repository,omitempty"`
+	Branch     string    `json:"branch,omitempty"`
+	Worktree   string    `json:"worktree,omitempty"`
+	Program    string    `json:"program,omitempty"`
+	// MergedAt is when the instance's branch was merged, for merge_completed.
+	MergedAt time.Time `json:"merged_at,omitempty"`
+}
+
+// NewPayload returns the payload for event on instance.
+func NewPayload(event string, instance *session.Instance) Payload {
+	payload := Payload{
+		Event:      event,
+		Time:       time.Now(),
+		Instance:   instance.Title,
+		Repository: instance.RepositoryPath,
+		Branch:     instance.Branch,
+		Program:    instance.Program,
+		MergedAt:   instance.MergedAt,
+	}
+	if worktree, err := instance.GetGitWorktree(); err == nil {
+		payload.Repository = worktree.GetRepoPath()
+		payload.Worktree = worktree.GetWorktreePath()
+	}
+	return payload
+}
+
+// Run runs the hooks configured for event in the background. Failures are logged rather than returned,
+// since a broken hook shouldn't get in the way of the instance.
+func Run(event string, instance *session.Instance) {
+	commands := config.LoadConfig().Hooks[event]
+	if len(commands) == 0 {
+		return
+	}
+	payload := NewPayload(event, instance)
+	go func() {
+		for _, command := range commands {
+			if err := run(command, payload); err != nil {
+				log.ErrorLog.Print(err)
+			}
+		}
+	}()
+}
+
+// run runs command with sh, writing payload to its stdin.
+func run(command string, payload Payload) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s hook payload: %w", payload.Event, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(), "CS_EVENT="+payload.Event, "CS_INSTANCE="+payload.Instance)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s hook %q failed: %s (%w)", payload.Event, command, strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package hooks
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "payload.json")
+	payload := Payload{
+		Event:    StatusReady,
+		Time:     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Instance: "fix-login",
+		Branch:   "alice/fix-login",
+	}
+
+	require.NoError(t, run(`cat > "`+out+`" && test "$CS_EVENT" = status_ready`, payload))
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	var got Payload
+	require.NoError(t, json.Unmarshal(data, &got))
+	require.Equal(t, payload.Instance, got.Instance)
+	require.Equal(t, payload.Branch, got.Branch)
+	require.True(t, payload.Time.Equal(got.Time))
+
+	err = run("echo nope >&2; exit 1", payload)
+	require.ErrorContains(t, err, "nope")
+}
@@ -0,0 +1,177 @@
+package keys
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// keyNames are the names the keys are remapped by in the config's keymap.
+var keyNames = map[KeyName]string{
+	KeyUp:              "up",
+	KeyDown:            "down",
+	KeyShiftUp:         "scroll_up",
+	KeyShiftDown:       "scroll_down",
+	KeyEnter:           "attach",
+	KeyNew:             "new",
+	KeyDirectoryPicker: "new_in_repo",
+	KeyKill:            "stop",
+	KeyPurge:           "purge",
+	KeyQuit:            "quit",
+	KeyTab:             "switch_tab",
+	KeyCheckout:        "checkout",
+	KeyResume:          "resume",
+	KeySubmit:          "push",
+	KeyPrompt:          "new_with_prompt",
+	KeyHelp:            "help",
+	KeyRepoTabPrev:     "prev_repo_tab",
+	KeyRepoTabNext:     "next_repo_tab",
+	KeyCompare:         "compare",
+	KeyStalled:         "stalled",
+	KeyToggleTimes:     "toggle_times",
+	KeySort:            "sort",
+	KeyTask:            "new_from_task",
+	KeyPlan:            "plan",
+	KeyDatabase:        "database",
+	KeyFreeze:          "freeze",
+	KeyHistory:         "history",
+	KeyMute:            "mute",
+	KeySnooze:          "snooze",
+	KeyPane:            "pane",
+	KeyPreview:         "preview",
+	KeyPalette:         "plugins",
+	KeyTicket:          "new_from_ticket",
+	KeyStats:           "stats",
+	KeyBranch:          "new_from_branch",
+	KeyAdopt:           "adopt",
+	KeyBudget:          "budget",
+	KeyWorkspace:       "workspace",
+	KeyPinRepo:         "pin_repo",
+	KeyDismissHint:     "dismiss_hint",
+	KeyInterrupt:       "stalled_interrupt",
+	KeyNudge:           "stalled_nudge",
+	KeyRestart:         "stalled_restart",
+	KeyPauseStale:      "review_pause_stale",
+	KeyDeleteMerged:    "review_delete_merged",
+	KeyExportStats:     "stats_export",
+	KeyResetDatabase:   "database_reset",
+}
+
+// reservedKeys can't be remapped, since they close the overlays and quit wherever they're pressed.
+var reservedKeys = map[string]bool{
+	"ctrl+c": true,
+	"esc":    true,
+}
+
+// helpKeyNames are how keys are shown in the help, where they differ from the key strings.
+var helpKeyNames = map[string]string{
+	"up":         "↑",
+	"down":       "↓",
+	"shift+up":   "shift+↑",
+	"shift+down": "shift+↓",
+	"enter":      "↵",
+	" ":          "space",
+}
+
+// Names returns the names of the keys that can be remapped, sorted.
+func Names() []string {
+	names := make([]string, 0, len(keyNames))
+	for _, name := range keyNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyKeymap remaps the keys in keymap, which maps key names to the keys that trigger them, e.g.
+// {"up": ["up", "e"], "stop": ["ctrl+d"]}. Keys that aren't in keymap keep their default bindings. Nothing is
+// remapped if keymap names an unknown key, binds ctrl+c or esc, or binds a key to two of the instance list's or
+// of an overlay's.
+func ApplyKeymap(keymap map[string][]string) error {
+	byName := make(map[string]KeyName, len(keyNames))
+	for k, name := range keyNames {
+		byName[name] = k
+	}
+	remapped := make(map[KeyName][]string, len(keymap))
+	for name, bound := range keymap {
+		k, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("unknown key %q in the keymap, expected one of %s", name, strings.Join(Names(), ", "))
+		}
+		if len(bound) == 0 {
+			return fmt.Errorf("no keys are bound to %q in the keymap", name)
+		}
+		for _, s := range bound {
+			if reservedKeys[s] {
+				return fmt.Errorf("key %q can't be bound to %q in the keymap", s, name)
+			}
+		}
+		remapped[k] = bound
+	}
+
+	// Keys only conflict with the others of the instance list, or of their overlay.
+	keyStrings, err := remapKeyStrings(GlobalKeyStringsMap, remapped)
+	if err != nil {
+		return err
+	}
+	overlayKeyStrings := make(map[Overlay]map[string]KeyName, len(OverlayKeyStringsMap))
+	for overlay, overlayStrings := range OverlayKeyStringsMap {
+		if overlayKeyStrings[overlay], err = remapKeyStrings(overlayStrings, remapped); err != nil {
+			return err
+		}
+	}
+
+	GlobalKeyStringsMap = keyStrings
+	OverlayKeyStringsMap = overlayKeyStrings
+	for k, bound := range remapped {
+		GlobalkeyBindings[k] = key.NewBinding(
+			key.WithKeys(bound...),
+			key.WithHelp(helpKeys(bound), GlobalkeyBindings[k].Help().Desc),
+		)
+	}
+	return nil
+}
+
+// remapKeyStrings returns a copy of keyStrings with the keys in it that are in remapped bound to their new keys
+// instead, or an error if a key would be bound to two of them.
+func remapKeyStrings(keyStrings map[string]KeyName, remapped map[KeyName][]string) (map[string]KeyName, error) {
+	result := make(map[string]KeyName, len(keyStrings))
+	own := make(map[KeyName]bool)
+	for s, k := range keyStrings {
+		own[k] = true
+		if _, ok := remapped[k]; !ok {
+			result[s] = k
+		}
+	}
+	for k, bound := range remapped {
+		if !own[k] {
+			continue
+		}
+		for _, s := range bound {
+			if other, ok := result[s]; ok && other != k {
+				return nil, fmt.Errorf("key %q is bound to both %q and %q in the keymap", s, keyNames[other], keyNames[k])
+			}
+			result[s] = k
+		}
+	}
+	return result, nil
+}
+
+// helpKeys formats the keys bound to a key for the help, e.g. "↑/k".
+func helpKeys(bound []string) string {
+	shown := make([]string, len(bound))
+	for i, s := range bound {
+		shown[i] = s
+		if name, ok := helpKeyNames[s]; ok {
+			shown[i] = name
+		}
+	}
+	return strings.Join(shown, "/")
+}
+
+// Help returns how the keys bound to k are shown in the help, e.g. "↑/k".
+func Help(k KeyName) string {
+	return GlobalkeyBindings[k].Help().Key
+}
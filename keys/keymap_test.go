@@ -0,0 +1,73 @@
+package keys
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyKeymap(t *testing.T) {
+	defaultStrings := maps.Clone(GlobalKeyStringsMap)
+	defaultBindings := maps.Clone(GlobalkeyBindings)
+	defaultOverlayStrings := maps.Clone(OverlayKeyStringsMap)
+	t.Cleanup(func() {
+		GlobalKeyStringsMap = defaultStrings
+		GlobalkeyBindings = defaultBindings
+		OverlayKeyStringsMap = defaultOverlayStrings
+	})
+
+	require.Error(t, ApplyKeymap(map[string][]string{"jump": {"g"}}))
+	require.Error(t, ApplyKeymap(map[string][]string{"up": {}}))
+	// n is still bound to new.
+	require.Error(t, ApplyKeymap(map[string][]string{"down": {"down", "n"}}))
+	require.Equal(t, defaultStrings, GlobalKeyStringsMap, "nothing is remapped after an error")
+
+	require.NoError(t, ApplyKeymap(map[string][]string{
+		"down": {"down", "n"},
+		"new":  {"a"},
+		"up":   {"up", "e"},
+	}))
+	require.Equal(t, KeyDown, GlobalKeyStringsMap["n"])
+	require.Equal(t, KeyNew, GlobalKeyStringsMap["a"])
+	require.Equal(t, KeyUp, GlobalKeyStringsMap["e"])
+	_, ok := GlobalKeyStringsMap["k"]
+	require.False(t, ok, "the default keys of remapped keys are unbound")
+	require.Equal(t, KeyKill, GlobalKeyStringsMap["D"])
+
+	require.Equal(t, "↑/e", Help(KeyUp))
+	require.Equal(t, "up", GlobalkeyBindings[KeyUp].Help().Desc)
+	require.Equal(t, []string{"up", "e"}, GlobalkeyBindings[KeyUp].Keys())
+
+	require.NoError(t, ApplyKeymap(map[string][]string{"quit": {"Q"}}))
+	require.Equal(t, KeyQuit, GlobalKeyStringsMap["Q"])
+	_, ok = GlobalKeyStringsMap["q"]
+	require.False(t, ok, "q doesn't quit once quit is remapped")
+	require.Error(t, ApplyKeymap(map[string][]string{"quit": {"ctrl+c"}}))
+	require.Error(t, ApplyKeymap(map[string][]string{"review_pause_stale": {"esc"}}))
+}
+
+func TestApplyKeymapOverlays(t *testing.T) {
+	defaultStrings := maps.Clone(GlobalKeyStringsMap)
+	defaultBindings := maps.Clone(GlobalkeyBindings)
+	defaultOverlayStrings := maps.Clone(OverlayKeyStringsMap)
+	t.Cleanup(func() {
+		GlobalKeyStringsMap = defaultStrings
+		GlobalkeyBindings = defaultBindings
+		OverlayKeyStringsMap = defaultOverlayStrings
+	})
+
+	// Overlay keys only conflict with the other keys of their overlay.
+	require.Error(t, ApplyKeymap(map[string][]string{"stalled_interrupt": {"n"}}))
+	require.NoError(t, ApplyKeymap(map[string][]string{"stalled_interrupt": {"x"}, "review_pause_stale": {"s"}}))
+
+	name, ok := OverlayKey(OverlayStalled, "x")
+	require.True(t, ok)
+	require.Equal(t, KeyInterrupt, name)
+	_, ok = OverlayKey(OverlayStalled, "i")
+	require.False(t, ok)
+	name, _ = OverlayKey(OverlayReview, "s")
+	require.Equal(t, KeyPauseStale, name)
+	require.Equal(t, KeyDismissHint, GlobalKeyStringsMap["x"], "the instance list's keys are unchanged")
+	require.Equal(t, "x", Help(KeyInterrupt))
+}
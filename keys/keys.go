@@ -33,8 +33,80 @@ const (
 	// Diff keybindings
 	KeyShiftUp
 	KeyShiftDown
+
+	KeyCompare     // Key for comparing two instances
+	KeyStalled     // Key for showing the actions for a stalled instance
+	KeyToggleTimes // Key for switching between relative and absolute timestamps
+	KeySort        // Key for cycling the order of the instance list
+	KeyTask        // Key for starting a new instance from the task library
+	KeyPlan        // Key for starting a new instance in plan mode, or reviewing its plan
+	KeyDatabase    // Key for showing the state of an instance's database and resetting it
+	KeyFreeze      // Key for archiving an instance to a tarball and killing it
+	KeyHistory     // Key for showing the frozen instances
+	KeyMute        // Key for muting or unmuting an instance's alerts
+	KeySnooze      // Key for snoozing an instance's alerts and notifications
+	KeyPane        // Key for cycling the pane that's previewed and sent prompts
+	KeyPreview     // Key for switching between the list and the preview in narrow terminals
+	KeyPalette     // Key for showing the plugins' commands
+	KeyTicket      // Key for creating a new instance from an issue tracker ticket
+	KeyStats       // Key for showing the repository stats
+	KeyBranch      // Key for creating a new instance that checks out an existing branch
+	KeyAdopt       // Key for adopting a tmux session claude-squad didn't start as an instance
+	KeyChangeRepo  // Key for picking another repository for the instance being named
+	KeyPurge       // Key for killing an instance and deleting its branch and worktree
+	KeyBudget      // Key for setting an instance's time and cost budget
+	KeyWorkspace   // Key for switching the view between workspaces
+	KeyPinRepo     // Key for pinning or unpinning the selected repository tab
+	KeyDismissHint // Key for dismissing the hint shown, for good
+
+	KeyChangeProgram // Key for changing the program of the instance being named
+
+	// Overlay keys, which only apply while their overlay is shown.
+	KeyInterrupt     // Key for interrupting a stalled instance
+	KeyNudge         // Key for sending a stalled instance the nudge prompt
+	KeyRestart       // Key for restarting a stalled instance's program
+	KeyPauseStale    // Key for pausing the instances the weekly review found stale
+	KeyDeleteMerged  // Key for deleting the branches the weekly review found merged
+	KeyExportStats   // Key for exporting the stats shown
+	KeyResetDatabase // Key for resetting the selected instance's database
+)
+
+// Overlay is a screen with keys of its own, which may reuse the keys of the instance list.
+type Overlay int
+
+const (
+	OverlayStalled Overlay = iota
+	OverlayReview
+	OverlayStats
+	OverlayDatabase
 )
 
+// OverlayKeyStringsMap maps the keys of each overlay to their names, like GlobalKeyStringsMap does for the
+// instance list.
+var OverlayKeyStringsMap = map[Overlay]map[string]KeyName{
+	OverlayStalled: {
+		"i": KeyInterrupt,
+		"n": KeyNudge,
+		"r": KeyRestart,
+	},
+	OverlayReview: {
+		"p": KeyPauseStale,
+		"b": KeyDeleteMerged,
+	},
+	OverlayStats: {
+		"e": KeyExportStats,
+	},
+	OverlayDatabase: {
+		"r": KeyResetDatabase,
+	},
+}
+
+// OverlayKey returns the name of the key s in overlay, if it's one of its keys.
+func OverlayKey(overlay Overlay, s string) (KeyName, bool) {
+	name, ok := OverlayKeyStringsMap[overlay][s]
+	return name, ok
+}
+
 // GlobalKeyStringsMap is a global, immutable map string to keybinding.
 var GlobalKeyStringsMap = map[string]KeyName{
 	"up":         KeyUp,
@@ -48,6 +120,7 @@ var GlobalKeyStringsMap = map[string]KeyName{
 	"n":          KeyNew,
 	"N":          KeyDirectoryPicker,
 	"D":          KeyKill,
+	"X":          KeyPurge,
 	"q":          KeyQuit,
 	"tab":        KeyTab,
 	"c":          KeyCheckout,
@@ -57,6 +130,28 @@ var GlobalKeyStringsMap = map[string]KeyName{
 	"?":          KeyHelp,
 	"J":          KeyRepoTabPrev,
 	"K":          KeyRepoTabNext,
+	"=":          KeyCompare,
+	"s":          KeyStalled,
+	"t":          KeyToggleTimes,
+	"S":          KeySort,
+	"T":          KeyTask,
+	"L":          KeyPlan,
+	"d":          KeyDatabase,
+	"F":          KeyFreeze,
+	"H":          KeyHistory,
+	"m":          KeyMute,
+	"z":          KeySnooze,
+	"v":          KeyPane,
+	" ":          KeyPreview,
+	":":          KeyPalette,
+	"I":          KeyTicket,
+	"i":          KeyStats,
+	"B":          KeyBranch,
+	"A":          KeyAdopt,
+	"$":          KeyBudget,
+	"W":          KeyWorkspace,
+	"*":          KeyPinRepo,
+	"x":          KeyDismissHint,
 }
 
 // GlobalkeyBindings is a global, immutable map of KeyName tot keybinding.
@@ -87,7 +182,11 @@ var GlobalkeyBindings = map[KeyName]key.Binding{
 	),
 	KeyKill: key.NewBinding(
 		key.WithKeys("D"),
-		key.WithHelp("D", "kill"),
+		key.WithHelp("D", "stop"),
+	),
+	KeyPurge: key.NewBinding(
+		key.WithKeys("X"),
+		key.WithHelp("X", "purge"),
 	),
 	KeyHelp: key.NewBinding(
 		key.WithKeys("?"),
@@ -119,7 +218,7 @@ var GlobalkeyBindings = map[KeyName]key.Binding{
 	),
 	KeyDirectoryPicker: key.NewBinding(
 		key.WithKeys("N"),
-		key.WithHelp("N", "pick directory"),
+		key.WithHelp("N", "new in repo"),
 	),
 	KeyRepoTabPrev: key.NewBinding(
 		key.WithKeys("J"),
@@ -129,6 +228,94 @@ var GlobalkeyBindings = map[KeyName]key.Binding{
 		key.WithKeys("K"),
 		key.WithHelp("K", "next repo tab"),
 	),
+	KeyCompare: key.NewBinding(
+		key.WithKeys("="),
+		key.WithHelp("=", "compare"),
+	),
+	KeyStalled: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "stalled actions"),
+	),
+	KeyToggleTimes: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "toggle times"),
+	),
+	KeySort: key.NewBinding(
+		key.WithKeys("S"),
+		key.WithHelp("S", "sort"),
+	),
+	KeyTask: key.NewBinding(
+		key.WithKeys("T"),
+		key.WithHelp("T", "new from task"),
+	),
+	KeyPlan: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "plan"),
+	),
+	KeyDatabase: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "database"),
+	),
+	KeyFreeze: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "freeze"),
+	),
+	KeyHistory: key.NewBinding(
+		key.WithKeys("H"),
+		key.WithHelp("H", "history"),
+	),
+	KeyMute: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "mute"),
+	),
+	KeySnooze: key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "snooze"),
+	),
+	KeyPane: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "pane"),
+	),
+	KeyPreview: key.NewBinding(
+		key.WithKeys(" "),
+		key.WithHelp("space", "preview"),
+	),
+	KeyPalette: key.NewBinding(
+		key.WithKeys(":"),
+		key.WithHelp(":", "plugins"),
+	),
+	KeyTicket: key.NewBinding(
+		key.WithKeys("I"),
+		key.WithHelp("I", "new from ticket"),
+	),
+	KeyStats: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "stats"),
+	),
+	KeyBranch: key.NewBinding(
+		key.WithKeys("B"),
+		key.WithHelp("B", "new from branch"),
+	),
+	KeyAdopt: key.NewBinding(
+		key.WithKeys("A"),
+		key.WithHelp("A", "adopt session"),
+	),
+	KeyBudget: key.NewBinding(
+		key.WithKeys("$"),
+		key.WithHelp("$", "budget"),
+	),
+	KeyWorkspace: key.NewBinding(
+		key.WithKeys("W"),
+		key.WithHelp("W", "workspace"),
+	),
+	KeyPinRepo: key.NewBinding(
+		key.WithKeys("*"),
+		key.WithHelp("*", "pin repo"),
+	),
+	KeyDismissHint: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "dismiss hint"),
+	),
 
 	// -- Special keybindings --
 
@@ -136,4 +323,43 @@ var GlobalkeyBindings = map[KeyName]key.Binding{
 		key.WithKeys("enter"),
 		key.WithHelp("enter", "submit name"),
 	),
+	KeyChangeRepo: key.NewBinding(
+		key.WithKeys("tab"),
+		key.WithHelp("tab", "change repo"),
+	),
+	KeyChangeProgram: key.NewBinding(
+		key.WithKeys("ctrl+p"),
+		key.WithHelp("ctrl+p", "change program"),
+	),
+
+	// -- Overlay keybindings --
+
+	KeyInterrupt: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "interrupt"),
+	),
+	KeyNudge: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "nudge"),
+	),
+	KeyRestart: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "restart"),
+	),
+	KeyPauseStale: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "pause stale"),
+	),
+	KeyDeleteMerged: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "delete merged branches"),
+	),
+	KeyExportStats: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "export stats"),
+	),
+	KeyResetDatabase: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "reset database"),
+	),
 }
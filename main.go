@@ -1,31 +1,96 @@
 package main
 
 import (
+	"bufio"
 	"claude-squad/app"
+	"claude-squad/archive"
+	"claude-squad/backup"
+	"claude-squad/bench"
 	cmd2 "claude-squad/cmd"
 	"claude-squad/config"
 	"claude-squad/daemon"
+	"claude-squad/digest"
 	"claude-squad/log"
+	"claude-squad/network"
+	"claude-squad/plugin"
+	"claude-squad/policy"
+	"claude-squad/prompt"
+	"claude-squad/secrets"
 	"claude-squad/session"
 	"claude-squad/session/git"
 	"claude-squad/session/tmux"
+	"claude-squad/stats"
+	"claude-squad/telemetry"
+	"claude-squad/ui"
+	"claude-squad/validate"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 var (
-	version     = "1.0.5"
-	programFlag string
-	autoYesFlag bool
-	daemonFlag  bool
+	version              = "1.0.5"
+	programFlag          string
+	autoYesFlag          bool
+	identityFlag         string
+	signingKeyFlag       string
+	signingFormatFlag    string
+	daemonFlag           bool
+	accessibleFlag       bool
+	searchIgnoreCaseFlag bool
+	backupOutFlag        string
+	snapshotNoteFlag     string
+	restoreForceFlag     bool
+	exportOutFlag        string
+	configExportOutFlag  string
+	validateJSONFlag     bool
+	digestPeriodFlag     string
+	digestSendFlag       bool
+	statsJSONFlag        bool
+	benchInstancesFlag   int
+	benchProgramFlag     string
+	profileFlag          string
+	configDirFlag        string
+	branchesDeleteFlag   bool
+	branchesMergedFlag   bool
+	branchesYesFlag      bool
+	scanAddFlag          bool
 	rootCmd     = &cobra.Command{
 		Use:   "claude-squad [directory]",
 		Short: "Claude Squad - Manage multiple AI agents like Claude Code, Aider, Codex, and Amp.",
 		Args:  cobra.MaximumNArgs(1),
+		// The config directory and profile are selected before any command runs, so they all use their config
+		// and state.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if configDirFlag != "" {
+				if err := config.SetConfigDir(configDirFlag); err != nil {
+					return err
+				}
+			}
+			profile := profileFlag
+			if profile == "" {
+				profile = os.Getenv(config.ProfileEnv)
+			}
+			if profile != "" && profile != config.DefaultProfile {
+				if err := config.SetProfile(profile); err != nil {
+					return err
+				}
+				tmux.SetProfile(profile)
+			}
+			// Logging isn't initialized yet, so only the air_gapped field is read: LoadConfig's fallbacks and the
+			// default config log. A config that can't be read is reported by the command that loads it.
+			network.SetAirGapped(config.AirGappedEnabled())
+			return nil
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := context.Background()
 			log.Initialize(daemonFlag)
@@ -33,7 +98,15 @@ var (
 
 			if daemonFlag {
 				cfg := config.LoadConfig()
-				err := daemon.RunDaemon(cfg)
+				transport, err := network.NewTransport(cfg.Network)
+				if err != nil {
+					return err
+				}
+				if !cfg.AirGapped {
+					telemetry.Init(cfg.Tracing.Endpoint, cfg.Tracing.Headers, transport)
+					defer telemetry.Shutdown()
+				}
+				err = daemon.RunDaemon(cfg, autoYesFlag)
 				log.ErrorLog.Printf("failed to start daemon %v", err)
 				return err
 			}
@@ -58,9 +131,21 @@ var (
 			// The app will handle showing existing instances or prompting for directory selection
 
 			cfg := config.LoadConfig()
+			transport, err := network.NewTransport(cfg.Network)
+			if err != nil {
+				return err
+			}
+			if !cfg.AirGapped {
+				telemetry.Init(cfg.Tracing.Endpoint, cfg.Tracing.Headers, transport)
+				defer telemetry.Shutdown()
+			}
 
-			// Program flag overrides config
+			// Program flag overrides the repositories' default programs and the preference, which overrides
+			// config. The repositories' are applied when instances are created in them.
 			program := cfg.DefaultProgram
+			if preferred := config.LoadState().GetPreferences().DefaultProgram; preferred != "" {
+				program = preferred
+			}
 			if programFlag != "" {
 				program = programFlag
 			}
@@ -69,9 +154,21 @@ var (
 			if autoYesFlag {
 				autoYes = true
 			}
-			if autoYes {
+			// Accessible mode is enabled by the flag, the config or the NO_COLOR convention.
+			if accessibleFlag || cfg.Accessible || os.Getenv("NO_COLOR") != "" {
+				ui.SetAccessible()
+			}
+			// The system policy can't be overridden by config or flags. Repository policies are
+			// enforced per instance.
+			if p, err := policy.Load(""); err != nil {
+				return err
+			} else if p.DisableAutoYes {
+				autoYes = false
+			}
+			// The daemon takes over once the TUI exits, if there's anything for it to do.
+			if daemon.Wanted(cfg, autoYes) {
 				defer func() {
-					if err := daemon.LaunchDaemon(); err != nil {
+					if err := daemon.LaunchDaemon(autoYes); err != nil {
 						log.ErrorLog.Printf("failed to launch daemon: %v", err)
 					}
 				}()
@@ -81,7 +178,27 @@ var (
 				log.ErrorLog.Printf("failed to stop daemon: %v", err)
 			}
 
-			return app.Run(ctx, program, autoYes, targetDir)
+			if cfg.Sync.Auto && cfg.Sync.URL != "" && !cfg.AirGapped {
+				if err := syncState(true, false); err != nil {
+					fmt.Printf("failed to pull the synced state: %v\n", err)
+				}
+				defer func() {
+					if err := syncState(false, true); err != nil {
+						fmt.Printf("failed to push the synced state: %v\n", err)
+					}
+				}()
+			}
+
+			// The identity flags override the repositories' commit identities for the instances created.
+			var identity config.CommitIdentity
+			if identityFlag != "" {
+				if identity, err = config.ParseCommitIdentity(identityFlag); err != nil {
+					return err
+				}
+			}
+			identity.SigningKey, identity.SigningFormat = signingKeyFlag, signingFormatFlag
+
+			return app.Run(ctx, program, programFlag != "", autoYes, targetDir, identity)
 		},
 	}
 
@@ -97,6 +214,15 @@ var (
 			if err != nil {
 				return fmt.Errorf("failed to initialize storage: %w", err)
 			}
+			// Branches that instances checked out rather than created are kept.
+			adopted := make(map[string]bool)
+			if instances, err := storage.LoadInstanceData(); err == nil {
+				for _, instance := range instances {
+					if instance.Worktree.Adopted {
+						adopted[instance.Worktree.BranchName] = true
+					}
+				}
+			}
 			if err := storage.DeleteAllInstances(); err != nil {
 				return fmt.Errorf("failed to reset storage: %w", err)
 			}
@@ -107,7 +233,7 @@ var (
 			}
 			fmt.Println("Tmux sessions have been cleaned up")
 
-			if err := git.CleanupWorktrees(); err != nil {
+			if err := git.CleanupWorktrees(adopted); err != nil {
 				return fmt.Errorf("failed to cleanup worktrees: %w", err)
 			}
 			fmt.Println("Worktrees have been cleaned up")
@@ -135,42 +261,1149 @@ var (
 			configJson, _ := json.MarshalIndent(cfg, "", "  ")
 
 			fmt.Printf("Config: %s\n%s\n", filepath.Join(configDir, config.ConfigFileName), configJson)
+			if stateDir, err := config.GetStateDir(); err == nil && stateDir != configDir {
+				fmt.Printf("State: %s\n", stateDir)
+			}
 
 			return nil
 		},
 	}
 
-	versionCmd = &cobra.Command{
-		Use:   "version",
-		Short: "Print the version number of claude-squad",
-		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Printf("claude-squad version %s\n", version)
-			fmt.Printf("https://github.com/smtg-ai/claude-squad/releases/tag/v%s\n", version)
+	searchCmd = &cobra.Command{
+		Use:   "search <pattern>",
+		Short: "Search for a pattern across the worktrees of all active instances",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			storage, err := session.NewStorage(config.LoadState())
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			instances, err := storage.LoadInstanceData()
+			if err != nil {
+				return err
+			}
+
+			found := false
+			searched, failed := 0, 0
+			var searchErr error
+			for _, instance := range instances {
+				// Paused instances have no worktree on disk.
+				if instance.Status == session.Paused {
+					continue
+				}
+				searched++
+				hits, err := git.Search(instance.Worktree.WorktreePath, args[0], searchIgnoreCaseFlag)
+				if err != nil {
+					// A worktree that's gone or broken doesn't keep the others from being searched.
+					fmt.Fprintf(os.Stderr, "warning: %s: %v\n", instance.Title, err)
+					failed++
+					searchErr = err
+					continue
+				}
+				if len(hits) == 0 {
+					continue
+				}
+				found = true
+				fmt.Printf("%s (%s)\n", instance.Title, instance.Branch)
+				for _, hit := range hits {
+					fmt.Printf("  %s:%d: %s\n", hit.Path, hit.Line, hit.Text)
+				}
+			}
+			// If no search succeeded, e.g. because the pattern is invalid, there's no telling whether anything matches.
+			if searched > 0 && failed == searched {
+				return searchErr
+			}
+			if !found {
+				fmt.Println("No matches")
+			}
+			return nil
 		},
 	}
-)
 
-func init() {
-	rootCmd.Flags().StringVarP(&programFlag, "program", "p", "",
-		"Program to run in new instances (e.g. 'aider --model ollama_chat/gemma3:1b')")
-	rootCmd.Flags().BoolVarP(&autoYesFlag, "autoyes", "y", false,
-		"[experimental] If enabled, all instances will automatically accept prompts")
-	rootCmd.Flags().BoolVar(&daemonFlag, "daemon", false, "Run a program that loads all sessions"+
-		" and runs autoyes mode on them.")
+	backupCmd = &cobra.Command{
+		Use:   "backup",
+		Short: "Back up config, state and the branches of all instances to an archive, or manage the state's snapshots",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
 
-	// Hide the daemonFlag as it's only for internal use
-	err := rootCmd.Flags().MarkHidden("daemon")
-	if err != nil {
-		panic(err)
+			out := backupOutFlag
+			if out == "" {
+				out = fmt.Sprintf("squad-backup-%s.tar.gz", time.Now().Format("20060102-150405"))
+			}
+			result, err := backup.Create(out)
+			if err != nil {
+				return err
+			}
+			for _, warning := range result.Warnings {
+				fmt.Printf("warning: %s\n", warning)
+			}
+			fmt.Printf("Backed up %d branches to %s\n", len(result.Manifest.Branches), out)
+			return nil
+		},
 	}
 
-	rootCmd.AddCommand(debugCmd)
-	rootCmd.AddCommand(versionCmd)
-	rootCmd.AddCommand(resetCmd)
-}
+	backupCreateCmd = &cobra.Command{
+		Use:   "create",
+		Short: "Snapshot the state and the instances' sessions and worktrees, to roll back to with `backup restore`",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
 
-func main() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+			snapshot, err := backup.CreateSnapshot(snapshotNoteFlag)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Created snapshot %s of %d instances\n", snapshot.ID, len(snapshot.Sessions))
+			return nil
+		},
+	}
+
+	backupListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the snapshots, the most recent first",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			snapshots, err := backup.ListSnapshots()
+			if err != nil {
+				return err
+			}
+			if len(snapshots) == 0 {
+				fmt.Println("No snapshots, create one with `claude-squad backup create`")
+				return nil
+			}
+			for _, snapshot := range snapshots {
+				fmt.Printf("%s  %s  %d instances", snapshot.ID, snapshot.CreatedAt.Format(time.RFC822),
+					len(snapshot.Sessions))
+				if snapshot.Note != "" {
+					fmt.Printf("  %s", snapshot.Note)
+				}
+				fmt.Println()
+			}
+			return nil
+		},
+	}
+
+	backupRestoreCmd = &cobra.Command{
+		Use:   "restore <snapshot>",
+		Short: "Roll the state back to a snapshot, snapshotting the current state first",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			result, err := backup.RestoreSnapshot(args[0])
+			if err != nil {
+				return err
+			}
+			for _, warning := range result.Warnings {
+				fmt.Printf("warning: %s\n", warning)
+			}
+			fmt.Printf("Restored the snapshot from %s. Instances whose sessions are gone are stopped or paused, resume them to restart them.\n",
+				result.Manifest.CreatedAt.Format(time.RFC822))
+			return nil
+		},
+	}
+
+	restoreCmd = &cobra.Command{
+		Use:   "restore <archive>",
+		Short: "Restore config, state and instance branches from a backup",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			result, err := backup.Restore(args[0], restoreForceFlag)
+			if err != nil {
+				return err
+			}
+			for _, warning := range result.Warnings {
+				fmt.Printf("warning: %s\n", warning)
+			}
+			fmt.Printf("Restored backup from %s. Instances are paused, resume them to recreate their worktrees.\n",
+				result.Manifest.CreatedAt.Format(time.RFC822))
+			return nil
+		},
+	}
+
+	restoreStateCmd = &cobra.Command{
+		Use:   "restore-state",
+		Short: "Restore the state from its most recent backup, e.g. after it was damaged",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			backup, err := config.RestoreStateBackup()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Restored the state from %s\n", backup)
+			return nil
+		},
+	}
+
+	exportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export your repositories and instances to an archive, to import them on another machine",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			out := exportOutFlag
+			if out == "" {
+				out = fmt.Sprintf("squad-export-%s.tar.gz", time.Now().Format("20060102-150405"))
+			}
+			export, err := backup.Export(out)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Exported %d repositories and %d instances to %s\n", len(export.Repositories), len(export.Instances), out)
+			return nil
+		},
+	}
+
+	importCmd = &cobra.Command{
+		Use:   "import <archive>",
+		Short: "Import the repositories and instances of an export into your state",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			result, err := backup.Import(args[0])
+			if err != nil {
+				return err
+			}
+			for _, warning := range result.Warnings {
+				fmt.Printf("warning: %s\n", warning)
+			}
+			fmt.Printf("Imported %d repositories and %d instances. Instances are paused, resume them to recreate their worktrees.\n",
+				result.Repositories, result.Instances)
+			return nil
+		},
+	}
+
+	syncCmd = &cobra.Command{
+		Use:   "sync",
+		Short: "Pull, then push, the repositories and instances synced between your machines through sync.url",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			return syncState(true, true)
+		},
+	}
+
+	syncPullCmd = &cobra.Command{
+		Use:   "pull",
+		Short: "Replace your repositories and paused instances with the synced ones",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			return syncState(true, false)
+		},
+	}
+
+	syncPushCmd = &cobra.Command{
+		Use:   "push",
+		Short: "Replace the synced repositories and instances with yours",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			return syncState(false, true)
+		},
+	}
+
+	retentionCmd = &cobra.Command{
+		Use:   "retention",
+		Short: "Preview what the retention policy will archive and delete",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			cfg := config.LoadConfig()
+			storage, err := session.NewStorage(config.LoadState())
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			instances, err := storage.LoadInstanceData()
+			if err != nil {
+				return err
+			}
+			plan, err := archive.NewPlan(cfg.Retention, instances, time.Now())
+			if err != nil {
+				return err
+			}
+
+			if !cfg.Retention.Enabled {
+				fmt.Println("The retention policy is disabled. Set retention.enabled in the config to have the daemon enforce it while claude-squad is closed.")
+			}
+			for _, warning := range plan.Warnings {
+				fmt.Printf("warning: %s\n", warning)
+			}
+			if plan.Empty() {
+				fmt.Println("Nothing to archive or delete")
+				return nil
+			}
+			for _, candidate := range plan.Instances {
+				fmt.Printf("archive %s (%s), merged %s\n", candidate.Title, candidate.Branch,
+					candidate.MergedAt.Format(time.RFC822))
+			}
+			for _, entry := range plan.Archives {
+				fmt.Printf("delete  %s, frozen %s\n", entry.Path, entry.FrozenAt.Format(time.RFC822))
+			}
+			return nil
+		},
+	}
+
+	branchesCmd = &cobra.Command{
+		Use:   "branches",
+		Short: "List the branches claude-squad created that no instance or archive uses anymore, and delete them",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			cfg := config.LoadConfig()
+			state := config.LoadState()
+			storage, err := session.NewStorage(state)
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			instances, err := storage.LoadInstanceData()
+			if err != nil {
+				return err
+			}
+			orphans, err := archive.FindOrphans(cfg, state.GetRepositories(), instances)
+			if err != nil {
+				return err
+			}
+
+			for _, warning := range orphans.Warnings {
+				fmt.Printf("warning: %s\n", warning)
+			}
+			var branches []git.SquadBranch
+			for _, branch := range orphans.Branches {
+				if !branchesMergedFlag || branch.Ahead == 0 {
+					branches = append(branches, branch)
+				}
+			}
+			if len(branches) == 0 {
+				fmt.Println("No orphaned branches")
+				return nil
+			}
+			for _, branch := range branches {
+				ahead := "unknown base"
+				if branch.Base != "" {
+					ahead = fmt.Sprintf("%d ahead, %d behind %s", branch.Ahead, branch.Behind, branch.Base)
+				}
+				fmt.Printf("%s  %s  %s, last commit %s\n", filepath.Base(branch.Repository), branch.Name, ahead,
+					branch.CommittedAt.Format(time.DateOnly))
+			}
+			if !branchesDeleteFlag {
+				fmt.Printf("%d orphaned branches. Delete them with --delete, or only those without unmerged commits with --delete --merged\n",
+					len(branches))
+				return nil
+			}
+
+			if !branchesYesFlag {
+				fmt.Printf("Delete %d branches? The commits of branches ahead of their base are lost. [y/N] ", len(branches))
+				answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+				if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+					fmt.Println("Nothing was deleted")
+					return nil
+				}
+			}
+			deleted := 0
+			for _, branch := range branches {
+				if err := git.DeleteBranch(branch.Repository, branch.Name); err != nil {
+					fmt.Printf("warning: %v\n", err)
+					continue
+				}
+				deleted++
+			}
+			fmt.Printf("Deleted %d branches\n", deleted)
+			return nil
+		},
+	}
+
+	cleanupCmd = &cobra.Command{
+		Use:   "cleanup",
+		Short: "Remove the repositories that don't exist anymore and the instances, sessions and worktrees left by them",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			state := config.LoadState()
+			storage, err := session.NewStorage(state)
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			rm := config.NewRepositoryManager(state, storage)
+			report, err := rm.Cleanup()
+			if err != nil {
+				return err
+			}
+			if err := storage.UpdateInstanceCounts(); err != nil {
+				return err
+			}
+
+			for _, repo := range report.Repositories {
+				fmt.Printf("removed repository %s\n", repo)
+			}
+			for _, title := range report.Instances {
+				fmt.Printf("removed instance   %s\n", title)
+			}
+			for _, kept := range report.Kept {
+				fmt.Printf("kept %s\n", kept)
+			}
+			if len(report.Repositories) == 0 && len(report.Instances) == 0 {
+				fmt.Println("Nothing to clean up")
+			} else if len(report.Instances) > 0 {
+				fmt.Println("The branches of removed instances are kept, list them with `claude-squad branches`")
+			}
+
+			repos, err := rm.GetRepositoriesWithCounts()
+			if err != nil {
+				return err
+			}
+			if len(repos) > 0 {
+				fmt.Println()
+			}
+			for _, repo := range repos {
+				fmt.Printf("%s  %d instances\n", repo.Path, repo.InstanceCount)
+			}
+			return nil
+		},
+	}
+
+	digestCmd = &cobra.Command{
+		Use:   "digest",
+		Short: "Summarize the instances created, merged, abandoned and waiting on you, e.g. from a daily cron job",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			if digestPeriodFlag != digest.Daily && digestPeriodFlag != digest.Weekly {
+				return fmt.Errorf("unknown period %q, expected %s or %s", digestPeriodFlag, digest.Daily, digest.Weekly)
+			}
+			d, err := digest.Collect(digestPeriodFlag, time.Now())
+			if err != nil {
+				return err
+			}
+			if !digestSendFlag {
+				fmt.Print(d.String())
+				return nil
+			}
+			cfg := config.LoadConfig()
+			if err := digest.Send(cfg.Digest, cfg.Network, d); err != nil {
+				return err
+			}
+			fmt.Println("Sent the digest")
+			return nil
+		},
+	}
+
+	statsCmd = &cobra.Command{
+		Use:   "stats",
+		Short: "Show the instances created, merged and around over time in each repository, and the most used tasks",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			storage, err := session.NewStorage(config.LoadState())
+			if err != nil {
+				return fmt.Errorf("failed to initialize storage: %w", err)
+			}
+			data, err := storage.LoadInstanceData()
+			if err != nil {
+				return err
+			}
+			report, err := stats.Collect(time.Now(), data)
+			if err != nil {
+				return err
+			}
+			if !statsJSONFlag {
+				fmt.Print(report.String())
+				return nil
+			}
+			out, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		},
+	}
+
+	benchCmd = &cobra.Command{
+		Use:   "bench",
+		Short: "Time worktree creation, instance startup, state saves and list rendering with synthetic instances",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			if benchInstancesFlag < 1 {
+				return fmt.Errorf("--instances must be at least 1")
+			}
+			results, err := bench.Run(bench.Options{Instances: benchInstancesFlag, Program: benchProgramFlag},
+				func(format string, args ...any) { fmt.Printf(format+"\n", args...) })
+			if err != nil {
+				return err
+			}
+			fmt.Println()
+			return bench.Report(os.Stdout, results)
+		},
+	}
+
+	pluginsCmd = &cobra.Command{
+		Use:   "plugins",
+		Short: "List the plugins on PATH and what they add",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plugins, errs := plugin.Discover()
+			for _, err := range errs {
+				fmt.Printf("warning: %v\n", err)
+			}
+			if len(plugins) == 0 {
+				fmt.Printf("No plugins found. Plugins are executables named %s* on PATH.\n", plugin.Prefix)
+				return nil
+			}
+			for _, p := range plugins {
+				fmt.Printf("%s (%s)\n", p.Name, p.Path)
+				for _, command := range p.Manifest.Commands {
+					fmt.Printf("  command %s: %s\n", command.Name, command.Description)
+				}
+				for _, flow := range p.Manifest.Flows {
+					fmt.Printf("  flow    %s: %s\n", flow.Name, flow.Description)
+				}
+				for _, column := range p.Manifest.Columns {
+					fmt.Printf("  column  %s\n", column)
+				}
+			}
+			return nil
+		},
+	}
+
+	configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Share claude-squad settings with a team, or check them for problems",
+	}
+
+	configExportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export the settings and task templates that can be shared with a team, leaving out secrets and machine-specific paths",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			shared := config.LoadConfig().Export()
+			configDir, err := config.GetConfigDir()
+			if err != nil {
+				return fmt.Errorf("failed to get config directory: %w", err)
+			}
+			if err := shared.ReadTasks(filepath.Join(configDir, prompt.TasksDirName)); err != nil {
+				return err
+			}
+			data, err := json.MarshalIndent(shared, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal config: %w", err)
+			}
+			if configExportOutFlag == "" {
+				fmt.Println(string(data))
+				return nil
+			}
+			if err := os.WriteFile(configExportOutFlag, data, 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", configExportOutFlag, err)
+			}
+			fmt.Printf("Exported config to %s\n", configExportOutFlag)
+			return nil
+		},
+	}
+
+	configImportCmd = &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import shared settings into your config",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", args[0], err)
+			}
+			shared, err := config.ParseSharedConfig(data)
+			if err != nil {
+				return err
+			}
+			cfg := config.LoadConfig()
+			cfg.Import(shared)
+			if err := config.SaveConfig(cfg); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+			configDir, err := config.GetConfigDir()
+			if err != nil {
+				return fmt.Errorf("failed to get config directory: %w", err)
+			}
+			if err := shared.WriteTasks(filepath.Join(configDir, prompt.TasksDirName)); err != nil {
+				return err
+			}
+			fmt.Printf("Imported config from %s\n", args[0])
+			return nil
+		},
+	}
+
+	configValidateCmd = &cobra.Command{
+		Use:   "validate",
+		Short: "Check the config and state for problems, like missing repositories, worktrees and programs",
+		// The problems are the report, not a misuse of the command.
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			report := validate.Run()
+			if validateJSONFlag {
+				data, err := json.MarshalIndent(report, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to marshal report: %w", err)
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+			fmt.Print(report)
+			if !report.OK() {
+				return fmt.Errorf("found problems in the config or state")
+			}
+			return nil
+		},
+	}
+
+	secretsCmd = &cobra.Command{
+		Use:   "secrets",
+		Short: "Manage the API tokens used by integrations like ticket sync",
+	}
+
+	secretsSetCmd = &cobra.Command{
+		Use:   "set <name>",
+		Short: "Store a secret, read from stdin. An empty value removes it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var value []byte
+			var err error
+			if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+				fmt.Printf("Value for %s: ", args[0])
+				value, err = term.ReadPassword(fd)
+				fmt.Println()
+			} else {
+				value, err = io.ReadAll(os.Stdin)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read secret: %w", err)
+			}
+			if err := secrets.Set(args[0], strings.TrimSpace(string(value))); err != nil {
+				return err
+			}
+			fmt.Printf("Saved secret %s\n", args[0])
+			return nil
+		},
+	}
+
+	preferencesCmd = &cobra.Command{
+		Use:   "preferences",
+		Short: "List your preferences, which are kept with the state",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			preferences := config.LoadState().GetPreferences()
+			for _, key := range config.PreferenceKeys {
+				value, err := preferences.Get(key)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("%s = %s\n", key, value)
+			}
+			return nil
+		},
+	}
+
+	preferencesSetCmd = &cobra.Command{
+		Use:   "set <key> [value]",
+		Short: "Set a preference, or reset it to its default if no value is given",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			value := ""
+			if len(args) > 1 {
+				value = args[1]
+			}
+			state := config.LoadState()
+			preferences := state.GetPreferences()
+			if err := preferences.Set(args[0], value); err != nil {
+				return err
+			}
+			if err := state.SetPreferences(preferences); err != nil {
+				return fmt.Errorf("failed to save preferences: %w", err)
+			}
+			value, _ = preferences.Get(args[0])
+			fmt.Printf("%s = %s\n", args[0], value)
+			return nil
+		},
+	}
+
+	profileCmd = &cobra.Command{
+		Use:   "profile",
+		Short: "Manage profiles, which keep separate config and state, e.g. for personal and work repositories",
+	}
+
+	profileListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the profiles, marking the current one",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pm, err := config.NewProfileManager()
+			if err != nil {
+				return err
+			}
+			profiles, err := pm.List()
+			if err != nil {
+				return err
+			}
+			for _, profile := range profiles {
+				marker := " "
+				if profile == config.GetProfile() {
+					marker = "*"
+				}
+				fmt.Printf("%s %s\n", marker, profile)
+			}
+			return nil
+		},
+	}
+
+	profileCreateCmd = &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a profile, starting with a copy of the default profile's config",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pm, err := config.NewProfileManager()
+			if err != nil {
+				return err
+			}
+			if err := pm.Create(args[0]); err != nil {
+				return err
+			}
+			dir, _ := pm.Dir(args[0])
+			fmt.Printf("Created profile %s in %s. Use it with `claude-squad --profile %s`\n", args[0], dir, args[0])
+			return nil
+		},
+	}
+
+	profileDeleteCmd = &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Delete a profile with its config and state",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pm, err := config.NewProfileManager()
+			if err != nil {
+				return err
+			}
+			if err := pm.Delete(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("Deleted profile %s\n", args[0])
+			return nil
+		},
+	}
+
+	repoCmd = &cobra.Command{
+		Use:   "repo",
+		Short: "List the repositories claude-squad knows, by the names they're shown by, marking the pinned ones",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repos := config.LoadState().GetRepositoriesSortedByLastAccessed()
+			if len(repos) == 0 {
+				fmt.Println("No repositories yet, they're added when sessions are created in them")
+				return nil
+			}
+			for _, repo := range repos {
+				marker := " "
+				if repo.Pinned {
+					marker = "*"
+				}
+				fmt.Printf("%s %-20s %s", marker, repo.DisplayName(), repo.Path)
+				if program := repo.ProgramFor(""); program != "" {
+					fmt.Printf("  runs %s", strings.TrimSpace(program))
+				}
+				fmt.Println()
+			}
+			return nil
+		},
+	}
+
+	repoAliasCmd = &cobra.Command{
+		Use:   "alias <repository> [alias]",
+		Short: "Set the name a repository is shown by, e.g. to tell apart two repositories named api, or clear it",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoPath, err := config.FindRepositoryForPath(args[0])
+			if err != nil {
+				return err
+			}
+			alias := ""
+			if len(args) > 1 {
+				alias = args[1]
+			}
+			if err := config.LoadState().SetRepositoryAlias(repoPath, alias); err != nil {
+				return err
+			}
+			if alias == "" {
+				fmt.Printf("Cleared the alias of %s\n", repoPath)
+			} else {
+				fmt.Printf("%s is shown as %s\n", repoPath, alias)
+			}
+			return nil
+		},
+	}
+
+	scanCmd = &cobra.Command{
+		Use:   "scan",
+		Short: "Scan the directories under discovery in the config for git repositories, which the repository picker offers",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			discovery := config.LoadConfig().Discovery
+			roots, err := discovery.GetRoots()
+			if err != nil {
+				return err
+			}
+			if len(roots) == 0 {
+				return fmt.Errorf("no directories to scan, set them in the config, e.g. \"discovery\": {\"roots\": [\"~/src\"]}")
+			}
+			repos, err := git.FindRepositories(roots, discovery.GetDepth())
+			if err != nil {
+				fmt.Printf("warning: %v\n", err)
+			}
+			state := config.LoadState()
+			if err := state.SetDiscoveredRepositories(repos); err != nil {
+				return err
+			}
+			if len(repos) == 0 {
+				fmt.Println("No repositories found")
+				return nil
+			}
+
+			added := 0
+			for _, repo := range repos {
+				marker := " "
+				if _, err := state.GetRepository(repo); err == nil {
+					marker = "+"
+				} else if scanAddFlag {
+					data, err := config.CreateRepositoryData(repo)
+					if err == nil {
+						err = state.AddRepository(data)
+					}
+					if err != nil {
+						fmt.Printf("warning: %v\n", err)
+					} else {
+						marker = "+"
+						added++
+					}
+				}
+				fmt.Printf("%s %s\n", marker, repo)
+			}
+			fmt.Printf("Found %d repositories, marked + once added", len(repos))
+			if scanAddFlag {
+				fmt.Printf(", %d just now", added)
+			}
+			fmt.Println()
+			return nil
+		},
+	}
+
+	repoProgramCmd = &cobra.Command{
+		Use:   "program <repository> [-- program [args...]]",
+		Short: "Set the program and arguments new sessions in a repository run, e.g. -- claude --model sonnet, or clear them",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			state := config.LoadState()
+			repoPath, err := config.FindRepositoryForPath(args[0])
+			if err != nil {
+				return err
+			}
+			// Repositories are added when a program is set for them, like when a session is created in them.
+			if _, err := state.GetRepository(repoPath); err != nil {
+				repoData, err := config.CreateRepositoryData(repoPath)
+				if err != nil {
+					return err
+				}
+				if err := state.AddRepository(repoData); err != nil {
+					return err
+				}
+			}
+			program, programArgs := "", ""
+			if len(args) > 1 {
+				program, programArgs = args[1], strings.Join(args[2:], " ")
+			}
+			if err := state.SetRepositoryProgram(repoPath, program, programArgs); err != nil {
+				return err
+			}
+			if program == "" {
+				fmt.Printf("New sessions in %s run the default program\n", repoPath)
+			} else {
+				fmt.Printf("New sessions in %s run %s\n", repoPath, strings.Join(args[1:], " "))
+			}
+			return nil
+		},
+	}
+
+	workspaceCmd = &cobra.Command{
+		Use:   "workspace",
+		Short: "List the workspaces, named sets of repositories shown together, marking the selected one",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			state := config.LoadState()
+			workspaces := state.GetWorkspaces()
+			if len(workspaces) == 0 {
+				fmt.Println("No workspaces, create one with `claude-squad workspace set <name> <repository>...`")
+				return nil
+			}
+			for _, workspace := range workspaces {
+				marker := " "
+				if workspace.Name == state.GetSelectedWorkspace() {
+					marker = "*"
+				}
+				fmt.Printf("%s %s\n", marker, workspace.Name)
+				for _, repo := range workspace.Repositories {
+					fmt.Printf("    %s\n", repo)
+				}
+			}
+			return nil
+		},
+	}
+
+	workspaceSetCmd = &cobra.Command{
+		Use:   "set <name> <repository>...",
+		Short: "Create a workspace of the given repositories, or replace the repositories of an existing one",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			state := config.LoadState()
+			workspace := config.Workspace{Name: args[0]}
+			for _, path := range args[1:] {
+				repoPath, err := config.FindRepositoryForPath(path)
+				if err != nil {
+					return err
+				}
+				// Repositories claude-squad hasn't been used in yet are registered, so they get tabs.
+				if _, err := state.GetRepository(repoPath); err != nil {
+					repo, err := config.CreateRepositoryData(repoPath)
+					if err != nil {
+						return err
+					}
+					if err := state.AddRepository(repo); err != nil {
+						return fmt.Errorf("failed to register repository %s: %w", repoPath, err)
+					}
+				}
+				if !workspace.Contains(repoPath) {
+					workspace.Repositories = append(workspace.Repositories, repoPath)
+				}
+			}
+			if err := state.SaveWorkspace(workspace); err != nil {
+				return err
+			}
+			fmt.Printf("Saved workspace %s with %d repositories\n", workspace.Name, len(workspace.Repositories))
+			return nil
+		},
+	}
+
+	workspaceDeleteCmd = &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Delete a workspace, keeping its repositories and their sessions",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.LoadState().RemoveWorkspace(args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("Deleted workspace %s\n", args[0])
+			return nil
+		},
+	}
+
+	versionCmd = &cobra.Command{
+		Use:   "version",
+		Short: "Print the version number of claude-squad",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Printf("claude-squad version %s\n", version)
+			fmt.Printf("https://github.com/smtg-ai/claude-squad/releases/tag/v%s\n", version)
+		},
+	}
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configDirFlag, "config-dir", "",
+		"Directory to keep the config and state in. Defaults to $"+config.ConfigDirEnv+", ~/.claude-squad or the XDG directories")
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "",
+		"Profile whose config and state to use, e.g. 'work'. Defaults to $"+config.ProfileEnv+" or the default profile")
+	rootCmd.Flags().StringVarP(&programFlag, "program", "p", "",
+		"Program to run in new instances (e.g. 'aider --model ollama_chat/gemma3:1b')")
+	rootCmd.Flags().BoolVarP(&autoYesFlag, "autoyes", "y", false,
+		"[experimental] If enabled, all instances will automatically accept prompts")
+	rootCmd.Flags().StringVar(&identityFlag, "identity", "",
+		"Author and committer of the commits made by new instances, e.g. 'Review Bot <bot@example.com>'")
+	rootCmd.Flags().StringVar(&signingKeyFlag, "signing-key", "",
+		"Key to sign the commits made by new instances with: a GPG key ID, or a public key with --signing-format ssh")
+	rootCmd.Flags().StringVar(&signingFormatFlag, "signing-format", "",
+		"Format of --signing-key: openpgp (default), ssh or x509")
+	rootCmd.Flags().BoolVar(&accessibleFlag, "accessible", false,
+		"Render without colors, box-drawing characters or spinners, for screen readers and logging")
+	rootCmd.Flags().BoolVar(&daemonFlag, "daemon", false, "Run a program that loads all sessions"+
+		" and runs autoyes mode on them.")
+
+	// Hide the daemonFlag as it's only for internal use
+	err := rootCmd.Flags().MarkHidden("daemon")
+	if err != nil {
+		panic(err)
+	}
+
+	searchCmd.Flags().BoolVarP(&searchIgnoreCaseFlag, "ignore-case", "i", false, "Match case-insensitively")
+
+	backupCmd.Flags().StringVarP(&backupOutFlag, "out", "o", "",
+		"Archive to write, compressed with zstd if it ends in .zst and gzip otherwise")
+	backupCreateCmd.Flags().StringVar(&snapshotNoteFlag, "note", "", "Why the snapshot is taken, shown by `backup list`")
+	backupCmd.AddCommand(backupCreateCmd)
+	backupCmd.AddCommand(backupListCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+	restoreCmd.Flags().BoolVar(&restoreForceFlag, "force", false, "Overwrite existing state")
+	exportCmd.Flags().StringVarP(&exportOutFlag, "out", "o", "",
+		"Archive to write, compressed with zstd if it ends in .zst and gzip otherwise")
+
+	branchesCmd.Flags().BoolVar(&branchesDeleteFlag, "delete", false, "Delete the orphaned branches")
+	branchesCmd.Flags().BoolVar(&branchesMergedFlag, "merged", false,
+		"Only include branches without commits that aren't on their repository's default branch")
+	branchesCmd.Flags().BoolVarP(&branchesYesFlag, "yes", "y", false, "Delete without asking for confirmation")
+
+	digestCmd.Flags().StringVar(&digestPeriodFlag, "period", digest.Daily, "Period to summarize: daily or weekly")
+	digestCmd.Flags().BoolVar(&digestSendFlag, "send", false,
+		"Send the digest with the command or SMTP server in the config instead of printing it")
+
+	statsCmd.Flags().BoolVar(&statsJSONFlag, "json", false, "Print the stats as JSON")
+
+	scanCmd.Flags().BoolVar(&scanAddFlag, "add", false,
+		"Add the repositories found, so they get tabs right away rather than once sessions are created in them")
+
+	benchCmd.Flags().IntVarP(&benchInstancesFlag, "instances", "n", 10, "Number of synthetic instances")
+	benchCmd.Flags().StringVar(&benchProgramFlag, "program", "sh", "Program to run in the synthetic instances")
+
+	configExportCmd.Flags().StringVarP(&configExportOutFlag, "out", "o", "", "File to write instead of stdout")
+	configValidateCmd.Flags().BoolVar(&validateJSONFlag, "json", false, "Print the report as JSON")
+	syncCmd.AddCommand(syncPullCmd)
+	syncCmd.AddCommand(syncPushCmd)
+	configCmd.AddCommand(configExportCmd)
+	configCmd.AddCommand(configImportCmd)
+	configCmd.AddCommand(configValidateCmd)
+	secretsCmd.AddCommand(secretsSetCmd)
+	preferencesCmd.AddCommand(preferencesSetCmd)
+	profileCmd.AddCommand(profileListCmd)
+	profileCmd.AddCommand(profileCreateCmd)
+	profileCmd.AddCommand(profileDeleteCmd)
+	repoCmd.AddCommand(repoAliasCmd)
+	repoCmd.AddCommand(repoProgramCmd)
+	workspaceCmd.AddCommand(workspaceSetCmd)
+	workspaceCmd.AddCommand(workspaceDeleteCmd)
+
+	rootCmd.AddCommand(debugCmd)
+	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(resetCmd)
+	rootCmd.AddCommand(searchCmd)
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(restoreCmd)
+	rootCmd.AddCommand(restoreStateCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(importCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(retentionCmd)
+	rootCmd.AddCommand(branchesCmd)
+	rootCmd.AddCommand(cleanupCmd)
+	rootCmd.AddCommand(digestCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(benchCmd)
+	rootCmd.AddCommand(pluginsCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(secretsCmd)
+	rootCmd.AddCommand(preferencesCmd)
+	rootCmd.AddCommand(profileCmd)
+	rootCmd.AddCommand(repoCmd)
+	rootCmd.AddCommand(scanCmd)
+	rootCmd.AddCommand(workspaceCmd)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// syncState pulls and pushes the synced state, as asked, through the configured remote and reports what changed.
+func syncState(pull, push bool) error {
+	remote, err := backup.NewRemote(config.LoadConfig().Sync)
+	if err != nil {
+		return err
+	}
+	if pull {
+		var result *backup.SyncResult
+		err := withCredentials(func() (err error) {
+			result, err = backup.Pull(remote)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		for _, warning := range result.Warnings {
+			fmt.Printf("warning: %s\n", warning)
+		}
+		switch {
+		case result.Remote == nil:
+			fmt.Println("Nothing was synced yet")
+		case !result.Changed:
+			fmt.Println("Already up to date")
+		default:
+			fmt.Printf("Pulled the state pushed from %s at %s: %d repositories and %d instances changed\n",
+				result.Remote.Machine, result.Remote.ExportedAt.Format(time.RFC822), result.Repositories, result.Instances)
+		}
+	}
+	if push {
+		var result *backup.SyncResult
+		err := withCredentials(func() (err error) {
+			result, err = backup.Push(remote)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		for _, warning := range result.Warnings {
+			fmt.Printf("warning: %s\n", warning)
+		}
+		if result.Changed {
+			fmt.Printf("Pushed %d repositories and %d instances\n", len(result.Remote.Repositories),
+				len(result.Remote.Instances))
+		} else {
+			fmt.Println("Nothing to push")
+		}
+	}
+	return nil
+}
+
+// withCredentials runs sync, and if one of its git commands needs credentials, runs that command in the
+// terminal so they can be entered, then runs sync again.
+func withCredentials(sync func() error) error {
+	err := sync()
+	var credErr *git.CredentialsRequiredError
+	if !errors.As(err, &credErr) {
+		return err
+	}
+	fmt.Printf("git needs credentials: %s\n", credErr.Prompt())
+	cmd := credErr.Command()
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s failed: %w", cmd.Args[1], err)
 	}
+	return sync()
 }
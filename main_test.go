@@ -0,0 +1,40 @@
+package main
+
+import (
+	"claude-squad/config"
+	"claude-squad/network"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAirGappedWithoutProfile(t *testing.T) {
+	dir := t.TempDir()
+	// SetConfigDir and SetProfile set these, so they're restored after the test.
+	t.Setenv(config.ConfigDirEnv, "")
+	t.Setenv(config.ProfileEnv, "")
+	if err := os.WriteFile(filepath.Join(dir, config.ConfigFileName), []byte(`{"air_gapped": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer network.SetAirGapped(false)
+
+	rootCmd.SetArgs([]string{"--config-dir", dir, "version"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("failed to run the root command: %v", err)
+	}
+	if err := network.Check("pushing branches"); err == nil {
+		t.Error("air-gapped mode isn't on for the default profile")
+	}
+}
+
+func TestVersionWithoutClaude(t *testing.T) {
+	// A fresh install: no config file and claude not on the PATH, before logging is initialized.
+	t.Setenv(config.ConfigDirEnv, "")
+	t.Setenv(config.ProfileEnv, "")
+	t.Setenv("PATH", "")
+
+	rootCmd.SetArgs([]string{"--config-dir", t.TempDir(), "version"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("failed to run the version command: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+)
+
+// ErrAirGapped is the error of the features that reach network services while air-gapped mode is on.
+var ErrAirGapped = errors.New("air-gapped mode is on")
+
+// airGapped is whether air-gapped mode is on, set once at startup from the config.
+var airGapped atomic.Bool
+
+// SetAirGapped turns air-gapped mode on or off. While it's on, every feature that reaches a network service
+// fails with ErrAirGapped before connecting, and the clients and transports of this package refuse to dial.
+func SetAirGapped(on bool) {
+	airGapped.Store(on)
+}
+
+// AirGapped returns true if air-gapped mode is on.
+func AirGapped() bool {
+	return airGapped.Load()
+}
+
+// Check returns an error wrapping ErrAirGapped if air-gapped mode is on. feature describes what was
+// disabled, e.g. "pushing branches", for the error message.
+func Check(feature string) error {
+	if !AirGapped() {
+		return nil
+	}
+	return fmt.Errorf("%w, %s is disabled", ErrAirGapped, feature)
+}
+
+// refuseDial is the dialer of transports in air-gapped mode, a last resort for requests no feature checked.
+func refuseDial(ctx context.Context, network, addr string) (net.Conn, error) {
+	return nil, fmt.Errorf("%w, refused to connect to %s", ErrAirGapped, addr)
+}
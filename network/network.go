@@ -0,0 +1,121 @@
+// Package network builds the HTTP clients and TLS configurations of the features that reach network services,
+// honoring the proxies and certificate authorities in the config.
+package network
+
+import (
+	"claude-squad/config"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// direct is the proxy of an override that connects without a proxy.
+const direct = "direct"
+
+// NewClient returns an HTTP client for cfg whose requests time out after timeout.
+func NewClient(cfg config.NetworkConfig, timeout time.Duration) (*http.Client, error) {
+	transport, err := NewTransport(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+// NewTransport returns an HTTP transport that goes through the proxies and trusts the certificate authorities
+// of cfg. In air-gapped mode, it refuses to connect.
+func NewTransport(cfg config.NetworkConfig) (*http.Transport, error) {
+	proxy, err := proxyFunc(cfg)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := TLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	if AirGapped() {
+		transport.DialContext = refuseDial
+	}
+	return transport, nil
+}
+
+// TLSConfig returns the TLS configuration trusting the system's certificate authorities and those of
+// cfg.CABundle, or nil if there's no bundle and the defaults apply.
+func TLSConfig(cfg config.NetworkConfig) (*tls.Config, error) {
+	if cfg.CABundle == "" {
+		return nil, nil
+	}
+	pem, err := os.ReadFile(cfg.CABundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("the CA bundle %s has no PEM certificates", cfg.CABundle)
+	}
+	return &tls.Config{RootCAs: pool}, nil
+}
+
+// proxyFunc returns the function choosing the proxy of each request: that of the override for its host, or
+// the configured proxy, or the one in the environment.
+func proxyFunc(cfg config.NetworkConfig) (func(*http.Request) (*url.URL, error), error) {
+	parse := func(proxy string) (*url.URL, error) {
+		if proxy == direct {
+			return nil, nil
+		}
+		u, err := url.Parse(proxy)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy %q, expected a URL like http://proxy.example.com:8080", proxy)
+		}
+		return u, nil
+	}
+	overrides := make([]*url.URL, len(cfg.Proxies))
+	for i, override := range cfg.Proxies {
+		u, err := parse(override.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		overrides[i] = u
+	}
+	var proxy *url.URL
+	if cfg.Proxy != "" {
+		u, err := parse(cfg.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		proxy = u
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		host := strings.ToLower(req.URL.Hostname())
+		for i, override := range cfg.Proxies {
+			if matchesHost(host, strings.ToLower(override.Host)) {
+				return overrides[i], nil
+			}
+		}
+		if cfg.Proxy != "" {
+			return proxy, nil
+		}
+		return http.ProxyFromEnvironment(req)
+	}, nil
+}
+
+// matchesHost returns true if host is pattern, or a subdomain of it if pattern starts with a dot.
+func matchesHost(host, pattern string) bool {
+	if domain, ok := strings.CutPrefix(pattern, "."); ok {
+		return host == domain || strings.HasSuffix(host, pattern)
+	}
+	return host == pattern
+}
@@ -0,0 +1,87 @@
+package network
+
+import (
+	"claude-squad/config"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxy(t *testing.T) {
+	proxy, err := proxyFunc(config.NetworkConfig{
+		Proxy: "http://proxy.example.com:8080",
+		Proxies: []config.ProxyOverride{
+			{Host: ".corp.example.com", Proxy: "direct"},
+			{Host: "Jira.example.com", Proxy: "http://jira-proxy.example.com:3128"},
+		},
+	})
+	require.NoError(t, err)
+
+	for host, expected := range map[string]string{
+		"api.deps.dev":            "http://proxy.example.com:8080",
+		"corp.example.com":        "",
+		"git.corp.example.com":    "",
+		"jira.example.com":        "http://jira-proxy.example.com:3128",
+		"notjira.example.com":     "http://proxy.example.com:8080",
+		"evilcorp.example.com.io": "http://proxy.example.com:8080",
+	} {
+		req, err := http.NewRequest(http.MethodGet, "https://"+host+"/", nil)
+		require.NoError(t, err)
+		u, err := proxy(req)
+		require.NoError(t, err)
+		if expected == "" {
+			require.Nil(t, u, host)
+		} else {
+			require.Equal(t, expected, u.String(), host)
+		}
+	}
+
+	_, err = proxyFunc(config.NetworkConfig{Proxy: "proxy.example.com"})
+	require.Error(t, err)
+}
+
+func TestCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client, err := NewClient(config.NetworkConfig{}, time.Second)
+	require.NoError(t, err)
+	_, err = client.Get(server.URL)
+	require.Error(t, err, "the server's certificate isn't trusted by default")
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(bundle, cert, 0644))
+	client, err = NewClient(config.NetworkConfig{CABundle: bundle}, time.Second)
+	require.NoError(t, err)
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.NoError(t, os.WriteFile(bundle, []byte("not a certificate"), 0644))
+	_, err = NewClient(config.NetworkConfig{CABundle: bundle}, time.Second)
+	require.Error(t, err)
+}
+
+func TestAirGapped(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	require.NoError(t, Check("pushing"))
+	SetAirGapped(true)
+	defer SetAirGapped(false)
+	err := Check("pushing")
+	require.ErrorIs(t, err, ErrAirGapped)
+	require.EqualError(t, err, "air-gapped mode is on, pushing is disabled")
+
+	client, err := NewClient(config.NetworkConfig{}, time.Second)
+	require.NoError(t, err)
+	_, err = client.Get(server.URL)
+	require.ErrorIs(t, err, ErrAirGapped)
+}
@@ -0,0 +1,170 @@
+// Package plugin runs external plugins that extend claude-squad without changes to it.
+//
+// A plugin is an executable named claude-squad-<name> on PATH. For each request, claude-squad runs it
+// with the request as a JSON object on stdin and reads the response as a JSON object from stdout. A
+// non-zero exit status is a failure, and the plugin's stderr is reported as the error. The requests
+// are:
+//
+//	{"type": "describe"}
+//	    -> Manifest: the commands, columns and creation flows the plugin adds.
+//	{"type": "command", "name": "...", "instance": {...}}
+//	    -> {"message": "..."}: runs a command from the palette on the selected instance, if any.
+//	{"type": "columns", "instances": [{...}]}
+//	    -> {"values": {"<instance title>": {"<column>": "..."}}}: the plugin's columns in the list.
+//	{"type": "create", "name": "..."}
+//	    -> {"title": "...", "prompt": "..."}: a new instance to name and start with the prompt.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Prefix is the prefix of the names of plugin executables.
+const Prefix = "claude-squad-"
+
+// timeout bounds how long a plugin may take to respond to a request.
+const timeout = 30 * time.Second
+
+// Item is a command or creation flow a plugin adds to the palette.
+type Item struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Manifest describes what a plugin adds.
+type Manifest struct {
+	// Commands are run from the palette on the selected instance.
+	Commands []Item `json:"commands,omitempty"`
+	// Columns are shown under each instance in the list.
+	Columns []string `json:"columns,omitempty"`
+	// Flows create new instances from the palette.
+	Flows []Item `json:"flows,omitempty"`
+}
+
+// Plugin is a discovered plugin executable.
+type Plugin struct {
+	// Name is the executable's name without the prefix.
+	Name string
+	// Path is the executable's path.
+	Path     string
+	Manifest Manifest
+}
+
+// Instance is an instance as it's sent to plugins.
+type Instance struct {
+	Title      string `json:"title"`
+	Status     string `json:"status"`
+	Branch     string `json:"branch,omitempty"`
+	Repository string `json:"repository,omitempty"`
+	Worktree   string `json:"worktree,omitempty"`
+	Program    string `json:"program,omitempty"`
+}
+
+type request struct {
+	Type      string     `json:"type"`
+	Name      string     `json:"name,omitempty"`
+	Instance  *Instance  `json:"instance,omitempty"`
+	Instances []Instance `json:"instances,omitempty"`
+}
+
+// CommandResult is a plugin's response to a command.
+type CommandResult struct {
+	// Message is shown to the user.
+	Message string `json:"message,omitempty"`
+}
+
+// NewInstance is a plugin's response to a creation flow.
+type NewInstance struct {
+	// Title is the suggested title for the new instance.
+	Title string `json:"title,omitempty"`
+	// Prompt is sent to the new instance once it's started.
+	Prompt string `json:"prompt,omitempty"`
+}
+
+// Discover finds the plugin executables on PATH and asks each for its manifest. If there are several
+// with the same name, the first on PATH is used, like a shell would. Plugins that fail to describe
+// themselves are returned as errors alongside the others.
+func Discover() ([]*Plugin, []error) {
+	var plugins []*Plugin
+	var errs []error
+	seen := make(map[string]bool)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := strings.CutPrefix(entry.Name(), Prefix)
+			if !ok || name == "" || seen[name] {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if info, err := os.Stat(path); err != nil || info.IsDir() || info.Mode()&0111 == 0 {
+				continue
+			}
+			seen[name] = true
+
+			p := &Plugin{Name: name, Path: path}
+			if err := p.call(request{Type: "describe"}, &p.Manifest); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			plugins = append(plugins, p)
+		}
+	}
+	return plugins, errs
+}
+
+// RunCommand runs the plugin's command on instance, which may be nil.
+func (p *Plugin) RunCommand(name string, instance *Instance) (CommandResult, error) {
+	var result CommandResult
+	err := p.call(request{Type: "command", Name: name, Instance: instance}, &result)
+	return result, err
+}
+
+// Columns returns the values of the plugin's columns for each instance, by instance title and column.
+func (p *Plugin) Columns(instances []Instance) (map[string]map[string]string, error) {
+	var result struct {
+		Values map[string]map[string]string `json:"values"`
+	}
+	err := p.call(request{Type: "columns", Instances: instances}, &result)
+	return result.Values, err
+}
+
+// Create runs the plugin's creation flow.
+func (p *Plugin) Create(name string) (NewInstance, error) {
+	var result NewInstance
+	err := p.call(request{Type: "create", Name: name}, &result)
+	return result, err
+}
+
+// call sends req to the plugin and decodes its response into resp.
+func (p *Plugin) call(req request, resp any) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s request for plugin %s: %w", req.Type, p.Name, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Path)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("plugin %s failed to handle %s: %s (%w)", p.Name, req.Type, strings.TrimSpace(stderr.String()), err)
+	}
+	if err := json.Unmarshal(stdout.Bytes(), resp); err != nil {
+		return fmt.Errorf("plugin %s sent an invalid %s response: %w", p.Name, req.Type, err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testPlugin = `#!/bin/sh
+request=$(cat)
+case "$request" in
+*'"type":"describe"'*)
+	echo '{"commands": [{"name": "open-pr", "description": "open the pull request"}], "columns": ["ci"], "flows": [{"name": "bug"}]}' ;;
+*'"type":"command"'*)
+	echo "$request" | grep -q '"title":"fix-login"' && echo '{"message": "opened"}' ;;
+*'"type":"columns"'*)
+	echo '{"values": {"fix-login": {"ci": "passing"}}}' ;;
+*'"type":"create"'*)
+	echo '{"title": "bug-123", "prompt": "fix bug 123"}' ;;
+esac
+`
+
+func TestPlugin(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "claude-squad-github"), []byte(testPlugin), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "claude-squad-broken"), []byte("#!/bin/sh\necho oops >&2\nexit 1\n"), 0755))
+	// Not executable, so not a plugin.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "claude-squad-notes"), []byte("notes"), 0644))
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	plugins, errs := Discover()
+	require.Len(t, errs, 1)
+	require.ErrorContains(t, errs[0], "oops")
+	require.Len(t, plugins, 1)
+	p := plugins[0]
+	require.Equal(t, "github", p.Name)
+	require.Equal(t, Manifest{
+		Commands: []Item{{Name: "open-pr", Description: "open the pull request"}},
+		Columns:  []string{"ci"},
+		Flows:    []Item{{Name: "bug"}},
+	}, p.Manifest)
+
+	result, err := p.RunCommand("open-pr", &Instance{Title: "fix-login"})
+	require.NoError(t, err)
+	require.Equal(t, "opened", result.Message)
+
+	values, err := p.Columns([]Instance{{Title: "fix-login"}})
+	require.NoError(t, err)
+	require.Equal(t, "passing", values["fix-login"]["ci"])
+
+	created, err := p.Create("bug")
+	require.NoError(t, err)
+	require.Equal(t, NewInstance{Title: "bug-123", Prompt: "fix bug 123"}, created)
+}
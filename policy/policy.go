@@ -0,0 +1,129 @@
+// Package policy loads organization policy files, which constrain what claude-squad may do in a way
+// the user's own config can't override.
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	// SystemPath is the machine-wide policy file, typically managed by an administrator.
+	SystemPath = "/etc/claude-squad/policy.json"
+	// RepoFileName is the path of the policy file committed to a repository, relative to its root.
+	RepoFileName = ".claude-squad/policy.json"
+)
+
+// Policy is a set of constraints on claude-squad's behavior.
+type Policy struct {
+	// DisableAutoYes prevents instances from automatically accepting prompts.
+	DisableAutoYes bool `json:"disable_auto_yes,omitempty"`
+	// AllowedPrograms restricts the programs instances may run, by executable name (e.g. "claude").
+	// Empty allows any program.
+	AllowedPrograms []string `json:"allowed_programs,omitempty"`
+	// PreMergeChecks are shell commands that must succeed in an instance's worktree before its branch
+	// is pushed.
+	PreMergeChecks []string `json:"pre_merge_checks,omitempty"`
+	// ForbiddenBranchPatterns are glob patterns (e.g. "main", "release/*") that instance branches may
+	// not match.
+	ForbiddenBranchPatterns []string `json:"forbidden_branch_patterns,omitempty"`
+}
+
+// Load returns the policy in effect for the repository at repoPath, combining the system policy with
+// the policy committed to the repository. Missing files impose no constraints.
+func Load(repoPath string) (*Policy, error) {
+	policy, err := loadFile(SystemPath)
+	if err != nil {
+		return nil, err
+	}
+	if repoPath == "" {
+		return policy, nil
+	}
+	repoPolicy, err := loadFile(filepath.Join(repoPath, RepoFileName))
+	if err != nil {
+		return nil, err
+	}
+	return policy.Merge(repoPolicy), nil
+}
+
+func loadFile(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Policy{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy %s: %w", path, err)
+	}
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy %s: %w", path, err)
+	}
+	return &policy, nil
+}
+
+// Merge combines two policies so that the result is at least as strict as both.
+func (p *Policy) Merge(other *Policy) *Policy {
+	merged := &Policy{
+		DisableAutoYes:          p.DisableAutoYes || other.DisableAutoYes,
+		PreMergeChecks:          append(slices.Clone(p.PreMergeChecks), other.PreMergeChecks...),
+		ForbiddenBranchPatterns: append(slices.Clone(p.ForbiddenBranchPatterns), other.ForbiddenBranchPatterns...),
+	}
+	switch {
+	case p.AllowedPrograms == nil:
+		merged.AllowedPrograms = other.AllowedPrograms
+	case other.AllowedPrograms == nil:
+		merged.AllowedPrograms = p.AllowedPrograms
+	default:
+		// A program has to be allowed by both policies.
+		for _, program := range p.AllowedPrograms {
+			if slices.Contains(other.AllowedPrograms, program) {
+				merged.AllowedPrograms = append(merged.AllowedPrograms, program)
+			}
+		}
+		if merged.AllowedPrograms == nil {
+			merged.AllowedPrograms = []string{}
+		}
+	}
+	return merged
+}
+
+// CheckProgram returns an error if the program command isn't allowed.
+func (p *Policy) CheckProgram(program string) error {
+	if p.AllowedPrograms == nil {
+		return nil
+	}
+	fields := strings.Fields(program)
+	if len(fields) > 0 && slices.Contains(p.AllowedPrograms, filepath.Base(fields[0])) {
+		return nil
+	}
+	return fmt.Errorf("program %q is not allowed by policy, allowed programs: %s",
+		program, strings.Join(p.AllowedPrograms, ", "))
+}
+
+// CheckBranch returns an error if the branch name matches a forbidden pattern.
+func (p *Policy) CheckBranch(branch string) error {
+	for _, pattern := range p.ForbiddenBranchPatterns {
+		if matched, _ := path.Match(pattern, branch); matched {
+			return fmt.Errorf("branch %q is forbidden by policy (matches %q)", branch, pattern)
+		}
+	}
+	return nil
+}
+
+// RunPreMergeChecks runs the pre-merge checks in dir, stopping at the first failure.
+func (p *Policy) RunPreMergeChecks(dir string) error {
+	for _, check := range p.PreMergeChecks {
+		cmd := exec.Command("sh", "-c", check)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("pre-merge check %q failed: %s (%w)", check, strings.TrimSpace(string(output)), err)
+		}
+	}
+	return nil
+}
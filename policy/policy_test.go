@@ -0,0 +1,45 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeIsAtLeastAsStrictAsBoth(t *testing.T) {
+	system := &Policy{AllowedPrograms: []string{"claude", "aider"}, ForbiddenBranchPatterns: []string{"main"}}
+	repo := &Policy{DisableAutoYes: true, AllowedPrograms: []string{"claude", "codex"}, PreMergeChecks: []string{"make test"}}
+
+	merged := system.Merge(repo)
+	require.True(t, merged.DisableAutoYes)
+	require.Equal(t, []string{"claude"}, merged.AllowedPrograms)
+	require.Equal(t, []string{"main"}, merged.ForbiddenBranchPatterns)
+	require.Equal(t, []string{"make test"}, merged.PreMergeChecks)
+
+	// Disjoint allow lists allow nothing rather than everything.
+	merged = (&Policy{AllowedPrograms: []string{"aider"}}).Merge(&Policy{AllowedPrograms: []string{"codex"}})
+	require.Error(t, merged.CheckProgram("aider"))
+}
+
+func TestCheckProgram(t *testing.T) {
+	policy := &Policy{AllowedPrograms: []string{"claude", "aider"}}
+	require.NoError(t, policy.CheckProgram("/usr/local/bin/claude"))
+	require.NoError(t, policy.CheckProgram("aider --model ollama_chat/gemma3:1b"))
+	require.Error(t, policy.CheckProgram("codex"))
+
+	require.NoError(t, (&Policy{}).CheckProgram("anything"))
+}
+
+func TestCheckBranch(t *testing.T) {
+	policy := &Policy{ForbiddenBranchPatterns: []string{"main", "release/*"}}
+	require.Error(t, policy.CheckBranch("main"))
+	require.Error(t, policy.CheckBranch("release/1.0"))
+	require.NoError(t, policy.CheckBranch("alice/fix-login"))
+}
+
+func TestRunPreMergeChecks(t *testing.T) {
+	require.NoError(t, (&Policy{PreMergeChecks: []string{"true"}}).RunPreMergeChecks(t.TempDir()))
+
+	err := (&Policy{PreMergeChecks: []string{"true", "echo lint failed; false"}}).RunPreMergeChecks(t.TempDir())
+	require.ErrorContains(t, err, "lint failed")
+}
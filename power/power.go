@@ -0,0 +1,31 @@
+// Package power detects whether the machine is running on battery, so refreshes can be slowed down to save
+// it.
+package power
+
+import (
+	"claude-squad/config"
+	"time"
+)
+
+// CheckInterval is how often callers in auto low-power mode should check whether the machine is on battery.
+const CheckInterval = 30 * time.Second
+
+// LowPower reports whether refreshes should be slowed down under cfg, checking the power source in auto mode.
+func LowPower(cfg config.RefreshConfig) bool {
+	switch cfg.GetLowPower() {
+	case config.LowPowerAlways:
+		return true
+	case config.LowPowerNever:
+		return false
+	default:
+		return OnBattery()
+	}
+}
+
+// Scale returns interval slowed down by cfg's low-power factor if lowPower is set.
+func Scale(cfg config.RefreshConfig, interval time.Duration, lowPower bool) time.Duration {
+	if !lowPower {
+		return interval
+	}
+	return interval * time.Duration(cfg.GetLowPowerFactor())
+}
@@ -0,0 +1,22 @@
+package power
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// OnBattery reports whether the machine is running on battery, going by pmset.
+func OnBattery() bool {
+	out, err := exec.Command("pmset", "-g", "batt").Output()
+	if err != nil {
+		return false
+	}
+	return onBatteryFromPmset(string(out))
+}
+
+// onBatteryFromPmset parses the output of `pmset -g batt`, whose first line names the power source, e.g.
+// "Now drawing from 'Battery Power'".
+func onBatteryFromPmset(out string) bool {
+	first, _, _ := strings.Cut(out, "\n")
+	return strings.Contains(first, "'Battery Power'")
+}
@@ -0,0 +1,46 @@
+package power
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// supplyDir is where the kernel lists the power supplies.
+var supplyDir = "/sys/class/power_supply"
+
+// OnBattery reports whether the machine is running on battery: it has a discharging battery and no mains
+// supply online. Machines without a battery, or whose power supplies can't be read, aren't.
+func OnBattery() bool {
+	entries, err := os.ReadDir(supplyDir)
+	if err != nil {
+		return false
+	}
+	discharging := false
+	for _, entry := range entries {
+		dir := filepath.Join(supplyDir, entry.Name())
+		switch readAttr(dir, "type") {
+		case "Mains", "USB":
+			if readAttr(dir, "online") == "1" {
+				return false
+			}
+		case "Battery":
+			// Peripherals' batteries, e.g. a mouse's, don't power the machine.
+			if readAttr(dir, "scope") == "Device" {
+				continue
+			}
+			if readAttr(dir, "status") == "Discharging" {
+				discharging = true
+			}
+		}
+	}
+	return discharging
+}
+
+func readAttr(dir, name string) string {
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(content))
+}
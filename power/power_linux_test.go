@@ -0,0 +1,36 @@
+package power
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeSupply(t *testing.T, name string, attrs map[string]string) {
+	dir := filepath.Join(supplyDir, name)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	for attr, value := range attrs {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, attr), []byte(value+"\n"), 0644))
+	}
+}
+
+func TestOnBattery(t *testing.T) {
+	old := supplyDir
+	defer func() { supplyDir = old }()
+
+	supplyDir = t.TempDir()
+	require.False(t, OnBattery(), "no battery")
+
+	writeSupply(t, "BAT0", map[string]string{"type": "Battery", "status": "Discharging"})
+	writeSupply(t, "hidpp_battery_0", map[string]string{"type": "Battery", "scope": "Device", "status": "Charging"})
+	writeSupply(t, "AC", map[string]string{"type": "Mains", "online": "0"})
+	require.True(t, OnBattery())
+
+	writeSupply(t, "AC", map[string]string{"online": "1"})
+	require.False(t, OnBattery(), "plugged in")
+
+	supplyDir = filepath.Join(t.TempDir(), "missing")
+	require.False(t, OnBattery())
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin
+
+package power
+
+// OnBattery reports whether the machine is running on battery. It isn't detected on this platform.
+func OnBattery() bool {
+	return false
+}
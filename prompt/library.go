@@ -0,0 +1,91 @@
+package prompt
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// TasksDirName is the directory in the config dir that holds user-defined task templates.
+const TasksDirName = "tasks"
+
+//go:embed tasks/*.md
+var builtinTasks embed.FS
+
+// Task is a reusable prompt template. Tasks are markdown files: the file name without its extension is
+// the task's name, an optional first line starting with "# " is its description and the rest is the prompt.
+type Task struct {
+	Name        string
+	Description string
+	Prompt      string
+	// Builtin is true if the task ships with claude-squad rather than coming from the user's tasks dir.
+	Builtin bool
+}
+
+// LoadTasks returns the built-in tasks together with the ones in dir, sorted by name. A task in dir
+// replaces the built-in task of the same name. A missing dir is not an error.
+func LoadTasks(dir string) ([]Task, error) {
+	tasks := make(map[string]Task)
+
+	builtin, err := readTasks(builtinTasks, "tasks")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read built-in tasks: %w", err)
+	}
+	for _, task := range builtin {
+		task.Builtin = true
+		tasks[task.Name] = task
+	}
+
+	if dir != "" {
+		user, err := readTasks(os.DirFS(dir), ".")
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read tasks from %s: %w", dir, err)
+		}
+		for _, task := range user {
+			tasks[task.Name] = task
+		}
+	}
+
+	result := make([]Task, 0, len(tasks))
+	for _, task := range tasks {
+		result = append(result, task)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+func readTasks(fsys fs.FS, dir string) ([]Task, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var tasks []Task
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".md" {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, ParseTask(strings.TrimSuffix(entry.Name(), ".md"), string(data)))
+	}
+	return tasks, nil
+}
+
+// ParseTask parses the contents of a task file.
+func ParseTask(name, content string) Task {
+	task := Task{Name: name}
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	if rest, ok := strings.CutPrefix(content, "# "); ok {
+		description, body, _ := strings.Cut(rest, "\n")
+		task.Description = strings.TrimSpace(description)
+		content = body
+	}
+	task.Prompt = strings.TrimSpace(content)
+	return task
+}
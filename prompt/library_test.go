@@ -0,0 +1,50 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTask(t *testing.T) {
+	task := ParseTask("add-tests", "# Add tests\n\nWrite tests for {{files}}.\n")
+	require.Equal(t, "add-tests", task.Name)
+	require.Equal(t, "Add tests", task.Description)
+	require.Equal(t, "Write tests for {{files}}.", task.Prompt)
+
+	task = ParseTask("plain", "Just a prompt")
+	require.Empty(t, task.Description)
+	require.Equal(t, "Just a prompt", task.Prompt)
+}
+
+func TestLoadTasks(t *testing.T) {
+	builtin, err := LoadTasks(filepath.Join(t.TempDir(), "missing"))
+	require.NoError(t, err)
+	require.NotEmpty(t, builtin)
+	for _, task := range builtin {
+		require.True(t, task.Builtin)
+		require.NotEmpty(t, task.Prompt, task.Name)
+		require.NotEmpty(t, task.Description, task.Name)
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "add-tests.md"), []byte("# Mine\nMy tests prompt"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "release.md"), []byte("Cut a release"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0644))
+
+	tasks, err := LoadTasks(dir)
+	require.NoError(t, err)
+	require.Len(t, tasks, len(builtin)+1)
+
+	byName := make(map[string]Task)
+	for _, task := range tasks {
+		byName[task.Name] = task
+	}
+	require.Equal(t, "My tests prompt", byName["add-tests"].Prompt)
+	require.False(t, byName["add-tests"].Builtin)
+	require.Equal(t, "Cut a release", byName["release"].Prompt)
+	_, ok := byName["notes"]
+	require.False(t, ok)
+}
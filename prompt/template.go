@@ -0,0 +1,44 @@
+// Package prompt expands {{variable}} placeholders in prompts before they're sent to an instance.
+package prompt
+
+import (
+	"regexp"
+	"strings"
+)
+
+var variableRegex = regexp.MustCompile(`{{\s*([A-Za-z_][A-Za-z0-9_]*)\s*}}`)
+
+// Variables returns the names of the variables referenced in text, in order of first appearance.
+func Variables(text string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range variableRegex.FindAllStringSubmatch(text, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// Missing returns the variables referenced in text that have no value in values.
+func Missing(text string, values map[string]string) []string {
+	var missing []string
+	for _, name := range Variables(text) {
+		if _, ok := values[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// Expand replaces every variable in text with its value. Variables without a value are left as is.
+func Expand(text string, values map[string]string) string {
+	return variableRegex.ReplaceAllStringFunc(text, func(match string) string {
+		name := strings.TrimSpace(match[2 : len(match)-2])
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return match
+	})
+}
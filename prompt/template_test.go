@@ -0,0 +1,24 @@
+package prompt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVariables(t *testing.T) {
+	text := "Fix {{issue_title}} on {{ branch }}, touching {{files}}. Mention {{issue_title}} in the commit."
+	require.Equal(t, []string{"issue_title", "branch", "files"}, Variables(text))
+	require.Empty(t, Variables("no variables, {{ not a var }}"))
+}
+
+func TestMissing(t *testing.T) {
+	values := map[string]string{"branch": "alice/fix", "files": ""}
+	require.Equal(t, []string{"issue_title"}, Missing("{{issue_title}} {{branch}} {{files}}", values))
+}
+
+func TestExpand(t *testing.T) {
+	values := map[string]string{"branch": "alice/fix", "repo": "claude-squad"}
+	require.Equal(t, "Work on alice/fix in claude-squad, see {{ticket}}",
+		Expand("Work on {{branch}} in {{ repo }}, see {{ticket}}", values))
+}
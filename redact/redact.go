@@ -0,0 +1,98 @@
+// Package redact masks secrets such as API keys, tokens and email addresses in agent output before it's
+// displayed or persisted.
+package redact
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"fmt"
+	"regexp"
+)
+
+// Placeholder replaces every redacted match.
+const Placeholder = "[REDACTED]"
+
+// secretPatterns match common credential formats.
+var secretPatterns = []string{
+	`AKIA[0-9A-Z]{16}`,                                              // AWS access key ID
+	`gh[pousr]_[A-Za-z0-9]{36,}`,                                    // GitHub token
+	`github_pat_[A-Za-z0-9_]{22,}`,                                  // GitHub fine-grained token
+	`glpat-[A-Za-z0-9_-]{20,}`,                                      // GitLab token
+	`sk-ant-[A-Za-z0-9_-]{20,}`,                                     // Anthropic API key
+	`sk-[A-Za-z0-9_-]{20,}`,                                         // OpenAI-style API key
+	`xox[abposr]-[A-Za-z0-9-]{10,}`,                                 // Slack token
+	`AIza[0-9A-Za-z_-]{35}`,                                         // Google API key
+	`eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`, // JWT
+	`(?i)bearer\s+[A-Za-z0-9._~+/-]{20,}=*`,                         // bearer token
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----`,                            // private key header
+}
+
+const emailPattern = `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`
+
+// Redactor replaces matches of its patterns with Placeholder.
+type Redactor struct {
+	patterns []*regexp.Regexp
+}
+
+// New returns a redactor for the built-in secret patterns, emails unless keepEmails is set, and the extra
+// patterns.
+func New(extra []string, keepEmails bool) (*Redactor, error) {
+	patterns := append([]string{}, secretPatterns...)
+	if !keepEmails {
+		patterns = append(patterns, emailPattern)
+	}
+	patterns = append(patterns, extra...)
+
+	r := &Redactor{}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// FromConfig returns the redactor configured in cfg. It returns nil, which redacts nothing, if
+// redaction is disabled.
+func FromConfig(cfg *config.Config) (*Redactor, error) {
+	if cfg.Redaction.Disabled {
+		return nil, nil
+	}
+	return New(cfg.Redaction.Patterns, cfg.Redaction.KeepEmails)
+}
+
+// Load returns the redactor configured in cfg. If the configured patterns are invalid, the error is
+// logged and the built-in patterns are used so that secrets are still masked.
+func Load(cfg *config.Config) *Redactor {
+	r, err := FromConfig(cfg)
+	if err != nil {
+		log.ErrorLog.Printf("%v, using the built-in redaction patterns", err)
+		r, _ = New(nil, cfg.Redaction.KeepEmails)
+	}
+	return r
+}
+
+// Matches returns every match of the redactor's patterns in text.
+func (r *Redactor) Matches(text string) []string {
+	if r == nil {
+		return nil
+	}
+	var matches []string
+	for _, re := range r.patterns {
+		matches = append(matches, re.FindAllString(text, -1)...)
+	}
+	return matches
+}
+
+// Redact returns text with every match replaced by Placeholder.
+func (r *Redactor) Redact(text string) string {
+	if r == nil {
+		return text
+	}
+	for _, re := range r.patterns {
+		text = re.ReplaceAllString(text, Placeholder)
+	}
+	return text
+}
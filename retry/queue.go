@@ -0,0 +1,137 @@
+// Package retry queues operations that failed because the network was unavailable and retries them
+// with exponential backoff.
+package retry
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	initialBackoff = 5 * time.Second
+	maxBackoff     = 5 * time.Minute
+)
+
+// operation is a queued operation and its retry schedule.
+type operation struct {
+	name        string
+	run         func() error
+	attempts    int
+	nextAttempt time.Time
+}
+
+// Result is the outcome of an operation that has left the queue.
+type Result struct {
+	// Name is the name the operation was queued with.
+	Name string
+	// Err is nil if the operation succeeded, or the error that made it give up.
+	Err error
+}
+
+// Queue holds operations waiting to be retried. It is safe for concurrent use.
+type Queue struct {
+	mu  sync.Mutex
+	ops []*operation
+	now func() time.Time
+}
+
+// NewQueue creates an empty queue.
+func NewQueue() *Queue {
+	return &Queue{now: time.Now}
+}
+
+// Add queues run to be retried after the initial backoff. name describes the operation to the user.
+func (q *Queue) Add(name string, run func() error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.ops = append(q.ops, &operation{name: name, run: run, nextAttempt: q.now().Add(initialBackoff)})
+}
+
+// Len returns the number of queued operations, including any currently being retried.
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.ops)
+}
+
+// Names returns the names of the queued operations, in the order they were queued.
+func (q *Queue) Names() []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	names := make([]string, len(q.ops))
+	for i, op := range q.ops {
+		names[i] = op.name
+	}
+	return names
+}
+
+// HasDue returns true if any operation is ready to be retried.
+func (q *Queue) HasDue() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+	for _, op := range q.ops {
+		if op.nextAttempt.After(now) || op.attempts < 0 {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// RunDue retries every operation whose backoff has elapsed. Operations failing with an error for
+// which retryable returns true stay queued with a longer backoff; all others leave the queue and are
+// reported in the results.
+func (q *Queue) RunDue(retryable func(error) bool) []Result {
+	q.mu.Lock()
+	now := q.now()
+	var due []*operation
+	for _, op := range q.ops {
+		// A negative attempt count marks an operation that is being run by another call.
+		if op.attempts < 0 || op.nextAttempt.After(now) {
+			continue
+		}
+		due = append(due, op)
+	}
+	attempts := make([]int, len(due))
+	for i, op := range due {
+		attempts[i] = op.attempts
+		op.attempts = -1
+	}
+	q.mu.Unlock()
+
+	var results []Result
+	for i, op := range due {
+		err := op.run()
+
+		q.mu.Lock()
+		if err != nil && retryable(err) {
+			op.attempts = attempts[i] + 1
+			op.nextAttempt = q.now().Add(backoff(op.attempts))
+		} else {
+			q.remove(op)
+			results = append(results, Result{Name: op.name, Err: err})
+		}
+		q.mu.Unlock()
+	}
+	return results
+}
+
+// remove drops op from the queue. The caller must hold q.mu.
+func (q *Queue) remove(op *operation) {
+	for i, queued := range q.ops {
+		if queued == op {
+			q.ops = append(q.ops[:i], q.ops[i+1:]...)
+			return
+		}
+	}
+}
+
+// backoff returns how long to wait before the next attempt after the given number of failed retries.
+func backoff(attempts int) time.Duration {
+	d := initialBackoff
+	for i := 0; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
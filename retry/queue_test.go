@@ -0,0 +1,56 @@
+package retry
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errOffline = errors.New("could not resolve host")
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, 5*time.Second, backoff(0))
+	require.Equal(t, 10*time.Second, backoff(1))
+	require.Equal(t, 40*time.Second, backoff(3))
+	require.Equal(t, maxBackoff, backoff(20))
+}
+
+func TestQueueRunDue(t *testing.T) {
+	now := time.Unix(0, 0)
+	q := NewQueue()
+	q.now = func() time.Time { return now }
+	retryable := func(err error) bool { return errors.Is(err, errOffline) }
+
+	online := false
+	q.Add("push", func() error {
+		if !online {
+			return errOffline
+		}
+		return nil
+	})
+	q.Add("broken", func() error { return errors.New("permission denied") })
+
+	// Nothing is due before the initial backoff has elapsed.
+	require.False(t, q.HasDue())
+	require.Empty(t, q.RunDue(retryable))
+
+	now = now.Add(initialBackoff)
+	require.True(t, q.HasDue())
+	results := q.RunDue(retryable)
+	require.Len(t, results, 1)
+	require.Equal(t, "broken", results[0].Name)
+	require.Error(t, results[0].Err)
+	require.Equal(t, 1, q.Len())
+	require.Equal(t, []string{"push"}, q.Names())
+
+	// The failed push backs off for longer before the next attempt.
+	now = now.Add(initialBackoff)
+	require.False(t, q.HasDue())
+
+	online = true
+	now = now.Add(backoff(1))
+	require.Equal(t, []Result{{Name: "push"}}, q.RunDue(retryable))
+	require.Equal(t, 0, q.Len())
+}
@@ -0,0 +1,217 @@
+// Package rules evaluates the user's automation rules, a Lua script in the config directory that the
+// daemon runs on instance events. For example:
+//
+//	on("diff", function(instance)
+//	  if instance.added + instance.removed > 1000 then
+//	    instance:pause()
+//	    instance:notify("changed over 1000 lines, paused for review")
+//	  end
+//	end)
+//
+//	on("prompt", function(instance)
+//	  for _, file in ipairs(instance.files) do
+//	    if not file.path:match("%.md$") then return false end
+//	  end
+//	  return true
+//	end)
+//
+// Scripts run in a sandbox: only the base, string, table and math libraries are available, so they can't
+// touch files, run programs or load other code, and each handler has a time limit. They act on instances
+// only through the instance passed to their handler.
+package rules
+
+import (
+	"claude-squad/log"
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// FileName is the name of the rules script in the config directory.
+const FileName = "rules.lua"
+
+// Events that rules can handle.
+const (
+	// EventPrompt is when an instance is waiting on a prompt. Handlers return true to approve it, false to
+	// leave it for the user and nil to approve it as usual.
+	EventPrompt = "prompt"
+	// EventDiff is when an instance's diff has changed.
+	EventDiff = "diff"
+	// EventReady is when an instance has finished working.
+	EventReady = "ready"
+)
+
+// handlerTimeout bounds how long a handler may run, so a runaway rule doesn't stall the daemon.
+const handlerTimeout = time.Second
+
+// Subject is the instance an event is about, as rules see it.
+type Subject struct {
+	Title   string
+	Branch  string
+	Status  string
+	Program string
+	Added   int
+	Removed int
+	Files   []git.FileChange
+}
+
+// NewSubject returns instance as rules see it.
+func NewSubject(instance *session.Instance) Subject {
+	subject := Subject{
+		Title:   instance.Title,
+		Branch:  instance.Branch,
+		Status:  instance.Status.String(),
+		Program: instance.Program,
+	}
+	if stats := instance.GetDiffStats(); stats != nil && stats.Error == nil {
+		subject.Added = stats.Added
+		subject.Removed = stats.Removed
+		subject.Files = stats.Files
+	}
+	return subject
+}
+
+// Actions are what rules can do to the instance an event is about.
+type Actions struct {
+	Pause  func() error
+	Notify func(message string)
+}
+
+// Engine runs the handlers registered by a rules script.
+type Engine struct {
+	state    *lua.LState
+	handlers map[string][]*lua.LFunction
+}
+
+// Load runs the rules script at path to register its handlers. It returns nil if there's no script.
+func Load(path string) (*Engine, error) {
+	script, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read rules: %w", err)
+	}
+
+	e := &Engine{state: newSandbox(), handlers: make(map[string][]*lua.LFunction)}
+	e.state.SetGlobal("on", e.state.NewFunction(func(L *lua.LState) int {
+		event := L.CheckString(1)
+		switch event {
+		case EventPrompt, EventDiff, EventReady:
+		default:
+			L.ArgError(1, fmt.Sprintf("unknown event %q", event))
+		}
+		e.handlers[event] = append(e.handlers[event], L.CheckFunction(2))
+		return 0
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), handlerTimeout)
+	defer cancel()
+	e.state.SetContext(ctx)
+	defer e.state.RemoveContext()
+	if err := e.state.DoString(string(script)); err != nil {
+		e.Close()
+		return nil, fmt.Errorf("failed to load rules from %s: %w", path, err)
+	}
+	return e, nil
+}
+
+// newSandbox returns a Lua state without the libraries that reach outside the script.
+func newSandbox() *lua.LState {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, name := range []string{"dofile", "loadfile", "load", "loadstring", "module", "require"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+	L.SetGlobal("print", L.NewFunction(func(L *lua.LState) int {
+		var args []any
+		for i := 1; i <= L.GetTop(); i++ {
+			args = append(args, L.Get(i).String())
+		}
+		log.InfoLog.Print(append([]any{"rules: "}, args...)...)
+		return 0
+	}))
+	return L
+}
+
+// Close releases the script's resources.
+func (e *Engine) Close() {
+	e.state.Close()
+}
+
+// Handles returns true if the script has handlers for event.
+func (e *Engine) Handles(event string) bool {
+	return len(e.handlers[event]) > 0
+}
+
+// Fire runs the handlers for event on subject. For EventPrompt, approve is whether to approve the prompt:
+// false if any handler returned false, and otherwise true.
+func (e *Engine) Fire(event string, subject Subject, actions Actions) (approve bool, err error) {
+	approve = true
+	instance := e.newInstance(subject, actions)
+	for _, handler := range e.handlers[event] {
+		ctx, cancel := context.WithTimeout(context.Background(), handlerTimeout)
+		e.state.SetContext(ctx)
+		err := e.state.CallByParam(lua.P{Fn: handler, NRet: 1, Protect: true}, instance)
+		e.state.RemoveContext()
+		cancel()
+		if err != nil {
+			return true, fmt.Errorf("%s rule failed for %s: %w", event, subject.Title, err)
+		}
+		if ret := e.state.Get(-1); ret == lua.LFalse {
+			approve = false
+		}
+		e.state.Pop(1)
+	}
+	return approve, nil
+}
+
+// newInstance returns the table handlers receive for subject, with its actions as methods.
+func (e *Engine) newInstance(subject Subject, actions Actions) *lua.LTable {
+	L := e.state
+	instance := L.NewTable()
+	instance.RawSetString("title", lua.LString(subject.Title))
+	instance.RawSetString("branch", lua.LString(subject.Branch))
+	instance.RawSetString("status", lua.LString(subject.Status))
+	instance.RawSetString("program", lua.LString(subject.Program))
+	instance.RawSetString("added", lua.LNumber(subject.Added))
+	instance.RawSetString("removed", lua.LNumber(subject.Removed))
+	files := L.NewTable()
+	for _, file := range subject.Files {
+		f := L.NewTable()
+		f.RawSetString("path", lua.LString(file.Path))
+		f.RawSetString("added", lua.LNumber(file.Added))
+		f.RawSetString("removed", lua.LNumber(file.Removed))
+		files.Append(f)
+	}
+	instance.RawSetString("files", files)
+
+	instance.RawSetString("pause", L.NewFunction(func(L *lua.LState) int {
+		if err := actions.Pause(); err != nil {
+			L.RaiseError("failed to pause %s: %v", subject.Title, err)
+		}
+		return 0
+	}))
+	instance.RawSetString("notify", L.NewFunction(func(L *lua.LState) int {
+		actions.Notify(L.CheckString(2))
+		return 0
+	}))
+	return instance
+}
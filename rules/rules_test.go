@@ -0,0 +1,94 @@
+package rules
+
+import (
+	"claude-squad/session/git"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func load(t *testing.T, script string) *Engine {
+	path := filepath.Join(t.TempDir(), FileName)
+	require.NoError(t, os.WriteFile(path, []byte(script), 0644))
+	engine, err := Load(path)
+	require.NoError(t, err)
+	t.Cleanup(engine.Close)
+	return engine
+}
+
+func TestLoadMissing(t *testing.T) {
+	engine, err := Load(filepath.Join(t.TempDir(), FileName))
+	require.NoError(t, err)
+	require.Nil(t, engine)
+}
+
+func TestSandbox(t *testing.T) {
+	for _, script := range []string{
+		`os.execute("true")`,
+		`io.open("/etc/passwd")`,
+		`dofile("/etc/passwd")`,
+		`require("os")`,
+		`on("merged", function() end)`,
+	} {
+		path := filepath.Join(t.TempDir(), FileName)
+		require.NoError(t, os.WriteFile(path, []byte(script), 0644))
+		_, err := Load(path)
+		require.Error(t, err, script)
+	}
+}
+
+func TestFirePrompt(t *testing.T) {
+	engine := load(t, `
+on("prompt", function(instance)
+  for _, file in ipairs(instance.files) do
+    if not file.path:match("%.md$") then return false end
+  end
+  return true
+end)`)
+	require.True(t, engine.Handles(EventPrompt))
+	require.False(t, engine.Handles(EventDiff))
+
+	docs := Subject{Title: "docs", Files: []git.FileChange{{Path: "README.md"}}}
+	approve, err := engine.Fire(EventPrompt, docs, Actions{})
+	require.NoError(t, err)
+	require.True(t, approve)
+
+	code := Subject{Title: "code", Files: []git.FileChange{{Path: "README.md"}, {Path: "main.go"}}}
+	approve, err = engine.Fire(EventPrompt, code, Actions{})
+	require.NoError(t, err)
+	require.False(t, approve)
+}
+
+func TestFireActions(t *testing.T) {
+	engine := load(t, `
+on("diff", function(instance)
+  if instance.added + instance.removed > 1000 then
+    instance:pause()
+    instance:notify(instance.title .. " changed too much")
+  end
+end)`)
+
+	var paused bool
+	var messages []string
+	actions := Actions{
+		Pause:  func() error { paused = true; return nil },
+		Notify: func(message string) { messages = append(messages, message) },
+	}
+
+	_, err := engine.Fire(EventDiff, Subject{Title: "small", Added: 10}, actions)
+	require.NoError(t, err)
+	require.False(t, paused)
+
+	_, err = engine.Fire(EventDiff, Subject{Title: "big", Added: 900, Removed: 200}, actions)
+	require.NoError(t, err)
+	require.True(t, paused)
+	require.Equal(t, []string{"big changed too much"}, messages)
+}
+
+func TestFireTimeout(t *testing.T) {
+	engine := load(t, `on("ready", function() while true do end end)`)
+	_, err := engine.Fire(EventReady, Subject{Title: "loop"}, Actions{})
+	require.Error(t, err)
+}
@@ -0,0 +1,89 @@
+// Package secrets stores the API tokens claude-squad's integrations use, such as the Jira and Linear
+// tokens for ticket sync. They're kept out of config.json, whose settings are exported to share with a team,
+// in a file in the config directory that only the user can read.
+package secrets
+
+import (
+	"claude-squad/config"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileName is the name of the secrets file in the config directory.
+const FileName = "secrets.json"
+
+func getSecretsPath() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return filepath.Join(configDir, FileName), nil
+}
+
+func load() (map[string]string, error) {
+	path, err := getSecretsPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read secrets: %w", err)
+	}
+	secrets := map[string]string{}
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to parse secrets: %w", err)
+	}
+	return secrets, nil
+}
+
+// Get returns the secret called name.
+func Get(name string) (string, error) {
+	secrets, err := load()
+	if err != nil {
+		return "", err
+	}
+	value, ok := secrets[name]
+	if !ok {
+		return "", fmt.Errorf("secret %q is not set, set it with `claude-squad secrets set %s`", name, name)
+	}
+	return value, nil
+}
+
+// Set stores value as the secret called name, replacing any previous value. An empty value removes the
+// secret.
+func Set(name, value string) error {
+	secrets, err := load()
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		delete(secrets, name)
+	} else {
+		secrets[name] = value
+	}
+
+	path, err := getSecretsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	data, err := json.MarshalIndent(secrets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal secrets: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write secrets: %w", err)
+	}
+	// WriteFile keeps the mode of an existing file.
+	if err := os.Chmod(path, 0600); err != nil {
+		return fmt.Errorf("failed to restrict secrets: %w", err)
+	}
+	return nil
+}
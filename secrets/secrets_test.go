@@ -0,0 +1,30 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetGet(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	_, err := Get("jira")
+	require.ErrorContains(t, err, "claude-squad secrets set jira")
+
+	require.NoError(t, Set("jira", "token"))
+	value, err := Get("jira")
+	require.NoError(t, err)
+	require.Equal(t, "token", value)
+
+	info, err := os.Stat(filepath.Join(home, ".claude-squad", FileName))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	require.NoError(t, Set("jira", ""))
+	_, err = Get("jira")
+	require.Error(t, err)
+}
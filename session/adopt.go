@@ -0,0 +1,61 @@
+package session
+
+import (
+	"claude-squad/session/git"
+	"claude-squad/session/tmux"
+	"fmt"
+	"time"
+)
+
+// AdoptOptions are the options for adopting a tmux session claude-squad didn't create.
+type AdoptOptions struct {
+	// Title is the title of the instance.
+	Title string
+	// Session is the tmux session to adopt.
+	Session tmux.ExternalSession
+}
+
+// AdoptTmuxSession creates an instance for a tmux session started by hand, e.g. an agent someone ran in a
+// checkout of their own, so it's monitored and previewed like the others. The session is renamed after the
+// instance and its programs keep running. The instance works in the session's directory, which must be in a
+// git repository, rather than in a worktree of its own, so it can't be paused and killing it leaves the
+// directory and its branch alone.
+func AdoptTmuxSession(opts AdoptOptions) (*Instance, error) {
+	if opts.Title == "" {
+		return nil, fmt.Errorf("instance title cannot be empty")
+	}
+	gitWorktree, branch, err := git.NewExternalWorktree(opts.Session.Dir, opts.Title)
+	if err != nil {
+		return nil, fmt.Errorf("cannot adopt %s: %w", opts.Session.Name, err)
+	}
+
+	t := time.Now()
+	i := &Instance{
+		Title:          opts.Title,
+		Path:           gitWorktree.GetWorktreePath(),
+		Branch:         branch,
+		Status:         Ready,
+		Program:        opts.Session.Command,
+		CreatedAt:      t,
+		UpdatedAt:      t,
+		RepositoryPath: gitWorktree.GetRepoPath(),
+		gitWorktree:    gitWorktree,
+	}
+	i.tmuxSession = i.newTmuxSession()
+	i.configureCommits()
+	if err := i.loadPolicy(); err != nil {
+		return nil, err
+	}
+
+	if err := i.tmuxSession.Adopt(opts.Session.Name); err != nil {
+		return nil, err
+	}
+	if err := i.tmuxSession.Restore(); err != nil {
+		if releaseErr := i.tmuxSession.Release(opts.Session.Name); releaseErr != nil {
+			err = fmt.Errorf("%v (release error: %v)", err, releaseErr)
+		}
+		return nil, fmt.Errorf("failed to attach to %s: %w", opts.Session.Name, err)
+	}
+	i.started = true
+	return i, nil
+}
@@ -0,0 +1,138 @@
+package session
+
+import (
+	"claude-squad/config"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Budget limits how long an instance runs and how much its agent spends.
+type Budget struct {
+	// MaxRuntime is how long the instance may run after it's started or resumed. 0 is no limit.
+	MaxRuntime time.Duration `json:"max_runtime,omitempty"`
+	// MaxCost is how many dollars the instance's agent may spend, going by the costs it prints. 0 is no limit.
+	MaxCost float64 `json:"max_cost,omitempty"`
+}
+
+// BudgetFromConfig returns the budget cfg gives new instances.
+func BudgetFromConfig(cfg config.BudgetConfig) Budget {
+	return Budget{MaxRuntime: time.Duration(cfg.MaxRuntimeMinutes) * time.Minute, MaxCost: cfg.MaxCost}
+}
+
+// ParseBudget parses a budget like "2h", "$5" or "90m $2.50". The empty string is no budget.
+func ParseBudget(s string) (Budget, error) {
+	var b Budget
+	for _, field := range strings.Fields(s) {
+		if amount, ok := strings.CutPrefix(field, "$"); ok {
+			cost, err := strconv.ParseFloat(amount, 64)
+			if err != nil || cost <= 0 {
+				return Budget{}, fmt.Errorf("invalid cost %q, expected an amount like $5 or $2.50", field)
+			}
+			b.MaxCost = cost
+			continue
+		}
+		runtime, err := time.ParseDuration(field)
+		if err != nil || runtime <= 0 {
+			return Budget{}, fmt.Errorf("invalid budget %q, expected a time like 2h or 90m, or a cost like $5", field)
+		}
+		b.MaxRuntime = runtime
+	}
+	return b, nil
+}
+
+// IsZero returns true if there's no limit.
+func (b Budget) IsZero() bool {
+	return b.MaxRuntime == 0 && b.MaxCost == 0
+}
+
+// String returns the budget in the form ParseBudget parses.
+func (b Budget) String() string {
+	var fields []string
+	if b.MaxRuntime > 0 {
+		fields = append(fields, formatRuntime(b.MaxRuntime))
+	}
+	if b.MaxCost > 0 {
+		fields = append(fields, formatCost(b.MaxCost))
+	}
+	return strings.Join(fields, " ")
+}
+
+// costPatterns match the costs agents print: aider's "Cost: $0.01 message, $0.23 session." and Claude Code's
+// "Total cost: $0.23", from /cost and on exit. The first group is the total so far.
+var costPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\$([0-9]+(?:\.[0-9]+)?) session`),
+	regexp.MustCompile(`(?i)total cost:\s*\$([0-9]+(?:\.[0-9]+)?)`),
+}
+
+// parseCost returns the highest cost printed in content, and whether any was.
+func parseCost(content string) (float64, bool) {
+	var highest float64
+	found := false
+	for _, pattern := range costPatterns {
+		for _, match := range pattern.FindAllStringSubmatch(content, -1) {
+			if cost, err := strconv.ParseFloat(match[1], 64); err == nil {
+				highest = max(highest, cost)
+				found = true
+			}
+		}
+	}
+	return highest, found
+}
+
+// SetBudget sets the instance's budget. An instance over its previous budget is checked against the new one
+// from then on.
+func (i *Instance) SetBudget(b Budget) {
+	i.Budget = b
+	i.OverBudgetAt = time.Time{}
+	if i.Status == OverBudget {
+		i.SetStatus(Ready)
+	}
+}
+
+// CheckBudget interrupts the instance and marks it over budget if it ran or spent more than its budget
+// allows, and returns why. Nothing is done once the instance is over budget, until its budget is changed.
+// The error is that of interrupting the agent, which is marked over budget regardless.
+func (i *Instance) CheckBudget() (reason string, err error) {
+	if !i.started || i.Inactive() || i.dormant || i.Budget.IsZero() || !i.OverBudgetAt.IsZero() {
+		return "", nil
+	}
+	if i.Budget.MaxCost > 0 {
+		if content, err := i.tmuxSession.CapturePaneContent(); err == nil {
+			if cost, ok := parseCost(content); ok {
+				// The pane only shows the latest output, so the highest cost seen is kept.
+				i.Cost = max(i.Cost, cost)
+			}
+		}
+	}
+	switch uptime := i.Uptime(); {
+	case i.Budget.MaxRuntime > 0 && uptime >= i.Budget.MaxRuntime:
+		reason = fmt.Sprintf("ran for %s, over its budget of %s", formatRuntime(uptime.Round(time.Minute)),
+			formatRuntime(i.Budget.MaxRuntime))
+	case i.Budget.MaxCost > 0 && i.Cost >= i.Budget.MaxCost:
+		reason = fmt.Sprintf("spent %s, over its budget of %s", formatCost(i.Cost), formatCost(i.Budget.MaxCost))
+	default:
+		return "", nil
+	}
+	i.OverBudgetAt = time.Now()
+	i.SetStatus(OverBudget)
+	return reason, i.Interrupt()
+}
+
+// formatRuntime formats d without its zero minutes and seconds, e.g. "2h" rather than "2h0m0s".
+func formatRuntime(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+func formatCost(cost float64) string {
+	return "$" + strconv.FormatFloat(cost, 'f', 2, 64)
+}
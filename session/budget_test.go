@@ -0,0 +1,62 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBudget(t *testing.T) {
+	for _, tt := range []struct {
+		input string
+		want  Budget
+	}{
+		{"", Budget{}},
+		{"2h", Budget{MaxRuntime: 2 * time.Hour}},
+		{"$5", Budget{MaxCost: 5}},
+		{"90m $2.50", Budget{MaxRuntime: 90 * time.Minute, MaxCost: 2.5}},
+	} {
+		got, err := ParseBudget(tt.input)
+		require.NoError(t, err, tt.input)
+		require.Equal(t, tt.want, got, tt.input)
+	}
+	for _, input := range []string{"2 hours", "$", "$-1", "0m", "5"} {
+		_, err := ParseBudget(input)
+		require.Error(t, err, input)
+	}
+
+	require.Equal(t, "1h30m $2.50", Budget{MaxRuntime: 90 * time.Minute, MaxCost: 2.5}.String())
+	require.Equal(t, "2h", Budget{MaxRuntime: 2 * time.Hour}.String())
+	require.Equal(t, "45m", Budget{MaxRuntime: 45 * time.Minute}.String())
+	budget, err := ParseBudget(Budget{MaxRuntime: 150 * time.Minute, MaxCost: 10}.String())
+	require.NoError(t, err)
+	require.Equal(t, Budget{MaxRuntime: 150 * time.Minute, MaxCost: 10}, budget)
+}
+
+func TestParseCost(t *testing.T) {
+	_, ok := parseCost("no costs here, $5 isn't one")
+	require.False(t, ok)
+
+	cost, ok := parseCost("Tokens: 1.2k sent, 300 received. Cost: $0.01 message, $0.23 session.\n" +
+		"Tokens: 2.4k sent, 500 received. Cost: $0.02 message, $0.25 session.")
+	require.True(t, ok)
+	require.Equal(t, 0.25, cost)
+
+	cost, ok = parseCost("Total cost:            $1.73\nTotal duration (API):  2m 3.1s")
+	require.True(t, ok)
+	require.Equal(t, 1.73, cost)
+}
+
+func TestSetBudget(t *testing.T) {
+	instance := &Instance{Status: OverBudget, OverBudgetAt: time.Now()}
+	instance.SetBudget(Budget{MaxCost: 10})
+	require.Equal(t, Ready, instance.Status)
+	require.True(t, instance.OverBudgetAt.IsZero())
+
+	// An instance that isn't started is never over budget.
+	instance.SetBudget(Budget{MaxRuntime: time.Nanosecond})
+	reason, err := instance.CheckBudget()
+	require.NoError(t, err)
+	require.Empty(t, reason)
+}
@@ -0,0 +1,39 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+// captureWrittenOutput returns what the agent was asked to write to fileName in the worktree. The file
+// is removed once read so it doesn't end up in a commit. If the agent didn't write the file, the agent's
+// output is used instead.
+func (i *Instance) captureWrittenOutput(fileName string) (string, error) {
+	var content string
+	if i.gitWorktree != nil {
+		path := filepath.Join(i.gitWorktree.GetWorktreePath(), fileName)
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to read %s: %w", fileName, err)
+		}
+		if err == nil {
+			content = redactOutput(string(data))
+			if err := os.Remove(path); err != nil {
+				return "", fmt.Errorf("failed to remove %s: %w", fileName, err)
+			}
+		}
+	}
+	if strings.TrimSpace(content) == "" {
+		preview, err := i.Preview()
+		if err != nil {
+			return "", fmt.Errorf("failed to capture output: %w", err)
+		}
+		content = ansiRegex.ReplaceAllString(preview, "")
+	}
+	return strings.TrimSpace(content), nil
+}
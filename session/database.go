@@ -0,0 +1,106 @@
+package session
+
+import (
+	"claude-squad/config"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DatabaseState is the state of the instance's own copy of the repository's development database.
+type DatabaseState struct {
+	// Name is the database name passed to the hooks as CS_DATABASE.
+	Name string `json:"name,omitempty"`
+	// SnapshotAt is when the database was last snapshotted or restored.
+	SnapshotAt time.Time `json:"snapshot_at,omitempty"`
+	// Error is the error from the last hook, if it failed.
+	Error string `json:"error,omitempty"`
+}
+
+var nonDatabaseChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// databaseConfig returns the database hooks for the instance's repository.
+func (i *Instance) databaseConfig() config.DatabaseConfig {
+	return config.LoadConfig().GetRepoConfig(i.gitWorktree.GetRepoPath()).Database
+}
+
+// HasDatabase returns true if the instance's repository has database hooks configured.
+func (i *Instance) HasDatabase() bool {
+	return i.gitWorktree != nil && i.databaseConfig().Snapshot != ""
+}
+
+// snapshotDatabase creates the instance's database with the repository's snapshot hook.
+func (i *Instance) snapshotDatabase() error {
+	cfg := i.databaseConfig()
+	if cfg.Snapshot == "" {
+		return nil
+	}
+	// The worktree directory is unique per instance and keeps its name when the instance is renamed.
+	name := "claudesquad_" + nonDatabaseChars.ReplaceAllString(strings.ToLower(filepath.Base(i.gitWorktree.GetWorktreePath())), "_")
+	i.Database = DatabaseState{Name: name}
+	return i.recordDatabaseHook(i.runDatabaseHook("snapshot", cfg.Snapshot))
+}
+
+// ResetDatabase restores the instance's database to a fresh snapshot, discarding everything the agent
+// did to it.
+func (i *Instance) ResetDatabase() error {
+	cfg := i.databaseConfig()
+	if cfg.Snapshot == "" {
+		return fmt.Errorf("no database hooks are configured for this repository")
+	}
+	if i.Database.Name == "" {
+		return i.snapshotDatabase()
+	}
+	if cfg.Restore != "" {
+		return i.recordDatabaseHook(i.runDatabaseHook("restore", cfg.Restore))
+	}
+	if cfg.Drop != "" {
+		if err := i.runDatabaseHook("drop", cfg.Drop); err != nil {
+			return i.recordDatabaseHook(err)
+		}
+	}
+	return i.recordDatabaseHook(i.runDatabaseHook("snapshot", cfg.Snapshot))
+}
+
+// dropDatabase deletes the instance's database with the repository's drop hook.
+func (i *Instance) dropDatabase() error {
+	cfg := i.databaseConfig()
+	if cfg.Drop == "" || i.Database.Name == "" {
+		return nil
+	}
+	return i.runDatabaseHook("drop", cfg.Drop)
+}
+
+// recordDatabaseHook updates the database state with the result of a snapshot or restore.
+func (i *Instance) recordDatabaseHook(err error) error {
+	if err != nil {
+		i.Database.Error = err.Error()
+		return err
+	}
+	i.Database.Error = ""
+	i.Database.SnapshotAt = time.Now()
+	return nil
+}
+
+func (i *Instance) runDatabaseHook(name, command string) error {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = i.gitWorktree.GetWorktreePath()
+	if _, err := os.Stat(cmd.Dir); err != nil {
+		// Paused instances have no worktree.
+		cmd.Dir = i.gitWorktree.GetRepoPath()
+	}
+	cmd.Env = append(os.Environ(),
+		"CS_DATABASE="+i.Database.Name,
+		"CS_INSTANCE="+i.Title,
+		"CS_WORKTREE="+i.gitWorktree.GetWorktreePath(),
+		"CS_REPOSITORY="+i.gitWorktree.GetRepoPath(),
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("database %s hook failed: %s (%w)", name, strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
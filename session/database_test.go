@@ -0,0 +1,49 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"claude-squad/config"
+	"claude-squad/session/git"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseHooks(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	repo := t.TempDir()
+	worktree := filepath.Join(t.TempDir(), "my-task_18a2f")
+	require.NoError(t, os.Mkdir(worktree, 0755))
+	log := filepath.Join(repo, "hooks.log")
+
+	cfg := config.DefaultConfig()
+	cfg.Repos = map[string]config.RepoConfig{repo: {Database: config.DatabaseConfig{
+		Snapshot: `echo "snapshot $CS_DATABASE" >> ` + log,
+		Drop:     `echo "drop $CS_DATABASE" >> ` + log,
+	}}}
+	require.NoError(t, config.SaveConfig(cfg))
+
+	instance := &Instance{
+		Title:       "my task",
+		gitWorktree: git.NewGitWorktreeFromStorage(repo, worktree, "my task", "my-task", ""),
+	}
+	require.True(t, instance.HasDatabase())
+	require.NoError(t, instance.snapshotDatabase())
+	require.Equal(t, "claudesquad_my_task_18a2f", instance.Database.Name)
+	require.False(t, instance.Database.SnapshotAt.IsZero())
+
+	// Without a restore hook, resetting drops the database and snapshots it again.
+	require.NoError(t, instance.ResetDatabase())
+	require.NoError(t, instance.dropDatabase())
+	output, err := os.ReadFile(log)
+	require.NoError(t, err)
+	require.Equal(t, "snapshot claudesquad_my_task_18a2f\ndrop claudesquad_my_task_18a2f\n"+
+		"snapshot claudesquad_my_task_18a2f\ndrop claudesquad_my_task_18a2f\n", string(output))
+
+	cfg.Repos[repo] = config.RepoConfig{Database: config.DatabaseConfig{Snapshot: "true", Restore: "exit 3"}}
+	require.NoError(t, config.SaveConfig(cfg))
+	require.Error(t, instance.ResetDatabase())
+	require.Contains(t, instance.Database.Error, "database restore hook failed")
+}
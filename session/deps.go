@@ -0,0 +1,71 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/deps"
+	"claude-squad/log"
+	"claude-squad/network"
+	"claude-squad/session/git"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// CheckDependencies reports dependencies the instance added that use a disallowed license, and manifests
+// that gained more dependencies than allowed. Dependencies whose license can't be looked up (e.g. while
+// offline) are logged and skipped, and licenses aren't looked up at all in air-gapped mode. block is whether the findings must be overridden before pushing.
+func (i *Instance) CheckDependencies() (findings []git.Finding, block bool, err error) {
+	cfg := config.LoadConfig().Dependencies
+	if cfg.Disabled {
+		return nil, false, nil
+	}
+	if i.gitWorktree == nil {
+		return nil, false, fmt.Errorf("instance %s has no worktree", i.Title)
+	}
+	added, err := i.gitWorktree.AddedDependencies()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find added dependencies: %w", err)
+	}
+
+	client, err := network.NewClient(config.LoadConfig().Network, deps.RequestTimeout)
+	if err != nil {
+		return nil, false, err
+	}
+	resolver := deps.NewResolver(client)
+	disallowed := cfg.GetDisallowedLicenses()
+	unreachable := false
+	for _, manifest := range added {
+		if max := cfg.GetMaxNewDependencies(); len(manifest.Dependencies) > max {
+			findings = append(findings, git.Finding{
+				Kind:   git.FindingDependencyCount,
+				Path:   manifest.Manifest,
+				Detail: fmt.Sprintf("%d added, the limit is %d", len(manifest.Dependencies), max),
+			})
+		}
+		if len(disallowed) == 0 || network.AirGapped() {
+			continue
+		}
+		for _, dep := range manifest.Dependencies {
+			if unreachable {
+				break
+			}
+			licenses, err := resolver.Licenses(dep)
+			if err != nil {
+				log.WarningLog.Printf("failed to resolve license of %s: %v", dep, err)
+				// Don't wait on the lookup timeout for every other dependency.
+				var urlErr *url.Error
+				unreachable = errors.As(err, &urlErr)
+				continue
+			}
+			if license, ok := deps.Disallowed(licenses, disallowed); ok {
+				findings = append(findings, git.Finding{
+					Kind:   git.FindingLicense,
+					Path:   manifest.Manifest,
+					Detail: fmt.Sprintf("%s is licensed under %s (%s)", dep, strings.Join(licenses, ", "), license),
+				})
+			}
+		}
+	}
+	return findings, cfg.Block, nil
+}
@@ -0,0 +1,60 @@
+package session
+
+import (
+	"claude-squad/codeowners"
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session/git"
+	"sync"
+	"time"
+)
+
+var (
+	diffSettingsOnce sync.Once
+	sensitivePaths   []string
+	blameConfig      config.BlameConfig
+	diffStorage      config.DiffStorageConfig
+)
+
+func loadDiffSettings() {
+	diffSettingsOnce.Do(func() {
+		cfg := config.LoadConfig()
+		sensitivePaths = cfg.GetSensitivePaths()
+		blameConfig = cfg.Blame
+		diffStorage = cfg.DiffStorage
+	})
+}
+
+// flagSensitive records which of the files changed in stats match the configured sensitive paths.
+func flagSensitive(stats *git.DiffStats) {
+	loadDiffSettings()
+	stats.Sensitive = git.MatchPaths(stats.Files, sensitivePaths)
+}
+
+// annotateBlame adds the blame of removed lines and the resulting regression hints to stats, if enabled.
+// Blame is against the base commit, so it's reused from prev while the diff is unchanged.
+func (i *Instance) annotateBlame(stats, prev *git.DiffStats) {
+	loadDiffSettings()
+	if !blameConfig.Enabled {
+		return
+	}
+	if prev != nil && prev.Blame != nil && prev.Content == stats.Content {
+		stats.Blame = prev.Blame
+		stats.Hints = prev.Hints
+		return
+	}
+
+	blame, err := i.gitWorktree.Blame(stats.Content)
+	if err != nil {
+		log.WarningLog.Printf("failed to blame changes of %s: %v", i.Title, err)
+		// Don't retry until the diff changes.
+		stats.Blame = git.Blame{}
+		return
+	}
+	owners, err := codeowners.Load(i.gitWorktree.GetRepoPath())
+	if err != nil {
+		log.WarningLog.Printf("failed to read CODEOWNERS: %v", err)
+	}
+	stats.Blame = blame
+	stats.Hints = git.RegressionHints(blame, owners, blameConfig.GetRecent(), time.Now())
+}
@@ -0,0 +1,124 @@
+package session
+
+import (
+	"claude-squad/config"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressedDiffExt is the extension of diffs that are stored zstd-compressed.
+const compressedDiffExt = ".zst"
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+)
+
+func loadZstd() {
+	zstdOnce.Do(func() {
+		// Neither fails without options.
+		zstdEncoder, _ = zstd.NewWriter(nil)
+		zstdDecoder, _ = zstd.NewReader(nil)
+	})
+}
+
+func diffDir() (string, error) {
+	stateDir, err := config.GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, config.DiffsDirName), nil
+}
+
+// diffFileName returns the name of the file the diff of the instance titled title is stored in.
+func diffFileName(title string, compress bool) string {
+	name := fmt.Sprintf("%x.diff", sha256.Sum256([]byte(title)))
+	if compress {
+		name += compressedDiffExt
+	}
+	return name
+}
+
+// writeDiff stores content in the diff file name, compressing it if the name says so. Diffs are encrypted like
+// the state when the config asks for it, and only readable by the user either way.
+func writeDiff(name, content string) error {
+	dir, err := diffDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create diff directory: %w", err)
+	}
+	data := []byte(content)
+	if strings.HasSuffix(name, compressedDiffExt) {
+		loadZstd()
+		data = zstdEncoder.EncodeAll(data, nil)
+	}
+	if data, err = config.SealStateData(data); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	// Diffs written before weren't only readable by the user.
+	return os.Chmod(path, 0600)
+}
+
+// readDiff loads the diff stored in the diff file name.
+func readDiff(name string) (string, error) {
+	dir, err := diffDir()
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, filepath.Base(name)))
+	if err != nil {
+		return "", err
+	}
+	if data, err = config.OpenStateData(data); err != nil {
+		return "", fmt.Errorf("failed to decrypt diff: %w", err)
+	}
+	if strings.HasSuffix(name, compressedDiffExt) {
+		loadZstd()
+		if data, err = zstdDecoder.DecodeAll(data, nil); err != nil {
+			return "", fmt.Errorf("failed to decompress diff: %w", err)
+		}
+	}
+	return string(data), nil
+}
+
+// removeDiffs removes the stored diffs with the given file names. Names that aren't stored are skipped.
+func removeDiffs(names ...string) error {
+	dir, err := diffDir()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, filepath.Base(name))); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeAllDiffs removes every stored diff.
+func removeAllDiffs() error {
+	dir, err := diffDir()
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove diff directory: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package git
+
+import (
+	"claude-squad/config"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Branch is an existing branch an instance can be created from.
+type Branch struct {
+	// Name is the branch's name, e.g. feature, or origin/feature for a remote-tracking branch.
+	Name string
+	// Remote is true for remote-tracking branches.
+	Remote bool
+	// CommittedAt is the time of the branch's last commit.
+	CommittedAt time.Time
+	// Subject is the subject of the branch's last commit.
+	Subject string
+}
+
+// Branches returns the branches of the repository at repoPath that instances can be created from, most
+// recently committed to first: the local branches that aren't checked out anywhere, and the remote-tracking
+// branches without a local branch of the same name.
+func Branches(repoPath string) ([]Branch, error) {
+	output, err := exec.Command("git", "-C", repoPath, "for-each-ref", "--sort=-committerdate",
+		"--format=%(refname)%00%(committerdate:unix)%00%(worktreepath)%00%(contents:subject)",
+		"refs/heads", "refs/remotes").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the branches of %s: %w", repoPath, err)
+	}
+	return parseBranches(string(output)), nil
+}
+
+// parseBranches parses the output of the for-each-ref command run by Branches.
+func parseBranches(output string) []Branch {
+	type ref struct {
+		name, committedAt, worktree, subject string
+	}
+	var refs []ref
+	local := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.SplitN(line, "\x00", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		r := ref{name: fields[0], committedAt: fields[1], worktree: fields[2], subject: fields[3]}
+		if name, ok := strings.CutPrefix(r.name, "refs/heads/"); ok {
+			local[name] = true
+		}
+		refs = append(refs, r)
+	}
+
+	var branches []Branch
+	for _, r := range refs {
+		committedAt, _, err := parseUnixTime(r.committedAt)
+		if err != nil {
+			continue
+		}
+		if name, ok := strings.CutPrefix(r.name, "refs/heads/"); ok {
+			if r.worktree == "" {
+				branches = append(branches, Branch{Name: name, CommittedAt: committedAt, Subject: r.subject})
+			}
+			continue
+		}
+		name := strings.TrimPrefix(r.name, "refs/remotes/")
+		_, localName, _ := strings.Cut(name, "/")
+		if localName == "HEAD" || local[localName] {
+			continue
+		}
+		branches = append(branches, Branch{Name: name, Remote: true, CommittedAt: committedAt, Subject: r.subject})
+	}
+	return branches
+}
+
+// NewGitWorktreeFromBranch creates a GitWorktree that checks out the existing branch rather than creating one,
+// e.g. a branch someone is working on or one kept from an archived instance. A remote-tracking branch such as
+// origin/feature is checked out as the local branch feature, tracking it. The branch is adopted: it keeps its
+// name when the session is renamed and it isn't deleted when the worktree is cleaned up. settings are as for
+// NewGitWorktree.
+func NewGitWorktreeFromBranch(repoPath string, sessionName string, branch string, settings *config.RepoSettings) (*GitWorktree, string, error) {
+	tree, _, err := NewGitWorktree(repoPath, sessionName, settings)
+	if err != nil {
+		return nil, "", err
+	}
+
+	localName := branch
+	if _, err := tree.runGitCommand(tree.repoPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err != nil {
+		if _, err := tree.runGitCommand(tree.repoPath, "rev-parse", "--verify", "--quiet", "refs/remotes/"+branch); err != nil {
+			return nil, "", fmt.Errorf("there's no branch %s in %s", branch, tree.repoPath)
+		}
+		_, localName, _ = strings.Cut(branch, "/")
+		tree.upstream = branch
+	}
+
+	// The changes shown are the branch's own, since it forked from the checked out branch.
+	base, err := tree.runGitCommand(tree.repoPath, "merge-base", "HEAD", branch)
+	if err != nil {
+		if base, err = tree.runGitCommand(tree.repoPath, "rev-parse", branch); err != nil {
+			return nil, "", fmt.Errorf("failed to resolve branch %s: %w", branch, err)
+		}
+	}
+	tree.baseCommitSHA = strings.TrimSpace(base)
+	tree.branchName = localName
+	tree.adopted = true
+	return tree, localName, nil
+}
+
+// NewExternalWorktree creates a GitWorktree for dir, a directory claude-squad didn't create, e.g. the one an
+// adopted tmux session works in. Its branch is the one checked out there, and the changes shown are those
+// made since it was adopted. Neither the directory nor the branch is removed when the worktree is cleaned up.
+func NewExternalWorktree(dir string, sessionName string) (*GitWorktree, string, error) {
+	absPath, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	repoPath, err := findGitRepoRoot(absPath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	tree := &GitWorktree{
+		repoPath:     repoPath,
+		worktreePath: absPath,
+		sessionName:  sessionName,
+		adopted:      true,
+		external:     true,
+	}
+	branch, err := tree.runGitCommand(absPath, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get the branch checked out in %s: %w", absPath, err)
+	}
+	base, err := tree.runGitCommand(absPath, "rev-parse", "HEAD")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get the commit checked out in %s: %w", absPath, err)
+	}
+	tree.branchName = strings.TrimSpace(branch)
+	tree.baseCommitSHA = strings.TrimSpace(base)
+	return tree, tree.branchName, nil
+}
+
+// SetExternal sets whether the worktree is a directory claude-squad didn't create, in which case it's kept
+// when the worktree is cleaned up.
+func (g *GitWorktree) SetExternal(external bool) {
+	g.external = external
+}
+
+// IsExternal returns whether the worktree is a directory claude-squad didn't create.
+func (g *GitWorktree) IsExternal() bool {
+	return g.external
+}
+
+// SetAdopted sets whether the worktree's branch existed before the session, in which case it's kept when the
+// worktree is cleaned up.
+func (g *GitWorktree) SetAdopted(adopted bool) {
+	g.adopted = adopted
+}
+
+// IsAdopted returns whether the worktree's branch existed before the session.
+func (g *GitWorktree) IsAdopted() bool {
+	return g.adopted
+}
+
+// setupAdopted creates the worktree of an adopted branch, creating the local branch first if a remote-tracking
+// branch was adopted.
+func (g *GitWorktree) setupAdopted() error {
+	if g.upstream == "" {
+		return g.SetupFromExistingBranch()
+	}
+	if _, err := g.runGitCommand(g.repoPath, "worktree", "add", "--track", "-b", g.branchName, g.worktreePath, g.upstream); err != nil {
+		return fmt.Errorf("failed to create worktree from branch %s: %w", g.upstream, err)
+	}
+	g.upstream = ""
+	return nil
+}
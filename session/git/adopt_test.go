@@ -0,0 +1,80 @@
+package git
+
+import (
+	"claude-squad/log"
+	"claude-squad/session/git/gittest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdoptBranch(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	origin := gittest.New(t)
+	origin.Commit("base", "base")
+	origin.Run("checkout", "-q", "-b", "wip")
+	origin.Commit("wip", "wip")
+	origin.Run("checkout", "-q", "main")
+
+	clone := gittest.Open(t, filepath.Join(t.TempDir(), "repo"))
+	origin.Run("clone", "-q", origin.Dir, clone.Dir)
+	clone.Run("checkout", "-q", "-b", "feature")
+	clone.Commit("feature", "feature")
+	clone.Run("checkout", "-q", "main")
+	repo := clone.Dir
+
+	// The checked out branch and the remote-tracking branches with a local branch aren't listed.
+	branches, err := Branches(repo)
+	require.NoError(t, err)
+	var names []string
+	for _, branch := range branches {
+		names = append(names, branch.Name)
+	}
+	require.ElementsMatch(t, []string{"feature", "origin/wip"}, names)
+
+	tree, branch, err := NewGitWorktreeFromBranch(repo, "wip session", "origin/wip", nil)
+	require.NoError(t, err)
+	require.Equal(t, "wip", branch)
+	require.NoError(t, tree.Setup())
+	require.FileExists(t, filepath.Join(tree.GetWorktreePath(), "wip"))
+	require.Equal(t, "origin/wip\n", clone.Run("rev-parse", "--abbrev-ref", "wip@{upstream}"))
+
+	// The adopted branch keeps its name and outlives the worktree.
+	renamed, err := tree.Rename("other")
+	require.NoError(t, err)
+	require.Equal(t, "wip", renamed)
+	require.NoError(t, tree.Cleanup())
+	require.NoDirExists(t, tree.GetWorktreePath())
+	clone.Run("rev-parse", "--verify", "refs/heads/wip")
+
+	_, _, err = NewGitWorktreeFromBranch(repo, "missing", "nope", nil)
+	require.ErrorContains(t, err, "no branch nope")
+}
+
+func TestExternalWorktree(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	r := gittest.New(t)
+	r.Run("commit", "--allow-empty", "-m", "base")
+	repo := r.Dir
+
+	tree, branch, err := NewExternalWorktree(repo, "manual")
+	require.NoError(t, err)
+	require.Equal(t, "main", branch)
+	require.True(t, tree.IsExternal())
+
+	// Neither the directory nor its branch is claude-squad's to remove.
+	require.Error(t, tree.Remove())
+	require.NoError(t, tree.Cleanup())
+	require.DirExists(t, repo)
+	require.Contains(t, r.Run("branch", "--list", "main"), "main")
+
+	_, _, err = NewExternalWorktree(t.TempDir(), "elsewhere")
+	require.Error(t, err)
+}
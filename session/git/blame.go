@@ -0,0 +1,170 @@
+package git
+
+import (
+	"claude-squad/codeowners"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BlameLine is who last changed a line before the instance's changes, and when.
+type BlameLine struct {
+	SHA    string
+	Author string
+	Time   time.Time
+}
+
+// Blame maps a file's path to the blame of its removed or modified lines, keyed by their line number in
+// the base commit.
+type Blame map[string]map[int]BlameLine
+
+// Blame returns the blame of the lines diff removes or modifies, as of the worktree's base commit.
+func (g *GitWorktree) Blame(diff string) (Blame, error) {
+	removed := make(map[string][]int)
+	walkRemoved(diff, func(file string, line int) {
+		removed[file] = append(removed[file], line)
+	})
+
+	blame := make(Blame)
+	for file, lines := range removed {
+		args := []string{"--no-pager", "blame", "--porcelain"}
+		for _, r := range lineRanges(lines) {
+			args = append(args, "-L", fmt.Sprintf("%d,%d", r[0], r[1]))
+		}
+		args = append(args, g.baseCommitSHA, "--", file)
+		output, err := g.runGitCommand(g.repoPath, args...)
+		if err != nil {
+			return nil, err
+		}
+		blame[file] = parseBlamePorcelain(output)
+	}
+	return blame, nil
+}
+
+// HunkOldStart returns the line in the old version of the file that a hunk header ("@@ -a,b +c,d @@")
+// starts at.
+func HunkOldStart(header string) (int, bool) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || fields[0] != "@@" || !strings.HasPrefix(fields[1], "-") {
+		return 0, false
+	}
+	start, _, _ := strings.Cut(strings.TrimPrefix(fields[1], "-"), ",")
+	line, err := strconv.Atoi(start)
+	return line, err == nil
+}
+
+// walkRemoved calls fn with the file and old line number of every line diff removes. Added files have
+// nothing to blame and are skipped.
+func walkRemoved(diff string, fn func(file string, line int)) {
+	var file string
+	line := 0
+	inHunk := false
+	for _, text := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(text, "diff --git "):
+			file = ""
+			inHunk = false
+		case !inHunk && strings.HasPrefix(text, "--- "):
+			file = strings.TrimPrefix(text, "--- a/")
+			if text == "--- /dev/null" {
+				file = ""
+			}
+		case strings.HasPrefix(text, "@@"):
+			line, inHunk = HunkOldStart(text)
+		case !inHunk || file == "":
+			continue
+		case strings.HasPrefix(text, "-"):
+			fn(file, line)
+			line++
+		case strings.HasPrefix(text, " "):
+			line++
+		}
+	}
+}
+
+// lineRanges collapses sorted line numbers into inclusive ranges.
+func lineRanges(lines []int) [][2]int {
+	var ranges [][2]int
+	for _, line := range lines {
+		if n := len(ranges); n > 0 && ranges[n-1][1]+1 == line {
+			ranges[n-1][1] = line
+			continue
+		}
+		ranges = append(ranges, [2]int{line, line})
+	}
+	return ranges
+}
+
+// parseBlamePorcelain parses the output of git blame --porcelain into the blame of each final line.
+func parseBlamePorcelain(output string) map[int]BlameLine {
+	commits := make(map[string]*BlameLine)
+	lines := make(map[int]*BlameLine)
+	var current *BlameLine
+	for _, text := range strings.Split(output, "\n") {
+		fields := strings.Fields(text)
+		switch {
+		case strings.HasPrefix(text, "\t"):
+			// The line's content ends its entry.
+			current = nil
+		case current == nil && len(fields) >= 3 && (len(fields[0]) == 40 || len(fields[0]) == 64):
+			commit, ok := commits[fields[0]]
+			if !ok {
+				commit = &BlameLine{SHA: fields[0][:7]}
+				commits[fields[0]] = commit
+			}
+			if final, err := strconv.Atoi(fields[2]); err == nil {
+				lines[final] = commit
+			}
+			current = commit
+		case current != nil && strings.HasPrefix(text, "author "):
+			current.Author = strings.TrimPrefix(text, "author ")
+		case current != nil && strings.HasPrefix(text, "author-time "):
+			if seconds, err := strconv.ParseInt(strings.TrimPrefix(text, "author-time "), 10, 64); err == nil {
+				current.Time = time.Unix(seconds, 0)
+			}
+		}
+	}
+	// Commit details are only printed the first time a commit appears, so resolve lines at the end.
+	blame := make(map[int]BlameLine, len(lines))
+	for line, commit := range lines {
+		blame[line] = *commit
+	}
+	return blame
+}
+
+// RegressionHints warns about files in which the diff rewrites lines changed more recently than recent
+// ago, or lines owned by someone in CODEOWNERS.
+func RegressionHints(blame Blame, owners *codeowners.Owners, recent time.Duration, now time.Time) []string {
+	files := make([]string, 0, len(blame))
+	for file := range blame {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var hints []string
+	for _, file := range files {
+		young := 0
+		authors := make(map[string]bool)
+		for _, line := range blame[file] {
+			if recent > 0 && now.Sub(line.Time) < recent {
+				young++
+				authors[line.Author] = true
+			}
+		}
+		if young > 0 {
+			names := make([]string, 0, len(authors))
+			for author := range authors {
+				names = append(names, author)
+			}
+			sort.Strings(names)
+			hints = append(hints, fmt.Sprintf("%s: rewrites %d lines changed in the last %d days by %s",
+				file, young, int(recent.Hours()/24), strings.Join(names, ", ")))
+		}
+		if fileOwners := owners.Of(file); len(fileOwners) > 0 {
+			hints = append(hints, fmt.Sprintf("%s: rewrites code owned by %s", file, strings.Join(fileOwners, " ")))
+		}
+	}
+	return hints
+}
@@ -0,0 +1,51 @@
+package git
+
+import (
+	"claude-squad/codeowners"
+	"claude-squad/session/git/gittest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlame(t *testing.T) {
+	r := gittest.New(t)
+	repo := r.Dir
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "main.go"), []byte("one\ntwo\nthree\nfour\n"), 0644))
+	r.Run("add", ".")
+	r.RunAt("2024-01-02T03:04:05Z", "commit", "-m", "initial")
+	base := r.Head()
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "main.go"), []byte("one\n2\nthree\n"), 0644))
+	diff := r.Run("--no-pager", "diff", base)
+
+	worktree := &GitWorktree{repoPath: repo, worktreePath: repo, baseCommitSHA: base}
+	blame, err := worktree.Blame(diff)
+	require.NoError(t, err)
+	require.Len(t, blame["main.go"], 2)
+	for _, line := range []int{2, 4} {
+		require.Equal(t, "Alice", blame["main.go"][line].Author)
+		require.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), blame["main.go"][line].Time.UTC())
+	}
+}
+
+func TestRegressionHints(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	blame := Blame{
+		"api/server.go": {
+			10: {Author: "Bob", Time: now.Add(-48 * time.Hour)},
+			11: {Author: "Alice", Time: now.Add(-24 * time.Hour)},
+			12: {Author: "Carol", Time: now.Add(-90 * 24 * time.Hour)},
+		},
+		"README.md": {1: {Author: "Carol", Time: now.Add(-90 * 24 * time.Hour)}},
+	}
+	owners := codeowners.Parse("/api/ @org/api\n")
+
+	require.Equal(t, []string{
+		"api/server.go: rewrites 2 lines changed in the last 14 days by Alice, Bob",
+		"api/server.go: rewrites code owned by @org/api",
+	}, RegressionHints(blame, owners, 14*24*time.Hour, now))
+}
@@ -0,0 +1,103 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SquadBranch is a local branch claude-squad created.
+type SquadBranch struct {
+	Repository string
+	Name       string
+	// Base is the repository's default branch, which Ahead and Behind compare the branch to. It's empty if it
+	// couldn't be determined.
+	Base string
+	// Ahead is the number of the branch's commits that aren't on Base: the work that's lost if it's deleted.
+	Ahead int
+	// Behind is the number of Base's commits that aren't on the branch.
+	Behind int
+	// CommittedAt is the time of the branch's last commit.
+	CommittedAt time.Time
+}
+
+// SquadBranches returns the local branches of the repository at repoPath that claude-squad created: those
+// named with one of prefixes, and those whose last commit carries one of trailerKeys, like the Session-ID trailer
+// instances add to their commits. Branches checked out in the repository or one of its worktrees, and the
+// default branch, are left out.
+func SquadBranches(repoPath string, prefixes, trailerKeys []string) ([]SquadBranch, error) {
+	output, err := exec.Command("git", "-C", repoPath, "for-each-ref",
+		"--format=%(refname:short)%00%(committerdate:unix)%00%(worktreepath)%00%(contents:trailers)%1e",
+		"refs/heads").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the branches of %s: %w", repoPath, err)
+	}
+	g := &GitWorktree{repoPath: repoPath}
+	base, _ := g.defaultBranch()
+
+	var branches []SquadBranch
+	for _, record := range strings.Split(string(output), "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x00", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		name, worktree, trailers := fields[0], fields[2], fields[3]
+		if worktree != "" || name == base {
+			continue
+		}
+		if !hasPrefix(name, prefixes) && !hasTrailer(trailers, trailerKeys) {
+			continue
+		}
+		branch := SquadBranch{Repository: repoPath, Name: name, Base: base}
+		branch.CommittedAt, _, _ = parseUnixTime(fields[1])
+		if base != "" {
+			counts, err := g.runGitCommand(repoPath, "rev-list", "--left-right", "--count", name+"..."+base)
+			if err != nil {
+				return nil, err
+			}
+			if parts := strings.Fields(counts); len(parts) == 2 {
+				branch.Ahead, _ = strconv.Atoi(parts[0])
+				branch.Behind, _ = strconv.Atoi(parts[1])
+			}
+		}
+		branches = append(branches, branch)
+	}
+	return branches, nil
+}
+
+// hasPrefix returns true if name starts with one of prefixes.
+func hasPrefix(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTrailer returns true if the commit trailers include one of keys.
+func hasTrailer(trailers string, keys []string) bool {
+	for _, line := range strings.Split(trailers, "\n") {
+		key, _, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		for _, k := range keys {
+			if strings.EqualFold(strings.TrimSpace(key), k) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DeleteBranch deletes the local branch of the repository at repoPath, even if it isn't merged.
+func DeleteBranch(repoPath, branch string) error {
+	g := &GitWorktree{repoPath: repoPath}
+	if _, err := g.runGitCommand(repoPath, "branch", "-D", branch); err != nil {
+		return fmt.Errorf("failed to delete branch %s of %s: %w", branch, repoPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package git
+
+import (
+	"claude-squad/session/git/gittest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSquadBranches(t *testing.T) {
+	r := gittest.New(t)
+	repo := r.Dir
+	r.Commit("base", "base")
+	r.Run("branch", "alice/merged")
+	r.Run("checkout", "-q", "-b", "alice/work")
+	r.Commit("work", "work")
+	r.Run("checkout", "-q", "-b", "renamed")
+	r.Commit("renamed", "renamed\n\nSession-ID: claudesquad_renamed")
+	r.Run("checkout", "-q", "-b", "alice/current")
+	r.Run("checkout", "-q", "-b", "unrelated", "main")
+	r.Commit("unrelated", "unrelated")
+	r.Run("checkout", "-q", "alice/current")
+
+	branches, err := SquadBranches(repo, []string{"alice/"}, []string{"Session-ID"})
+	require.NoError(t, err)
+	ahead := make(map[string]int)
+	for _, branch := range branches {
+		require.Equal(t, "main", branch.Base)
+		require.False(t, branch.CommittedAt.IsZero())
+		ahead[branch.Name] = branch.Ahead
+	}
+	// The checked out branch and the branches claude-squad didn't create are left out.
+	require.Equal(t, map[string]int{"alice/merged": 0, "alice/work": 1, "renamed": 2}, ahead)
+
+	require.NoError(t, DeleteBranch(repo, "alice/work"))
+	require.False(t, HasBranch(repo, "alice/work"))
+	require.Error(t, DeleteBranch(repo, "alice/current"))
+}
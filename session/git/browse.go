@@ -0,0 +1,73 @@
+package git
+
+import (
+	"fmt"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// branchWebURL returns the web page of branch on the host of the remote at remoteURL, or false if the host
+// isn't one whose pages are known.
+func branchWebURL(remoteURL, branch string) (string, bool) {
+	host, path, ok := parseRemoteURL(remoteURL)
+	if !ok {
+		return "", false
+	}
+	base := "https://" + host + "/" + path
+	name := strings.ToLower(host)
+	switch {
+	case strings.Contains(name, "gitlab"):
+		return base + "/-/tree/" + branch, true
+	case strings.Contains(name, "bitbucket"):
+		return base + "/branch/" + branch, true
+	case strings.Contains(name, "codeberg") || strings.Contains(name, "gitea"):
+		return base + "/src/branch/" + branch, true
+	case strings.Contains(name, "github"):
+		return base + "/tree/" + branch, true
+	}
+	return "", false
+}
+
+// parseRemoteURL returns the host and repository path of a git remote URL, either a URL like
+// https://host/owner/repo.git or ssh://git@host/owner/repo.git, or the scp-like git@host:owner/repo.git.
+func parseRemoteURL(remoteURL string) (host, path string, ok bool) {
+	remoteURL = strings.TrimSpace(remoteURL)
+	if u, err := url.Parse(remoteURL); err == nil && u.Scheme != "" && u.Host != "" {
+		host = u.Host
+		if u.Scheme != "https" && u.Scheme != "http" {
+			// The port of an SSH remote isn't the web server's.
+			host = u.Hostname()
+		}
+		path = u.Path
+	} else if at, rest, found := strings.Cut(remoteURL, ":"); found && len(at) > 1 && !strings.Contains(at, "/") {
+		_, host, _ = strings.Cut(at, "@")
+		if host == "" {
+			host = at
+		}
+		path = rest
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || path == "" {
+		return "", "", false
+	}
+	return host, path, true
+}
+
+// openBrowser opens url in the default browser.
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to open %s: %w", url, err)
+	}
+	return nil
+}
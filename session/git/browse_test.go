@@ -0,0 +1,29 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBranchWebURL(t *testing.T) {
+	tests := []struct {
+		remote   string
+		expected string
+	}{
+		{"https://github.com/acme/app.git", "https://github.com/acme/app/tree/fix"},
+		{"git@github.com:acme/app.git", "https://github.com/acme/app/tree/fix"},
+		{"ssh://git@gitlab.example.com:2222/group/sub/app.git", "https://gitlab.example.com/group/sub/app/-/tree/fix"},
+		{"https://deploy@bitbucket.org/acme/app", "https://bitbucket.org/acme/app/branch/fix"},
+		{"git@codeberg.org:acme/app.git", "https://codeberg.org/acme/app/src/branch/fix"},
+		{"https://git.example.com:8443/acme/app.git", ""},
+		{"/srv/git/app.git", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.remote, func(t *testing.T) {
+			url, ok := branchWebURL(tt.remote, "fix")
+			require.Equal(t, tt.expected != "", ok)
+			require.Equal(t, tt.expected, url)
+		})
+	}
+}
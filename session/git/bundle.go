@@ -0,0 +1,26 @@
+package git
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// CreateBundle writes branch of the repository at repoPath, with its full history, to a bundle file.
+func CreateBundle(repoPath, branch, bundlePath string) error {
+	cmd := exec.Command("git", "-C", repoPath, "bundle", "create", bundlePath, branch)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to bundle branch %s: %s (%w)", branch, output, err)
+	}
+	return nil
+}
+
+// FetchBundle creates branch in the repository at repoPath from a bundle created by CreateBundle. An
+// existing branch is only updated if that is a fast-forward.
+func FetchBundle(repoPath, bundlePath, branch string) error {
+	refspec := fmt.Sprintf("refs/heads/%s:refs/heads/%s", branch, branch)
+	cmd := exec.Command("git", "-C", repoPath, "fetch", bundlePath, refspec)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to restore branch %s: %s (%w)", branch, output, err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FileChange is the number of lines changed in a single file.
+type FileChange struct {
+	Path    string
+	Added   int
+	Removed int
+}
+
+// Comparison is the difference between the contents of two worktrees.
+type Comparison struct {
+	// Files lists every file that differs, in git's order.
+	Files []FileChange
+	// Added is the total number of added lines.
+	Added int
+	// Removed is the total number of removed lines.
+	Removed int
+	// Content is the full diff.
+	Content string
+}
+
+// Compare diffs the contents of worktree a against worktree b. Both worktrees must belong to the same
+// repository. Uncommitted and untracked files are included; paused worktrees are compared by their branch.
+func Compare(a, b *GitWorktree) (*Comparison, error) {
+	if a.repoPath != b.repoPath {
+		return nil, fmt.Errorf("cannot compare instances from different repositories")
+	}
+
+	treeA, err := a.snapshotTree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot %s: %w", a.sessionName, err)
+	}
+	treeB, err := b.snapshotTree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot %s: %w", b.sessionName, err)
+	}
+
+	numstat, err := a.runGitCommand(a.repoPath, "--no-pager", "diff", "--numstat", treeA, treeB)
+	if err != nil {
+		return nil, err
+	}
+	content, err := a.runGitCommand(a.repoPath, "--no-pager", "diff", treeA, treeB)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &Comparison{Files: parseNumstat(numstat), Content: content}
+	for _, file := range comparison.Files {
+		comparison.Added += file.Added
+		comparison.Removed += file.Removed
+	}
+	return comparison, nil
+}
+
+// snapshotTree writes the worktree's current contents to a tree object and returns its hash. A temporary
+// index is used so the worktree's own index is left untouched. If the worktree doesn't exist (e.g. the
+// instance is paused), the branch name is returned instead.
+func (g *GitWorktree) snapshotTree() (string, error) {
+	if _, err := os.Stat(g.worktreePath); os.IsNotExist(err) {
+		return g.branchName, nil
+	}
+
+	indexDir, err := os.MkdirTemp("", "claudesquad-index-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary index: %w", err)
+	}
+	defer os.RemoveAll(indexDir)
+	env := append(os.Environ(), "GIT_INDEX_FILE="+filepath.Join(indexDir, "index"))
+
+	for _, args := range [][]string{{"add", "-A"}, {"write-tree"}} {
+		cmd := exec.Command("git", append([]string{"-C", g.worktreePath}, args...)...)
+		cmd.Env = env
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return "", fmt.Errorf("git command failed: %s (%w)", output, err)
+		}
+		if args[0] == "write-tree" {
+			return strings.TrimSpace(string(output)), nil
+		}
+	}
+	return "", nil
+}
+
+// parseNumstat parses the output of git diff --numstat. Binary files are reported with zero line counts.
+func parseNumstat(output string) []FileChange {
+	var files []FileChange
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		added, _ := strconv.Atoi(fields[0])
+		removed, _ := strconv.Atoi(fields[1])
+		files = append(files, FileChange{Path: fields[2], Added: added, Removed: removed})
+	}
+	return files
+}
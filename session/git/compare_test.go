@@ -0,0 +1,19 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNumstat(t *testing.T) {
+	output := "10\t2\tmain.go\n-\t-\tassets/logo.png\n0\t7\tdocs/old name.md\n"
+
+	require.Equal(t, []FileChange{
+		{Path: "main.go", Added: 10, Removed: 2},
+		{Path: "assets/logo.png"},
+		{Path: "docs/old name.md", Removed: 7},
+	}, parseNumstat(output))
+
+	require.Empty(t, parseNumstat(""))
+}
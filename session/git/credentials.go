@@ -0,0 +1,115 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// credentialPromptMessages are fragments of the messages git and ssh print when they needed to prompt for
+// credentials, a passphrase or to confirm a host key, but prompting was disabled.
+var credentialPromptMessages = []string{
+	"terminal prompts disabled",
+	"could not read username",
+	"could not read password",
+	"unable to read askpass response",
+	"read_passphrase",
+	"permission denied (publickey",
+	"host key verification failed",
+}
+
+// CredentialsRequiredError is returned when a git command needs credentials it would have had to prompt
+// for. The command isn't allowed to prompt in the background, where nothing would answer it and it would
+// hang; run Command in the terminal to let the user enter them instead.
+type CredentialsRequiredError struct {
+	// Output is the combined output of the command that failed.
+	Output string
+	args   []string
+	dir    string
+	env    []string
+	// resume runs the rest of the operation after the command.
+	resume func() error
+}
+
+func (e *CredentialsRequiredError) Error() string {
+	return "git needs credentials: " + e.Prompt()
+}
+
+// Prompt returns the line of the output saying what the command needed. That's the last such line, since git
+// ends with the fatal error after reporting why it couldn't prompt.
+func (e *CredentialsRequiredError) Prompt() string {
+	lines := strings.Split(strings.TrimSpace(e.Output), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if needsCredentials(lines[i]) {
+			return strings.TrimSpace(lines[i])
+		}
+	}
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// Command returns the command that failed, allowed to prompt, to run with the terminal's input and output.
+func (e *CredentialsRequiredError) Command() *exec.Cmd {
+	cmd := exec.Command("git", e.args...)
+	cmd.Dir = e.dir
+	cmd.Env = append(os.Environ(), e.env...)
+	return cmd
+}
+
+// Resume runs the steps of the operation that were left after the command failed. Call it once Command
+// succeeded.
+func (e *CredentialsRequiredError) Resume() error {
+	if e.resume == nil {
+		return nil
+	}
+	return e.resume()
+}
+
+// resumeWith sets the steps to resume if err says credentials are required, and returns err.
+func resumeWith(err error, resume func() error) error {
+	var credErr *CredentialsRequiredError
+	if errors.As(err, &credErr) {
+		credErr.resume = resume
+	}
+	return err
+}
+
+// RunWithoutPrompt runs git with args in dir, not allowed to prompt on the terminal. It returns the
+// combined output, or a *CredentialsRequiredError if git needed credentials.
+func RunWithoutPrompt(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), noPromptEnv()...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if needsCredentials(string(output)) {
+			return "", &CredentialsRequiredError{Output: string(output), args: args, dir: dir}
+		}
+		return "", fmt.Errorf("git %s failed: %s (%w)", args[0], strings.TrimSpace(string(output)), err)
+	}
+	return string(output), nil
+}
+
+// needsCredentials returns true if output says git or ssh couldn't prompt for what they needed.
+func needsCredentials(output string) bool {
+	output = strings.ToLower(output)
+	for _, fragment := range credentialPromptMessages {
+		if strings.Contains(output, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// noPromptEnv returns the environment that stops git, and ssh run by git, from prompting on the terminal,
+// which the TUI owns. Credential helpers and ssh agents still supply credentials; commands that would
+// otherwise prompt fail instead. An askpass program the user set up, e.g. a graphical one, is left alone.
+func noPromptEnv() []string {
+	env := []string{"GIT_TERMINAL_PROMPT=0"}
+	if os.Getenv("SSH_ASKPASS") == "" {
+		// Since OpenSSH 8.4, ssh asks the askpass program rather than the terminal when it's forced to.
+		env = append(env, "SSH_ASKPASS=false", "SSH_ASKPASS_REQUIRE=force")
+	}
+	return env
+}
@@ -0,0 +1,43 @@
+package git
+
+import (
+	"claude-squad/deps"
+	"fmt"
+	"strings"
+)
+
+// AddedDependencies is the dependencies added to one manifest file.
+type AddedDependencies struct {
+	Manifest     string
+	Dependencies []deps.Dependency
+}
+
+// AddedDependencies returns the dependencies the worktree added to its manifests (go.mod, package.json,
+// requirements.txt) since its base commit, including uncommitted and untracked changes.
+func (g *GitWorktree) AddedDependencies() ([]AddedDependencies, error) {
+	tree, err := g.snapshotTree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot worktree: %w", err)
+	}
+	changed, err := g.runGitCommand(g.repoPath, "--no-pager", "diff", "--name-only", "--diff-filter=AM", g.baseCommitSHA, tree)
+	if err != nil {
+		return nil, err
+	}
+
+	var added []AddedDependencies
+	for _, file := range strings.Split(changed, "\n") {
+		if file == "" || !deps.IsManifest(file) {
+			continue
+		}
+		// The manifest may be new, in which case it has no old content.
+		oldContent, _ := g.runGitCommand(g.repoPath, "show", g.baseCommitSHA+":"+file)
+		newContent, err := g.runGitCommand(g.repoPath, "show", tree+":"+file)
+		if err != nil {
+			return nil, err
+		}
+		if dependencies := deps.Added(file, oldContent, newContent); len(dependencies) > 0 {
+			added = append(added, AddedDependencies{Manifest: file, Dependencies: dependencies})
+		}
+	}
+	return added, nil
+}
@@ -1,7 +1,9 @@
 package git
 
 import (
+	"claude-squad/log"
 	"strings"
+	"time"
 )
 
 // DiffStats holds statistics about the changes in a diff
@@ -12,6 +14,16 @@ type DiffStats struct {
 	Added int
 	// Removed is the number of removed lines
 	Removed int
+	// Commits are the commits made on the branch since the base commit
+	Commits []CommitInfo
+	// Files is the number of lines changed per file
+	Files []FileChange
+	// Sensitive lists the changed files that match the configured sensitive paths
+	Sensitive []string
+	// Blame is the blame of the removed and modified lines, if blame hints are enabled
+	Blame Blame
+	// Hints warn about risky rewrites, such as of recently changed or owned code
+	Hints []string
 	// Error holds any error that occurred during diff computation
 	// This allows propagating setup errors (like missing base commit) without breaking the flow
 	Error error
@@ -21,8 +33,32 @@ func (d *DiffStats) IsEmpty() bool {
 	return d.Added == 0 && d.Removed == 0 && d.Content == ""
 }
 
-// Diff returns the git diff between the worktree and the base branch along with statistics
+// Diff returns the git diff between the worktree and the base branch along with statistics. While the
+// worktree's fingerprint is unchanged, the last diff is reused instead of running git again, and the branch's
+// commits are only listed again once its HEAD moves.
 func (g *GitWorktree) Diff() *DiffStats {
+	fingerprint, head, err := g.fingerprint()
+	if err != nil {
+		log.WarningLog.Printf("failed to fingerprint worktree %s: %v", g.worktreePath, err)
+	} else if stats := g.cachedDiff(fingerprint); stats != nil {
+		return stats
+	}
+
+	stats := g.computeDiff()
+	if stats.Error == nil {
+		stats.Commits = g.branchCommits(head, err == nil)
+	}
+	if stats.Error == nil && err == nil {
+		cached := *stats
+		g.diff.stats = &cached
+		g.diff.fingerprint = fingerprint
+		g.diff.at = time.Now()
+	}
+	return stats
+}
+
+// computeDiff runs git to compute the diff between the worktree and the base branch.
+func (g *GitWorktree) computeDiff() *DiffStats {
 	stats := &DiffStats{}
 
 	// -N stages untracked files (intent to add), including them in the diff
@@ -46,6 +82,41 @@ func (g *GitWorktree) Diff() *DiffStats {
 		}
 	}
 	stats.Content = content
-
+	stats.Files = ParseDiffFiles(content)
 	return stats
 }
+
+// branchCommits returns the commits made on the branch, reusing the last ones listed while head, the
+// fingerprint of the worktree's HEAD, and the base commit are unchanged. Failing to list them is logged
+// rather than failing the diff, and the last ones listed are kept.
+func (g *GitWorktree) branchCommits(head uint64, headKnown bool) []CommitInfo {
+	if headKnown && head == g.diff.commitsHead && g.baseCommitSHA == g.diff.commitsBase {
+		return g.diff.commits
+	}
+	commits, err := g.BranchCommits()
+	if err != nil {
+		log.WarningLog.Printf("failed to list the commits on %s: %v", g.branchName, err)
+		return g.diff.commits
+	}
+	g.diff.commits = commits
+	g.diff.commitsHead = head
+	g.diff.commitsBase = g.baseCommitSHA
+	if !headKnown {
+		// Without HEAD's fingerprint, the commits are listed again next time.
+		g.diff.commitsBase = ""
+	}
+	return commits
+}
+
+// ChangedFiles returns the paths of the files changed in the worktree since the base commit, including
+// untracked files.
+func (g *GitWorktree) ChangedFiles() ([]string, error) {
+	if _, err := g.runGitCommand(g.worktreePath, "add", "-N", "."); err != nil {
+		return nil, err
+	}
+	output, err := g.runGitCommand(g.worktreePath, "--no-pager", "diff", "--name-only", g.GetBaseCommitSHA())
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(output), nil
+}
@@ -0,0 +1,67 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// skippedDirs are directories that are never searched for repositories, since they're large and only hold
+// dependencies.
+var skippedDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// FindRepositories returns the git repositories in roots and in the directories up to depth levels below them,
+// sorted. Repositories aren't searched for nested ones, and neither are hidden directories, so worktrees and
+// submodules, whose .git is a file, aren't returned. Roots that can't be read are reported in the error, along
+// with the repositories found in the others.
+func FindRepositories(roots []string, depth int) ([]string, error) {
+	seen := make(map[string]bool)
+	var errs []error
+	for _, root := range roots {
+		root, err := filepath.Abs(root)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if path == root {
+					return err
+				}
+				// Directories that can't be read below the root are skipped.
+				return fs.SkipDir
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			if path != root && (strings.HasPrefix(d.Name(), ".") || skippedDirs[d.Name()]) {
+				return fs.SkipDir
+			}
+			if info, err := os.Stat(filepath.Join(path, ".git")); err == nil && info.IsDir() {
+				seen[path] = true
+				return fs.SkipDir
+			}
+			if rel, _ := filepath.Rel(root, path); rel != "." && strings.Count(rel, string(filepath.Separator)) >= depth-1 {
+				return fs.SkipDir
+			}
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to scan %s for repositories: %w", root, err))
+		}
+	}
+
+	repos := make([]string, 0, len(seen))
+	for repo := range seen {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	return repos, errors.Join(errs...)
+}
@@ -0,0 +1,43 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindRepositories(t *testing.T) {
+	root := t.TempDir()
+	mkdir := func(path string) {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, path), 0755))
+	}
+	mkdir("api/.git")
+	mkdir("api/vendor/lib/.git")
+	mkdir("shop/web/.git")
+	mkdir("shop/payments/.git")
+	mkdir("archive/2019/old/deep/.git")
+	mkdir("node_modules/pkg/.git")
+	mkdir(".cache/tool/.git")
+	mkdir("notes")
+	// Worktrees' and submodules' .git is a file.
+	mkdir("worktree")
+	require.NoError(t, os.WriteFile(filepath.Join(root, "worktree", ".git"), []byte("gitdir: /elsewhere"), 0644))
+
+	repos, err := FindRepositories([]string{root}, 3)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(root, "api"),
+		filepath.Join(root, "shop", "payments"),
+		filepath.Join(root, "shop", "web"),
+	}, repos)
+
+	repos, err = FindRepositories([]string{root}, 1)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(root, "api")}, repos)
+
+	repos, err = FindRepositories([]string{filepath.Join(root, "shop"), filepath.Join(root, "missing")}, 3)
+	require.Error(t, err)
+	require.Len(t, repos, 2, "the repositories of the roots that could be scanned are returned")
+}
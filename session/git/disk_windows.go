@@ -0,0 +1,18 @@
+//go:build windows
+
+package git
+
+import "golang.org/x/sys/windows"
+
+// FreeSpace returns the space available to the user on the disk of path, in bytes.
+func FreeSpace(path string) (uint64, error) {
+	dir, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var free uint64
+	if err := windows.GetDiskFreeSpaceEx(dir, &free, nil, nil); err != nil {
+		return 0, err
+	}
+	return free, nil
+}
@@ -0,0 +1,127 @@
+package git
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SpaceEstimate is the disk space a new worktree is expected to take, and the space available to it.
+type SpaceEstimate struct {
+	// Dir is the directory whose disk the worktree is created on.
+	Dir string
+	// Checkout is the size of the files checked out.
+	Checkout uint64
+	// Hooks is the estimated size of what the repository's post-checkout hook writes, e.g. the files Git LFS
+	// downloads, which is assumed to be as much as the checkout. It's 0 if the repository has no such hook.
+	Hooks uint64
+	// Free is the space available on the disk.
+	Free uint64
+}
+
+// Required returns the space the worktree is expected to take.
+func (e SpaceEstimate) Required() uint64 {
+	return e.Checkout + e.Hooks
+}
+
+// InsufficientSpaceError is returned when a worktree isn't expected to fit on its disk.
+type InsufficientSpaceError struct {
+	SpaceEstimate
+}
+
+func (e *InsufficientSpaceError) Error() string {
+	details := formatBytes(e.Checkout) + " of files"
+	if e.Hooks > 0 {
+		details += " and " + formatBytes(e.Hooks) + " written by the post-checkout hook"
+	}
+	return fmt.Sprintf("not enough disk space for the worktree in %s: it needs about %s (%s) but only %s is free",
+		e.Dir, formatBytes(e.Required()), details, formatBytes(e.Free))
+}
+
+// EstimateSpace estimates the space the worktree takes once rev is checked out in it.
+func (g *GitWorktree) EstimateSpace(rev string) (SpaceEstimate, error) {
+	estimate := SpaceEstimate{Dir: existingAncestor(g.worktreePath)}
+	free, err := FreeSpace(estimate.Dir)
+	if err != nil {
+		return estimate, fmt.Errorf("failed to get the free space of %s: %w", estimate.Dir, err)
+	}
+	estimate.Free = free
+
+	output, err := g.runGitCommand(g.repoPath, "ls-tree", "-r", "-l", "--full-tree", rev)
+	if err != nil {
+		return estimate, err
+	}
+	for _, line := range strings.Split(output, "\n") {
+		// Each line is "<mode> <type> <object> <size>\t<path>". Submodules have no size.
+		meta, _, ok := strings.Cut(line, "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 4 {
+			continue
+		}
+		if size, err := strconv.ParseUint(fields[3], 10, 64); err == nil {
+			estimate.Checkout += size
+		}
+	}
+
+	if hooks, err := g.runGitCommand(g.repoPath, "rev-parse", "--git-path", "hooks"); err == nil {
+		hooksDir := strings.TrimSpace(hooks)
+		if !filepath.IsAbs(hooksDir) {
+			hooksDir = filepath.Join(g.repoPath, hooksDir)
+		}
+		if info, err := os.Stat(filepath.Join(hooksDir, "post-checkout")); err == nil && info.Mode()&0111 != 0 {
+			estimate.Hooks = estimate.Checkout
+		}
+	}
+	return estimate, nil
+}
+
+// checkDiskSpace refuses to create the worktree if checking out rev isn't expected to fit on the disk, rather
+// than letting the checkout fail midway. Creating a worktree that leaves less free space than the health checks
+// warn about is only logged.
+func (g *GitWorktree) checkDiskSpace(rev string) error {
+	estimate, err := g.EstimateSpace(rev)
+	if err != nil {
+		log.WarningLog.Printf("skipping the disk space check: %v", err)
+		return nil
+	}
+	if estimate.Free < estimate.Required() {
+		return &InsufficientSpaceError{SpaceEstimate: estimate}
+	}
+	if minFree := config.LoadConfig().Health.GetMinFreeDisk(); estimate.Free-estimate.Required() < minFree {
+		log.WarningLog.Printf("the worktree in %s leaves only about %s free on its disk", estimate.Dir,
+			formatBytes(estimate.Free-estimate.Required()))
+	}
+	return nil
+}
+
+// existingAncestor returns path, or its closest ancestor that exists.
+func existingAncestor(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// formatBytes formats n bytes in the largest unit it's at least one of, e.g. "1.5 GB".
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,40 @@
+package git
+
+import (
+	"claude-squad/session/git/gittest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateSpace(t *testing.T) {
+	r := gittest.New(t)
+	repo := r.Dir
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "src"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "README.md"), []byte(strings.Repeat("a", 1000)), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "src", "main.go"), []byte(strings.Repeat("b", 2000)), 0644))
+	r.Run("add", ".")
+	r.Run("commit", "-m", "initial")
+
+	g := &GitWorktree{repoPath: repo, worktreePath: filepath.Join(t.TempDir(), "worktrees", "session")}
+	estimate, err := g.EstimateSpace("HEAD")
+	require.NoError(t, err)
+	require.Equal(t, uint64(3000), estimate.Checkout)
+	require.Zero(t, estimate.Hooks)
+	require.NotZero(t, estimate.Free)
+	require.DirExists(t, estimate.Dir)
+
+	hook := filepath.Join(repo, ".git", "hooks", "post-checkout")
+	require.NoError(t, os.MkdirAll(filepath.Dir(hook), 0755))
+	require.NoError(t, os.WriteFile(hook, []byte("#!/bin/sh\n"), 0755))
+	estimate, err = g.EstimateSpace("HEAD")
+	require.NoError(t, err)
+	require.Equal(t, uint64(6000), estimate.Required())
+
+	estimate.Free = 1024
+	require.EqualError(t, &InsufficientSpaceError{SpaceEstimate: estimate}, "not enough disk space for the worktree in "+
+		estimate.Dir+": it needs about 5.9 KB (2.9 KB of files and 2.9 KB written by the post-checkout hook) but only 1.0 KB is free")
+}
@@ -0,0 +1,165 @@
+package git
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+)
+
+// diffMaxAge is how long Diff reuses its last result while the worktree's fingerprint is unchanged. The
+// fingerprint only looks at file metadata, so the diff is recomputed now and then regardless.
+const diffMaxAge = 30 * time.Second
+
+// indexChecksumSize is the size of the SHA-1 checksum that ends the index.
+const indexChecksumSize = 20
+
+// diffCache is the last result of Diff and the fingerprint of the worktree it was computed from.
+type diffCache struct {
+	stats       *DiffStats
+	fingerprint uint64
+	at          time.Time
+	// indexChecksum is the checksum of the index that paths were read from.
+	indexChecksum string
+	// paths are the tracked files and the directories containing them, relative to the worktree.
+	paths []string
+	// commits are the branch's commits, listed when HEAD's fingerprint was commitsHead and the base commit
+	// commitsBase. They only change when either does.
+	commits     []CommitInfo
+	commitsHead uint64
+	commitsBase string
+}
+
+// cachedDiff returns a copy of the last diff if it's recent and the worktree hasn't changed since.
+func (g *GitWorktree) cachedDiff(fingerprint uint64) *DiffStats {
+	if g.diff.stats == nil || fingerprint != g.diff.fingerprint || time.Since(g.diff.at) >= diffMaxAge {
+		return nil
+	}
+	stats := *g.diff.stats
+	return &stats
+}
+
+// fingerprint hashes the metadata of everything Diff depends on: the worktree's HEAD and branch ref, the
+// index's checksum, and the size and modification time of each tracked file and of the directories
+// containing them, which change when files are created or deleted. It's computed in-process, which is much
+// cheaper than running the git commands behind Diff. head is the hash of HEAD and the refs alone, which
+// changes when commits are made on the branch.
+func (g *GitWorktree) fingerprint() (worktree uint64, head uint64, err error) {
+	gitDir, err := worktreeGitDir(g.worktreePath)
+	if err != nil {
+		return 0, 0, err
+	}
+	commonDir := gitDir
+	if content, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir = strings.TrimSpace(string(content))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+	}
+
+	h := fnv.New64a()
+	buf := make([]byte, 0, 256)
+	stat := func(name string) {
+		buf = append(buf[:0], name...)
+		if info, err := os.Lstat(name); err == nil {
+			buf = binary.LittleEndian.AppendUint64(buf, uint64(info.Size()))
+			buf = binary.LittleEndian.AppendUint64(buf, uint64(info.ModTime().UnixNano()))
+		}
+		_, _ = h.Write(buf)
+	}
+
+	headPath := filepath.Join(gitDir, "HEAD")
+	stat(headPath)
+	headRef, err := os.ReadFile(headPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	if ref, ok := strings.CutPrefix(strings.TrimSpace(string(headRef)), "ref: "); ok {
+		stat(filepath.Join(commonDir, filepath.FromSlash(ref)))
+	}
+	stat(filepath.Join(commonDir, "packed-refs"))
+	head = h.Sum64()
+
+	// The index is rewritten by every Diff, so its checksum is used rather than its modification time.
+	if err := g.loadIndexPaths(filepath.Join(gitDir, "index")); err != nil {
+		return 0, 0, err
+	}
+	_, _ = h.Write([]byte(g.diff.indexChecksum))
+	for _, p := range g.diff.paths {
+		stat(filepath.Join(g.worktreePath, p))
+	}
+	return h.Sum64(), head, nil
+}
+
+// loadIndexPaths reads the paths the fingerprint covers from the index, unless they were read from the same
+// version of it already, going by the checksum at the end of the index.
+func (g *GitWorktree) loadIndexPaths(indexPath string) error {
+	f, err := os.Open(indexPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	checksum := make([]byte, indexChecksumSize)
+	if _, err := f.Seek(-indexChecksumSize, io.SeekEnd); err != nil {
+		return fmt.Errorf("failed to read index: %w", err)
+	}
+	if _, err := io.ReadFull(f, checksum); err != nil {
+		return fmt.Errorf("failed to read index: %w", err)
+	}
+	if string(checksum) == g.diff.indexChecksum {
+		return nil
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	idx := &index.Index{}
+	if err := index.NewDecoder(f).Decode(idx); err != nil {
+		return fmt.Errorf("failed to read index: %w", err)
+	}
+
+	dirs := map[string]bool{".": true}
+	paths := []string{"."}
+	for _, entry := range idx.Entries {
+		paths = append(paths, filepath.FromSlash(entry.Name))
+		for dir := path.Dir(entry.Name); !dirs[dir]; dir = path.Dir(dir) {
+			dirs[dir] = true
+			paths = append(paths, filepath.FromSlash(dir))
+		}
+	}
+	g.diff.indexChecksum = string(checksum)
+	g.diff.paths = paths
+	return nil
+}
+
+// worktreeGitDir returns the git directory of the worktree at worktreePath. In a linked worktree, .git is a
+// file pointing to it.
+func worktreeGitDir(worktreePath string) (string, error) {
+	dotGit := filepath.Join(worktreePath, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return dotGit, nil
+	}
+	content, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", err
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(content)), "gitdir: ")
+	if !ok {
+		return "", fmt.Errorf("unexpected contents in %s", dotGit)
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(worktreePath, gitDir)
+	}
+	return gitDir, nil
+}
@@ -0,0 +1,70 @@
+package git
+
+import (
+	"claude-squad/session/git/gittest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffReusedWhileUnchanged(t *testing.T) {
+	r := gittest.New(t)
+	repo := r.Dir
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, "src"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "src", "main.go"), []byte("package main\n"), 0644))
+	r.Run("add", ".")
+	r.Run("commit", "-q", "-m", "initial")
+	base := r.Head()
+
+	worktree := &GitWorktree{repoPath: repo, worktreePath: repo, branchName: "main", baseCommitSHA: base}
+	stats := worktree.Diff()
+	require.NoError(t, stats.Error)
+	require.True(t, stats.IsEmpty())
+
+	fingerprint, _, err := worktree.fingerprint()
+	require.NoError(t, err)
+	again, _, err := worktree.fingerprint()
+	require.NoError(t, err)
+	require.Equal(t, fingerprint, again)
+
+	// Changing a tracked file is noticed.
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "src", "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644))
+	stats = worktree.Diff()
+	require.NoError(t, stats.Error)
+	require.Equal(t, 2, stats.Added)
+
+	// So is creating a file in a directory with tracked files.
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "src", "new.go"), []byte("package main\n"), 0644))
+	stats = worktree.Diff()
+	require.NoError(t, stats.Error)
+	require.Equal(t, 3, stats.Added)
+
+	// While nothing changes, the last diff is reused.
+	worktree.Diff()
+	fingerprint, _, err = worktree.fingerprint()
+	require.NoError(t, err)
+	require.NotNil(t, worktree.cachedDiff(fingerprint))
+	require.Equal(t, 3, worktree.Diff().Added)
+
+	// So are commits.
+	r.Run("add", ".")
+	r.Run("commit", "-q", "-m", "change")
+	stats = worktree.Diff()
+	require.NoError(t, stats.Error)
+	require.Len(t, stats.Commits, 1)
+
+	// The commits aren't listed again while HEAD doesn't move, even though the diff changes.
+	worktree.diff.commits = []CommitInfo{{Subject: "listed before"}}
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "src", "main.go"), []byte("package main\n\nfunc init() {}\n"), 0644))
+	stats = worktree.Diff()
+	require.NoError(t, stats.Error)
+	require.Equal(t, []CommitInfo{{Subject: "listed before"}}, stats.Commits)
+
+	// Once it does, they are.
+	r.Run("commit", "-q", "-am", "another change")
+	stats = worktree.Diff()
+	require.NoError(t, stats.Error)
+	require.Len(t, stats.Commits, 2)
+}
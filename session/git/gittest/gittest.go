@@ -0,0 +1,70 @@
+// Package gittest creates git repositories for tests.
+package gittest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Repo is a git repository created for a test. Its commits are made by Alice <alice@example.com>.
+type Repo struct {
+	t testing.TB
+	// Dir is the repository's directory.
+	Dir string
+}
+
+// New initializes a repository with a main branch in a temporary directory.
+func New(t testing.TB) *Repo {
+	t.Helper()
+	repo := Open(t, t.TempDir())
+	repo.Run("init", "-q", "-b", "main")
+	return repo
+}
+
+// Open returns the repository in dir, e.g. one cloned from another, without initializing it.
+func Open(t testing.TB, dir string) *Repo {
+	return &Repo{t: t, Dir: dir}
+}
+
+// Run runs git with args in the repository and returns its output. The test fails if git does.
+func (r *Repo) Run(args ...string) string {
+	r.t.Helper()
+	return r.RunAt("", args...)
+}
+
+// RunAt runs git like Run, with the author and committer dates set to date, e.g. "2024-01-02T03:04:05Z". An
+// empty date leaves them to git.
+func (r *Repo) RunAt(date string, args ...string) string {
+	r.t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", r.Dir, "-c", "user.name=Alice", "-c", "user.email=alice@example.com"}, args...)...)
+	if date != "" {
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+	}
+	output, err := cmd.CombinedOutput()
+	require.NoError(r.t, err, string(output))
+	return string(output)
+}
+
+// Commit writes file, holding its own name, and commits everything with message.
+func (r *Repo) Commit(file, message string) {
+	r.t.Helper()
+	r.CommitAt("", file, message)
+}
+
+// CommitAt commits like Commit, dated date as for RunAt.
+func (r *Repo) CommitAt(date, file, message string) {
+	r.t.Helper()
+	require.NoError(r.t, os.WriteFile(filepath.Join(r.Dir, file), []byte(file), 0644))
+	r.RunAt(date, "add", ".")
+	r.RunAt(date, "commit", "-q", "-m", message)
+}
+
+// Head returns the hash of the commit HEAD points to.
+func (r *Repo) Head() string {
+	r.t.Helper()
+	return r.Run("rev-parse", "HEAD")[:40]
+}
@@ -0,0 +1,97 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	instructionsBegin = "<!-- claude-squad instructions -->"
+	instructionsEnd   = "<!-- end claude-squad instructions -->"
+)
+
+// InjectInstructions writes instructions into fileName (e.g. CLAUDE.md) in the worktree, appending them
+// to the file if it exists. The instructions are wrapped in markers so injecting again replaces them.
+// The change is hidden from git so the instructions don't end up in the branch's commits.
+func (g *GitWorktree) InjectInstructions(fileName, instructions string) error {
+	path := filepath.Join(g.worktreePath, fileName)
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", fileName, err)
+	}
+	if err := os.WriteFile(path, []byte(mergeInstructions(string(existing), instructions)), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", fileName, err)
+	}
+
+	return g.HideFromGit(fileName)
+}
+
+// HideFromGit keeps local changes to the file at path, relative to the worktree, out of commits. Tracked
+// files are marked skip-worktree and untracked ones are added to the repository's exclude file.
+func (g *GitWorktree) HideFromGit(path string) error {
+	if _, err := g.runGitCommand(g.worktreePath, "ls-files", "--error-unmatch", "--", path); err == nil {
+		if _, err := g.runGitCommand(g.worktreePath, "update-index", "--skip-worktree", "--", path); err != nil {
+			return fmt.Errorf("failed to hide changes to %s from git: %w", path, err)
+		}
+		return nil
+	}
+	return g.exclude("/" + filepath.ToSlash(path))
+}
+
+// exclude adds pattern to the repository's info/exclude file unless it's already there.
+func (g *GitWorktree) exclude(pattern string) error {
+	excludePath, err := g.runGitCommand(g.worktreePath, "rev-parse", "--path-format=absolute", "--git-path", "info/exclude")
+	if err != nil {
+		return fmt.Errorf("failed to find exclude file: %w", err)
+	}
+	excludePath = strings.TrimSpace(excludePath)
+
+	existing, err := os.ReadFile(excludePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read exclude file: %w", err)
+	}
+	for _, line := range strings.Split(string(existing), "\n") {
+		if strings.TrimSpace(line) == pattern {
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(excludePath), 0755); err != nil {
+		return fmt.Errorf("failed to create exclude file: %w", err)
+	}
+	f, err := os.OpenFile(excludePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open exclude file: %w", err)
+	}
+	defer f.Close()
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		pattern = "\n" + pattern
+	}
+	if _, err := f.WriteString(pattern + "\n"); err != nil {
+		return fmt.Errorf("failed to write exclude file: %w", err)
+	}
+	return nil
+}
+
+// mergeInstructions returns existing with the marked instructions block replaced by instructions, or
+// with the block appended if there isn't one yet.
+func mergeInstructions(existing, instructions string) string {
+	block := instructionsBegin + "\n" + strings.TrimSpace(instructions) + "\n" + instructionsEnd + "\n"
+
+	if start := strings.Index(existing, instructionsBegin); start >= 0 {
+		if end := strings.Index(existing[start:], instructionsEnd); end >= 0 {
+			rest := strings.TrimPrefix(existing[start+end+len(instructionsEnd):], "\n")
+			return existing[:start] + block + rest
+		}
+	}
+
+	if existing == "" {
+		return block
+	}
+	if !strings.HasSuffix(existing, "\n") {
+		existing += "\n"
+	}
+	return existing + "\n" + block
+}
@@ -0,0 +1,24 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeInstructions(t *testing.T) {
+	block := instructionsBegin + "\nUse tabs.\n" + instructionsEnd + "\n"
+
+	require.Equal(t, block, mergeInstructions("", "Use tabs."))
+
+	merged := mergeInstructions("# Project\nSome notes", "Use tabs.")
+	require.Equal(t, "# Project\nSome notes\n\n"+block, merged)
+
+	// Injecting again replaces the block instead of adding another one.
+	replaced := mergeInstructions(merged, "Use spaces.")
+	require.Equal(t, "# Project\nSome notes\n\n"+instructionsBegin+"\nUse spaces.\n"+instructionsEnd+"\n", replaced)
+
+	// Content after the block is kept.
+	require.Equal(t, "a\n"+instructionsBegin+"\nnew\n"+instructionsEnd+"\nb\n",
+		mergeInstructions("a\n"+block+"b\n", "new"))
+}
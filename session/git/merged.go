@@ -0,0 +1,73 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultBranch returns the branch the repository's changes are merged into: the remote's HEAD if it's
+// known, and otherwise main or master.
+func (g *GitWorktree) defaultBranch() (string, error) {
+	if output, err := g.runGitCommand(g.repoPath, "symbolic-ref", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		return strings.TrimSpace(output), nil
+	}
+	for _, branch := range []string{"main", "master"} {
+		if _, err := g.runGitCommand(g.repoPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+			return branch, nil
+		}
+	}
+	return "", fmt.Errorf("could not determine the default branch of %s", g.repoPath)
+}
+
+// MergedAt reports whether the branch has been merged into the repository's default branch and, if so,
+// when. A branch without commits of its own isn't considered merged. Squash merges and rebases rewrite the
+// branch's commits, so they aren't detected.
+func (g *GitWorktree) MergedAt() (time.Time, bool, error) {
+	target, err := g.defaultBranch()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	output, err := g.runGitCommand(g.repoPath, "rev-parse", "--verify", g.branchName)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to resolve branch %s: %w", g.branchName, err)
+	}
+	tip := strings.TrimSpace(output)
+	if tip == g.baseCommitSHA {
+		return time.Time{}, false, nil
+	}
+
+	if _, err := g.runGitCommand(g.repoPath, "merge-base", "--is-ancestor", tip, target); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return time.Time{}, false, nil
+		}
+		return time.Time{}, false, err
+	}
+
+	// The merge is the oldest commit on the default branch descending from the tip. If there's none, the
+	// default branch was fast-forwarded to the tip.
+	output, err = g.runGitCommand(g.repoPath, "log", "--ancestry-path", "--reverse", "--format=%ct", tip+".."+target)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	if fields := strings.Fields(output); len(fields) > 0 {
+		return parseUnixTime(fields[0])
+	}
+	output, err = g.runGitCommand(g.repoPath, "log", "-1", "--format=%ct", tip)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return parseUnixTime(strings.TrimSpace(output))
+}
+
+func parseUnixTime(s string) (time.Time, bool, error) {
+	seconds, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid commit time %q: %w", s, err)
+	}
+	return time.Unix(seconds, 0), true, nil
+}
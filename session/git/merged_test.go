@@ -0,0 +1,36 @@
+package git
+
+import (
+	"claude-squad/session/git/gittest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergedAt(t *testing.T) {
+	r := gittest.New(t)
+	r.CommitAt("2024-01-01T00:00:00Z", "base", "base")
+	base := r.Head()
+
+	worktree := &GitWorktree{repoPath: r.Dir, branchName: "feature", baseCommitSHA: base}
+	r.Run("branch", "feature")
+	_, merged, err := worktree.MergedAt()
+	require.NoError(t, err)
+	require.False(t, merged, "a branch without commits isn't merged")
+
+	r.Run("checkout", "-q", "feature")
+	r.CommitAt("2024-01-02T00:00:00Z", "feature", "feature")
+	r.Run("checkout", "-q", "main")
+	_, merged, err = worktree.MergedAt()
+	require.NoError(t, err)
+	require.False(t, merged)
+
+	r.CommitAt("2024-01-03T00:00:00Z", "other", "other")
+	r.RunAt("2024-01-04T00:00:00Z", "merge", "--no-ff", "-m", "merge", "feature")
+	r.CommitAt("2024-01-05T00:00:00Z", "later", "later")
+	at, merged, err := worktree.MergedAt()
+	require.NoError(t, err)
+	require.True(t, merged)
+	require.Equal(t, time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC), at.UTC())
+}
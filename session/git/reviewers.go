@@ -0,0 +1,102 @@
+package git
+
+import (
+	"claude-squad/codeowners"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Ownership is the changed files owned by one CODEOWNERS owner.
+type Ownership struct {
+	Owner string
+	Files []string
+}
+
+// Ownership returns who owns the files the worktree changed, according to the repository's CODEOWNERS,
+// with the owners of the most files first. It returns nothing if the repository has no CODEOWNERS.
+func (g *GitWorktree) Ownership() ([]Ownership, error) {
+	owners, err := codeowners.Load(g.repoPath)
+	if err != nil || owners == nil {
+		return nil, err
+	}
+	files, err := g.ChangedFiles()
+	if err != nil {
+		return nil, err
+	}
+	return ownershipOf(owners, files), nil
+}
+
+func ownershipOf(owners *codeowners.Owners, files []string) []Ownership {
+	index := make(map[string]int)
+	var ownership []Ownership
+	for _, file := range files {
+		for _, owner := range owners.Of(file) {
+			i, ok := index[owner]
+			if !ok {
+				i = len(ownership)
+				index[owner] = i
+				ownership = append(ownership, Ownership{Owner: owner})
+			}
+			ownership[i].Files = append(ownership[i].Files, file)
+		}
+	}
+	sort.SliceStable(ownership, func(i, j int) bool {
+		return len(ownership[i].Files) > len(ownership[j].Files)
+	})
+	return ownership
+}
+
+// Reviewers returns the owners that can be requested as pull request reviewers, in gh's format
+// ("user" or "org/team"). Owners given as email addresses are skipped.
+func Reviewers(ownership []Ownership) []string {
+	var reviewers []string
+	for _, o := range ownership {
+		if !strings.HasPrefix(o.Owner, "@") {
+			continue
+		}
+		reviewers = append(reviewers, strings.TrimPrefix(o.Owner, "@"))
+	}
+	return reviewers
+}
+
+// openPullRequest opens the branch's pull request in the browser with reviewers requested, creating the
+// pull request from the branch's commits if it doesn't exist yet.
+func (g *GitWorktree) openPullRequest(reviewers []string) error {
+	// GitHub refuses review requests from the pull request's author.
+	if output, err := exec.Command("gh", "api", "user", "--jq", ".login").Output(); err == nil {
+		login := strings.TrimSpace(string(output))
+		filtered := reviewers[:0]
+		for _, reviewer := range reviewers {
+			if !strings.EqualFold(reviewer, login) {
+				filtered = append(filtered, reviewer)
+			}
+		}
+		reviewers = filtered
+	}
+	if len(reviewers) == 0 {
+		return g.OpenBranchURL()
+	}
+
+	list := strings.Join(reviewers, ",")
+	create := exec.Command("gh", "pr", "create", "--fill", "--head", g.branchName, "--reviewer", list)
+	create.Dir = g.worktreePath
+	if output, err := create.CombinedOutput(); err != nil {
+		if !strings.Contains(string(output), "already exists") {
+			return fmt.Errorf("failed to create pull request: %s (%w)", strings.TrimSpace(string(output)), err)
+		}
+		edit := exec.Command("gh", "pr", "edit", g.branchName, "--add-reviewer", list)
+		edit.Dir = g.worktreePath
+		if output, err := edit.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to request reviewers: %s (%w)", strings.TrimSpace(string(output)), err)
+		}
+	}
+
+	view := exec.Command("gh", "pr", "view", g.branchName, "--web")
+	view.Dir = g.worktreePath
+	if err := view.Run(); err != nil {
+		return fmt.Errorf("failed to open pull request: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package git
+
+import (
+	"claude-squad/codeowners"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOwnership(t *testing.T) {
+	owners := codeowners.Parse("* @org/core\n/api/ @org/api @bob\n/docs/ docs@example.com\n")
+	ownership := ownershipOf(owners, []string{"api/a.go", "api/b.go", "main.go", "docs/x.md"})
+
+	require.Equal(t, []Ownership{
+		{Owner: "@org/api", Files: []string{"api/a.go", "api/b.go"}},
+		{Owner: "@bob", Files: []string{"api/a.go", "api/b.go"}},
+		{Owner: "@org/core", Files: []string{"main.go"}},
+		{Owner: "docs@example.com", Files: []string{"docs/x.md"}},
+	}, ownership)
+	require.Equal(t, []string{"org/api", "bob", "org/core"}, Reviewers(ownership))
+}
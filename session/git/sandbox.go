@@ -0,0 +1,154 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// A sandboxed worktree is checked out into a lower directory next to the worktree path and presented at
+// the worktree path through a copy-on-write view. Everything written in the worktree, including ignored
+// files like caches and local databases, lands in a per-instance layer that is discarded wholesale when
+// the worktree is removed. Commits still go to the repository.
+//
+// On Linux the view is an overlay mounted with fuse-overlayfs, or the kernel's overlayfs when running as
+// root. macOS has no overlay filesystem, so the lower directory is cloned with APFS clonefile instead,
+// which shares blocks until they're written.
+
+func (g *GitWorktree) sandboxLowerDir() string { return g.worktreePath + ".lower" }
+func (g *GitWorktree) sandboxUpperDir() string { return g.worktreePath + ".upper" }
+func (g *GitWorktree) sandboxWorkDir() string  { return g.worktreePath + ".work" }
+
+// SetSandboxed sets whether the worktree is presented through a copy-on-write view.
+func (g *GitWorktree) SetSandboxed(sandboxed bool) {
+	g.sandboxed = sandboxed
+}
+
+// IsSandboxed returns whether the worktree is presented through a copy-on-write view.
+func (g *GitWorktree) IsSandboxed() bool {
+	return g.sandboxed
+}
+
+// CheckSandboxSupport returns an error if copy-on-write views can't be created on this machine.
+func CheckSandboxSupport() error {
+	switch runtime.GOOS {
+	case "linux":
+		if _, err := exec.LookPath("fuse-overlayfs"); err == nil || os.Geteuid() == 0 {
+			return nil
+		}
+		return fmt.Errorf("sandboxed instances need fuse-overlayfs, please install it first")
+	case "darwin":
+		return nil
+	default:
+		return fmt.Errorf("sandboxed instances are not supported on %s", runtime.GOOS)
+	}
+}
+
+// setupSandbox moves the freshly checked out worktree into the lower directory and presents it at the
+// worktree path through a copy-on-write view.
+func (g *GitWorktree) setupSandbox() error {
+	if err := CheckSandboxSupport(); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(g.sandboxLowerDir()); err != nil {
+		return fmt.Errorf("failed to clear sandbox: %w", err)
+	}
+	if err := os.Rename(g.worktreePath, g.sandboxLowerDir()); err != nil {
+		return fmt.Errorf("failed to move worktree into sandbox: %w", err)
+	}
+	return g.mountSandbox()
+}
+
+// EnsureSandbox recreates the worktree's copy-on-write view if it's missing, e.g. after a reboot
+// unmounted it. The instance's writes are kept.
+func (g *GitWorktree) EnsureSandbox() error {
+	if !g.sandboxed {
+		return nil
+	}
+	if _, err := os.Stat(g.sandboxLowerDir()); err != nil {
+		// Paused: there's no worktree to present.
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(g.worktreePath, ".git")); err == nil {
+		return nil
+	}
+	return g.mountSandbox()
+}
+
+func (g *GitWorktree) mountSandbox() error {
+	lower, upper, work := g.sandboxLowerDir(), g.sandboxUpperDir(), g.sandboxWorkDir()
+
+	if runtime.GOOS == "darwin" {
+		if _, err := os.Stat(g.worktreePath); err == nil {
+			// A clone is never unmounted, so the view is already there.
+			return nil
+		}
+		if output, err := exec.Command("cp", "-c", "-R", lower, g.worktreePath).CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to clone worktree into sandbox: %s (%w)", output, err)
+		}
+		return nil
+	}
+
+	for _, dir := range []string{g.worktreePath, upper, work} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create sandbox directory: %w", err)
+		}
+	}
+	options := fmt.Sprintf("lowerdir=%s,upperdir=%s,workdir=%s", lower, upper, work)
+	var cmd *exec.Cmd
+	if _, err := exec.LookPath("fuse-overlayfs"); err == nil {
+		cmd = exec.Command("fuse-overlayfs", "-o", options, g.worktreePath)
+	} else {
+		cmd = exec.Command("mount", "-t", "overlay", "overlay", "-o", options, g.worktreePath)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to mount sandbox: %s (%w)", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// unmountSandbox removes the copy-on-write view from the worktree path.
+func (g *GitWorktree) unmountSandbox() error {
+	if runtime.GOOS == "darwin" {
+		return nil
+	}
+	// Unmount with the counterpart of whatever mountSandbox used.
+	cmd := exec.Command("umount", g.worktreePath)
+	if _, err := exec.LookPath("fuse-overlayfs"); err == nil {
+		fusermount := "fusermount3"
+		if _, err := exec.LookPath(fusermount); err != nil {
+			fusermount = "fusermount"
+		}
+		cmd = exec.Command(fusermount, "-u", g.worktreePath)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		// Nothing to do if the view was already gone.
+		if strings.Contains(string(output), "not mounted") || strings.Contains(string(output), "not found in /etc/mtab") {
+			return nil
+		}
+		return fmt.Errorf("failed to unmount sandbox: %s (%w)", strings.TrimSpace(string(output)), err)
+	}
+	return nil
+}
+
+// removeSandbox unmounts the worktree's copy-on-write view and discards every layer, including the
+// instance's writes. The repository's worktree metadata is left for Prune.
+func (g *GitWorktree) removeSandbox() error {
+	if err := g.unmountSandbox(); err != nil {
+		return err
+	}
+	return g.discardSandbox()
+}
+
+// discardSandbox deletes the worktree path and every layer of its view.
+func (g *GitWorktree) discardSandbox() error {
+	for _, dir := range []string{g.worktreePath, g.sandboxLowerDir(), g.sandboxUpperDir(), g.sandboxWorkDir()} {
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("failed to remove sandbox: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package git
+
+import (
+	"claude-squad/session/git/gittest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandboxDiscardsWrites(t *testing.T) {
+	if err := CheckSandboxSupport(); err != nil {
+		t.Skip(err)
+	}
+
+	r := gittest.New(t)
+	r.Run("commit", "--allow-empty", "-m", "initial")
+	repo := r.Dir
+
+	worktree := &GitWorktree{
+		repoPath:     repo,
+		worktreePath: filepath.Join(t.TempDir(), "sandboxed"),
+		branchName:   "sandboxed",
+		sandboxed:    true,
+	}
+	if err := worktree.Setup(); err != nil {
+		t.Skipf("can't mount a sandbox here: %v", err)
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(worktree.worktreePath, "cache.db"), []byte("data"), 0644))
+	_, err := os.Stat(filepath.Join(worktree.sandboxLowerDir(), "cache.db"))
+	require.True(t, os.IsNotExist(err), "writes must not reach the lower directory")
+	dirty, err := worktree.IsDirty()
+	require.NoError(t, err)
+	require.True(t, dirty)
+
+	require.NoError(t, worktree.Remove())
+	for _, dir := range []string{worktree.worktreePath, worktree.sandboxLowerDir(), worktree.sandboxUpperDir()} {
+		_, err := os.Stat(dir)
+		require.True(t, os.IsNotExist(err), dir)
+	}
+	require.NoError(t, worktree.Prune())
+}
@@ -0,0 +1,181 @@
+package git
+
+import (
+	"claude-squad/redact"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// FindingKind is the kind of problem found by ScanChanges.
+type FindingKind string
+
+const (
+	FindingSecret        FindingKind = "secret"
+	FindingLargeBinary   FindingKind = "large binary"
+	FindingForbiddenPath FindingKind = "forbidden path"
+	// FindingLicense and FindingDependencyCount are reported by the dependency checks rather than ScanChanges.
+	FindingLicense         FindingKind = "disallowed license"
+	FindingDependencyCount FindingKind = "many new dependencies"
+)
+
+// Finding is a problem found in a worktree's changes.
+type Finding struct {
+	Kind FindingKind
+	Path string
+	// Line is the line of the secret in the new version of the file. It's 0 for other findings.
+	Line   int
+	Detail string
+}
+
+func (f Finding) String() string {
+	location := f.Path
+	if f.Line > 0 {
+		location = fmt.Sprintf("%s:%d", f.Path, f.Line)
+	}
+	return fmt.Sprintf("%s in %s: %s", f.Kind, location, f.Detail)
+}
+
+// ScanOptions configures ScanChanges.
+type ScanOptions struct {
+	// Secrets finds secrets in added lines. If nil, secrets aren't scanned for.
+	Secrets *redact.Redactor
+	// MaxBinarySize is the size in bytes above which an added or modified binary file is reported.
+	// 0 disables the check.
+	MaxBinarySize int64
+	// ForbiddenPaths are glob patterns of files that must not be changed. A pattern matches a file's
+	// full path or its base name; a pattern ending in "/" matches everything under that directory.
+	ForbiddenPaths []string
+}
+
+// ScanChanges scans everything the worktree changed since its base commit, including uncommitted and
+// untracked files, for secrets, large binaries and forbidden paths.
+func (g *GitWorktree) ScanChanges(opts ScanOptions) ([]Finding, error) {
+	tree, err := g.snapshotTree()
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot worktree: %w", err)
+	}
+
+	numstat, err := g.runGitCommand(g.repoPath, "--no-pager", "diff", "--numstat", "--diff-filter=AM", g.baseCommitSHA, tree)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []Finding
+	var binaries []string
+	for _, line := range strings.Split(numstat, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		file := fields[2]
+		if pattern, ok := matchPathPattern(file, opts.ForbiddenPaths); ok {
+			findings = append(findings, Finding{Kind: FindingForbiddenPath, Path: file, Detail: "matches " + pattern})
+		}
+		if fields[0] == "-" && fields[1] == "-" {
+			binaries = append(binaries, file)
+		}
+	}
+
+	if opts.MaxBinarySize > 0 && len(binaries) > 0 {
+		sizes, err := g.fileSizes(tree, binaries)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range binaries {
+			if size := sizes[file]; size > opts.MaxBinarySize {
+				findings = append(findings, Finding{
+					Kind:   FindingLargeBinary,
+					Path:   file,
+					Detail: fmt.Sprintf("%d bytes, the limit is %d", size, opts.MaxBinarySize),
+				})
+			}
+		}
+	}
+
+	if opts.Secrets != nil {
+		diff, err := g.runGitCommand(g.repoPath, "--no-pager", "diff", "-U0", "--diff-filter=AM", g.baseCommitSHA, tree)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, scanAddedLines(diff, opts.Secrets)...)
+	}
+	return findings, nil
+}
+
+// fileSizes returns the sizes of files in tree.
+func (g *GitWorktree) fileSizes(tree string, files []string) (map[string]int64, error) {
+	output, err := g.runGitCommand(g.repoPath, append([]string{"ls-tree", "-r", "-l", tree, "--"}, files...)...)
+	if err != nil {
+		return nil, err
+	}
+	sizes := make(map[string]int64)
+	for _, line := range strings.Split(output, "\n") {
+		meta, file, ok := strings.Cut(line, "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 4 {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		sizes[file] = size
+	}
+	return sizes, nil
+}
+
+// matchPathPattern returns the first of patterns that file matches. A pattern matches a file's full path
+// or its base name; a pattern ending in "/" matches everything under that directory.
+func matchPathPattern(file string, patterns []string) (string, bool) {
+	for _, pattern := range patterns {
+		if dir, ok := strings.CutSuffix(pattern, "/"); ok {
+			if strings.HasPrefix(file, dir+"/") || strings.Contains(file, "/"+dir+"/") {
+				return pattern, true
+			}
+			continue
+		}
+		if matched, _ := path.Match(pattern, file); matched {
+			return pattern, true
+		}
+		if matched, _ := path.Match(pattern, path.Base(file)); matched {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// scanAddedLines finds secrets in the lines added by a unified diff.
+func scanAddedLines(diff string, secrets *redact.Redactor) []Finding {
+	var findings []Finding
+	var file string
+	line := 0
+	for _, text := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(text, "+++ "):
+			file = strings.TrimPrefix(strings.TrimPrefix(text, "+++ "), "b/")
+		case strings.HasPrefix(text, "@@ "):
+			// @@ -a,b +c,d @@: added lines start at line c.
+			fields := strings.Fields(text)
+			if len(fields) >= 3 {
+				start, _, _ := strings.Cut(strings.TrimPrefix(fields[2], "+"), ",")
+				line, _ = strconv.Atoi(start)
+			}
+		case strings.HasPrefix(text, "+"):
+			for _, match := range secrets.Matches(text[1:]) {
+				findings = append(findings, Finding{Kind: FindingSecret, Path: file, Line: line, Detail: mask(match)})
+			}
+			line++
+		}
+	}
+	return findings
+}
+
+// mask hides all but the start of a secret so the report doesn't leak it.
+func mask(secret string) string {
+	if len(secret) <= 8 {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:4] + strings.Repeat("*", 8)
+}
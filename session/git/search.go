@@ -0,0 +1,64 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// SearchHit is a single line matching a search pattern.
+type SearchHit struct {
+	Path string
+	Line int
+	Text string
+}
+
+// Search looks for the regular expression pattern in the files under dir. ripgrep is used if it's
+// installed, otherwise git grep. Both skip ignored files but include untracked ones.
+func Search(dir, pattern string, ignoreCase bool) ([]SearchHit, error) {
+	var cmd *exec.Cmd
+	if _, err := exec.LookPath("rg"); err == nil {
+		args := []string{"--line-number", "--no-heading", "--color", "never"}
+		if ignoreCase {
+			args = append(args, "--ignore-case")
+		}
+		cmd = exec.Command("rg", append(args, "-e", pattern)...)
+		cmd.Dir = dir
+	} else {
+		args := []string{"-C", dir, "grep", "--line-number", "--untracked", "--extended-regexp"}
+		if ignoreCase {
+			args = append(args, "--ignore-case")
+		}
+		cmd = exec.Command("git", append(args, "-e", pattern)...)
+	}
+
+	output, err := cmd.Output()
+	if err != nil {
+		// Both tools exit with status 1 when nothing matched.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("search in %s failed: %w", dir, err)
+	}
+	return parseSearchOutput(string(output)), nil
+}
+
+// parseSearchOutput parses path:line:text lines as printed by ripgrep and git grep.
+func parseSearchOutput(output string) []SearchHit {
+	var hits []SearchHit
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		lineNumber, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		hits = append(hits, SearchHit{Path: fields[0], Line: lineNumber, Text: fields[2]})
+	}
+	return hits
+}
@@ -0,0 +1,16 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSearchOutput(t *testing.T) {
+	output := "main.go:12:\tfoo := bar()\napp/app.go:3:// foo: the thing\nnot a hit\n"
+
+	require.Equal(t, []SearchHit{
+		{Path: "main.go", Line: 12, Text: "\tfoo := bar()"},
+		{Path: "app/app.go", Line: 3, Text: "// foo: the thing"},
+	}, parseSearchOutput(output))
+}
@@ -0,0 +1,138 @@
+package git
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// languages maps file extensions to the language they're summarized under.
+var languages = map[string]string{
+	".go":    "Go",
+	".ts":    "TypeScript",
+	".tsx":   "TypeScript",
+	".js":    "JavaScript",
+	".jsx":   "JavaScript",
+	".mjs":   "JavaScript",
+	".py":    "Python",
+	".rs":    "Rust",
+	".java":  "Java",
+	".kt":    "Kotlin",
+	".rb":    "Ruby",
+	".php":   "PHP",
+	".swift": "Swift",
+	".c":     "C",
+	".h":     "C",
+	".cc":    "C++",
+	".cpp":   "C++",
+	".hpp":   "C++",
+	".cs":    "C#",
+	".sh":    "Shell",
+	".sql":   "SQL",
+	".html":  "HTML",
+	".css":   "CSS",
+	".scss":  "CSS",
+	".md":    "Markdown",
+	".json":  "JSON",
+	".yaml":  "YAML",
+	".yml":   "YAML",
+	".toml":  "TOML",
+}
+
+// Language returns the language of file based on its extension, or "Other".
+func Language(file string) string {
+	if language, ok := languages[strings.ToLower(path.Ext(file))]; ok {
+		return language
+	}
+	return "Other"
+}
+
+// Breakdown is the number of lines changed in a group of files.
+type Breakdown struct {
+	Name    string
+	Added   int
+	Removed int
+}
+
+// ByLanguage groups changes by language, largest first.
+func ByLanguage(files []FileChange) []Breakdown {
+	return groupChanges(files, Language)
+}
+
+// ByDirectory groups changes by top-level directory (e.g. "web/"), largest first. Files at the root of
+// the repository are grouped under "./".
+func ByDirectory(files []FileChange) []Breakdown {
+	return groupChanges(files, func(file string) string {
+		if dir, _, ok := strings.Cut(file, "/"); ok {
+			return dir + "/"
+		}
+		return "./"
+	})
+}
+
+func groupChanges(files []FileChange, key func(string) string) []Breakdown {
+	index := make(map[string]int)
+	var groups []Breakdown
+	for _, file := range files {
+		name := key(file.Path)
+		i, ok := index[name]
+		if !ok {
+			i = len(groups)
+			index[name] = i
+			groups = append(groups, Breakdown{Name: name})
+		}
+		groups[i].Added += file.Added
+		groups[i].Removed += file.Removed
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Added+groups[i].Removed > groups[j].Added+groups[j].Removed
+	})
+	return groups
+}
+
+// MatchPaths returns the files that match any of patterns. Patterns follow the same rules as
+// ScanOptions.ForbiddenPaths.
+func MatchPaths(files []FileChange, patterns []string) []string {
+	var matched []string
+	for _, file := range files {
+		if _, ok := matchPathPattern(file.Path, patterns); ok {
+			matched = append(matched, file.Path)
+		}
+	}
+	return matched
+}
+
+// ParseDiffFiles returns the lines changed per file in a unified diff.
+func ParseDiffFiles(diff string) []FileChange {
+	var files []FileChange
+	var current *FileChange
+	inHunk := false
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			files = append(files, FileChange{})
+			current = &files[len(files)-1]
+			inHunk = false
+			// Binary files have no ---/+++ lines, so start from the header's b/ path.
+			if i := strings.LastIndex(line, " b/"); i != -1 {
+				current.Path = line[i+len(" b/"):]
+			}
+		case current == nil:
+			continue
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+		case !inHunk:
+			// File headers. The old path is only used when the file was deleted (+++ /dev/null).
+			if p, ok := strings.CutPrefix(line, "+++ b/"); ok {
+				current.Path = p
+			} else if p, ok := strings.CutPrefix(line, "--- a/"); ok {
+				current.Path = p
+			}
+		case strings.HasPrefix(line, "+"):
+			current.Added++
+		case strings.HasPrefix(line, "-"):
+			current.Removed++
+		}
+	}
+	return files
+}
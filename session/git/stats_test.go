@@ -0,0 +1,55 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDiffFiles(t *testing.T) {
+	diff := `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@
+ package main
+-import "fmt"
++import (
++	"fmt"
++)
+diff --git a/db/migrations/001.sql b/db/migrations/001.sql
+new file mode 100644
+--- /dev/null
++++ b/db/migrations/001.sql
+@@ -0,0 +1,2 @@
++-- create users
++CREATE TABLE users (id int);
+diff --git a/old.md b/old.md
+deleted file mode 100644
+--- a/old.md
++++ /dev/null
+@@ -1 +0,0 @@
+---- a heading rule
+diff --git a/logo.png b/logo.png
+Binary files a/logo.png and b/logo.png differ
+`
+	files := ParseDiffFiles(diff)
+	require.Equal(t, []FileChange{
+		{Path: "main.go", Added: 3, Removed: 1},
+		{Path: "db/migrations/001.sql", Added: 2},
+		{Path: "old.md", Removed: 1},
+		{Path: "logo.png"},
+	}, files)
+
+	require.Equal(t, []Breakdown{
+		{Name: "Go", Added: 3, Removed: 1},
+		{Name: "SQL", Added: 2},
+		{Name: "Markdown", Removed: 1},
+		{Name: "Other"},
+	}, ByLanguage(files))
+	require.Equal(t, []Breakdown{
+		{Name: "./", Added: 3, Removed: 2},
+		{Name: "db/", Added: 2},
+	}, ByDirectory(files))
+	require.Equal(t, []string{"db/migrations/001.sql"}, MatchPaths(files, []string{"migrations/", ".github/workflows/"}))
+}
@@ -67,6 +67,11 @@ func IsGitRepo(path string) bool {
 	}
 }
 
+// HasBranch returns true if the repository at repoPath has the local branch.
+func HasBranch(repoPath, branch string) bool {
+	return exec.Command("git", "-C", repoPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch).Run() == nil
+}
+
 func findGitRepoRoot(path string) (string, error) {
 	currentPath := path
 	for {
@@ -84,3 +89,36 @@ func findGitRepoRoot(path string) (string, error) {
 		currentPath = parent
 	}
 }
+
+// networkErrorMessages are fragments of the messages git, ssh, gh and Go's HTTP client print when the remote
+// can't be reached.
+var networkErrorMessages = []string{
+	"could not resolve host",
+	"could not resolve hostname",
+	"temporary failure in name resolution",
+	"network is unreachable",
+	"connection timed out",
+	"operation timed out",
+	"connection refused",
+	"connection reset",
+	"failed to connect to",
+	"no route to host",
+	"no such host",
+	"i/o timeout",
+	"error connecting to api.github.com",
+}
+
+// IsNetworkError returns true if err looks like it was caused by the remote being unreachable, as
+// opposed to e.g. an authentication failure or a rejected push.
+func IsNetworkError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range networkErrorMessages {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
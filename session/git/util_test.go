@@ -1,6 +1,7 @@
 package git
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -71,3 +72,25 @@ func TestSanitizeBranchName(t *testing.T) {
 		})
 	}
 }
+
+func TestIsNetworkError(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{errors.New("failed to push branch: fatal: unable to access 'https://github.com/a/b/': Could not resolve host: github.com (exit status 128)"), true},
+		{errors.New("ssh: connect to host github.com port 22: Connection timed out"), true},
+		{errors.New("error connecting to api.github.com"), true},
+		{errors.New(`Post "https://api.linear.app/graphql": dial tcp: lookup api.linear.app: no such host`), true},
+		{&OpenError{Err: errors.New("failed to create pull request: error connecting to api.github.com")}, true},
+		{errors.New("failed to push branch: ! [rejected] main -> main (non-fast-forward)"), false},
+		{errors.New("Permission denied (publickey)."), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsNetworkError(tt.err); got != tt.expected {
+			t.Errorf("IsNetworkError(%v) = %v, want %v", tt.err, got, tt.expected)
+		}
+	}
+}
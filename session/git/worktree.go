@@ -9,12 +9,30 @@ import (
 )
 
 func getWorktreeDirectory() (string, error) {
-	configDir, err := config.GetConfigDir()
+	stateDir, err := config.GetStateDir()
 	if err != nil {
 		return "", err
 	}
 
-	return filepath.Join(configDir, "worktrees"), nil
+	return filepath.Join(stateDir, "worktrees"), nil
+}
+
+// repoSettings returns settings, or, if they weren't given, the settings in the RepoFileName of the repository
+// at repoPath.
+func repoSettings(settings *config.RepoSettings, repoPath string) *config.RepoSettings {
+	if settings != nil {
+		return settings
+	}
+	return config.GetRepoSettings(nil, repoPath)
+}
+
+// branchPrefix returns the prefix of the branches of instances of a repository with the given settings: the
+// repository's own, or else the global one.
+func branchPrefix(settings *config.RepoSettings) string {
+	if settings.BranchPrefix != "" {
+		return settings.BranchPrefix
+	}
+	return config.LoadConfig().BranchPrefix
 }
 
 // GitWorktree manages git worktree operations for a session
@@ -29,6 +47,23 @@ type GitWorktree struct {
 	branchName string
 	// Base commit hash for the worktree
 	baseCommitSHA string
+	// settings are the settings of the repository, or nil to read its RepoFileName when they're needed
+	settings *config.RepoSettings
+	// identity overrides the repository's commit identity for commits made in this worktree
+	identity config.CommitIdentity
+	// trailers are appended to commits made in this worktree
+	trailers []config.CommitTrailer
+	// sandboxed is true if the worktree is presented through a copy-on-write view
+	sandboxed bool
+	// adopted is true if the branch existed before the session, in which case it's kept on cleanup
+	adopted bool
+	// external is true if the worktree is a directory claude-squad didn't create, which is kept on cleanup
+	external bool
+	// upstream is the remote-tracking branch the local branch is created from when an adopted worktree is
+	// set up, if a remote-tracking branch was adopted
+	upstream string
+	// diff is the last result of Diff, reused while the worktree is unchanged
+	diff diffCache
 }
 
 func NewGitWorktreeFromStorage(repoPath string, worktreePath string, sessionName string, branchName string, baseCommitSHA string) *GitWorktree {
@@ -41,11 +76,11 @@ func NewGitWorktreeFromStorage(repoPath string, worktreePath string, sessionName
 	}
 }
 
-// NewGitWorktree creates a new GitWorktree instance
-func NewGitWorktree(repoPath string, sessionName string) (tree *GitWorktree, branchname string, err error) {
+// NewGitWorktree creates a new GitWorktree instance. settings are the repository's settings, e.g. those in the
+// state; if nil, its RepoFileName is read.
+func NewGitWorktree(repoPath string, sessionName string, settings *config.RepoSettings) (tree *GitWorktree, branchname string, err error) {
 	cfg := config.LoadConfig()
 	sanitizedName := sanitizeBranchName(sessionName)
-	branchName := fmt.Sprintf("%s%s", cfg.BranchPrefix, sanitizedName)
 
 	// Convert repoPath to absolute path
 	absPath, err := filepath.Abs(repoPath)
@@ -60,9 +95,14 @@ func NewGitWorktree(repoPath string, sessionName string) (tree *GitWorktree, bra
 		return nil, "", err
 	}
 
-	worktreeDir, err := getWorktreeDirectory()
-	if err != nil {
-		return nil, "", err
+	settings = repoSettings(settings, repoPath)
+	branchName := fmt.Sprintf("%s%s", branchPrefix(settings), sanitizedName)
+	worktreeDir := settings.GetWorktreeDir(repoPath)
+	if worktreeDir == "" {
+		worktreeDir, err = getWorktreeDirectory()
+		if err != nil {
+			return nil, "", err
+		}
 	}
 
 	worktreePath := filepath.Join(worktreeDir, sanitizedName)
@@ -73,9 +113,35 @@ func NewGitWorktree(repoPath string, sessionName string) (tree *GitWorktree, bra
 		sessionName:  sessionName,
 		branchName:   branchName,
 		worktreePath: worktreePath,
+		settings:     settings,
+		sandboxed:    cfg.Sandbox,
 	}, branchName, nil
 }
 
+// Rename renames the worktree's branch to match a new session name. The worktree stays where it is.
+func (g *GitWorktree) Rename(sessionName string) (string, error) {
+	g.settings = repoSettings(g.settings, g.repoPath)
+	branchName := branchPrefix(g.settings) + sanitizeBranchName(sessionName)
+	// Adopted branches were named by someone else.
+	if g.adopted {
+		branchName = g.branchName
+	}
+	if branchName != g.branchName {
+		if _, err := g.runGitCommand(g.worktreePath, "branch", "-m", g.branchName, branchName); err != nil {
+			return "", fmt.Errorf("failed to rename branch: %w", err)
+		}
+	}
+	g.sessionName = sessionName
+	g.branchName = branchName
+	return branchName, nil
+}
+
+// SetRepoSettings sets the settings of the worktree's repository, e.g. those in the state, which the branch is
+// named by when the worktree is renamed.
+func (g *GitWorktree) SetRepoSettings(settings *config.RepoSettings) {
+	g.settings = settings
+}
+
 // GetWorktreePath returns the path to the worktree
 func (g *GitWorktree) GetWorktreePath() string {
 	return g.worktreePath
@@ -96,6 +162,16 @@ func (g *GitWorktree) GetRepoName() string {
 	return filepath.Base(g.repoPath)
 }
 
+// SetCommitIdentity overrides the identity used for commits made in this worktree.
+func (g *GitWorktree) SetCommitIdentity(identity config.CommitIdentity) {
+	g.identity = identity
+}
+
+// SetCommitTrailers sets the trailers appended to commits made in this worktree.
+func (g *GitWorktree) SetCommitTrailers(trailers []config.CommitTrailer) {
+	g.trailers = trailers
+}
+
 // GetBaseCommitSHA returns the base commit SHA for the worktree
 func (g *GitWorktree) GetBaseCommitSHA() string {
 	return g.baseCommitSHA
@@ -1,8 +1,13 @@
 package git
 
 import (
+	"claude-squad/audit"
+	"claude-squad/config"
 	"claude-squad/log"
+	"claude-squad/network"
+	"claude-squad/policy"
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
 )
@@ -22,7 +27,24 @@ func (g *GitWorktree) runGitCommand(path string, args ...string) (string, error)
 
 // PushChanges commits and pushes changes in the worktree to the remote branch
 func (g *GitWorktree) PushChanges(commitMessage string, open bool) error {
-	if err := checkGHCLI(); err != nil {
+	if err := network.Check("pushing"); err != nil {
+		return err
+	}
+	push := config.LoadConfig().GetRepoConfig(g.repoPath).Push
+
+	// An explicit push configuration may point at any git host, so only the default flow needs gh.
+	if !push.IsExplicit() {
+		if err := checkGHCLI(); err != nil {
+			return err
+		}
+	}
+
+	p, err := policy.Load(g.repoPath)
+	if err != nil {
+		return err
+	}
+	if err := p.RunPreMergeChecks(g.worktreePath); err != nil {
+		log.ErrorLog.Print(err)
 		return err
 	}
 
@@ -40,36 +62,100 @@ func (g *GitWorktree) PushChanges(commitMessage string, open bool) error {
 		}
 
 		// Create commit
-		if _, err := g.runGitCommand(g.worktreePath, "commit", "-m", commitMessage, "--no-verify"); err != nil {
+		if err := g.commit(commitMessage); err != nil {
 			log.ErrorLog.Print(err)
 			return fmt.Errorf("failed to commit changes: %w", err)
 		}
 	}
 
+	if push.IsExplicit() {
+		if err := g.pushBranch(push); err != nil {
+			return resumeWith(err, func() error {
+				g.openPushedBranch(push, open)
+				return nil
+			})
+		}
+		g.openPushedBranch(push, open)
+		return nil
+	}
+
 	// First push the branch to remote to ensure it exists
 	pushCmd := exec.Command("gh", "repo", "sync", "--source", "-b", g.branchName)
 	pushCmd.Dir = g.worktreePath
+	pushCmd.Env = append(os.Environ(), noPromptEnv()...)
 	if err := pushCmd.Run(); err != nil {
 		// If sync fails, try creating the branch on remote first
-		gitPushCmd := exec.Command("git", "push", "-u", "origin", g.branchName)
-		gitPushCmd.Dir = g.worktreePath
-		if pushOutput, pushErr := gitPushCmd.CombinedOutput(); pushErr != nil {
-			log.ErrorLog.Print(pushErr)
-			return fmt.Errorf("failed to push branch: %s (%w)", pushOutput, pushErr)
+		if pushErr := g.pushBranch(push); pushErr != nil {
+			return resumeWith(pushErr, func() error { return g.syncBranch(open) })
+		}
+	}
+	return g.syncBranch(open)
+}
+
+// openPushedBranch opens the branch pushed with an explicit push configuration, if open is set. Through gh,
+// that's the pull request if CODEOWNERS suggests reviewers for it, like after a default push. The remote may
+// not be on GitHub though, or gh not be set up, in which case the branch's page on the remote's host is opened.
+func (g *GitWorktree) openPushedBranch(push config.PushConfig, open bool) {
+	if !open {
+		return
+	}
+	// gh finds the repository through its remotes, which a push URL isn't one of.
+	if push.PushURL == "" && checkGHCLI() == nil {
+		err := g.OpenOnGitHub()
+		if err == nil {
+			return
 		}
+		log.WarningLog.Printf("failed to open the branch through gh: %v", err)
 	}
 
-	// Now sync with remote
+	remoteURL := push.PushURL
+	if remoteURL == "" {
+		output, err := g.runGitCommand(g.worktreePath, "remote", "get-url", push.Remote)
+		if err != nil {
+			log.ErrorLog.Printf("failed to open branch URL: %v", err)
+			return
+		}
+		remoteURL = strings.TrimSpace(output)
+	}
+	url, ok := branchWebURL(remoteURL, g.branchName)
+	if !ok {
+		log.InfoLog.Printf("not opening %s: the web page of a branch on %s isn't known", g.branchName, remoteURL)
+		return
+	}
+	if err := openBrowser(url); err != nil {
+		log.ErrorLog.Printf("failed to open branch URL: %v", err)
+	}
+}
+
+// OpenOnGitHub opens the branch on GitHub in the browser, or creates and opens its pull request if CODEOWNERS
+// suggests reviewers for it.
+func (g *GitWorktree) OpenOnGitHub() error {
+	ownership, err := g.Ownership()
+	if err != nil {
+		log.ErrorLog.Printf("failed to read code owners: %v", err)
+	}
+	if reviewers := Reviewers(ownership); len(reviewers) > 0 {
+		return g.openPullRequest(reviewers)
+	}
+	return g.OpenBranchURL()
+}
+
+// syncBranch syncs the pushed branch with the remote through gh and, if open is set, opens it on GitHub.
+func (g *GitWorktree) syncBranch(open bool) error {
 	syncCmd := exec.Command("gh", "repo", "sync", "-b", g.branchName)
 	syncCmd.Dir = g.worktreePath
+	syncCmd.Env = append(os.Environ(), noPromptEnv()...)
 	if output, err := syncCmd.CombinedOutput(); err != nil {
 		log.ErrorLog.Print(err)
 		return fmt.Errorf("failed to sync changes: %s (%w)", output, err)
 	}
 
-	// Open the branch in the browser
 	if open {
-		if err := g.OpenBranchURL(); err != nil {
+		if err := g.OpenOnGitHub(); err != nil {
+			// The pull request can be created once the network is back.
+			if IsNetworkError(err) {
+				return &OpenError{Err: err}
+			}
 			// Just log the error but don't fail the push operation
 			log.ErrorLog.Printf("failed to open branch URL: %v", err)
 		}
@@ -78,6 +164,58 @@ func (g *GitWorktree) PushChanges(commitMessage string, open bool) error {
 	return nil
 }
 
+// OpenError is returned by PushChanges when the branch was pushed, but opening it on GitHub, or creating its
+// pull request, failed because the network was unavailable. Retry it with OpenOnGitHub.
+type OpenError struct {
+	Err error
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("pushed, but failed to open the branch: %v", e.Err)
+}
+
+func (e *OpenError) Unwrap() error {
+	return e.Err
+}
+
+// pushBranch pushes the worktree's branch using the given push configuration. It returns a
+// *CredentialsRequiredError if the push needs credentials that have to be entered.
+func (g *GitWorktree) pushBranch(push config.PushConfig) error {
+	args := pushArgs(push, g.branchName)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = g.worktreePath
+	cmd.Env = append(append(os.Environ(), pushEnv(push)...), noPromptEnv()...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		log.ErrorLog.Print(err)
+		if needsCredentials(string(output)) {
+			return &CredentialsRequiredError{Output: string(output), args: args, dir: g.worktreePath, env: pushEnv(push)}
+		}
+		return fmt.Errorf("failed to push branch: %s (%w)", output, err)
+	}
+	return nil
+}
+
+// pushArgs returns the git arguments to push branch according to push.
+func pushArgs(push config.PushConfig, branch string) []string {
+	var args []string
+	if push.CredentialHelper != "" {
+		// The empty helper resets any helpers inherited from the user's git config.
+		args = append(args, "-c", "credential.helper=", "-c", "credential.helper="+push.CredentialHelper)
+	}
+	if push.PushURL != "" {
+		return append(args, "push", push.PushURL, branch)
+	}
+	return append(args, "push", "-u", push.Remote, branch)
+}
+
+// pushEnv returns the extra environment needed to authenticate a push.
+func pushEnv(push config.PushConfig) []string {
+	if push.SSHKey == "" {
+		return nil
+	}
+	return []string{fmt.Sprintf("GIT_SSH_COMMAND=ssh -i '%s' -o IdentitiesOnly=yes", push.SSHKey)}
+}
+
 // CommitChanges commits changes locally without pushing to remote
 func (g *GitWorktree) CommitChanges(commitMessage string) error {
 	// Check if there are any changes to commit
@@ -94,7 +232,7 @@ func (g *GitWorktree) CommitChanges(commitMessage string) error {
 		}
 
 		// Create commit (local only)
-		if _, err := g.runGitCommand(g.worktreePath, "commit", "-m", commitMessage, "--no-verify"); err != nil {
+		if err := g.commit(commitMessage); err != nil {
 			log.ErrorLog.Print(err)
 			return fmt.Errorf("failed to commit changes: %w", err)
 		}
@@ -103,6 +241,143 @@ func (g *GitWorktree) CommitChanges(commitMessage string) error {
 	return nil
 }
 
+// CommitError is returned when git refuses to create a commit, most commonly because a hook rejected it.
+type CommitError struct {
+	// Output is the combined output of git and any hooks that ran.
+	Output string
+	Err    error
+}
+
+func (e *CommitError) Error() string {
+	return fmt.Sprintf("commit failed: %v", e.Err)
+}
+
+func (e *CommitError) Unwrap() error {
+	return e.Err
+}
+
+// commitOptions controls how commitArgs builds a commit command.
+type commitOptions struct {
+	identity  config.CommitIdentity
+	trailers  []config.CommitTrailer
+	skipHooks bool
+}
+
+// commit creates a commit of the staged changes using the worktree's commit identity. The repository's
+// hooks run unless the repository is configured to skip them.
+func (g *GitWorktree) commit(commitMessage string) error {
+	repoCfg := config.LoadConfig().GetRepoConfig(g.repoPath)
+	opts := commitOptions{
+		identity:  g.commitIdentity(repoCfg),
+		trailers:  g.trailers,
+		skipHooks: repoCfg.SkipHooks,
+	}
+
+	cmd := exec.Command("git", append([]string{"-C", g.worktreePath}, commitArgs(opts, commitMessage)...)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return &CommitError{Output: string(output), Err: err}
+	}
+
+	if opts.skipHooks {
+		audit.Record(audit.Entry{
+			Action:     "commit_hooks_skipped",
+			Instance:   g.sessionName,
+			Repository: g.repoPath,
+			Detail:     fmt.Sprintf("branch %s: %s", g.branchName, commitMessage),
+		})
+	} else {
+		log.InfoLog.Printf("committed on %s:\n%s", g.branchName, output)
+	}
+	return nil
+}
+
+// commitIdentity returns the repository's configured identity, with the fields set on this worktree overriding
+// its own.
+func (g *GitWorktree) commitIdentity(repoCfg config.RepoConfig) config.CommitIdentity {
+	return repoCfg.Identity.Merge(g.identity)
+}
+
+// commitArgs returns the git arguments to commit with the given options and message.
+func commitArgs(opts commitOptions, commitMessage string) []string {
+	var args []string
+	identity := opts.identity
+	if identity.Name != "" {
+		args = append(args, "-c", "user.name="+identity.Name)
+	}
+	if identity.Email != "" {
+		args = append(args, "-c", "user.email="+identity.Email)
+	}
+	if identity.SigningKey != "" {
+		args = append(args, "-c", "user.signingkey="+identity.SigningKey)
+		if identity.SigningFormat != "" {
+			args = append(args, "-c", "gpg.format="+identity.SigningFormat)
+		}
+	}
+	args = append(args, "commit", "-m", commitMessage)
+	if opts.skipHooks {
+		args = append(args, "--no-verify")
+	}
+	for _, trailer := range opts.trailers {
+		args = append(args, "--trailer", fmt.Sprintf("%s: %s", trailer.Key, trailer.Value))
+	}
+	if identity.SigningKey != "" {
+		args = append(args, "-S")
+	}
+	return args
+}
+
+// CommitInfo describes a commit on the worktree's branch.
+type CommitInfo struct {
+	// SHA is the abbreviated commit hash.
+	SHA string
+	// Subject is the first line of the commit message.
+	Subject string
+	// AgentAuthored is true if the commit carries one of the worktree's commit trailers, with its value.
+	AgentAuthored bool
+}
+
+// BranchCommits returns the commits made on the branch since the base commit, newest first.
+func (g *GitWorktree) BranchCommits() ([]CommitInfo, error) {
+	if g.baseCommitSHA == "" {
+		return nil, fmt.Errorf("base commit SHA not set")
+	}
+	output, err := g.runGitCommand(g.worktreePath, "--no-pager", "log",
+		"--format=%h%x1f%s%x1f%(trailers:only,unfold)%x1e", g.baseCommitSHA+"..HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return parseBranchCommits(output, g.trailers), nil
+}
+
+// parseBranchCommits parses the output of the git log format used by BranchCommits.
+func parseBranchCommits(output string, trailers []config.CommitTrailer) []CommitInfo {
+	var commits []CommitInfo
+	for _, record := range strings.Split(output, "\x1e") {
+		fields := strings.SplitN(strings.TrimSpace(record), "\x1f", 3)
+		if len(fields) < 2 {
+			continue
+		}
+		commit := CommitInfo{SHA: fields[0], Subject: fields[1]}
+		if len(fields) == 3 {
+			for _, line := range strings.Split(fields[2], "\n") {
+				key, value, ok := strings.Cut(line, ":")
+				if !ok {
+					continue
+				}
+				// Keys like Co-Authored-By are also used by people, so the value has to match too.
+				for _, trailer := range trailers {
+					if strings.EqualFold(strings.TrimSpace(key), trailer.Key) && strings.TrimSpace(value) == trailer.Value {
+						commit.AgentAuthored = true
+					}
+				}
+			}
+		}
+		commits = append(commits, commit)
+	}
+	return commits
+}
+
 // IsDirty checks if the worktree has uncommitted changes
 func (g *GitWorktree) IsDirty() (bool, error) {
 	output, err := g.runGitCommand(g.worktreePath, "status", "--porcelain")
@@ -123,6 +398,9 @@ func (g *GitWorktree) IsBranchCheckedOut() (bool, error) {
 
 // OpenBranchURL opens the branch URL in the default browser
 func (g *GitWorktree) OpenBranchURL() error {
+	if err := network.Check("opening branches on GitHub"); err != nil {
+		return err
+	}
 	// Check if GitHub CLI is available
 	if err := checkGHCLI(); err != nil {
 		return err
@@ -0,0 +1,157 @@
+package git
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session/git/gittest"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		push     config.PushConfig
+		expected []string
+	}{
+		{
+			name:     "default remote",
+			push:     config.PushConfig{Remote: "origin"},
+			expected: []string{"push", "-u", "origin", "feature"},
+		},
+		{
+			name:     "custom remote",
+			push:     config.PushConfig{Remote: "work"},
+			expected: []string{"push", "-u", "work", "feature"},
+		},
+		{
+			name:     "push url overrides remote",
+			push:     config.PushConfig{Remote: "origin", PushURL: "git@example.com:me/repo.git"},
+			expected: []string{"push", "git@example.com:me/repo.git", "feature"},
+		},
+		{
+			name: "credential helper",
+			push: config.PushConfig{Remote: "origin", CredentialHelper: "store --file ~/.work-creds"},
+			expected: []string{"-c", "credential.helper=", "-c", "credential.helper=store --file ~/.work-creds",
+				"push", "-u", "origin", "feature"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, pushArgs(tt.push, "feature"))
+		})
+	}
+}
+
+func TestPushEnv(t *testing.T) {
+	require.Empty(t, pushEnv(config.PushConfig{Remote: "origin"}))
+	require.Equal(t, []string{"GIT_SSH_COMMAND=ssh -i '/home/me/.ssh/work' -o IdentitiesOnly=yes"},
+		pushEnv(config.PushConfig{SSHKey: "/home/me/.ssh/work"}))
+}
+
+func TestCommitArgs(t *testing.T) {
+	require.Equal(t, []string{"commit", "-m", "msg"},
+		commitArgs(commitOptions{}, "msg"))
+
+	require.Equal(t, []string{"commit", "-m", "msg", "--no-verify"},
+		commitArgs(commitOptions{skipHooks: true}, "msg"))
+
+	require.Equal(t, []string{"-c", "user.name=Agent", "-c", "user.email=agent@example.com",
+		"commit", "-m", "msg"},
+		commitArgs(commitOptions{identity: config.CommitIdentity{Name: "Agent", Email: "agent@example.com"}}, "msg"))
+
+	require.Equal(t, []string{"-c", "user.signingkey=~/.ssh/id_ed25519.pub", "-c", "gpg.format=ssh",
+		"commit", "-m", "msg", "-S"},
+		commitArgs(commitOptions{identity: config.CommitIdentity{SigningKey: "~/.ssh/id_ed25519.pub", SigningFormat: "ssh"}}, "msg"))
+}
+
+func TestCommitArgsTrailers(t *testing.T) {
+	trailers := []config.CommitTrailer{{Key: "Agent", Value: "claude"}, {Key: "Session-ID", Value: "claudesquad_x"}}
+	require.Equal(t, []string{"commit", "-m", "msg",
+		"--trailer", "Agent: claude", "--trailer", "Session-ID: claudesquad_x"},
+		commitArgs(commitOptions{trailers: trailers}, "msg"))
+}
+
+func TestParseBranchCommits(t *testing.T) {
+	trailers := []config.CommitTrailer{
+		{Key: "Co-Authored-By", Value: "Claude <noreply@anthropic.com>"},
+		{Key: "Agent", Value: "claude"},
+	}
+	output := "abc1234\x1fagent change\x1fAgent: claude\nSession-ID: x\n\x1e\n" +
+		"def5678\x1fhuman change\x1f\x1e\n" +
+		"0123abc\x1fpaired change\x1fCo-authored-by: Jane Doe <jane@example.com>\n\x1e\n" +
+		"4567def\x1fco-authored change\x1fCo-Authored-By: Claude <noreply@anthropic.com>\n\x1e\n"
+
+	commits := parseBranchCommits(output, trailers)
+	require.Equal(t, []CommitInfo{
+		{SHA: "abc1234", Subject: "agent change", AgentAuthored: true},
+		{SHA: "def5678", Subject: "human change"},
+		{SHA: "0123abc", Subject: "paired change"},
+		{SHA: "4567def", Subject: "co-authored change", AgentAuthored: true},
+	}, commits)
+}
+
+func TestPushBranchCredentialsRequired(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	// The remote asks for credentials, which there's no helper for.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="git"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	r := gittest.New(t)
+	r.Run("commit", "-q", "--allow-empty", "-m", "init")
+	repo := r.Dir
+
+	g := &GitWorktree{worktreePath: repo, branchName: "main"}
+	err := g.pushBranch(config.PushConfig{PushURL: server.URL + "/repo.git"})
+	var credErr *CredentialsRequiredError
+	require.ErrorAs(t, err, &credErr)
+	require.Contains(t, credErr.Prompt(), "could not read Username")
+
+	cmd := credErr.Command()
+	require.Equal(t, []string{"git", "push", server.URL + "/repo.git", "main"}, cmd.Args)
+	require.Equal(t, repo, cmd.Dir)
+
+	// The rest of the push is resumed once the command succeeded in the terminal.
+	resumed := false
+	require.Same(t, credErr, resumeWith(err, func() error {
+		resumed = true
+		return nil
+	}))
+	require.NoError(t, credErr.Resume())
+	require.True(t, resumed)
+}
+
+func TestCommitWithInstanceIdentity(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	r := gittest.New(t)
+	r.Run("commit", "-q", "--allow-empty", "-m", "init")
+	repo := r.Dir
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), 0644))
+
+	identity, err := config.ParseCommitIdentity("Review Bot <bot@example.com>")
+	require.NoError(t, err)
+	g := &GitWorktree{repoPath: repo, worktreePath: repo, branchName: "main"}
+	g.SetCommitIdentity(identity)
+	require.NoError(t, g.CommitChanges("add main"))
+
+	output := r.Run("log", "-1", "--format=%an <%ae>|%cn <%ce>")
+	require.Equal(t, "Review Bot <bot@example.com>|Review Bot <bot@example.com>", strings.TrimSpace(output))
+}
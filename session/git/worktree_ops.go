@@ -12,8 +12,34 @@ import (
 	"github.com/go-git/go-git/v5/plumbing"
 )
 
-// Setup creates a new worktree for the session
+// Setup creates a new worktree for the session. Sandboxed worktrees are then presented through a
+// copy-on-write view.
 func (g *GitWorktree) Setup() error {
+	if err := g.setup(); err != nil {
+		return err
+	}
+	if g.sandboxed {
+		if err := g.setupSandbox(); err != nil {
+			_ = g.discardSandbox()
+			_ = g.Prune()
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *GitWorktree) setup() error {
+	if g.adopted {
+		rev := g.branchName
+		if g.upstream != "" {
+			rev = g.upstream
+		}
+		if err := g.checkDiskSpace(rev); err != nil {
+			return err
+		}
+		return g.setupAdopted()
+	}
+
 	// Check if branch exists first
 	repo, err := git.PlainOpen(g.repoPath)
 	if err != nil {
@@ -23,10 +49,16 @@ func (g *GitWorktree) Setup() error {
 	branchRef := plumbing.NewBranchReferenceName(g.branchName)
 	if _, err := repo.Reference(branchRef, false); err == nil {
 		// Branch exists, use SetupFromExistingBranch
+		if err := g.checkDiskSpace(g.branchName); err != nil {
+			return err
+		}
 		return g.SetupFromExistingBranch()
 	}
 
 	// Branch doesn't exist, create new worktree from HEAD
+	if err := g.checkDiskSpace("HEAD"); err != nil {
+		return err
+	}
 	return g.SetupNewWorktree()
 }
 
@@ -99,7 +131,13 @@ func (g *GitWorktree) Cleanup() error {
 	var errs []error
 
 	// Check if worktree path exists before attempting removal
-	if _, err := os.Stat(g.worktreePath); err == nil {
+	if g.external {
+		// The directory isn't claude-squad's to remove.
+	} else if g.sandboxed {
+		if err := g.removeSandbox(); err != nil {
+			errs = append(errs, err)
+		}
+	} else if _, err := os.Stat(g.worktreePath); err == nil {
 		// Remove the worktree using git command
 		if _, err := g.runGitCommand(g.repoPath, "worktree", "remove", "-f", g.worktreePath); err != nil {
 			errs = append(errs, err)
@@ -118,13 +156,15 @@ func (g *GitWorktree) Cleanup() error {
 
 	branchRef := plumbing.NewBranchReferenceName(g.branchName)
 
-	// Check if branch exists before attempting removal
-	if _, err := repo.Reference(branchRef, false); err == nil {
-		if err := repo.Storer.RemoveReference(branchRef); err != nil {
-			errs = append(errs, fmt.Errorf("failed to remove branch %s: %w", g.branchName, err))
+	// Check if branch exists before attempting removal. Adopted branches are kept.
+	if !g.adopted {
+		if _, err := repo.Reference(branchRef, false); err == nil {
+			if err := repo.Storer.RemoveReference(branchRef); err != nil {
+				errs = append(errs, fmt.Errorf("failed to remove branch %s: %w", g.branchName, err))
+			}
+		} else if err != plumbing.ErrReferenceNotFound {
+			errs = append(errs, fmt.Errorf("error checking branch %s existence: %w", g.branchName, err))
 		}
-	} else if err != plumbing.ErrReferenceNotFound {
-		errs = append(errs, fmt.Errorf("error checking branch %s existence: %w", g.branchName, err))
 	}
 
 	// Prune the worktree to clean up any remaining references
@@ -141,6 +181,13 @@ func (g *GitWorktree) Cleanup() error {
 
 // Remove removes the worktree but keeps the branch
 func (g *GitWorktree) Remove() error {
+	if g.external {
+		return fmt.Errorf("%s wasn't created by claude-squad, so it isn't removed", g.worktreePath)
+	}
+	if g.sandboxed {
+		// The instance's writes are discarded along with the view; Prune drops the worktree metadata.
+		return g.removeSandbox()
+	}
 	// Remove the worktree using git command
 	if _, err := g.runGitCommand(g.repoPath, "worktree", "remove", "-f", g.worktreePath); err != nil {
 		return fmt.Errorf("failed to remove worktree: %w", err)
@@ -157,8 +204,9 @@ func (g *GitWorktree) Prune() error {
 	return nil
 }
 
-// CleanupWorktrees removes all worktrees and their associated branches
-func CleanupWorktrees() error {
+// CleanupWorktrees removes all worktrees and their associated branches, except the branches in keep, which
+// instances adopted rather than created.
+func CleanupWorktrees(keep map[string]bool) error {
 	worktreesDir, err := getWorktreeDirectory()
 	if err != nil {
 		return fmt.Errorf("failed to get worktree directory: %w", err)
@@ -200,6 +248,9 @@ func CleanupWorktrees() error {
 			// Delete the branch associated with this worktree if found
 			for path, branch := range worktreeBranches {
 				if strings.Contains(path, entry.Name()) {
+					if keep[branch] {
+						break
+					}
 					// Delete the branch
 					deleteCmd := exec.Command("git", "branch", "-D", branch)
 					if err := deleteCmd.Run(); err != nil {
@@ -0,0 +1,36 @@
+package git
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session/git/gittest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGitWorktreeRepoSettings(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	repo := gittest.New(t).Dir
+	require.NoError(t, os.WriteFile(filepath.Join(repo, config.RepoFileName), []byte(
+		"branch_prefix: file/\nworktree_dir: ../file-worktrees\n"), 0644))
+
+	// Without settings, the repository's file is read.
+	tree, branch, err := NewGitWorktree(repo, "task", nil)
+	require.NoError(t, err)
+	require.Equal(t, "file/task", branch)
+	require.Equal(t, filepath.Join(filepath.Dir(repo), "file-worktrees"), filepath.Dir(tree.GetWorktreePath()))
+
+	// The settings given, e.g. those in the state, are used instead.
+	settings := &config.RepoSettings{BranchPrefix: "state/"}
+	tree, branch, err = NewGitWorktree(repo, "task", settings)
+	require.NoError(t, err)
+	require.Equal(t, "state/task", branch)
+	require.NotEqual(t, filepath.Join(filepath.Dir(repo), "file-worktrees"), filepath.Dir(tree.GetWorktreePath()))
+
+}
@@ -3,12 +3,15 @@ package session
 import (
 	"claude-squad/config"
 	"claude-squad/log"
+	"claude-squad/policy"
 	"claude-squad/session/git"
 	"claude-squad/session/tmux"
+	"claude-squad/telemetry"
 	"path/filepath"
 
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -26,8 +29,40 @@ const (
 	Loading
 	// Paused is if the instance is paused (worktree removed but branch preserved).
 	Paused
+	// Stalled is if the instance is working but hasn't produced any output for a while.
+	Stalled
+	// Error is if killing the instance failed, e.g. because its programs outlived its tmux session. It can be
+	// force-killed.
+	Error
+	// Stopped is if the instance's agent was killed but its worktree and branch were kept, to be resumed or purged.
+	Stopped
+	// OverBudget is if the instance ran or spent more than its budget allows and was interrupted.
+	OverBudget
 )
 
+func (s Status) String() string {
+	switch s {
+	case Running:
+		return "running"
+	case Ready:
+		return "ready"
+	case Loading:
+		return "loading"
+	case Paused:
+		return "paused"
+	case Stalled:
+		return "stalled"
+	case Error:
+		return "error"
+	case Stopped:
+		return "stopped"
+	case OverBudget:
+		return "over budget"
+	default:
+		return "unknown"
+	}
+}
+
 // Instance is a running instance of claude code.
 type Instance struct {
 	// Title is the title of the instance.
@@ -48,27 +83,91 @@ type Instance struct {
 	CreatedAt time.Time
 	// UpdatedAt is the time the instance was last updated.
 	UpdatedAt time.Time
+	// StartedAt is the time the instance's session was last started or resumed.
+	StartedAt time.Time
+	// LastActivity is the time the instance's output last changed.
+	LastActivity time.Time
 	// AutoYes is true if the instance should automatically press enter when prompted.
 	AutoYes bool
-	// Prompt is the initial prompt to pass to the instance on startup
+	// Prompt is the initial prompt to pass to the instance on startup, or the first prompt sent to it
 	Prompt string
 	// RepositoryPath is the absolute path to the repository root this instance belongs to
 	RepositoryPath string
+	// CommitIdentity overrides the repository's commit identity for commits made by this instance
+	CommitIdentity config.CommitIdentity
+	// AutoTitled is true once the instance's placeholder title has been replaced automatically.
+	AutoTitled bool
+	// Plan is the plan written by the agent in the plan/approve workflow, as edited by the user.
+	Plan string
+	// PlanState is where the instance is in the plan/approve workflow.
+	PlanState PlanState
+	// Summary is the agent's summary of what it changed and what's left to do.
+	Summary string
+	// Database is the state of the instance's own copy of the development database, if the repository
+	// has database hooks configured.
+	Database DatabaseState
+	// Muted silences the audible alerts for the instance's state changes.
+	Muted bool
+	// SnoozedUntil is when the instance's snooze ends. While snoozed, it raises no alerts or notifications.
+	SnoozedUntil time.Time
+	// Pane is the index of the pane of the instance's tmux window that's previewed and sent prompts from
+	// the prompt overlay. 0 is the agent's pane; the others are side panes.
+	Pane int
+	// MergedAt is when the instance's branch was found merged into the repository's default branch, if it
+	// has been.
+	MergedAt time.Time
+	// Ticket is the key of the issue tracker ticket the instance was created from, e.g. "ENG-123".
+	Ticket string
+	// Task is the name of the task from the task library the instance was started from, if any.
+	Task string
+	// Budget limits how long the instance runs and how much its agent spends.
+	Budget Budget
+	// Cost is the highest cost, in dollars, the instance's agent was seen printing. It's only tracked while
+	// the budget limits the cost.
+	Cost float64
+	// OverBudgetAt is when the instance went over its budget, if it has since its budget was last set.
+	OverBudgetAt time.Time
 
 	// DiffStats stores the current git diff statistics
 	diffStats *git.DiffStats
+	// diffFile is the stored diff the content of diffStats is loaded from when it's first needed.
+	diffFile string
+	// adoptBranch is the existing branch the instance checks out when it's first started, if any.
+	adoptBranch string
+	// repoSettings are the settings of the instance's repository, or nil to read its settings file.
+	repoSettings *config.RepoSettings
 
 	// The below fields are initialized upon calling Start().
 
 	started bool
+	// dormant is set while a lazily restored instance's tmux session hasn't been restored. See Wake.
+	dormant bool
+	// wakeErr is why waking the instance failed. It isn't retried.
+	wakeErr error
 	// tmuxSession is the tmux session for the instance.
 	tmuxSession *tmux.TmuxSession
+	// windowSize overrides the preview size for the instance's program.
+	windowSize config.WindowSize
 	// gitWorktree is the git worktree for the instance.
 	gitWorktree *git.GitWorktree
+	// policy is the organization policy in effect for the instance's repository.
+	policy *policy.Policy
+	// summaryPending is set while the agent is writing a summary that hasn't been captured yet.
+	summaryPending bool
+	// outputBaselined is set once the first output after starting has been observed. That first
+	// observation isn't activity, just the monitor catching up with the pane.
+	outputBaselined bool
 }
 
 // ToInstanceData converts an Instance to its serializable form
 func (i *Instance) ToInstanceData() InstanceData {
+	i.loadDiffContent()
+	return i.toInstanceData()
+}
+
+// toInstanceData converts an Instance to its serializable form without loading its stored diff, which is
+// referred to by file instead.
+func (i *Instance) toInstanceData() InstanceData {
 	data := InstanceData{
 		Title:          i.Title,
 		Path:           i.Path,
@@ -77,10 +176,27 @@ func (i *Instance) ToInstanceData() InstanceData {
 		Height:         i.Height,
 		Width:          i.Width,
 		CreatedAt:      i.CreatedAt,
-		UpdatedAt:      time.Now(),
+		UpdatedAt:      i.UpdatedAt,
+		StartedAt:      i.StartedAt,
+		LastActivity:   i.LastActivity,
 		Program:        i.Program,
 		AutoYes:        i.AutoYes,
 		RepositoryPath: i.RepositoryPath,
+		CommitIdentity: i.CommitIdentity,
+		AutoTitled:     i.AutoTitled,
+		Plan:           i.Plan,
+		PlanState:      i.PlanState,
+		Summary:        i.Summary,
+		Database:       i.Database,
+		Muted:          i.Muted,
+		SnoozedUntil:   i.SnoozedUntil,
+		Pane:           i.Pane,
+		MergedAt:       i.MergedAt,
+		Ticket:         i.Ticket,
+		Task:           i.Task,
+		Budget:         i.Budget,
+		Cost:           i.Cost,
+		OverBudgetAt:   i.OverBudgetAt,
 	}
 	
 	// If RepositoryPath is not set but we have gitWorktree, derive it from RepoPath
@@ -96,6 +212,9 @@ func (i *Instance) ToInstanceData() InstanceData {
 			SessionName:   i.Title,
 			BranchName:    i.gitWorktree.GetBranchName(),
 			BaseCommitSHA: i.gitWorktree.GetBaseCommitSHA(),
+			Sandboxed:     i.gitWorktree.IsSandboxed(),
+			Adopted:       i.gitWorktree.IsAdopted(),
+			External:      i.gitWorktree.IsExternal(),
 		}
 		
 		// Ensure RepositoryPath is set from gitWorktree if not already set
@@ -109,7 +228,8 @@ func (i *Instance) ToInstanceData() InstanceData {
 		data.DiffStats = DiffStatsData{
 			Added:   i.diffStats.Added,
 			Removed: i.diffStats.Removed,
-			Content: i.diffStats.Content,
+			Content: redactOutput(i.diffStats.Content),
+			File:    i.diffFile,
 		}
 	}
 
@@ -118,6 +238,12 @@ func (i *Instance) ToInstanceData() InstanceData {
 
 // FromInstanceData creates a new Instance from serialized data
 func FromInstanceData(data InstanceData) (*Instance, error) {
+	return fromInstanceData(data, false)
+}
+
+// fromInstanceData creates a new Instance from serialized data. If lazy is set, an instance that isn't
+// paused is left dormant instead of restoring its tmux session.
+func fromInstanceData(data InstanceData, lazy bool) (*Instance, error) {
 	instance := &Instance{
 		Title:          data.Title,
 		Path:           data.Path,
@@ -127,9 +253,26 @@ func FromInstanceData(data InstanceData) (*Instance, error) {
 		Width:          data.Width,
 		CreatedAt:      data.CreatedAt,
 		UpdatedAt:      data.UpdatedAt,
+		StartedAt:      data.StartedAt,
+		LastActivity:   data.LastActivity,
 		Program:        data.Program,
 		AutoYes:        data.AutoYes,
 		RepositoryPath: data.RepositoryPath,
+		CommitIdentity: data.CommitIdentity,
+		AutoTitled:     data.AutoTitled,
+		Plan:           data.Plan,
+		PlanState:      data.PlanState,
+		Summary:        data.Summary,
+		Database:       data.Database,
+		Muted:          data.Muted,
+		SnoozedUntil:   data.SnoozedUntil,
+		Pane:           data.Pane,
+		MergedAt:       data.MergedAt,
+		Ticket:         data.Ticket,
+		Task:           data.Task,
+		Budget:         data.Budget,
+		Cost:           data.Cost,
+		OverBudgetAt:   data.OverBudgetAt,
 		gitWorktree: git.NewGitWorktreeFromStorage(
 			data.Worktree.RepoPath,
 			data.Worktree.WorktreePath,
@@ -141,12 +284,27 @@ func FromInstanceData(data InstanceData) (*Instance, error) {
 			Added:   data.DiffStats.Added,
 			Removed: data.DiffStats.Removed,
 			Content: data.DiffStats.Content,
+			Files:   git.ParseDiffFiles(data.DiffStats.Content),
 		},
 	}
+	if data.DiffStats.Content == "" {
+		instance.diffFile = data.DiffStats.File
+	}
+	flagSensitive(instance.diffStats)
+
+	instance.gitWorktree.SetSandboxed(data.Worktree.Sandboxed)
+	instance.gitWorktree.SetAdopted(data.Worktree.Adopted)
+	instance.gitWorktree.SetExternal(data.Worktree.External)
 
-	if instance.Paused() {
+	// Instances that failed to be killed aren't restarted: what's left of them is only waiting to be killed.
+	if instance.Inactive() || instance.Status == Error || lazy {
 		instance.started = true
-		instance.tmuxSession = tmux.NewTmuxSession(instance.Title, instance.Program)
+		instance.dormant = !instance.Inactive() && instance.Status != Error
+		instance.tmuxSession = instance.newTmuxSession()
+		instance.configureCommits()
+		if err := instance.loadPolicy(); err != nil {
+			log.ErrorLog.Printf("%v", err)
+		}
 	} else {
 		if err := instance.Start(false); err != nil {
 			return nil, err
@@ -166,6 +324,16 @@ type InstanceOptions struct {
 	Program string
 	// If AutoYes is true, then
 	AutoYes bool
+	// CommitIdentity optionally overrides the repository's commit identity for this instance.
+	CommitIdentity config.CommitIdentity
+	// Branch is an existing branch to check out instead of creating one, e.g. origin/feature. The branch is
+	// kept when the instance is killed.
+	Branch string
+	// Budget limits how long the instance runs and how much its agent spends.
+	Budget Budget
+	// RepoSettings are the settings of the instance's repository, as kept in the state. If nil, the
+	// repository's settings file is read.
+	RepoSettings *config.RepoSettings
 }
 
 func NewInstance(opts InstanceOptions) (*Instance, error) {
@@ -196,9 +364,46 @@ func NewInstance(opts InstanceOptions) (*Instance, error) {
 		UpdatedAt:      t,
 		AutoYes:        false,
 		RepositoryPath: repoPath,
+		CommitIdentity: opts.CommitIdentity,
+		Branch:         opts.Branch,
+		Budget:         opts.Budget,
+		adoptBranch:    opts.Branch,
+		repoSettings:   opts.RepoSettings,
 	}, nil
 }
 
+// RepoPath returns the path of the repository the instance works in, or "" if it isn't known yet.
+func (i *Instance) RepoPath() string {
+	if i.started && i.gitWorktree != nil {
+		return i.gitWorktree.GetRepoPath()
+	}
+	return i.RepositoryPath
+}
+
+// SetRepoSettings sets the settings of the instance's repository, e.g. those in the state, which its worktree
+// and branch are named by.
+func (i *Instance) SetRepoSettings(settings *config.RepoSettings) {
+	i.repoSettings = settings
+	if i.gitWorktree != nil {
+		i.gitWorktree.SetRepoSettings(settings)
+	}
+}
+
+// SetTmuxSession sets the instance's tmux session and marks it as started, e.g. to drive its status with a
+// session that doesn't run tmux in tests.
+func (i *Instance) SetTmuxSession(session *tmux.TmuxSession) {
+	i.tmuxSession = session
+	i.started = true
+}
+
+// TmuxSessionName returns the name of the instance's tmux session, e.g. to attach to it outside claude-squad.
+func (i *Instance) TmuxSessionName() string {
+	if i.tmuxSession == nil {
+		return ""
+	}
+	return i.tmuxSession.Name()
+}
+
 func (i *Instance) RepoName() (string, error) {
 	if !i.started {
 		return "", fmt.Errorf("cannot get repo name for instance that has not been started")
@@ -207,20 +412,53 @@ func (i *Instance) RepoName() (string, error) {
 }
 
 func (i *Instance) SetStatus(status Status) {
+	if i.Status != status {
+		i.UpdatedAt = time.Now()
+	}
 	i.Status = status
 }
 
+// newTmuxSession creates the instance's tmux session with the configured tmux options and the side panes
+// configured for its repository.
+func (i *Instance) newTmuxSession() *tmux.TmuxSession {
+	cfg := config.LoadConfig()
+	session := tmux.NewTmuxSession(i.Title, i.Program)
+	session.SetOptions(cfg.TmuxOptions)
+	var panes []tmux.Pane
+	for _, pane := range cfg.GetRepoConfig(i.gitWorktree.GetRepoPath()).Panes {
+		panes = append(panes, tmux.Pane{Command: pane.Command, Size: pane.Size})
+	}
+	session.SetPanes(panes)
+	i.windowSize = cfg.GetWindowSize(i.Program)
+	return session
+}
+
+// newGitWorktree creates the instance's worktree, which checks out the branch the instance adopts, if any.
+func (i *Instance) newGitWorktree() (*git.GitWorktree, string, error) {
+	if i.adoptBranch != "" {
+		return git.NewGitWorktreeFromBranch(i.Path, i.Title, i.adoptBranch, i.repoSettings)
+	}
+	return git.NewGitWorktree(i.Path, i.Title, i.repoSettings)
+}
+
 // firstTimeSetup is true if this is a new instance. Otherwise, it's one loaded from storage.
-func (i *Instance) Start(firstTimeSetup bool) error {
+func (i *Instance) Start(firstTimeSetup bool) (err error) {
 	if i.Title == "" {
 		return fmt.Errorf("instance title cannot be empty")
 	}
 
-	tmuxSession := tmux.NewTmuxSession(i.Title, i.Program)
-	i.tmuxSession = tmuxSession
+	span := telemetry.Start("instance.start", "instance", i.Title, "program", i.Program,
+		"first_time_setup", strconv.FormatBool(firstTimeSetup))
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
 
 	if firstTimeSetup {
-		gitWorktree, branchName, err := git.NewGitWorktree(i.Path, i.Title)
+		step := span.Child("worktree.create")
+		gitWorktree, branchName, err := i.newGitWorktree()
+		step.SetError(err)
+		step.End()
 		if err != nil {
 			return fmt.Errorf("failed to create git worktree: %w", err)
 		}
@@ -228,6 +466,22 @@ func (i *Instance) Start(firstTimeSetup bool) error {
 		i.Branch = branchName
 	}
 
+	tmuxSession := i.newTmuxSession()
+	i.tmuxSession = tmuxSession
+	i.configureCommits()
+
+	if err := i.loadPolicy(); err != nil {
+		return err
+	}
+	if firstTimeSetup {
+		if err := i.policy.CheckProgram(i.Program); err != nil {
+			return err
+		}
+		if err := i.policy.CheckBranch(i.Branch); err != nil {
+			return err
+		}
+	}
+
 	// Setup error handler to cleanup resources on any error
 	var setupErr error
 	defer func() {
@@ -241,20 +495,45 @@ func (i *Instance) Start(firstTimeSetup bool) error {
 	}()
 
 	if !firstTimeSetup {
+		// The sandbox's view doesn't survive a reboot.
+		if err := i.gitWorktree.EnsureSandbox(); err != nil {
+			log.WarningLog.Printf("failed to restore sandbox for %s: %v", i.Title, err)
+		}
 		// Reuse existing session
-		if err := tmuxSession.Restore(); err != nil {
+		step := span.Child("tmux.restore")
+		err := tmuxSession.Restore()
+		step.SetError(err)
+		step.End()
+		if err != nil {
 			setupErr = fmt.Errorf("failed to restore existing session: %w", err)
 			return setupErr
 		}
 	} else {
 		// Setup git worktree first
-		if err := i.gitWorktree.Setup(); err != nil {
+		step := span.Child("worktree.setup")
+		err := i.gitWorktree.Setup()
+		step.SetError(err)
+		step.End()
+		if err != nil {
 			setupErr = fmt.Errorf("failed to setup git worktree: %w", err)
 			return setupErr
 		}
+		if err := i.injectInstructions(); err != nil {
+			log.WarningLog.Printf("failed to write instructions for %s: %v", i.Title, err)
+		}
+		if err := i.configureMCPServers(); err != nil {
+			log.WarningLog.Printf("failed to configure MCP servers for %s: %v", i.Title, err)
+		}
+		if err := i.snapshotDatabase(); err != nil {
+			log.WarningLog.Printf("failed to snapshot database for %s: %v", i.Title, err)
+		}
 
 		// Create new session
-		if err := i.tmuxSession.Start(i.gitWorktree.GetWorktreePath()); err != nil {
+		step = span.Child("tmux.start")
+		err = i.tmuxSession.Start(i.gitWorktree.GetWorktreePath())
+		step.SetError(err)
+		step.End()
+		if err != nil {
 			// Cleanup git worktree if tmux session creation fails
 			if cleanupErr := i.gitWorktree.Cleanup(); cleanupErr != nil {
 				err = fmt.Errorf("%v (cleanup error: %v)", err, cleanupErr)
@@ -264,13 +543,76 @@ func (i *Instance) Start(firstTimeSetup bool) error {
 		}
 	}
 
+	if firstTimeSetup {
+		i.StartedAt = time.Now()
+	}
 	i.SetStatus(Running)
 
 	return nil
 }
 
-// Kill terminates the instance and cleans up all resources
+// loadPolicy loads the organization policy for the instance's repository. If it can't be loaded, the
+// instance falls back to the strictest behavior it can enforce on its own.
+func (i *Instance) loadPolicy() error {
+	p, err := policy.Load(i.gitWorktree.GetRepoPath())
+	if err != nil {
+		i.policy = &policy.Policy{DisableAutoYes: true}
+		return fmt.Errorf("failed to load policy: %w", err)
+	}
+	i.policy = p
+	return nil
+}
+
+// configureCommits sets the identity and trailers used for commits made in the instance's worktree.
+func (i *Instance) configureCommits() {
+	i.gitWorktree.SetCommitIdentity(i.CommitIdentity)
+
+	replacer := strings.NewReplacer(
+		"{program}", i.Program,
+		"{title}", i.Title,
+		"{branch}", i.Branch,
+		"{session}", i.tmuxSession.Name(),
+		"{coauthor}", coAuthor(i.Program),
+	)
+	var trailers []config.CommitTrailer
+	for _, trailer := range config.LoadConfig().CommitTrailers {
+		value := strings.TrimSpace(replacer.Replace(trailer.Value))
+		if value == "" {
+			continue
+		}
+		trailers = append(trailers, config.CommitTrailer{Key: trailer.Key, Value: value})
+	}
+	i.gitWorktree.SetCommitTrailers(trailers)
+}
+
+// coAuthors are the identities of the agents that have one, by program name, for Co-Authored-By trailers.
+var coAuthors = map[string]string{
+	tmux.ProgramClaude: "Claude <noreply@anthropic.com>",
+}
+
+// coAuthor returns the co-author identity of the agent program runs, or "" if it has none.
+func coAuthor(program string) string {
+	fields := strings.Fields(program)
+	if len(fields) == 0 {
+		return ""
+	}
+	return coAuthors[filepath.Base(fields[0])]
+}
+
+// Kill terminates the instance and cleans up all resources. The tmux session is verified to be gone along with
+// its programs before the worktree is removed. If either fails, the instance is left in the Error status with
+// what's left of it, so it stays listed and can be killed again or force-killed.
 func (i *Instance) Kill() error {
+	return i.kill(false)
+}
+
+// ForceKill kills the instance's programs that survive its tmux session with SIGKILL, then cleans up the rest of
+// its resources even if that fails. The instance should be removed whatever the error.
+func (i *Instance) ForceKill() error {
+	return i.kill(true)
+}
+
+func (i *Instance) kill(force bool) error {
 	if !i.started {
 		// If instance was never started, just return success
 		return nil
@@ -278,21 +620,32 @@ func (i *Instance) Kill() error {
 
 	var errs []error
 
-	// Always try to cleanup both resources, even if one fails
-	// Clean up tmux session first since it's using the git worktree
+	// Clean up tmux session first since it's using the git worktree. The worktree is kept while the
+	// programs that use it might still run, unless the kill is forced.
 	if i.tmuxSession != nil {
-		if err := i.tmuxSession.Close(); err != nil {
-			errs = append(errs, fmt.Errorf("failed to close tmux session: %w", err))
+		if err := i.tmuxSession.Kill(force); err != nil {
+			err = fmt.Errorf("failed to close tmux session: %w", err)
+			if !force {
+				i.SetStatus(Error)
+				return err
+			}
+			errs = append(errs, err)
 		}
 	}
 
 	// Then clean up git worktree
 	if i.gitWorktree != nil {
+		if err := i.dropDatabase(); err != nil {
+			errs = append(errs, err)
+		}
 		if err := i.gitWorktree.Cleanup(); err != nil {
 			errs = append(errs, fmt.Errorf("failed to cleanup git worktree: %w", err))
 		}
 	}
 
+	if len(errs) > 0 && !force {
+		i.SetStatus(Error)
+	}
 	return i.combineErrors(errs)
 }
 
@@ -312,6 +665,15 @@ func (i *Instance) combineErrors(errs []error) error {
 	return fmt.Errorf("%s", errMsg)
 }
 
+// Disown lets go of the instance's tmux session without killing it, for an instance another process changed,
+// that's replaced by a copy restored from storage, or removed.
+func (i *Instance) Disown() error {
+	if !i.started || i.tmuxSession == nil {
+		return nil
+	}
+	return i.tmuxSession.ClosePTY()
+}
+
 // Close is an alias for Kill to maintain backward compatibility
 func (i *Instance) Close() error {
 	if !i.started {
@@ -321,29 +683,123 @@ func (i *Instance) Close() error {
 }
 
 func (i *Instance) Preview() (string, error) {
-	if !i.started || i.Status == Paused {
+	if !i.started || i.Inactive() {
 		return "", nil
 	}
-	return i.tmuxSession.CapturePaneContent()
+	if i.Pane > 0 {
+		content, err := i.tmuxSession.CaptureSidePane(i.Pane)
+		return redactOutput(content), err
+	}
+	content, err := i.tmuxSession.CapturePaneContent()
+	if err != nil {
+		return "", err
+	}
+	if i.tmuxSession.HasSidePanes() {
+		panes, err := i.tmuxSession.CaptureSidePanes()
+		if err != nil {
+			return "", err
+		}
+		content = strings.TrimSuffix(content, "\n") + "\n" + panes
+	}
+	return redactOutput(content), nil
 }
 
 func (i *Instance) TerminalPreview() (string, error) {
-	if !i.started || i.Status == Paused {
+	if !i.started || i.Inactive() {
 		return "", nil
 	}
-	return i.tmuxSession.CaptureTerminalContent()
+	content, err := i.tmuxSession.CaptureTerminalContent()
+	return redactOutput(content), err
 }
 
 func (i *Instance) HasUpdated() (updated bool, hasPrompt bool) {
-	if !i.started {
+	if !i.started || i.dormant {
 		return false, false
 	}
 	return i.tmuxSession.HasUpdated()
 }
 
+// RecordActivity notes that the instance's output changed.
+func (i *Instance) RecordActivity() {
+	if !i.outputBaselined {
+		i.outputBaselined = true
+		if !i.LastActivity.IsZero() {
+			return
+		}
+	}
+	i.LastActivity = time.Now()
+}
+
+// Uptime returns how long the instance's session has been running. Paused and stopped instances have no uptime.
+func (i *Instance) Uptime() time.Duration {
+	if !i.started || i.Inactive() {
+		return 0
+	}
+	startedAt := i.StartedAt
+	if startedAt.IsZero() {
+		// Instances stored before StartedAt was tracked.
+		startedAt = i.CreatedAt
+	}
+	return time.Since(startedAt)
+}
+
+// Busy returns true if the program is showing its working indicator.
+func (i *Instance) Busy() bool {
+	if !i.started || i.Inactive() || i.dormant {
+		return false
+	}
+	return i.tmuxSession.Busy()
+}
+
+// Progressed returns true if the instance's output changed at the last call to HasUpdated, leaving aside
+// the program's working indicator.
+func (i *Instance) Progressed() bool {
+	if !i.started || i.Inactive() || i.dormant {
+		return false
+	}
+	return i.tmuxSession.Progressed()
+}
+
+// IdleFor returns how long it has been since the instance's output last changed.
+func (i *Instance) IdleFor() time.Duration {
+	if !i.started || i.Inactive() || i.dormant {
+		return 0
+	}
+	return time.Since(i.tmuxSession.LastOutputChange())
+}
+
+// ProgramAlive returns true if the instance's program is still running in its tmux session.
+func (i *Instance) ProgramAlive() (bool, error) {
+	if !i.started || i.Inactive() || i.dormant {
+		return false, fmt.Errorf("cannot check the program of instance that has not been started or is paused or stopped")
+	}
+	return i.tmuxSession.ProgramAlive()
+}
+
+// Interrupt asks the program to stop what it's currently doing.
+func (i *Instance) Interrupt() error {
+	if !i.started || i.Inactive() {
+		return fmt.Errorf("cannot interrupt instance that has not been started or is paused or stopped")
+	}
+	return i.tmuxSession.Interrupt()
+}
+
+// RestartProgram kills the program and starts it again in the instance's worktree.
+func (i *Instance) RestartProgram() error {
+	if !i.started || i.Inactive() {
+		return fmt.Errorf("cannot restart instance that has not been started or is paused or stopped")
+	}
+	if err := i.tmuxSession.RestartProgram(i.gitWorktree.GetWorktreePath()); err != nil {
+		return err
+	}
+	i.SetStatus(Loading)
+	return nil
+}
+
 // TapEnter sends an enter key press to the tmux session if AutoYes is enabled.
 func (i *Instance) TapEnter() {
-	if !i.started || !i.AutoYes {
+	// While the user is attached, they answer the prompts themselves.
+	if !i.started || i.dormant || !i.AnswersPrompts() || i.Attached() {
 		return
 	}
 	if err := i.tmuxSession.TapEnter(); err != nil {
@@ -351,6 +807,23 @@ func (i *Instance) TapEnter() {
 	}
 }
 
+// Attached returns true if the user is attached to the instance's tmux session. Its notifications are
+// suppressed and auto-yes is paused meanwhile, so automation doesn't fight the user.
+func (i *Instance) Attached() bool {
+	return i.started && !i.Inactive() && i.tmuxSession.IsAttached()
+}
+
+// Snoozed returns true if the instance's alerts and notifications are snoozed.
+func (i *Instance) Snoozed() bool {
+	return time.Now().Before(i.SnoozedUntil)
+}
+
+// AnswersPrompts returns true if the instance's prompts are accepted automatically, i.e. it's in auto-yes
+// mode, its repository's policy allows it and it isn't over its budget.
+func (i *Instance) AnswersPrompts() bool {
+	return i.AutoYes && !i.policy.DisableAutoYes && i.OverBudgetAt.IsZero()
+}
+
 func (i *Instance) Attach() (chan struct{}, error) {
 	if !i.started {
 		return nil, fmt.Errorf("cannot attach instance that has not been started")
@@ -373,10 +846,31 @@ func (i *Instance) AttachToTerminal() (chan struct{}, error) {
 	return i.tmuxSession.AttachToWindow("terminal")
 }
 
+// SwitchTo switches the tmux client claude-squad runs in to the instance's session, or to its terminal
+// window, instead of attaching to it. It's only possible when claude-squad runs inside tmux.
+func (i *Instance) SwitchTo(terminal bool) error {
+	if !i.started {
+		return fmt.Errorf("cannot switch to instance that has not been started")
+	}
+	if !terminal {
+		return i.tmuxSession.SwitchClient("0")
+	}
+	if _, err := i.tmuxSession.CaptureTerminalContent(); err != nil {
+		return fmt.Errorf("failed to ensure terminal window exists: %w", err)
+	}
+	return i.tmuxSession.SwitchClient("terminal")
+}
+
 func (i *Instance) SetPreviewSize(width, height int) error {
-	if !i.started || i.Status == Paused {
+	if !i.started || i.Inactive() {
 		return fmt.Errorf("cannot set preview size for instance that has not been started or " +
-			"is paused")
+			"is paused or stopped")
+	}
+	if i.windowSize.Width > 0 {
+		width = i.windowSize.Width
+	}
+	if i.windowSize.Height > 0 {
+		height = i.windowSize.Height
 	}
 	return i.tmuxSession.SetDetachedSize(width, height)
 }
@@ -393,6 +887,38 @@ func (i *Instance) Started() bool {
 	return i.started
 }
 
+// Dormant returns true if the instance was restored lazily and its tmux session hasn't been restored yet.
+func (i *Instance) Dormant() bool {
+	return i.dormant
+}
+
+// Wake restores the tmux session of a dormant instance. It does nothing if the instance isn't dormant.
+func (i *Instance) Wake() (err error) {
+	if !i.dormant {
+		return nil
+	}
+	if i.wakeErr != nil {
+		return i.wakeErr
+	}
+
+	span := telemetry.Start("instance.wake", "instance", i.Title)
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
+	// The sandbox's view doesn't survive a reboot.
+	if err := i.gitWorktree.EnsureSandbox(); err != nil {
+		log.WarningLog.Printf("failed to restore sandbox for %s: %v", i.Title, err)
+	}
+	if err := i.tmuxSession.Restore(); err != nil {
+		i.wakeErr = fmt.Errorf("failed to restore existing session for %s: %w", i.Title, err)
+		return i.wakeErr
+	}
+	i.dormant = false
+	return nil
+}
+
 // SetTitle sets the title of the instance. Returns an error if the instance has started.
 // We cant change the title once it's been used for a tmux session etc.
 func (i *Instance) SetTitle(title string) error {
@@ -403,10 +929,64 @@ func (i *Instance) SetTitle(title string) error {
 	return nil
 }
 
+// Rename changes the title of a started instance, renaming its tmux session and branch to match.
+func (i *Instance) Rename(title string) error {
+	if !i.started || i.Inactive() {
+		return fmt.Errorf("cannot rename instance that has not been started or is paused or stopped")
+	}
+	if err := i.tmuxSession.Rename(title); err != nil {
+		return err
+	}
+	branch, err := i.gitWorktree.Rename(title)
+	if err != nil {
+		// Keep the session name in line with the unchanged title.
+		if revertErr := i.tmuxSession.Rename(i.Title); revertErr != nil {
+			err = fmt.Errorf("%v (revert error: %v)", err, revertErr)
+		}
+		return err
+	}
+	i.Title = title
+	i.Branch = branch
+	i.configureCommits()
+	return nil
+}
+
+// RefineTitle replaces a placeholder title like "test2" with one derived from the first prompt or the
+// changes made so far. isTaken reports titles already used by other instances. It returns true if the
+// instance was renamed. Each instance is only renamed automatically once.
+func (i *Instance) RefineTitle(isTaken func(title string) bool) (bool, error) {
+	if i.AutoTitled || !isPlaceholderTitle(i.Title) {
+		return false, nil
+	}
+	diff := ""
+	if stats := i.GetDiffStats(); stats != nil {
+		diff = stats.Content
+	}
+	title := suggestTitle(i.Prompt, diff)
+	if title == "" || title == i.Title || isTaken(title) {
+		return false, nil
+	}
+	if err := i.Rename(title); err != nil {
+		return false, err
+	}
+	i.AutoTitled = true
+	return true, nil
+}
+
 func (i *Instance) Paused() bool {
 	return i.Status == Paused
 }
 
+// Stopped returns true if the instance's agent was stopped, keeping its worktree and branch.
+func (i *Instance) Stopped() bool {
+	return i.Status == Stopped
+}
+
+// Inactive returns true if the instance has no tmux session because it's paused or stopped.
+func (i *Instance) Inactive() bool {
+	return i.Status == Paused || i.Status == Stopped
+}
+
 // TmuxAlive returns true if the tmux session is alive. This is a sanity check before attaching.
 func (i *Instance) TmuxAlive() bool {
 	return i.tmuxSession.DoesSessionExist()
@@ -420,6 +1000,10 @@ func (i *Instance) Pause() error {
 	if i.Status == Paused {
 		return fmt.Errorf("instance is already paused")
 	}
+	if i.gitWorktree.IsExternal() {
+		return fmt.Errorf("%s was adopted from a tmux session and works in %s, so it can't be paused",
+			i.Title, i.gitWorktree.GetWorktreePath())
+	}
 
 	var errs []error
 
@@ -438,12 +1022,14 @@ func (i *Instance) Pause() error {
 		}
 	}
 
-	// Close tmux session first since it's using the git worktree
-	if err := i.tmuxSession.Close(); err != nil {
-		errs = append(errs, fmt.Errorf("failed to close tmux session: %w", err))
-		log.ErrorLog.Print(err)
-		// Return early if we can't close tmux to avoid corrupted state
-		return i.combineErrors(errs)
+	// Close tmux session first since it's using the git worktree. A stopped instance has none.
+	if i.Status != Stopped {
+		if err := i.tmuxSession.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close tmux session: %w", err))
+			log.ErrorLog.Print(err)
+			// Return early if we can't close tmux to avoid corrupted state
+			return i.combineErrors(errs)
+		}
 	}
 
 	// Check if worktree exists before trying to remove it
@@ -473,13 +1059,33 @@ func (i *Instance) Pause() error {
 	return nil
 }
 
+// Stop kills the instance's tmux session and its programs like Kill does, but keeps its worktree and branch, so
+// the agent's work can be looked at, resumed or purged later.
+func (i *Instance) Stop() error {
+	if !i.started {
+		return fmt.Errorf("cannot stop instance that has not been started")
+	}
+	if i.Inactive() {
+		return fmt.Errorf("instance is already %s", i.Status)
+	}
+	if err := i.tmuxSession.Kill(false); err != nil {
+		return fmt.Errorf("failed to close tmux session: %w", err)
+	}
+	i.dormant = false
+	i.SetStatus(Stopped)
+	return nil
+}
+
 // Resume recreates the worktree and restarts the tmux session
 func (i *Instance) Resume() error {
 	if !i.started {
 		return fmt.Errorf("cannot resume instance that has not been started")
 	}
+	if i.Status == Stopped {
+		return i.restart()
+	}
 	if i.Status != Paused {
-		return fmt.Errorf("can only resume paused instances")
+		return fmt.Errorf("can only resume paused or stopped instances")
 	}
 
 	// Check if branch is checked out
@@ -507,6 +1113,25 @@ func (i *Instance) Resume() error {
 		return fmt.Errorf("failed to start new session: %w", err)
 	}
 
+	i.StartedAt = time.Now()
+	i.outputBaselined = false
+	i.SetStatus(Running)
+	return nil
+}
+
+// restart starts a new tmux session in the worktree a stopped instance kept.
+func (i *Instance) restart() error {
+	if _, err := os.Stat(i.gitWorktree.GetWorktreePath()); err != nil {
+		return fmt.Errorf("cannot resume: the worktree %s is gone, purge the instance instead",
+			i.gitWorktree.GetWorktreePath())
+	}
+	if err := i.tmuxSession.Start(i.gitWorktree.GetWorktreePath()); err != nil {
+		log.ErrorLog.Print(err)
+		return fmt.Errorf("failed to start new session: %w", err)
+	}
+
+	i.StartedAt = time.Now()
+	i.outputBaselined = false
 	i.SetStatus(Running)
 	return nil
 }
@@ -533,15 +1158,61 @@ func (i *Instance) UpdateDiffStats() error {
 		return fmt.Errorf("failed to get diff stats: %w", stats.Error)
 	}
 
+	flagSensitive(stats)
+	i.annotateBlame(stats, i.diffStats)
 	i.diffStats = stats
+	i.diffFile = ""
 	return nil
 }
 
 // GetDiffStats returns the current git diff statistics
 func (i *Instance) GetDiffStats() *git.DiffStats {
+	i.loadDiffContent()
 	return i.diffStats
 }
 
+// loadDiffContent loads the content of the diff stats from the stored diff, the first time it's needed.
+func (i *Instance) loadDiffContent() {
+	if i.diffFile == "" || i.diffStats == nil {
+		return
+	}
+	name := i.diffFile
+	i.diffFile = ""
+	content, err := readDiff(name)
+	if err != nil {
+		log.WarningLog.Printf("failed to load the stored diff of %s: %v", i.Title, err)
+		return
+	}
+	i.diffStats.Content = content
+	i.diffStats.Files = git.ParseDiffFiles(content)
+	flagSensitive(i.diffStats)
+}
+
+// CyclePane selects the next pane of the instance's tmux window for the preview and for prompts sent from
+// the prompt overlay, wrapping around to the agent's pane.
+func (i *Instance) CyclePane() error {
+	if !i.started || i.Inactive() {
+		return fmt.Errorf("cannot select a pane of an instance that isn't running")
+	}
+	count, err := i.tmuxSession.PaneCount()
+	if err != nil {
+		return err
+	}
+	i.Pane = (i.Pane + 1) % max(count, 1)
+	return nil
+}
+
+// SendInput sends text typed by the user to the selected pane. Sent to the agent's pane, it's a prompt.
+func (i *Instance) SendInput(text string) error {
+	if i.Pane == 0 {
+		return i.SendPrompt(text)
+	}
+	if !i.started {
+		return fmt.Errorf("instance not started")
+	}
+	return i.tmuxSession.SendToSidePane(i.Pane, text)
+}
+
 // SendPrompt sends a prompt to the tmux session
 func (i *Instance) SendPrompt(prompt string) error {
 	if !i.started {
@@ -550,13 +1221,20 @@ func (i *Instance) SendPrompt(prompt string) error {
 	if i.tmuxSession == nil {
 		return fmt.Errorf("tmux session not initialized")
 	}
+	span := telemetry.Start("instance.send_prompt", "instance", i.Title)
+	defer span.End()
 	if err := i.tmuxSession.SendKeys(prompt); err != nil {
+		span.SetError(err)
 		return fmt.Errorf("error sending keys to tmux session: %w", err)
 	}
+	if i.Prompt == "" {
+		i.Prompt = prompt
+	}
 
 	// Brief pause to prevent carriage return from being interpreted as newline
 	time.Sleep(100 * time.Millisecond)
 	if err := i.tmuxSession.TapEnter(); err != nil {
+		span.SetError(err)
 		return fmt.Errorf("error tapping enter: %w", err)
 	}
 
@@ -0,0 +1,14 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoAuthor(t *testing.T) {
+	require.Equal(t, "Claude <noreply@anthropic.com>", coAuthor("claude"))
+	require.Equal(t, "Claude <noreply@anthropic.com>", coAuthor("/usr/local/bin/claude --model opus"))
+	require.Equal(t, "", coAuthor("aider --model sonnet"))
+	require.Equal(t, "", coAuthor(""))
+}
@@ -0,0 +1,40 @@
+package session
+
+import (
+	"claude-squad/config"
+	"path/filepath"
+	"strings"
+)
+
+// instructionsFile returns the agent instructions file for program, unless configured overrides it.
+func instructionsFile(program, configured string) string {
+	if configured != "" {
+		return configured
+	}
+	switch name := programName(program); {
+	case strings.Contains(name, "claude"):
+		return "CLAUDE.md"
+	case strings.Contains(name, "gemini"):
+		return "GEMINI.md"
+	default:
+		return "AGENTS.md"
+	}
+}
+
+// programName returns the name of the executable in a program command line, e.g. "claude" for
+// "/usr/local/bin/claude --verbose".
+func programName(program string) string {
+	command, _, _ := strings.Cut(strings.TrimSpace(program), " ")
+	return filepath.Base(command)
+}
+
+// injectInstructions writes the configured squad instructions into the new worktree's agent
+// instructions file.
+func (i *Instance) injectInstructions() error {
+	cfg := config.LoadConfig()
+	instructions := cfg.GetInstructions(i.gitWorktree.GetRepoPath())
+	if instructions == "" {
+		return nil
+	}
+	return i.gitWorktree.InjectInstructions(instructionsFile(i.Program, cfg.InstructionsFile), instructions)
+}
@@ -0,0 +1,16 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstructionsFile(t *testing.T) {
+	require.Equal(t, "CLAUDE.md", instructionsFile("claude", ""))
+	require.Equal(t, "CLAUDE.md", instructionsFile("/usr/local/bin/claude --verbose", ""))
+	require.Equal(t, "GEMINI.md", instructionsFile("gemini", ""))
+	require.Equal(t, "AGENTS.md", instructionsFile("codex", ""))
+	require.Equal(t, "AGENTS.md", instructionsFile("", ""))
+	require.Equal(t, "CONVENTIONS.md", instructionsFile("aider", "CONVENTIONS.md"))
+}
@@ -0,0 +1,87 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// mcpConfigFile returns the project-level file program reads its MCP servers from, relative to the
+// worktree. It returns "" for programs claude-squad can't configure.
+func mcpConfigFile(program string) string {
+	switch name := programName(program); {
+	case strings.Contains(name, "claude"):
+		return ".mcp.json"
+	case strings.Contains(name, "gemini"):
+		return filepath.Join(".gemini", "settings.json")
+	default:
+		return ""
+	}
+}
+
+// mergeMCPServers adds servers to the "mcpServers" object of the JSON config in existing, replacing
+// servers with the same name and keeping everything else.
+func mergeMCPServers(existing []byte, servers map[string]config.MCPServer) ([]byte, error) {
+	doc := make(map[string]json.RawMessage)
+	if len(strings.TrimSpace(string(existing))) > 0 {
+		if err := json.Unmarshal(existing, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse existing config: %w", err)
+		}
+	}
+
+	merged := make(map[string]json.RawMessage)
+	if raw, ok := doc["mcpServers"]; ok {
+		if err := json.Unmarshal(raw, &merged); err != nil {
+			return nil, fmt.Errorf("failed to parse existing mcpServers: %w", err)
+		}
+	}
+	for name, server := range servers {
+		raw, err := json.Marshal(server)
+		if err != nil {
+			return nil, err
+		}
+		merged[name] = raw
+	}
+
+	raw, err := json.Marshal(merged)
+	if err != nil {
+		return nil, err
+	}
+	doc["mcpServers"] = raw
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// configureMCPServers writes the configured MCP servers into the new worktree's MCP config, so the
+// instance's program starts with the same servers as every other instance.
+func (i *Instance) configureMCPServers() error {
+	servers := config.LoadConfig().GetMCPServers(i.gitWorktree.GetRepoPath())
+	if len(servers) == 0 {
+		return nil
+	}
+	file := mcpConfigFile(i.Program)
+	if file == "" {
+		log.WarningLog.Printf("MCP servers aren't supported for %s, not configuring them for %s", i.Program, i.Title)
+		return nil
+	}
+
+	path := filepath.Join(i.gitWorktree.GetWorktreePath(), file)
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	data, err := mergeMCPServers(existing, servers)
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", file, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(file), err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", file, err)
+	}
+	return i.gitWorktree.HideFromGit(file)
+}
@@ -0,0 +1,45 @@
+package session
+
+import (
+	"claude-squad/config"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeMCPServers(t *testing.T) {
+	servers := map[string]config.MCPServer{
+		"github": {Command: "github-mcp", Args: []string{"stdio"}},
+		"docs":   {Type: "http", URL: "https://docs.example.com/mcp"},
+	}
+	existing := []byte(`{"theme": "dark", "mcpServers": {"local": {"command": "local-mcp"}, "docs": {"command": "old"}}}`)
+
+	data, err := mergeMCPServers(existing, servers)
+	require.NoError(t, err)
+
+	var got struct {
+		Theme      string                      `json:"theme"`
+		MCPServers map[string]config.MCPServer `json:"mcpServers"`
+	}
+	require.NoError(t, json.Unmarshal(data, &got))
+	require.Equal(t, "dark", got.Theme)
+	require.Equal(t, map[string]config.MCPServer{
+		"local":  {Command: "local-mcp"},
+		"github": {Command: "github-mcp", Args: []string{"stdio"}},
+		"docs":   {Type: "http", URL: "https://docs.example.com/mcp"},
+	}, got.MCPServers)
+
+	data, err = mergeMCPServers(nil, servers)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"github-mcp"`)
+
+	_, err = mergeMCPServers([]byte("not json"), servers)
+	require.Error(t, err)
+}
+
+func TestMCPConfigFile(t *testing.T) {
+	require.Equal(t, ".mcp.json", mcpConfigFile("claude --verbose"))
+	require.Equal(t, ".gemini/settings.json", mcpConfigFile("gemini"))
+	require.Equal(t, "", mcpConfigFile("aider"))
+}
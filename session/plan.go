@@ -0,0 +1,79 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PlanState tracks an instance through the plan/approve workflow, where the agent first writes a plan
+// that the user reviews before any changes are made.
+type PlanState int
+
+const (
+	// PlanNone means the instance isn't using the plan/approve workflow.
+	PlanNone PlanState = iota
+	// PlanDrafting means the agent has been asked for a plan and is writing it.
+	PlanDrafting
+	// PlanAwaitingApproval means the plan has been captured and is waiting for the user to review it.
+	PlanAwaitingApproval
+	// PlanApproved means the user approved the plan and the agent was told to execute it.
+	PlanApproved
+)
+
+// PlanFileName is the file in the worktree the agent is asked to write its plan to. It's removed once
+// the plan has been captured.
+const PlanFileName = ".claude-squad-plan.md"
+
+// planPrompt asks the agent for a plan for task without making any changes.
+func planPrompt(task string) string {
+	return fmt.Sprintf("%s\n\nDon't change any code yet. First write a step-by-step plan for this task to %s "+
+		"in the repository root, then stop and wait. The plan will be reviewed before you continue.",
+		strings.TrimSpace(task), PlanFileName)
+}
+
+// executePrompt tells the agent to carry out the approved plan.
+func executePrompt(plan string) string {
+	return fmt.Sprintf("The plan has been reviewed and approved. Carry it out now:\n\n%s", strings.TrimSpace(plan))
+}
+
+// StartPlan sends task to the agent asking for a plan only. The plan is captured with CapturePlan once
+// the agent is done.
+func (i *Instance) StartPlan(task string) error {
+	if err := i.SendPrompt(planPrompt(task)); err != nil {
+		return err
+	}
+	i.Plan = ""
+	i.PlanState = PlanDrafting
+	return nil
+}
+
+// CapturePlan captures the plan the agent wrote to PlanFileName.
+func (i *Instance) CapturePlan() error {
+	if i.PlanState != PlanDrafting {
+		return fmt.Errorf("instance %s is not drafting a plan", i.Title)
+	}
+
+	plan, err := i.captureWrittenOutput(PlanFileName)
+	if err != nil {
+		return fmt.Errorf("failed to capture plan: %w", err)
+	}
+	i.Plan = plan
+	i.PlanState = PlanAwaitingApproval
+	return nil
+}
+
+// ApprovePlan records plan, possibly edited by the user, as approved and tells the agent to execute it.
+func (i *Instance) ApprovePlan(plan string) error {
+	if i.PlanState != PlanAwaitingApproval {
+		return fmt.Errorf("instance %s has no plan awaiting approval", i.Title)
+	}
+	if strings.TrimSpace(plan) == "" {
+		return fmt.Errorf("plan cannot be empty")
+	}
+	if err := i.SendPrompt(executePrompt(plan)); err != nil {
+		return err
+	}
+	i.Plan = strings.TrimSpace(plan)
+	i.PlanState = PlanApproved
+	return nil
+}
@@ -0,0 +1,38 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"claude-squad/session/git"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapturePlanFromFile(t *testing.T) {
+	dir := t.TempDir()
+	planPath := filepath.Join(dir, PlanFileName)
+	require.NoError(t, os.WriteFile(planPath, []byte("1. Do this\n2. Do that\n"), 0644))
+
+	instance := &Instance{
+		Title:       "plan",
+		PlanState:   PlanDrafting,
+		gitWorktree: git.NewGitWorktreeFromStorage(dir, dir, "plan", "plan", ""),
+	}
+	require.NoError(t, instance.CapturePlan())
+	require.Equal(t, "1. Do this\n2. Do that", instance.Plan)
+	require.Equal(t, PlanAwaitingApproval, instance.PlanState)
+
+	_, err := os.Stat(planPath)
+	require.True(t, os.IsNotExist(err), "plan file should be removed once captured")
+}
+
+func TestPlanStateTransitions(t *testing.T) {
+	instance := &Instance{Title: "plan"}
+	require.Error(t, instance.CapturePlan())
+	require.Error(t, instance.ApprovePlan("do it"))
+
+	instance.PlanState = PlanAwaitingApproval
+	require.EqualError(t, instance.ApprovePlan("  "), "plan cannot be empty")
+}
@@ -0,0 +1,20 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/redact"
+	"sync"
+)
+
+var (
+	redactorOnce   sync.Once
+	outputRedactor *redact.Redactor
+)
+
+// redactOutput masks secrets in agent output before it's displayed or persisted.
+func redactOutput(text string) string {
+	redactorOnce.Do(func() {
+		outputRedactor = redact.Load(config.LoadConfig())
+	})
+	return outputRedactor.Redact(text)
+}
@@ -0,0 +1,29 @@
+package session
+
+import (
+	"claude-squad/config"
+	"claude-squad/redact"
+	"claude-squad/session/git"
+	"fmt"
+)
+
+// ScanChanges scans the instance's changes for secrets, large binaries and forbidden paths before they're
+// pushed. It returns no findings if the scan is disabled.
+func (i *Instance) ScanChanges() ([]git.Finding, error) {
+	cfg := config.LoadConfig()
+	if cfg.SafetyScan.Disabled {
+		return nil, nil
+	}
+	secrets, err := redact.New(cfg.Redaction.Patterns, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load secret patterns: %w", err)
+	}
+	if i.gitWorktree == nil {
+		return nil, fmt.Errorf("instance %s has no worktree", i.Title)
+	}
+	return i.gitWorktree.ScanChanges(git.ScanOptions{
+		Secrets:        secrets,
+		MaxBinarySize:  cfg.SafetyScan.GetMaxBinarySize(),
+		ForbiddenPaths: cfg.SafetyScan.GetForbiddenPaths(),
+	})
+}
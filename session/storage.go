@@ -2,8 +2,14 @@ package session
 
 import (
 	"claude-squad/config"
+	"claude-squad/log"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"slices"
+	"sync"
 	"time"
 )
 
@@ -18,8 +24,42 @@ type InstanceData struct {
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 	AutoYes      bool      `json:"auto_yes"`
+	// StartedAt is when the instance's session was last started or resumed
+	StartedAt time.Time `json:"started_at,omitempty"`
+	// LastActivity is when the instance's output last changed
+	LastActivity time.Time `json:"last_activity,omitempty"`
 	// RepositoryPath is the absolute path to the repository root this instance belongs to
 	RepositoryPath string `json:"repository_path"`
+	// CommitIdentity overrides the repository's commit identity for this instance
+	CommitIdentity config.CommitIdentity `json:"commit_identity,omitempty"`
+	// AutoTitled is true once the instance's placeholder title has been replaced automatically
+	AutoTitled bool `json:"auto_titled,omitempty"`
+	// Plan is the plan captured in the plan/approve workflow
+	Plan string `json:"plan,omitempty"`
+	// PlanState is where the instance is in the plan/approve workflow
+	PlanState PlanState `json:"plan_state,omitempty"`
+	// Summary is the agent's summary of what it changed and what's left to do
+	Summary string `json:"summary,omitempty"`
+	// Database is the state of the instance's own copy of the development database
+	Database DatabaseState `json:"database,omitempty"`
+	// Muted silences the instance's audible alerts
+	Muted bool `json:"muted,omitempty"`
+	// SnoozedUntil is when the instance's snooze ends
+	SnoozedUntil time.Time `json:"snoozed_until,omitempty"`
+	// Pane is the index of the pane that's previewed and sent prompts
+	Pane int `json:"pane,omitempty"`
+	// MergedAt is when the instance's branch was found merged into the default branch
+	MergedAt time.Time `json:"merged_at,omitempty"`
+	// Ticket is the key of the ticket the instance was created from
+	Ticket string `json:"ticket,omitempty"`
+	// Task is the name of the task the instance was started from
+	Task string `json:"task,omitempty"`
+	// Budget limits how long the instance runs and how much its agent spends
+	Budget Budget `json:"budget,omitempty"`
+	// Cost is the highest cost the instance's agent was seen printing
+	Cost float64 `json:"cost,omitempty"`
+	// OverBudgetAt is when the instance went over its budget
+	OverBudgetAt time.Time `json:"over_budget_at,omitempty"`
 
 	Program   string          `json:"program"`
 	Worktree  GitWorktreeData `json:"worktree"`
@@ -33,6 +73,14 @@ type GitWorktreeData struct {
 	SessionName   string `json:"session_name"`
 	BranchName    string `json:"branch_name"`
 	BaseCommitSHA string `json:"base_commit_sha"`
+	// Sandboxed is true if the worktree is presented through a copy-on-write view
+	Sandboxed bool `json:"sandboxed,omitempty"`
+	// Adopted is true if the branch existed before the instance, in which case it's kept when the instance
+	// is killed
+	Adopted bool `json:"adopted,omitempty"`
+	// External is true if the worktree is a directory claude-squad didn't create, e.g. the one of an adopted
+	// tmux session, in which case it's kept when the instance is killed
+	External bool `json:"external,omitempty"`
 }
 
 // DiffStatsData represents the serializable data of a DiffStats
@@ -40,17 +88,32 @@ type DiffStatsData struct {
 	Added   int    `json:"added"`
 	Removed int    `json:"removed"`
 	Content string `json:"content"`
+	// File is the file in the diffs directory the content is stored in, when it isn't stored inline
+	File string `json:"file,omitempty"`
 }
 
+// restoreConcurrency is how many instances are restored at once. Restoring runs a few git and tmux
+// commands per instance.
+const restoreConcurrency = 8
+
 // Storage handles saving and loading instances using the state interface
 type Storage struct {
 	state config.StateManager
+	// unrestored is the data of the instances RestoreInstances failed to restore. It's saved back with the
+	// other instances so they aren't lost.
+	unrestored []InstanceData
+	// lazy defers restoring the tmux sessions of the instances RestoreInstances restores.
+	lazy bool
+	// storedDiffs are the hashes of the diffs written to the diffs directory, keyed by file name, so
+	// unchanged diffs aren't written again.
+	storedDiffs map[string][sha256.Size]byte
 }
 
 // NewStorage creates a new storage instance
 func NewStorage(state config.StateManager) (*Storage, error) {
 	return &Storage{
-		state: state,
+		state:       state,
+		storedDiffs: make(map[string][sha256.Size]byte),
 	}, nil
 }
 
@@ -58,11 +121,24 @@ func NewStorage(state config.StateManager) (*Storage, error) {
 func (s *Storage) SaveInstances(instances []*Instance) error {
 	// Convert instances to InstanceData
 	data := make([]InstanceData, 0)
+	titles := make(map[string]bool)
 	for _, instance := range instances {
 		if instance.Started() {
-			data = append(data, instance.ToInstanceData())
+			data = append(data, instance.toInstanceData())
+			titles[instance.Title] = true
+		}
+	}
+	for _, unrestored := range s.unrestored {
+		if !titles[unrestored.Title] {
+			data = append(data, unrestored)
 		}
 	}
+	for i := range data {
+		s.storeDiff(&data[i])
+	}
+	if err := s.pruneDiffs(data); err != nil {
+		log.WarningLog.Printf("failed to prune stored diffs: %v", err)
+	}
 
 	// Marshal to JSON
 	jsonData, err := json.Marshal(data)
@@ -73,6 +149,66 @@ func (s *Storage) SaveInstances(instances []*Instance) error {
 	return s.state.SaveInstances(jsonData)
 }
 
+// SetLazyRestore makes RestoreInstances leave the instances it restores dormant, without their tmux
+// sessions, until they're woken with Instance.Wake.
+func (s *Storage) SetLazyRestore(lazy bool) {
+	s.lazy = lazy
+}
+
+// storeDiff moves the diff content of data to its own file in the diffs directory. Diffs over the size cap
+// aren't stored at all.
+func (s *Storage) storeDiff(data *InstanceData) {
+	content := data.DiffStats.Content
+	if content == "" {
+		return
+	}
+	data.DiffStats.Content = ""
+	data.DiffStats.File = ""
+
+	loadDiffSettings()
+	if len(content) > diffStorage.GetMaxSize() {
+		return
+	}
+	name := diffFileName(data.Title, diffStorage.Compress)
+	hash := sha256.Sum256([]byte(content))
+	if stored, ok := s.storedDiffs[name]; !ok || stored != hash {
+		if err := writeDiff(name, content); err != nil {
+			log.WarningLog.Printf("failed to store the diff of %s: %v", data.Title, err)
+			return
+		}
+		s.storedDiffs[name] = hash
+	}
+	data.DiffStats.File = name
+}
+
+// pruneDiffs removes the diffs this storage wrote that none of the instances refer to anymore. Diffs it
+// didn't write are left alone, since they may belong to instances another process added that these
+// instances don't include yet.
+func (s *Storage) pruneDiffs(instances []InstanceData) error {
+	keep := make(map[string]bool, len(instances))
+	for _, data := range instances {
+		keep[data.DiffStats.File] = true
+	}
+	var stale []string
+	for name := range s.storedDiffs {
+		if !keep[name] {
+			stale = append(stale, name)
+			delete(s.storedDiffs, name)
+		}
+	}
+	return removeDiffs(stale...)
+}
+
+// removeDiffsOf removes the stored diffs of the removed instances.
+func (s *Storage) removeDiffsOf(removed []InstanceData) error {
+	names := make([]string, 0, len(removed))
+	for _, data := range removed {
+		names = append(names, data.DiffStats.File)
+		delete(s.storedDiffs, data.DiffStats.File)
+	}
+	return removeDiffs(names...)
+}
+
 // LoadInstances loads the list of instances from disk
 func (s *Storage) LoadInstances() ([]*Instance, error) {
 	jsonData := s.state.GetInstances()
@@ -82,41 +218,171 @@ func (s *Storage) LoadInstances() ([]*Instance, error) {
 		return nil, fmt.Errorf("failed to unmarshal instances: %w", err)
 	}
 
-	instances := make([]*Instance, len(instancesData))
-	for i, data := range instancesData {
-		instance, err := FromInstanceData(data)
+	instances, errs := s.restoreInstances(instancesData, false, nil)
+	for _, err := range errs {
 		if err != nil {
-			return nil, fmt.Errorf("failed to create instance %s: %w", data.Title, err)
+			return nil, err
 		}
-		instances[i] = instance
 	}
-
 	return instances, nil
 }
 
-// DeleteInstance removes an instance from storage
+// RestoreInstances loads the stored instances like LoadInstances, but an instance that fails to restore
+// doesn't stop the others: they're returned along with an error describing the failures. The failed
+// instances are kept in storage. progress, if not nil, is called as each instance is restored.
+func (s *Storage) RestoreInstances(progress func(done, total int)) ([]*Instance, error) {
+	instancesData, err := s.LoadInstanceData()
+	if err != nil {
+		return nil, err
+	}
+
+	restored, errs := s.restoreInstances(instancesData, s.lazy, progress)
+	s.unrestored = nil
+	var instances []*Instance
+	for i, instance := range restored {
+		if errs[i] != nil {
+			s.unrestored = append(s.unrestored, instancesData[i])
+			continue
+		}
+		instances = append(instances, instance)
+	}
+	return instances, errors.Join(errs...)
+}
+
+// TakeMergedInstances restores the instances another process added or changed that saving the state took in
+// since it was last called, and returns them with the titles of those it removed, so the holder of the
+// instances can bring them up to date. An instance that fails to restore is kept in storage like
+// RestoreInstances does, and reported in the error.
+func (s *Storage) TakeMergedInstances() (restored []*Instance, removed []string, err error) {
+	titles := s.state.TakeMergedInstances()
+	if len(titles) == 0 {
+		return nil, nil, nil
+	}
+	instancesData, err := s.LoadInstanceData()
+	if err != nil {
+		return nil, nil, err
+	}
+	stored := make(map[string]InstanceData, len(instancesData))
+	for _, data := range instancesData {
+		stored[data.Title] = data
+	}
+
+	var changed []InstanceData
+	seen := make(map[string]bool, len(titles))
+	for _, title := range titles {
+		if seen[title] {
+			continue
+		}
+		seen[title] = true
+		s.unrestored = slices.DeleteFunc(s.unrestored, func(data InstanceData) bool { return data.Title == title })
+		if data, ok := stored[title]; ok {
+			changed = append(changed, data)
+		} else {
+			removed = append(removed, title)
+		}
+	}
+
+	instances, errs := s.restoreInstances(changed, s.lazy, nil)
+	for i, instance := range instances {
+		if errs[i] != nil {
+			s.unrestored = append(s.unrestored, changed[i])
+			continue
+		}
+		restored = append(restored, instance)
+	}
+	return restored, removed, errors.Join(errs...)
+}
+
+// restoreInstances restores the instances in parallel, restoreConcurrency at a time. It returns the
+// instances and the errors restoring them, in the order of data. If lazy is set, the instances are left
+// dormant. The instances get their repositories' settings from the state.
+func (s *Storage) restoreInstances(data []InstanceData, lazy bool, progress func(done, total int)) ([]*Instance, []error) {
+	instances := make([]*Instance, len(data))
+	errs := make([]error, len(data))
+
+	var mu sync.Mutex
+	done := 0
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, restoreConcurrency)
+	for i, d := range data {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			instance, err := fromInstanceData(d, lazy)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to create instance %s: %w", d.Title, err)
+			}
+			instances[i] = instance
+
+			if progress != nil {
+				mu.Lock()
+				done++
+				progress(done, len(data))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	for _, instance := range instances {
+		if instance != nil {
+			instance.SetRepoSettings(config.GetRepoSettings(s.state, instance.RepoPath()))
+		}
+	}
+	return instances, errs
+}
+
+// LoadInstanceData loads the stored data of every instance without restoring their sessions. An instance
+// that can't be decoded is left out, rather than failing the others, and logged.
+func (s *Storage) LoadInstanceData() ([]InstanceData, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(s.state.GetInstances(), &raw); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal instances: %w", err)
+	}
+	instancesData := make([]InstanceData, 0, len(raw))
+	for i, r := range raw {
+		var data InstanceData
+		if err := json.Unmarshal(r, &data); err != nil {
+			log.ErrorLog.Printf("left out stored instance %d, which can't be decoded: %v: %.200s", i, err, r)
+			continue
+		}
+		instancesData = append(instancesData, data)
+	}
+	return instancesData, nil
+}
+
+// DeleteInstance removes an instance from storage. The other instances' stored data is left as is, without
+// restoring them, so an instance that can't be restored doesn't prevent deleting the others.
 func (s *Storage) DeleteInstance(title string) error {
-	instances, err := s.LoadInstances()
+	instancesData, err := s.LoadInstanceData()
 	if err != nil {
 		return fmt.Errorf("failed to load instances: %w", err)
 	}
 
-	found := false
-	newInstances := make([]*Instance, 0)
-	for _, instance := range instances {
-		data := instance.ToInstanceData()
+	var removed []InstanceData
+	newData := make([]InstanceData, 0)
+	for _, data := range instancesData {
 		if data.Title != title {
-			newInstances = append(newInstances, instance)
+			newData = append(newData, data)
 		} else {
-			found = true
+			removed = append(removed, data)
 		}
 	}
 
-	if !found {
+	if len(removed) == 0 {
 		return fmt.Errorf("instance not found: %s", title)
 	}
+	s.unrestored = slices.DeleteFunc(s.unrestored, func(data InstanceData) bool { return data.Title == title })
+	if err := s.removeDiffsOf(removed); err != nil {
+		log.WarningLog.Printf("failed to prune stored diffs: %v", err)
+	}
 
-	return s.SaveInstances(newInstances)
+	jsonData, err := json.Marshal(newData)
+	if err != nil {
+		return fmt.Errorf("failed to marshal instances: %w", err)
+	}
+	return s.state.SaveInstances(jsonData)
 }
 
 // UpdateInstance updates an existing instance in storage
@@ -146,6 +412,10 @@ func (s *Storage) UpdateInstance(instance *Instance) error {
 
 // DeleteAllInstances removes all stored instances
 func (s *Storage) DeleteAllInstances() error {
+	if err := removeAllDiffs(); err != nil {
+		log.WarningLog.Printf("failed to prune stored diffs: %v", err)
+	}
+	clear(s.storedDiffs)
 	return s.state.DeleteAllInstances()
 }
 
@@ -171,14 +441,13 @@ func (s *Storage) LoadInstancesForRepository(repositoryPath string) ([]*Instance
 
 // GetInstanceCountByRepository returns the number of instances per repository
 func (s *Storage) GetInstanceCountByRepository() (map[string]int, error) {
-	allInstances, err := s.LoadInstances()
+	instancesData, err := s.LoadInstanceData()
 	if err != nil {
 		return nil, err
 	}
 	
 	counts := make(map[string]int)
-	for _, instance := range allInstances {
-		data := instance.ToInstanceData()
+	for _, data := range instancesData {
 		if data.RepositoryPath != "" {
 			counts[data.RepositoryPath]++
 		}
@@ -206,11 +475,14 @@ func (s *Storage) UpdateInstanceCounts() error {
 	return nil
 }
 
-// CleanupOrphanedInstances removes instances whose repositories no longer exist
-func (s *Storage) CleanupOrphanedInstances() error {
-	allInstances, err := s.LoadInstances()
+// CleanupOrphanedInstances removes the instances whose repositories aren't known anymore. Their tmux sessions
+// are killed and their worktrees removed; their branches are kept, for `claude-squad branches` to list. The
+// worktrees of repositories that don't exist anymore, and those claude-squad didn't create, are left in place
+// and reported as kept.
+func (s *Storage) CleanupOrphanedInstances() (*config.CleanupReport, error) {
+	instancesData, err := s.LoadInstanceData()
 	if err != nil {
-		return fmt.Errorf("failed to load instances: %w", err)
+		return nil, fmt.Errorf("failed to load instances: %w", err)
 	}
 	
 	repos := s.state.GetRepositories()
@@ -219,27 +491,71 @@ func (s *Storage) CleanupOrphanedInstances() error {
 		repoMap[repo.Path] = true
 	}
 	
-	var validInstances []*Instance
-	orphanedCount := 0
-	
-	for _, instance := range allInstances {
-		data := instance.ToInstanceData()
+	report := &config.CleanupReport{}
+	validData := make([]InstanceData, 0, len(instancesData))
+	var removed []InstanceData
+	for _, data := range instancesData {
 		// Keep instances that either have no repository association (legacy)
 		// or whose repository still exists
 		if data.RepositoryPath == "" || repoMap[data.RepositoryPath] {
-			validInstances = append(validInstances, instance)
-		} else {
-			orphanedCount++
+			validData = append(validData, data)
+			continue
 		}
-	}
-	
-	if orphanedCount > 0 {
-		if err := s.SaveInstances(validInstances); err != nil {
-			return fmt.Errorf("failed to save cleaned instances: %w", err)
+		removed = append(removed, data)
+		report.Instances = append(report.Instances, data.Title)
+		if kept := cleanupOrphan(data); kept != "" {
+			report.Kept = append(report.Kept, kept)
 		}
 	}
 	
-	return nil
+	if len(report.Instances) == 0 {
+		return report, nil
+	}
+	s.unrestored = slices.DeleteFunc(s.unrestored, func(data InstanceData) bool {
+		return slices.Contains(report.Instances, data.Title)
+	})
+	if err := s.removeDiffsOf(removed); err != nil {
+		log.WarningLog.Printf("failed to prune stored diffs: %v", err)
+	}
+	jsonData, err := json.Marshal(validData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal instances: %w", err)
+	}
+	if err := s.state.SaveInstances(jsonData); err != nil {
+		return nil, fmt.Errorf("failed to save cleaned instances: %w", err)
+	}
+	return report, nil
+}
+
+// cleanupOrphan kills the tmux session of the orphaned instance and removes its worktree. It returns what was
+// left in place and why, if anything.
+func cleanupOrphan(data InstanceData) string {
+	instance, err := fromInstanceData(data, true)
+	if err != nil {
+		return fmt.Sprintf("%s: %v", data.Title, err)
+	}
+	if err := instance.tmuxSession.Kill(false); err != nil {
+		log.WarningLog.Printf("failed to kill the tmux session of %s: %v", data.Title, err)
+	}
+
+	worktree := instance.gitWorktree
+	switch {
+	case data.Worktree.WorktreePath == "":
+		return ""
+	case data.Worktree.External:
+		return fmt.Sprintf("%s: %s wasn't created by claude-squad", data.Title, data.Worktree.WorktreePath)
+	}
+	if _, err := os.Stat(data.Worktree.RepoPath); err != nil {
+		return fmt.Sprintf("%s: worktree %s is kept since its repository %s is gone", data.Title,
+			data.Worktree.WorktreePath, data.Worktree.RepoPath)
+	}
+	if err := worktree.Remove(); err != nil {
+		return fmt.Sprintf("%s: %v", data.Title, err)
+	}
+	if err := worktree.Prune(); err != nil {
+		log.WarningLog.Printf("%v", err)
+	}
+	return ""
 }
 
 // AssociateInstanceWithRepository associates an instance with a repository
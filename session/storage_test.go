@@ -0,0 +1,298 @@
+package session
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"claude-squad/config"
+	"claude-squad/log"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreInstancesIsolatesFailures(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	state := config.LoadState()
+	storage, err := NewStorage(state)
+	require.NoError(t, err)
+
+	stored := []InstanceData{
+		{Title: "paused", Status: Paused, Program: "sh"},
+		// An instance without a title can't be started, so it fails to restore.
+		{Title: "", Status: Running, Program: "sh"},
+	}
+	raw, err := json.Marshal(stored)
+	require.NoError(t, err)
+	require.NoError(t, state.SaveInstances(raw))
+
+	var progress []int
+	instances, err := storage.RestoreInstances(func(done, total int) {
+		require.Equal(t, 2, total)
+		progress = append(progress, done)
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "instance title cannot be empty")
+	require.Len(t, instances, 1)
+	require.Equal(t, "paused", instances[0].Title)
+	require.ElementsMatch(t, []int{1, 2}, progress)
+
+	// Saving the restored instances keeps the data of the one that failed.
+	require.NoError(t, storage.SaveInstances(instances))
+	data, err := storage.LoadInstanceData()
+	require.NoError(t, err)
+	require.Len(t, data, 2)
+	require.Equal(t, "paused", data[0].Title)
+	require.Equal(t, Running, data[1].Status)
+}
+
+func TestRestoreInstancesLazily(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	state := config.LoadState()
+	storage, err := NewStorage(state)
+	require.NoError(t, err)
+	storage.SetLazyRestore(true)
+
+	stored := []InstanceData{
+		{Title: "paused", Status: Paused, Program: "sh"},
+		{Title: "running", Status: Running, Program: "sh"},
+	}
+	raw, err := json.Marshal(stored)
+	require.NoError(t, err)
+	require.NoError(t, state.SaveInstances(raw))
+
+	instances, err := storage.RestoreInstances(nil)
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+
+	require.False(t, instances[0].Dormant())
+	// The running instance is restored without its tmux session, and isn't polled until it's woken.
+	require.True(t, instances[1].Started())
+	require.True(t, instances[1].Dormant())
+	updated, prompt := instances[1].HasUpdated()
+	require.False(t, updated)
+	require.False(t, prompt)
+	require.Equal(t, Running, instances[1].Status)
+}
+
+func TestRestoreStoppedInstances(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	state := config.LoadState()
+	storage, err := NewStorage(state)
+	require.NoError(t, err)
+
+	worktree := t.TempDir()
+	stored := []InstanceData{
+		{Title: "stopped", Status: Stopped, Program: "sh", Worktree: GitWorktreeData{WorktreePath: worktree}},
+		{Title: "purged", Status: Stopped, Program: "sh", Worktree: GitWorktreeData{WorktreePath: filepath.Join(worktree, "gone")}},
+	}
+	raw, err := json.Marshal(stored)
+	require.NoError(t, err)
+	require.NoError(t, state.SaveInstances(raw))
+
+	instances, err := storage.RestoreInstances(nil)
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+
+	// Stopped instances keep their worktree but aren't restarted.
+	stopped := instances[0]
+	require.True(t, stopped.Started())
+	require.False(t, stopped.Dormant())
+	require.True(t, stopped.Inactive())
+	require.Zero(t, stopped.Uptime())
+	require.ErrorContains(t, stopped.Stop(), "already stopped")
+
+	require.ErrorContains(t, instances[1].Resume(), "worktree")
+	require.Equal(t, Stopped, instances[1].Status)
+}
+
+func TestRepositoryManagerCleanup(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	log.Initialize(false)
+	defer log.Close()
+
+	state := config.LoadState()
+	storage, err := NewStorage(state)
+	require.NoError(t, err)
+
+	repo := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(repo, ".git"), 0755))
+	gone := filepath.Join(t.TempDir(), "gone")
+	require.NoError(t, state.AddRepository(config.RepositoryData{Path: repo}))
+	require.NoError(t, state.AddRepository(config.RepositoryData{Path: gone}))
+
+	worktree := t.TempDir()
+	stored := []InstanceData{
+		{Title: "kept", Status: Paused, Program: "sh", RepositoryPath: repo},
+		{Title: "orphan", Status: Paused, Program: "sh", RepositoryPath: gone,
+			Worktree: GitWorktreeData{RepoPath: gone, WorktreePath: worktree}},
+	}
+	raw, err := json.Marshal(stored)
+	require.NoError(t, err)
+	require.NoError(t, state.SaveInstances(raw))
+
+	rm := config.NewRepositoryManager(state, storage)
+	report, err := rm.Cleanup()
+	require.NoError(t, err)
+	require.Equal(t, []string{gone}, report.Repositories)
+	require.Equal(t, []string{"orphan"}, report.Instances)
+	// The worktree of a repository that's gone isn't removed.
+	require.Len(t, report.Kept, 1)
+	require.Contains(t, report.Kept[0], worktree)
+	require.DirExists(t, worktree)
+
+	data, err := storage.LoadInstanceData()
+	require.NoError(t, err)
+	require.Len(t, data, 1)
+	require.Equal(t, "kept", data[0].Title)
+
+	repos, err := rm.GetRepositoriesWithCounts()
+	require.NoError(t, err)
+	require.Len(t, repos, 1)
+	require.Equal(t, 1, repos[0].InstanceCount)
+
+	report, err = rm.RemoveRepositoryAndCleanup(repo)
+	require.NoError(t, err)
+	require.Equal(t, []string{repo}, report.Repositories)
+	require.Equal(t, []string{"kept"}, report.Instances)
+}
+
+func TestDiffsStoredOutsideState(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	state := config.LoadState()
+	storage, err := NewStorage(state)
+	require.NoError(t, err)
+
+	content := "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-old\n+new\n"
+	stored := []InstanceData{
+		{Title: "paused", Status: Paused, Program: "sh", DiffStats: DiffStatsData{Added: 1, Removed: 1, Content: content}},
+	}
+	raw, err := json.Marshal(stored)
+	require.NoError(t, err)
+	require.NoError(t, state.SaveInstances(raw))
+
+	instances, err := storage.RestoreInstances(nil)
+	require.NoError(t, err)
+	require.NoError(t, storage.SaveInstances(instances))
+
+	// The state refers to the diff's own file instead of embedding it.
+	data, err := storage.LoadInstanceData()
+	require.NoError(t, err)
+	require.Empty(t, data[0].DiffStats.Content)
+	require.Equal(t, 1, data[0].DiffStats.Added)
+	require.NotEmpty(t, data[0].DiffStats.File)
+
+	// It's loaded once it's needed, and saving before then keeps it.
+	instances, err = storage.RestoreInstances(nil)
+	require.NoError(t, err)
+	require.NoError(t, storage.SaveInstances(instances))
+	stats := instances[0].GetDiffStats()
+	require.Equal(t, content, stats.Content)
+	require.Len(t, stats.Files, 1)
+
+	dir, err := diffDir()
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(dir, data[0].DiffStats.File))
+	require.NoError(t, storage.DeleteInstance("paused"))
+	require.NoFileExists(t, filepath.Join(dir, data[0].DiffStats.File))
+}
+
+func TestSaveKeepsDiffsOfOtherProcesses(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	content := "diff --git a/main.go b/main.go\n--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-old\n+new\n"
+	stored := []InstanceData{
+		{Title: "theirs", Status: Paused, Program: "sh", DiffStats: DiffStatsData{Added: 1, Removed: 1, Content: content}},
+	}
+	raw, err := json.Marshal(stored)
+	require.NoError(t, err)
+
+	// Another process saves an instance with a diff after this one loaded the state.
+	ours, err := NewStorage(config.LoadState())
+	require.NoError(t, err)
+	theirState := config.LoadState()
+	require.NoError(t, theirState.SaveInstances(raw))
+	theirs, err := NewStorage(theirState)
+	require.NoError(t, err)
+	instances, err := theirs.RestoreInstances(nil)
+	require.NoError(t, err)
+	require.NoError(t, theirs.SaveInstances(instances))
+	data, err := theirs.LoadInstanceData()
+	require.NoError(t, err)
+	require.NotEmpty(t, data[0].DiffStats.File)
+
+	// Saving this process's instances, which don't include it yet, keeps its diff.
+	require.NoError(t, ours.SaveInstances(nil))
+	dir, err := diffDir()
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(dir, data[0].DiffStats.File))
+}
+
+func TestCompressedDiffs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	name := diffFileName("instance", true)
+	content := strings.Repeat("+line\n", 1000)
+	require.NoError(t, writeDiff(name, content))
+
+	dir, err := diffDir()
+	require.NoError(t, err)
+	info, err := os.Stat(filepath.Join(dir, name))
+	require.NoError(t, err)
+	require.Less(t, info.Size(), int64(len(content)))
+
+	loaded, err := readDiff(name)
+	require.NoError(t, err)
+	require.Equal(t, content, loaded)
+}
+
+func TestEncryptedDiffs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(config.StateKeyEnv, base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+	configDir, err := config.GetConfigDir()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(configDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, config.ConfigFileName), []byte(`{"encrypt_state": true}`), 0644))
+
+	name := diffFileName("instance", false)
+	content := "+secret-line\n"
+	require.NoError(t, writeDiff(name, content))
+
+	dir, err := diffDir()
+	require.NoError(t, err)
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "secret-line")
+	info, err := os.Stat(filepath.Join(dir, name))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	loaded, err := readDiff(name)
+	require.NoError(t, err)
+	require.Equal(t, content, loaded)
+}
+
+func TestLoadInstanceDataLeavesOutUndecodableInstances(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	t.Setenv("HOME", t.TempDir())
+
+	state := config.LoadState()
+	storage, err := NewStorage(state)
+	require.NoError(t, err)
+	require.NoError(t, state.SaveInstances(json.RawMessage(`[{"title": "a"}, {"title": 5}, {"title": "c"}]`)))
+
+	data, err := storage.LoadInstanceData()
+	require.NoError(t, err)
+	require.Len(t, data, 2)
+	require.Equal(t, "a", data[0].Title)
+	require.Equal(t, "c", data[1].Title)
+}
@@ -0,0 +1,41 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SummaryFileName is the file in the worktree the agent is asked to write its summary to. It's removed
+// once the summary has been captured.
+const SummaryFileName = ".claude-squad-summary.md"
+
+// RequestSummary asks the agent to summarize its work using prompt. The summary is captured with
+// CaptureSummary once the agent is done.
+func (i *Instance) RequestSummary(prompt string) error {
+	err := i.SendPrompt(fmt.Sprintf("%s\n\nWrite the summary to %s in the repository root and don't change anything else.",
+		strings.TrimSpace(prompt), SummaryFileName))
+	if err != nil {
+		return err
+	}
+	i.summaryPending = true
+	return nil
+}
+
+// SummaryPending reports whether a summary was requested and hasn't been captured yet.
+func (i *Instance) SummaryPending() bool {
+	return i.summaryPending
+}
+
+// CaptureSummary captures the summary the agent wrote to SummaryFileName.
+func (i *Instance) CaptureSummary() error {
+	if !i.summaryPending {
+		return fmt.Errorf("no summary was requested from instance %s", i.Title)
+	}
+	summary, err := i.captureWrittenOutput(SummaryFileName)
+	if err != nil {
+		return fmt.Errorf("failed to capture summary: %w", err)
+	}
+	i.Summary = summary
+	i.summaryPending = false
+	return nil
+}
@@ -0,0 +1,29 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"claude-squad/session/git"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureSummary(t *testing.T) {
+	dir := t.TempDir()
+	instance := &Instance{
+		Title:       "summary",
+		gitWorktree: git.NewGitWorktreeFromStorage(dir, dir, "summary", "summary", ""),
+	}
+	require.Error(t, instance.CaptureSummary(), "no summary was requested")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, SummaryFileName), []byte("Changed x.\nTODO: y\n"), 0644))
+	instance.summaryPending = true
+	require.NoError(t, instance.CaptureSummary())
+	require.Equal(t, "Changed x.\nTODO: y", instance.Summary)
+	require.False(t, instance.SummaryPending())
+
+	_, err := os.Stat(filepath.Join(dir, SummaryFileName))
+	require.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,101 @@
+package session
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxTitleLength matches the limit on titles entered by the user.
+const maxTitleLength = 32
+
+// placeholderTitleRegex matches throwaway names like "test2", "tmp" or "asdf".
+var placeholderTitleRegex = regexp.MustCompile(`^(?i)(test|tmp|temp|foo|bar|new|untitled|session|instance|asdf|qwer|wip|x+|a+)[-_ ]?\d*$`)
+
+// promptFillerWords are dropped from the start of a prompt when turning it into a title.
+var promptFillerWords = map[string]bool{
+	"please": true, "can": true, "could": true, "would": true, "you": true, "i": true, "we": true,
+	"want": true, "need": true, "like": true, "to": true, "help": true, "me": true, "let's": true,
+}
+
+// isPlaceholderTitle returns true if title looks like a name the user didn't put any thought into.
+func isPlaceholderTitle(title string) bool {
+	return len(strings.TrimSpace(title)) <= 2 || placeholderTitleRegex.MatchString(strings.TrimSpace(title))
+}
+
+// suggestTitle derives a title from the first prompt sent to the instance or, failing that, from the
+// file with the most changed lines in diff. It returns "" if there's nothing to go on.
+func suggestTitle(prompt, diff string) string {
+	if title := titleFromPrompt(prompt); title != "" {
+		return title
+	}
+	return titleFromDiff(diff)
+}
+
+func titleFromPrompt(prompt string) string {
+	words := strings.Fields(strings.ToLower(prompt))
+	for len(words) > 0 && promptFillerWords[strings.Trim(words[0], ",.!?")] {
+		words = words[1:]
+	}
+
+	var title string
+	for _, word := range words {
+		word = strings.Trim(word, ",.!?:;\"'`()")
+		if word == "" {
+			continue
+		}
+		if len(title)+len(word)+1 > maxTitleLength {
+			break
+		}
+		if title != "" {
+			title += " "
+		}
+		title += word
+	}
+	return title
+}
+
+func titleFromDiff(diff string) string {
+	var topFile string
+	var topChanges int
+	var topIsNew bool
+
+	var file string
+	var changes int
+	var isNew bool
+	flush := func() {
+		if file != "" && changes > topChanges {
+			topFile, topChanges, topIsNew = file, changes, isNew
+		}
+	}
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			fields := strings.Fields(line)
+			file = strings.TrimPrefix(fields[len(fields)-1], "b/")
+			changes, isNew = 0, false
+		case strings.HasPrefix(line, "new file mode"):
+			isNew = true
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"), strings.HasPrefix(line, "-"):
+			changes++
+		}
+	}
+	flush()
+
+	if topFile == "" {
+		return ""
+	}
+	verb := "update"
+	if topIsNew {
+		verb = "add"
+	}
+	name := strings.TrimSuffix(filepath.Base(topFile), filepath.Ext(topFile))
+	title := fmt.Sprintf("%s %s", verb, name)
+	if len(title) > maxTitleLength {
+		title = title[:maxTitleLength]
+	}
+	return title
+}
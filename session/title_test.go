@@ -0,0 +1,38 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPlaceholderTitle(t *testing.T) {
+	for _, title := range []string{"test2", "tmp", "asdf", "New 3", "xx", "a1"} {
+		require.True(t, isPlaceholderTitle(title), title)
+	}
+	for _, title := range []string{"fix login redirect", "retry-queue", "tests for api"} {
+		require.False(t, isPlaceholderTitle(title), title)
+	}
+}
+
+func TestSuggestTitle(t *testing.T) {
+	require.Equal(t, "add retry logic to the push", suggestTitle("Please add retry logic to the push command, it fails offline.", ""))
+
+	diff := `diff --git a/app/app.go b/app/app.go
+--- a/app/app.go
++++ b/app/app.go
+@@ -1,2 +1,2 @@
+-old
++new
+diff --git a/retry/queue.go b/retry/queue.go
+new file mode 100644
+--- /dev/null
++++ b/retry/queue.go
+@@ -0,0 +1,3 @@
++package retry
++
++type Queue struct{}
+`
+	require.Equal(t, "add queue", suggestTitle("", diff))
+	require.Equal(t, "", suggestTitle("", ""))
+}
@@ -0,0 +1,126 @@
+package tmux
+
+import (
+	"claude-squad/log"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// DetachKey is the control key that returns from an attached session to claude-squad.
+type DetachKey struct {
+	// Name is how the key is shown to the user, e.g. "ctrl-q".
+	Name string
+	// code is the byte the terminal sends for the key.
+	code byte
+	// tmuxName is tmux's name for the key, e.g. "C-q".
+	tmuxName string
+}
+
+// DefaultDetachKey is ctrl-q, which the agents don't use.
+var DefaultDetachKey = DetachKey{Name: "ctrl-q", code: 0x11, tmuxName: "C-q"}
+
+var (
+	detachKey  = DefaultDetachKey
+	attachHelp = true
+)
+
+// reservedDetachKeys are the control keys the terminal sends for keys programs need, like enter and tab, or
+// that interrupt them.
+var reservedDetachKeys = map[byte]string{
+	'c': "it interrupts programs",
+	'h': "it's sent by backspace",
+	'i': "it's sent by tab",
+	'j': "it's sent by enter",
+	'm': "it's sent by enter",
+}
+
+// ParseDetachKey parses a detach key like "ctrl-q", "ctrl+]" or "C-b". An empty name is the default key.
+func ParseDetachKey(name string) (DetachKey, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if key == "" {
+		return DefaultDetachKey, nil
+	}
+	prefixed := false
+	for _, prefix := range []string{"ctrl-", "ctrl+", "c-", "^"} {
+		if rest, ok := strings.CutPrefix(key, prefix); ok {
+			key, prefixed = rest, true
+			break
+		}
+	}
+	if !prefixed || len(key) != 1 {
+		return DetachKey{}, fmt.Errorf("invalid detach key %q: expected a control key like ctrl-q", name)
+	}
+	c := key[0]
+	var code byte
+	switch {
+	case c >= 'a' && c <= 'z':
+		code = c - 'a' + 1
+	case c == '\\':
+		code = 0x1c
+	case c == ']':
+		code = 0x1d
+	default:
+		return DetachKey{}, fmt.Errorf("invalid detach key %q: only ctrl with a letter, \\ or ] can be used", name)
+	}
+	if reason, ok := reservedDetachKeys[c]; ok {
+		return DetachKey{}, fmt.Errorf("ctrl-%c can't be the detach key: %s", c, reason)
+	}
+	return DetachKey{Name: "ctrl-" + key, code: code, tmuxName: "C-" + key}, nil
+}
+
+// SetDetachKey sets the key that returns from attached sessions to claude-squad.
+func SetDetachKey(key DetachKey) {
+	detachKey = key
+}
+
+// GetDetachKey returns the key that returns from attached sessions to claude-squad.
+func GetDetachKey() DetachKey {
+	return detachKey
+}
+
+// SetAttachHelp sets whether attached sessions show a status line reminding how to return to claude-squad.
+func SetAttachHelp(enabled bool) {
+	attachHelp = enabled
+}
+
+// attachHelpOptions are the session options that show the reminder of the detach key in the status line.
+func attachHelpOptions() [][2]string {
+	return [][2]string{
+		{"status", "on"},
+		{"status-right", fmt.Sprintf(" %s: back to claude-squad ", detachKey.Name)},
+	}
+}
+
+// showAttachHelp turns on the session's status line with a reminder of the detach key. New users otherwise
+// get stuck in attached sessions, which look just like a plain terminal.
+func (t *TmuxSession) showAttachHelp() {
+	if !attachHelp {
+		return
+	}
+	for _, option := range attachHelpOptions() {
+		setCmd := exec.Command("tmux", "set-option", "-t", t.sanitizedName, option[0], option[1])
+		if err := t.cmdExec.Run(setCmd); err != nil {
+			log.ErrorLog.Printf("error showing the detach key in tmux session %s: %v", t.sanitizedName, err)
+			return
+		}
+	}
+}
+
+// hideAttachHelp restores the status line options showAttachHelp changed, so the preview keeps the whole
+// window: to the configured tmux option if there's one, or else to the global one.
+func (t *TmuxSession) hideAttachHelp() {
+	if !attachHelp {
+		return
+	}
+	for _, option := range attachHelpOptions() {
+		setCmd := exec.Command("tmux", "set-option", "-t", t.sanitizedName, "-u", option[0])
+		if value, ok := t.options[option[0]]; ok {
+			setCmd = exec.Command("tmux", "set-option", "-t", t.sanitizedName, option[0], value)
+		}
+		if err := t.cmdExec.Run(setCmd); err != nil {
+			log.ErrorLog.Printf("error hiding the detach key in tmux session %s: %v", t.sanitizedName, err)
+			return
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package tmux
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// killAttempts is the number of times Kill tries to end the session before giving up.
+const killAttempts = 3
+
+// killTimeout is how long Kill waits after each attempt for the session and its programs to be gone.
+var killTimeout = 2 * time.Second
+
+// Kill terminates the session and verifies that it's gone along with the programs that ran in its panes,
+// which can outlive it, e.g. when they ignore the hangup tmux sends them. Programs that survive are sent
+// SIGTERM and the session is killed again; if force is set, they're then killed with SIGKILL. A session that
+// doesn't exist is already killed.
+func (t *TmuxSession) Kill(force bool) error {
+	pids := t.panePIDs()
+	var err error
+	for attempt := 1; attempt <= killAttempts; attempt++ {
+		switch {
+		case attempt == killAttempts && force:
+			signalProcesses(pids, true)
+		case attempt > 1:
+			signalProcesses(pids, false)
+		}
+		if t.DoesSessionExist() {
+			if closeErr := t.Close(); closeErr != nil && t.DoesSessionExist() {
+				err = closeErr
+				continue
+			}
+		} else if t.ptmx != nil {
+			t.ptmx.Close()
+			t.ptmx = nil
+		}
+		if err = t.waitForExit(pids); err == nil {
+			return nil
+		}
+	}
+	if !force {
+		return fmt.Errorf("%w; force-kill the session to kill its programs", err)
+	}
+	return err
+}
+
+// panePIDs returns the process IDs of the programs running in the session's panes.
+func (t *TmuxSession) panePIDs() []int {
+	output, err := t.cmdExec.Output(exec.Command("tmux", "list-panes", "-s", "-t", t.sanitizedName, "-F", "#{pane_pid}"))
+	if err != nil {
+		return nil
+	}
+	var pids []int
+	for _, field := range strings.Fields(string(output)) {
+		if pid, err := strconv.Atoi(field); err == nil && pid > 0 {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// waitForExit waits up to killTimeout for the session to be gone and the processes pids to exit.
+func (t *TmuxSession) waitForExit(pids []int) error {
+	deadline := time.Now().Add(killTimeout)
+	for {
+		var alive []string
+		for _, pid := range pids {
+			if processAlive(pid) {
+				alive = append(alive, strconv.Itoa(pid))
+			}
+		}
+		exists := t.DoesSessionExist()
+		if !exists && len(alive) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			if exists {
+				return fmt.Errorf("tmux session %s still exists after being killed", t.sanitizedName)
+			}
+			return fmt.Errorf("processes %s of tmux session %s are still running", strings.Join(alive, ", "), t.sanitizedName)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// signalProcesses asks the processes pids to exit, or kills them outright if force is set.
+func signalProcesses(pids []int, force bool) {
+	for _, pid := range pids {
+		if processAlive(pid) {
+			terminateProcess(pid, force)
+		}
+	}
+}
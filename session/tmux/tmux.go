@@ -12,6 +12,7 @@ import (
 	"os"
 	"os/exec"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -35,6 +36,10 @@ type TmuxSession struct {
 	ptyFactory PtyFactory
 	// cmdExec is used to execute commands in the tmux session.
 	cmdExec cmd.Executor
+	// options are the tmux options applied to the session when it's started.
+	options map[string]string
+	// panes are the side panes created below the program's pane when the session is started.
+	panes []Pane
 
 	// Initialized by Start or Restore
 	//
@@ -59,12 +64,33 @@ type TmuxSession struct {
 
 const TmuxPrefix = "claudesquad_"
 
+// profilePrefix starts the names of the sessions of profiles other than the default one, followed by the
+// profile's name.
+const profilePrefix = "claudesquad-"
+
+// sessionPrefix is the prefix of the names of the current profile's sessions.
+var sessionPrefix = TmuxPrefix
+
 var whiteSpaceRegex = regexp.MustCompile(`\s+`)
 
 func toClaudeSquadTmuxName(str string) string {
 	str = whiteSpaceRegex.ReplaceAllString(str, "")
 	str = strings.ReplaceAll(str, ".", "_") // tmux replaces all . with _
-	return fmt.Sprintf("%s%s", TmuxPrefix, str)
+	return fmt.Sprintf("%s%s", sessionPrefix, str)
+}
+
+// SetProfile namespaces the sessions by profile, so instances of different profiles can have the same title
+// and cleaning up one profile's sessions leaves the others' alone. The default profile, "", keeps TmuxPrefix.
+func SetProfile(profile string) {
+	sessionPrefix = TmuxPrefix
+	if profile != "" {
+		sessionPrefix = profilePrefix + profile + "_"
+	}
+}
+
+// isSquadSession returns true if the session called name was created by claude-squad, in any profile.
+func isSquadSession(name string) bool {
+	return strings.HasPrefix(name, TmuxPrefix) || strings.HasPrefix(name, profilePrefix)
 }
 
 // NewTmuxSession creates a new TmuxSession with the given name and program.
@@ -72,15 +98,27 @@ func NewTmuxSession(name string, program string) *TmuxSession {
 	return newTmuxSession(name, program, MakePtyFactory(), cmd.MakeExecutor())
 }
 
+// NewTmuxSessionWithDeps creates a new TmuxSession that starts its PTYs with ptyFactory and runs tmux
+// through cmdExec, e.g. to drive it without tmux in tests.
+func NewTmuxSessionWithDeps(name string, program string, ptyFactory PtyFactory, cmdExec cmd.Executor) *TmuxSession {
+	return newTmuxSession(name, program, ptyFactory, cmdExec)
+}
+
 func newTmuxSession(name string, program string, ptyFactory PtyFactory, cmdExec cmd.Executor) *TmuxSession {
 	return &TmuxSession{
 		sanitizedName: toClaudeSquadTmuxName(name),
 		program:       program,
 		ptyFactory:    ptyFactory,
 		cmdExec:       cmdExec,
+		monitor:       newStatusMonitor(),
 	}
 }
 
+// Name returns the name of the tmux session.
+func (t *TmuxSession) Name() string {
+	return t.sanitizedName
+}
+
 // Start creates and starts a new tmux session, then attaches to it. Program is the command to run in
 // the session (ex. claude). workdir is the git worktree directory.
 func (t *TmuxSession) Start(workDir string) error {
@@ -89,8 +127,14 @@ func (t *TmuxSession) Start(workDir string) error {
 		return fmt.Errorf("tmux session already exists: %s", t.sanitizedName)
 	}
 
-	// Create a new detached tmux session and start claude in it
-	cmd := exec.Command("tmux", "new-session", "-d", "-s", t.sanitizedName, "-c", workDir, t.program)
+	// Create a new detached tmux session and start claude in it. If there are options to apply, the session
+	// starts with a placeholder that's replaced once they're set, since some options, like history-limit,
+	// only affect panes created afterwards.
+	program := t.program
+	if len(t.options) > 0 {
+		program = "cat"
+	}
+	cmd := exec.Command("tmux", "new-session", "-d", "-s", t.sanitizedName, "-c", workDir, program)
 
 	ptmx, err := t.ptyFactory.Start(cmd)
 	if err != nil {
@@ -121,6 +165,21 @@ func (t *TmuxSession) Start(workDir string) error {
 	}
 	ptmx.Close()
 
+	if len(t.options) > 0 {
+		if err := t.applyOptions(workDir); err != nil {
+			if cleanupErr := t.Close(); cleanupErr != nil {
+				err = fmt.Errorf("%v (cleanup error: %v)", err, cleanupErr)
+			}
+			return err
+		}
+	}
+	if err := t.createPanes(workDir); err != nil {
+		if cleanupErr := t.Close(); cleanupErr != nil {
+			err = fmt.Errorf("%v (cleanup error: %v)", err, cleanupErr)
+		}
+		return err
+	}
+
 	err = t.Restore()
 	if err != nil {
 		if cleanupErr := t.Close(); cleanupErr != nil {
@@ -156,6 +215,65 @@ func (t *TmuxSession) Start(workDir string) error {
 	return nil
 }
 
+// SetOptions sets the tmux options, such as history-limit or status, applied to the session when it's
+// started. Server options, like default-terminal, apply to the whole tmux server.
+func (t *TmuxSession) SetOptions(options map[string]string) {
+	t.options = options
+}
+
+// Pane is a side pane running a command next to the program, such as a test watcher or a dev server.
+type Pane struct {
+	// Command is the shell command the pane runs.
+	Command string
+	// Size is the pane's height as a percentage of the window. If 0, tmux halves the pane it splits.
+	Size int
+}
+
+// SetPanes sets the side panes created below the program's pane when the session is started. They're
+// shown in the preview and killed with the session.
+func (t *TmuxSession) SetPanes(panes []Pane) {
+	t.panes = panes
+}
+
+// createPanes creates the side panes, stacked below the program's pane in order.
+func (t *TmuxSession) createPanes(workDir string) error {
+	for _, pane := range t.panes {
+		args := []string{"split-window", "-d", "-v", "-t", t.sanitizedName + ":0.{bottom}", "-c", workDir}
+		if pane.Size > 0 {
+			args = append(args, "-l", fmt.Sprintf("%d%%", pane.Size))
+		}
+		if err := t.cmdExec.Run(exec.Command("tmux", append(args, pane.Command)...)); err != nil {
+			return fmt.Errorf("error creating tmux pane for %q: %w", pane.Command, err)
+		}
+	}
+	return nil
+}
+
+// agentTarget is the tmux target of the pane running the program.
+func (t *TmuxSession) agentTarget() string {
+	return t.sanitizedName + ":0.0"
+}
+
+// applyOptions sets the session's options, then replaces the placeholder window with the program's.
+func (t *TmuxSession) applyOptions(workDir string) error {
+	names := make([]string, 0, len(t.options))
+	for name := range t.options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		setCmd := exec.Command("tmux", "set-option", "-t", t.sanitizedName, name, t.options[name])
+		if err := t.cmdExec.Run(setCmd); err != nil {
+			return fmt.Errorf("error setting tmux option %s: %w", name, err)
+		}
+	}
+	replaceCmd := exec.Command("tmux", "new-window", "-k", "-t", t.sanitizedName+":0", "-c", workDir, t.program)
+	if err := t.cmdExec.Run(replaceCmd); err != nil {
+		return fmt.Errorf("error starting program in tmux session: %w", err)
+	}
+	return nil
+}
+
 // Restore attaches to an existing session and restores the window size
 func (t *TmuxSession) Restore() error {
 	ptmx, err := t.ptyFactory.Start(exec.Command("tmux", "attach-session", "-t", t.sanitizedName))
@@ -170,10 +288,19 @@ func (t *TmuxSession) Restore() error {
 type statusMonitor struct {
 	// Store hashes to save memory.
 	prevOutputHash []byte
+	// prevStallHash is the hash of the pane content without the program's working indicator.
+	prevStallHash []byte
+	// lastChange is when the pane content last changed, leaving aside the working indicator, whose spinner
+	// and timer redraw every second while the program works.
+	lastChange time.Time
+	// busy is whether the program showed its working indicator at the last check.
+	busy bool
+	// progressed is whether the pane content changed at the last check, leaving aside the working indicator.
+	progressed bool
 }
 
 func newStatusMonitor() *statusMonitor {
-	return &statusMonitor{}
+	return &statusMonitor{lastChange: time.Now()}
 }
 
 // hash hashes the string.
@@ -225,11 +352,96 @@ func (t *TmuxSession) HasUpdated() (updated bool, hasPrompt bool) {
 		hasPrompt = strings.Contains(content, "Yes, allow once")
 	}
 
-	if !bytes.Equal(t.monitor.hash(content), t.monitor.prevOutputHash) {
-		t.monitor.prevOutputHash = t.monitor.hash(content)
-		return true, hasPrompt
+	return t.monitor.update(t.program, content), hasPrompt
+}
+
+// update records the pane content and returns true if it changed since the last call.
+func (m *statusMonitor) update(program, content string) bool {
+	m.busy = isBusy(program, content)
+
+	m.progressed = false
+	if stallHash := m.hash(withoutBusyLines(program, content)); !bytes.Equal(stallHash, m.prevStallHash) {
+		m.prevStallHash = stallHash
+		m.lastChange = time.Now()
+		m.progressed = true
+	}
+	if outputHash := m.hash(content); !bytes.Equal(outputHash, m.prevOutputHash) {
+		m.prevOutputHash = outputHash
+		return true
 	}
-	return false, hasPrompt
+	return false
+}
+
+// busyIndicator returns the text of the program's working indicator, or "" if it has no known one.
+func busyIndicator(program string) string {
+	if program == ProgramClaude {
+		return "esc to interrupt"
+	} else if strings.HasPrefix(program, ProgramGemini) {
+		return "esc to cancel"
+	}
+	return ""
+}
+
+// isBusy reports whether the pane content shows the program's working indicator. Programs without a
+// known indicator are never considered busy.
+func isBusy(program, content string) bool {
+	indicator := busyIndicator(program)
+	return indicator != "" && strings.Contains(content, indicator)
+}
+
+// withoutBusyLines returns the pane content without the lines showing the program's working indicator.
+func withoutBusyLines(program, content string) string {
+	indicator := busyIndicator(program)
+	if indicator == "" || !strings.Contains(content, indicator) {
+		return content
+	}
+	lines := strings.Split(content, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.Contains(line, indicator) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// Busy reports whether the program was working at the last call to HasUpdated.
+func (t *TmuxSession) Busy() bool {
+	return t.monitor.busy
+}
+
+// Progressed reports whether the pane content changed at the last call to HasUpdated other than in the
+// program's working indicator, whose spinner and timer redraw every second while it works.
+func (t *TmuxSession) Progressed() bool {
+	return t.monitor.progressed
+}
+
+// LastOutputChange returns when the pane content last changed, as observed by HasUpdated.
+func (t *TmuxSession) LastOutputChange() time.Time {
+	return t.monitor.lastChange
+}
+
+// Interrupt asks the program to stop what it's doing. Claude and Gemini stop on escape, other
+// programs get ctrl-c.
+func (t *TmuxSession) Interrupt() error {
+	key := []byte{0x03}
+	if t.program == ProgramClaude || strings.HasPrefix(t.program, ProgramGemini) {
+		key = []byte{0x1B}
+	}
+	if _, err := t.ptmx.Write(key); err != nil {
+		return fmt.Errorf("error sending interrupt to PTY: %w", err)
+	}
+	return nil
+}
+
+// RestartProgram kills the program running in the pane and starts it again in workDir.
+func (t *TmuxSession) RestartProgram(workDir string) error {
+	cmd := exec.Command("tmux", "respawn-pane", "-k", "-t", t.agentTarget(), "-c", workDir, t.program)
+	if err := t.cmdExec.Run(cmd); err != nil {
+		return fmt.Errorf("error restarting program in tmux session %s: %w", t.sanitizedName, err)
+	}
+	t.monitor = newStatusMonitor()
+	return nil
 }
 
 func (t *TmuxSession) Attach() (chan struct{}, error) {
@@ -249,9 +461,11 @@ func (t *TmuxSession) AttachToWindow(windowName string) (chan struct{}, error) {
 		target = fmt.Sprintf("%s:%s", t.sanitizedName, windowName)
 	}
 	
+	t.showAttachHelp()
 	// Create new PTY connection to the specific window
 	ptmx, err := t.ptyFactory.Start(exec.Command("tmux", "attach-session", "-t", target))
 	if err != nil {
+		t.hideAttachHelp()
 		return nil, fmt.Errorf("error opening PTY to window: %w", err)
 	}
 	t.ptmx = ptmx
@@ -279,7 +493,7 @@ func (t *TmuxSession) AttachToWindow(windowName string) (chan struct{}, error) {
 		default:
 			// If context is not done, it was likely an abnormal termination (Ctrl-D)
 			// Print warning message
-			fmt.Fprintf(os.Stderr, "\n\033[31mError: Session terminated without detaching. Use Ctrl-Q to properly detach from tmux sessions.\033[0m\n")
+			fmt.Fprintf(os.Stderr, "\n\033[31mError: Session terminated without detaching. Use %s to properly detach from tmux sessions.\033[0m\n", detachKey.Name)
 		}
 	}()
 
@@ -291,7 +505,7 @@ func (t *TmuxSession) AttachToWindow(windowName string) (chan struct{}, error) {
 			close(timeoutCh)
 		}()
 
-		// Read input from stdin and check for Ctrl+q
+		// Read input from stdin and check for the detach key
 		buf := make([]byte, 32)
 		for {
 			nr, err := os.Stdin.Read(buf)
@@ -316,8 +530,8 @@ func (t *TmuxSession) AttachToWindow(windowName string) (chan struct{}, error) {
 				continue
 			}
 
-			// Check for Ctrl+q (ASCII 17)
-			if nr == 1 && buf[0] == 17 {
+			// Check for the detach key, e.g. ctrl-q (ASCII 17)
+			if nr == 1 && buf[0] == detachKey.code {
 				// Detach from the session
 				t.Detach()
 				return
@@ -332,6 +546,46 @@ func (t *TmuxSession) AttachToWindow(windowName string) (chan struct{}, error) {
 	return t.attachCh, nil
 }
 
+// InsideTmux returns true if claude-squad itself runs inside tmux. Its instances' sessions then live on
+// the same tmux server.
+func InsideTmux() bool {
+	return os.Getenv("TMUX") != ""
+}
+
+// SwitchClient switches the tmux client claude-squad runs in to a window of the session, instead of
+// attaching to it in a nested tmux. If windowName is empty, it switches to the session's current window.
+// The detach key is bound to switch back to claude-squad's session from any instance's session. The session's
+// status line keeps the reminder of the detach key, since switching back happens in tmux.
+func (t *TmuxSession) SwitchClient(windowName string) error {
+	args := []string{"display-message", "-p"}
+	if pane := os.Getenv("TMUX_PANE"); pane != "" {
+		args = append(args, "-t", pane)
+	}
+	output, err := t.cmdExec.Output(exec.Command("tmux", append(args, "#{session_name}")...))
+	if err != nil {
+		return fmt.Errorf("error getting claude-squad's tmux session: %w", err)
+	}
+	squadSession := strings.TrimSpace(string(output))
+
+	// Outside of the instances' sessions, the detach key is passed through unchanged.
+	bindCmd := exec.Command("tmux", "bind-key", "-n", detachKey.tmuxName, "if-shell", "-F",
+		"#{m:claudesquad[_-]*,#{session_name}}", fmt.Sprintf("switch-client -t '=%s'", squadSession),
+		"send-keys "+detachKey.tmuxName)
+	if err := t.cmdExec.Run(bindCmd); err != nil {
+		return fmt.Errorf("error binding %s to return to claude-squad: %w", detachKey.Name, err)
+	}
+	t.showAttachHelp()
+
+	target := t.sanitizedName
+	if windowName != "" {
+		target = fmt.Sprintf("%s:%s", t.sanitizedName, windowName)
+	}
+	if err := t.cmdExec.Run(exec.Command("tmux", "switch-client", "-t", target)); err != nil {
+		return fmt.Errorf("error switching to tmux session %s: %w", target, err)
+	}
+	return nil
+}
+
 // Detach disconnects from the current tmux session. It panics if detaching fails. At the moment, there's no
 // way to recover from a failed detach.
 func (t *TmuxSession) Detach() {
@@ -363,11 +617,24 @@ func (t *TmuxSession) Detach() {
 		panic(msg)
 	}
 
+	t.hideAttachHelp()
+
 	// Cancel goroutines created by Attach.
 	t.cancel()
 	t.wg.Wait()
 }
 
+// ClosePTY closes the PTY attached to the tmux session, leaving the session running, for a TmuxSession that's
+// no longer used, e.g. replaced by another attached to the same session.
+func (t *TmuxSession) ClosePTY() error {
+	if t.ptmx == nil {
+		return nil
+	}
+	err := t.ptmx.Close()
+	t.ptmx = nil
+	return err
+}
+
 // Close terminates the tmux session and cleans up resources
 func (t *TmuxSession) Close() error {
 	var errs []error
@@ -420,12 +687,43 @@ func (t *TmuxSession) DoesSessionExist() bool {
 	return t.cmdExec.Run(existsCmd) == nil
 }
 
+// IsAttached returns true if the user is attached to the session, either through claude-squad or with a
+// tmux client of their own. The background client claude-squad keeps attached isn't counted.
+func (t *TmuxSession) IsAttached() bool {
+	if t.attachCh != nil {
+		return true
+	}
+	output, err := t.cmdExec.Output(exec.Command("tmux", "list-clients", "-t", t.sanitizedName, "-F", "#{client_pid}"))
+	if err != nil {
+		return false
+	}
+	return len(strings.Fields(string(output))) > 1
+}
+
+// Rename renames the tmux session to match a new instance name.
+func (t *TmuxSession) Rename(name string) error {
+	newName := toClaudeSquadTmuxName(name)
+	if newName == t.sanitizedName {
+		return nil
+	}
+	existsCmd := exec.Command("tmux", "has-session", fmt.Sprintf("-t=%s", newName))
+	if t.cmdExec.Run(existsCmd) == nil {
+		return fmt.Errorf("tmux session already exists: %s", newName)
+	}
+	renameCmd := exec.Command("tmux", "rename-session", "-t", t.sanitizedName, newName)
+	if err := t.cmdExec.Run(renameCmd); err != nil {
+		return fmt.Errorf("error renaming tmux session %s: %w", t.sanitizedName, err)
+	}
+	t.sanitizedName = newName
+	return nil
+}
+
 // CapturePaneContent captures the content of the tmux pane
 func (t *TmuxSession) CapturePaneContent() (string, error) {
 	// Add -e flag to preserve escape sequences (ANSI color codes)
-	// Explicitly target window 0 (the main Claude window) to avoid confusion with other windows
-	mainTarget := fmt.Sprintf("%s:0", t.sanitizedName)
-	cmd := exec.Command("tmux", "capture-pane", "-p", "-e", "-J", "-t", mainTarget)
+	// Explicitly target the program's pane in window 0 (the main Claude window) to avoid confusion with
+	// other windows and side panes
+	cmd := exec.Command("tmux", "capture-pane", "-p", "-e", "-J", "-t", t.agentTarget())
 	output, err := t.cmdExec.Output(cmd)
 	if err != nil {
 		return "", fmt.Errorf("error capturing pane content: %v", err)
@@ -433,6 +731,80 @@ func (t *TmuxSession) CapturePaneContent() (string, error) {
 	return string(output), nil
 }
 
+// CaptureSidePanes captures the content of the side panes below the program's pane, each preceded by a
+// separator line as wide as the pane, like tmux draws it.
+func (t *TmuxSession) CaptureSidePanes() (string, error) {
+	listCmd := exec.Command("tmux", "list-panes", "-t", t.sanitizedName+":0", "-F", "#{pane_index} #{pane_width}")
+	output, err := t.cmdExec.Output(listCmd)
+	if err != nil {
+		return "", fmt.Errorf("error listing panes: %v", err)
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		var index, width int
+		if _, err := fmt.Sscanf(line, "%d %d", &index, &width); err != nil || index == 0 {
+			continue
+		}
+		content, err := t.CaptureSidePane(index)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(strings.Repeat("─", width) + "\n")
+		b.WriteString(content)
+	}
+	return b.String(), nil
+}
+
+// PaneCount returns the number of panes in the program's window, including the program's own.
+func (t *TmuxSession) PaneCount() (int, error) {
+	output, err := t.cmdExec.Output(exec.Command("tmux", "list-panes", "-t", t.sanitizedName+":0", "-F", "#{pane_index}"))
+	if err != nil {
+		return 0, fmt.Errorf("error listing panes: %v", err)
+	}
+	return len(strings.Fields(string(output))), nil
+}
+
+// ProgramAlive returns true if the program in the session's pane is still running.
+func (t *TmuxSession) ProgramAlive() (bool, error) {
+	cmd := exec.Command("tmux", "display-message", "-p", "-t", t.agentTarget(), "#{pane_dead} #{pane_pid}")
+	output, err := t.cmdExec.Output(cmd)
+	if err != nil {
+		return false, fmt.Errorf("error checking the program's pane: %v", err)
+	}
+	var dead, pid int
+	if _, err := fmt.Sscanf(strings.TrimSpace(string(output)), "%d %d", &dead, &pid); err != nil {
+		return false, fmt.Errorf("unexpected pane status %q: %v", strings.TrimSpace(string(output)), err)
+	}
+	return dead == 0 && processAlive(pid), nil
+}
+
+// CaptureSidePane captures the content of a single pane of the program's window by index.
+func (t *TmuxSession) CaptureSidePane(index int) (string, error) {
+	cmd := exec.Command("tmux", "capture-pane", "-p", "-e", "-J", "-t", fmt.Sprintf("%s:0.%d", t.sanitizedName, index))
+	output, err := t.cmdExec.Output(cmd)
+	if err != nil {
+		return "", fmt.Errorf("error capturing pane content: %v", err)
+	}
+	return string(output), nil
+}
+
+// SendToSidePane types keys into a pane of the program's window by index, followed by enter.
+func (t *TmuxSession) SendToSidePane(index int, keys string) error {
+	target := fmt.Sprintf("%s:0.%d", t.sanitizedName, index)
+	if err := t.cmdExec.Run(exec.Command("tmux", "send-keys", "-t", target, "-l", keys)); err != nil {
+		return fmt.Errorf("error sending keys to pane %s: %w", target, err)
+	}
+	if err := t.cmdExec.Run(exec.Command("tmux", "send-keys", "-t", target, "Enter")); err != nil {
+		return fmt.Errorf("error sending enter to pane %s: %w", target, err)
+	}
+	return nil
+}
+
+// HasSidePanes returns true if the session was started with side panes.
+func (t *TmuxSession) HasSidePanes() bool {
+	return len(t.panes) > 0
+}
+
 // CapturePaneContentWithOptions captures the pane content with additional options
 // start and end specify the starting and ending line numbers (use "-" for the start/end of history)
 func (t *TmuxSession) CapturePaneContentWithOptions(start, end string) (string, error) {
@@ -519,7 +891,7 @@ func CleanupSessions(cmdExec cmd.Executor) error {
 		return fmt.Errorf("failed to list tmux sessions: %v", err)
 	}
 
-	re := regexp.MustCompile(fmt.Sprintf(`%s.*:`, TmuxPrefix))
+	re := regexp.MustCompile(fmt.Sprintf(`(?m)^%s.*?:`, regexp.QuoteMeta(sessionPrefix)))
 	matches := re.FindAllString(string(output), -1)
 	for i, match := range matches {
 		matches[i] = match[:strings.Index(match, ":")]
@@ -533,3 +905,63 @@ func CleanupSessions(cmdExec cmd.Executor) error {
 	}
 	return nil
 }
+
+// ExternalSession is a tmux session claude-squad didn't create.
+type ExternalSession struct {
+	// Name is the name of the session.
+	Name string
+	// Dir is the current directory of the session's active pane.
+	Dir string
+	// Command is the command running in the session's active pane.
+	Command string
+}
+
+// ListExternalSessions returns the tmux sessions that weren't created by claude-squad.
+func ListExternalSessions(cmdExec cmd.Executor) ([]ExternalSession, error) {
+	output, err := cmdExec.Output(exec.Command("tmux", "list-sessions", "-F",
+		"#{session_name}\t#{pane_current_path}\t#{pane_current_command}"))
+	if err != nil {
+		// No server is running, so there are no sessions.
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list tmux sessions: %v", err)
+	}
+	return parseExternalSessions(string(output)), nil
+}
+
+// parseExternalSessions parses the output of the list-sessions command run by ListExternalSessions.
+func parseExternalSessions(output string) []ExternalSession {
+	var sessions []ExternalSession
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 || isSquadSession(fields[0]) {
+			continue
+		}
+		sessions = append(sessions, ExternalSession{Name: fields[0], Dir: fields[1], Command: fields[2]})
+	}
+	return sessions
+}
+
+// Adopt takes over the tmux session named external, which claude-squad didn't create, by renaming it to this
+// session's name. The session's programs keep running.
+func (t *TmuxSession) Adopt(external string) error {
+	existsCmd := exec.Command("tmux", "has-session", fmt.Sprintf("-t=%s", t.sanitizedName))
+	if t.cmdExec.Run(existsCmd) == nil {
+		return fmt.Errorf("tmux session already exists: %s", t.sanitizedName)
+	}
+	renameCmd := exec.Command("tmux", "rename-session", "-t", "="+external, t.sanitizedName)
+	if err := t.cmdExec.Run(renameCmd); err != nil {
+		return fmt.Errorf("error adopting tmux session %s: %w", external, err)
+	}
+	return nil
+}
+
+// Release gives the session back its name from before it was adopted. The session isn't closed.
+func (t *TmuxSession) Release(external string) error {
+	renameCmd := exec.Command("tmux", "rename-session", "-t", t.sanitizedName, external)
+	if err := t.cmdExec.Run(renameCmd); err != nil {
+		return fmt.Errorf("error renaming tmux session %s back to %s: %w", t.sanitizedName, external, err)
+	}
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"claude-squad/cmd/cmd_test"
 
@@ -86,3 +87,234 @@ func TestStartTmuxSession(t *testing.T) {
 	_, err = ptyFactory.files[1].Stat()
 	require.NoError(t, err)
 }
+
+func TestStartTmuxSessionWithOptions(t *testing.T) {
+	ptyFactory := NewMockPtyFactory(t)
+
+	created := false
+	var ran []string
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error {
+			if strings.Contains(cmd.String(), "has-session") {
+				if !created {
+					created = true
+					return fmt.Errorf("session already exists")
+				}
+				return nil
+			}
+			ran = append(ran, cmd2.ToString(cmd))
+			return nil
+		},
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			return []byte("output"), nil
+		},
+	}
+
+	workdir := t.TempDir()
+	session := newTmuxSession("test-session", "claude", ptyFactory, cmdExec)
+	session.SetOptions(map[string]string{"status": "off", "history-limit": "50000"})
+
+	require.NoError(t, session.Start(workdir))
+	require.Equal(t, fmt.Sprintf("tmux new-session -d -s claudesquad_test-session -c %s cat", workdir),
+		cmd2.ToString(ptyFactory.cmds[0]))
+	require.Equal(t, []string{
+		"tmux set-option -t claudesquad_test-session history-limit 50000",
+		"tmux set-option -t claudesquad_test-session status off",
+		fmt.Sprintf("tmux new-window -k -t claudesquad_test-session:0 -c %s claude", workdir),
+	}, ran[:3])
+}
+
+func TestSidePanes(t *testing.T) {
+	var ran []string
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error {
+			ran = append(ran, cmd2.ToString(cmd))
+			return nil
+		},
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			switch cmd2.ToString(cmd) {
+			case "tmux list-panes -t claudesquad_test-session:0 -F #{pane_index} #{pane_width}":
+				return []byte("0 5\n1 5\n"), nil
+			case "tmux capture-pane -p -e -J -t claudesquad_test-session:0.1":
+				return []byte("PASS\n"), nil
+			}
+			return nil, fmt.Errorf("unexpected command: %s", cmd)
+		},
+	}
+	session := newTmuxSession("test-session", "claude", NewMockPtyFactory(t), cmdExec)
+	session.SetPanes([]Pane{{Command: "go test ./...", Size: 30}, {Command: "tail -f log"}})
+	require.True(t, session.HasSidePanes())
+
+	require.NoError(t, session.createPanes("/work"))
+	require.Equal(t, []string{
+		"tmux split-window -d -v -t claudesquad_test-session:0.{bottom} -c /work -l 30% go test ./...",
+		"tmux split-window -d -v -t claudesquad_test-session:0.{bottom} -c /work tail -f log",
+	}, ran)
+
+	content, err := session.CaptureSidePanes()
+	require.NoError(t, err)
+	require.Equal(t, "─────\nPASS\n", content)
+
+	ran = nil
+	require.NoError(t, session.SendToSidePane(1, "make test"))
+	require.Equal(t, []string{
+		"tmux send-keys -t claudesquad_test-session:0.1 -l make test",
+		"tmux send-keys -t claudesquad_test-session:0.1 Enter",
+	}, ran)
+}
+
+func TestIsAttached(t *testing.T) {
+	clients := "1234\n"
+	cmdExec := cmd_test.MockCmdExec{
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			require.Equal(t, "tmux list-clients -t claudesquad_test-session -F #{client_pid}", cmd2.ToString(cmd))
+			return []byte(clients), nil
+		},
+	}
+	session := newTmuxSession("test-session", "claude", NewMockPtyFactory(t), cmdExec)
+	require.False(t, session.IsAttached(), "claude-squad's own client doesn't count")
+
+	clients = "1234\n5678\n"
+	require.True(t, session.IsAttached())
+}
+
+func TestSwitchClient(t *testing.T) {
+	t.Setenv("TMUX_PANE", "%3")
+	var ran []string
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error {
+			ran = append(ran, cmd2.ToString(cmd))
+			return nil
+		},
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			require.Equal(t, "tmux display-message -p -t %3 #{session_name}", cmd2.ToString(cmd))
+			return []byte("work\n"), nil
+		},
+	}
+	session := newTmuxSession("test-session", "claude", NewMockPtyFactory(t), cmdExec)
+	require.NoError(t, session.SwitchClient("terminal"))
+	require.Equal(t, []string{
+		"tmux bind-key -n C-q if-shell -F #{m:claudesquad[_-]*,#{session_name}} switch-client -t '=work' send-keys C-q",
+		"tmux set-option -t claudesquad_test-session status on",
+		"tmux set-option -t claudesquad_test-session status-right  ctrl-q: back to claude-squad ",
+		"tmux switch-client -t claudesquad_test-session:terminal",
+	}, ran)
+
+	// A configured detach key is bound instead, and the reminder can be turned off.
+	key, err := ParseDetachKey("ctrl-]")
+	require.NoError(t, err)
+	SetDetachKey(key)
+	SetAttachHelp(false)
+	defer SetDetachKey(DefaultDetachKey)
+	defer SetAttachHelp(true)
+	ran = nil
+	require.NoError(t, session.SwitchClient(""))
+	require.Equal(t, []string{
+		"tmux bind-key -n C-] if-shell -F #{m:claudesquad[_-]*,#{session_name}} switch-client -t '=work' send-keys C-]",
+		"tmux switch-client -t claudesquad_test-session",
+	}, ran)
+}
+
+func TestParseDetachKey(t *testing.T) {
+	key, err := ParseDetachKey("")
+	require.NoError(t, err)
+	require.Equal(t, DefaultDetachKey, key)
+
+	key, err = ParseDetachKey("Ctrl+B")
+	require.NoError(t, err)
+	require.Equal(t, DetachKey{Name: "ctrl-b", code: 0x02, tmuxName: "C-b"}, key)
+	key, err = ParseDetachKey(`^\`)
+	require.NoError(t, err)
+	require.EqualValues(t, 0x1c, key.code)
+
+	for _, name := range []string{"q", "ctrl-", "ctrl-qq", "ctrl-1", "ctrl-c", "ctrl-m"} {
+		_, err := ParseDetachKey(name)
+		require.Error(t, err, name)
+	}
+}
+
+func TestIsBusy(t *testing.T) {
+	require.True(t, isBusy(ProgramClaude, "✻ Thinking… (12s · esc to interrupt)"))
+	require.False(t, isBusy(ProgramClaude, "> "))
+	require.True(t, isBusy("gemini --yolo", "⠏ Working (esc to cancel, 3s)"))
+	require.False(t, isBusy("aider", "esc to interrupt"))
+}
+
+func TestAdoptExternalSession(t *testing.T) {
+	var ran []string
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error {
+			ran = append(ran, cmd2.ToString(cmd))
+			if strings.Contains(cmd.String(), "has-session") {
+				return fmt.Errorf("no such session")
+			}
+			return nil
+		},
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			return []byte("work\t/src/app\tclaude\n" + TmuxPrefix + "fix\t/worktrees/fix\tclaude\n"), nil
+		},
+	}
+
+	sessions, err := ListExternalSessions(cmdExec)
+	require.NoError(t, err)
+	require.Equal(t, []ExternalSession{{Name: "work", Dir: "/src/app", Command: "claude"}}, sessions)
+
+	session := newTmuxSession("work", "claude", NewMockPtyFactory(t), cmdExec)
+	require.NoError(t, session.Adopt("work"))
+	require.Equal(t, "tmux rename-session -t =work claudesquad_work", ran[len(ran)-1])
+}
+
+func TestKillVerifiesProcessExit(t *testing.T) {
+	defer func(timeout time.Duration) { killTimeout = timeout }(killTimeout)
+	killTimeout = 200 * time.Millisecond
+
+	// The program ignores the hangup and termination signals, so it outlives its session.
+	program := exec.Command("sh", "-c", `trap "" HUP TERM; while :; do sleep 0.05; done`)
+	require.NoError(t, program.Start())
+	exited := make(chan struct{})
+	go func() {
+		_ = program.Wait()
+		close(exited)
+	}()
+	defer program.Process.Kill()
+
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error {
+			if strings.Contains(cmd.String(), "has-session") {
+				return fmt.Errorf("no such session")
+			}
+			return nil
+		},
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			return []byte(fmt.Sprintf("%d\n", program.Process.Pid)), nil
+		},
+	}
+	session := newTmuxSession("test-session", "claude", NewMockPtyFactory(t), cmdExec)
+	err := session.Kill(false)
+	require.ErrorContains(t, err, "still running")
+	require.ErrorContains(t, err, "force-kill")
+
+	require.NoError(t, session.Kill(true))
+	<-exited
+}
+
+func TestStallIgnoresBusyIndicator(t *testing.T) {
+	monitor := newStatusMonitor()
+	monitor.update(ProgramClaude, "⏺ Reading app/app.go\n\n✻ Thinking… (11s · esc to interrupt)\n> ")
+	stalledSince := time.Now().Add(-time.Minute)
+	monitor.lastChange = stalledSince
+
+	// The spinner and timer redraw every second while claude works, which isn't output.
+	for i, frame := range []string{"✶", "✳", "✢", "·"} {
+		content := fmt.Sprintf("⏺ Reading app/app.go\n\n%s Thinking… (%ds · ↑ 1.2k tokens · esc to interrupt)\n> ", frame, 12+i)
+		require.True(t, monitor.update(ProgramClaude, content))
+		require.True(t, monitor.busy)
+		require.False(t, monitor.progressed)
+	}
+	require.Equal(t, stalledSince, monitor.lastChange)
+
+	// New output is.
+	monitor.update(ProgramClaude, "⏺ Reading app/app.go\n⏺ Editing app/app.go\n\n✻ Thinking… (16s · esc to interrupt)\n> ")
+	require.True(t, monitor.lastChange.After(stalledSince))
+	require.True(t, monitor.progressed)
+}
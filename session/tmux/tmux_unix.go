@@ -76,3 +76,18 @@ func (t *TmuxSession) monitorWindowSize() {
 		}
 	}()
 }
+
+// processAlive returns true if the process pid is running, including when it's another user's.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// terminateProcess sends the process pid SIGTERM, or SIGKILL if force is set.
+func terminateProcess(pid int, force bool) {
+	sig := syscall.SIGTERM
+	if force {
+		sig = syscall.SIGKILL
+	}
+	_ = syscall.Kill(pid, sig)
+}
@@ -55,3 +55,20 @@ func (t *TmuxSession) monitorWindowSize() {
 		}
 	}()
 }
+
+// processAlive returns true if the process pid is running.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
+
+// terminateProcess kills the process pid. Windows has no SIGTERM, so it's killed even if force isn't set.
+func terminateProcess(pid int, force bool) {
+	if p, err := os.FindProcess(pid); err == nil {
+		_ = p.Kill()
+	}
+}
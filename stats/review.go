@@ -0,0 +1,161 @@
+package stats
+
+import (
+	"claude-squad/session"
+	"claude-squad/session/git"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// StaleAfter is how long an instance that isn't paused goes without output before its worktree is reported as
+// stale, taking disk for nothing.
+const StaleAfter = week
+
+// ReviewInstance is an instance that's still around, as the weekly review sees it.
+type ReviewInstance struct {
+	Title      string
+	Repository string
+	CreatedAt  time.Time
+	// LastActive is when the instance last produced output, or changed status if it never did.
+	LastActive time.Time
+	Paused     bool
+	Merged     bool
+	// WorktreeBytes is the disk used by the instance's worktree.
+	WorktreeBytes int64
+}
+
+// Review is the weekly look at the state that drifts unnoticed: the instances still open from the weeks
+// before, worktrees nobody works in, the disk they take and the branches left behind. It's shown on the first
+// launch of each week.
+type Review struct {
+	GeneratedAt time.Time
+	// Open are the instances created before the last week that are still around, oldest first.
+	Open []ReviewInstance
+	// Stale are the instances that aren't paused but produced no output for StaleAfter, longest idle first.
+	// Pausing them frees their worktrees.
+	Stale []ReviewInstance
+	// Merged are the instances whose branches were merged, which are done with.
+	Merged []ReviewInstance
+	// WorktreeBytes is the disk used by the instances' worktrees, and LastWorktreeBytes what it was at the last
+	// review, or 0 if there was none.
+	WorktreeBytes     int64
+	LastWorktreeBytes int64
+	// MergedBranches are the branches claude-squad created that no instance uses anymore and that have no
+	// commits left to merge, so they can be deleted without losing work.
+	MergedBranches []git.SquadBranch
+}
+
+// BuildReview returns the review as of now of the instances still around and the orphaned branches.
+// lastWorktreeBytes is the disk the worktrees used at the last review.
+func BuildReview(now time.Time, instances []ReviewInstance, lastWorktreeBytes int64, orphans []git.SquadBranch) Review {
+	r := Review{GeneratedAt: now, LastWorktreeBytes: lastWorktreeBytes}
+	for _, instance := range instances {
+		r.WorktreeBytes += instance.WorktreeBytes
+		if instance.CreatedAt.Before(now.Add(-week)) {
+			r.Open = append(r.Open, instance)
+		}
+		if !instance.Paused && instance.LastActive.Before(now.Add(-StaleAfter)) {
+			r.Stale = append(r.Stale, instance)
+		}
+		if instance.Merged {
+			r.Merged = append(r.Merged, instance)
+		}
+	}
+	slices.SortStableFunc(r.Open, func(a, b ReviewInstance) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	slices.SortStableFunc(r.Stale, func(a, b ReviewInstance) int { return a.LastActive.Compare(b.LastActive) })
+	for _, branch := range orphans {
+		if branch.Base != "" && branch.Ahead == 0 {
+			r.MergedBranches = append(r.MergedBranches, branch)
+		}
+	}
+	return r
+}
+
+// CollectReview returns the review as of now for the stored instances, measuring the disk used by their
+// worktrees, which may take a while.
+func CollectReview(now time.Time, data []session.InstanceData, lastWorktreeBytes int64, orphans []git.SquadBranch) Review {
+	instances := make([]ReviewInstance, 0, len(data))
+	for _, d := range data {
+		lastActive := d.LastActivity
+		if lastActive.IsZero() {
+			lastActive = d.UpdatedAt
+		}
+		instance := ReviewInstance{
+			Title:      d.Title,
+			Repository: d.RepositoryPath,
+			CreatedAt:  d.CreatedAt,
+			LastActive: lastActive,
+			Paused:     d.Status == session.Paused,
+			Merged:     !d.MergedAt.IsZero(),
+		}
+		// Paused instances' worktrees are removed.
+		if !instance.Paused {
+			instance.WorktreeBytes = diskUsage(d.Worktree.WorktreePath)
+		}
+		instances = append(instances, instance)
+	}
+	return BuildReview(now, instances, lastWorktreeBytes, orphans)
+}
+
+// Empty returns true if there's nothing to review or clean up.
+func (r Review) Empty() bool {
+	return len(r.Open) == 0 && len(r.Stale) == 0 && len(r.Merged) == 0 && len(r.MergedBranches) == 0
+}
+
+// StaleBytes returns the disk used by the stale instances' worktrees, which pausing them frees.
+func (r Review) StaleBytes() int64 {
+	var total int64
+	for _, instance := range r.Stale {
+		total += instance.WorktreeBytes
+	}
+	return total
+}
+
+// Lines renders the review as plain text, one line per element.
+func (r Review) Lines() []string {
+	lines := []string{fmt.Sprintf("Weekly review, %s", r.GeneratedAt.Format("Mon Jan 2"))}
+	disk := fmt.Sprintf("Worktrees use %s", FormatBytes(r.WorktreeBytes))
+	if r.LastWorktreeBytes > 0 {
+		change := "up"
+		diff := r.WorktreeBytes - r.LastWorktreeBytes
+		if diff < 0 {
+			change, diff = "down", -diff
+		}
+		disk += fmt.Sprintf(", %s %s since the last review", change, FormatBytes(diff))
+	}
+	lines = append(lines, "", disk)
+
+	sections := []struct {
+		title     string
+		instances []ReviewInstance
+		describe  func(ReviewInstance) string
+	}{
+		{"Still open from before last week", r.Open, func(i ReviewInstance) string {
+			return "created " + i.CreatedAt.Format("Mon Jan 2")
+		}},
+		{"Stale, no output for a week", r.Stale, func(i ReviewInstance) string {
+			return fmt.Sprintf("last active %s, worktree uses %s", i.LastActive.Format("Mon Jan 2"), FormatBytes(i.WorktreeBytes))
+		}},
+		{"Merged, done with", r.Merged, func(i ReviewInstance) string {
+			return "created " + i.CreatedAt.Format("Mon Jan 2")
+		}},
+	}
+	for _, section := range sections {
+		if len(section.instances) == 0 {
+			continue
+		}
+		lines = append(lines, "", fmt.Sprintf("%s (%d):", section.title, len(section.instances)))
+		for _, instance := range section.instances {
+			lines = append(lines, fmt.Sprintf("  %s (%s), %s", instance.Title, repositoryName(instance.Repository),
+				section.describe(instance)))
+		}
+	}
+	if len(r.MergedBranches) > 0 {
+		lines = append(lines, "", fmt.Sprintf("Branches left behind with nothing to merge (%d):", len(r.MergedBranches)))
+		for _, branch := range r.MergedBranches {
+			lines = append(lines, fmt.Sprintf("  %s (%s)", branch.Name, repositoryName(branch.Repository)))
+		}
+	}
+	return lines
+}
@@ -0,0 +1,48 @@
+package stats
+
+import (
+	"claude-squad/session/git"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildReview(t *testing.T) {
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	daysAgo := func(d int) time.Time { return now.AddDate(0, 0, -d) }
+
+	instances := []ReviewInstance{
+		{Title: "cache", Repository: "/src/app", CreatedAt: daysAgo(2), LastActive: daysAgo(1), WorktreeBytes: 2000},
+		{Title: "login", Repository: "/src/app", CreatedAt: daysAgo(20), LastActive: daysAgo(9), WorktreeBytes: 3000},
+		{Title: "docs", Repository: "/src/site", CreatedAt: daysAgo(30), LastActive: daysAgo(25), Paused: true},
+		{Title: "api", Repository: "/src/app", CreatedAt: daysAgo(10), LastActive: daysAgo(12), Merged: true,
+			WorktreeBytes: 1000},
+	}
+	orphans := []git.SquadBranch{
+		{Repository: "/src/app", Name: "cs/old", Base: "main"},
+		{Repository: "/src/app", Name: "cs/wip", Base: "main", Ahead: 2},
+		{Repository: "/src/app", Name: "cs/unknown"},
+	}
+
+	r := BuildReview(now, instances, 4000, orphans)
+	titles := func(instances []ReviewInstance) []string {
+		var titles []string
+		for _, instance := range instances {
+			titles = append(titles, instance.Title)
+		}
+		return titles
+	}
+	require.Equal(t, []string{"docs", "login", "api"}, titles(r.Open))
+	require.Equal(t, []string{"api", "login"}, titles(r.Stale))
+	require.Equal(t, []string{"api"}, titles(r.Merged))
+	require.Equal(t, int64(6000), r.WorktreeBytes)
+	require.Equal(t, int64(4000), r.StaleBytes())
+	require.Len(t, r.MergedBranches, 1)
+	require.Equal(t, "cs/old", r.MergedBranches[0].Name)
+	require.False(t, r.Empty())
+	require.Contains(t, strings.Join(r.Lines(), "\n"), "Worktrees use 6.0 kB, up 2.0 kB since the last review")
+
+	require.True(t, BuildReview(now, instances[:1], 0, nil).Empty())
+}
@@ -0,0 +1,331 @@
+// Package stats reports how the squad is used in each repository: the instances created, merged and
+// abandoned, how many were around week by week, how long they lived and the disk their worktrees take, along
+// with the tasks instances are most often started from. Reports are built from the audit log and the stored
+// instances, shown in the TUI and printed or exported as JSON by `claude-squad stats`.
+package stats
+
+import (
+	"claude-squad/audit"
+	"claude-squad/digest"
+	"claude-squad/session"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Weeks is how many weeks of instance counts a report covers.
+const Weeks = 8
+
+const week = 7 * 24 * time.Hour
+
+// Week is the instance counts of a repository over a week.
+type Week struct {
+	// Start is when the week starts. It ends a week later.
+	Start time.Time `json:"start"`
+	// Created is how many instances were created during the week.
+	Created int `json:"created"`
+	// Active is how many instances were around at the end of the week.
+	Active int `json:"active"`
+}
+
+// Repository is the usage of a repository.
+type Repository struct {
+	Path string `json:"path"`
+	Name string `json:"name"`
+	// Active is how many instances are around now.
+	Active    int `json:"active"`
+	Created   int `json:"created"`
+	Merged    int `json:"merged"`
+	Abandoned int `json:"abandoned"`
+	// AverageLifetime is how long the killed instances were around on average, or zero if none were.
+	AverageLifetime time.Duration `json:"average_lifetime_ns"`
+	// WorktreeBytes is the disk used by the worktrees of the instances around now.
+	WorktreeBytes int64 `json:"worktree_bytes"`
+	// Weeks are the instance counts over the last Weeks weeks, oldest first.
+	Weeks []Week `json:"weeks"`
+}
+
+// TaskUsage is how many instances were started from a task in the task library.
+type TaskUsage struct {
+	Name      string `json:"name"`
+	Instances int    `json:"instances"`
+}
+
+// Report is the usage of every repository the squad has worked in.
+type Report struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	// Repositories are sorted by the number of instances created in them, most first.
+	Repositories []Repository `json:"repositories"`
+	// Tasks are sorted by the number of instances started from them, most first.
+	Tasks []TaskUsage `json:"tasks"`
+}
+
+// Instance is an instance that's still around, as the stats see it.
+type Instance struct {
+	Title      string
+	Repository string
+	CreatedAt  time.Time
+	MergedAt   time.Time
+	Task       string
+	// WorktreeBytes is the disk used by the instance's worktree.
+	WorktreeBytes int64
+}
+
+// lifetime is when an instance was created and, if it has been, killed.
+type lifetime struct {
+	created, killed time.Time
+}
+
+type instanceKey struct {
+	repository, title string
+}
+
+// Build returns the report as of now, from the audit log entries and the instances still around. Instances
+// created before the audit log was kept are counted from when they were stored.
+func Build(now time.Time, entries []audit.Entry, instances []Instance) Report {
+	repos := make(map[string]*Repository)
+	lifetimes := make(map[string][]*lifetime)
+	tasks := make(map[string]int)
+	repo := func(path string) *Repository {
+		r, ok := repos[path]
+		if !ok {
+			r = &Repository{Path: path, Name: repositoryName(path)}
+			repos[path] = r
+		}
+		return r
+	}
+
+	// open are the lifetimes of the instances created and not killed yet, as far as the audit log goes.
+	open := make(map[instanceKey]*lifetime)
+	for _, entry := range entries {
+		key := instanceKey{entry.Repository, entry.Instance}
+		switch entry.Action {
+		case digest.ActionCreated:
+			r := repo(entry.Repository)
+			r.Created++
+			if task := digest.Task(entry); task != "" {
+				tasks[task]++
+			}
+			l := &lifetime{created: entry.Time}
+			open[key] = l
+			lifetimes[entry.Repository] = append(lifetimes[entry.Repository], l)
+		case digest.ActionKilled:
+			r := repo(entry.Repository)
+			if _, merged := digest.MergedAt(entry); merged {
+				r.Merged++
+			} else {
+				r.Abandoned++
+			}
+			if l := open[key]; l != nil {
+				l.killed = entry.Time
+				delete(open, key)
+			}
+		}
+	}
+
+	around := make(map[*lifetime]bool)
+	for _, instance := range instances {
+		key := instanceKey{instance.Repository, instance.Title}
+		r := repo(instance.Repository)
+		r.Active++
+		r.WorktreeBytes += instance.WorktreeBytes
+		if !instance.MergedAt.IsZero() {
+			r.Merged++
+		}
+		l := open[key]
+		if l == nil {
+			r.Created++
+			if instance.Task != "" {
+				tasks[instance.Task]++
+			}
+			l = &lifetime{created: instance.CreatedAt}
+			lifetimes[instance.Repository] = append(lifetimes[instance.Repository], l)
+		}
+		around[l] = true
+	}
+
+	report := Report{GeneratedAt: now, Repositories: []Repository{}, Tasks: []TaskUsage{}}
+	for path, r := range repos {
+		var total time.Duration
+		killed := 0
+		// Instances the audit log has no kill for and that aren't around anymore ended at an unknown time,
+		// so they're left out of the lifetimes and the weekly counts.
+		var known []*lifetime
+		for _, l := range lifetimes[path] {
+			if !l.killed.IsZero() {
+				total += l.killed.Sub(l.created)
+				killed++
+				known = append(known, l)
+			} else if around[l] {
+				known = append(known, l)
+			}
+		}
+		if killed > 0 {
+			r.AverageLifetime = total / time.Duration(killed)
+		}
+		r.Weeks = weeks(now, known)
+		report.Repositories = append(report.Repositories, *r)
+	}
+	slices.SortFunc(report.Repositories, func(a, b Repository) int {
+		if a.Created != b.Created {
+			return b.Created - a.Created
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	for name, count := range tasks {
+		report.Tasks = append(report.Tasks, TaskUsage{Name: name, Instances: count})
+	}
+	slices.SortFunc(report.Tasks, func(a, b TaskUsage) int {
+		if a.Instances != b.Instances {
+			return b.Instances - a.Instances
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return report
+}
+
+// weeks counts the instances created during, and around at the end of, each of the last Weeks weeks.
+func weeks(now time.Time, lifetimes []*lifetime) []Week {
+	result := make([]Week, Weeks)
+	for i := range result {
+		start := now.Add(-time.Duration(Weeks-i) * week)
+		end := start.Add(week)
+		result[i].Start = start
+		for _, l := range lifetimes {
+			if !l.created.Before(start) && l.created.Before(end) {
+				result[i].Created++
+			}
+			if l.created.Before(end) && (l.killed.IsZero() || !l.killed.Before(end)) {
+				result[i].Active++
+			}
+		}
+	}
+	return result
+}
+
+func repositoryName(path string) string {
+	if path == "" {
+		return "(no repository)"
+	}
+	return filepath.Base(path)
+}
+
+// Collect returns the report as of now for the stored instances, measuring the disk used by their worktrees,
+// which may take a while.
+func Collect(now time.Time, data []session.InstanceData) (Report, error) {
+	entries, err := audit.Read()
+	if err != nil {
+		return Report{}, err
+	}
+	instances := make([]Instance, 0, len(data))
+	for _, d := range data {
+		instances = append(instances, Instance{
+			Title:         d.Title,
+			Repository:    d.Worktree.RepoPath,
+			CreatedAt:     d.CreatedAt,
+			MergedAt:      d.MergedAt,
+			Task:          d.Task,
+			WorktreeBytes: diskUsage(d.Worktree.WorktreePath),
+		})
+	}
+	return Build(now, entries, instances), nil
+}
+
+// diskUsage returns the total size of the files under dir. Files that can't be read are left out.
+func diskUsage(dir string) int64 {
+	if dir == "" {
+		return 0
+	}
+	var total int64
+	_ = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
+
+// sparkline draws values as a row of bars, the highest one full height.
+func sparkline(values []int) string {
+	bars := []rune("▁▂▃▄▅▆▇█")
+	peak := slices.Max(values)
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if peak > 0 {
+			i = v * (len(bars) - 1) / peak
+		}
+		b.WriteRune(bars[i])
+	}
+	return b.String()
+}
+
+// FormatBytes formats a size in bytes for people, e.g. "1.5 GB".
+func FormatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
+
+// formatLifetime formats an average lifetime, e.g. "2d 3h" or "45m".
+func formatLifetime(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "n/a"
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd %dh", d/(24*time.Hour), d%(24*time.Hour)/time.Hour)
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh %dm", d/time.Hour, d%time.Hour/time.Minute)
+	default:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+}
+
+// Lines renders the report as plain text, one line per element.
+func (r Report) Lines() []string {
+	lines := []string{fmt.Sprintf("Repository stats as of %s", r.GeneratedAt.Format("Mon Jan 2 15:04"))}
+	if len(r.Repositories) == 0 {
+		return append(lines, "", "No instances yet.")
+	}
+	for _, repo := range r.Repositories {
+		active := make([]int, len(repo.Weeks))
+		for i, w := range repo.Weeks {
+			active[i] = w.Active
+		}
+		lines = append(lines, "",
+			fmt.Sprintf("%s (%s)", repo.Name, repo.Path),
+			fmt.Sprintf("  %d active, %d created, %d merged, %d abandoned",
+				repo.Active, repo.Created, repo.Merged, repo.Abandoned),
+			fmt.Sprintf("  Average lifetime %s, worktrees use %s",
+				formatLifetime(repo.AverageLifetime), FormatBytes(repo.WorktreeBytes)),
+			fmt.Sprintf("  Active over the last %d weeks: %s (peak %d)", len(active), sparkline(active), slices.Max(active)),
+		)
+	}
+	if len(r.Tasks) > 0 {
+		lines = append(lines, "", "Most used tasks:")
+		for _, task := range r.Tasks {
+			lines = append(lines, fmt.Sprintf("  %s: %d", task.Name, task.Instances))
+		}
+	}
+	return lines
+}
+
+// String renders the report as plain text.
+func (r Report) String() string {
+	return strings.Join(r.Lines(), "\n") + "\n"
+}
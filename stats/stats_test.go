@@ -0,0 +1,70 @@
+package stats
+
+import (
+	"claude-squad/audit"
+	"claude-squad/digest"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuild(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	daysAgo := func(d int) time.Time { return now.AddDate(0, 0, -d) }
+
+	entries := []audit.Entry{
+		{Time: daysAgo(20), Action: digest.ActionCreated, Instance: "login", Repository: "/src/app", Detail: "task fix-bug"},
+		{Time: daysAgo(18), Action: digest.ActionCreated, Instance: "spike", Repository: "/src/app"},
+		{Time: daysAgo(16), Action: digest.ActionKilled, Instance: "login", Repository: "/src/app",
+			Detail: "merged " + daysAgo(17).Format(time.RFC3339)},
+		{Time: daysAgo(10), Action: digest.ActionKilled, Instance: "spike", Repository: "/src/app", Detail: "abandoned"},
+		{Time: daysAgo(2), Action: digest.ActionCreated, Instance: "cache", Repository: "/src/app", Detail: "task fix-bug"},
+		{Time: daysAgo(1), Action: digest.ActionCreated, Instance: "gone", Repository: "/src/site"},
+	}
+	instances := []Instance{
+		{Title: "cache", Repository: "/src/app", CreatedAt: daysAgo(2), WorktreeBytes: 2000},
+		{Title: "api", Repository: "/src/app", CreatedAt: daysAgo(40), MergedAt: daysAgo(1), Task: "review", WorktreeBytes: 500},
+	}
+
+	r := Build(now, entries, instances)
+	require.Len(t, r.Repositories, 2)
+	app := r.Repositories[0]
+	require.Equal(t, "app", app.Name)
+	require.Equal(t, 2, app.Active)
+	require.Equal(t, 4, app.Created)
+	require.Equal(t, 2, app.Merged)
+	require.Equal(t, 1, app.Abandoned)
+	require.Equal(t, 6*24*time.Hour, app.AverageLifetime)
+	require.Equal(t, int64(2500), app.WorktreeBytes)
+
+	require.Len(t, app.Weeks, Weeks)
+	// Three weeks ago, login and spike were created, and login killed before the end of the week.
+	require.Equal(t, Week{Start: now.Add(-3 * week), Created: 2, Active: 2}, app.Weeks[Weeks-3])
+	require.Equal(t, Week{Start: now.Add(-2 * week), Created: 0, Active: 1}, app.Weeks[Weeks-2])
+	require.Equal(t, Week{Start: now.Add(-week), Created: 1, Active: 2}, app.Weeks[Weeks-1])
+
+	// The instance the audit log has no kill for, and isn't around, is only counted as created.
+	site := r.Repositories[1]
+	require.Equal(t, 1, site.Created)
+	require.Equal(t, 0, site.Active)
+	require.Equal(t, 0, site.Weeks[Weeks-1].Active)
+
+	require.Equal(t, []TaskUsage{{Name: "fix-bug", Instances: 2}, {Name: "review", Instances: 1}}, r.Tasks)
+
+	data, err := json.Marshal(r)
+	require.NoError(t, err)
+	var decoded Report
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, r, decoded)
+
+	require.Contains(t, r.String(), "2 active, 4 created, 2 merged, 1 abandoned")
+	require.Contains(t, r.String(), "Average lifetime 6d 0h, worktrees use 2.5 kB")
+}
+
+func TestFormatBytes(t *testing.T) {
+	require.Equal(t, "999 B", FormatBytes(999))
+	require.Equal(t, "1.5 kB", FormatBytes(1500))
+	require.Equal(t, "2.0 GB", FormatBytes(2e9))
+}
@@ -0,0 +1,285 @@
+// Package telemetry traces claude-squad's slow operations, like creating worktrees, starting tmux sessions,
+// sending prompts, polling statuses and saving state, as OpenTelemetry spans. They're exported to an OTLP
+// endpoint over HTTP with the JSON encoding, e.g. a local Jaeger or an OpenTelemetry collector, to see
+// where the time goes. Tracing is off unless an endpoint is configured, and spans are then no-ops.
+package telemetry
+
+import (
+	"bytes"
+	"claude-squad/log"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	serviceName = "claude-squad"
+	// flushInterval is how often finished spans are exported.
+	flushInterval = 5 * time.Second
+	// maxPending is how many finished spans are kept waiting to be exported. Spans finished while it's
+	// full are dropped.
+	maxPending = 2048
+	// exportTimeout bounds each export request.
+	exportTimeout = 10 * time.Second
+)
+
+var (
+	mu       sync.Mutex
+	exporter *otlpExporter
+)
+
+// Init starts exporting spans to the OTLP/HTTP endpoint, e.g. "http://localhost:4318", with headers added
+// to each request, sent with transport or the default transport if it's nil. If endpoint is empty,
+// OTEL_EXPORTER_OTLP_ENDPOINT is used, and if that's unset tracing stays off.
+func Init(endpoint string, headers map[string]string, transport http.RoundTripper) {
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	if endpoint == "" {
+		return
+	}
+	e := &otlpExporter{
+		url:     strings.TrimSuffix(endpoint, "/") + "/v1/traces",
+		headers: headers,
+		client:  &http.Client{Transport: transport, Timeout: exportTimeout},
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	mu.Lock()
+	exporter = e
+	mu.Unlock()
+	go e.run()
+}
+
+// Shutdown exports the remaining spans and stops tracing.
+func Shutdown() {
+	mu.Lock()
+	e := exporter
+	exporter = nil
+	mu.Unlock()
+	if e != nil {
+		close(e.done)
+		<-e.stopped
+	}
+}
+
+// Span is a timed operation. A nil span, returned when tracing is off, ignores everything.
+type Span struct {
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	start    time.Time
+	attrs    map[string]string
+	err      error
+}
+
+// Start starts a span for a new operation, with attributes given as key/value pairs.
+func Start(name string, attrs ...string) *Span {
+	mu.Lock()
+	enabled := exporter != nil
+	mu.Unlock()
+	if !enabled {
+		return nil
+	}
+	s := newSpan(name, attrs)
+	_, _ = rand.Read(s.traceID[:])
+	return s
+}
+
+// Child starts a span for a step of the span's operation.
+func (s *Span) Child(name string, attrs ...string) *Span {
+	if s == nil {
+		return nil
+	}
+	child := newSpan(name, attrs)
+	child.traceID = s.traceID
+	child.parentID = s.spanID
+	return child
+}
+
+func newSpan(name string, attrs []string) *Span {
+	s := &Span{name: name, start: time.Now(), attrs: make(map[string]string)}
+	_, _ = rand.Read(s.spanID[:])
+	for i := 0; i+1 < len(attrs); i += 2 {
+		s.attrs[attrs[i]] = attrs[i+1]
+	}
+	return s
+}
+
+// SetAttr sets an attribute of the span.
+func (s *Span) SetAttr(key, value string) {
+	if s != nil {
+		s.attrs[key] = value
+	}
+}
+
+// SetError marks the span as failed with err, if it's not nil.
+func (s *Span) SetError(err error) {
+	if s != nil && err != nil {
+		s.err = err
+	}
+}
+
+// End finishes the span and queues it for export.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	end := time.Now()
+	mu.Lock()
+	e := exporter
+	mu.Unlock()
+	if e != nil {
+		e.add(s.toOTLP(end))
+	}
+}
+
+// otlpSpan is a span in the OTLP JSON encoding.
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// OTLP span kinds and status codes.
+const (
+	spanKindInternal = 1
+	statusOK         = 1
+	statusError      = 2
+)
+
+func attribute(key, value string) otlpAttribute {
+	a := otlpAttribute{Key: key}
+	a.Value.StringValue = value
+	return a
+}
+
+func (s *Span) toOTLP(end time.Time) otlpSpan {
+	span := otlpSpan{
+		TraceID:           hex.EncodeToString(s.traceID[:]),
+		SpanID:            hex.EncodeToString(s.spanID[:]),
+		Name:              s.name,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(end.UnixNano(), 10),
+		Status:            otlpStatus{Code: statusOK},
+	}
+	if s.parentID != [8]byte{} {
+		span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+	}
+	for key, value := range s.attrs {
+		span.Attributes = append(span.Attributes, attribute(key, value))
+	}
+	if s.err != nil {
+		span.Status = otlpStatus{Code: statusError, Message: s.err.Error()}
+	}
+	return span
+}
+
+// otlpExporter batches finished spans and posts them to an OTLP/HTTP endpoint.
+type otlpExporter struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+
+	mu      sync.Mutex
+	pending []otlpSpan
+	// done is closed to stop the exporter, which closes stopped once it has exported the remaining spans.
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func (e *otlpExporter) add(span otlpSpan) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.pending) < maxPending {
+		e.pending = append(e.pending, span)
+	}
+}
+
+func (e *otlpExporter) run() {
+	defer close(e.stopped)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			e.flush()
+		case <-e.done:
+			e.flush()
+			return
+		}
+	}
+}
+
+func (e *otlpExporter) flush() {
+	e.mu.Lock()
+	spans := e.pending
+	e.pending = nil
+	e.mu.Unlock()
+	if len(spans) == 0 {
+		return
+	}
+	if err := e.export(spans); err != nil {
+		log.WarningLog.Printf("failed to export %d spans: %v", len(spans), err)
+	}
+}
+
+func (e *otlpExporter) export(spans []otlpSpan) error {
+	request := map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{"attributes": []otlpAttribute{attribute("service.name", serviceName)}},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]string{"name": serviceName},
+				"spans": spans,
+			}},
+		}},
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: %s", e.url, resp.Status)
+	}
+	return nil
+}
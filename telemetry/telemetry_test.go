@@ -0,0 +1,74 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisabled(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	Init("", nil, nil)
+	span := Start("instance.start")
+	require.Nil(t, span)
+	// Spans are no-ops when tracing is off.
+	child := span.Child("worktree.setup")
+	child.SetAttr("key", "value")
+	child.SetError(errors.New("failed"))
+	child.End()
+	span.End()
+	Shutdown()
+}
+
+func TestExport(t *testing.T) {
+	var mu sync.Mutex
+	var spans []otlpSpan
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v1/traces", r.URL.Path)
+		require.Equal(t, "secret", r.Header.Get("Authorization"))
+		var request struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []otlpSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		mu.Lock()
+		defer mu.Unlock()
+		for _, resource := range request.ResourceSpans {
+			for _, scope := range resource.ScopeSpans {
+				spans = append(spans, scope.Spans...)
+			}
+		}
+	}))
+	defer server.Close()
+
+	Init(server.URL+"/", map[string]string{"Authorization": "secret"}, nil)
+	span := Start("instance.start", "instance", "fix-login")
+	child := span.Child("worktree.setup")
+	child.SetError(errors.New("no space left"))
+	child.End()
+	span.End()
+	Shutdown()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, spans, 2)
+	require.Equal(t, "worktree.setup", spans[0].Name)
+	require.Equal(t, otlpStatus{Code: statusError, Message: "no space left"}, spans[0].Status)
+	require.Equal(t, "instance.start", spans[1].Name)
+	require.Equal(t, []otlpAttribute{attribute("instance", "fix-login")}, spans[1].Attributes)
+	require.Equal(t, spans[1].TraceID, spans[0].TraceID)
+	require.Equal(t, spans[1].SpanID, spans[0].ParentSpanID)
+	require.Empty(t, spans[1].ParentSpanID)
+	require.Len(t, spans[1].TraceID, 32)
+
+	// Tracing is off after shutdown.
+	require.Nil(t, Start("state.save"))
+}
@@ -0,0 +1,97 @@
+package tickets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// jira is a Jira site, accessed with its REST API. Tokens are API tokens for the user's account, or
+// personal access tokens on Jira Data Center if no user is configured.
+type jira struct {
+	client    *http.Client
+	url       string
+	user      string
+	token     string
+	doneState string
+}
+
+func (j *jira) request(method, path string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, j.url+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if j.user != "" {
+		req.SetBasicAuth(j.user, j.token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+j.token)
+	}
+	return do(j.client, req, out)
+}
+
+func (j *jira) Get(key string) (Ticket, error) {
+	var issue struct {
+		Key    string `json:"key"`
+		Fields struct {
+			Summary     string `json:"summary"`
+			Description string `json:"description"`
+		} `json:"fields"`
+	}
+	if err := j.request(http.MethodGet, "/rest/api/2/issue/"+url.PathEscape(key)+"?fields=summary,description", nil, &issue); err != nil {
+		return Ticket{}, fmt.Errorf("failed to get Jira issue %s: %w", key, err)
+	}
+	return Ticket{
+		Key:         issue.Key,
+		Title:       issue.Fields.Summary,
+		Description: issue.Fields.Description,
+		URL:         j.url + "/browse/" + issue.Key,
+	}, nil
+}
+
+func (j *jira) Resolve(key string) error {
+	path := "/rest/api/2/issue/" + url.PathEscape(key) + "/transitions"
+	var transitions struct {
+		Transitions []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+			To   struct {
+				Name string `json:"name"`
+			} `json:"to"`
+		} `json:"transitions"`
+	}
+	if err := j.request(http.MethodGet, path, nil, &transitions); err != nil {
+		return fmt.Errorf("failed to get the transitions of Jira issue %s: %w", key, err)
+	}
+	for _, transition := range transitions.Transitions {
+		if strings.EqualFold(transition.Name, j.doneState) || strings.EqualFold(transition.To.Name, j.doneState) {
+			body := map[string]any{"transition": map[string]string{"id": transition.ID}}
+			if err := j.request(http.MethodPost, path, body, nil); err != nil {
+				return fmt.Errorf("failed to transition Jira issue %s: %w", key, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("Jira issue %s has no transition to %q", key, j.doneState)
+}
+
+func (j *jira) Comment(key, body string) error {
+	if err := j.request(http.MethodPost, "/rest/api/2/issue/"+url.PathEscape(key)+"/comment", map[string]string{"body": body}, nil); err != nil {
+		return fmt.Errorf("failed to comment on Jira issue %s: %w", key, err)
+	}
+	return nil
+}
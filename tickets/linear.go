@@ -0,0 +1,135 @@
+package tickets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// linearEndpoint is Linear's GraphQL API.
+const linearEndpoint = "https://api.linear.app/graphql"
+
+// linear is a Linear workspace, accessed with a personal API key.
+type linear struct {
+	client    *http.Client
+	endpoint  string
+	token     string
+	doneState string
+}
+
+func (l *linear) query(query string, variables map[string]any, out any) error {
+	data, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, l.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", l.token)
+
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := do(l.client, req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		messages := make([]string, 0, len(resp.Errors))
+		for _, e := range resp.Errors {
+			messages = append(messages, e.Message)
+		}
+		return fmt.Errorf("%s", strings.Join(messages, "; "))
+	}
+	if err := json.Unmarshal(resp.Data, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// linearIssue is the part of a Linear issue claude-squad uses.
+type linearIssue struct {
+	ID          string `json:"id"`
+	Identifier  string `json:"identifier"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	URL         string `json:"url"`
+	Team        struct {
+		States struct {
+			Nodes []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"nodes"`
+		} `json:"states"`
+	} `json:"team"`
+}
+
+func (l *linear) issue(key string) (linearIssue, error) {
+	var data struct {
+		Issue linearIssue `json:"issue"`
+	}
+	err := l.query(`query($id: String!) {
+  issue(id: $id) { id identifier title description url team { states { nodes { id name } } } }
+}`, map[string]any{"id": key}, &data)
+	return data.Issue, err
+}
+
+func (l *linear) Get(key string) (Ticket, error) {
+	issue, err := l.issue(key)
+	if err != nil {
+		return Ticket{}, fmt.Errorf("failed to get Linear issue %s: %w", key, err)
+	}
+	return Ticket{Key: issue.Identifier, Title: issue.Title, Description: issue.Description, URL: issue.URL}, nil
+}
+
+func (l *linear) Resolve(key string) error {
+	issue, err := l.issue(key)
+	if err != nil {
+		return fmt.Errorf("failed to get Linear issue %s: %w", key, err)
+	}
+	for _, state := range issue.Team.States.Nodes {
+		if !strings.EqualFold(state.Name, l.doneState) {
+			continue
+		}
+		var data struct {
+			IssueUpdate struct {
+				Success bool `json:"success"`
+			} `json:"issueUpdate"`
+		}
+		err := l.query(`mutation($id: String!, $stateId: String!) {
+  issueUpdate(id: $id, input: {stateId: $stateId}) { success }
+}`, map[string]any{"id": issue.ID, "stateId": state.ID}, &data)
+		if err == nil && !data.IssueUpdate.Success {
+			err = fmt.Errorf("the update was rejected")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to move Linear issue %s to %s: %w", key, state.Name, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("Linear issue %s's team has no state %q", key, l.doneState)
+}
+
+func (l *linear) Comment(key, body string) error {
+	var data struct {
+		CommentCreate struct {
+			Success bool `json:"success"`
+		} `json:"commentCreate"`
+	}
+	err := l.query(`mutation($id: String!, $body: String!) {
+  commentCreate(input: {issueId: $id, body: $body}) { success }
+}`, map[string]any{"id": key, "body": body}, &data)
+	if err == nil && !data.CommentCreate.Success {
+		err = fmt.Errorf("the comment was rejected")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to comment on Linear issue %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+// Package tickets connects repositories to their issue trackers, Jira and Linear. Instances can be created
+// from a ticket, taking its title and description as their prompt, and once an instance's branch merges its
+// ticket is moved to done with the instance's summary as a comment.
+package tickets
+
+import (
+	"claude-squad/config"
+	"claude-squad/network"
+	"claude-squad/secrets"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// requestTimeout bounds each request to a tracker.
+const requestTimeout = 30 * time.Second
+
+// Ticket is an issue in a tracker.
+type Ticket struct {
+	// Key identifies the ticket, e.g. "ENG-123".
+	Key         string
+	Title       string
+	Description string
+	URL         string
+}
+
+// Prompt returns the prompt for an instance working on the ticket.
+func (t Ticket) Prompt() string {
+	parts := []string{t.Title}
+	if description := strings.TrimSpace(t.Description); description != "" {
+		parts = append(parts, description)
+	}
+	if t.URL != "" {
+		parts = append(parts, "Ticket: "+t.URL)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Tracker is an issue tracker.
+type Tracker interface {
+	// Get returns the ticket with key.
+	Get(key string) (Ticket, error)
+	// Resolve moves the ticket with key to the configured done state.
+	Resolve(key string) error
+	// Comment adds a comment to the ticket with key.
+	Comment(key, body string) error
+}
+
+// New returns the tracker cfg configures, with its token from the secrets store.
+func New(cfg config.TicketConfig) (Tracker, error) {
+	if err := network.Check("issue tracker sync"); err != nil {
+		return nil, err
+	}
+	switch cfg.Tracker {
+	case config.TrackerJira, config.TrackerLinear:
+	case "":
+		return nil, fmt.Errorf("no issue tracker is configured")
+	default:
+		return nil, fmt.Errorf("unknown issue tracker %q, expected %q or %q", cfg.Tracker, config.TrackerJira, config.TrackerLinear)
+	}
+	token, err := secrets.Get(cfg.GetTokenSecret())
+	if err != nil {
+		return nil, err
+	}
+	client, err := network.NewClient(config.LoadConfig().Network, requestTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Tracker == config.TrackerLinear {
+		return &linear{client: client, endpoint: linearEndpoint, token: token, doneState: cfg.GetDoneState()}, nil
+	}
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("the Jira url is not configured")
+	}
+	return &jira{client: client, url: strings.TrimSuffix(cfg.URL, "/"), user: cfg.User, token: token,
+		doneState: cfg.GetDoneState()}, nil
+}
+
+// do sends req with client and decodes the JSON response into out, if it's not nil.
+func do(client *http.Client, req *http.Request, out any) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package tickets
+
+import (
+	"claude-squad/config"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJira(t *testing.T) {
+	var transitioned string
+	var comment string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, token, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "me@example.com", user)
+		require.Equal(t, "secret", token)
+
+		switch r.Method + " " + r.URL.Path {
+		case "GET /rest/api/2/issue/ENG-1":
+			w.Write([]byte(`{"key": "ENG-1", "fields": {"summary": "Fix login", "description": "It times out."}}`))
+		case "GET /rest/api/2/issue/ENG-1/transitions":
+			w.Write([]byte(`{"transitions": [{"id": "1", "name": "Start", "to": {"name": "In Progress"}}, {"id": "2", "name": "Finish", "to": {"name": "Done"}}]}`))
+		case "POST /rest/api/2/issue/ENG-1/transitions":
+			var body struct {
+				Transition struct {
+					ID string `json:"id"`
+				} `json:"transition"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			transitioned = body.Transition.ID
+			w.WriteHeader(http.StatusNoContent)
+		case "POST /rest/api/2/issue/ENG-1/comment":
+			var body struct {
+				Body string `json:"body"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			comment = body.Body
+			w.WriteHeader(http.StatusCreated)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tracker := &jira{client: server.Client(), url: server.URL, user: "me@example.com", token: "secret", doneState: "done"}
+	ticket, err := tracker.Get("ENG-1")
+	require.NoError(t, err)
+	require.Equal(t, Ticket{Key: "ENG-1", Title: "Fix login", Description: "It times out.", URL: server.URL + "/browse/ENG-1"}, ticket)
+	require.Equal(t, "Fix login\n\nIt times out.\n\nTicket: "+server.URL+"/browse/ENG-1", ticket.Prompt())
+
+	require.NoError(t, tracker.Resolve("ENG-1"))
+	require.Equal(t, "2", transitioned)
+	require.NoError(t, tracker.Comment("ENG-1", "Merged."))
+	require.Equal(t, "Merged.", comment)
+
+	_, err = tracker.Get("ENG-2")
+	require.ErrorContains(t, err, "404")
+
+	tracker.doneState = "Closed"
+	require.ErrorContains(t, tracker.Resolve("ENG-1"), `no transition to "Closed"`)
+}
+
+func TestLinear(t *testing.T) {
+	var requests []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "secret", r.Header.Get("Authorization"))
+		data, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		var body struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		require.NoError(t, json.Unmarshal(data, &body))
+		requests = append(requests, body.Variables)
+
+		switch {
+		case body.Variables["id"] == "ENG-9":
+			w.Write([]byte(`{"errors": [{"message": "Entity not found"}]}`))
+		case body.Variables["stateId"] != nil:
+			w.Write([]byte(`{"data": {"issueUpdate": {"success": true}}}`))
+		case body.Variables["body"] != nil:
+			w.Write([]byte(`{"data": {"commentCreate": {"success": true}}}`))
+		default:
+			w.Write([]byte(`{"data": {"issue": {"id": "uuid-1", "identifier": "ENG-1", "title": "Fix login",
+				"url": "https://linear.app/eng/issue/ENG-1", "team": {"states": {"nodes": [{"id": "s1", "name": "Todo"}, {"id": "s2", "name": "Done"}]}}}}}`))
+		}
+	}))
+	defer server.Close()
+
+	tracker := &linear{client: server.Client(), endpoint: server.URL, token: "secret", doneState: "Done"}
+	ticket, err := tracker.Get("ENG-1")
+	require.NoError(t, err)
+	require.Equal(t, Ticket{Key: "ENG-1", Title: "Fix login", URL: "https://linear.app/eng/issue/ENG-1"}, ticket)
+
+	require.NoError(t, tracker.Resolve("ENG-1"))
+	require.Equal(t, map[string]any{"id": "uuid-1", "stateId": "s2"}, requests[len(requests)-1])
+	require.NoError(t, tracker.Comment("ENG-1", "Merged."))
+	require.Equal(t, map[string]any{"id": "ENG-1", "body": "Merged."}, requests[len(requests)-1])
+
+	_, err = tracker.Get("ENG-9")
+	require.ErrorContains(t, err, "Entity not found")
+}
+
+func TestNew(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	_, err := New(config.TicketConfig{})
+	require.ErrorContains(t, err, "no issue tracker")
+	_, err = New(config.TicketConfig{Tracker: "github"})
+	require.ErrorContains(t, err, "unknown issue tracker")
+	_, err = New(config.TicketConfig{Tracker: config.TrackerLinear})
+	require.ErrorContains(t, err, "secrets set linear")
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"claude-squad/ui/overlay"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// accessible renders the UI for screen readers and logs: without colors, with ASCII characters
+// only, and with text status markers instead of icons and spinners, so lines don't change unless
+// their content does. It's set by SetAccessible.
+var accessible bool
+
+// SetAccessible switches the UI to accessible mode. It must be called before the UI is created.
+func SetAccessible() {
+	accessible = true
+	lipgloss.SetColorProfile(termenv.Ascii)
+	overlay.SetAccessible()
+
+	inactiveTabBorder = tabBorderWithBottom(overlay.ASCIIBorder, "+", "-", "+")
+	activeTabBorder = tabBorderWithBottom(overlay.ASCIIBorder, "+", " ", "+")
+	inactiveTabStyle = inactiveTabStyle.Border(inactiveTabBorder, true)
+	activeTabStyle = activeTabStyle.Border(activeTabBorder, true)
+	windowStyle = windowStyle.Border(overlay.ASCIIBorder, false, true, true, true)
+	tabEdge, tabJoinLeft, tabJoinRight = "|", "+", "+"
+	compareBorderStyle = compareBorderStyle.Border(overlay.ASCIIBorder)
+
+	separator = ", "
+	verticalSeparator = " | "
+	FallBackText = "Claude Squad"
+}
+
+// boxBorder returns the border boxes are drawn with.
+func boxBorder() lipgloss.Border {
+	if accessible {
+		return overlay.ASCIIBorder
+	}
+	return lipgloss.RoundedBorder()
+}
+
+// statusMarker returns icon, or the status spelled out in accessible mode.
+func statusMarker(icon, status string) string {
+	if accessible {
+		return "[" + status + "] "
+	}
+	return icon
+}
@@ -0,0 +1,33 @@
+package ui
+
+import (
+	"claude-squad/session"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderAccessible(t *testing.T) {
+	accessible = true
+	t.Cleanup(func() { accessible = false })
+
+	s := spinner.New()
+	r := &InstanceRenderer{spinner: &s}
+	r.setWidth(40)
+	instance := &session.Instance{Title: "fix-login", Branch: "fix-login", Status: session.Ready}
+
+	rendered := r.Render(instance, 1, true, false)
+	require.Contains(t, rendered, ">1.  fix-login")
+	require.Contains(t, rendered, "[ready]")
+
+	instance.Status = session.Running
+	rendered = r.Render(instance, 1, false, false)
+	require.Contains(t, rendered, " 1.  fix-login")
+	require.Contains(t, rendered, "[running]")
+	for _, line := range strings.Split(rendered, "\n") {
+		require.LessOrEqual(t, lipgloss.Width(line), 40)
+	}
+}
@@ -0,0 +1,86 @@
+package ui
+
+import (
+	"claude-squad/session/git"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var compareBorderStyle = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).
+	BorderForeground(lipgloss.Color("62")).
+	Padding(0, 1)
+
+var compareTitleStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#7D56F4"))
+
+// ComparePane shows the difference between two instances' worktrees.
+type ComparePane struct {
+	viewport viewport.Model
+	title    string
+	content  string
+	width    int
+	height   int
+}
+
+func NewComparePane() *ComparePane {
+	return &ComparePane{
+		viewport: viewport.New(0, 0),
+	}
+}
+
+// SetSize sets the outer size of the pane, including its border.
+func (c *ComparePane) SetSize(width, height int) {
+	c.width = width
+	c.height = height
+	// Leave room for the border, padding and title.
+	c.viewport.Width = max(width-4, 0)
+	c.viewport.Height = max(height-4, 0)
+	c.viewport.SetContent(c.content)
+}
+
+// SetComparison replaces the pane's content with the comparison of from against to.
+func (c *ComparePane) SetComparison(from, to string, comparison *git.Comparison) {
+	c.title = fmt.Sprintf("Comparing %s → %s", from, to)
+
+	if len(comparison.Files) == 0 {
+		c.content = "No differences"
+	} else {
+		additions := AdditionStyle.Render(fmt.Sprintf("%d additions(+)", comparison.Added))
+		deletions := DeletionStyle.Render(fmt.Sprintf("%d deletions(-)", comparison.Removed))
+		summary := fmt.Sprintf("%s %s across %d files", additions, deletions, len(comparison.Files))
+
+		files := make([]string, 0, len(comparison.Files))
+		for _, file := range comparison.Files {
+			files = append(files, fmt.Sprintf("%s %s %s",
+				AdditionStyle.Render(fmt.Sprintf("%+5d", file.Added)),
+				DeletionStyle.Render(fmt.Sprintf("%5d", -file.Removed)),
+				file.Path))
+		}
+		c.content = lipgloss.JoinVertical(lipgloss.Left,
+			summary, "", strings.Join(files, "\n"), "", colorizeDiff(comparison.Content, nil))
+	}
+
+	c.viewport.SetContent(c.content)
+	c.viewport.GotoTop()
+}
+
+// ScrollUp scrolls the viewport up
+func (c *ComparePane) ScrollUp() {
+	c.viewport.LineUp(1)
+}
+
+// ScrollDown scrolls the viewport down
+func (c *ComparePane) ScrollDown() {
+	c.viewport.LineDown(1)
+}
+
+func (c *ComparePane) String() string {
+	body := lipgloss.JoinVertical(lipgloss.Left,
+		compareTitleStyle.Render(c.title),
+		"",
+		c.viewport.View())
+	return compareBorderStyle.Width(max(c.width-2, 0)).Render(body)
+}
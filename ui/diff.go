@@ -2,17 +2,22 @@ package ui
 
 import (
 	"claude-squad/session"
+	"claude-squad/session/git"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	"github.com/charmbracelet/lipgloss"
 )
 
 var (
-	AdditionStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#22c55e"))
-	DeletionStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ef4444"))
-	HunkStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#0ea5e9"))
+	AdditionStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#22c55e"))
+	DeletionStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#ef4444"))
+	HunkStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("#0ea5e9"))
+	AgentStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#7D56F4"))
+	CommitStyle    = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#888888", Dark: "#888888"})
+	SensitiveStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#de613e"))
 )
 
 type DiffPane struct {
@@ -89,7 +94,13 @@ func (d *DiffPane) SetDiff(instance *session.Instance) {
 		additions := AdditionStyle.Render(fmt.Sprintf("%d additions(+)", stats.Added))
 		deletions := DeletionStyle.Render(fmt.Sprintf("%d deletions(-)", stats.Removed))
 		d.stats = lipgloss.JoinHorizontal(lipgloss.Center, additions, " ", deletions)
-		d.diff = colorizeDiff(stats.Content)
+		if breakdown := renderBreakdown(stats); breakdown != "" {
+			d.stats = lipgloss.JoinVertical(lipgloss.Left, d.stats, breakdown)
+		}
+		if commits := renderCommits(stats.Commits); commits != "" {
+			d.stats = lipgloss.JoinVertical(lipgloss.Left, d.stats, "", commits)
+		}
+		d.diff = colorizeDiff(stats.Content, stats.Blame)
 		d.viewport.SetContent(lipgloss.JoinVertical(lipgloss.Left, d.stats, d.diff))
 	}
 }
@@ -108,13 +119,65 @@ func (d *DiffPane) ScrollDown() {
 	d.viewport.LineDown(1)
 }
 
-func colorizeDiff(diff string) string {
+// renderBreakdown summarizes the changes by language and by top-level directory, and warns about changes
+// to sensitive paths.
+func renderBreakdown(stats *git.DiffStats) string {
+	if len(stats.Files) == 0 {
+		return ""
+	}
+	format := func(groups []git.Breakdown) string {
+		parts := make([]string, 0, len(groups))
+		for _, group := range groups {
+			parts = append(parts, fmt.Sprintf("%s %s/%s", group.Name,
+				AdditionStyle.Render(fmt.Sprintf("+%d", group.Added)),
+				DeletionStyle.Render(fmt.Sprintf("-%d", group.Removed))))
+		}
+		return strings.Join(parts, " · ")
+	}
+	lines := []string{format(git.ByLanguage(stats.Files)), format(git.ByDirectory(stats.Files))}
+	for _, file := range stats.Sensitive {
+		lines = append(lines, SensitiveStyle.Render("[sensitive] "+file))
+	}
+	for _, hint := range stats.Hints {
+		lines = append(lines, SensitiveStyle.Render("[regression] "+hint))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderCommits lists the branch's commits, marking the ones created on behalf of the agent.
+func renderCommits(commits []git.CommitInfo) string {
+	if len(commits) == 0 {
+		return ""
+	}
+	lines := []string{fmt.Sprintf("%d commits on branch:", len(commits))}
+	for _, commit := range commits {
+		marker := "       "
+		if commit.AgentAuthored {
+			marker = AgentStyle.Render("[agent]")
+		}
+		lines = append(lines, fmt.Sprintf("%s %s %s", marker, CommitStyle.Render(commit.SHA), commit.Subject))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// colorizeDiff colors the diff. If blame is set, removed lines are annotated with who last changed them
+// and when.
+func colorizeDiff(diff string, blame git.Blame) string {
 	var coloredOutput strings.Builder
 
+	// The file and old line number of the current line, for looking up its blame.
+	var file string
+	oldLine := 0
+	now := time.Now()
+
 	lines := strings.Split(diff, "\n")
 	for _, line := range lines {
 		if len(line) > 0 {
+			if strings.HasPrefix(line, "--- a/") {
+				file = strings.TrimPrefix(line, "--- a/")
+			}
 			if strings.HasPrefix(line, "@@") {
+				oldLine, _ = git.HunkOldStart(line)
 				// Color hunk headers cyan
 				coloredOutput.WriteString(HunkStyle.Render(line) + "\n")
 			} else if line[0] == '+' && (len(line) == 1 || line[1] != '+') {
@@ -122,8 +185,16 @@ func colorizeDiff(diff string) string {
 				coloredOutput.WriteString(AdditionStyle.Render(line) + "\n")
 			} else if line[0] == '-' && (len(line) == 1 || line[1] != '-') {
 				// Color removed lines red, excluding metadata like '---'
-				coloredOutput.WriteString(DeletionStyle.Render(line) + "\n")
+				coloredOutput.WriteString(DeletionStyle.Render(line))
+				if b, ok := blame[file][oldLine]; ok {
+					coloredOutput.WriteString(CommitStyle.Render(fmt.Sprintf("  (%s, %s)", b.Author, formatTimestamp(b.Time, now, false))))
+				}
+				coloredOutput.WriteString("\n")
+				oldLine++
 			} else {
+				if line[0] == ' ' {
+					oldLine++
+				}
 				// Print metadata and unchanged lines without color
 				coloredOutput.WriteString(line + "\n")
 			}
@@ -220,7 +220,7 @@ func (dp *DirectoryPicker) View() string {
 	
 	// Border
 	borderStyle := lipgloss.NewStyle().
-		BorderStyle(lipgloss.RoundedBorder()).
+		BorderStyle(boxBorder()).
 		BorderForeground(lipgloss.Color("#874BFD")).
 		Padding(1)
 	
@@ -8,6 +8,9 @@ import (
 type ErrBox struct {
 	height, width int
 	err           error
+	info          string
+	// hint is shown when there's no error or info to show.
+	hint string
 }
 
 var errStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{
@@ -15,16 +18,39 @@ var errStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{
 	Dark:  "#FF0000",
 })
 
+var infoStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{
+	Light: "#1a1a1a",
+	Dark:  "#dddddd",
+})
+
+var hintStyle = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{
+	Light: "#767676",
+	Dark:  "#9c9c9c",
+})
+
 func NewErrBox() *ErrBox {
 	return &ErrBox{}
 }
 
 func (e *ErrBox) SetError(err error) {
 	e.err = err
+	e.info = ""
+}
+
+// SetInfo shows a non-error status message in place of the error.
+func (e *ErrBox) SetInfo(info string) {
+	e.err = nil
+	e.info = info
+}
+
+// SetHint shows a contextual hint while there's no error or info. Clear leaves it; the empty string hides it.
+func (e *ErrBox) SetHint(hint string) {
+	e.hint = hint
 }
 
 func (e *ErrBox) Clear() {
 	e.err = nil
+	e.info = ""
 }
 
 func (e *ErrBox) SetSize(width, height int) {
@@ -34,13 +60,20 @@ func (e *ErrBox) SetSize(width, height int) {
 
 func (e *ErrBox) String() string {
 	var err string
+	style := errStyle
 	if e.err != nil {
 		err = e.err.Error()
-		lines := strings.Split(err, "\n")
-		err = strings.Join(lines, "//")
-		if len(err) > e.width-3 && e.width-3 >= 0 {
-			err = err[:e.width-3] + "..."
-		}
+	} else if e.info != "" {
+		err = e.info
+		style = infoStyle
+	} else if e.hint != "" {
+		err = e.hint
+		style = hintStyle
+	}
+	lines := strings.Split(err, "\n")
+	err = strings.Join(lines, "//")
+	if e.width-3 >= 0 {
+		err = truncate(err, e.width)
 	}
-	return lipgloss.Place(e.width, e.height, lipgloss.Center, lipgloss.Center, errStyle.Render(err))
+	return lipgloss.Place(e.width, e.height, lipgloss.Center, lipgloss.Center, style.Render(err))
 }
@@ -1,18 +1,30 @@
 package ui
 
 import (
+	"claude-squad/config"
 	"claude-squad/log"
+	"cmp"
 	"claude-squad/session"
-	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
 )
 
 const readyIcon = "* "
 const pausedIcon = "|| "
+const stalledIcon = "! "
+const planIcon = "? "
+const errorIcon = "x "
+const stoppedIcon = "# "
+const overBudgetIcon = "$ "
+
+// sensitiveBadge marks instances that changed sensitive paths such as migrations.
+const sensitiveBadge = "!"
 
 var readyStyle = lipgloss.NewStyle().
 	Foreground(lipgloss.AdaptiveColor{Light: "#51bd73", Dark: "#51bd73"})
@@ -26,6 +38,16 @@ var removedLinesStyle = lipgloss.NewStyle().
 var pausedStyle = lipgloss.NewStyle().
 	Foreground(lipgloss.AdaptiveColor{Light: "#888888", Dark: "#888888"})
 
+var stalledStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#de613e"))
+
+var errorStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#ff3333")).
+	Bold(true)
+
+var planStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("#FFCC00"))
+
 var titleStyle = lipgloss.NewStyle().
 	Padding(1, 1, 0, 1).
 	Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#dddddd"})
@@ -52,12 +74,29 @@ var autoYesStyle = lipgloss.NewStyle().
 	Background(lipgloss.Color("#dde4f0")).
 	Foreground(lipgloss.Color("#1a1a1a"))
 
+var offlineStyle = lipgloss.NewStyle().
+	Background(lipgloss.Color("#de613e")).
+	Foreground(lipgloss.Color("#1a1a1a"))
+
+var airGappedStyle = lipgloss.NewStyle().
+	Background(lipgloss.Color("#7d56f4")).
+	Foreground(lipgloss.Color("#ffffff"))
+
 type List struct {
 	items         []*session.Instance
 	selectedIdx   int
 	height, width int
 	renderer      *InstanceRenderer
 	autoyes       bool
+	// sortMode is the order instances are listed in. It doesn't change the underlying order of items.
+	sortMode SortMode
+	// queued is the number of network operations waiting to be retried. The list shows an offline
+	// indicator while it's non-zero.
+	queued int
+	// airGapped shows an indicator that the features reaching network services are disabled.
+	airGapped bool
+	// workspace is the selected workspace, whose repositories are the only ones shown. Nil shows them all.
+	workspace *config.Workspace
 
 	// map of repo name to number of instances using it. Used to display the repo name only if there are
 	// multiple repos in play.
@@ -65,6 +104,14 @@ type List struct {
 	
 	// Repository tabs component for managing multiple repositories
 	repoTabs *RepoTabs
+
+	// previewWidth and previewHeight are the size instances' tmux windows are set to.
+	previewWidth, previewHeight int
+	// sized are the instances whose tmux windows have been set to the preview size since it last changed.
+	// The others are resized when they're selected.
+	sized map[*session.Instance]bool
+	// lastLen is the length of the last rendered list, to size the next one's buffer.
+	lastLen int
 }
 
 func NewList(spinner *spinner.Model, autoYes bool) *List {
@@ -77,6 +124,68 @@ func NewList(spinner *spinner.Model, autoYes bool) *List {
 	}
 }
 
+// ToggleAbsoluteTimes switches timestamps between relative ("12m ago") and absolute local times.
+func (l *List) ToggleAbsoluteTimes() {
+	l.renderer.absoluteTimes = !l.renderer.absoluteTimes
+}
+
+// SetAbsoluteTimes sets whether timestamps are shown as absolute local times.
+func (l *List) SetAbsoluteTimes(absolute bool) {
+	l.renderer.absoluteTimes = absolute
+}
+
+// SetColumns sets the time columns shown under each instance. Nil shows the default columns.
+func (l *List) SetColumns(columns []string) {
+	l.renderer.columns = columns
+}
+
+// SetPluginColumns sets the plugins' columns shown under each instance, by instance title.
+func (l *List) SetPluginColumns(columns map[string][]string) {
+	l.renderer.pluginColumns = columns
+}
+
+// SetHealth sets the problems the health checks found, by instance title, shown in the health column.
+func (l *List) SetHealth(health map[string]string) {
+	l.renderer.health = health
+}
+
+// CycleSort switches to the next sort mode and returns it.
+func (l *List) CycleSort() SortMode {
+	l.sortMode = (l.sortMode + 1) % (SortActivity + 1)
+	return l.sortMode
+}
+
+// SetSortMode sets the order instances are listed in.
+func (l *List) SetSortMode(mode SortMode) {
+	l.sortMode = mode
+}
+
+// SetWorkspace shows only the repositories of workspace and their instances, or all of them if it's nil.
+func (l *List) SetWorkspace(workspace *config.Workspace) {
+	l.workspace = workspace
+	if workspace == nil {
+		l.repoTabs.SetWorkspace(nil)
+	} else {
+		l.repoTabs.SetWorkspace(workspace.Repositories)
+	}
+	l.EnsureValidSelection()
+}
+
+// GetWorkspace returns the selected workspace, or nil if all repositories are shown.
+func (l *List) GetWorkspace() *config.Workspace {
+	return l.workspace
+}
+
+// SetAirGapped sets whether air-gapped mode is on, shown as an indicator.
+func (l *List) SetAirGapped(airGapped bool) {
+	l.airGapped = airGapped
+}
+
+// SetQueued sets the number of operations waiting for the network, shown as an offline indicator.
+func (l *List) SetQueued(queued int) {
+	l.queued = queued
+}
+
 // SetSize sets the height and width of the list.
 func (l *List) SetSize(width, height int) {
 	l.width = width
@@ -85,20 +194,37 @@ func (l *List) SetSize(width, height int) {
 	l.repoTabs.SetWidth(width)
 }
 
+// SetCompact sets whether instances are rendered compactly, for narrow terminals.
+func (l *List) SetCompact(compact bool) {
+	l.renderer.compact = compact
+}
+
 // SetSessionPreviewSize sets the height and width for the tmux sessions. This makes the stdout line have the correct
-// width and height.
-func (l *List) SetSessionPreviewSize(width, height int) (err error) {
-	for i, item := range l.items {
-		if !item.Started() || item.Paused() {
-			continue
-		}
+// width and height. Only the selected instance is resized right away; the others are resized when they're selected.
+func (l *List) SetSessionPreviewSize(width, height int) error {
+	l.previewWidth = width
+	l.previewHeight = height
+	// Instances' PTYs may have been recreated since they were sized, e.g. by attaching, so size them again
+	// even if the size didn't change.
+	l.sized = make(map[*session.Instance]bool)
+	return l.SizeSelected()
+}
 
-		if innerErr := item.SetPreviewSize(width, height); innerErr != nil {
-			err = errors.Join(
-				err, fmt.Errorf("could not set preview size for instance %d: %v", i, innerErr))
-		}
+// SizeSelected sets the selected instance's tmux window to the preview size, if it isn't already.
+func (l *List) SizeSelected() error {
+	selected := l.GetSelectedInstance()
+	if selected == nil || !selected.Started() || selected.Inactive() || selected.Dormant() || l.sized[selected] ||
+		l.previewWidth == 0 {
+		return nil
+	}
+	if err := selected.SetPreviewSize(l.previewWidth, l.previewHeight); err != nil {
+		return fmt.Errorf("could not set preview size for '%s': %w", selected.Title, err)
+	}
+	if l.sized == nil {
+		l.sized = make(map[*session.Instance]bool)
 	}
-	return
+	l.sized[selected] = true
+	return nil
 }
 
 func (l *List) NumInstances() int {
@@ -109,76 +235,292 @@ func (l *List) NumInstances() int {
 type InstanceRenderer struct {
 	spinner *spinner.Model
 	width   int
+	// listWidth is the width of the list, which rows are padded to.
+	listWidth int
+	// absoluteTimes renders timestamps as local times instead of relative to now.
+	absoluteTimes bool
+	// columns are the time columns shown under each instance.
+	columns []string
+	// pluginColumns are the plugins' columns shown under each instance, by instance title.
+	pluginColumns map[string][]string
+	// health are the problems the health checks found, by instance title.
+	health map[string]string
+	// compact drops the diff stats and all but the first time column, for narrow terminals.
+	compact bool
+	// rows are the instances' last rendered rows.
+	rows map[*session.Instance]renderedRow
+}
+
+// Time columns that can be shown under each instance.
+const (
+	ColumnCreated  = "created"
+	ColumnUpdated  = "updated"
+	ColumnUptime   = "uptime"
+	ColumnActivity = "activity"
+	// ColumnHealth shows the problems the health checks found, if any.
+	ColumnHealth = "health"
+)
+
+var defaultColumns = []string{ColumnCreated, ColumnUpdated, ColumnHealth}
+
+// SortMode is the order instances are listed in.
+type SortMode int
+
+const (
+	// SortDefault lists instances in the order they were created.
+	SortDefault SortMode = iota
+	// SortUptime lists the longest running instances first.
+	SortUptime
+	// SortActivity lists the most recently active instances first.
+	SortActivity
+)
+
+func (s SortMode) String() string {
+	switch s {
+	case SortUptime:
+		return "uptime"
+	case SortActivity:
+		return "activity"
+	default:
+		return "default"
+	}
+}
+
+// ParseSortMode returns the sort mode named name, or SortDefault if there's none.
+func ParseSortMode(name string) SortMode {
+	for mode := SortDefault; mode <= SortActivity; mode++ {
+		if mode.String() == name {
+			return mode
+		}
+	}
+	return SortDefault
 }
 
 func (r *InstanceRenderer) setWidth(width int) {
 	r.width = AdjustPreviewWidth(width)
+	r.listWidth = width
 }
 
 const branchIcon = ">"
 
+// repoAccentBar is drawn beside instances' rows in their repository's accent color.
+const repoAccentBar = "▎"
+
+// rowKey is everything an instance's row is rendered from. Rows are only rendered again when it changes,
+// e.g. at each spinner frame while the instance is running.
+type rowKey struct {
+	idx                     int
+	selected, multipleRepos bool
+	width, listWidth        int
+	compact                 bool
+	status                  session.Status
+	awaitingApproval        bool
+	spinner                 string
+	title                   string
+	branch                  string
+	showDiff, sensitive     bool
+	added, removed          int
+	columns                 string
+	summary                 string
+	database                string
+	// accent is the color of the bar beside the row, the accent of the instance's repository. It's only
+	// shown when instances of several repositories are listed.
+	accent lipgloss.Color
+}
+
+// renderedRow is an instance's rendered row and the key it was rendered from.
+type renderedRow struct {
+	key  rowKey
+	text string
+}
+
+// diffStyles are the styles of the diff stats, on the background of a row.
+type diffStyles struct {
+	sensitive, added, comma, removed lipgloss.Style
+}
+
+func newDiffStyles(desc lipgloss.Style) diffStyles {
+	return diffStyles{
+		sensitive: SensitiveStyle.Background(desc.GetBackground()),
+		added:     addedLinesStyle.Background(desc.GetBackground()),
+		comma:     lipgloss.NewStyle().Background(desc.GetBackground()).Foreground(desc.GetForeground()),
+		removed:   removedLinesStyle.Background(desc.GetBackground()),
+	}
+}
+
+var (
+	listDiffStyles     = newDiffStyles(listDescStyle)
+	selectedDiffStyles = newDiffStyles(selectedDescStyle)
+)
+
 func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, hasMultipleRepos bool) string {
-	prefix := fmt.Sprintf(" %d. ", idx)
-	if idx >= 10 {
+	key := r.rowKey(i, idx, selected, hasMultipleRepos)
+	if row, ok := r.rows[i]; ok && row.key == key {
+		return row.text
+	}
+	text := padLines(r.renderRow(key), key.listWidth)
+	if r.rows == nil {
+		r.rows = make(map[*session.Instance]renderedRow)
+	}
+	r.rows[i] = renderedRow{key: key, text: text}
+	return text
+}
+
+// forgetRows drops the rendered rows of instances that are no longer listed.
+func (r *InstanceRenderer) forgetRows(items []*session.Instance) {
+	if len(r.rows) <= len(items) {
+		return
+	}
+	listed := make(map[*session.Instance]bool, len(items))
+	for _, item := range items {
+		listed[item] = true
+	}
+	for instance := range r.rows {
+		if !listed[instance] {
+			delete(r.rows, instance)
+		}
+	}
+}
+
+// rowKey gathers what the row of instance i is rendered from.
+func (r *InstanceRenderer) rowKey(i *session.Instance, idx int, selected bool, hasMultipleRepos bool) rowKey {
+	key := rowKey{
+		idx:              idx,
+		selected:         selected,
+		multipleRepos:    hasMultipleRepos,
+		width:            r.width,
+		listWidth:        r.listWidth,
+		compact:          r.compact,
+		status:           i.Status,
+		awaitingApproval: i.PlanState == session.PlanAwaitingApproval,
+		title:            i.Title,
+		branch:           i.Branch,
+		columns:          r.renderColumns(i),
+	}
+	if i.Status == session.Running {
+		key.spinner = r.spinner.View()
+	}
+	if i.Muted {
+		key.title += " (muted)"
+	}
+	if i.Snoozed() {
+		key.title += " (snoozed until " + i.SnoozedUntil.Format("15:04") + ")"
+	}
+	if hasMultipleRepos && !accessible {
+		if repoPath := i.RepoPath(); repoPath != "" {
+			key.accent = RepoAccent(repoPath)
+		}
+	}
+	if i.Started() && hasMultipleRepos {
+		if repoPath := i.RepoPath(); repoPath != "" {
+			key.branch += fmt.Sprintf(" (%s)", RepoDisplayName(repoPath))
+		}
+	}
+
+	// Don't show diff stats if there's an error, if they don't exist or if there's no room for them
+	if stat := i.GetDiffStats(); stat != nil && stat.Error == nil && !stat.IsEmpty() && !r.compact {
+		key.showDiff = true
+		key.sensitive = len(stat.Sensitive) > 0
+		key.added = stat.Added
+		key.removed = stat.Removed
+	}
+
+	if selected {
+		key.summary = i.Summary
+		if i.Database.Name != "" {
+			status := "snapshot " + formatTimestamp(i.Database.SnapshotAt, time.Now(), r.absoluteTimes)
+			if i.Database.Error != "" {
+				status = "snapshot failed, press d"
+			}
+			key.database = "db " + i.Database.Name + " · " + status
+		}
+	}
+	return key
+}
+
+// renderRow renders an instance's row from its key.
+func (r *InstanceRenderer) renderRow(key rowKey) string {
+	// Leave room for the accent bar.
+	if key.accent != "" {
+		key.width--
+	}
+	prefix := fmt.Sprintf(" %d. ", key.idx)
+	if key.idx >= 10 {
 		prefix = prefix[:len(prefix)-1]
 	}
+	// Without colors, the selected instance is only told apart by a marker.
+	if accessible && key.selected {
+		prefix = ">" + prefix[1:]
+	}
 	titleS := selectedTitleStyle
 	descS := selectedDescStyle
-	if !selected {
+	diffS := selectedDiffStyles
+	if !key.selected {
 		titleS = titleStyle
 		descS = listDescStyle
+		diffS = listDiffStyles
 	}
 
 	// add spinner next to title if it's running
 	var join string
-	switch i.Status {
+	switch key.status {
 	case session.Running:
-		join = fmt.Sprintf("%s ", r.spinner.View())
+		join = statusMarker(key.spinner+" ", "running")
 	case session.Ready:
-		join = readyStyle.Render(readyIcon)
+		join = readyStyle.Render(statusMarker(readyIcon, "ready"))
+		if key.awaitingApproval {
+			join = planStyle.Render(statusMarker(planIcon, "plan"))
+		}
 	case session.Paused:
-		join = pausedStyle.Render(pausedIcon)
+		join = pausedStyle.Render(statusMarker(pausedIcon, "paused"))
+	case session.Stalled:
+		join = stalledStyle.Render(statusMarker(stalledIcon, "stalled"))
+	case session.Error:
+		join = errorStyle.Render(statusMarker(errorIcon, "error"))
+	case session.Stopped:
+		join = pausedStyle.Render(statusMarker(stoppedIcon, "stopped"))
+	case session.OverBudget:
+		join = stalledStyle.Render(statusMarker(overBudgetIcon, "over budget"))
 	default:
 	}
+	// The title is cut to leave room for the status, which is at most two cells wide unless it's spelled out.
+	titleWidth := key.width - 1 - max(lipgloss.Width(join), 2)
 
 	// Cut the title if it's too long
-	titleText := i.Title
-	widthAvail := r.width - 3 - len(prefix) - 1
-	if widthAvail > 0 && widthAvail < len(titleText) && len(titleText) >= widthAvail-3 {
-		titleText = titleText[:widthAvail-3] + "..."
+	titleText := key.title
+	widthAvail := titleWidth - len(prefix) - 1
+	if widthAvail > 0 {
+		titleText = truncate(titleText, widthAvail)
 	}
 	title := titleS.Render(lipgloss.JoinHorizontal(
 		lipgloss.Left,
-		lipgloss.Place(r.width-3, 1, lipgloss.Left, lipgloss.Center, fmt.Sprintf("%s %s", prefix, titleText)),
+		lipgloss.Place(titleWidth, 1, lipgloss.Left, lipgloss.Center, prefix+" "+titleText),
 		" ",
 		join,
 	))
 
-	stat := i.GetDiffStats()
-
 	var diff string
-	var addedDiff, removedDiff string
-	if stat == nil || stat.Error != nil || stat.IsEmpty() {
-		// Don't show diff stats if there's an error or if they don't exist
-		addedDiff = ""
-		removedDiff = ""
-		diff = ""
-	} else {
-		addedDiff = fmt.Sprintf("+%d", stat.Added)
-		removedDiff = fmt.Sprintf("-%d ", stat.Removed)
+	var badge, addedDiff, removedDiff string
+	if key.showDiff {
+		if key.sensitive {
+			badge = sensitiveBadge + " "
+		}
+		addedDiff = fmt.Sprintf("+%d", key.added)
+		removedDiff = fmt.Sprintf("-%d ", key.removed)
 		diff = lipgloss.JoinHorizontal(
 			lipgloss.Center,
-			addedLinesStyle.Background(descS.GetBackground()).Render(addedDiff),
-			lipgloss.Style{}.Background(descS.GetBackground()).Foreground(descS.GetForeground()).Render(","),
-			removedLinesStyle.Background(descS.GetBackground()).Render(removedDiff),
+			diffS.sensitive.Render(badge),
+			diffS.added.Render(addedDiff),
+			diffS.comma.Render(","),
+			diffS.removed.Render(removedDiff),
 		)
 	}
 
-	remainingWidth := r.width
+	remainingWidth := key.width
 	remainingWidth -= len(prefix)
 	remainingWidth -= len(branchIcon)
 
-	diffWidth := len(addedDiff) + len(removedDiff)
+	diffWidth := len(badge) + len(addedDiff) + len(removedDiff)
 	if diffWidth > 0 {
 		diffWidth += 1
 	}
@@ -186,112 +528,198 @@ func (r *InstanceRenderer) Render(i *session.Instance, idx int, selected bool, h
 	// Use fixed width for diff stats to avoid layout issues
 	remainingWidth -= diffWidth
 
-	branch := i.Branch
-	if i.Started() && hasMultipleRepos {
-		repoName, err := i.RepoName()
-		if err != nil {
-			log.ErrorLog.Printf("could not get repo name in instance renderer: %v", err)
-		} else {
-			branch += fmt.Sprintf(" (%s)", repoName)
-		}
-	}
+	branch := key.branch
 	// Don't show branch if there's no space for it. Or show ellipsis if it's too long.
-	if remainingWidth < 0 {
+	if remainingWidth < len(ellipsis) && runewidth.StringWidth(branch) > remainingWidth {
 		branch = ""
-	} else if remainingWidth < len(branch) {
-		if remainingWidth < 3 {
-			branch = ""
-		} else {
-			// We know the remainingWidth is at least 4 and branch is longer than that, so this is safe.
-			branch = branch[:remainingWidth-3] + "..."
-		}
+	} else {
+		branch = truncate(branch, remainingWidth)
 	}
-	remainingWidth -= len(branch)
-
 	// Add spaces to fill the remaining width.
-	spaces := ""
-	if remainingWidth > 0 {
-		spaces = strings.Repeat(" ", remainingWidth)
+	branch = padRight(branch, remainingWidth)
+
+	indent := strings.Repeat(" ", len(prefix))
+	branchLine := indent + " " + branchIcon + "-" + branch + diff
+
+	timeLine := indent + "  " + key.columns
+	if lipgloss.Width(timeLine) > key.width {
+		timeLine = ""
 	}
 
-	branchLine := fmt.Sprintf("%s %s-%s%s%s", strings.Repeat(" ", len(prefix)), branchIcon, branch, spaces, diff)
+	lines := []string{branchLine, timeLine}
+	// Show the first line of the agent's summary of its work under the selected instance.
+	if key.summary != "" {
+		lines = append(lines, indent+"  "+firstLine(key.summary, key.width-len(indent)-4))
+	}
+	// Show the state of the instance's database snapshot under the selected instance.
+	if key.database != "" {
+		lines = append(lines, indent+"  "+firstLine(key.database, key.width-len(indent)-4))
+	}
 
 	// join title and subtitle
 	text := lipgloss.JoinVertical(
 		lipgloss.Left,
 		title,
-		descS.Render(branchLine),
+		descS.Render(lipgloss.JoinVertical(lipgloss.Left, lines...)),
 	)
+	if key.accent != "" {
+		text = accentBar(text, key.accent)
+	}
 
 	return text
 }
 
+// accentBar draws a bar in color beside the lines of row, leaving out its top and bottom padding.
+func accentBar(row string, color lipgloss.Color) string {
+	bar := lipgloss.NewStyle().Foreground(color).Render(repoAccentBar)
+	lines := strings.Split(row, "\n")
+	for i, line := range lines {
+		if i == 0 || i == len(lines)-1 {
+			lines[i] = " " + line
+		} else {
+			lines[i] = bar + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// firstLine returns the first non-empty line of text, cut to width cells.
+func firstLine(text string, width int) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if width > len(ellipsis) {
+			return truncate(line, width)
+		}
+		return line
+	}
+	return ""
+}
+
+// renderColumns renders the configured time columns for an instance.
+func (r *InstanceRenderer) renderColumns(i *session.Instance) string {
+	columns := r.columns
+	if columns == nil {
+		columns = defaultColumns
+	}
+	if r.compact && len(columns) > 1 {
+		columns = columns[:1]
+	}
+
+	now := time.Now()
+	var parts []string
+	for _, column := range columns {
+		switch column {
+		case ColumnCreated:
+			parts = append(parts, "created "+formatTimestamp(i.CreatedAt, now, r.absoluteTimes))
+		case ColumnUpdated:
+			parts = append(parts, "updated "+formatTimestamp(i.UpdatedAt, now, r.absoluteTimes))
+		case ColumnUptime:
+			if uptime := i.Uptime(); uptime > 0 {
+				parts = append(parts, "up "+formatDuration(uptime))
+			}
+		case ColumnActivity:
+			parts = append(parts, "active "+formatTimestamp(i.LastActivity, now, r.absoluteTimes))
+		case ColumnHealth:
+			if problems := r.health[i.Title]; problems != "" {
+				parts = append(parts, "unhealthy: "+problems)
+			}
+		}
+	}
+	if !r.compact {
+		parts = append(parts, r.pluginColumns[i.Title]...)
+	}
+	return strings.Join(parts, " · ")
+}
+
 func (l *List) String() string {
 	const titleText = " Instances "
 	const autoYesText = " auto-yes "
 
 	// Write the title.
-	var b strings.Builder
-	b.WriteString("\n")
-	b.WriteString("\n")
+	var header strings.Builder
+	header.WriteString("\n")
+	header.WriteString("\n")
 
 	// Render repository tabs if there are multiple repos
 	if l.repoTabs.ShouldShowTabs() {
 		tabsContent := l.repoTabs.Render()
 		if tabsContent != "" {
-			b.WriteString(tabsContent)
-			b.WriteString("\n")
+			header.WriteString(tabsContent)
+			header.WriteString("\n")
 		}
 	}
 
 	// Write title line
 	// add padding of 2 because the border on list items adds some extra characters
 	titleWidth := AdjustPreviewWidth(l.width) + 2
-	if !l.autoyes {
-		b.WriteString(lipgloss.Place(
+	var badges []string
+	if l.sortMode != SortDefault {
+		badges = append(badges, autoYesStyle.Render(fmt.Sprintf(" sort: %s ", l.sortMode)))
+	}
+	if l.workspace != nil {
+		badges = append(badges, autoYesStyle.Render(fmt.Sprintf(" workspace: %s ", l.workspace.Name)))
+	}
+	if l.airGapped {
+		badges = append(badges, airGappedStyle.Render(" air-gapped "))
+	}
+	if l.queued > 0 {
+		badges = append(badges, offlineStyle.Render(fmt.Sprintf(" offline: %d queued ", l.queued)))
+	}
+	if l.autoyes {
+		badges = append(badges, autoYesStyle.Render(autoYesText))
+	}
+	if len(badges) == 0 {
+		header.WriteString(lipgloss.Place(
 			titleWidth, 1, lipgloss.Left, lipgloss.Bottom, mainTitle.Render(titleText)))
 	} else {
 		title := lipgloss.Place(
 			titleWidth/2, 1, lipgloss.Left, lipgloss.Bottom, mainTitle.Render(titleText))
-		autoYes := lipgloss.Place(
-			titleWidth-(titleWidth/2), 1, lipgloss.Right, lipgloss.Bottom, autoYesStyle.Render(autoYesText))
-		b.WriteString(lipgloss.JoinHorizontal(
-			lipgloss.Top, title, autoYes))
+		right := lipgloss.Place(
+			titleWidth-(titleWidth/2), 1, lipgloss.Right, lipgloss.Bottom, strings.Join(badges, " "))
+		header.WriteString(lipgloss.JoinHorizontal(
+			lipgloss.Top, title, right))
 	}
 
-	b.WriteString("\n")
-	b.WriteString("\n")
+	// The rows come padded to the list's width, so only the header and the blank lines are padded here.
+	blank := strings.Repeat(" ", l.width)
+	var b strings.Builder
+	b.Grow(l.lastLen)
+	b.WriteString(padLines(header.String(), l.width))
+	b.WriteString("\n" + blank + "\n")
 
 	// Get filtered instances based on selected repository
 	filteredItems := l.GetFilteredInstances()
 	
 	// Render the filtered list
+	l.renderer.forgetRows(l.items)
+	var selected *session.Instance
+	if l.selectedIdx < len(l.items) {
+		selected = l.items[l.selectedIdx]
+	}
 	for i, item := range filteredItems {
-		// Find the original index for selection highlighting
-		originalIdx := -1
-		for j, originalItem := range l.items {
-			if originalItem == item {
-				originalIdx = j
-				break
-			}
-		}
-		
-		isSelected := originalIdx == l.selectedIdx
-		b.WriteString(l.renderer.Render(item, i+1, isSelected, len(l.repos) > 1))
+		b.WriteString(l.renderer.Render(item, i+1, item == selected, len(l.repos) > 1))
 		if i != len(filteredItems)-1 {
-			b.WriteString("\n\n")
+			b.WriteString("\n" + blank + "\n")
 		}
 	}
 	
 	// Add empty lines at the end if we have space
 	if len(filteredItems) == 0 && l.repoTabs.ShouldShowTabs() {
-		b.WriteString("\n")
-		b.WriteString(lipgloss.NewStyle().
+		b.WriteString(blank + "\n")
+		b.WriteString(padLines(lipgloss.NewStyle().
 			Foreground(lipgloss.AdaptiveColor{Light: "#999999", Dark: "#666666"}).
-			Render("  No instances in this repository"))
+			Render("  No instances in this repository"), l.width))
 	}
-	
-	return lipgloss.Place(l.width, l.height, lipgloss.Left, lipgloss.Top, b.String())
+
+	// Fill the rest of the list's height.
+	for lines := strings.Count(b.String(), "\n") + 1; lines < l.height; lines++ {
+		b.WriteString("\n" + blank)
+	}
+	l.lastLen = b.Len()
+	return b.String()
 }
 
 // Down selects the next item in the list.
@@ -342,25 +770,57 @@ func (l *List) Down() {
 	}
 }
 
-// Kill selects the next item in the list.
-func (l *List) Kill() {
+// Kill kills the selected instance, removes it and selects the next item in the list. An instance that can't be
+// killed is kept in the list, in the Error status, and the error is returned.
+func (l *List) Kill() error {
+	return l.kill(false)
+}
+
+// ForceKill force-kills the selected instance and removes it from the list, even if killing it failed. The
+// error is returned to be reported.
+func (l *List) ForceKill() error {
+	return l.kill(true)
+}
+
+func (l *List) kill(force bool) error {
 	if len(l.items) == 0 {
-		return
+		return nil
 	}
 	targetInstance := l.items[l.selectedIdx]
 
 	// Kill the tmux session
-	if err := targetInstance.Kill(); err != nil {
-		log.ErrorLog.Printf("could not kill instance: %v", err)
+	kill := targetInstance.Kill
+	if force {
+		kill = targetInstance.ForceKill
 	}
+	killErr := kill()
+	if killErr != nil {
+		log.ErrorLog.Printf("could not kill instance: %v", killErr)
+		if !force {
+			return killErr
+		}
+	}
+
+	// Verifying the kill takes a while, during which the selection may have moved.
+	l.RemoveInstance(targetInstance)
+	return killErr
+}
 
-	// If you delete the last one in the list, select the previous one.
-	if l.selectedIdx == len(l.items)-1 {
+// RemoveInstance removes instance from the list without killing it, e.g. once another process killed it.
+func (l *List) RemoveInstance(instance *session.Instance) {
+	idx := slices.Index(l.items, instance)
+	if idx < 0 {
+		return
+	}
+	if idx < l.selectedIdx {
+		l.selectedIdx--
+	} else if idx == l.selectedIdx && l.selectedIdx == len(l.items)-1 {
+		// If you delete the last one in the list, select the previous one.
 		defer l.Up()
 	}
 
 	// Unregister the repository path.
-	gitWorktree, err := targetInstance.GetGitWorktree()
+	gitWorktree, err := instance.GetGitWorktree()
 	if err != nil {
 		log.ErrorLog.Printf("could not get git worktree: %v", err)
 	} else if gitWorktree != nil {
@@ -368,7 +828,18 @@ func (l *List) Kill() {
 	}
 
 	// Since there's items after this, the selectedIdx can stay the same.
-	l.items = append(l.items[:l.selectedIdx], l.items[l.selectedIdx+1:]...)
+	l.items = append(l.items[:idx], l.items[idx+1:]...)
+}
+
+// ReplaceInstance puts replacement in the place of instance in the list, e.g. once it's restored again from
+// storage after another process changed it. It's added if instance isn't in the list.
+func (l *List) ReplaceInstance(instance, replacement *session.Instance) {
+	idx := slices.Index(l.items, instance)
+	if idx < 0 {
+		l.AddInstance(replacement)()
+		return
+	}
+	l.items[idx] = replacement
 }
 
 func (l *List) Attach() (chan struct{}, error) {
@@ -450,8 +921,10 @@ func (l *List) rmRepo(repo string) {
 	l.repos[repo]--
 	if l.repos[repo] == 0 {
 		delete(l.repos, repo)
-		// Remove from repository tabs
-		l.repoTabs.RemoveRepo(repo)
+		// Remove from repository tabs, unless the repository is registered
+		if !l.repoTabs.IsRegistered(repo) {
+			l.repoTabs.RemoveRepo(repo)
+		}
 		
 		// Ensure valid selection after removing repo
 		l.EnsureValidSelection()
@@ -479,12 +952,17 @@ func (l *List) AddInstance(instance *session.Instance) (finalize func()) {
 	}
 }
 
-// GetSelectedInstance returns the currently selected instance
+// GetSelectedInstance returns the currently selected instance. It's nil if the selected repository tab has
+// no instances.
 func (l *List) GetSelectedInstance() *session.Instance {
 	if len(l.items) == 0 {
 		return nil
 	}
-	return l.items[l.selectedIdx]
+	selected := l.items[l.selectedIdx]
+	if !l.inSelectedRepo(selected) {
+		return nil
+	}
+	return selected
 }
 
 // SetSelectedInstance sets the selected index. Noop if the index is out of bounds.
@@ -507,29 +985,35 @@ func (l *List) GetRepoTabs() *RepoTabs {
 
 // GetFilteredInstances returns instances filtered by the currently selected repository
 func (l *List) GetFilteredInstances() []*session.Instance {
-	if !l.repoTabs.ShouldShowTabs() {
-		return l.items
+	return l.sorted(l.filterByRepo())
+}
+
+// sorted returns the instances ordered by the list's sort mode.
+func (l *List) sorted(instances []*session.Instance) []*session.Instance {
+	switch l.sortMode {
+	case SortUptime:
+		instances = slices.Clone(instances)
+		slices.SortStableFunc(instances, func(a, b *session.Instance) int {
+			return cmp.Compare(b.Uptime(), a.Uptime())
+		})
+	case SortActivity:
+		instances = slices.Clone(instances)
+		slices.SortStableFunc(instances, func(a, b *session.Instance) int {
+			return b.LastActivity.Compare(a.LastActivity)
+		})
 	}
-	
-	selectedRepo := l.repoTabs.GetSelectedRepo()
-	if selectedRepo == "" {
+	return instances
+}
+
+// filterByRepo returns the instances in the selected repository tab.
+func (l *List) filterByRepo() []*session.Instance {
+	if l.workspace == nil && (!l.repoTabs.ShouldShowTabs() || l.repoTabs.GetSelectedRepo() == "") {
 		return l.items
 	}
 	
 	var filtered []*session.Instance
 	for _, instance := range l.items {
-		if instance.Started() {
-			// Get the git worktree to access the full repository path
-			gitWorktree, err := instance.GetGitWorktree()
-			if err != nil {
-				log.ErrorLog.Printf("could not get git worktree for filtering: %v", err)
-				continue
-			}
-			if gitWorktree != nil && gitWorktree.GetRepoPath() == selectedRepo {
-				filtered = append(filtered, instance)
-			}
-		} else {
-			// Include non-started instances as they don't have a repository yet
+		if l.inSelectedRepo(instance) {
 			filtered = append(filtered, instance)
 		}
 	}
@@ -537,6 +1021,31 @@ func (l *List) GetFilteredInstances() []*session.Instance {
 	return filtered
 }
 
+// inSelectedRepo returns true if instance is shown in the selected repository tab and workspace.
+func (l *List) inSelectedRepo(instance *session.Instance) bool {
+	selectedRepo := l.repoTabs.GetSelectedRepo()
+	if !l.repoTabs.ShouldShowTabs() {
+		selectedRepo = ""
+	}
+	if l.workspace == nil && selectedRepo == "" {
+		return true
+	}
+	// Include non-started instances as they don't have a repository yet
+	if !instance.Started() {
+		return true
+	}
+	// Get the git worktree to access the full repository path
+	gitWorktree, err := instance.GetGitWorktree()
+	if err != nil {
+		log.ErrorLog.Printf("could not get git worktree for filtering: %v", err)
+		return false
+	}
+	if gitWorktree == nil || (l.workspace != nil && !l.workspace.Contains(gitWorktree.GetRepoPath())) {
+		return false
+	}
+	return selectedRepo == "" || gitWorktree.GetRepoPath() == selectedRepo
+}
+
 // EnsureValidSelection ensures the current selection is visible in the filtered view
 func (l *List) EnsureValidSelection() {
 	filteredItems := l.GetFilteredInstances()
@@ -567,6 +1076,13 @@ func (l *List) EnsureValidSelection() {
 	}
 }
 
+// SelectRepo switches to the repository tab of repoPath, selecting an instance in it if the selected one
+// isn't.
+func (l *List) SelectRepo(repoPath string) {
+	l.repoTabs.SelectRepo(repoPath)
+	l.EnsureValidSelection()
+}
+
 // NextRepo switches to the next repository tab
 func (l *List) NextRepo() {
 	if l.repoTabs.ShouldShowTabs() {
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"claude-squad/session"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderReusesUnchangedRows(t *testing.T) {
+	s := spinner.New()
+	r := &InstanceRenderer{spinner: &s}
+	r.setWidth(40)
+	instance := &session.Instance{Title: "fix-login", Branch: "fix-login", Status: session.Ready}
+
+	first := r.Render(instance, 1, false, false)
+	require.Equal(t, first, r.Render(instance, 1, false, false))
+	for _, line := range strings.Split(first, "\n") {
+		require.Equal(t, 40, lipgloss.Width(line), "rows are padded to the list's width")
+	}
+
+	instance.Muted = true
+	require.Contains(t, r.Render(instance, 1, false, false), "fix-login (muted)")
+
+	// Running rows follow the spinner.
+	instance.Status = session.Running
+	running := r.Render(instance, 1, false, false)
+	s, _ = s.Update(s.Tick())
+	require.NotEqual(t, running, r.Render(instance, 1, false, false))
+
+	r.forgetRows(nil)
+	require.Empty(t, r.rows)
+}
+
+func TestRepoAccent(t *testing.T) {
+	defer SetRepoColors(nil)
+
+	require.Equal(t, RepoAccent("/src/app"), RepoAccent("/src/app"), "a repository keeps its color")
+	require.Contains(t, repoAccents, RepoAccent("/src/site"))
+	SetRepoColors(map[string]string{"/src/app": "#ff8800"})
+	require.Equal(t, lipgloss.Color("#ff8800"), RepoAccent("/src/app"))
+
+	// The accent bar is only drawn beside rows when instances of several repositories are listed.
+	s := spinner.New()
+	r := &InstanceRenderer{spinner: &s}
+	r.setWidth(40)
+	instance := &session.Instance{Title: "fix-login", Branch: "fix-login", RepositoryPath: "/src/app"}
+	require.NotContains(t, r.Render(instance, 1, false, false), repoAccentBar)
+	row := r.Render(instance, 1, false, true)
+	require.Contains(t, row, repoAccentBar)
+	for _, line := range strings.Split(row, "\n") {
+		require.Equal(t, 40, lipgloss.Width(line), "rows are padded to the list's width")
+	}
+}
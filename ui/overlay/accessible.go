@@ -0,0 +1,37 @@
+package overlay
+
+import "github.com/charmbracelet/lipgloss"
+
+// accessible draws overlays with ASCII characters and without a shadow. It's set by SetAccessible.
+var accessible bool
+
+// ASCIIBorder is a border drawn with plain ASCII characters.
+var ASCIIBorder = lipgloss.Border{
+	Top:          "-",
+	Bottom:       "-",
+	Left:         "|",
+	Right:        "|",
+	TopLeft:      "+",
+	TopRight:     "+",
+	BottomLeft:   "+",
+	BottomRight:  "+",
+	MiddleLeft:   "+",
+	MiddleRight:  "+",
+	Middle:       "+",
+	MiddleTop:    "+",
+	MiddleBottom: "+",
+}
+
+// SetAccessible draws overlays with ASCII characters and without a shadow, for screen readers and
+// terminals that can't display box-drawing characters.
+func SetAccessible() {
+	accessible = true
+}
+
+// boxBorder returns the border overlays are drawn with.
+func boxBorder() lipgloss.Border {
+	if accessible {
+		return ASCIIBorder
+	}
+	return lipgloss.RoundedBorder()
+}
@@ -99,8 +99,8 @@ func PlaceOverlay(
 		placeX, placeY = CalculateCenterCoordinates(fgLines, bgLines, fgWidth, bgWidth)
 	}
 
-	// Handle shadow if enabled
-	if shadow {
+	// Handle shadow if enabled. Accessible mode has no shadow, since it's drawn with a block character.
+	if shadow && !accessible {
 		// Define shadow style and character
 		shadowStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#333333"))
 		shadowChar := shadowStyle.Render("░")
@@ -0,0 +1,106 @@
+package overlay
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// SelectionItem is one of the choices in a SelectionOverlay.
+type SelectionItem struct {
+	Label       string
+	Description string
+}
+
+// SelectionOverlay lets the user pick one item from a list.
+type SelectionOverlay struct {
+	Title string
+	items []SelectionItem
+	// cursor is the index of the highlighted item
+	cursor int
+	// selected is the index of the chosen item, or -1 if nothing was chosen
+	selected int
+	width    int
+}
+
+var (
+	selectionTitleStyle  = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#7D56F4"))
+	selectionItemStyle   = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#1a1a1a", Dark: "#dddddd"})
+	selectionCursorStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#FFCC00"))
+	selectionDescStyle   = lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#A49FA5", Dark: "#777777"})
+)
+
+// NewSelectionOverlay creates a new selection overlay with the given title and items.
+func NewSelectionOverlay(title string, items []SelectionItem) *SelectionOverlay {
+	return &SelectionOverlay{
+		Title:    title,
+		items:    items,
+		selected: -1,
+		width:    60,
+	}
+}
+
+// HandleKeyPress processes a key press and updates the state.
+// Returns true if the overlay should be closed.
+func (s *SelectionOverlay) HandleKeyPress(msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "up", "k":
+		if s.cursor > 0 {
+			s.cursor--
+		}
+	case "down", "j":
+		if s.cursor < len(s.items)-1 {
+			s.cursor++
+		}
+	case "enter":
+		if len(s.items) > 0 {
+			s.selected = s.cursor
+		}
+		return true
+	case "esc", "q", "ctrl+c":
+		return true
+	}
+	return false
+}
+
+// Selected returns the index of the chosen item, or -1 if the overlay was canceled.
+func (s *SelectionOverlay) Selected() int {
+	return s.selected
+}
+
+// Render renders the selection overlay.
+func (s *SelectionOverlay) Render(opts ...WhitespaceOption) string {
+	style := lipgloss.NewStyle().
+		Border(boxBorder()).
+		BorderForeground(lipgloss.Color("62")).
+		Padding(1, 2).
+		Width(s.width)
+
+	var b strings.Builder
+	b.WriteString(selectionTitleStyle.Render(s.Title))
+	b.WriteString("\n\n")
+	for i, item := range s.items {
+		if i == s.cursor {
+			b.WriteString(selectionCursorStyle.Render("> " + item.Label))
+		} else {
+			b.WriteString(selectionItemStyle.Render("  " + item.Label))
+		}
+		if item.Description != "" {
+			b.WriteString(selectionDescStyle.Render("  " + item.Description))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	hint := "↑/↓ to move, enter to select, esc to cancel"
+	if accessible {
+		hint = "up/down to move, enter to select, esc to cancel"
+	}
+	b.WriteString(selectionDescStyle.Render(hint))
+	return style.Render(b.String())
+}
+
+// SetWidth sets the width of the selection overlay.
+func (s *SelectionOverlay) SetWidth(width int) {
+	s.width = width
+}
@@ -123,7 +123,7 @@ func (t *TextInputOverlay) SetOnSubmit(onSubmit func()) {
 func (t *TextInputOverlay) Render() string {
 	// Create styles
 	style := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
+		Border(boxBorder()).
 		BorderForeground(lipgloss.Color("62")).
 		Padding(1, 2)
 
@@ -41,7 +41,7 @@ func (t *TextOverlay) HandleKeyPress(msg tea.KeyMsg) bool {
 func (t *TextOverlay) Render(opts ...WhitespaceOption) string {
 	// Create styles
 	style := lipgloss.NewStyle().
-		Border(lipgloss.RoundedBorder()).
+		Border(boxBorder()).
 		BorderForeground(lipgloss.Color("62")).
 		Padding(1, 2).
 		Width(t.width)
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"claude-squad/keys"
 	"claude-squad/session"
 	"fmt"
 	"strings"
@@ -46,11 +47,11 @@ func (p *PreviewPane) setFallbackState(message string) {
 func (p *PreviewPane) UpdateContent(instance *session.Instance) error {
 	switch {
 	case instance == nil:
-		p.setFallbackState("No agents running yet. Spin up a new instance with 'n' to get started!")
+		p.setFallbackState(fmt.Sprintf("No agents running yet. Spin up a new instance with '%s' to get started!", keys.Help(keys.KeyNew)))
 		return nil
 	case instance.Status == session.Paused:
 		p.setFallbackState(lipgloss.JoinVertical(lipgloss.Center,
-			"Session is paused. Press 'r' to resume.",
+			fmt.Sprintf("Session is paused. Press '%s' to resume.", keys.Help(keys.KeyResume)),
 			"",
 			lipgloss.NewStyle().
 				Foreground(lipgloss.AdaptiveColor{
@@ -63,6 +64,21 @@ func (p *PreviewPane) UpdateContent(instance *session.Instance) error {
 				)),
 		))
 		return nil
+	case instance.Status == session.Error:
+		p.setFallbackState(lipgloss.JoinVertical(lipgloss.Center,
+			"Killing this session failed: its programs or worktree may still be around.",
+			"",
+			fmt.Sprintf("Press '%s' to force-kill it.", keys.Help(keys.KeyKill)),
+		))
+		return nil
+	case instance.Status == session.Stopped:
+		p.setFallbackState(lipgloss.JoinVertical(lipgloss.Center,
+			fmt.Sprintf("Session is stopped. Press '%s' to resume.", keys.Help(keys.KeyResume)),
+			"",
+			fmt.Sprintf("Its work is kept on branch '%s'. Press '%s' to purge the branch and worktree.",
+				instance.Branch, keys.Help(keys.KeyPurge)),
+		))
+		return nil
 	}
 
 	content, err := instance.Preview()
@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"hash/fnv"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// repoAccents is the palette repositories' accent colors are picked from when none is chosen in their
+// settings. The colors read on both light and dark backgrounds.
+var repoAccents = []lipgloss.Color{
+	"#7D56F4", // violet
+	"#2E9CCA", // blue
+	"#3BAA6E", // green
+	"#D9822B", // orange
+	"#C9456B", // rose
+	"#1F9E9E", // teal
+	"#B5A000", // olive
+	"#9C5BC7", // purple
+}
+
+// repoColors are the accent colors chosen in repositories' settings, keyed by repository path.
+var repoColors map[string]string
+
+// SetRepoColors sets the accent colors chosen in repositories' settings, keyed by repository path.
+func SetRepoColors(colors map[string]string) {
+	repoColors = colors
+}
+
+// RepoAccent returns the accent color of the repository at repoPath: the one chosen in its settings, or else
+// one picked from the palette by hashing the path, so a repository keeps its color across sessions.
+func RepoAccent(repoPath string) lipgloss.Color {
+	if color := repoColors[repoPath]; color != "" {
+		return lipgloss.Color(color)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(repoPath))
+	return repoAccents[h.Sum32()%uint32(len(repoAccents))]
+}
@@ -0,0 +1,22 @@
+package ui
+
+import "path/filepath"
+
+// repoAliases are the names repositories are shown by instead of their directory names, keyed by repository
+// path.
+var repoAliases map[string]string
+
+// SetRepoAliases sets the names repositories are shown by instead of their directory names, keyed by
+// repository path.
+func SetRepoAliases(aliases map[string]string) {
+	repoAliases = aliases
+}
+
+// RepoDisplayName returns the name the repository at repoPath is shown by: its alias, or else its directory
+// name.
+func RepoDisplayName(repoPath string) string {
+	if alias := repoAliases[repoPath]; alias != "" {
+		return alias
+	}
+	return filepath.Base(repoPath)
+}
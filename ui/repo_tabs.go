@@ -1,7 +1,7 @@
 package ui
 
 import (
-	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
@@ -13,6 +13,14 @@ type RepoTabs struct {
 	repoNames   []string // List of repository display names
 	selectedIdx int      // Currently selected repository index
 	width       int      // Available width for tabs
+	// registered are the repositories registered in the state, whose tabs are kept when they have no instances
+	registered map[string]bool
+	// workspace are the repositories of the selected workspace, the only ones with tabs. Nil shows them all.
+	workspace map[string]bool
+	// hidden are the repositories left out of the selected workspace, whose tabs come back when it's changed.
+	hidden []string
+	// pinned are the pinned repositories, whose tabs come first
+	pinned map[string]bool
 }
 
 // Tab styling - consistent with main title styling in list.go
@@ -36,6 +44,9 @@ var (
 					Padding(0, 0, 0, 0)
 )
 
+// pinnedTabMarker is shown before the names of pinned tabs.
+const pinnedTabMarker = "★ "
+
 // NewRepoTabs creates a new repository tabs component
 func NewRepoTabs() *RepoTabs {
 	return &RepoTabs{
@@ -43,6 +54,8 @@ func NewRepoTabs() *RepoTabs {
 		repoNames:   []string{},
 		selectedIdx: 0,
 		width:       0,
+		registered:  make(map[string]bool),
+		pinned:      make(map[string]bool),
 	}
 }
 
@@ -59,13 +72,73 @@ func (rt *RepoTabs) AddRepo(repoPath string) {
 			return
 		}
 	}
+	if rt.workspace != nil && !rt.workspace[repoPath] {
+		if !slices.Contains(rt.hidden, repoPath) {
+			rt.hidden = append(rt.hidden, repoPath)
+		}
+		return
+	}
 	
 	rt.repos = append(rt.repos, repoPath)
 	rt.repoNames = append(rt.repoNames, rt.getRepoDisplayName(repoPath))
+	rt.sortPinned()
+}
+
+// SetPinned pins or unpins the tab of a repository. Pinned tabs come first.
+func (rt *RepoTabs) SetPinned(repoPath string, pinned bool) {
+	if pinned {
+		rt.pinned[repoPath] = true
+	} else {
+		delete(rt.pinned, repoPath)
+	}
+	rt.sortPinned()
+}
+
+// IsPinned returns true if the repository's tab is pinned
+func (rt *RepoTabs) IsPinned(repoPath string) bool {
+	return rt.pinned[repoPath]
+}
+
+// sortPinned moves the pinned tabs first, keeping the order of the tabs otherwise, and the selected tab.
+func (rt *RepoTabs) sortPinned() {
+	selected := rt.GetSelectedRepo()
+	order := make([]int, len(rt.repos))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		switch pa, pb := rt.pinned[rt.repos[a]], rt.pinned[rt.repos[b]]; {
+		case pa && !pb:
+			return -1
+		case !pa && pb:
+			return 1
+		}
+		return 0
+	})
+	repos := make([]string, len(order))
+	names := make([]string, len(order))
+	for i, j := range order {
+		repos[i], names[i] = rt.repos[j], rt.repoNames[j]
+	}
+	rt.repos, rt.repoNames = repos, names
+	rt.SelectRepo(selected)
+}
+
+// RegisterRepo adds a tab for a repository registered in the state, which is kept even when the repository
+// has no instances, so new instances can be created there.
+func (rt *RepoTabs) RegisterRepo(repoPath string) {
+	rt.registered[repoPath] = true
+	rt.AddRepo(repoPath)
+}
+
+// IsRegistered returns true if the repository is registered in the state
+func (rt *RepoTabs) IsRegistered(repoPath string) bool {
+	return rt.registered[repoPath]
 }
 
 // RemoveRepo removes a repository from the tabs
 func (rt *RepoTabs) RemoveRepo(repoPath string) {
+	rt.hidden = slices.DeleteFunc(rt.hidden, func(hidden string) bool { return hidden == repoPath })
 	for i, repo := range rt.repos {
 		if repo == repoPath {
 			rt.repos = append(rt.repos[:i], rt.repos[i+1:]...)
@@ -82,6 +155,26 @@ func (rt *RepoTabs) RemoveRepo(repoPath string) {
 	}
 }
 
+// SetWorkspace shows only the tabs of repos, the repositories of the selected workspace, or all tabs if
+// repos is nil. The selected tab is kept if it's still shown.
+func (rt *RepoTabs) SetWorkspace(repos []string) {
+	selected := rt.GetSelectedRepo()
+	all := append(slices.Clone(rt.repos), rt.hidden...)
+	rt.workspace = nil
+	if repos != nil {
+		rt.workspace = make(map[string]bool, len(repos))
+		for _, repo := range repos {
+			rt.workspace[repo] = true
+		}
+	}
+	rt.repos, rt.repoNames, rt.hidden = nil, nil, nil
+	rt.selectedIdx = 0
+	for _, repo := range all {
+		rt.AddRepo(repo)
+	}
+	rt.SelectRepo(selected)
+}
+
 // GetSelectedRepo returns the currently selected repository path
 func (rt *RepoTabs) GetSelectedRepo() string {
 	if rt.selectedIdx >= 0 && rt.selectedIdx < len(rt.repos) {
@@ -138,8 +231,8 @@ func (rt *RepoTabs) getRepoDisplayName(repoPath string) string {
 		return "unknown"
 	}
 	
-	// Use the last directory name as the display name
-	return filepath.Base(repoPath)
+	// Use the alias, or else the last directory name, as the display name
+	return RepoDisplayName(repoPath)
 }
 
 // Render renders the repository tabs
@@ -172,19 +265,19 @@ func (rt *RepoTabs) Render() string {
 	for i, repoName := range rt.repoNames {
 		// Truncate repo name if it's too long
 		displayName := repoName
+		if rt.pinned[rt.repos[i]] {
+			displayName = pinnedTabMarker + displayName
+		}
 		maxNameLength := maxTabWidth - 4 // Account for padding
-		if maxNameLength > 0 && len(displayName) > maxNameLength {
-			if maxNameLength <= 3 {
-				displayName = displayName[:maxNameLength] // Show as much as possible
-			} else {
-				displayName = displayName[:maxNameLength-3] + "..."
-			}
+		if maxNameLength > 0 {
+			displayName = truncate(displayName, maxNameLength)
 		}
 
+		accent := RepoAccent(rt.repos[i])
 		if i == rt.selectedIdx {
-			tabs = append(tabs, repoActiveTabStyle.Render(displayName))
+			tabs = append(tabs, repoActiveTabStyle.Background(accent).Render(displayName))
 		} else {
-			tabs = append(tabs, repoInactiveTabStyle.Render(displayName))
+			tabs = append(tabs, repoInactiveTabStyle.Foreground(accent).Render(displayName))
 		}
 	}
 
@@ -212,6 +305,7 @@ func (rt *RepoTabs) SetRepos(repos []string) {
 	for i, repo := range repos {
 		rt.repoNames[i] = rt.getRepoDisplayName(repo)
 	}
+	rt.sortPinned()
 	
 	// Reset selection to first repo
 	if len(rt.repos) > 0 {
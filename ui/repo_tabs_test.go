@@ -124,4 +124,87 @@ func TestRepoTabs_Rendering(t *testing.T) {
 	if len(rendered) == 0 {
 		t.Error("Expected rendered content to have some length")
 	}
-}
\ No newline at end of file
+}
+func TestRepoTabs_RegisterRepo(t *testing.T) {
+	tabs := NewRepoTabs()
+	tabs.RegisterRepo("/path/to/repo1")
+	tabs.AddRepo("/path/to/repo2")
+	tabs.RegisterRepo("/path/to/repo2")
+
+	if tabs.NumRepos() != 2 {
+		t.Errorf("Expected 2 repos, got %d", tabs.NumRepos())
+	}
+	if !tabs.IsRegistered("/path/to/repo1") || !tabs.IsRegistered("/path/to/repo2") {
+		t.Error("Expected both repos to be registered")
+	}
+	if tabs.IsRegistered("/path/to/repo3") {
+		t.Error("Expected an unknown repo not to be registered")
+	}
+}
+
+func TestRepoTabs_SetWorkspace(t *testing.T) {
+	tabs := NewRepoTabs()
+	tabs.AddRepo("/path/to/repo1")
+	tabs.AddRepo("/path/to/repo2")
+	tabs.AddRepo("/path/to/repo3")
+	tabs.SelectRepo("/path/to/repo3")
+
+	tabs.SetWorkspace([]string{"/path/to/repo1", "/path/to/repo3"})
+	if tabs.NumRepos() != 2 {
+		t.Errorf("Expected 2 repos in the workspace, got %d", tabs.NumRepos())
+	}
+	if tabs.GetSelectedRepo() != "/path/to/repo3" {
+		t.Errorf("Expected the selected repo to be kept, got '%s'", tabs.GetSelectedRepo())
+	}
+
+	// Repositories added outside of the workspace get their tabs once it's left.
+	tabs.AddRepo("/path/to/repo4")
+	if tabs.NumRepos() != 2 {
+		t.Errorf("Expected 2 repos in the workspace, got %d", tabs.NumRepos())
+	}
+	tabs.RemoveRepo("/path/to/repo2")
+	tabs.SetWorkspace(nil)
+	want := []string{"/path/to/repo1", "/path/to/repo3", "/path/to/repo4"}
+	if got := tabs.GetAllRepos(); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("Expected repos %v, got %v", want, got)
+	}
+}
+
+func TestRepoTabs_Aliases(t *testing.T) {
+	SetRepoAliases(map[string]string{"/src/shop/api": "shop-api"})
+	defer SetRepoAliases(nil)
+	tabs := NewRepoTabs()
+	tabs.AddRepo("/src/shop/api")
+	tabs.AddRepo("/src/blog/api")
+
+	if tabs.GetSelectedRepoName() != "shop-api" {
+		t.Errorf("Expected the aliased repo to be named 'shop-api', got '%s'", tabs.GetSelectedRepoName())
+	}
+	tabs.NextRepo()
+	if tabs.GetSelectedRepoName() != "api" {
+		t.Errorf("Expected the other repo to be named 'api', got '%s'", tabs.GetSelectedRepoName())
+	}
+}
+
+func TestRepoTabs_Pinned(t *testing.T) {
+	tabs := NewRepoTabs()
+	tabs.AddRepo("/path/to/repo1")
+	tabs.AddRepo("/path/to/repo2")
+	tabs.SetPinned("/path/to/repo3", true)
+	tabs.AddRepo("/path/to/repo3")
+	tabs.SelectRepo("/path/to/repo2")
+
+	tabs.SetPinned("/path/to/repo2", true)
+	want := []string{"/path/to/repo3", "/path/to/repo2", "/path/to/repo1"}
+	if got := tabs.GetAllRepos(); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("Expected pinned repos first, %v, got %v", want, got)
+	}
+	if tabs.GetSelectedRepo() != "/path/to/repo2" {
+		t.Errorf("Expected the selected repo to be kept, got '%s'", tabs.GetSelectedRepo())
+	}
+
+	tabs.SetPinned("/path/to/repo2", false)
+	if tabs.IsPinned("/path/to/repo2") || tabs.GetAllRepos()[0] != "/path/to/repo3" {
+		t.Errorf("Expected repo2 to be unpinned, got %v", tabs.GetAllRepos())
+	}
+}
@@ -6,8 +6,7 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-func tabBorderWithBottom(left, middle, right string) lipgloss.Border {
-	border := lipgloss.RoundedBorder()
+func tabBorderWithBottom(border lipgloss.Border, left, middle, right string) lipgloss.Border {
 	border.BottomLeft = left
 	border.Bottom = middle
 	border.BottomRight = right
@@ -15,8 +14,8 @@ func tabBorderWithBottom(left, middle, right string) lipgloss.Border {
 }
 
 var (
-	inactiveTabBorder = tabBorderWithBottom("┴", "─", "┴")
-	activeTabBorder   = tabBorderWithBottom("┘", " ", "└")
+	inactiveTabBorder = tabBorderWithBottom(lipgloss.RoundedBorder(), "┴", "─", "┴")
+	activeTabBorder   = tabBorderWithBottom(lipgloss.RoundedBorder(), "┘", " ", "└")
 	highlightColor    = lipgloss.AdaptiveColor{Light: "#874BFD", Dark: "#7D56F4"}
 	inactiveTabStyle  = lipgloss.NewStyle().
 				Border(inactiveTabBorder, true).
@@ -30,6 +29,9 @@ var (
 			Border(lipgloss.NormalBorder(), false, true, true, true)
 )
 
+// The bottom corners of the outermost tabs, which join the window's border.
+var tabEdge, tabJoinLeft, tabJoinRight = "│", "├", "┤"
+
 const (
 	PreviewTab = iota
 	DiffTab
@@ -53,6 +55,10 @@ type TabbedWindow struct {
 	preview  *PreviewPane
 	diff     *DiffPane
 	terminal *TerminalPane
+
+	// repoPath is the repository whose accent color the borders are drawn in. The default highlight color
+	// is used when it's empty.
+	repoPath string
 }
 
 func NewTabbedWindow(preview *PreviewPane, diff *DiffPane) *TabbedWindow {
@@ -143,6 +149,11 @@ func (w *TabbedWindow) IsInTerminalTab() bool {
 	return w.activeTab == TerminalTab
 }
 
+// SetRepo sets the repository whose accent color the borders are drawn in, "" for the default color.
+func (w *TabbedWindow) SetRepo(repoPath string) {
+	w.repoPath = repoPath
+}
+
 func (w *TabbedWindow) String() string {
 	if w.width == 0 || w.height == 0 {
 		return ""
@@ -153,6 +164,10 @@ func (w *TabbedWindow) String() string {
 	tabWidth := w.width / len(w.tabs)
 	lastTabWidth := w.width - tabWidth*(len(w.tabs)-1)
 	tabHeight := activeTabStyle.GetVerticalFrameSize() + 1 // get padding border margin size + 1 for character height
+	var borderColor lipgloss.TerminalColor = highlightColor
+	if w.repoPath != "" {
+		borderColor = RepoAccent(w.repoPath)
+	}
 
 	for i, t := range w.tabs {
 		width := tabWidth
@@ -169,15 +184,15 @@ func (w *TabbedWindow) String() string {
 		}
 		border, _, _, _, _ := style.GetBorder()
 		if isFirst && isActive {
-			border.BottomLeft = "│"
+			border.BottomLeft = tabEdge
 		} else if isFirst && !isActive {
-			border.BottomLeft = "├"
+			border.BottomLeft = tabJoinLeft
 		} else if isLast && isActive {
-			border.BottomRight = "│"
+			border.BottomRight = tabEdge
 		} else if isLast && !isActive {
-			border.BottomRight = "┤"
+			border.BottomRight = tabJoinRight
 		}
-		style = style.Border(border)
+		style = style.Border(border).BorderForeground(borderColor)
 		style = style.Width(width - 1)
 		renderedTabs = append(renderedTabs, style.Render(t))
 	}
@@ -194,7 +209,7 @@ func (w *TabbedWindow) String() string {
 	default:
 		content = w.preview.String()
 	}
-	window := windowStyle.Render(
+	window := windowStyle.BorderForeground(borderColor).Render(
 		lipgloss.Place(
 			w.width, w.height-2-windowStyle.GetVerticalFrameSize()-tabHeight,
 			lipgloss.Left, lipgloss.Top, content))
@@ -1,7 +1,9 @@
 package ui
 
 import (
+	"claude-squad/keys"
 	"claude-squad/session"
+	"fmt"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
@@ -45,10 +47,14 @@ func (t *TerminalPane) setFallbackState(message string) {
 func (t *TerminalPane) UpdateContent(instance *session.Instance) error {
 	switch {
 	case instance == nil:
-		t.setFallbackState("No agents running yet. Spin up a new instance with 'n' to get started!")
+		t.setFallbackState(fmt.Sprintf("No agents running yet. Spin up a new instance with '%s' to get started!", keys.Help(keys.KeyNew)))
 		return nil
 	case instance.Status == session.Paused:
-		t.setFallbackState("Session is paused. Press 'r' to resume.")
+		t.setFallbackState(fmt.Sprintf("Session is paused. Press '%s' to resume.", keys.Help(keys.KeyResume)))
+		return nil
+	case instance.Status == session.Stopped:
+		t.setFallbackState(fmt.Sprintf("Session is stopped. Press '%s' to resume or '%s' to purge its branch and worktree.",
+			keys.Help(keys.KeyResume), keys.Help(keys.KeyPurge)))
 		return nil
 	}
 
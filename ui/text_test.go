@@ -0,0 +1,24 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncate(t *testing.T) {
+	require.Equal(t, "short", truncate("short", 10))
+	require.Equal(t, "featu...", truncate("feature-branch", 8))
+	// Wide characters take two cells and aren't split.
+	require.Equal(t, "日本...", truncate("日本語のタイトル", 8))
+	require.Equal(t, "🚀...", truncate("🚀🚀🚀🚀", 6))
+	require.Equal(t, "日", truncate("日本語", 3))
+	require.Equal(t, "", truncate("title", 0))
+}
+
+func TestPadRight(t *testing.T) {
+	require.Equal(t, "日本  ", padRight("日本", 6))
+	require.Equal(t, 6, runewidth.StringWidth(padRight(truncate("日本語のタイトル", 6), 6)))
+	require.Equal(t, "title", padRight("title", 3))
+}
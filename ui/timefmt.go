@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+)
+
+// formatTimestamp renders t relative to now (e.g. "12m ago"), or as an absolute time in the local
+// timezone if absolute is set.
+func formatTimestamp(t, now time.Time, absolute bool) string {
+	if t.IsZero() {
+		return "never"
+	}
+	if absolute {
+		local := t.Local()
+		if local.Year() != now.Local().Year() {
+			return local.Format("Jan 2 2006 15:04")
+		}
+		return local.Format("Jan 2 15:04")
+	}
+
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	default:
+		return formatTimestamp(t, now, true)
+	}
+}
+
+// formatDuration renders d with its two most significant units, e.g. "3h 12m".
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd %dh", int(d.Hours()/24), int(d.Hours())%24)
+	}
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatTimestamp(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		t        time.Time
+		absolute bool
+		expected string
+	}{
+		{"zero", time.Time{}, false, "never"},
+		{"seconds", now.Add(-30 * time.Second), false, "just now"},
+		{"minutes", now.Add(-12 * time.Minute), false, "12m ago"},
+		{"hours", now.Add(-3*time.Hour - 20*time.Minute), false, "3h ago"},
+		{"days", now.Add(-50 * time.Hour), false, "2d ago"},
+		{"old falls back to absolute", now.AddDate(0, -2, 0), false, "Apr 15 12:00"},
+		{"absolute", now.Add(-12 * time.Minute), true, "Jun 15 11:48"},
+		{"absolute previous year", now.AddDate(-1, 0, 0), true, "Jun 15 2024 12:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatTimestamp(tt.t, now, tt.absolute); got != tt.expected {
+				t.Errorf("formatTimestamp() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		45 * time.Second:              "45s",
+		12 * time.Minute:              "12m",
+		3*time.Hour + 12*time.Minute:  "3h 12m",
+		50*time.Hour + 30*time.Minute: "2d 2h",
+	}
+	for d, expected := range tests {
+		if got := formatDuration(d); got != expected {
+			t.Errorf("formatDuration(%v) = %q, want %q", d, got, expected)
+		}
+	}
+}
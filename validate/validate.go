@@ -0,0 +1,236 @@
+// Package validate checks the config and the state for problems, like repositories that don't exist anymore,
+// instances left behind by them and programs that aren't installed.
+package validate
+
+import (
+	"claude-squad/config"
+	"claude-squad/session"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Severities of problems.
+const (
+	// SeverityError is a problem that keeps claude-squad, or some of its instances, from working.
+	SeverityError = "error"
+	// SeverityWarning is a problem that may only matter later, e.g. when an instance is resumed.
+	SeverityWarning = "warning"
+)
+
+// Problem is one thing wrong with the config or the state.
+type Problem struct {
+	Severity string `json:"severity"`
+	// Subject is what the problem is about, e.g. "config" or "instance login".
+	Subject string `json:"subject"`
+	Message string `json:"message"`
+	// Fix is how to fix the problem, if there's a command or setting for it.
+	Fix string `json:"fix,omitempty"`
+}
+
+func (p Problem) String() string {
+	s := fmt.Sprintf("%-7s %s: %s", p.Severity, p.Subject, p.Message)
+	if p.Fix != "" {
+		s += ". " + p.Fix
+	}
+	return s
+}
+
+// Report is the result of validating the config and the state.
+type Report struct {
+	ConfigDir string `json:"config_dir"`
+	StateDir  string `json:"state_dir"`
+	// Repositories and Instances are the number of repositories and instances in the state.
+	Repositories int       `json:"repositories"`
+	Instances    int       `json:"instances"`
+	Problems     []Problem `json:"problems"`
+}
+
+// OK returns true if there are no errors, only warnings if any.
+func (r *Report) OK() bool {
+	for _, p := range r.Problems {
+		if p.Severity == SeverityError {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalJSON adds whether the report is OK, for scripts.
+func (r *Report) MarshalJSON() ([]byte, error) {
+	type report Report
+	return json.Marshal(struct {
+		OK bool `json:"ok"`
+		*report
+	}{r.OK(), (*report)(r)})
+}
+
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Config: %s\nState:  %s\n%d repositories, %d instances\n\n", r.ConfigDir, r.StateDir,
+		r.Repositories, r.Instances)
+	if len(r.Problems) == 0 {
+		b.WriteString("No problems found\n")
+		return b.String()
+	}
+	for _, p := range r.Problems {
+		b.WriteString(p.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func (r *Report) add(severity, subject, message, fix string) {
+	r.Problems = append(r.Problems, Problem{Severity: severity, Subject: subject, Message: message, Fix: fix})
+}
+
+// Run loads the config and the state and reports their problems. Nothing is changed: problems are only
+// reported, with how to fix them.
+func Run() *Report {
+	r := &Report{Problems: []Problem{}}
+	cfg := r.checkConfig()
+	state := r.checkState()
+	r.checkTools()
+
+	programs := map[string]string{"default_program": cfg.DefaultProgram}
+	if state != nil {
+		if program := state.GetPreferences().DefaultProgram; program != "" {
+			programs["default_program preference"] = program
+		}
+		r.checkRepositories(state)
+		for _, instance := range r.checkInstances(state) {
+			// Instances need their programs when they're resumed or restarted.
+			programs["instance "+instance.Title] = instance.Program
+		}
+	}
+	r.checkPrograms(programs)
+	return r
+}
+
+// checkConfig checks that the config directory can be read and the config parsed, and returns the config, or
+// the default config if it can't be loaded.
+func (r *Report) checkConfig() *config.Config {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		r.add(SeverityError, "config", err.Error(), "")
+		return config.DefaultConfig()
+	}
+	r.ConfigDir = dir
+	if _, err := os.ReadDir(dir); err != nil && !os.IsNotExist(err) {
+		r.add(SeverityError, "config", fmt.Sprintf("directory %s can't be read: %v", dir, err),
+			"Fix its permissions, or point CS_CONFIG_DIR somewhere else")
+		return config.DefaultConfig()
+	}
+	cfg, err := config.ReadConfig()
+	if err != nil {
+		r.add(SeverityError, "config", err.Error(),
+			fmt.Sprintf("Fix %s; until then the default config is used", filepath.Join(dir, config.ConfigFileName)))
+		return config.DefaultConfig()
+	}
+	return cfg
+}
+
+// checkState checks that the state can be loaded, and returns it, or nil if it can't.
+func (r *Report) checkState() *config.State {
+	dir, err := config.GetStateDir()
+	if err != nil {
+		r.add(SeverityError, "state", err.Error(), "")
+		return nil
+	}
+	r.StateDir = dir
+	state, err := config.ReadStateFile()
+	if err != nil {
+		r.add(SeverityError, "state", err.Error(),
+			"claude-squad sets it aside and starts over when it starts; `claude-squad restore-state` restores a backup")
+		return nil
+	}
+	return state
+}
+
+// checkTools checks that the commands claude-squad can't work without are installed.
+func (r *Report) checkTools() {
+	for _, tool := range []string{"tmux", "git"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			r.add(SeverityError, tool, "not installed, or not in PATH", "")
+		}
+	}
+}
+
+// checkRepositories checks that the repositories in the state are still git repositories.
+func (r *Report) checkRepositories(state *config.State) {
+	repos := state.GetRepositories()
+	r.Repositories = len(repos)
+	for _, repo := range repos {
+		if err := config.ValidateRepositoryPath(repo.Path); err != nil {
+			r.add(SeverityError, "repository "+repo.Path, err.Error(), "`claude-squad cleanup` removes it")
+		}
+	}
+}
+
+// checkInstances checks that the instances in the state can be decoded, belong to known repositories and
+// have their worktrees, and returns them.
+func (r *Report) checkInstances(state *config.State) []session.InstanceData {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(state.GetInstances(), &raw); err != nil {
+		r.add(SeverityError, "instances", fmt.Sprintf("can't be decoded: %v", err), "")
+		return nil
+	}
+	r.Instances = len(raw)
+	repos := make(map[string]bool)
+	for _, repo := range state.GetRepositories() {
+		repos[repo.Path] = true
+	}
+
+	var instances []session.InstanceData
+	for i, data := range raw {
+		var instance session.InstanceData
+		if err := json.Unmarshal(data, &instance); err != nil {
+			r.add(SeverityError, fmt.Sprintf("instance %d", i+1), fmt.Sprintf("can't be decoded: %v", err),
+				"It's left out when instances are loaded")
+			continue
+		}
+		instances = append(instances, instance)
+		subject := "instance " + instance.Title
+		if instance.RepositoryPath != "" && !repos[instance.RepositoryPath] {
+			r.add(SeverityError, subject, fmt.Sprintf("its repository %s isn't known anymore", instance.RepositoryPath),
+				"`claude-squad cleanup` removes it")
+			continue
+		}
+		// Paused instances' worktrees are removed, and recreated when they're resumed.
+		if instance.Status == session.Paused || instance.Worktree.WorktreePath == "" {
+			continue
+		}
+		if _, err := os.Stat(instance.Worktree.WorktreePath); os.IsNotExist(err) {
+			r.add(SeverityError, subject, fmt.Sprintf("its worktree %s is missing", instance.Worktree.WorktreePath), "")
+		}
+	}
+	return instances
+}
+
+// checkPrograms checks that the programs, keyed by what runs them, are installed. The default programs are
+// needed for new instances, so they're errors; the instances' programs are only needed to resume them.
+func (r *Report) checkPrograms(programs map[string]string) {
+	subjects := make([]string, 0, len(programs))
+	for subject := range programs {
+		subjects = append(subjects, subject)
+	}
+	sort.Strings(subjects)
+	for _, subject := range subjects {
+		fields := strings.Fields(programs[subject])
+		if len(fields) == 0 {
+			continue
+		}
+		if _, err := exec.LookPath(fields[0]); err == nil {
+			continue
+		}
+		severity := SeverityError
+		if strings.HasPrefix(subject, "instance ") {
+			severity = SeverityWarning
+		}
+		r.add(severity, subject, fmt.Sprintf("program %s isn't installed, or isn't in PATH", fields[0]), "")
+	}
+}
@@ -0,0 +1,73 @@
+package validate
+
+import (
+	"claude-squad/config"
+	"claude-squad/log"
+	"claude-squad/session"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	repo := filepath.Join(home, "src", "app")
+	require.NoError(t, os.MkdirAll(repo, 0755))
+	require.NoError(t, exec.Command("git", "init", "-q", repo).Run())
+	worktree := filepath.Join(home, "worktrees", "docs")
+	require.NoError(t, os.MkdirAll(worktree, 0755))
+
+	cfg := config.DefaultConfig()
+	cfg.DefaultProgram = "sh"
+	require.NoError(t, config.SaveConfig(cfg))
+	state := config.LoadState()
+	require.NoError(t, state.AddRepository(config.RepositoryData{Path: repo, Name: "app"}))
+	require.NoError(t, state.AddRepository(config.RepositoryData{Path: filepath.Join(home, "gone"), Name: "gone"}))
+	instances, err := json.Marshal([]session.InstanceData{
+		{Title: "docs", Status: session.Ready, Program: "sh", RepositoryPath: repo,
+			Worktree: session.GitWorktreeData{WorktreePath: worktree}},
+		{Title: "login", Status: session.Ready, Program: "sh", RepositoryPath: repo,
+			Worktree: session.GitWorktreeData{WorktreePath: filepath.Join(home, "worktrees", "login")}},
+		{Title: "api", Status: session.Paused, Program: "no-such-agent --fast", RepositoryPath: repo},
+		{Title: "old", Status: session.Paused, Program: "sh", RepositoryPath: filepath.Join(home, "forgotten")},
+	})
+	require.NoError(t, err)
+	require.NoError(t, state.SaveInstances(instances))
+
+	report := Run()
+	require.Equal(t, 2, report.Repositories)
+	require.Equal(t, 4, report.Instances)
+	var subjects []string
+	for _, p := range report.Problems {
+		// Whether tmux is installed depends on the machine.
+		if p.Subject != "tmux" {
+			subjects = append(subjects, p.Severity+" "+p.Subject)
+		}
+	}
+	require.Equal(t, []string{
+		"error repository " + filepath.Join(home, "gone"),
+		"error instance login",
+		"error instance old",
+		"warning instance api",
+	}, subjects)
+	require.False(t, report.OK())
+
+	data, err := json.Marshal(report)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"ok":false`)
+
+	configDir, err := config.GetConfigDir()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, config.ConfigFileName), []byte("{"), 0644))
+	report = Run()
+	require.Equal(t, "config", report.Problems[0].Subject)
+	require.Contains(t, report.Problems[0].Message, "failed to parse config")
+}